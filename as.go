@@ -0,0 +1,14 @@
+//go:build !tinygo && !faults_noreflect
+
+package faults
+
+import "errors"
+
+// as finds the first error in the tree of `err` that matches `T`.
+func as[T error](err error) (T, bool) {
+	var target T
+	if errors.As(err, &target) {
+		return target, true
+	}
+	return target, false
+}
@@ -0,0 +1,36 @@
+//go:build tinygo || faults_noreflect
+
+package faults
+
+// as finds the first error in the tree of `err` that matches `T`.
+//
+// This implementation is used by TinyGo builds, where the reflection used by
+// `errors.As` is only partially supported. It relies on type assertions
+// instead, and follows the same depth-first traversal as `errors.As`,
+// including `As(any) bool` methods.
+func as[T error](err error) (T, bool) {
+	var target T
+	for err != nil {
+		if e, ok := err.(T); ok {
+			return e, true
+		}
+		if x, ok := err.(interface{ As(interface{}) bool }); ok && x.As(&target) {
+			return target, true
+		}
+
+		switch x := err.(type) {
+		case interface{ Unwrap() error }:
+			err = x.Unwrap()
+		case interface{ Unwrap() []error }:
+			for _, err := range x.Unwrap() {
+				if e, ok := as[T](err); ok {
+					return e, true
+				}
+			}
+			return target, false
+		default:
+			return target, false
+		}
+	}
+	return target, false
+}
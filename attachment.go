@@ -0,0 +1,56 @@
+package faults
+
+// Attachment carries an application-defined detail about a failure, e.g. the
+// current state of the resource that caused a conflict, for the cases the
+// built-in violations and ErrorInfo don't cover.
+//
+// Attachment is a detail: it does not have a category of its own, and is
+// meant to be attached to a fault with Attach.
+type Attachment struct {
+	error
+
+	// Type identifies the kind of detail, e.g. "order". It allows the
+	// receiver to tell attachments apart, and to decode their value.
+	Type string
+	// Value is the detail. Only problem details documents (see faultshttp),
+	// and the JSON-RPC error objects derived from them, carry attachments:
+	// they marshal it as JSON, so it should be a plain data structure. When
+	// it can't be marshalled, it is replaced with a marker describing the
+	// failure rather than failing the whole fault. The other encodings, such
+	// as Encode, EncodeJSON and gRPC statuses, drop attachments.
+	Value any
+}
+
+func (e *Attachment) Error() string {
+	if e.error == nil {
+		return e.Type
+	}
+	return e.error.Error()
+}
+
+func (e *Attachment) Unwrap() error {
+	return e.error
+}
+
+// Attach attaches the detail `value` of type `typ` to `parent`, which keeps
+// its category.
+//
+// Example:
+//
+//	return faults.Attach(faults.Aborted(), "order", order)
+func Attach(parent error, typ string, value any) error {
+	return traced(EventWrap, &Attachment{error: parent, Type: typ, Value: value})
+}
+
+// AttachmentsOf returns the attachments found in the tree of `err`, from the
+// outermost to the innermost one (see Walk).
+func AttachmentsOf(err error) []*Attachment {
+	var attachments []*Attachment
+	Walk(err, func(err error) bool {
+		if e, ok := err.(*Attachment); ok {
+			attachments = append(attachments, e)
+		}
+		return true
+	})
+	return attachments
+}
@@ -0,0 +1,41 @@
+package faults_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/deixis/faults"
+)
+
+// TestAttach ensures attachments keep the category and the message of the
+// fault they are attached to.
+func TestAttach(t *testing.T) {
+	err := faults.Attach(faults.Aborted(), "order", map[string]int{"version": 3})
+	err = fmt.Errorf("update: %w", faults.Attach(err, "hint", "reload the order"))
+
+	if !faults.IsAborted(err) {
+		t.Errorf("expect code %s, but got %s", faults.CodeAborted, faults.CodeOf(err))
+	}
+	if msg := err.Error(); msg != "update: "+faults.Aborted().Error() {
+		t.Errorf("expect message to be unchanged, but got %q", msg)
+	}
+
+	attachments := faults.AttachmentsOf(err)
+	if len(attachments) != 2 {
+		t.Fatalf("expect 2 attachments, but got %d", len(attachments))
+	}
+	if attachments[0].Type != "hint" || attachments[1].Type != "order" {
+		t.Errorf("expect attachments from the outermost, but got %s then %s", attachments[0].Type, attachments[1].Type)
+	}
+	if v, ok := attachments[1].Value.(map[string]int); !ok || v["version"] != 3 {
+		t.Errorf("expect attached value, but got %v", attachments[1].Value)
+	}
+
+	if attachments := faults.AttachmentsOf(errors.New("boom")); attachments != nil {
+		t.Errorf("expect no attachment, but got %v", attachments)
+	}
+	if msg := faults.Attach(nil, "order", nil).Error(); msg != "order" {
+		t.Errorf("expect type as message without parent, but got %q", msg)
+	}
+}
@@ -0,0 +1,114 @@
+package faults
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// A JitterSource provides the randomness used to spread retries over time.
+// `*rand.Rand` of `math/rand/v2` implements it.
+//
+// Tests can inject a seeded source to make retry delays reproducible:
+//
+//	b := faults.DefaultBackoff
+//	b.Source = rand.New(rand.NewPCG(1, 2))
+type JitterSource interface {
+	// Float64 returns a pseudo-random number in [0.0,1.0).
+	Float64() float64
+}
+
+// DefaultBackoff is the backoff used by retry components when none is given.
+var DefaultBackoff = Backoff{
+	Base:       100 * time.Millisecond,
+	Max:        10 * time.Second,
+	Multiplier: 2,
+	Jitter:     0.2,
+}
+
+// Backoff computes exponential delays between retries.
+type Backoff struct {
+	// Base is the delay before the first retry. It defaults to the base of
+	// DefaultBackoff when it is zero.
+	Base time.Duration
+	// Max caps the delay between two retries, before jitter is applied. There
+	// is no cap when it is zero.
+	Max time.Duration
+	// Multiplier is the factor by which the delay grows after each attempt. It
+	// defaults to the multiplier of DefaultBackoff when it is zero.
+	Multiplier float64
+	// Jitter randomises each delay by a factor in [1-Jitter, 1+Jitter], so
+	// clients failing at the same time don't retry in lockstep. There is no
+	// jitter when it is zero.
+	Jitter float64
+	// Source provides the randomness used for jitter. When it is nil, the
+	// goroutine-safe generator of `math/rand/v2` is used, which is seeded by
+	// the runtime from the operating system's entropy source.
+	Source JitterSource
+}
+
+// Delay returns the delay before retrying after `attempt` failed attempts,
+// starting at 0 for the first retry.
+func (b Backoff) Delay(attempt int) time.Duration {
+	base, multiplier := b.Base, b.Multiplier
+	if base <= 0 {
+		base = DefaultBackoff.Base
+	}
+	if multiplier <= 0 {
+		multiplier = DefaultBackoff.Multiplier
+	}
+	if attempt < 0 {
+		attempt = 0
+	}
+
+	delay := float64(base) * math.Pow(multiplier, float64(attempt))
+	if b.Max > 0 && delay > float64(b.Max) {
+		delay = float64(b.Max)
+	}
+	if b.Jitter > 0 {
+		delay *= 1 + b.Jitter*(2*b.source().Float64()-1)
+	}
+	if delay >= math.MaxInt64 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(delay)
+}
+
+// Next reports whether the call that failed with `err` can be retried, and
+// after how long. The delay is the longest of the backoff delay and the delay
+// advised by the fault (see RetryAdvice).
+//
+// Failures that must be retried at a higher level, such as Aborted, are not
+// retryable by Next, since retrying the failing call alone would not help.
+func (b Backoff) Next(err error, attempt int) (time.Duration, bool) {
+	advice := RetryAdvice(err)
+	if !advice.Retryable || advice.Restart {
+		if tracing() {
+			tracedf(EventRetry, err, CodeOf(err), "give up")
+		}
+		return 0, false
+	}
+
+	delay := b.Delay(attempt)
+	if advice.Delay > delay {
+		delay = advice.Delay
+	}
+	if tracing() {
+		tracedf(EventRetry, err, CodeOf(err), "retry in "+delay.String())
+	}
+	return delay, true
+}
+
+func (b Backoff) source() JitterSource {
+	if b.Source != nil {
+		return b.Source
+	}
+	return runtimeSource{}
+}
+
+// runtimeSource uses the top-level generator of `math/rand/v2`.
+type runtimeSource struct{}
+
+func (runtimeSource) Float64() float64 {
+	return rand.Float64()
+}
@@ -0,0 +1,81 @@
+package faults_test
+
+import (
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+)
+
+// TestBackoffDelay ensures delays grow exponentially and are capped.
+func TestBackoffDelay(t *testing.T) {
+	b := faults.Backoff{Base: time.Second, Max: 5 * time.Second, Multiplier: 2}
+
+	expect := []time.Duration{
+		time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second,
+	}
+	for attempt, delay := range expect {
+		if got := b.Delay(attempt); got != delay {
+			t.Errorf("%d - expect delay %s, but got %s", attempt, delay, got)
+		}
+	}
+
+	if got := (faults.Backoff{}).Delay(0); got != faults.DefaultBackoff.Base {
+		t.Errorf("expect default base delay %s, but got %s", faults.DefaultBackoff.Base, got)
+	}
+}
+
+// TestBackoffJitter ensures an injected source makes jitter reproducible.
+func TestBackoffJitter(t *testing.T) {
+	newBackoff := func() faults.Backoff {
+		b := faults.Backoff{Base: time.Second, Jitter: 0.5}
+		b.Source = rand.New(rand.NewPCG(1, 2))
+		return b
+	}
+
+	a, b := newBackoff(), newBackoff()
+	for attempt := 0; attempt < 10; attempt++ {
+		delayA, delayB := a.Delay(attempt), b.Delay(attempt)
+		if delayA != delayB {
+			t.Errorf("%d - expect reproducible delays, but got %s and %s", attempt, delayA, delayB)
+		}
+
+		base := time.Second << attempt
+		if delayA < base/2 || delayA > base*3/2 {
+			t.Errorf("%d - expect delay within jitter bounds, but got %s", attempt, delayA)
+		}
+	}
+
+	// Default source
+	for i := 0; i < 10; i++ {
+		delay := faults.Backoff{Base: time.Second, Jitter: 0.5}.Delay(0)
+		if delay < time.Second/2 || delay > time.Second*3/2 {
+			t.Errorf("%d - expect delay within jitter bounds, but got %s", i, delay)
+		}
+	}
+}
+
+// TestBackoffNext ensures retry advice is honoured.
+func TestBackoffNext(t *testing.T) {
+	b := faults.Backoff{Base: time.Second}
+
+	table := []struct {
+		Error     error
+		Delay     time.Duration
+		Retryable bool
+	}{
+		{Error: faults.Unavailable(0), Delay: time.Second, Retryable: true},
+		{Error: faults.Unavailable(time.Minute), Delay: time.Minute, Retryable: true},
+		{Error: faults.Aborted(), Retryable: false},
+		{Error: faults.NotFound, Retryable: false},
+		{Error: faults.Permanent(faults.Unavailable(0)), Retryable: false},
+	}
+
+	for i, test := range table {
+		delay, ok := b.Next(test.Error, 0)
+		if ok != test.Retryable || delay != test.Delay {
+			t.Errorf("%d - expect (%s, %t), but got (%s, %t)", i, test.Delay, test.Retryable, delay, ok)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package faults_test
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// minimalForbidden lists the packages the core must not depend on when it is
+// built with the `faults_minimal` tag.
+var minimalForbidden = []string{
+	"database/sql",
+	"encoding/json",
+	"expvar",
+	"log/slog",
+	"net",
+	"net/http",
+	"runtime/debug",
+}
+
+// sideEffects lists the packages the core must not depend on in any build, as
+// importing them changes the program, e.g. expvar registers /debug/vars on
+// http.DefaultServeMux.
+var sideEffects = []string{
+	"expvar",
+	"net/http/pprof",
+}
+
+// TestDefaultBuild ensures the core doesn't import packages with side
+// effects.
+func TestDefaultBuild(t *testing.T) {
+	gobin := goTool(t)
+
+	out, err := exec.Command(gobin, "list", "-deps", ".").Output()
+	if err != nil {
+		t.Fatalf("expect go list to succeed, but got %s", err)
+	}
+
+	deps := map[string]bool{}
+	for _, dep := range strings.Fields(string(out)) {
+		deps[dep] = true
+	}
+	for _, pkg := range sideEffects {
+		if deps[pkg] {
+			t.Errorf("expect core not to depend on %s", pkg)
+		}
+	}
+}
+
+// TestNoDependencies ensures the core module doesn't require other modules,
+// so it adds nothing to the module graph of its users. Integrations with
+// third-party libraries are modules of their own.
+func TestNoDependencies(t *testing.T) {
+	gobin := goTool(t)
+
+	out, err := exec.Command(gobin, "list", "-m", "all").Output()
+	if err != nil {
+		t.Fatalf("expect go list to succeed, but got %s", err)
+	}
+	if mods := strings.Fields(string(out)); len(mods) != 1 || mods[0] != "github.com/deixis/faults" {
+		t.Errorf("expect no module dependency, but got %v", mods)
+	}
+}
+
+// TestMinimalBuild ensures the core compiles with the `faults_minimal` tag and
+// that optional features don't leak heavy dependencies into it.
+func TestMinimalBuild(t *testing.T) {
+	gobin := goTool(t)
+
+	out, err := exec.Command(gobin, "build", "-tags", "faults_minimal", ".").CombinedOutput()
+	if err != nil {
+		t.Fatalf("expect minimal build to succeed, but got %s: %s", err, out)
+	}
+
+	out, err = exec.Command(gobin, "list", "-tags", "faults_minimal", "-deps",
+		"-f", "{{if not .Standard}}-{{end}}{{.ImportPath}}", ".",
+	).Output()
+	if err != nil {
+		t.Fatalf("expect go list to succeed, but got %s", err)
+	}
+
+	deps := map[string]bool{}
+	for _, dep := range strings.Fields(string(out)) {
+		deps[dep] = true
+		if strings.HasPrefix(dep, "-") && dep != "-github.com/deixis/faults" {
+			t.Errorf("expect no third-party dependency, but got %s", dep[1:])
+		}
+	}
+	for _, pkg := range minimalForbidden {
+		if deps[pkg] {
+			t.Errorf("expect minimal build not to depend on %s", pkg)
+		}
+	}
+}
+
+// TestWasmBuild ensures the core and the JavaScript bridge compile for
+// WebAssembly.
+func TestWasmBuild(t *testing.T) {
+	gobin := goTool(t)
+
+	cmd := exec.Command(gobin, "build", ".", "./faultsjs")
+	cmd.Env = append(os.Environ(), "GOOS=js", "GOARCH=wasm")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("expect wasm build to succeed, but got %s: %s", err, out)
+	}
+}
+
+// TestNoReflectBuild ensures faults can be inspected without the reflection
+// used by the standard `errors` package, as it is the case with TinyGo.
+func TestNoReflectBuild(t *testing.T) {
+	gobin := goTool(t)
+
+	out, err := exec.Command(gobin, "test", "-tags", "faults_noreflect",
+		"-run", "^(TestIs|TestAs|TestAsChain|TestGenerics)$", ".",
+	).CombinedOutput()
+	if err != nil {
+		t.Fatalf("expect noreflect tests to succeed, but got %s: %s", err, out)
+	}
+}
+
+// goTool returns the path to the go tool, or skips the test when it is not
+// available.
+func goTool(t *testing.T) string {
+	if testing.Short() {
+		t.Skip("skipping build verification in short mode")
+	}
+
+	gobin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not found")
+	}
+	return gobin
+}
@@ -0,0 +1,239 @@
+package faults
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// A Builder builds a fault with several details step by step, so callers
+// don't need to know the shape of every fault type and detail:
+//
+//	return faults.Build(faults.CodeFailedPrecondition).
+//		Wrap(err).
+//		Precondition("TOS", "user:42", "Terms of service not accepted").
+//		Reason("TOS_NOT_ACCEPTED", map[string]string{"version": "2024-01"}).
+//		Err()
+//
+// The details a category can't carry are ignored, e.g. the violations of a
+// NotFound fault, or the retry delay of an Aborted fault, which RetryAdvice
+// reports by its category. A Builder must not be used concurrently.
+type Builder struct {
+	code       Code
+	parent     error
+	message    string
+	fields     []*FieldViolation
+	conditions []*PreconditionViolation
+	conflicts  []*ConflictViolation
+	quotas     []*QuotaViolation
+	retryDelay time.Duration
+	challenges []Challenge
+	links      []Link
+	reason     string
+	domain     string
+	metadata   map[string]string
+	permanent  bool
+}
+
+// Build returns a builder of a fault of the category `code`, which can be
+// any registered category (see Register), with the details set by `opts`.
+func Build(code Code, opts ...Option) *Builder {
+	return (&Builder{code: code}).With(opts...)
+}
+
+// New returns a fault of the category `code`, with the message `msg` and the
+// details set by `opts`, e.g. to build faults from an error table defined in
+// a configuration file rather than with the constructor of each category:
+//
+//	code, _ := faults.ParseCode(entry.Code) // e.g. "FAILED_PRECONDITION"
+//	return faults.New(code, entry.Message, faults.WithCause(err))
+//
+// The message of the fault is `msg`, whatever its category, or the message of
+// the category when `msg` is empty. Like Builder.Err, it returns nil for
+// CodeOK, and an error that CodeOf reports as Unknown for CodeUnknown and the
+// categories that are not registered.
+func New(code Code, msg string, opts ...Option) error {
+	if code == CodeOK {
+		return nil
+	}
+	kind, err := Build(code, opts...).build(1)
+	if msg != "" {
+		err = &described{error: err, message: msg}
+	}
+	return traced(kind, err)
+}
+
+// Wrap sets the error wrapped by the fault, which is its cause.
+func (b *Builder) Wrap(err error) *Builder {
+	b.parent = err
+	return b
+}
+
+// Message sets the message of the error wrapped by the fault. When the fault
+// also wraps an error (see Wrap), the message prefixes the message of that
+// error, like with fmt.Errorf("message: %w", err).
+func (b *Builder) Message(message string) *Builder {
+	b.message = message
+	return b
+}
+
+// Field adds a field violation to a BadRequest, with an optional code
+// telling why the field is bad (see FieldViolation.Code).
+func (b *Builder) Field(field, description string, code ...string) *Builder {
+	b.fields = append(b.fields, newField(field, description, code, 1))
+	return b
+}
+
+// Precondition adds a precondition violation to a PreconditionFailure.
+func (b *Builder) Precondition(typ, subject, description string) *Builder {
+	b.conditions = append(b.conditions, newPrecondition(typ, subject, description, 1))
+	return b
+}
+
+// Conflict adds a conflict violation to a ConflictFailure.
+func (b *Builder) Conflict(resource, description string) *Builder {
+	b.conflicts = append(b.conflicts, Conflict(resource, description))
+	return b
+}
+
+// Quota adds a quota violation to a QuotaFailure.
+func (b *Builder) Quota(subject, description string) *Builder {
+	b.quotas = append(b.quotas, Quota(subject, description))
+	return b
+}
+
+// Violations adds violations to the fault, whatever their type, e.g. field
+// violations to a BadRequest and conflict violations to a ConflictFailure.
+func (b *Builder) Violations(violations ...Violation) *Builder {
+	for _, v := range violations {
+		switch v := v.(type) {
+		case *FieldViolation:
+			b.fields = append(b.fields, v)
+		case *PreconditionViolation:
+			b.conditions = append(b.conditions, v)
+		case *ConflictViolation:
+			b.conflicts = append(b.conflicts, v)
+		case *QuotaViolation:
+			b.quotas = append(b.quotas, v)
+		}
+	}
+	return b
+}
+
+// Retryable sets the delay advised before retrying an AvailabilityFailure or
+// a QuotaFailure (see RetryInfo).
+func (b *Builder) Retryable(delay time.Duration) *Builder {
+	b.retryDelay = delay
+	return b
+}
+
+// Challenge adds a challenge the caller may answer to authenticate to an
+// AuthenticationFailure.
+func (b *Builder) Challenge(c Challenge) *Builder {
+	b.challenges = append(b.challenges, c)
+	return b
+}
+
+// Reason attaches a reason, which may be qualified by its domain, and its
+// metadata, to the fault (see WithErrorInfo).
+func (b *Builder) Reason(reason string, metadata map[string]string) *Builder {
+	b.domain, b.reason = splitReason(reason)
+	b.metadata = metadata
+	return b
+}
+
+// Help attaches links to documentation to the fault (see Help).
+func (b *Builder) Help(links ...Link) *Builder {
+	b.links = append(b.links, links...)
+	return b
+}
+
+// Permanent marks the fault as not retryable (see Permanent).
+func (b *Builder) Permanent() *Builder {
+	b.permanent = true
+	return b
+}
+
+// Err returns the fault. It returns nil for CodeOK. For CodeUnknown, and
+// categories that are not registered, it returns the error wrapped by the
+// fault, or a plain error naming the category, which CodeOf reports as
+// Unknown.
+func (b *Builder) Err() error {
+	if b.code == CodeOK {
+		return nil
+	}
+	kind, err := b.build(1)
+	return traced(kind, err)
+}
+
+// built returns the fault of the category `code` wrapping `parent`, with the
+// details set by `opts`, for the constructors accepting options, e.g.
+// WithNotFound. The fault is reported as built by the caller of the
+// constructor.
+func built(code Code, parent error, opts []Option) error {
+	kind, err := Build(code).Wrap(parent).With(opts...).build(2)
+	return tracedAt(kind, err, 1)
+}
+
+// build returns the fault, and the kind of event it is for tracing. The error
+// it wraps is recorded as wrapped by the caller `skip` frames above the caller
+// of build (see SetRecordHops).
+func (b *Builder) build(skip int) (EventKind, error) {
+	kind := EventCreate
+	parent := b.parent
+	switch {
+	case parent != nil && b.message != "":
+		kind = EventWrap
+		parent = fmt.Errorf("%s: %w", b.message, parent)
+	case parent != nil:
+		kind = EventWrap
+	case b.message != "":
+		parent = errors.New(b.message)
+	}
+	if kind == EventWrap {
+		parent = hopped(parent, skip+2)
+	}
+
+	var err error
+	switch b.code {
+	case CodeBad:
+		err = &BadRequest{parent, b.fields}
+	case CodeNotFound:
+		err = &MissingFailure{parent}
+	case CodePermissionDenied:
+		err = &PermissionFailure{parent}
+	case CodeUnauthenticated:
+		err = &AuthenticationFailure{parent, b.challenges}
+	case CodeFailedPrecondition:
+		err = &PreconditionFailure{parent, b.conditions}
+	case CodeAborted:
+		err = &ConflictFailure{parent, b.conflicts}
+	case CodeResourceExhausted:
+		err = &QuotaFailure{error: parent, Violations: b.quotas, RetryInfo: RetryInfo{RetryDelay: b.retryDelay}}
+	case CodeUnavailable:
+		err = &AvailabilityFailure{parent, RetryInfo{RetryDelay: b.retryDelay}}
+	case CodeUnimplemented:
+		err = &UnimplementedFailure{parent}
+	case CodeInternal:
+		err = &InternalFailure{parent}
+	case CodeDeadlineExceeded:
+		err = &DeadlineFailure{parent}
+	default:
+		if d, ok := Describe(b.code); ok && d.New != nil {
+			err = d.New(parent)
+		} else if err = parent; err == nil {
+			err = errors.New("faults: " + b.code.String())
+		}
+	}
+
+	if len(b.links) > 0 {
+		err = &Help{error: err, Links: b.links}
+	}
+	if b.reason != "" {
+		err = &ErrorInfo{error: err, Reason: b.reason, Domain: b.domain, Metadata: b.metadata}
+	}
+	if b.permanent {
+		err = &permanent{error: err}
+	}
+	return kind, err
+}
@@ -0,0 +1,173 @@
+package faults_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+)
+
+// TestBuild ensures builders produce faults with their details.
+func TestBuild(t *testing.T) {
+	cause := errors.New("version mismatch")
+
+	table := []struct {
+		Builder *faults.Builder
+		Expect  error
+	}{
+		{
+			Builder: faults.Build(faults.CodeBad).
+				Field("email", "Field required", faults.ViolationRequired).
+				Field("name", "Too long"),
+			Expect: faults.Bad(
+				&faults.FieldViolation{Field: "email", Code: faults.ViolationRequired, Description: "Field required"},
+				&faults.FieldViolation{Field: "name", Description: "Too long"},
+			),
+		},
+		{
+			Builder: faults.Build(faults.CodeFailedPrecondition).Precondition("TOS", "user:42", "not accepted"),
+			Expect:  faults.FailedPrecondition(&faults.PreconditionViolation{Type: "TOS", Subject: "user:42", Description: "not accepted"}),
+		},
+		{
+			Builder: faults.Build(faults.CodeAborted).Wrap(cause).Conflict("invoice:123", "stale"),
+			Expect:  faults.WithAborted(cause, &faults.ConflictViolation{Resource: "invoice:123", Description: "stale"}),
+		},
+		{
+			Builder: faults.Build(faults.CodeUnavailable).Retryable(2 * time.Second),
+			Expect:  faults.Unavailable(2 * time.Second),
+		},
+		{
+			Builder: faults.Build(faults.CodeUnauthenticated).Challenge(faults.Challenge{Scheme: "Bearer", Realm: "api"}),
+			Expect:  faults.WithUnauthenticated(nil, faults.Challenge{Scheme: "Bearer", Realm: "api"}),
+		},
+		{
+			Builder: faults.Build(faults.CodeNotFound).Message("no such user"),
+			Expect:  faults.WithNotFound(errors.New("no such user")),
+		},
+		{
+			Builder: faults.Build(faults.CodeInternal).Message("loading profile").Wrap(cause),
+			Expect:  faults.WithInternal(errors.New("loading profile: version mismatch")),
+		},
+		{
+			Builder: faults.Build(faults.CodeUnimplemented),
+			Expect:  faults.Unimplemented,
+		},
+	}
+
+	for i, test := range table {
+		err := test.Builder.Err()
+		if reflect.TypeOf(err) != reflect.TypeOf(test.Expect) {
+			t.Errorf("%d - expect type %T, but got %T", i, test.Expect, err)
+		}
+		if err.Error() != test.Expect.Error() {
+			t.Errorf("%d - expect message %q, but got %q", i, test.Expect, err)
+		}
+		if !reflect.DeepEqual(faults.Flatten(err), faults.Flatten(test.Expect)) {
+			t.Errorf("%d - expect details %+v, but got %+v", i, faults.Flatten(test.Expect), faults.Flatten(err))
+		}
+	}
+}
+
+// TestBuildDetails ensures the details carried by every category are
+// attached to built faults.
+func TestBuildDetails(t *testing.T) {
+	cause := errors.New("quota store")
+	err := faults.Build(faults.CodeResourceExhausted).
+		Wrap(cause).
+		Quota("project:42", "daily limit").
+		Retryable(time.Minute).
+		Reason("DAILY_LIMIT", map[string]string{"limit": "1000"}).
+		Err()
+
+	if !errors.Is(err, cause) {
+		t.Errorf("expect cause to be wrapped, but got %v", err)
+	}
+	if e, ok := faults.AsResourceExhausted(err); !ok || len(e.Violations) != 1 || e.Violations[0].Subject != "project:42" {
+		t.Errorf("expect quota violation, but got %v", err)
+	}
+	if advice := faults.RetryAdvice(err); !advice.Retryable || advice.Delay != time.Minute {
+		t.Errorf("expect retry in 1m, but got %+v", advice)
+	}
+	if e, ok := faults.AsErrorInfo(err); !ok || e.Reason != "DAILY_LIMIT" || e.Metadata["limit"] != "1000" {
+		t.Errorf("expect reason DAILY_LIMIT, but got %v", e)
+	}
+
+	err = faults.Build(faults.CodeUnavailable).Permanent().Err()
+	if !faults.IsUnavailable(err) || faults.IsRetryable(err) {
+		t.Errorf("expect permanent unavailable fault, but got %s %v", faults.CodeOf(err), err)
+	}
+
+	// Details the category can't carry are ignored
+	err = faults.Build(faults.CodeNotFound).Field("email", "Field required").Retryable(time.Second).Err()
+	if _, ok := err.(*faults.MissingFailure); !ok || faults.RetryAdvice(err).Delay != 0 {
+		t.Errorf("expect plain not found fault, but got %T %v", err, err)
+	}
+}
+
+// TestBuildCode ensures builders handle every code.
+func TestBuildCode(t *testing.T) {
+	if err := faults.Build(faults.CodeOK).Message("ignored").Err(); err != nil {
+		t.Errorf("expect nil for OK, but got %v", err)
+	}
+
+	err := faults.Build(faults.CodeUnknown).Err()
+	if err == nil || faults.CodeOf(err) != faults.CodeUnknown {
+		t.Errorf("expect unknown error, but got %v", err)
+	}
+	err = faults.Build(faults.CodeUnknown).Message("boom").Err()
+	if err == nil || err.Error() != "boom" {
+		t.Errorf("expect message boom, but got %v", err)
+	}
+
+	for code := faults.CodeUnknown + 1; code <= faults.CodeDeadlineExceeded; code++ {
+		if c := faults.CodeOf(faults.Build(code).Err()); c != code {
+			t.Errorf("expect code %s, but got %s", code, c)
+		}
+	}
+}
+
+// TestNew ensures New builds faults of any category with the given message.
+func TestNew(t *testing.T) {
+	cause := errors.New("no such row")
+
+	table := []struct {
+		Code    faults.Code
+		Message string
+		Options []faults.Option
+		Expect  string
+	}{
+		{Code: faults.CodeNotFound, Message: "user 42 not found", Expect: "user 42 not found"},
+		{Code: faults.CodeNotFound, Expect: "resource not found"},
+		{Code: faults.CodeBad, Message: "invalid form", Expect: "invalid form"},
+		{Code: faults.CodeUnknown, Message: "boom", Expect: "boom"},
+		{
+			Code:    faults.CodeUnavailable,
+			Message: "maintenance",
+			Options: []faults.Option{faults.WithRetry(time.Minute), faults.WithCause(cause)},
+			Expect:  "maintenance",
+		},
+	}
+
+	for i, test := range table {
+		err := faults.New(test.Code, test.Message, test.Options...)
+		if code := faults.CodeOf(err); code != test.Code {
+			t.Errorf("%d - expect code %s, but got %s", i, test.Code, code)
+		}
+		if err.Error() != test.Expect {
+			t.Errorf("%d - expect message %q, but got %q", i, test.Expect, err)
+		}
+	}
+
+	err := faults.New(faults.CodeUnavailable, "maintenance", faults.WithRetry(time.Minute), faults.WithCause(cause))
+	if !errors.Is(err, cause) {
+		t.Errorf("expect cause to be wrapped, but got %v", err)
+	}
+	if delay := faults.RetryAdvice(err).Delay; delay != time.Minute {
+		t.Errorf("expect retry delay of 1m, but got %s", delay)
+	}
+	if err := faults.New(faults.CodeOK, "ignored"); err != nil {
+		t.Errorf("expect nil for OK, but got %v", err)
+	}
+}
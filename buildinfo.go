@@ -0,0 +1,33 @@
+//go:build !faults_minimal
+
+package faults
+
+import (
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+// buildDetail returns the build information of the binary, e.g.
+// "github.com/acme/orders v1.4.0 go1.22.5 vcs.revision=4f1c2e9".
+var buildDetail = sync.OnceValue(func() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	var s []string
+	if info.Main.Path != "" {
+		s = append(s, info.Main.Path)
+	}
+	if info.Main.Version != "" {
+		s = append(s, info.Main.Version)
+	}
+	s = append(s, info.GoVersion)
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision", "vcs.modified":
+			s = append(s, setting.Key+"="+setting.Value)
+		}
+	}
+	return strings.Join(s, " ")
+})
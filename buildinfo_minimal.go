@@ -0,0 +1,9 @@
+//go:build faults_minimal
+
+package faults
+
+// buildDetail returns no build information, since reading it depends on
+// runtime/debug.
+func buildDetail() string {
+	return ""
+}
@@ -0,0 +1,24 @@
+//go:build !faults_minimal
+
+package faults_test
+
+import (
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/deixis/faults"
+)
+
+// TestDebugBuildInfo ensures faults created in debug mode describe the build
+// of the binary.
+func TestDebugBuildInfo(t *testing.T) {
+	faults.SetDebug(true)
+	defer faults.SetDebug(false)
+
+	info, ok := faults.AsDebugInfo(faults.WithNotFound(errors.New("no such user")))
+	if !ok || !strings.Contains(info.Detail, runtime.Version()) {
+		t.Errorf("expect build info with %s, but got %v", runtime.Version(), info)
+	}
+}
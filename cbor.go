@@ -0,0 +1,673 @@
+package faults
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+// CBOR major types (RFC 8949).
+const (
+	cborUint   = 0
+	cborNegint = 1
+	cborBytes  = 2
+	cborText   = 3
+	cborArray  = 4
+	cborMap    = 5
+	cborTag    = 6
+	cborSimple = 7
+
+	cborFalse      = 20
+	cborTrue       = 21
+	cborIndefinite = 31
+	cborBreak      = 0xff
+
+	// cborMaxDepth bounds the nesting of the items skipped by the decoder.
+	cborMaxDepth = 16
+)
+
+// errMalformedCBOR is returned by DecodeCBOR when its input is not a valid
+// encoding.
+var errMalformedCBOR = errors.New("faults: malformed CBOR encoding")
+
+// EncodeCBOR returns the CBOR representation (RFC 8949) of `err`, for
+// constrained transports where JSON is too heavy. DecodeCBOR converts it back
+// to a fault.
+//
+// The representation is a map following the schema of the JSON
+// representation, with the same keys and the same values (see EncodeJSON),
+// so both can be converted into one another by generic tools. It doesn't
+// depend on any CBOR library, but the fault types implement the
+// `MarshalCBOR` and `UnmarshalCBOR` methods used by them, e.g. by
+// github.com/fxamacker/cbor.
+//
+// It returns nil when `err` is nil.
+func EncodeCBOR(err error) ([]byte, error) {
+	if err == nil {
+		return nil, nil
+	}
+	return marshalCBOR(err), nil
+}
+
+// DecodeCBOR converts the CBOR representation produced by EncodeCBOR back to
+// a fault. Like with DecodeJSON, representations of later versions are
+// decoded too, unknown keys are skipped, and categories that are not
+// registered in this process are decoded as plain errors carrying the
+// original message.
+//
+// It returns nil when `data` is empty or null, and an Unknown error when
+// `data` is malformed.
+func DecodeCBOR(data []byte) error {
+	if len(data) == 0 || (len(data) == 1 && data[0] == 0xf6) {
+		return nil
+	}
+	e, err := decodeCBOREnvelope(data)
+	if err != nil {
+		return err
+	}
+	return e.fault()
+}
+
+func marshalCBOR(err error) []byte {
+	return limited(err, cborEnvelope)
+}
+
+// cborEnvelope returns the CBOR representation of `e`.
+func cborEnvelope(e *envelope) []byte {
+	n := 2 // version and code
+	for _, present := range []bool{
+		e.Message != "", e.Cause != "", len(e.Violations) > 0, e.RetryDelay > 0,
+		e.Reason != "", e.Domain != "", len(e.Metadata) > 0, e.Permanent, len(e.Challenges) > 0,
+		e.Truncated,
+	} {
+		if present {
+			n++
+		}
+	}
+
+	b := cborHead(nil, cborMap, uint64(n))
+	b = cborString(b, "version")
+	b = cborHead(b, cborUint, uint64(e.Version))
+	b = cborString(b, "code")
+	b = cborString(b, e.Code)
+	if e.Message != "" {
+		b = cborString(b, "message")
+		b = cborString(b, e.Message)
+	}
+	if e.Cause != "" {
+		b = cborString(b, "cause")
+		b = cborString(b, e.Cause)
+	}
+	if len(e.Violations) > 0 {
+		b = cborString(b, "violations")
+		b = cborHead(b, cborArray, uint64(len(e.Violations)))
+		for _, v := range e.Violations {
+			fields := [...][2]string{
+				{"field", v.Field},
+				{"code", v.Code},
+				{"type", v.Type},
+				{"subject", v.Subject},
+				{"resource", v.Resource},
+				{"description", v.Description},
+			}
+			n := 0
+			for _, f := range fields {
+				if f[1] != "" {
+					n++
+				}
+			}
+			if v.Template != "" {
+				n++
+			}
+			if len(v.Params) > 0 {
+				n++
+			}
+			b = cborHead(b, cborMap, uint64(n))
+			for _, f := range fields {
+				if f[1] != "" {
+					b = cborString(b, f[0])
+					b = cborString(b, f[1])
+				}
+			}
+			if v.Template != "" {
+				b = cborString(b, "template")
+				b = cborString(b, v.Template)
+			}
+			if len(v.Params) > 0 {
+				b = cborString(b, "params")
+				b = cborHead(b, cborMap, uint64(len(v.Params)))
+				for _, k := range v.paramKeys() {
+					b = cborString(b, k)
+					b = cborString(b, v.Params[k])
+				}
+			}
+		}
+	}
+	if e.RetryDelay > 0 {
+		b = cborString(b, "retryDelay")
+		b = cborHead(b, cborUint, uint64(e.RetryDelay))
+	}
+	if e.Reason != "" {
+		b = cborString(b, "reason")
+		b = cborString(b, e.Reason)
+	}
+	if e.Domain != "" {
+		b = cborString(b, "domain")
+		b = cborString(b, e.Domain)
+	}
+	if len(e.Metadata) > 0 {
+		b = cborString(b, "metadata")
+		b = cborHead(b, cborMap, uint64(len(e.Metadata)))
+		for _, k := range e.metadataKeys() {
+			b = cborString(b, k)
+			b = cborString(b, e.Metadata[k])
+		}
+	}
+	if e.Permanent {
+		b = cborString(b, "permanent")
+		b = append(b, cborSimple<<5|cborTrue)
+	}
+	if len(e.Challenges) > 0 {
+		b = cborString(b, "challenges")
+		b = cborHead(b, cborArray, uint64(len(e.Challenges)))
+		for _, c := range e.Challenges {
+			b = cborString(b, c)
+		}
+	}
+	if e.Truncated {
+		b = cborString(b, "truncated")
+		b = append(b, cborSimple<<5|cborTrue)
+	}
+	return b
+}
+
+// cborHead appends the head of an item of the major type `major`, with the
+// argument `arg`, in its shortest form.
+func cborHead(b []byte, major byte, arg uint64) []byte {
+	major <<= 5
+	switch {
+	case arg < 24:
+		return append(b, major|byte(arg))
+	case arg <= math.MaxUint8:
+		return append(b, major|24, byte(arg))
+	case arg <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, major|25), uint16(arg))
+	case arg <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, major|26), uint32(arg))
+	}
+	return binary.BigEndian.AppendUint64(append(b, major|27), arg)
+}
+
+func cborString(b []byte, s string) []byte {
+	return append(cborHead(b, cborText, uint64(len(s))), s...)
+}
+
+// decodeCBOREnvelope decodes the CBOR representation of a fault.
+func decodeCBOREnvelope(data []byte) (*envelope, error) {
+	r := &cborReader{data: data}
+	e := &envelope{}
+	err := r.each(cborMap, func() error {
+		key, err := r.text()
+		if err != nil {
+			return err
+		}
+		switch key {
+		case "version":
+			v, err := r.uint()
+			e.Version = int(v)
+			return err
+		case "code":
+			e.Code, err = r.text()
+		case "message":
+			e.Message, err = r.text()
+		case "cause":
+			e.Cause, err = r.text()
+		case "violations":
+			err = r.each(cborArray, func() error {
+				var v violation
+				err := r.each(cborMap, func() error {
+					key, err := r.text()
+					if err != nil {
+						return err
+					}
+					var field *string
+					switch key {
+					case "field":
+						field = &v.Field
+					case "code":
+						field = &v.Code
+					case "type":
+						field = &v.Type
+					case "subject":
+						field = &v.Subject
+					case "resource":
+						field = &v.Resource
+					case "description":
+						field = &v.Description
+					case "template":
+						field = &v.Template
+					case "params":
+						return r.each(cborMap, func() error {
+							k, err := r.text()
+							if err != nil {
+								return err
+							}
+							p, err := r.text()
+							if v.Params == nil {
+								v.Params = map[string]string{}
+							}
+							v.Params[k] = p
+							return err
+						})
+					default:
+						return r.skip(0)
+					}
+					*field, err = r.text()
+					return err
+				})
+				e.Violations = append(e.Violations, v)
+				return err
+			})
+		case "retryDelay":
+			var v uint64
+			v, err = r.uint()
+			if v > math.MaxInt64 {
+				return errMalformedCBOR
+			}
+			e.RetryDelay = int64(v)
+		case "reason":
+			e.Reason, err = r.text()
+		case "domain":
+			e.Domain, err = r.text()
+		case "metadata":
+			err = r.each(cborMap, func() error {
+				k, err := r.text()
+				if err != nil {
+					return err
+				}
+				v, err := r.text()
+				if e.Metadata == nil {
+					e.Metadata = map[string]string{}
+				}
+				e.Metadata[k] = v
+				return err
+			})
+		case "permanent":
+			e.Permanent, err = r.bool()
+		case "truncated":
+			e.Truncated, err = r.bool()
+		case "challenges":
+			err = r.each(cborArray, func() error {
+				c, err := r.text()
+				e.Challenges = append(e.Challenges, c)
+				return err
+			})
+		default:
+			err = r.skip(0)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(r.data) > 0 {
+		return nil, errMalformedCBOR
+	}
+	return e, nil
+}
+
+// MarshalCBOR returns the CBOR representation of `e` (see EncodeCBOR).
+func (e *AvailabilityFailure) MarshalCBOR() ([]byte, error) {
+	return marshalCBOR(e), nil
+}
+
+// UnmarshalCBOR decodes the CBOR representation of `e` (see EncodeCBOR).
+func (e *AvailabilityFailure) UnmarshalCBOR(data []byte) error {
+	env, err := unmarshalCBOR(data, CodeUnavailable, e)
+	if err != nil {
+		return err
+	}
+	*e = AvailabilityFailure{error: env.parent(), RetryInfo: env.retryInfo()}
+	return nil
+}
+
+// MarshalCBOR returns the CBOR representation of `e` (see EncodeCBOR).
+func (e *QuotaFailure) MarshalCBOR() ([]byte, error) {
+	return marshalCBOR(e), nil
+}
+
+// UnmarshalCBOR decodes the CBOR representation of `e` (see EncodeCBOR).
+func (e *QuotaFailure) UnmarshalCBOR(data []byte) error {
+	env, err := unmarshalCBOR(data, CodeResourceExhausted, e)
+	if err != nil {
+		return err
+	}
+	*e = QuotaFailure{error: env.parent(), Violations: env.quotaViolations(), RetryInfo: env.retryInfo()}
+	return nil
+}
+
+// MarshalCBOR returns the CBOR representation of `e` (see EncodeCBOR).
+func (e *PreconditionFailure) MarshalCBOR() ([]byte, error) {
+	return marshalCBOR(e), nil
+}
+
+// UnmarshalCBOR decodes the CBOR representation of `e` (see EncodeCBOR).
+func (e *PreconditionFailure) UnmarshalCBOR(data []byte) error {
+	env, err := unmarshalCBOR(data, CodeFailedPrecondition, e)
+	if err != nil {
+		return err
+	}
+	*e = PreconditionFailure{error: env.parent(), Violations: env.preconditionViolations()}
+	return nil
+}
+
+// MarshalCBOR returns the CBOR representation of `e` (see EncodeCBOR).
+func (e *BadRequest) MarshalCBOR() ([]byte, error) {
+	return marshalCBOR(e), nil
+}
+
+// UnmarshalCBOR decodes the CBOR representation of `e` (see EncodeCBOR).
+func (e *BadRequest) UnmarshalCBOR(data []byte) error {
+	env, err := unmarshalCBOR(data, CodeBad, e)
+	if err != nil {
+		return err
+	}
+	*e = BadRequest{error: env.parent(), Violations: env.fieldViolations()}
+	return nil
+}
+
+// MarshalCBOR returns the CBOR representation of `e` (see EncodeCBOR).
+func (e *ConflictFailure) MarshalCBOR() ([]byte, error) {
+	return marshalCBOR(e), nil
+}
+
+// UnmarshalCBOR decodes the CBOR representation of `e` (see EncodeCBOR).
+func (e *ConflictFailure) UnmarshalCBOR(data []byte) error {
+	env, err := unmarshalCBOR(data, CodeAborted, e)
+	if err != nil {
+		return err
+	}
+	*e = ConflictFailure{error: env.parent(), Violations: env.conflictViolations()}
+	return nil
+}
+
+// MarshalCBOR returns the CBOR representation of `e` (see EncodeCBOR).
+func (e *MissingFailure) MarshalCBOR() ([]byte, error) {
+	return marshalCBOR(e), nil
+}
+
+// UnmarshalCBOR decodes the CBOR representation of `e` (see EncodeCBOR).
+func (e *MissingFailure) UnmarshalCBOR(data []byte) error {
+	env, err := unmarshalCBOR(data, CodeNotFound, e)
+	if err != nil {
+		return err
+	}
+	*e = MissingFailure{error: env.parent()}
+	return nil
+}
+
+// MarshalCBOR returns the CBOR representation of `e` (see EncodeCBOR).
+func (e *PermissionFailure) MarshalCBOR() ([]byte, error) {
+	return marshalCBOR(e), nil
+}
+
+// UnmarshalCBOR decodes the CBOR representation of `e` (see EncodeCBOR).
+func (e *PermissionFailure) UnmarshalCBOR(data []byte) error {
+	env, err := unmarshalCBOR(data, CodePermissionDenied, e)
+	if err != nil {
+		return err
+	}
+	*e = PermissionFailure{error: env.parent()}
+	return nil
+}
+
+// MarshalCBOR returns the CBOR representation of `e` (see EncodeCBOR).
+func (e *AuthenticationFailure) MarshalCBOR() ([]byte, error) {
+	return marshalCBOR(e), nil
+}
+
+// UnmarshalCBOR decodes the CBOR representation of `e` (see EncodeCBOR).
+func (e *AuthenticationFailure) UnmarshalCBOR(data []byte) error {
+	env, err := unmarshalCBOR(data, CodeUnauthenticated, e)
+	if err != nil {
+		return err
+	}
+	*e = AuthenticationFailure{error: env.parent(), Challenges: env.challenges()}
+	return nil
+}
+
+// MarshalCBOR returns the CBOR representation of `e` (see EncodeCBOR).
+func (e *UnimplementedFailure) MarshalCBOR() ([]byte, error) {
+	return marshalCBOR(e), nil
+}
+
+// UnmarshalCBOR decodes the CBOR representation of `e` (see EncodeCBOR).
+func (e *UnimplementedFailure) UnmarshalCBOR(data []byte) error {
+	env, err := unmarshalCBOR(data, CodeUnimplemented, e)
+	if err != nil {
+		return err
+	}
+	*e = UnimplementedFailure{error: env.parent()}
+	return nil
+}
+
+// MarshalCBOR returns the CBOR representation of `e` (see EncodeCBOR).
+func (e *InternalFailure) MarshalCBOR() ([]byte, error) {
+	return marshalCBOR(e), nil
+}
+
+// UnmarshalCBOR decodes the CBOR representation of `e` (see EncodeCBOR).
+func (e *InternalFailure) UnmarshalCBOR(data []byte) error {
+	env, err := unmarshalCBOR(data, CodeInternal, e)
+	if err != nil {
+		return err
+	}
+	*e = InternalFailure{error: env.parent()}
+	return nil
+}
+
+// MarshalCBOR returns the CBOR representation of `e` (see EncodeCBOR).
+func (e *DeadlineFailure) MarshalCBOR() ([]byte, error) {
+	return marshalCBOR(e), nil
+}
+
+// UnmarshalCBOR decodes the CBOR representation of `e` (see EncodeCBOR).
+func (e *DeadlineFailure) UnmarshalCBOR(data []byte) error {
+	env, err := unmarshalCBOR(data, CodeDeadlineExceeded, e)
+	if err != nil {
+		return err
+	}
+	*e = DeadlineFailure{error: env.parent()}
+	return nil
+}
+
+// unmarshalCBOR decodes the CBOR representation of a fault of the category
+// `code`, which is about to be decoded into `target`.
+func unmarshalCBOR(data []byte, code Code, target error) (*envelope, error) {
+	e, err := decodeCBOREnvelope(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.check(code, target); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// cborReader reads the items of a CBOR encoding.
+type cborReader struct {
+	data []byte
+}
+
+// head reads the head of the next item, skipping its tags. `indefinite`
+// reports whether the item has an indefinite length.
+func (r *cborReader) head() (major byte, arg uint64, indefinite bool, err error) {
+	for {
+		if len(r.data) == 0 {
+			return 0, 0, false, errMalformedCBOR
+		}
+		arg = 0
+		b := r.data[0]
+		r.data = r.data[1:]
+		major, info := b>>5, b&0x1f
+
+		switch {
+		case info < 24:
+			arg = uint64(info)
+		case info <= 27:
+			size := 1 << (info - 24)
+			if len(r.data) < size {
+				return 0, 0, false, errMalformedCBOR
+			}
+			for _, c := range r.data[:size] {
+				arg = arg<<8 | uint64(c)
+			}
+			r.data = r.data[size:]
+		case info == cborIndefinite && major >= cborBytes && major <= cborMap:
+			indefinite = true
+		default:
+			return 0, 0, false, errMalformedCBOR
+		}
+		if major != cborTag {
+			return major, arg, indefinite, nil
+		}
+	}
+}
+
+// each calls `fn` for each element of the array, or each entry of the map,
+// that comes next. `fn` must read the whole element or entry.
+func (r *cborReader) each(major byte, fn func() error) error {
+	m, n, indefinite, err := r.head()
+	if err != nil {
+		return err
+	}
+	if m != major {
+		return errMalformedCBOR
+	}
+	if indefinite {
+		for {
+			if len(r.data) == 0 {
+				return errMalformedCBOR
+			}
+			if r.data[0] == cborBreak {
+				r.data = r.data[1:]
+				return nil
+			}
+			if err := fn(); err != nil {
+				return err
+			}
+		}
+	}
+	if n > uint64(len(r.data)) {
+		// Every element takes at least a byte
+		return errMalformedCBOR
+	}
+	for i := uint64(0); i < n; i++ {
+		if err := fn(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// text reads a text string.
+func (r *cborReader) text() (string, error) {
+	return r.str(cborText)
+}
+
+// str reads a string of the major type `major`, a byte or a text string.
+func (r *cborReader) str(major byte) (string, error) {
+	m, n, indefinite, err := r.head()
+	if err != nil {
+		return "", err
+	}
+	if m != major {
+		return "", errMalformedCBOR
+	}
+	if !indefinite {
+		if n > uint64(len(r.data)) {
+			return "", errMalformedCBOR
+		}
+		s := string(r.data[:n])
+		r.data = r.data[n:]
+		return s, nil
+	}
+
+	// Strings of indefinite length are a sequence of chunks of definite
+	// length
+	var s []byte
+	for {
+		if len(r.data) == 0 {
+			return "", errMalformedCBOR
+		}
+		if r.data[0] == cborBreak {
+			r.data = r.data[1:]
+			return string(s), nil
+		}
+		m, n, indefinite, err := r.head()
+		if err != nil {
+			return "", err
+		}
+		if m != major || indefinite || n > uint64(len(r.data)) {
+			return "", errMalformedCBOR
+		}
+		s = append(s, r.data[:n]...)
+		r.data = r.data[n:]
+	}
+}
+
+// uint reads an unsigned integer.
+func (r *cborReader) uint() (uint64, error) {
+	major, n, _, err := r.head()
+	if err != nil {
+		return 0, err
+	}
+	if major != cborUint {
+		return 0, errMalformedCBOR
+	}
+	return n, nil
+}
+
+// bool reads a boolean.
+func (r *cborReader) bool() (bool, error) {
+	major, n, _, err := r.head()
+	if err != nil {
+		return false, err
+	}
+	if major != cborSimple || (n != cborFalse && n != cborTrue) {
+		return false, errMalformedCBOR
+	}
+	return n == cborTrue, nil
+}
+
+// skip skips the next item, which is nested `depth` levels deep in an item
+// that is being skipped.
+func (r *cborReader) skip(depth int) error {
+	if depth > cborMaxDepth {
+		return errMalformedCBOR
+	}
+	peek := *r
+	major, _, _, err := peek.head()
+	if err != nil {
+		return err
+	}
+	switch major {
+	case cborBytes, cborText:
+		_, err := r.str(major)
+		return err
+	case cborArray:
+		return r.each(cborArray, func() error {
+			return r.skip(depth + 1)
+		})
+	case cborMap:
+		return r.each(cborMap, func() error {
+			if err := r.skip(depth + 1); err != nil {
+				return err
+			}
+			return r.skip(depth + 1)
+		})
+	}
+	// Integers and simple values, including floats, are made of their head
+	_, _, _, err = r.head()
+	return err
+}
@@ -0,0 +1,152 @@
+package faults_test
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+)
+
+// TestEncodeCBOR ensures faults survive their CBOR representation.
+func TestEncodeCBOR(t *testing.T) {
+	table := []error{
+		faults.Bad(&faults.FieldViolation{Field: "email", Code: faults.ViolationRequired, Description: "is required"}),
+		faults.WithNotFound(errors.New("no such user")),
+		faults.WithUnauthenticated(errors.New("token expired"), faults.Challenge{Scheme: "Bearer", Realm: "api"}),
+		faults.FailedPrecondition(&faults.PreconditionViolation{Type: "TOS", Subject: "user:42", Description: "not accepted"}),
+		faults.Aborted(&faults.ConflictViolation{Resource: "order:42", Description: "version mismatch"}),
+		faults.ResourceExhausted(&faults.QuotaViolation{Subject: "project:42", Description: "daily limit"}),
+		faults.Unavailable(90 * time.Hour),
+		faults.DeadlineExceeded,
+		faults.WithInternal(errors.New("pq: connection refused")),
+		errors.New("boom"),
+		faults.Permanent(faults.Unavailable(time.Second)),
+		faults.WithErrorInfo(faults.NotFound, "USER_NOT_FOUND", map[string]string{"id": "42", "tenant": "acme"}),
+		fmt.Errorf("get user: %w", faults.NotFound),
+	}
+
+	for i, fault := range table {
+		data, err := faults.EncodeCBOR(fault)
+		if err != nil {
+			t.Fatalf("%d - expect fault to be encoded, but got %s", i, err)
+		}
+
+		err = faults.DecodeCBOR(data)
+		if err == nil {
+			t.Fatalf("%d - expect fault to be decoded", i)
+		}
+		if err.Error() != fault.Error() {
+			t.Errorf("%d - expect message %q, but got %q", i, fault, err)
+		}
+		if code := faults.CodeOf(err); code != faults.CodeOf(fault) {
+			t.Errorf("%d - expect code %s, but got %s", i, faults.CodeOf(fault), code)
+		}
+		if expect, delay := faults.RetryAdvice(fault).Delay, faults.RetryAdvice(err).Delay; delay != expect {
+			t.Errorf("%d - expect retry delay %s, but got %s", i, expect, delay)
+		}
+		if expect, permanent := faults.IsPermanent(fault), faults.IsPermanent(err); permanent != expect {
+			t.Errorf("%d - expect permanent %t, but got %t", i, expect, permanent)
+		}
+		if expect, reason := faults.ReasonOf(fault), faults.ReasonOf(err); reason != expect {
+			t.Errorf("%d - expect reason %q, but got %q", i, expect, reason)
+		}
+		if expect, challenges := faults.ChallengesOf(fault), faults.ChallengesOf(err); !reflect.DeepEqual(expect, challenges) {
+			t.Errorf("%d - expect challenges %v, but got %v", i, expect, challenges)
+		}
+	}
+
+	if data, _ := faults.EncodeCBOR(nil); data != nil {
+		t.Errorf("expect no data, but got %x", data)
+	}
+	if err := faults.DecodeCBOR([]byte{0xf6}); err != nil {
+		t.Errorf("expect nil, but got %v", err)
+	}
+}
+
+// TestEncodeCBORFormat ensures the CBOR representation follows the schema of
+// the JSON representation.
+func TestEncodeCBORFormat(t *testing.T) {
+	data, _ := faults.EncodeCBOR(faults.Unavailable(1500 * time.Millisecond))
+
+	// {"version": 4, "code": "UNAVAILABLE",
+	// "message": "service temporarily unavailable, retry in 1.5s", "retryDelay": 1500}
+	expect := "a4" +
+		"6776657273696f6e" + "04" +
+		"64636f6465" + "6b554e415641494c41424c45" +
+		"676d657373616765" + "782e" + hex.EncodeToString([]byte("service temporarily unavailable, retry in 1.5s")) +
+		"6a726574727944656c6179" + "1905dc"
+	if got := hex.EncodeToString(data); got != expect {
+		t.Errorf("expect %s, but got %s", expect, got)
+	}
+}
+
+// TestDecodeCBOR ensures encodings produced by other CBOR libraries are
+// decoded, and malformed ones are rejected.
+func TestDecodeCBOR(t *testing.T) {
+	// {_ "code": (_ "NOT_", "FOUND"), "extra": [1.5, -1, h'00', {"a": true}],
+	// "message": 0("hi")}, with indefinite lengths and a tag
+	data, _ := hex.DecodeString("bf" +
+		"64636f6465" + "7f644e4f545f65464f554e44ff" +
+		"656578747261" + "84f93e00204100a16161f5" +
+		"676d657373616765" + "c0626869" +
+		"ff")
+	err := faults.DecodeCBOR(data)
+	if !faults.IsNotFound(err) || err.Error() != "hi" {
+		t.Errorf("expect not found, but got %v", err)
+	}
+
+	table := []string{
+		"a1",                                    // truncated map
+		"a16464636f6465",                        // truncated key
+		"a164636f646501",                        // code is not a text
+		"a1656578747261" + "9f",                 // unterminated array
+		"a1656578747261" + "1c",                 // reserved additional information
+		"a16776657273696f6e" + "20",             // negative version
+		"a1" + "64636f6465" + "634e4f50" + "00", // trailing data
+		"bb00000000ffffffff",                    // oversized map
+	}
+	for i, s := range table {
+		data, _ := hex.DecodeString(s)
+		err := faults.DecodeCBOR(data)
+		if err == nil || faults.CodeOf(err) != faults.CodeUnknown {
+			t.Errorf("%d - expect %s to be rejected, but got %v", i, s, err)
+		}
+	}
+
+	// Deeply nested items are rejected
+	nested := "a1656578747261"
+	for i := 0; i < 64; i++ {
+		nested += "81"
+	}
+	data, _ = hex.DecodeString(nested + "00")
+	if err := faults.DecodeCBOR(data); err == nil || faults.CodeOf(err) != faults.CodeUnknown {
+		t.Errorf("expect deeply nested items to be rejected, but got %v", err)
+	}
+}
+
+// TestMarshalCBOR ensures fault types can be marshalled and unmarshalled on
+// their own.
+func TestMarshalCBOR(t *testing.T) {
+	in, _ := faults.AsBad(faults.Bad(&faults.FieldViolation{Field: "email", Description: "is required"}))
+	data, err := in.MarshalCBOR()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out faults.BadRequest
+	if err := out.UnmarshalCBOR(data); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(in.Violations, out.Violations) || in.Error() != out.Error() {
+		t.Errorf("expect %v, but got %v", in, &out)
+	}
+
+	var missing faults.MissingFailure
+	if err := missing.UnmarshalCBOR(data); err == nil {
+		t.Error("expect representation of another category to be rejected")
+	}
+}
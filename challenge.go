@@ -0,0 +1,202 @@
+package faults
+
+import (
+	"sort"
+	"strings"
+)
+
+// A Challenge describes how a caller may authenticate (RFC 9110, section
+// 11.1), e.g. the Bearer challenge of OAuth 2.0 (RFC 6750):
+//
+//	faults.WithUnauthenticated(err, faults.Challenge{
+//		Scheme: "Bearer",
+//		Realm:  "api",
+//		Params: map[string]string{
+//			"error":             "invalid_token",
+//			"error_description": "The access token expired",
+//		},
+//	})
+type Challenge struct {
+	// Scheme is the authentication scheme, e.g. "Bearer" or "Basic".
+	Scheme string
+	// Realm is the protection space, if any.
+	Realm string
+	// Params holds the other parameters of the challenge, e.g. "error" and
+	// "scope" for OAuth 2.0. Names are lower case.
+	Params map[string]string
+}
+
+// String returns the challenge as written in a WWW-Authenticate header, e.g.
+// `Bearer realm="api", error="invalid_token"`. The realm comes first, and
+// the other parameters are sorted by name.
+func (c Challenge) String() string {
+	var b strings.Builder
+	b.WriteString(c.Scheme)
+
+	sep := " "
+	param := func(name, value string) {
+		b.WriteString(sep)
+		b.WriteString(name)
+		b.WriteString(`="`)
+		for i := 0; i < len(value); i++ {
+			if value[i] == '"' || value[i] == '\\' {
+				b.WriteByte('\\')
+			}
+			b.WriteByte(value[i])
+		}
+		b.WriteByte('"')
+		sep = ", "
+	}
+	if c.Realm != "" {
+		param("realm", c.Realm)
+	}
+	names := make([]string, 0, len(c.Params))
+	for name := range c.Params {
+		if name != "realm" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		param(name, c.Params[name])
+	}
+	return b.String()
+}
+
+// ChallengesOf returns the challenges carried by the AuthenticationFailure
+// of `err`, if any.
+func ChallengesOf(err error) []Challenge {
+	if e, ok := AsUnauthenticated(err); ok {
+		return e.Challenges
+	}
+	return nil
+}
+
+// ParseChallenges parses the value of a WWW-Authenticate header, which lists
+// one or more challenges. Parsing stops at the first malformed challenge.
+// Challenges carrying a token68 instead of parameters are returned without
+// parameters.
+func ParseChallenges(header string) []Challenge {
+	p := &challengeParser{s: header}
+
+	var challenges []Challenge
+	for {
+		p.skip(" \t,")
+		if p.done() {
+			return challenges
+		}
+		c := Challenge{Scheme: p.token()}
+		if c.Scheme == "" {
+			return challenges
+		}
+
+		for {
+			p.skip(" \t")
+			start := p.i
+			name := strings.ToLower(p.token())
+			p.skip(" \t")
+			if name == "" || !p.consume('=') {
+				// Not a parameter, but the scheme of the next challenge
+				p.i = start
+				break
+			}
+			p.skip(" \t")
+
+			var value string
+			switch {
+			case p.peek() == '"':
+				value = p.quoted()
+			case p.peek() == '=' || p.peek() == ',' || p.done():
+				// token68
+				p.skip("=")
+				name = ""
+			default:
+				value = p.token()
+			}
+			switch name {
+			case "":
+			case "realm":
+				c.Realm = value
+			default:
+				if c.Params == nil {
+					c.Params = map[string]string{}
+				}
+				c.Params[name] = value
+			}
+
+			p.skip(" \t")
+			if !p.consume(',') {
+				break
+			}
+		}
+		challenges = append(challenges, c)
+	}
+}
+
+// challengeParser scans the value of a WWW-Authenticate header.
+type challengeParser struct {
+	s string
+	i int
+}
+
+func (p *challengeParser) done() bool {
+	return p.i >= len(p.s)
+}
+
+func (p *challengeParser) peek() byte {
+	if p.done() {
+		return 0
+	}
+	return p.s[p.i]
+}
+
+func (p *challengeParser) consume(c byte) bool {
+	if p.peek() != c {
+		return false
+	}
+	p.i++
+	return true
+}
+
+func (p *challengeParser) skip(chars string) {
+	for !p.done() && strings.IndexByte(chars, p.s[p.i]) >= 0 {
+		p.i++
+	}
+}
+
+// token reads a token, as defined by RFC 9110.
+func (p *challengeParser) token() string {
+	start := p.i
+	for !p.done() && isTokenChar(p.s[p.i]) {
+		p.i++
+	}
+	return p.s[start:p.i]
+}
+
+// quoted reads a quoted string, and returns it unescaped.
+func (p *challengeParser) quoted() string {
+	p.i++ // opening quote
+	var b strings.Builder
+	for !p.done() {
+		c := p.s[p.i]
+		p.i++
+		switch {
+		case c == '"':
+			return b.String()
+		case c == '\\' && !p.done():
+			b.WriteByte(p.s[p.i])
+			p.i++
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+func isTokenChar(c byte) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		return true
+	}
+	return strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0
+}
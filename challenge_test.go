@@ -0,0 +1,89 @@
+package faults_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/deixis/faults"
+)
+
+// TestChallenge ensures challenges are written as WWW-Authenticate values.
+func TestChallenge(t *testing.T) {
+	table := []struct {
+		Challenge faults.Challenge
+		Expect    string
+	}{
+		{Challenge: faults.Challenge{Scheme: "Bearer"}, Expect: `Bearer`},
+		{Challenge: faults.Challenge{Scheme: "Basic", Realm: "api"}, Expect: `Basic realm="api"`},
+		{
+			Challenge: faults.Challenge{
+				Scheme: "Bearer",
+				Realm:  "api",
+				Params: map[string]string{
+					"scope":             "orders:write",
+					"error":             "insufficient_scope",
+					"error_description": `Missing "orders:write" \ scope`,
+				},
+			},
+			Expect: `Bearer realm="api", error="insufficient_scope", ` +
+				`error_description="Missing \"orders:write\" \\ scope", scope="orders:write"`,
+		},
+	}
+
+	for i, test := range table {
+		if s := test.Challenge.String(); s != test.Expect {
+			t.Errorf("%d - expect %s, but got %s", i, test.Expect, s)
+		}
+		parsed := faults.ParseChallenges(test.Challenge.String())
+		if len(parsed) != 1 || !reflect.DeepEqual(parsed[0], test.Challenge) {
+			t.Errorf("%d - expect challenge to round trip, but got %+v", i, parsed)
+		}
+	}
+}
+
+// TestParseChallenges ensures challenges written by other servers are parsed.
+func TestParseChallenges(t *testing.T) {
+	table := []struct {
+		Header string
+		Expect []faults.Challenge
+	}{
+		{Header: "", Expect: nil},
+		{Header: "Bearer", Expect: []faults.Challenge{{Scheme: "Bearer"}}},
+		{
+			Header: `Basic realm="a", Bearer realm=b,error=invalid_token , scope="x y"`,
+			Expect: []faults.Challenge{
+				{Scheme: "Basic", Realm: "a"},
+				{Scheme: "Bearer", Realm: "b", Params: map[string]string{"error": "invalid_token", "scope": "x y"}},
+			},
+		},
+		{
+			Header: `Negotiate abc123==, Basic REALM="x"`,
+			Expect: []faults.Challenge{{Scheme: "Negotiate"}, {Scheme: "Basic", Realm: "x"}},
+		},
+		{Header: `Bearer, Basic`, Expect: []faults.Challenge{{Scheme: "Bearer"}, {Scheme: "Basic"}}},
+		{Header: `Bearer realm="unterminated`, Expect: []faults.Challenge{{Scheme: "Bearer", Realm: "unterminated"}}},
+		{Header: `Bearer realm="a", "garbage"`, Expect: []faults.Challenge{{Scheme: "Bearer", Realm: "a"}}},
+	}
+
+	for i, test := range table {
+		if got := faults.ParseChallenges(test.Header); !reflect.DeepEqual(got, test.Expect) {
+			t.Errorf("%d - expect %+v, but got %+v", i, test.Expect, got)
+		}
+	}
+}
+
+// TestChallengesOf ensures challenges are found in the tree of an error.
+func TestChallengesOf(t *testing.T) {
+	challenge := faults.Challenge{Scheme: "Bearer", Realm: "api"}
+	err := faults.WithReason(faults.WithUnauthenticated(nil, challenge), "TOKEN_EXPIRED")
+
+	if !faults.IsUnauthenticated(err) {
+		t.Errorf("expect code %s, but got %s", faults.CodeUnauthenticated, faults.CodeOf(err))
+	}
+	if got := faults.ChallengesOf(err); len(got) != 1 || got[0].Scheme != "Bearer" {
+		t.Errorf("expect Bearer challenge, but got %+v", got)
+	}
+	if got := faults.ChallengesOf(faults.Unauthenticated); got != nil {
+		t.Errorf("expect no challenge, but got %+v", got)
+	}
+}
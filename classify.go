@@ -0,0 +1,90 @@
+package faults
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// A Classifier resolves the category of an error.
+//
+// Classifiers allow applications to plug domain-specific classification into
+// CodeOf, and therefore into every transport mapping. For example, an error
+// returned by a vendor SDK can be classified without being wrapped with a
+// fault first.
+type Classifier interface {
+	// Classify returns the code of `err`, or false when it does not recognise
+	// `err`.
+	Classify(err error) (Code, bool)
+}
+
+// The ClassifierFunc type is an adapter to allow the use of ordinary
+// functions as classifiers.
+type ClassifierFunc func(err error) (Code, bool)
+
+// Classify calls f(err).
+func (f ClassifierFunc) Classify(err error) (Code, bool) {
+	return f(err)
+}
+
+// Classifiers is a chain of classifiers. The first classifier to recognise an
+// error wins.
+type Classifiers []Classifier
+
+// Classify returns the code of the first classifier to recognise `err`.
+func (c Classifiers) Classify(err error) (Code, bool) {
+	for _, classifier := range c {
+		if code, ok := classifier.Classify(err); ok {
+			return code, true
+		}
+	}
+	return CodeUnknown, false
+}
+
+// FaultClassifier classifies errors with the faults found in their tree. When
+// several faults are found, the code is resolved with the precedence set with
+// SetPrecedence.
+//
+// It always comes first in the default chain, so an explicit classification
+// can't be overridden by a registered classifier.
+var FaultClassifier Classifier = ClassifierFunc(classifyFaults)
+
+var (
+	// classifiersMu serialises changes to the chain, which is swapped
+	// atomically so CodeOf never waits for a lock
+	classifiersMu sync.Mutex
+	classifiers   atomic.Pointer[Classifiers]
+)
+
+func init() {
+	classifiers.Store(&Classifiers{FaultClassifier})
+}
+
+// RegisterClassifier appends `c` to the default chain of classifiers used by
+// CodeOf. Classifiers are consulted in registration order, after
+// FaultClassifier.
+//
+// It is meant to be called when the program starts, e.g. from an `init`
+// function.
+func RegisterClassifier(c Classifier) {
+	classifiersMu.Lock()
+	cur := *classifiers.Load()
+	// The chain is copied, since it may still be used by readers
+	chain := append(cur[:len(cur):len(cur)], c)
+	classifiers.Store(&chain)
+	classifiersMu.Unlock()
+}
+
+// SetClassifiers replaces all classifiers registered with RegisterClassifier
+// by `c`. Calling it without arguments removes them all.
+func SetClassifiers(c ...Classifier) {
+	chain := append(Classifiers{FaultClassifier}, c...)
+	classifiersMu.Lock()
+	classifiers.Store(&chain)
+	classifiersMu.Unlock()
+}
+
+// DefaultClassifier returns the chain used by CodeOf: FaultClassifier first,
+// followed by the classifiers registered with RegisterClassifier.
+func DefaultClassifier() Classifier {
+	return *classifiers.Load()
+}
@@ -0,0 +1,83 @@
+package faults_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/deixis/faults"
+)
+
+// vendorError mimics an error returned by a third-party SDK.
+type vendorError struct {
+	Status int
+}
+
+func (e *vendorError) Error() string {
+	return fmt.Sprintf("vendor error %d", e.Status)
+}
+
+func classifyVendor(err error) (faults.Code, bool) {
+	var e *vendorError
+	if !errors.As(err, &e) {
+		return faults.CodeUnknown, false
+	}
+	switch e.Status {
+	case 404:
+		return faults.CodeNotFound, true
+	case 503:
+		return faults.CodeUnavailable, true
+	}
+	return faults.CodeUnknown, false
+}
+
+// TestRegisterClassifier ensures registered classifiers are consulted by
+// CodeOf, after the faults found in the chain.
+func TestRegisterClassifier(t *testing.T) {
+	defer faults.SetClassifiers()
+
+	faults.RegisterClassifier(faults.ClassifierFunc(classifyVendor))
+
+	table := []struct {
+		Error error
+		Code  faults.Code
+	}{
+		{Error: nil, Code: faults.CodeOK},
+		{Error: &vendorError{Status: 404}, Code: faults.CodeNotFound},
+		{Error: fmt.Errorf("call: %w", &vendorError{Status: 503}), Code: faults.CodeUnavailable},
+		{Error: &vendorError{Status: 418}, Code: faults.CodeUnknown},
+		{Error: faults.WithInternal(&vendorError{Status: 404}), Code: faults.CodeInternal},
+		{Error: errors.New("boom"), Code: faults.CodeUnknown},
+	}
+
+	for i, test := range table {
+		if code := faults.CodeOf(test.Error); code != test.Code {
+			t.Errorf("%d - expect code %s, but got %s", i, test.Code, code)
+		}
+	}
+
+	faults.SetClassifiers()
+	if code := faults.CodeOf(&vendorError{Status: 404}); code != faults.CodeUnknown {
+		t.Errorf("expect code %s after reset, but got %s", faults.CodeUnknown, code)
+	}
+}
+
+// TestClassifiers ensures the first classifier to recognise an error wins.
+func TestClassifiers(t *testing.T) {
+	never := faults.ClassifierFunc(func(error) (faults.Code, bool) {
+		return faults.CodeUnknown, false
+	})
+	always := func(code faults.Code) faults.Classifier {
+		return faults.ClassifierFunc(func(error) (faults.Code, bool) {
+			return code, true
+		})
+	}
+
+	chain := faults.Classifiers{never, always(faults.CodeAborted), always(faults.CodeInternal)}
+	if code, ok := chain.Classify(errors.New("boom")); !ok || code != faults.CodeAborted {
+		t.Errorf("expect code %s, but got %s (%t)", faults.CodeAborted, code, ok)
+	}
+	if _, ok := (faults.Classifiers{never}).Classify(errors.New("boom")); ok {
+		t.Error("expect error not to be classified")
+	}
+}
@@ -0,0 +1,15 @@
+module github.com/deixis/faults/cmd/faultsgen
+
+go 1.22.0
+
+replace github.com/deixis/faults => ../../
+
+replace github.com/deixis/faults/faultscatalog => ../../faultscatalog
+
+require github.com/deixis/faults/faultscatalog v0.0.0-00010101000000-000000000000
+
+require (
+	github.com/deixis/faults v0.0.0-00010101000000-000000000000 // indirect
+	github.com/kr/text v0.2.0 // indirect
+	gopkg.in/yaml.v2 v2.4.0 // indirect
+)
@@ -0,0 +1,56 @@
+// Command faultsgen generates the constructors, reasons and documentation of
+// the errors of a service from their catalog (see package faultscatalog). It
+// is meant to be run by go generate:
+//
+//	//go:generate go run github.com/deixis/faults/cmd/faultsgen -in catalog.yaml -out catalog_gen.go -doc CATALOG.md
+//
+// The package of the generated code is the package of the catalog, or the
+// package running go generate.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/deixis/faults/faultscatalog"
+)
+
+func main() {
+	in := flag.String("in", "catalog.yaml", "path of the catalog")
+	out := flag.String("out", "catalog_gen.go", "path of the generated Go file")
+	doc := flag.String("doc", "", "path of the generated Markdown documentation, if any")
+	pkg := flag.String("pkg", os.Getenv("GOPACKAGE"), "package of the generated code, when the catalog doesn't name it")
+	flag.Parse()
+
+	if err := run(*in, *out, *doc, *pkg); err != nil {
+		fmt.Fprintln(os.Stderr, "faultsgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(in, out, doc, pkg string) error {
+	data, err := os.ReadFile(in)
+	if err != nil {
+		return err
+	}
+	c, err := faultscatalog.Parse(data)
+	if err != nil {
+		return err
+	}
+	src, err := c.Generate(pkg, filepath.Base(in))
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(out, src, 0o644); err != nil {
+		return err
+	}
+	if doc == "" {
+		return nil
+	}
+	if c.Package == "" {
+		c.Package = pkg
+	}
+	return os.WriteFile(doc, c.Markdown(), 0o644)
+}
@@ -0,0 +1,297 @@
+package faults
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Code identifies the category of a failure. Every fault type belongs to
+// exactly one category, which is what transports use to pick a status code
+// when a fault crosses a boundary.
+//
+// The name of a code, as returned by String, is stable. It is part of the
+// wire formats of this package, and can be referenced by retry policies,
+// alert rules or configuration files. ParseCode converts it back to a code.
+type Code int
+
+const (
+	// CodeOK is returned by CodeOf for a nil error.
+	CodeOK Code = iota
+	// CodeUnknown is returned by CodeOf for errors that do not carry a fault.
+	CodeUnknown
+	// CodeBad is the code of a BadRequest.
+	CodeBad
+	// CodeNotFound is the code of a MissingFailure.
+	CodeNotFound
+	// CodePermissionDenied is the code of a PermissionFailure.
+	CodePermissionDenied
+	// CodeUnauthenticated is the code of an AuthenticationFailure.
+	CodeUnauthenticated
+	// CodeFailedPrecondition is the code of a PreconditionFailure.
+	CodeFailedPrecondition
+	// CodeAborted is the code of a ConflictFailure.
+	CodeAborted
+	// CodeResourceExhausted is the code of a QuotaFailure.
+	CodeResourceExhausted
+	// CodeUnavailable is the code of an AvailabilityFailure.
+	CodeUnavailable
+	// CodeUnimplemented is the code of an UnimplementedFailure.
+	CodeUnimplemented
+	// CodeInternal is the code of an InternalFailure.
+	CodeInternal
+	// CodeDeadlineExceeded is the code of a DeadlineFailure.
+	CodeDeadlineExceeded
+)
+
+// String returns the name of the code, e.g. "NOT_FOUND". Codes that are not
+// registered are named "UNKNOWN".
+func (c Code) String() string {
+	if d, ok := Describe(c); ok {
+		return d.Name
+	}
+	return "UNKNOWN"
+}
+
+// ParseCode returns the code named `name`, which is matched regardless of its
+// case. It returns an error when no such code is registered, which allows
+// configuration files to be validated when the program starts.
+func ParseCode(name string) (Code, error) {
+	code, ok := registry.Load().names[strings.ToUpper(name)]
+
+	if !ok {
+		return CodeUnknown, fmt.Errorf("faults: unknown code %q", name)
+	}
+	return code, nil
+}
+
+// MarshalText encodes the code as its name.
+func (c Code) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// UnmarshalText decodes a code from its name (see ParseCode).
+func (c *Code) UnmarshalText(text []byte) error {
+	code, err := ParseCode(string(text))
+	if err != nil {
+		return err
+	}
+	*c = code
+	return nil
+}
+
+// A Fault is an error that belongs to a failure category.
+//
+// All failure types of this package implement Fault. Custom error types can
+// implement it too in order to be classified by CodeOf.
+type Fault interface {
+	error
+
+	// Code returns the category of the failure.
+	Code() Code
+}
+
+// CodeOf returns the code of the fault carried by `err`.
+//
+// It returns CodeOK when `err` is nil and CodeUnknown when `err` can't be
+// classified. When the chain carries more than one fault, e.g. a `BadRequest`
+// re-wrapped by an `AvailabilityFailure` across layers, or several faults
+// joined with `errors.Join`, the winning code is decided by the precedence set
+// with SetPrecedence.
+//
+// Errors that do not carry any fault are passed to the classifiers registered
+// with RegisterClassifier, in order.
+func CodeOf(err error) Code {
+	if err == nil {
+		return CodeOK
+	}
+
+	code, ok := DefaultClassifier().Classify(err)
+	if !ok {
+		code = CodeUnknown
+	}
+	tracedf(EventClassify, err, code, "")
+	return code
+}
+
+// classifyFaults classifies `err` with the faults found in its tree.
+func classifyFaults(err error) (Code, bool) {
+	codes := collectCodes(err, nil)
+	switch len(codes) {
+	case 0:
+		return CodeUnknown, false
+	case 1:
+		return codes[0], true
+	default:
+		return currentPrecedence()(codes), true
+	}
+}
+
+// collectCodes appends the code of every fault found in the tree of `err` to
+// `codes`, in the order they are visited by Walk, i.e. from the outermost to
+// the innermost fault.
+func collectCodes(err error, codes []Code) []Code {
+	walk(err, func(err error) bool {
+		if f, ok := err.(Fault); ok {
+			codes = append(codes, f.Code())
+		}
+		return true
+	})
+	return codes
+}
+
+// A Precedence picks the code of an error chain that carries more than one
+// fault.
+//
+// `codes` lists the code of every fault found in the chain, starting with the
+// outermost one. When `errors.Join` is involved, the branches are visited in
+// order, depth-first. A Precedence is never called with an empty list.
+type Precedence func(codes []Code) Code
+
+// Outermost is the default precedence. The last layer to categorise an error
+// wins, which mirrors the behaviour of `errors.As`.
+func Outermost(codes []Code) Code {
+	return codes[0]
+}
+
+// Innermost gives precedence to the fault closest to the root cause.
+func Innermost(codes []Code) Code {
+	return codes[len(codes)-1]
+}
+
+// Ranked returns a precedence that picks the code appearing first in `order`.
+// Codes that are not listed rank below the listed ones, in which case the
+// outermost one wins.
+//
+// Example:
+//
+//	// Server-side failures always win over client-side ones
+//	faults.SetPrecedence(faults.Ranked(
+//		faults.CodeUnavailable,
+//		faults.CodeResourceExhausted,
+//	))
+func Ranked(order ...Code) Precedence {
+	rank := make(map[Code]int, len(order))
+	for i, c := range order {
+		if _, ok := rank[c]; !ok {
+			rank[c] = i
+		}
+	}
+
+	return func(codes []Code) Code {
+		best, bestRank := codes[0], len(order)
+		for _, c := range codes {
+			if r, ok := rank[c]; ok && r < bestRank {
+				best, bestRank = c, r
+			}
+		}
+		return best
+	}
+}
+
+// SetPrecedence sets the precedence used by CodeOf and all transport mappings
+// to resolve chains that carry more than one fault. A nil value restores the
+// default precedence, Outermost.
+//
+// It is meant to be called once, when the program starts.
+func SetPrecedence(p Precedence) {
+	configure(func(c *Config) { c.Precedence = p })
+}
+
+func currentPrecedence() Precedence {
+	return settings().Precedence
+}
+
+// IsClientFault reports whether `err` was caused by the caller, e.g. a bad
+// request, a missing resource or a permission issue. Such failures are not
+// expected to be fixed by the service.
+//
+// It returns false for a nil error.
+func IsClientFault(err error) bool {
+	code := CodeOf(err)
+	return code != CodeOK && !isServer(code)
+}
+
+// IsServerFault reports whether `err` was caused by the service itself, e.g.
+// an unavailable dependency or an internal failure. Errors that do not carry
+// a fault are considered server faults, since nothing is known about them.
+//
+// It returns false for a nil error.
+func IsServerFault(err error) bool {
+	return isServer(CodeOf(err))
+}
+
+// isServer reports whether `code` is caused by the service. Unregistered codes
+// are considered server-side, like errors that are not faults.
+func isServer(code Code) bool {
+	if code == CodeOK {
+		return false
+	}
+	d, ok := Describe(code)
+	return !ok || d.Server
+}
+
+// HTTPStatus returns the canonical HTTP status code of the category of `err`,
+// e.g. 404 for NotFound or 503 for Unavailable (see Descriptor.HTTPStatus).
+// It returns 200 for a nil error, and 500 for errors that do not carry a
+// fault.
+//
+// Example:
+//
+//	if err != nil {
+//		http.Error(w, err.Error(), faults.HTTPStatus(err))
+//		return
+//	}
+func HTTPStatus(err error) int {
+	if d, ok := Describe(CodeOf(err)); ok {
+		return d.HTTPStatus
+	}
+	return 500
+}
+
+// CodeOfHTTPStatus returns the category of the HTTP status code `status`,
+// which reverses HTTPStatus. When several categories share a status, the most
+// general one is picked, e.g. Bad for 400 rather than FailedPrecondition.
+// Statuses that are not mapped to a category are mapped to Bad for 4xx, and
+// to Internal for 5xx. Statuses below 400 are mapped to OK.
+//
+// Statuses set with SetHTTPStatus are mapped back to their category first.
+func CodeOfHTTPStatus(status int) Code {
+	if code, ok := overriddenCodeOfStatus(status); ok {
+		return code
+	}
+	switch status {
+	case 400, 422:
+		return CodeBad
+	case 401:
+		return CodeUnauthenticated
+	case 403:
+		return CodePermissionDenied
+	case 404, 410:
+		return CodeNotFound
+	case 409:
+		return CodeAborted
+	case 412:
+		return CodeFailedPrecondition
+	case 429:
+		return CodeResourceExhausted
+	case 500:
+		return CodeInternal
+	case 501:
+		return CodeUnimplemented
+	case 502, 503:
+		return CodeUnavailable
+	case 408, 504:
+		return CodeDeadlineExceeded
+	}
+
+	if code, ok := customCodeOfStatus(status); ok {
+		return code
+	}
+	switch {
+	case status < 400:
+		return CodeOK
+	case status < 500:
+		return CodeBad
+	}
+	return CodeInternal
+}
@@ -0,0 +1,229 @@
+package faults_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/deixis/faults"
+)
+
+// TestCodeOf ensures each fault type is classified with its own code.
+func TestCodeOf(t *testing.T) {
+	table := []struct {
+		Error error
+		Code  faults.Code
+	}{
+		{Error: nil, Code: faults.CodeOK},
+		{Error: errors.New("boom"), Code: faults.CodeUnknown},
+		{Error: faults.NotFound, Code: faults.CodeNotFound},
+		{Error: faults.PermissionDenied, Code: faults.CodePermissionDenied},
+		{Error: faults.Unauthenticated, Code: faults.CodeUnauthenticated},
+		{Error: faults.Unimplemented, Code: faults.CodeUnimplemented},
+		{Error: faults.Internal, Code: faults.CodeInternal},
+		{Error: faults.DeadlineExceeded, Code: faults.CodeDeadlineExceeded},
+		{Error: faults.Bad(), Code: faults.CodeBad},
+		{Error: faults.FailedPrecondition(), Code: faults.CodeFailedPrecondition},
+		{Error: faults.Aborted(), Code: faults.CodeAborted},
+		{Error: faults.Unavailable(0), Code: faults.CodeUnavailable},
+		{Error: faults.ResourceExhausted(), Code: faults.CodeResourceExhausted},
+		{Error: fmt.Errorf("wrapped: %w", faults.NotFound), Code: faults.CodeNotFound},
+	}
+
+	for i, test := range table {
+		if code := faults.CodeOf(test.Error); code != test.Code {
+			t.Errorf("%d - expect code %s, but got %s", i, test.Code, code)
+		}
+	}
+}
+
+// TestPrecedence ensures chains carrying several faults resolve to the same
+// code regardless of how they are inspected.
+func TestPrecedence(t *testing.T) {
+	defer faults.SetPrecedence(nil)
+
+	nested := faults.WithBad(faults.WithUnavailable(faults.NotFound, 0))
+	joined := errors.Join(faults.Bad(), faults.Unavailable(0))
+
+	table := []struct {
+		Precedence faults.Precedence
+		Error      error
+		Code       faults.Code
+	}{
+		{Precedence: nil, Error: nested, Code: faults.CodeBad},
+		{Precedence: nil, Error: joined, Code: faults.CodeBad},
+		{Precedence: faults.Outermost, Error: nested, Code: faults.CodeBad},
+		{Precedence: faults.Innermost, Error: nested, Code: faults.CodeNotFound},
+		{Precedence: faults.Innermost, Error: joined, Code: faults.CodeUnavailable},
+		{
+			Precedence: faults.Ranked(faults.CodeUnavailable),
+			Error:      nested,
+			Code:       faults.CodeUnavailable,
+		},
+		{
+			Precedence: faults.Ranked(faults.CodeNotFound, faults.CodeUnavailable),
+			Error:      nested,
+			Code:       faults.CodeNotFound,
+		},
+		{
+			Precedence: faults.Ranked(faults.CodeUnavailable),
+			Error:      joined,
+			Code:       faults.CodeUnavailable,
+		},
+		{
+			Precedence: faults.Ranked(faults.CodeUnimplemented),
+			Error:      nested,
+			Code:       faults.CodeBad,
+		},
+	}
+
+	for i, test := range table {
+		faults.SetPrecedence(test.Precedence)
+		if code := faults.CodeOf(test.Error); code != test.Code {
+			t.Errorf("%d - expect code %s, but got %s", i, test.Code, code)
+		}
+	}
+}
+
+// TestClientServerFault ensures faults are partitioned between caller-caused
+// and service-caused failures.
+func TestClientServerFault(t *testing.T) {
+	table := []struct {
+		Error  error
+		Client bool
+		Server bool
+	}{
+		{Error: nil},
+		{Error: errors.New("boom"), Server: true},
+		{Error: faults.NotFound, Client: true},
+		{Error: faults.PermissionDenied, Client: true},
+		{Error: faults.Unauthenticated, Client: true},
+		{Error: faults.Bad(), Client: true},
+		{Error: faults.FailedPrecondition(), Client: true},
+		{Error: faults.Aborted(), Client: true},
+		{Error: faults.ResourceExhausted(), Client: true},
+		{Error: faults.Unavailable(0), Server: true},
+		{Error: faults.Unimplemented, Server: true},
+		{Error: faults.Internal, Server: true},
+		{Error: faults.DeadlineExceeded, Server: true},
+		{Error: faults.WithInternal(faults.Bad()), Server: true},
+	}
+
+	for i, test := range table {
+		if client := faults.IsClientFault(test.Error); client != test.Client {
+			t.Errorf("%d - expect client fault %t, but got %t", i, test.Client, client)
+		}
+		if server := faults.IsServerFault(test.Error); server != test.Server {
+			t.Errorf("%d - expect server fault %t, but got %t", i, test.Server, server)
+		}
+	}
+}
+
+// TestHTTPStatus ensures each category maps to its canonical HTTP status.
+func TestHTTPStatus(t *testing.T) {
+	table := []struct {
+		Error  error
+		Status int
+	}{
+		{Error: nil, Status: 200},
+		{Error: errors.New("boom"), Status: 500},
+		{Error: faults.Bad(), Status: 400},
+		{Error: faults.Unauthenticated, Status: 401},
+		{Error: faults.PermissionDenied, Status: 403},
+		{Error: faults.NotFound, Status: 404},
+		{Error: faults.Aborted(), Status: 409},
+		{Error: faults.FailedPrecondition(), Status: 400},
+		{Error: faults.ResourceExhausted(), Status: 429},
+		{Error: faults.Internal, Status: 500},
+		{Error: faults.Unimplemented, Status: 501},
+		{Error: faults.Unavailable(0), Status: 503},
+		{Error: faults.DeadlineExceeded, Status: 504},
+		{Error: fmt.Errorf("wrapped: %w", faults.NotFound), Status: 404},
+	}
+
+	for i, test := range table {
+		if status := faults.HTTPStatus(test.Error); status != test.Status {
+			t.Errorf("%d - expect status %d, but got %d", i, test.Status, status)
+		}
+	}
+}
+
+// TestCodeOfHTTPStatus ensures HTTP statuses are mapped back to their
+// category.
+func TestCodeOfHTTPStatus(t *testing.T) {
+	table := []struct {
+		Status int
+		Code   faults.Code
+	}{
+		{Status: 200, Code: faults.CodeOK},
+		{Status: 304, Code: faults.CodeOK},
+		{Status: 400, Code: faults.CodeBad},
+		{Status: 401, Code: faults.CodeUnauthenticated},
+		{Status: 403, Code: faults.CodePermissionDenied},
+		{Status: 404, Code: faults.CodeNotFound},
+		{Status: 409, Code: faults.CodeAborted},
+		{Status: 412, Code: faults.CodeFailedPrecondition},
+		{Status: 418, Code: faults.CodeBad},
+		{Status: 429, Code: faults.CodeResourceExhausted},
+		{Status: 500, Code: faults.CodeInternal},
+		{Status: 501, Code: faults.CodeUnimplemented},
+		{Status: 503, Code: faults.CodeUnavailable},
+		{Status: 504, Code: faults.CodeDeadlineExceeded},
+		{Status: 507, Code: faults.CodeInternal},
+	}
+
+	for i, test := range table {
+		if code := faults.CodeOfHTTPStatus(test.Status); code != test.Code {
+			t.Errorf("%d - expect code %s, but got %s", i, test.Code, code)
+		}
+	}
+}
+
+// TestParseCode ensures code names round-trip, and unknown names are
+// rejected.
+func TestParseCode(t *testing.T) {
+	for code := faults.CodeOK; code <= faults.CodeDeadlineExceeded; code++ {
+		parsed, err := faults.ParseCode(code.String())
+		if err != nil {
+			t.Errorf("expect code %s to be parsed, but got %s", code, err)
+		}
+		if parsed != code {
+			t.Errorf("expect code %s, but got %s", code, parsed)
+		}
+	}
+
+	if code, err := faults.ParseCode("not_found"); err != nil || code != faults.CodeNotFound {
+		t.Errorf("expect code %s, but got %s (%v)", faults.CodeNotFound, code, err)
+	}
+	if _, err := faults.ParseCode("NOT_A_CODE"); err == nil {
+		t.Error("expect unknown code to be rejected")
+	}
+}
+
+// TestCodeText ensures codes can be used in text-based configuration formats.
+func TestCodeText(t *testing.T) {
+	var config struct {
+		Retry []faults.Code `json:"retry"`
+	}
+	if err := json.Unmarshal([]byte(`{"retry":["UNAVAILABLE","aborted"]}`), &config); err != nil {
+		t.Fatalf("expect config to be decoded, but got %s", err)
+	}
+	expect := []faults.Code{faults.CodeUnavailable, faults.CodeAborted}
+	if !reflect.DeepEqual(expect, config.Retry) {
+		t.Errorf("expect codes %v, but got %v", expect, config.Retry)
+	}
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		t.Fatalf("expect config to be encoded, but got %s", err)
+	}
+	if string(data) != `{"retry":["UNAVAILABLE","ABORTED"]}` {
+		t.Errorf("expect encoded config, but got %s", data)
+	}
+
+	if err := json.Unmarshal([]byte(`{"retry":["RETRY"]}`), &config); err == nil {
+		t.Error("expect unknown code to be rejected")
+	}
+}
@@ -0,0 +1,53 @@
+package faults
+
+// A Collector accumulates the field violations found by a validation pass,
+// and reports them as a single BadRequest at the end. Its zero value is
+// ready to use.
+//
+// Example:
+//
+//	var c faults.Collector
+//	c.Check(req.Name != "", "name", "Field required", faults.ViolationRequired)
+//	if c.Check(req.Email != "", "email", "Field required", faults.ViolationRequired) {
+//		c.Check(strings.Contains(req.Email, "@"), "email", "Must be a valid address", faults.ViolationFormat)
+//	}
+//	if err := c.Err(); err != nil {
+//		return err
+//	}
+//
+// A Collector must not be used concurrently.
+type Collector struct {
+	violations []*FieldViolation
+}
+
+// Check adds a violation of the field `field` when `ok` is false, with an
+// optional code telling why the field is bad (see FieldViolation.Code). It
+// returns `ok`, so checks depending on the validity of the field can be
+// skipped.
+func (c *Collector) Check(ok bool, field, description string, code ...string) bool {
+	if !ok {
+		c.violations = append(c.violations, newField(field, description, code, 1))
+	}
+	return ok
+}
+
+// Add adds violations.
+func (c *Collector) Add(violations ...*FieldViolation) {
+	c.violations = append(c.violations, violations...)
+}
+
+// Len returns the number of violations collected so far.
+func (c *Collector) Len() int {
+	return len(c.violations)
+}
+
+// Err returns a BadRequest carrying the violations collected so far, in the
+// order they were found. It returns nil when no violation was found.
+func (c *Collector) Err() error {
+	if len(c.violations) == 0 {
+		return nil
+	}
+	violations := make([]*FieldViolation, len(c.violations))
+	copy(violations, c.violations)
+	return traced(EventCreate, &BadRequest{Violations: violations})
+}
@@ -0,0 +1,77 @@
+package faults_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/deixis/faults"
+)
+
+// TestCollector ensures violations are collected into a single bad request.
+func TestCollector(t *testing.T) {
+	validate := func(name, email string) error {
+		var c faults.Collector
+		c.Check(name != "", "name", "Field required", faults.ViolationRequired)
+		if c.Check(email != "", "email", "Field required", faults.ViolationRequired) {
+			c.Check(strings.Contains(email, "@"), "email", "Must be a valid address", faults.ViolationFormat)
+		}
+		return c.Err()
+	}
+
+	table := []struct {
+		Name       string
+		Email      string
+		Violations []*faults.FieldViolation
+	}{
+		{Name: "Jane", Email: "jane@example.com"},
+		{
+			Name:       "Jane",
+			Email:      "jane",
+			Violations: []*faults.FieldViolation{faults.Field("email", "Must be a valid address", faults.ViolationFormat)},
+		},
+		{
+			Violations: []*faults.FieldViolation{
+				faults.Field("name", "Field required", faults.ViolationRequired),
+				faults.Field("email", "Field required", faults.ViolationRequired),
+			},
+		},
+	}
+
+	for i, test := range table {
+		err := validate(test.Name, test.Email)
+		if test.Violations == nil {
+			if err != nil {
+				t.Errorf("%d - expect nil, but got %v", i, err)
+			}
+			continue
+		}
+		e, ok := faults.AsBad(err)
+		if !ok {
+			t.Errorf("%d - expect bad request, but got %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(e.Violations, test.Violations) {
+			t.Errorf("%d - expect violations %v, but got %v", i, test.Violations, e.Violations)
+		}
+	}
+}
+
+// TestCollectorAdd ensures violations can be added as they are, and that
+// faults are not affected by later violations.
+func TestCollectorAdd(t *testing.T) {
+	var c faults.Collector
+	c.Add(faults.Field("items[0].sku", "Unknown product"), faults.Field("items[1].sku", "Unknown product"))
+	if n := c.Len(); n != 2 {
+		t.Errorf("expect 2 violations, but got %d", n)
+	}
+
+	err := c.Err()
+	c.Check(false, "coupon", "Expired")
+	if e, _ := faults.AsBad(err); len(e.Violations) != 2 {
+		t.Errorf("expect 2 violations in the fault, but got %v", err)
+	}
+	if e, _ := faults.AsBad(c.Err()); len(e.Violations) != 3 {
+		t.Errorf("expect 3 violations, but got %v", c.Err())
+	}
+}
@@ -0,0 +1,105 @@
+package faults
+
+import "sync/atomic"
+
+// Config gathers the settings of the package for the process, so they can be
+// set at once when the program starts, and read back by the libraries that
+// depend on them, e.g. to tell whether violations are truncated on the wire.
+//
+// The zero value disables every feature, including the limits, so a Config
+// is meant to be derived from DefaultConfig or CurrentConfig:
+//
+//	func main() {
+//		cfg := faults.DefaultConfig()
+//		cfg.Domain = "payments.example.com"
+//		cfg.Stack.TrimDependencies = true
+//		cfg.Limits.MaxViolations = 20
+//		faults.Configure(cfg)
+//		// ...
+//	}
+type Config struct {
+	// Domain is the domain of the reasons that are not qualified with a
+	// domain of their own (see SetDomain).
+	Domain string
+	// Debug attaches a DebugInfo to the faults created by the process (see
+	// SetDebug).
+	Debug bool
+	// RecordHops records where faults are wrapped, and where violations are
+	// produced (see SetRecordHops).
+	RecordHops bool
+	// Stack configures the stacks captured with faults, and the frames of
+	// hops (see SetStackOptions).
+	Stack StackOptions
+	// TrustInternalCause lets InternalCause return the original errors behind
+	// the copies returned by Sanitize and Redact (see SetTrustInternalCause).
+	TrustInternalCause bool
+	// Limits bounds the size of the faults encoded for transport, e.g. their
+	// number of violations (see SetLimits).
+	Limits Limits
+	// Precedence resolves the code of chains that carry more than one fault
+	// (see SetPrecedence). Nil stands for Outermost.
+	Precedence Precedence
+}
+
+// DefaultConfig returns the configuration of the package when the program
+// starts: the default limits (see DefaultLimits) and the Outermost
+// precedence, with every other feature disabled.
+func DefaultConfig() Config {
+	return Config{Limits: DefaultLimits, Precedence: Outermost}
+}
+
+// config holds the configuration of the process. It is replaced as a whole,
+// so every reader sees a consistent snapshot.
+var config atomic.Pointer[Config]
+
+func init() {
+	c := DefaultConfig()
+	config.Store(&c)
+}
+
+// Configure replaces the configuration of the process with `c`. It is meant to
+// be called once, when the program starts, before faults are created.
+//
+// Faults are built with the configuration in effect when they are created,
+// and keep it: a later call changes neither the domains of their reasons, nor
+// their hops, nor their stacks. The settings applied when faults are read or
+// encoded, such as the precedence and the limits, apply to every fault from
+// the call on.
+func Configure(c Config) {
+	config.Store(c.clone())
+}
+
+// CurrentConfig returns the configuration in effect, including the settings
+// set one by one, e.g. with SetDomain.
+func CurrentConfig() Config {
+	return *settings().clone()
+}
+
+// settings returns the configuration in effect, which must not be modified.
+func settings() *Config {
+	return config.Load()
+}
+
+// configure applies `f` to a copy of the configuration in effect, and makes
+// the copy the configuration in effect.
+func configure(f func(*Config)) {
+	for {
+		old := config.Load()
+		c := *old
+		f(&c)
+		if config.CompareAndSwap(old, c.clone()) {
+			return
+		}
+	}
+}
+
+// clone returns a deep copy of `c`, with its defaults applied.
+func (c *Config) clone() *Config {
+	clone := *c
+	clone.Stack.SkipFunctions = append([]string(nil), c.Stack.SkipFunctions...)
+	clone.Stack.TrimPrefixes = append([]string(nil), c.Stack.TrimPrefixes...)
+	if clone.Precedence == nil {
+		clone.Precedence = Outermost
+	}
+	return &clone
+}
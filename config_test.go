@@ -0,0 +1,82 @@
+package faults_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/deixis/faults"
+)
+
+// TestConfigure ensures the settings of a configuration apply at once.
+func TestConfigure(t *testing.T) {
+	defer faults.Configure(faults.DefaultConfig())
+
+	cfg := faults.DefaultConfig()
+	cfg.Domain = "payments.example.com"
+	cfg.RecordHops = true
+	cfg.Limits.MaxViolations = 1
+	cfg.Precedence = faults.Innermost
+	faults.Configure(cfg)
+
+	err := faults.WithReason(faults.WithInternal(faults.WithNotFound(errors.New("no such card"))), "CARD_NOT_FOUND")
+	if domain := faults.DomainOf(err); domain != "payments.example.com" {
+		t.Errorf("expect domain of the configuration, but got %q", domain)
+	}
+	if hops := faults.Hops(err); len(hops) != 3 {
+		t.Errorf("expect 3 hops, but got %v", hops)
+	}
+	if code := faults.CodeOf(err); code != faults.CodeNotFound {
+		t.Errorf("expect innermost code, but got %s", code)
+	}
+
+	bad := faults.Bad(faults.Field("email", "is required"), faults.Field("name", "is required"))
+	if f, ok := faults.AsBad(faults.Truncate(bad)); !ok || len(f.Violations) != 1 {
+		t.Errorf("expect violations to be limited, but got %v", faults.Truncate(bad))
+	}
+}
+
+// TestCurrentConfig ensures the configuration in effect includes the
+// settings set one by one, and can't be modified by its readers.
+func TestCurrentConfig(t *testing.T) {
+	defer faults.Configure(faults.DefaultConfig())
+
+	cfg := faults.CurrentConfig()
+	if cfg.Limits != faults.DefaultLimits || cfg.Precedence == nil || cfg.Debug || cfg.RecordHops {
+		t.Errorf("expect default configuration, but got %+v", cfg)
+	}
+
+	faults.SetDomain("payments.example.com")
+	faults.SetTrustInternalCause(true)
+	faults.SetStackOptions(faults.StackOptions{SkipFunctions: []string{"net/http."}})
+	faults.SetPrecedence(nil)
+	cfg = faults.CurrentConfig()
+	if cfg.Domain != "payments.example.com" || !cfg.TrustInternalCause || len(cfg.Stack.SkipFunctions) != 1 || cfg.Precedence == nil {
+		t.Errorf("expect settings to be reported, but got %+v", cfg)
+	}
+
+	cfg.Stack.SkipFunctions[0] = "main."
+	if skip := faults.CurrentConfig().Stack.SkipFunctions[0]; skip != "net/http." {
+		t.Errorf("expect configuration to be kept, but got %q", skip)
+	}
+}
+
+// TestConfigureSnapshot ensures faults keep the configuration they were
+// created with.
+func TestConfigureSnapshot(t *testing.T) {
+	defer faults.Configure(faults.DefaultConfig())
+
+	cfg := faults.DefaultConfig()
+	cfg.Domain = "payments.example.com"
+	faults.Configure(cfg)
+	err := faults.WithReason(faults.Internal, "CARD_DECLINED")
+
+	cfg.Domain = "billing.example.com"
+	cfg.RecordHops = true
+	faults.Configure(cfg)
+	if domain := faults.DomainOf(err); domain != "payments.example.com" {
+		t.Errorf("expect domain of creation, but got %q", domain)
+	}
+	if hops := faults.Hops(err); hops != nil {
+		t.Errorf("expect no hop, but got %v", hops)
+	}
+}
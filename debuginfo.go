@@ -0,0 +1,128 @@
+package faults
+
+import "runtime"
+
+// DebugInfo carries debugging information about a failure, such as the stack
+// where it occurred, like the DebugInfo detail of gRPC.
+//
+// DebugInfo is a detail: it does not have a category of its own, and is
+// meant to be attached to a fault with AttachStack, or by the constructors of
+// faults in debug mode (see SetDebug). It describes the internals of the
+// process, so it is not carried by transports, and is dropped by Sanitize and
+// Redact.
+type DebugInfo struct {
+	error
+
+	// Stack is the stack where the failure occurred, from the innermost
+	// frame.
+	Stack []Frame
+	// Detail is any additional debugging information.
+	Detail string
+}
+
+func (e *DebugInfo) Error() string {
+	if e.error == nil {
+		return "debug info"
+	}
+	return e.error.Error()
+}
+
+func (e *DebugInfo) Unwrap() error {
+	return e.error
+}
+
+// AttachStack attaches the stack of its caller to `parent`, which keeps its
+// category. The stack is filtered and trimmed according to the options set
+// with SetStackOptions.
+//
+// Example:
+//
+//	if err := tx.Commit(); err != nil {
+//		return faults.AttachStack(faults.WithInternal(err))
+//	}
+func AttachStack(parent error) error {
+	return traced(EventWrap, &DebugInfo{error: parent, Stack: callStack(1 + stackOptions().Skip)})
+}
+
+// AsDebugInfo finds the first DebugInfo in the tree of `err`.
+func AsDebugInfo(err error) (*DebugInfo, bool) {
+	return as[*DebugInfo](err)
+}
+
+// StackOf returns the stack attached to `err` (see DebugInfo), or nil when
+// there is none.
+func StackOf(err error) []Frame {
+	if e, ok := AsDebugInfo(err); ok {
+		return e.Stack
+	}
+	return nil
+}
+
+// SetDebug sets whether the faults created or wrapped by the process carry
+// a DebugInfo, with the stack of their creation and the build information of
+// the binary (its module, its version, the version of Go and the revision it
+// was built from, except with the `faults_minimal` build tag) as Detail. It
+// is disabled by default, and is meant for
+// development environments, since capturing stacks is expensive:
+//
+//	func main() {
+//		faults.SetDebug(os.Getenv("ENV") == "development")
+//		// ...
+//	}
+//
+// The DebugInfo is attached by the first constructor applied to an error,
+// e.g. WithNotFound or Build, around the error it returns, so faults created
+// in debug mode must be matched with errors.As or the As* functions, e.g.
+// AsBad, rather than with type assertions. The faults created by this package
+// on its own behalf, e.g. by Sanitize, don't carry any.
+//
+// Whatever the mode, DebugInfo is never encoded by transports, and the copies
+// returned by Sanitize and Redact never carry any.
+func SetDebug(debug bool) {
+	configure(func(c *Config) { c.Debug = debug })
+}
+
+// debugged returns `err` with a DebugInfo describing the caller `skip` frames
+// above the caller of debugged, when the debug mode is enabled and `err` does
+// not carry one already.
+func debugged(err error, skip int) error {
+	if err == nil || !settings().Debug {
+		return err
+	}
+	// The faults created by this package, e.g. the copies of Sanitize, are
+	// left alone
+	if pc, _, _, ok := runtime.Caller(skip + 1); ok {
+		if fn := runtime.FuncForPC(pc); fn != nil && packageOf(fn.Name()) == packagePath {
+			return err
+		}
+	}
+	if _, ok := AsDebugInfo(err); ok {
+		return err
+	}
+	return &DebugInfo{error: err, Stack: callStack(skip + 1 + stackOptions().Skip), Detail: buildDetail()}
+}
+
+// packagePath is the import path of this package.
+const packagePath = "github.com/deixis/faults"
+
+// undebugged returns a copy of `err` without its DebugInfo, and whether `err`
+// carried one. The copy keeps the message and the details of `err` that are meant for the caller:
+// its category, its violations, its reason, its retry delay, its challenges,
+// its markers, its help, its attachments and its public message.
+func undebugged(err error) (error, bool) {
+	if _, ok := AsDebugInfo(err); !ok {
+		return err, false
+	}
+	outbound := envelopeOf(err).fault()
+	attachments := AttachmentsOf(err)
+	for i := len(attachments) - 1; i >= 0; i-- {
+		outbound = Attach(outbound, attachments[i].Type, attachments[i].Value)
+	}
+	if links := HelpOf(err); len(links) > 0 {
+		outbound = AttachHelp(outbound, links...)
+	}
+	if message, ok := publicMessage(err); ok {
+		outbound = WithPublicMessage(outbound, message)
+	}
+	return outbound, true
+}
@@ -0,0 +1,121 @@
+package faults_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/deixis/faults"
+)
+
+// commit attaches stacks like a helper of a service.
+func commit() error {
+	return faults.AttachStack(faults.WithInternal(errors.New("tx aborted")))
+}
+
+// TestAttachStack ensures stacks are attached to faults without changing
+// their category or their message.
+func TestAttachStack(t *testing.T) {
+	err := commit()
+
+	if !faults.IsInternal(err) || err.Error() != faults.WithInternal(errors.New("tx aborted")).Error() {
+		t.Errorf("expect internal failure, but got %s %q", faults.CodeOf(err), err)
+	}
+	stack := faults.StackOf(err)
+	if len(stack) < 2 || !strings.HasSuffix(stack[0].Function, "faults_test.commit") || !strings.HasSuffix(stack[1].Function, "faults_test.TestAttachStack") {
+		t.Errorf("expect stack from commit, but got %v", stack)
+	}
+	if stack := faults.StackOf(faults.Sanitize(err)); stack != nil {
+		t.Errorf("expect stack to be dropped by Sanitize, but got %v", stack)
+	}
+	if stack := faults.StackOf(faults.Internal); stack != nil {
+		t.Errorf("expect no stack, but got %v", stack)
+	}
+}
+
+// TestAttachStackSkip ensures the frames of helpers can be skipped.
+func TestAttachStackSkip(t *testing.T) {
+	faults.SetStackOptions(faults.StackOptions{Skip: 1})
+	defer faults.SetStackOptions(faults.StackOptions{})
+
+	if stack := faults.StackOf(commit()); len(stack) == 0 || !strings.HasSuffix(stack[0].Function, "faults_test.TestAttachStackSkip") {
+		t.Errorf("expect stack from TestAttachStackSkip, but got %v", stack)
+	}
+}
+
+// TestSetDebug ensures faults carry a DebugInfo in debug mode, from where
+// they were created, without changing their category or their message.
+func TestSetDebug(t *testing.T) {
+	faults.SetDebug(true)
+	defer faults.SetDebug(false)
+
+	table := []error{
+		faults.WithNotFound(errors.New("no such user")),
+		faults.Bad(faults.Field("email", "is required")),
+		faults.Build(faults.CodeAborted).Message("version mismatch").Err(),
+		faults.WithInternalf(errors.New("tx aborted"), "committing order %d", 42),
+	}
+	for i, err := range table {
+		info, ok := faults.AsDebugInfo(err)
+		if !ok {
+			t.Errorf("%d - expect debug info, but got none", i)
+			continue
+		}
+		if len(info.Stack) == 0 || !strings.HasSuffix(info.Stack[0].Function, "faults_test.TestSetDebug") {
+			t.Errorf("%d - expect stack from TestSetDebug, but got %v", i, info.Stack)
+		}
+		if err.Error() != errors.Unwrap(err).Error() {
+			t.Errorf("%d - expect message to be kept, but got %q", i, err)
+		}
+	}
+
+	// A single DebugInfo is attached to an error, by its first constructor
+	err := faults.WithReason(table[0], "USER_NOT_FOUND")
+	if info, _ := faults.AsDebugInfo(err); info == nil || errors.Unwrap(info) != errors.Unwrap(table[0]) {
+		t.Errorf("expect debug info of WithNotFound, but got %v", info)
+	}
+	if !faults.IsNotFound(err) || faults.ReasonOf(err) != "USER_NOT_FOUND" {
+		t.Errorf("expect fault details to be kept, but got %v", err)
+	}
+}
+
+// TestSetDebugStripped ensures the copies returned by Sanitize and Redact
+// never carry a DebugInfo.
+func TestSetDebugStripped(t *testing.T) {
+	faults.SetDebug(true)
+	defer faults.SetDebug(false)
+
+	bad := faults.Bad(faults.Field("email", "is required"))
+	table := []error{
+		faults.WithInternal(errors.New("tx aborted")),
+		bad,
+		faults.AttachHelp(bad, faults.Link{Description: "Form", URL: "https://example.com/form"}),
+		faults.WithPublicMessage(faults.WithNotFound(errors.New("no such user")), "user not found"),
+		faults.WithReason(faults.WithUnavailable(nil, 0), "MAINTENANCE"),
+	}
+	for i, err := range table {
+		for _, outbound := range []error{faults.Sanitize(err), faults.Redact(err)} {
+			if info, ok := faults.AsDebugInfo(outbound); ok {
+				t.Errorf("%d - expect debug info to be stripped, but got %v", i, info.Stack)
+			}
+			if faults.CodeOf(outbound) != faults.CodeOf(err) || faults.ReasonOf(outbound) != faults.ReasonOf(err) {
+				t.Errorf("%d - expect fault details to be kept, but got %s %q", i, faults.CodeOf(outbound), faults.ReasonOf(outbound))
+			}
+		}
+	}
+
+	// The copies of client faults keep what the caller may see
+	outbound := faults.Sanitize(table[2])
+	if outbound.Error() != bad.Error() {
+		t.Errorf("expect message to be kept, but got %q", outbound)
+	}
+	if f, ok := faults.AsBad(outbound); !ok || len(f.Violations) != 1 || f.Violations[0].Field != "email" {
+		t.Errorf("expect violations to be kept, but got %v", outbound)
+	}
+	if links := faults.HelpOf(outbound); len(links) != 1 {
+		t.Errorf("expect help to be kept, but got %v", links)
+	}
+	if message := faults.PublicMessage(faults.Sanitize(table[3])); message != "user not found" {
+		t.Errorf("expect public message to be kept, but got %q", message)
+	}
+}
@@ -0,0 +1,62 @@
+package faults
+
+// Severity is the default order used by Dominant, from the most to the least
+// significant category. Failures of the service rank above failures of the
+// caller, since they are the ones that require attention.
+var Severity = []Code{
+	CodeInternal,
+	CodeUnknown,
+	CodeDeadlineExceeded,
+	CodeUnavailable,
+	CodeUnimplemented,
+	CodeResourceExhausted,
+	CodeUnauthenticated,
+	CodePermissionDenied,
+	CodeAborted,
+	CodeFailedPrecondition,
+	CodeNotFound,
+	CodeBad,
+}
+
+// Dominant returns the most significant fault found in the tree of `err`,
+// e.g. when `errors.Join` combines the failures of parallel operations and a
+// single response must be derived from them.
+//
+// Faults are ranked with `order`, or with Severity when no order is given.
+// Faults whose code is not listed rank below the listed ones, and the first
+// fault visited by Walk wins among faults of the same rank.
+//
+// Example:
+//
+//	err := errors.Join(results...)
+//	faults.CodeOf(faults.Dominant(err)) // e.g. UNAVAILABLE over NOT_FOUND
+//
+// It returns `err` when it does not carry any fault.
+func Dominant(err error, order ...Code) error {
+	if len(order) == 0 {
+		order = Severity
+	}
+	rank := func(c Code) int {
+		for i, o := range order {
+			if o == c {
+				return i
+			}
+		}
+		return len(order)
+	}
+
+	var best Fault
+	bestRank := len(order) + 1
+	walk(err, func(err error) bool {
+		if f, ok := err.(Fault); ok {
+			if r := rank(f.Code()); r < bestRank {
+				best, bestRank = f, r
+			}
+		}
+		return bestRank > 0
+	})
+	if best == nil {
+		return err
+	}
+	return best
+}
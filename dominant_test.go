@@ -0,0 +1,45 @@
+package faults_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/deixis/faults"
+)
+
+// TestDominant ensures the most significant fault of a tree is picked.
+func TestDominant(t *testing.T) {
+	boom := errors.New("boom")
+	notFound := faults.WithNotFound(boom)
+	unavailable := faults.Unavailable(0)
+	bad := faults.Bad()
+
+	table := []struct {
+		Error  error
+		Order  []faults.Code
+		Expect error
+	}{
+		{Error: nil, Expect: nil},
+		{Error: boom, Expect: boom},
+		{Error: notFound, Expect: notFound},
+		{Error: errors.Join(bad, notFound), Expect: notFound},
+		{Error: errors.Join(bad, notFound, unavailable), Expect: unavailable},
+		{Error: faults.WithBad(errors.Join(notFound, unavailable)), Expect: unavailable},
+		{
+			Error:  errors.Join(bad, notFound, unavailable),
+			Order:  []faults.Code{faults.CodeBad},
+			Expect: bad,
+		},
+		{
+			Error:  errors.Join(notFound, unavailable),
+			Order:  []faults.Code{faults.CodeBad},
+			Expect: notFound,
+		},
+	}
+
+	for i, test := range table {
+		if err := faults.Dominant(test.Error, test.Order...); err != test.Expect {
+			t.Errorf("%d - expect dominant fault %v, but got %v", i, test.Expect, err)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package faults
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// Field numbers of the binary encoding. The encoding follows the Protocol
+// Buffers wire format, so it can be inspected with `protoc --decode_raw`, and
+// unknown fields are skipped by Decode to let the format evolve.
+const (
+	fieldCode       = 1  // string, e.g. "NOT_FOUND"
+	fieldMessage    = 2  // string
+	fieldRetryDelay = 3  // varint, milliseconds
+	fieldReason     = 4  // string
+	fieldMetadata   = 5  // message {1: key, 2: value}
+	fieldViolation  = 6  // message {1: field, 2: code, 3: type, 4: subject, 5: resource, 6: description, 7: template, 8: param}
+	fieldPermanent  = 7  // varint
+	fieldChallenge  = 8  // string, as written in a WWW-Authenticate header
+	fieldCause      = 9  // string, the message of the error wrapped by the fault
+	fieldVersion    = 10 // varint, see SchemaVersion
+	fieldTruncated  = 11 // varint
+	fieldDomain     = 12 // string, the domain of the reason
+
+	// Field numbers of the violation messages, after the strings of their Go
+	// type.
+	violationTemplate = 7 // string
+	violationParam    = 8 // message {1: key, 2: value}
+
+	wireVarint = 0
+	wireBytes  = 2
+)
+
+// errMalformed is returned by Decode when its input is not a valid encoding.
+var errMalformed = errors.New("faults: malformed encoding")
+
+// Encode returns a compact binary representation of `err`, with its category,
+// its message, its violations, its reason, its retry delay, its challenges
+// and the version of the schema (see SchemaVersion). It is meant for
+// transports that can only carry opaque bytes, e.g. HTTP trailers, message
+// queue headers or custom protocols, like gRPC propagates rich statuses in
+// the `grpc-status-details-bin` trailer. Decode converts it back to a fault,
+// and EncodeHeader wraps it in a header value.
+//
+// The message of `err` is encoded as is. Faults crossing a trust boundary
+// should be sanitized first (see Sanitize). Attachments are not encoded, as
+// their values have no portable representation. Faults exceeding the limits
+// set with SetLimits are truncated.
+//
+// It returns nil when `err` is nil.
+func Encode(err error) ([]byte, error) {
+	if err == nil {
+		return nil, nil
+	}
+	return limited(err, encodeEnvelope), nil
+}
+
+// encodeEnvelope returns the binary representation of `e`.
+func encodeEnvelope(e *envelope) []byte {
+	var b []byte
+	b = appendString(b, fieldCode, e.Code)
+	b = appendString(b, fieldMessage, e.Message)
+	if e.RetryDelay > 0 {
+		b = appendVarint(b, fieldRetryDelay, uint64(e.RetryDelay))
+	}
+	b = appendString(b, fieldReason, e.Reason)
+	b = appendString(b, fieldDomain, e.Domain)
+	for _, k := range e.metadataKeys() {
+		var entry []byte
+		entry = appendString(entry, 1, k)
+		entry = appendString(entry, 2, e.Metadata[k])
+		b = appendBytes(b, fieldMetadata, entry)
+	}
+	for _, v := range e.Violations {
+		var entry []byte
+		for i, s := range [...]string{v.Field, v.Code, v.Type, v.Subject, v.Resource, v.Description} {
+			entry = appendString(entry, i+1, s)
+		}
+		entry = appendString(entry, violationTemplate, v.Template)
+		for _, k := range v.paramKeys() {
+			var param []byte
+			param = appendString(param, 1, k)
+			param = appendString(param, 2, v.Params[k])
+			entry = appendBytes(entry, violationParam, param)
+		}
+		b = appendBytes(b, fieldViolation, entry)
+	}
+	if e.Permanent {
+		b = appendVarint(b, fieldPermanent, 1)
+	}
+	for _, c := range e.Challenges {
+		b = appendString(b, fieldChallenge, c)
+	}
+	b = appendString(b, fieldCause, e.Cause)
+	b = appendVarint(b, fieldVersion, uint64(e.Version))
+	if e.Truncated {
+		b = appendVarint(b, fieldTruncated, 1)
+	}
+	return b
+}
+
+// Decode converts the binary representation produced by Encode back to a
+// fault. Like with DecodeJSON, representations of later versions are decoded
+// too, unknown fields are skipped, and categories that are not registered in
+// this process are decoded as plain errors carrying the original message.
+//
+// It returns nil when `data` is empty, and an Unknown error when `data` is
+// malformed.
+func Decode(data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	e, err := decodeEnvelope(data)
+	if err != nil {
+		return err
+	}
+	return e.fault()
+}
+
+// decodeEnvelope decodes the binary representation of a fault.
+func decodeEnvelope(data []byte) (*envelope, error) {
+	e := &envelope{}
+	err := readFields(data, func(field int, varint uint64, value []byte) error {
+		switch field {
+		case fieldCode:
+			e.Code = string(value)
+		case fieldMessage:
+			e.Message = string(value)
+		case fieldRetryDelay:
+			e.RetryDelay = int64(varint)
+		case fieldReason:
+			e.Reason = string(value)
+		case fieldDomain:
+			e.Domain = string(value)
+		case fieldMetadata:
+			k, v, err := readEntry(value)
+			if err != nil {
+				return err
+			}
+			if e.Metadata == nil {
+				e.Metadata = map[string]string{}
+			}
+			e.Metadata[k] = v
+		case fieldViolation:
+			var v violation
+			fields := [...]*string{&v.Field, &v.Code, &v.Type, &v.Subject, &v.Resource, &v.Description}
+			err := readFields(value, func(field int, _ uint64, value []byte) error {
+				switch {
+				case field >= 1 && field <= len(fields):
+					*fields[field-1] = string(value)
+				case field == violationTemplate:
+					v.Template = string(value)
+				case field == violationParam:
+					k, p, err := readEntry(value)
+					if err != nil {
+						return err
+					}
+					if v.Params == nil {
+						v.Params = map[string]string{}
+					}
+					v.Params[k] = p
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			e.Violations = append(e.Violations, v)
+		case fieldPermanent:
+			e.Permanent = varint != 0
+		case fieldChallenge:
+			e.Challenges = append(e.Challenges, string(value))
+		case fieldCause:
+			e.Cause = string(value)
+		case fieldVersion:
+			e.Version = int(varint)
+		case fieldTruncated:
+			e.Truncated = varint != 0
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// readEntry reads the key and the value of an entry of a map, e.g. of the
+// metadata.
+func readEntry(data []byte) (key, value string, err error) {
+	err = readFields(data, func(field int, _ uint64, v []byte) error {
+		switch field {
+		case 1:
+			key = string(v)
+		case 2:
+			value = string(v)
+		}
+		return nil
+	})
+	return key, value, err
+}
+
+func appendVarint(b []byte, field int, v uint64) []byte {
+	b = binary.AppendUvarint(b, uint64(field)<<3|wireVarint)
+	return binary.AppendUvarint(b, v)
+}
+
+func appendBytes(b []byte, field int, v []byte) []byte {
+	b = binary.AppendUvarint(b, uint64(field)<<3|wireBytes)
+	b = binary.AppendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+// appendString appends the string `v`, unless it is empty.
+func appendString(b []byte, field int, v string) []byte {
+	if v == "" {
+		return b
+	}
+	return appendBytes(b, field, []byte(v))
+}
+
+// readFields calls `fn` with each field of `data`. Fields using a wire type
+// other than varint and length-delimited are rejected.
+func readFields(data []byte, fn func(field int, varint uint64, value []byte) error) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return errMalformed
+		}
+		data = data[n:]
+
+		field := int(key >> 3)
+		switch key & 7 {
+		case wireVarint:
+			v, n := binary.Uvarint(data)
+			if n <= 0 {
+				return errMalformed
+			}
+			data = data[n:]
+			if err := fn(field, v, nil); err != nil {
+				return err
+			}
+		case wireBytes:
+			l, n := binary.Uvarint(data)
+			if n <= 0 || l > uint64(len(data)-n) {
+				return errMalformed
+			}
+			value := data[n : n+int(l)]
+			data = data[n+int(l):]
+			if err := fn(field, 0, value); err != nil {
+				return err
+			}
+		default:
+			return errMalformed
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package faults_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+)
+
+// TestEncode ensures faults survive their binary representation.
+func TestEncode(t *testing.T) {
+	table := []struct {
+		Error error
+		Delay time.Duration
+	}{
+		{Error: faults.Bad(&faults.FieldViolation{Field: "email", Code: faults.ViolationRequired, Description: "is required"})},
+		{Error: faults.NotFound},
+		{Error: faults.PermissionDenied},
+		{Error: faults.WithUnauthenticated(errors.New("token expired"), faults.Challenge{Scheme: "Bearer", Realm: "api"})},
+		{Error: faults.FailedPrecondition(&faults.PreconditionViolation{Type: "TOS", Subject: "user:42", Description: "not accepted"})},
+		{Error: faults.Aborted(&faults.ConflictViolation{Resource: "order:42", Description: "version mismatch"})},
+		{Error: faults.ResourceExhausted(&faults.QuotaViolation{Subject: "project:42", Description: "daily limit"})},
+		{Error: faults.Unavailable(1500 * time.Millisecond), Delay: 1500 * time.Millisecond},
+		{Error: faults.DeadlineExceeded},
+		{Error: faults.Unimplemented},
+		{Error: faults.Internal},
+		{Error: errors.New("boom")},
+		{Error: faults.Permanent(faults.Unavailable(time.Second))},
+		{Error: faults.WithErrorInfo(faults.NotFound, "USER_NOT_FOUND", map[string]string{"id": "42", "tenant": "acme"})},
+	}
+
+	for i, test := range table {
+		data, err := faults.Encode(test.Error)
+		if err != nil {
+			t.Fatalf("%d - expect fault to be encoded, but got %s", i, err)
+		}
+
+		err = faults.Decode(data)
+		if err == nil {
+			t.Fatalf("%d - expect fault to be decoded", i)
+		}
+		if err.Error() != test.Error.Error() {
+			t.Errorf("%d - expect message %q, but got %q", i, test.Error, err)
+		}
+		if code := faults.CodeOf(err); code != faults.CodeOf(test.Error) {
+			t.Errorf("%d - expect code %s, but got %s", i, faults.CodeOf(test.Error), code)
+		}
+		if expect, delay := faults.RetryAdvice(test.Error).Delay, faults.RetryAdvice(err).Delay; delay != expect {
+			t.Errorf("%d - expect retry delay %s, but got %s", i, expect, delay)
+		}
+		if expect, permanent := faults.IsPermanent(test.Error), faults.IsPermanent(err); permanent != expect {
+			t.Errorf("%d - expect permanent %t, but got %t", i, expect, permanent)
+		}
+		if expect, reason := faults.ReasonOf(test.Error), faults.ReasonOf(err); reason != expect {
+			t.Errorf("%d - expect reason %q, but got %q", i, expect, reason)
+		}
+		if expect, challenges := faults.ChallengesOf(test.Error), faults.ChallengesOf(err); !reflect.DeepEqual(expect, challenges) {
+			t.Errorf("%d - expect challenges %v, but got %v", i, expect, challenges)
+		}
+	}
+}
+
+// TestEncodeDetails ensures violations and metadata are restored exactly.
+func TestEncodeDetails(t *testing.T) {
+	fault := faults.Bad(
+		&faults.FieldViolation{Field: "email", Code: faults.ViolationRequired, Description: "is required"},
+		&faults.FieldViolation{Field: "name", Description: "is too long"},
+	)
+	data, _ := faults.Encode(faults.WithErrorInfo(fault, "INVALID_FORM", map[string]string{"form": "signup"}))
+
+	err := faults.Decode(data)
+	bad, ok := faults.AsBad(err)
+	if !ok {
+		t.Fatalf("expect bad request, but got %v", err)
+	}
+	expect, _ := faults.AsBad(fault)
+	if !reflect.DeepEqual(expect.Violations, bad.Violations) {
+		t.Errorf("expect violations %v, but got %v", expect.Violations, bad.Violations)
+	}
+	if e, ok := faults.AsErrorInfo(err); !ok || e.Metadata["form"] != "signup" {
+		t.Errorf("expect metadata to be restored, but got %v", e)
+	}
+
+	a, _ := faults.Encode(faults.WithErrorInfo(faults.NotFound, "X", map[string]string{"a": "1", "b": "2", "c": "3"}))
+	b, _ := faults.Encode(faults.WithErrorInfo(faults.NotFound, "X", map[string]string{"c": "3", "b": "2", "a": "1"}))
+	if string(a) != string(b) {
+		t.Error("expect encoding to be deterministic")
+	}
+}
+
+// TestDecode ensures malformed and unknown inputs are handled.
+func TestDecode(t *testing.T) {
+	if err := faults.Decode(nil); err != nil {
+		t.Errorf("expect nil, but got %v", err)
+	}
+	if data, _ := faults.Encode(nil); data != nil {
+		t.Errorf("expect no data, but got %x", data)
+	}
+
+	table := [][]byte{
+		{0x0a},                 // missing length
+		{0x0a, 0x05, 'a'},      // truncated string
+		{0x0d, 0x00, 0x00},     // unsupported wire type
+		{0x18, 0xff},           // truncated varint
+		{0x2a, 0x02, 0x0a, 42}, // malformed metadata
+	}
+	for i, data := range table {
+		err := faults.Decode(data)
+		if err == nil || faults.CodeOf(err) != faults.CodeUnknown {
+			t.Errorf("%d - expect malformed encoding to be rejected, but got %v", i, err)
+		}
+	}
+
+	// Unknown fields are skipped, and unknown categories are decoded as plain
+	// errors
+	data := []byte{0x0a, 0x04, 'N', 'O', 'P', 'E', 0x12, 0x02, 'h', 'i', 0x48, 0x01}
+	err := faults.Decode(data)
+	if faults.CodeOf(err) != faults.CodeUnknown || err.Error() != "hi" {
+		t.Errorf("expect plain error, but got %v", err)
+	}
+}
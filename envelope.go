@@ -0,0 +1,301 @@
+package faults
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// SchemaVersion is the version of the schema shared by the encodings of
+// faults (see Encode, EncodeJSON and EncodeCBOR), which is written with each
+// encoded fault.
+//
+// Later versions may add members to the schema, or categories, but never
+// remove or change them, so services running different versions during a
+// rolling deployment keep understanding each other: decoders accept
+// encodings of any version, skip the members they don't know, and decode the
+// categories they don't know as Unknown errors carrying the original message.
+//
+// Version 2 added the truncation indicator (see Limits), and version 3 the
+// templates of field violations and their parameters, and version 4 the
+// domains of reasons.
+const SchemaVersion = 4
+
+// JSONVersion is the version of the JSON representation of faults.
+//
+// Deprecated: the representations of faults share a single version, use
+// SchemaVersion instead.
+const JSONVersion = SchemaVersion
+
+// envelope is the portable description of a fault shared by its encodings
+// (see Encode and EncodeJSON). Its JSON representation is documented by
+// EncodeJSON.
+type envelope struct {
+	// Version is the version of the schema (see SchemaVersion). It is 0 for
+	// binary encodings written before the version was encoded.
+	Version int `json:"version"`
+	// Code is the name of the category, e.g. "NOT_FOUND".
+	Code string `json:"code"`
+	// Message is the message of the error.
+	Message string `json:"message,omitempty"`
+	// Cause is the message of the error wrapped by the fault carrying the
+	// category, if any, so the fault can be rebuilt with the same message.
+	Cause      string      `json:"cause,omitempty"`
+	Violations []violation `json:"violations,omitempty"`
+	// RetryDelay is the delay advised before retrying, in milliseconds.
+	RetryDelay int64             `json:"retryDelay,omitempty"`
+	Reason     string            `json:"reason,omitempty"`
+	Domain     string            `json:"domain,omitempty"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+	Permanent  bool              `json:"permanent,omitempty"`
+	// Challenges are written as in a WWW-Authenticate header.
+	Challenges []string `json:"challenges,omitempty"`
+	// Truncated reports whether details were dropped to fit the limits of
+	// the encoding (see Limits).
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// violation is the portable description of a violation. Only the fields of
+// the corresponding Go type are set, e.g. Field, Code and Description for a
+// FieldViolation.
+type violation struct {
+	Field       string            `json:"field,omitempty"`
+	Code        string            `json:"code,omitempty"`
+	Type        string            `json:"type,omitempty"`
+	Subject     string            `json:"subject,omitempty"`
+	Resource    string            `json:"resource,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Template    string            `json:"template,omitempty"`
+	Params      map[string]string `json:"params,omitempty"`
+}
+
+// envelopeOf returns the envelope describing `err`, which must not be nil.
+func envelopeOf(err error) *envelope {
+	code := CodeOf(err)
+	e := &envelope{Version: SchemaVersion, Code: code.String(), Message: err.Error()}
+	walk(err, func(err error) bool {
+		f, ok := err.(Fault)
+		if !ok || f.Code() != code {
+			return true
+		}
+		if u, ok := f.(interface{ Unwrap() error }); ok && u.Unwrap() != nil {
+			e.Cause = u.Unwrap().Error()
+		}
+		return false
+	})
+
+	for _, v := range ViolationsOf(err) {
+		e.Violations = append(e.Violations, violationOf(v))
+	}
+
+	if advice := RetryAdvice(err); advice.Delay > 0 {
+		e.RetryDelay = advice.Delay.Milliseconds()
+	}
+	if info, ok := AsErrorInfo(err); ok {
+		e.Reason = info.Reason
+		e.Domain = info.Domain
+		e.Metadata = info.Metadata
+	}
+	e.Permanent = IsPermanent(err)
+	for _, c := range ChallengesOf(err) {
+		e.Challenges = append(e.Challenges, c.String())
+	}
+	e.Truncated = IsTruncated(err)
+	return e
+}
+
+// violationOf returns the wire representation of `v`.
+func violationOf(v Violation) violation {
+	switch v := v.(type) {
+	case *FieldViolation:
+		return violation{Field: v.Field, Code: v.Code, Description: v.Description, Template: v.Template, Params: v.Params}
+	case *PreconditionViolation:
+		return violation{Type: v.Type, Subject: v.Subject, Description: v.Description}
+	case *ConflictViolation:
+		return violation{Resource: v.Resource, Description: v.Description}
+	case *QuotaViolation:
+		return violation{Subject: v.Subject, Description: v.Description}
+	}
+	return violation{}
+}
+
+// metadataKeys returns the keys of the metadata of `e`, sorted.
+func (e *envelope) metadataKeys() []string {
+	return sortedKeys(e.Metadata)
+}
+
+// paramKeys returns the keys of the parameters of the template of `v`,
+// sorted.
+func (v *violation) paramKeys() []string {
+	return sortedKeys(v.Params)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// fault converts `e` back to the fault it describes. The reason, the
+// permanent marker and the truncation marker wrap the fault, and the message it was encoded with is
+// kept when it differs from the message of the rebuilt fault, e.g. because
+// the fault was wrapped with fmt.Errorf.
+//
+// Categories that are not registered in this process are decoded as plain
+// errors carrying the original message.
+func (e *envelope) fault() error {
+	code, err := ParseCode(e.Code)
+	if err != nil {
+		code = CodeUnknown
+	}
+	var parent error
+	if e.Cause != "" {
+		parent = errors.New(e.Cause)
+	}
+	fault := e.category(code, parent)
+	if fault == nil {
+		message := e.Message
+		if message == "" {
+			message = "faults: " + e.Code
+		}
+		fault = errors.New(message)
+	}
+
+	if e.Reason != "" {
+		fault = WithScopedErrorInfo(fault, e.Domain, e.Reason, e.Metadata)
+	}
+	if e.Permanent {
+		fault = Permanent(fault)
+	}
+	if e.Truncated {
+		fault = Truncated(fault)
+	}
+	if e.Message != "" && fault.Error() != e.Message {
+		fault = &described{error: fault, message: e.Message}
+	}
+	return fault
+}
+
+// check returns an error when `e` does not describe a fault of the category
+// `code`, which is about to be decoded into `target`.
+func (e *envelope) check(code Code, target error) error {
+	if c, err := ParseCode(e.Code); err != nil || c != code {
+		return fmt.Errorf("faults: cannot decode %s fault into %T", e.Code, target)
+	}
+	return nil
+}
+
+// parent returns the error wrapped by the fault described by `e`, with its
+// reason, its permanent marker and its truncation marker, for the fault types
+// that are decoded on their own, since they can't carry them.
+func (e *envelope) parent() error {
+	var parent error
+	if e.Cause != "" {
+		parent = errors.New(e.Cause)
+	}
+	if e.Reason != "" {
+		parent = &ErrorInfo{error: parent, Reason: e.Reason, Domain: e.Domain, Metadata: e.Metadata}
+	}
+	if e.Permanent && parent != nil {
+		parent = &permanent{error: parent}
+	}
+	if e.Truncated && parent != nil {
+		parent = &truncated{error: parent}
+	}
+	return parent
+}
+
+// category returns the fault of the category `code` wrapping `parent`, with
+// the details of `e`. It returns nil when `code` has no fault of its own,
+// e.g. for Unknown.
+func (e *envelope) category(code Code, parent error) error {
+	switch code {
+	case CodeBad:
+		return WithBad(parent, e.fieldViolations()...)
+	case CodeFailedPrecondition:
+		return WithFailedPrecondition(parent, e.preconditionViolations()...)
+	case CodeAborted:
+		return WithAborted(parent, e.conflictViolations()...)
+	case CodeResourceExhausted:
+		return traced(EventWrap, &QuotaFailure{
+			error:      parent,
+			Violations: e.quotaViolations(),
+			RetryInfo:  e.retryInfo(),
+		})
+	case CodeUnavailable:
+		return WithUnavailable(parent, e.retryInfo().RetryDelay)
+	case CodeUnauthenticated:
+		return WithUnauthenticated(parent, e.challenges()...)
+	}
+	if d, ok := Describe(code); ok && d.New != nil {
+		return d.New(parent)
+	}
+	return parent
+}
+
+func (e *envelope) fieldViolations() []*FieldViolation {
+	var violations []*FieldViolation
+	for _, v := range e.Violations {
+		violations = append(violations, &FieldViolation{
+			Field: v.Field, Code: v.Code, Description: v.Description, Template: v.Template, Params: v.Params,
+		})
+	}
+	return violations
+}
+
+func (e *envelope) preconditionViolations() []*PreconditionViolation {
+	var violations []*PreconditionViolation
+	for _, v := range e.Violations {
+		violations = append(violations, &PreconditionViolation{Type: v.Type, Subject: v.Subject, Description: v.Description})
+	}
+	return violations
+}
+
+func (e *envelope) conflictViolations() []*ConflictViolation {
+	var violations []*ConflictViolation
+	for _, v := range e.Violations {
+		violations = append(violations, &ConflictViolation{Resource: v.Resource, Description: v.Description})
+	}
+	return violations
+}
+
+func (e *envelope) quotaViolations() []*QuotaViolation {
+	var violations []*QuotaViolation
+	for _, v := range e.Violations {
+		violations = append(violations, &QuotaViolation{Subject: v.Subject, Description: v.Description})
+	}
+	return violations
+}
+
+func (e *envelope) retryInfo() RetryInfo {
+	if e.RetryDelay <= 0 {
+		return RetryInfo{}
+	}
+	return RetryInfo{RetryDelay: time.Duration(e.RetryDelay) * time.Millisecond}
+}
+
+func (e *envelope) challenges() []Challenge {
+	var challenges []Challenge
+	for _, c := range e.Challenges {
+		challenges = append(challenges, ParseChallenges(c)...)
+	}
+	return challenges
+}
+
+// described restores the message a decoded fault was encoded with.
+type described struct {
+	error
+	message string
+}
+
+func (e *described) Error() string {
+	return e.message
+}
+
+func (e *described) Unwrap() error {
+	return e.error
+}
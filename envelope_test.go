@@ -0,0 +1,163 @@
+//go:build !faults_minimal
+
+package faults_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/deixis/faults"
+)
+
+// TestSchemaVersion ensures the version of the schema is written with each
+// encoded fault.
+func TestSchemaVersion(t *testing.T) {
+	fault := faults.WithNotFound(errors.New("no such user"))
+
+	data, _ := faults.EncodeJSON(fault)
+	var v struct{ Version int }
+	if err := json.Unmarshal(data, &v); err != nil || v.Version != faults.SchemaVersion {
+		t.Errorf("expect JSON version %d, but got %d", faults.SchemaVersion, v.Version)
+	}
+
+	// The version is the last field of the binary encoding (10 << 3 = 0x50)
+	data, _ = faults.Encode(fault)
+	if n := len(data); n < 2 || data[n-2] != 0x50 || data[n-1] != faults.SchemaVersion {
+		t.Errorf("expect binary version %d, but got %x", faults.SchemaVersion, data)
+	}
+
+	data, _ = faults.EncodeCBOR(fault)
+	if !faults.IsNotFound(faults.DecodeCBOR(data)) {
+		t.Errorf("expect CBOR encoding to round trip, but got %x", data)
+	}
+}
+
+// TestForwardCompatibility ensures faults encoded by later versions, with
+// members and categories that are not known to this version, are decoded.
+func TestForwardCompatibility(t *testing.T) {
+	unhex := func(b []byte) []byte {
+		b, _ = hex.DecodeString(string(b))
+		return b
+	}
+
+	table := []struct {
+		Name   string
+		Decode func([]byte) error
+		Future []byte // unknown category "TEAPOT", with an unknown member
+		Known  []byte // violations of BAD_REQUEST, with an unknown member
+	}{
+		{
+			Name:   "JSON",
+			Decode: faults.DecodeJSON,
+			Future: []byte(`{"version":7,"code":"TEAPOT","message":"short and stout","hologram":[1]}`),
+			Known:  []byte(`{"version":7,"code":"BAD_REQUEST","violations":[{"field":"email","pointer":"/email"}]}`),
+		},
+		{
+			Name:   "CBOR",
+			Decode: faults.DecodeCBOR,
+			Future: unhex([]byte("a46776657273696f6e0764636f646566544541504f54676d6573736167656f73686f727420616e642073746f757468686f6c6f6772616d8101")),
+			Known:  unhex([]byte("a36776657273696f6e0764636f64656b4241445f524551554553546a76696f6c6174696f6e7381a2656669656c6465656d61696c67706f696e746572662f656d61696c")),
+		},
+		{
+			// {1: "TEAPOT", 2: "short and stout", 10: 7, 15: "?"}
+			Name:   "binary",
+			Decode: faults.Decode,
+			Future: unhex([]byte("0a06544541504f54120f73686f727420616e642073746f75745007" + "7a013f")),
+			// {1: "BAD_REQUEST", 6: {1: "email", 15: "/email"}, 10: 7}
+			Known: unhex([]byte("0a0b4241445f52455155455354" + "320f0a05656d61696c7a062f656d61696c" + "5007")),
+		},
+	}
+
+	for _, test := range table {
+		err := test.Decode(test.Future)
+		if faults.CodeOf(err) != faults.CodeUnknown || err.Error() != "short and stout" {
+			t.Errorf("%s - expect unknown error with the original message, but got %s %v", test.Name, faults.CodeOf(err), err)
+		}
+
+		err = test.Decode(test.Known)
+		e, ok := faults.AsBad(err)
+		if !ok {
+			t.Errorf("%s - expect bad request, but got %v", test.Name, err)
+			continue
+		}
+		expect := []*faults.FieldViolation{{Field: "email"}}
+		if !reflect.DeepEqual(e.Violations, expect) {
+			t.Errorf("%s - expect violations %v, but got %v", test.Name, expect, e.Violations)
+		}
+	}
+}
+
+// TestDeterministic ensures the encodings of a fault are byte-stable, so they
+// can be compared by contract tests and cached.
+func TestDeterministic(t *testing.T) {
+	metadata := map[string]string{}
+	for i := 0; i < 16; i++ {
+		metadata[fmt.Sprintf("key%02d", i)] = fmt.Sprintf("value%02d", i)
+	}
+	fault := faults.WithErrorInfo(faults.Bad(
+		&faults.FieldViolation{Field: "name", Code: faults.ViolationTooLong, Description: "Too long"},
+		&faults.FieldViolation{Field: "email", Code: faults.ViolationRequired, Description: "Field required"},
+	), "INVALID_FORM", metadata)
+
+	table := []struct {
+		Name   string
+		Encode func(error) ([]byte, error)
+	}{
+		{Name: "binary", Encode: faults.Encode},
+		{Name: "JSON", Encode: faults.EncodeJSON},
+		{Name: "CBOR", Encode: faults.EncodeCBOR},
+		{Name: "text", Encode: faults.EncodeText},
+	}
+
+	for _, test := range table {
+		expect, _ := test.Encode(fault)
+		for i := 0; i < 10; i++ {
+			if data, _ := test.Encode(fault); !bytes.Equal(data, expect) {
+				t.Errorf("%s - expect encoding to be stable, but got %q and %q", test.Name, expect, data)
+				break
+			}
+		}
+	}
+}
+
+// TestEncodeDomain ensures the domains of reasons are carried by every
+// encoding, and that decoded reasons keep their domain, or lack of domain,
+// whatever the domain of the process.
+func TestEncodeDomain(t *testing.T) {
+	table := []struct {
+		Name   string
+		Encode func(error) ([]byte, error)
+		Decode func([]byte) error
+	}{
+		{Name: "binary", Encode: faults.Encode, Decode: faults.Decode},
+		{Name: "JSON", Encode: faults.EncodeJSON, Decode: faults.DecodeJSON},
+		{Name: "CBOR", Encode: faults.EncodeCBOR, Decode: faults.DecodeCBOR},
+		{Name: "text", Encode: faults.EncodeText, Decode: faults.DecodeText},
+	}
+
+	scoped := faults.WithErrorInfo(faults.FailedPrecondition(), "payments.example.com/INSUFFICIENT_FUNDS", map[string]string{"missing": "12.50"})
+	unscoped := faults.WithReason(faults.NotFound, "USER_NOT_FOUND")
+	faults.SetDomain("orders.example.com")
+	defer faults.SetDomain("")
+
+	for _, test := range table {
+		data, _ := test.Encode(scoped)
+		err := test.Decode(data)
+		if faults.ReasonOf(err) != "INSUFFICIENT_FUNDS" || faults.DomainOf(err) != "payments.example.com" {
+			t.Errorf("%s - expect reason payments.example.com/INSUFFICIENT_FUNDS, but got %q in %q", test.Name, faults.ReasonOf(err), faults.DomainOf(err))
+		}
+		if !faults.SameClass(err, scoped) {
+			t.Errorf("%s - expect decoded fault to be of the same class, but got %v", test.Name, err)
+		}
+
+		data, _ = test.Encode(unscoped)
+		if err := test.Decode(data); faults.ReasonOf(err) != "USER_NOT_FOUND" || faults.DomainOf(err) != "" {
+			t.Errorf("%s - expect unscoped reason, but got %q in %q", test.Name, faults.ReasonOf(err), faults.DomainOf(err))
+		}
+	}
+}
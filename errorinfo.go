@@ -0,0 +1,142 @@
+package faults
+
+import "strings"
+
+// ErrorInfo describes the cause of a failure with a stable, machine-readable
+// reason, e.g. "INSUFFICIENT_FUNDS". Where the category of a fault tells how
+// to handle it (retry, fix the request, ...), the reason tells what happened,
+// so clients can react to specific conditions without parsing messages.
+//
+// ErrorInfo is a detail: it does not have a category of its own, and is
+// meant to be attached to a fault with WithReason or WithErrorInfo.
+type ErrorInfo struct {
+	error
+
+	// Reason is the cause of the failure. It should be a constant value,
+	// written in upper snake case, that identifies the cause within the
+	// category of the fault.
+	Reason string
+	// Domain is the namespace of the reason, usually the name of the service
+	// that defines it, e.g. "payments.example.com", so reasons of different
+	// services don't collide once their faults are aggregated. It is empty
+	// for reasons that are not scoped.
+	Domain string
+	// Metadata carries additional structured details about the failure, e.g.
+	// the amount that is missing for an INSUFFICIENT_FUNDS reason.
+	Metadata map[string]string
+}
+
+func (e *ErrorInfo) Error() string {
+	if e.error == nil {
+		return e.QualifiedReason()
+	}
+	return e.error.Error()
+}
+
+func (e *ErrorInfo) Unwrap() error {
+	return e.error
+}
+
+// QualifiedReason returns the reason scoped by its domain, e.g.
+// "payments.example.com/INSUFFICIENT_FUNDS", or the reason alone when it has
+// no domain.
+func (e *ErrorInfo) QualifiedReason() string {
+	if e.Domain == "" {
+		return e.Reason
+	}
+	return e.Domain + "/" + e.Reason
+}
+
+// WithReason attaches the reason `reason` to `parent`, which keeps its
+// category. The reason may be qualified by its domain, e.g.
+// "payments.example.com/INSUFFICIENT_FUNDS", otherwise it belongs to the
+// domain of the process (see SetDomain).
+//
+// Example:
+//
+//	return faults.WithReason(faults.FailedPrecondition(), "INSUFFICIENT_FUNDS")
+func WithReason(parent error, reason string) error {
+	domain, reason := splitReason(reason)
+	return traced(EventWrap, &ErrorInfo{error: hopped(parent, 2), Reason: reason, Domain: domain})
+}
+
+// WithErrorInfo attaches the reason `reason` and its metadata to `parent`,
+// which keeps its category. Like with WithReason, the reason may be qualified
+// by its domain.
+func WithErrorInfo(parent error, reason string, metadata map[string]string) error {
+	domain, reason := splitReason(reason)
+	return traced(EventWrap, &ErrorInfo{error: hopped(parent, 2), Reason: reason, Domain: domain, Metadata: metadata})
+}
+
+// WithScopedErrorInfo attaches the reason `reason` of the domain `domain`,
+// and its metadata, to `parent`, which keeps its category. Unlike
+// WithErrorInfo, the domain of the process doesn't apply, so it is meant for
+// decoders rebuilding the reasons of faults received from other services,
+// whose domain, if any, is their own.
+func WithScopedErrorInfo(parent error, domain, reason string, metadata map[string]string) error {
+	return traced(EventWrap, &ErrorInfo{error: hopped(parent, 2), Reason: reason, Domain: domain, Metadata: metadata})
+}
+
+// SetDomain sets the domain of the reasons attached by the process, e.g.
+// "payments.example.com", to the reasons that are not qualified with a
+// domain of their own (see WithReason). It is empty by default, and is meant
+// to be set once during initialisation:
+//
+//	func main() {
+//		faults.SetDomain("payments.example.com")
+//		// ...
+//	}
+//
+// Reasons attached before the call keep their domain.
+func SetDomain(d string) {
+	configure(func(c *Config) { c.Domain = d })
+}
+
+// splitReason returns the domain and the reason of the qualified reason
+// `reason`, which is split at its last slash, or the domain of the process
+// when `reason` is not qualified.
+func splitReason(reason string) (string, string) {
+	if i := strings.LastIndexByte(reason, '/'); i >= 0 {
+		return reason[:i], reason[i+1:]
+	}
+	if d := settings().Domain; d != "" {
+		return d, reason
+	}
+	return "", reason
+}
+
+// AsErrorInfo finds the first ErrorInfo in the tree of `err`.
+func AsErrorInfo(err error) (*ErrorInfo, bool) {
+	return as[*ErrorInfo](err)
+}
+
+// ReasonOf returns the reason attached to `err` (see ErrorInfo), or an empty
+// string when there is none.
+func ReasonOf(err error) string {
+	if e, ok := AsErrorInfo(err); ok {
+		return e.Reason
+	}
+	return ""
+}
+
+// DomainOf returns the domain of the reason attached to `err` (see
+// ErrorInfo), or an empty string when there is none, or when the reason is
+// not scoped.
+func DomainOf(err error) string {
+	if e, ok := AsErrorInfo(err); ok {
+		return e.Domain
+	}
+	return ""
+}
+
+// SameClass reports whether `a` and `b` belong to the same class of errors,
+// i.e. whether they have the same category (see CodeOf) and the same reason
+// in the same domain (see ReasonOf and DomainOf). Messages, violations and
+// any other detail are ignored.
+//
+// It is meant for tests asserting that a system fails the same way across
+// runs, e.g. under chaos or integration testing, where messages carry
+// identifiers that change from one run to another.
+func SameClass(a, b error) bool {
+	return CodeOf(a) == CodeOf(b) && ReasonOf(a) == ReasonOf(b) && DomainOf(a) == DomainOf(b)
+}
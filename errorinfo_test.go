@@ -0,0 +1,161 @@
+package faults_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/deixis/faults"
+)
+
+// TestErrorInfo ensures reasons can be attached to faults without changing
+// their category or their message.
+func TestErrorInfo(t *testing.T) {
+	fault := faults.FailedPrecondition()
+	err := faults.WithErrorInfo(fault, "INSUFFICIENT_FUNDS", map[string]string{"missing": "12.50"})
+
+	if code := faults.CodeOf(err); code != faults.CodeFailedPrecondition {
+		t.Errorf("expect code %s, but got %s", faults.CodeFailedPrecondition, code)
+	}
+	if err.Error() != fault.Error() {
+		t.Errorf("expect message %q, but got %q", fault, err)
+	}
+	if reason := faults.ReasonOf(fmt.Errorf("wrapped: %w", err)); reason != "INSUFFICIENT_FUNDS" {
+		t.Errorf("expect reason INSUFFICIENT_FUNDS, but got %q", reason)
+	}
+	if e, ok := faults.AsErrorInfo(err); !ok || e.Metadata["missing"] != "12.50" {
+		t.Errorf("expect metadata to be attached, but got %v", e)
+	}
+	if !errors.Is(err, fault) {
+		t.Error("expect fault to be wrapped")
+	}
+	if reason := faults.ReasonOf(faults.NotFound); reason != "" {
+		t.Errorf("expect no reason, but got %q", reason)
+	}
+}
+
+// TestDomain ensures reasons can be scoped by a domain, given with the
+// reason.
+func TestDomain(t *testing.T) {
+	table := []struct {
+		Error          error
+		Reason, Domain string
+		Qualified      string
+	}{
+		{
+			Error:     faults.WithReason(faults.NotFound, "USER_NOT_FOUND"),
+			Reason:    "USER_NOT_FOUND",
+			Qualified: "USER_NOT_FOUND",
+		},
+		{
+			Error:     faults.WithReason(faults.FailedPrecondition(), "payments.example.com/INSUFFICIENT_FUNDS"),
+			Reason:    "INSUFFICIENT_FUNDS",
+			Domain:    "payments.example.com",
+			Qualified: "payments.example.com/INSUFFICIENT_FUNDS",
+		},
+		{
+			Error:     faults.WithErrorInfo(faults.Aborted(), "example.com/orders/LOCKED", map[string]string{"id": "42"}),
+			Reason:    "LOCKED",
+			Domain:    "example.com/orders",
+			Qualified: "example.com/orders/LOCKED",
+		},
+		{
+			Error:     faults.WithScopedErrorInfo(faults.Internal, "db.example.com", "DB_DOWN", nil),
+			Reason:    "DB_DOWN",
+			Domain:    "db.example.com",
+			Qualified: "db.example.com/DB_DOWN",
+		},
+		{
+			Error:     faults.Build(faults.CodeUnavailable).Reason("search.example.com/INDEX_REBUILD", nil).Err(),
+			Reason:    "INDEX_REBUILD",
+			Domain:    "search.example.com",
+			Qualified: "search.example.com/INDEX_REBUILD",
+		},
+		{Error: faults.NotFound},
+	}
+
+	for i, test := range table {
+		if reason := faults.ReasonOf(test.Error); reason != test.Reason {
+			t.Errorf("%d - expect reason %q, but got %q", i, test.Reason, reason)
+		}
+		if domain := faults.DomainOf(test.Error); domain != test.Domain {
+			t.Errorf("%d - expect domain %q, but got %q", i, test.Domain, domain)
+		}
+		if e, ok := faults.AsErrorInfo(test.Error); ok && e.QualifiedReason() != test.Qualified {
+			t.Errorf("%d - expect qualified reason %q, but got %q", i, test.Qualified, e.QualifiedReason())
+		}
+	}
+}
+
+// TestSetDomain ensures the domain of the process scopes the reasons that
+// are not qualified, but not the ones with a domain of their own.
+func TestSetDomain(t *testing.T) {
+	before := faults.WithReason(faults.NotFound, "USER_NOT_FOUND")
+	faults.SetDomain("accounts.example.com")
+	defer faults.SetDomain("")
+
+	table := []struct {
+		Error  error
+		Domain string
+	}{
+		{Error: before, Domain: ""},
+		{Error: faults.WithReason(faults.NotFound, "USER_NOT_FOUND"), Domain: "accounts.example.com"},
+		{Error: faults.WithErrorInfo(faults.NotFound, "USER_NOT_FOUND", nil), Domain: "accounts.example.com"},
+		{Error: faults.Build(faults.CodeNotFound).Reason("USER_NOT_FOUND", nil).Err(), Domain: "accounts.example.com"},
+		{Error: faults.WithReason(faults.NotFound, "users.example.com/USER_NOT_FOUND"), Domain: "users.example.com"},
+		{Error: faults.WithScopedErrorInfo(faults.NotFound, "", "USER_NOT_FOUND", nil), Domain: ""},
+		{Error: faults.Sanitize(faults.WithScopedErrorInfo(faults.Internal, "", "DB_DOWN", nil)), Domain: ""},
+	}
+
+	for i, test := range table {
+		if domain := faults.DomainOf(test.Error); domain != test.Domain {
+			t.Errorf("%d - expect domain %q, but got %q", i, test.Domain, domain)
+		}
+	}
+}
+
+// TestSameClass ensures errors are compared by category, reason and domain
+// only.
+func TestSameClass(t *testing.T) {
+	table := []struct {
+		A, B error
+		Same bool
+	}{
+		{A: nil, B: nil, Same: true},
+		{A: nil, B: faults.NotFound, Same: false},
+		{A: errors.New("a"), B: errors.New("b"), Same: true},
+		{A: faults.NotFound, B: faults.WithNotFound(errors.New("user 42")), Same: true},
+		{A: faults.NotFound, B: faults.PermissionDenied, Same: false},
+		{
+			A:    faults.Bad(&faults.FieldViolation{Field: "email"}),
+			B:    faults.Bad(&faults.FieldViolation{Field: "name"}),
+			Same: true,
+		},
+		{
+			A:    faults.WithReason(faults.Aborted(), "VERSION_MISMATCH"),
+			B:    fmt.Errorf("order 42: %w", faults.WithReason(faults.Aborted(), "VERSION_MISMATCH")),
+			Same: true,
+		},
+		{
+			A:    faults.WithReason(faults.Aborted(), "VERSION_MISMATCH"),
+			B:    faults.WithReason(faults.Aborted(), "LOCKED"),
+			Same: false,
+		},
+		{
+			A:    faults.WithReason(faults.Aborted(), "VERSION_MISMATCH"),
+			B:    faults.Aborted(),
+			Same: false,
+		},
+		{
+			A:    faults.WithReason(faults.Aborted(), "orders.example.com/VERSION_MISMATCH"),
+			B:    faults.WithReason(faults.Aborted(), "carts.example.com/VERSION_MISMATCH"),
+			Same: false,
+		},
+	}
+
+	for i, test := range table {
+		if same := faults.SameClass(test.A, test.B); same != test.Same {
+			t.Errorf("%d - expect same class %t, but got %t", i, test.Same, same)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultshttp"
+)
+
+// A Client calls the HTTP API of the inventory. Failures are returned as
+// faults, and retried when they are retryable.
+type Client struct {
+	BaseURL string
+	HTTP    *http.Client
+	Backoff faults.Backoff
+	// MaxAttempts is the maximum number of attempts of a call.
+	MaxAttempts int
+}
+
+// Get returns the item `id`.
+func (c *Client) Get(ctx context.Context, id string) (Item, error) {
+	var item Item
+	err := c.do(ctx, "GET", "/items/"+url.PathEscape(id), &item)
+	return item, err
+}
+
+// Reserve reserves `quantity` units of the item `id`.
+func (c *Client) Reserve(ctx context.Context, id string, quantity int) (Item, error) {
+	var item Item
+	path := "/items/" + url.PathEscape(id) + "/reserve?quantity=" + strconv.Itoa(quantity)
+	err := c.do(ctx, "POST", path, &item)
+	return item, err
+}
+
+func (c *Client) do(ctx context.Context, method, path string, v interface{}) error {
+	for attempt := 0; ; attempt++ {
+		err := c.call(ctx, method, path, v)
+		if err == nil || attempt+1 >= c.MaxAttempts {
+			return err
+		}
+
+		delay, ok := c.Backoff.Next(err, attempt)
+		if !ok {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (c *Client) call(ctx context.Context, method, path string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return faults.WithUnavailable(err, 0)
+	}
+	defer resp.Body.Close()
+
+	if err := faultshttp.ReadProblem(resp); err != nil {
+		return err
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultsgrpc"
+	"github.com/deixis/faults/faultstest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func newStore(warmUp int) *Store {
+	return NewStore(warmUp,
+		Item{ID: "apple", Name: "Apple", Stock: 10},
+		Item{ID: "pear", Name: "Pear", Stock: 0},
+	)
+}
+
+func newClient(srv *httptest.Server, maxAttempts int) *Client {
+	return &Client{
+		BaseURL:     srv.URL,
+		HTTP:        srv.Client(),
+		Backoff:     faults.Backoff{Base: time.Millisecond, Max: 10 * time.Millisecond},
+		MaxAttempts: maxAttempts,
+	}
+}
+
+// TestHTTP ensures faults cross the HTTP API with their category, reason and
+// details, and that clients retry while the service is warming up.
+func TestHTTP(t *testing.T) {
+	metrics := NewMetrics()
+	srv := httptest.NewServer(NewHandler(newStore(2), metrics))
+	defer srv.Close()
+	client := newClient(srv, 3)
+	ctx := context.Background()
+
+	// The first two calls fail while the store warms up
+	item, err := client.Get(ctx, "apple")
+	if err != nil {
+		t.Fatalf("expect call to be retried until it succeeds, but got %s", err)
+	}
+	if item.Stock != 10 {
+		t.Errorf("expect 10 units in stock, but got %d", item.Stock)
+	}
+	if n := metrics.Rendered(faults.CodeUnavailable); n != 2 {
+		t.Errorf("expect 2 unavailable faults to be rendered, but got %d", n)
+	}
+
+	_, err = client.Get(ctx, "banana")
+	if !faults.IsNotFound(err) || faults.ReasonOf(err) != "ITEM_NOT_FOUND" {
+		t.Errorf("expect ITEM_NOT_FOUND, but got %s (%v)", faults.CodeOf(err), err)
+	}
+
+	_, err = client.Reserve(ctx, "pear", 1)
+	faultstest.AssertContract(t, faultstest.Contract{
+		Code:       faults.CodeFailedPrecondition,
+		Reason:     "OUT_OF_STOCK",
+		Violations: []string{"item:pear"},
+		HTTPStatus: http.StatusBadRequest,
+	}, err)
+
+	_, err = client.Reserve(ctx, "apple", 0)
+	faultstest.AssertContract(t, faultstest.Contract{
+		Code:       faults.CodeBad,
+		Violations: []string{"quantity"},
+	}, err)
+
+	item, err = client.Reserve(ctx, "apple", 3)
+	if err != nil || item.Stock != 7 {
+		t.Errorf("expect 7 units in stock, but got %d (%v)", item.Stock, err)
+	}
+}
+
+// TestSanitization ensures the causes of server faults are not rendered.
+func TestSanitization(t *testing.T) {
+	srv := httptest.NewServer(NewHandler(newStore(1), NewMetrics()))
+	defer srv.Close()
+
+	resp, err := srv.Client().Get(srv.URL + "/items/apple")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expect status 503, but got %d", resp.StatusCode)
+	}
+	if strings.Contains(string(body), "10.0.0.12") {
+		t.Errorf("expect cause not to be rendered, but got %s", body)
+	}
+}
+
+// TestMetrics ensures rendered faults are exposed by category.
+func TestMetrics(t *testing.T) {
+	srv := httptest.NewServer(NewHandler(newStore(0), NewMetrics()))
+	defer srv.Close()
+	client := newClient(srv, 1)
+
+	client.Get(context.Background(), "banana")
+	client.Get(context.Background(), "cherry")
+
+	resp, err := srv.Client().Get(srv.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	if expect := `faults_rendered_total{code="NOT_FOUND"} 2`; !strings.Contains(string(body), expect) {
+		t.Errorf("expect metrics to contain %s, but got %s", expect, body)
+	}
+}
+
+// TestGRPC ensures gRPC clients wait for the delay advised by the service
+// before checking its health again.
+func TestGRPC(t *testing.T) {
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	grpc_health_v1.RegisterHealthServer(srv, &HealthServer{Store: newStore(2)})
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	policies := faultsgrpc.RetryPolicies{"": {
+		MaxAttempts:          3,
+		InitialBackoff:       time.Hour, // overridden by the delay advised by the service
+		RetryableStatusCodes: []codes.Code{codes.Unavailable},
+	}}
+	conn, err := grpc.NewClient("passthrough:///inventory",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDisableRetry(),
+		grpc.WithUnaryInterceptor(faultsgrpc.UnaryClientRetryInterceptor(policies)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	resp, err := grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("expect check to be retried until it succeeds, but got %s", err)
+	}
+	if resp.Status != grpc_health_v1.HealthCheckResponse_SERVING {
+		t.Errorf("expect service to be serving, but got %s", resp.Status)
+	}
+}
@@ -0,0 +1,30 @@
+// Command inventory is a reference service wired with faults. It serves an
+// HTTP API rendering faults as problem details documents, and a gRPC health
+// service advising clients when to retry.
+package main
+
+import (
+	"log"
+	"net"
+	"net/http"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func main() {
+	store := NewStore(3,
+		Item{ID: "apple", Name: "Apple", Stock: 10},
+		Item{ID: "pear", Name: "Pear", Stock: 0},
+	)
+
+	lis, err := net.Listen("tcp", ":8081")
+	if err != nil {
+		log.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	grpc_health_v1.RegisterHealthServer(srv, &HealthServer{Store: store})
+	go srv.Serve(lis)
+
+	log.Fatal(http.ListenAndServe(":8080", NewHandler(store, NewMetrics())))
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/deixis/faults"
+)
+
+// Metrics counts the faults rendered by the service, by category. It is a
+// trace sink attached to every request by its middleware.
+type Metrics struct {
+	mu       sync.Mutex
+	rendered map[faults.Code]int
+}
+
+// NewMetrics returns empty metrics.
+func NewMetrics() *Metrics {
+	return &Metrics{rendered: map[faults.Code]int{}}
+}
+
+// Trace counts rendered faults.
+func (m *Metrics) Trace(ev faults.Event) {
+	if ev.Kind != faults.EventRender {
+		return
+	}
+	m.mu.Lock()
+	m.rendered[ev.Code]++
+	m.mu.Unlock()
+}
+
+// Rendered returns the number of faults of the category `code` rendered so
+// far.
+func (m *Metrics) Rendered(code faults.Code) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.rendered[code]
+}
+
+// Middleware reports the faults rendered while serving requests to `m`.
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(faults.ContextWithTrace(r.Context(), m)))
+	})
+}
+
+// ServeHTTP exposes the metrics in the Prometheus text format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	codes := make([]faults.Code, 0, len(m.rendered))
+	for code := range m.rendered {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i] < codes[j] })
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	for _, code := range codes {
+		fmt.Fprintf(w, "faults_rendered_total{code=%q} %d\n", code, m.rendered[code])
+	}
+}
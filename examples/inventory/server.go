@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultsgrpc"
+	"github.com/deixis/faults/faultshttp"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// NewHandler returns the HTTP API of the inventory. Failures are rendered as
+// problem details documents, and counted by `metrics`.
+func NewHandler(store *Store, metrics *Metrics) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		item, err := store.Get(r.PathValue("id"))
+		if err != nil {
+			faultshttp.WriteProblem(w, r, err)
+			return
+		}
+		writeJSON(w, item)
+	})
+	mux.HandleFunc("POST /items/{id}/reserve", func(w http.ResponseWriter, r *http.Request) {
+		quantity, err := strconv.Atoi(r.URL.Query().Get("quantity"))
+		if err != nil {
+			faultshttp.WriteProblem(w, r, faults.WithBad(err, &faults.FieldViolation{
+				Field:       "quantity",
+				Description: "Must be a number",
+			}))
+			return
+		}
+
+		item, err := store.Reserve(r.PathValue("id"), quantity)
+		if err != nil {
+			faultshttp.WriteProblem(w, r, err)
+			return
+		}
+		writeJSON(w, item)
+	})
+	mux.Handle("GET /metrics", metrics)
+	return metrics.Middleware(mux)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// HealthServer is the gRPC health service of the inventory. It reports the
+// service as unavailable while the store is warming up, with the delay after
+// which clients should check again.
+type HealthServer struct {
+	grpc_health_v1.UnimplementedHealthServer
+
+	Store *Store
+}
+
+func (s *HealthServer) Check(ctx context.Context, req *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	s.Store.mu.Lock()
+	err := s.Store.ready()
+	s.Store.mu.Unlock()
+
+	if err != nil {
+		return nil, faultsgrpc.ToStatus(err).Err()
+	}
+	return &grpc_health_v1.HealthCheckResponse{
+		Status: grpc_health_v1.HealthCheckResponse_SERVING,
+	}, nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/deixis/faults"
+)
+
+// An Item is an item of the inventory.
+type Item struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Stock int    `json:"stock"`
+}
+
+// A Store keeps the inventory in memory. It simulates a database that fails
+// while it is warming up, so clients have to retry.
+type Store struct {
+	mu     sync.Mutex
+	items  map[string]*Item
+	warmUp int
+}
+
+// NewStore returns a store holding `items`, which fails the first `warmUp`
+// calls.
+func NewStore(warmUp int, items ...Item) *Store {
+	s := &Store{items: map[string]*Item{}, warmUp: warmUp}
+	for i := range items {
+		item := items[i]
+		s.items[item.ID] = &item
+	}
+	return s
+}
+
+// Get returns the item `id`.
+func (s *Store) Get(id string) (Item, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.ready(); err != nil {
+		return Item{}, err
+	}
+	item, ok := s.items[id]
+	if !ok {
+		return Item{}, faults.WithReason(faults.NotFound, "ITEM_NOT_FOUND")
+	}
+	return *item, nil
+}
+
+// Reserve reserves `quantity` units of the item `id`.
+func (s *Store) Reserve(id string, quantity int) (Item, error) {
+	if quantity <= 0 {
+		return Item{}, faults.Bad(&faults.FieldViolation{
+			Field:       "quantity",
+			Description: "Must be a positive number",
+		})
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.ready(); err != nil {
+		return Item{}, err
+	}
+	item, ok := s.items[id]
+	if !ok {
+		return Item{}, faults.WithReason(faults.NotFound, "ITEM_NOT_FOUND")
+	}
+	if item.Stock < quantity {
+		return Item{}, faults.WithReason(faults.FailedPrecondition(&faults.PreconditionViolation{
+			Type:        "STOCK",
+			Subject:     "item:" + id,
+			Description: "Not enough units in stock",
+		}), "OUT_OF_STOCK")
+	}
+	item.Stock -= quantity
+	return *item, nil
+}
+
+// ready fails while the store is warming up. The cause of the failure is an
+// implementation detail that must not be rendered to clients.
+func (s *Store) ready() error {
+	if s.warmUp <= 0 {
+		return nil
+	}
+	s.warmUp--
+	return faults.WithUnavailable(
+		errors.New("dial tcp 10.0.0.12:5432: connect: connection refused"),
+		10*time.Millisecond,
+	)
+}
@@ -8,6 +8,16 @@
 // The reason for the name change to `faults` is to avoid issues with linters
 // that don't validate `errors.Is` and `errors.As` issues when the package
 // is not the standard `errors` package.
+//
+// Optional features that pull heavy dependencies, such as structured logging,
+// are excluded from the build with the `faults_minimal` tag. The remaining core
+// only depends on a handful of standard packages, which keeps binaries small
+// for embedded and WebAssembly targets. Integrations with third-party
+// libraries live in their own modules (e.g. `faultszap`), so they are only
+// compiled, and only added to the module graph, when they are imported.
+//
+// TinyGo builds, or builds with the `faults_noreflect` tag, inspect error
+// chains with type assertions instead of the reflection used by `errors.As`.
 package faults
 
 import (
@@ -36,50 +46,96 @@ var (
 
 	// Unimplemented indicates the operation is not implemented or not supported
 	Unimplemented error = &UnimplementedFailure{}
+
+	// Internal indicates an invariant expected by the underlying system has
+	// been broken. It is reserved for serious errors that the caller cannot do
+	// anything about.
+	Internal error = &InternalFailure{}
+
+	// DeadlineExceeded means the operation expired before completion. For
+	// operations that change the state of the system, this error may be
+	// returned even if the operation has completed successfully.
+	DeadlineExceeded error = &DeadlineFailure{}
 )
 
-// WithPermissionDenied wraps `parent` with a `PermissionFailure`
-func WithPermissionDenied(parent error) error {
-	return &PermissionFailure{parent}
+// WithPermissionDenied wraps `parent` with a `PermissionFailure`, with the
+// details set by `opts`
+func WithPermissionDenied(parent error, opts ...Option) error {
+	if len(opts) > 0 {
+		return built(CodePermissionDenied, parent, opts)
+	}
+	return traced(EventWrap, &PermissionFailure{hopped(parent, 2)})
 }
 
-// WithUnauthenticated wraps `parent` with an `AuthenticationFailure`
-func WithUnauthenticated(parent error) error {
-	return &AuthenticationFailure{parent}
+// WithUnauthenticated wraps `parent` with an `AuthenticationFailure`, with
+// the challenges the caller may answer to authenticate
+func WithUnauthenticated(parent error, challenges ...Challenge) error {
+	return traced(EventWrap, &AuthenticationFailure{hopped(parent, 2), challenges})
 }
 
-// WithNotFound wraps `parent` with a `MissingFailure`
-func WithNotFound(parent error) error {
-	return &MissingFailure{parent}
+// WithNotFound wraps `parent` with a `MissingFailure`, with the details set
+// by `opts`
+func WithNotFound(parent error, opts ...Option) error {
+	if len(opts) > 0 {
+		return built(CodeNotFound, parent, opts)
+	}
+	return traced(EventWrap, &MissingFailure{hopped(parent, 2)})
 }
 
 // WithBad wraps `parent` with a `BadRequest`
 func WithBad(parent error, violations ...*FieldViolation) error {
-	return &BadRequest{parent, violations}
+	return traced(EventWrap, &BadRequest{hopped(parent, 2), violations})
 }
 
 // WithFailedPrecondition wraps `parent` with a `PreconditionFailure`
 func WithFailedPrecondition(parent error, violations ...*PreconditionViolation) error {
-	return &PreconditionFailure{parent, violations}
+	return traced(EventWrap, &PreconditionFailure{hopped(parent, 2), violations})
 }
 
 // WithAborted wraps `parent` with a `ConflictFailure`
 func WithAborted(parent error, violations ...*ConflictViolation) error {
-	return &ConflictFailure{parent, violations}
+	return traced(EventWrap, &ConflictFailure{hopped(parent, 2), violations})
 }
 
-// WithUnavailable wraps `parent` with an `AvailabilityFailure`
-func WithUnavailable(parent error, retryDelay time.Duration) error {
-	return &AvailabilityFailure{parent, RetryInfo{RetryDelay: retryDelay}}
+// WithUnavailable wraps `parent` with an `AvailabilityFailure`, with the
+// details set by `opts`
+func WithUnavailable(parent error, retryDelay time.Duration, opts ...Option) error {
+	if len(opts) > 0 {
+		return built(CodeUnavailable, parent, append([]Option{WithRetry(retryDelay)}, opts...))
+	}
+	return traced(EventWrap, &AvailabilityFailure{hopped(parent, 2), RetryInfo{RetryDelay: retryDelay}})
 }
 
 // WithResourceExhausted wraps `parent` with a `QuotaFailure`
 func WithResourceExhausted(parent error, violations ...*QuotaViolation) error {
-	return &QuotaFailure{parent, violations}
+	return traced(EventWrap, &QuotaFailure{error: hopped(parent, 2), Violations: violations})
 }
 
-func WithUnimplemented(parent error) error {
-	return &UnimplementedFailure{parent}
+// WithUnimplemented wraps `parent` with an `UnimplementedFailure`, with the
+// details set by `opts`
+func WithUnimplemented(parent error, opts ...Option) error {
+	if len(opts) > 0 {
+		return built(CodeUnimplemented, parent, opts)
+	}
+	return traced(EventWrap, &UnimplementedFailure{hopped(parent, 2)})
+}
+
+// WithInternal wraps `parent` with an `InternalFailure`, with the details set
+// by `opts`
+func WithInternal(parent error, opts ...Option) error {
+	if len(opts) > 0 {
+		return built(CodeInternal, parent, opts)
+	}
+	return traced(EventWrap, &InternalFailure{hopped(parent, 2)})
+}
+
+// WithDeadlineExceeded wraps `parent` with a `DeadlineFailure`, with the
+// details set by `opts`
+func WithDeadlineExceeded(parent error, opts ...Option) error {
+	if len(opts) > 0 {
+		return built(CodeDeadlineExceeded, parent, opts)
+	}
+	return traced(EventWrap, &DeadlineFailure{hopped(parent, 2)})
 }
 
 // Bad indicates client specified an invalid argument.
@@ -87,7 +143,7 @@ func WithUnimplemented(parent error) error {
 // that are problematic regardless of the state of the system
 // (e.g., a malformed file name).
 func Bad(violations ...*FieldViolation) error {
-	return &BadRequest{Violations: violations}
+	return traced(EventCreate, &BadRequest{Violations: violations})
 }
 
 // FailedPrecondition indicates operation was rejected because the
@@ -111,7 +167,7 @@ func Bad(violations ...*FieldViolation) error {
 //	    server does not match the condition. E.g., conflicting
 //	    read-modify-write on the same resource.
 func FailedPrecondition(violations ...*PreconditionViolation) error {
-	return &PreconditionFailure{Violations: violations}
+	return traced(EventCreate, &PreconditionFailure{Violations: violations})
 }
 
 // Aborted indicates the operation was aborted, typically due to a
@@ -121,7 +177,7 @@ func FailedPrecondition(violations ...*PreconditionViolation) error {
 // See litmus test above for deciding between FailedPrecondition,
 // Aborted, and Unavailable.
 func Aborted(violations ...*ConflictViolation) error {
-	return &ConflictFailure{Violations: violations}
+	return traced(EventCreate, &ConflictFailure{Violations: violations})
 }
 
 // Unavailable indicates the service is currently unavailable.
@@ -130,14 +186,20 @@ func Aborted(violations ...*ConflictViolation) error {
 //
 // See litmus test above for deciding between FailedPrecondition,
 // Aborted, and Unavailable.
-func Unavailable(retryDelay time.Duration) error {
-	return &AvailabilityFailure{RetryInfo: RetryInfo{RetryDelay: retryDelay}}
+//
+// Other details can be set with `opts`, e.g. links to documentation with
+// WithHelp.
+func Unavailable(retryDelay time.Duration, opts ...Option) error {
+	if len(opts) > 0 {
+		return built(CodeUnavailable, nil, append([]Option{WithRetry(retryDelay)}, opts...))
+	}
+	return traced(EventCreate, &AvailabilityFailure{RetryInfo: RetryInfo{RetryDelay: retryDelay}})
 }
 
 // ResourceExhausted indicates some resource has been exhausted, perhaps
 // a per-user quota, or perhaps the entire file system is out of space.
 func ResourceExhausted(violations ...*QuotaViolation) error {
-	return &QuotaFailure{Violations: violations}
+	return traced(EventCreate, &QuotaFailure{Violations: violations})
 }
 
 func IsPermissionDenied(err error) bool {
@@ -176,76 +238,85 @@ func IsUnimplemented(err error) bool {
 	return errors.Is(err, &UnimplementedFailure{})
 }
 
+func IsInternal(err error) bool {
+	return errors.Is(err, &InternalFailure{})
+}
+
+func IsDeadlineExceeded(err error) bool {
+	return errors.Is(err, &DeadlineFailure{})
+}
+
 func AsPermissionDenied(err error) (*PermissionFailure, bool) {
-	e := &PermissionFailure{}
-	if errors.As(err, &e) {
-		return e, true
-	}
-	return nil, false
+	return as[*PermissionFailure](err)
 }
 
 func AsUnauthenticated(err error) (*AuthenticationFailure, bool) {
-	e := &AuthenticationFailure{}
-	if errors.As(err, &e) {
-		return e, true
-	}
-	return nil, false
+	return as[*AuthenticationFailure](err)
 }
 
 func AsNotFound(err error) (*MissingFailure, bool) {
-	e := &MissingFailure{}
-	if errors.As(err, &e) {
-		return e, true
-	}
-	return nil, false
+	return as[*MissingFailure](err)
 }
 
 func AsBad(err error) (*BadRequest, bool) {
-	e := &BadRequest{}
-	if errors.As(err, &e) {
-		return e, true
-	}
-	return nil, false
+	return as[*BadRequest](err)
 }
 
 func AsFailedPrecondition(err error) (*PreconditionFailure, bool) {
-	e := &PreconditionFailure{}
-	if errors.As(err, &e) {
-		return e, true
-	}
-	return nil, false
+	return as[*PreconditionFailure](err)
 }
 
 func AsAborted(err error) (*ConflictFailure, bool) {
-	e := &ConflictFailure{}
-	if errors.As(err, &e) {
-		return e, true
-	}
-	return nil, false
+	return as[*ConflictFailure](err)
 }
 
 func AsUnavailable(err error) (*AvailabilityFailure, bool) {
-	e := &AvailabilityFailure{}
-	if errors.As(err, &e) {
-		return e, true
-	}
-	return nil, false
+	return as[*AvailabilityFailure](err)
 }
 
 func AsResourceExhausted(err error) (*QuotaFailure, bool) {
-	e := &QuotaFailure{}
-	if errors.As(err, &e) {
-		return e, true
-	}
-	return nil, false
+	return as[*QuotaFailure](err)
 }
 
 func AsUnimplemented(err error) (*UnimplementedFailure, bool) {
-	e := &UnimplementedFailure{}
-	if errors.As(err, &e) {
-		return e, true
-	}
-	return nil, false
+	return as[*UnimplementedFailure](err)
+}
+
+func AsInternal(err error) (*InternalFailure, bool) {
+	return as[*InternalFailure](err)
+}
+
+func AsDeadlineExceeded(err error) (*DeadlineFailure, bool) {
+	return as[*DeadlineFailure](err)
+}
+
+// IsType reports whether any error in the tree of `err` is of type `T`. It is
+// a generic alternative to the `Is*` functions, which also works with custom
+// fault types.
+//
+// Example:
+//
+//	if faults.IsType[*PaymentFailure](err) {
+//		// ...
+//	}
+func IsType[T error](err error) bool {
+	_, ok := as[T](err)
+	return ok
+}
+
+// AsType finds the first error in the tree of `err` that is of type `T`. It is
+// a generic alternative to the `As*` functions, which also works with custom
+// fault types.
+//
+// Example:
+//
+//	if e, ok := faults.AsType[*faults.BadRequest](err); ok {
+//		for _, v := range e.Violations {
+//			// ...
+//		}
+//	}
+func AsType[T error](err error) (T, bool) {
+	return as[T](err)
 }
 
 // AvailabilityFailure indicates that the service is currently unavailable.
@@ -272,6 +343,10 @@ func (e *AvailabilityFailure) Unwrap() error {
 	return e.error
 }
 
+func (e *AvailabilityFailure) Code() Code {
+	return CodeUnavailable
+}
+
 // Describes how a quota check failed.
 //
 // For example if a daily limit was exceeded for the calling project,
@@ -287,6 +362,10 @@ type QuotaFailure struct {
 
 	// Describes all quota violations.
 	Violations []*QuotaViolation
+
+	// RetryInfo is optional. When set, it advises the caller when the quota
+	// is expected to be replenished.
+	RetryInfo RetryInfo
 }
 
 func (e *QuotaFailure) Error() string {
@@ -310,6 +389,10 @@ func (e *QuotaFailure) Unwrap() error {
 	return e.error
 }
 
+func (e *QuotaFailure) Code() Code {
+	return CodeResourceExhausted
+}
+
 // A message type used to describe a single quota violation. For example, a
 // daily quota or a custom quota that was exceeded.
 type QuotaViolation struct {
@@ -331,6 +414,8 @@ func (v *QuotaViolation) String() string {
 	return strings.Join([]string{v.Subject, v.Description}, " - ")
 }
 
+func (v *QuotaViolation) violation() {}
+
 // Describes what preconditions have failed.
 //
 // For example, if an RPC failed because it required the Terms of Service to be
@@ -364,6 +449,10 @@ func (e *PreconditionFailure) Unwrap() error {
 	return e.error
 }
 
+func (e *PreconditionFailure) Code() Code {
+	return CodeFailedPrecondition
+}
+
 // A message type used to describe a single precondition failure.
 type PreconditionViolation struct {
 	// The type of PreconditionFailure. We recommend using a service-specific
@@ -379,12 +468,17 @@ type PreconditionViolation struct {
 	//
 	// For example: "Terms of service not accepted".
 	Description string
+	// Source is where the violation was produced, when its constructor
+	// recorded it (see SetRecordHops). It is not encoded by transports.
+	Source *Frame
 }
 
 func (v *PreconditionViolation) String() string {
 	return strings.Join([]string{v.Type, v.Subject, v.Description}, " - ")
 }
 
+func (v *PreconditionViolation) violation() {}
+
 // Describes violations in a client request. This error type focuses on the
 // syntactic aspects of the request.
 type BadRequest struct {
@@ -415,20 +509,43 @@ func (e *BadRequest) Unwrap() error {
 	return e.error
 }
 
+func (e *BadRequest) Code() Code {
+	return CodeBad
+}
+
 // A message type used to describe a single bad request field.
 type FieldViolation struct {
 	// A path leading to a field in the request body. The value will be a
 	// sequence of dot-separated identifiers that identify a protocol buffer
 	// field. E.g., "field_violations.field" would identify this field.
 	Field string
+	// Code is a short, machine-readable code telling why the field is bad,
+	// e.g. "REQUIRED", so clients can map the violation to a localised
+	// message without parsing its description. It is optional, and should
+	// preferably be one of the well-known violation codes (see
+	// ViolationCodes).
+	Code string
 	// A description of why the request element is bad.
 	Description string
+	// Template is the template the description was rendered from, if any,
+	// e.g. "must be at most {max} characters", so clients and translation
+	// layers can render the description again, e.g. in another language (see
+	// ExpandTemplate).
+	Template string
+	// Params are the values of the placeholders of Template, by name, e.g.
+	// {"max": "50"}.
+	Params map[string]string
+	// Source is where the violation was produced, when its constructor
+	// recorded it (see SetRecordHops). It is not encoded by transports.
+	Source *Frame
 }
 
 func (v *FieldViolation) String() string {
 	return strings.Join([]string{v.Field, v.Description}, " - ")
 }
 
+func (v *FieldViolation) violation() {}
+
 // A ConflictFailure indicates that the request conflicts with the current state
 // of the target resource.
 //
@@ -462,6 +579,10 @@ func (e *ConflictFailure) Unwrap() error {
 	return e.error
 }
 
+func (e *ConflictFailure) Code() Code {
+	return CodeAborted
+}
+
 type ConflictViolation struct {
 	// resource on which the conflict occurred.
 	// For example, "user:<uuid>" or "billing/invoice:<uuid>".
@@ -474,6 +595,8 @@ func (v *ConflictViolation) String() string {
 	return strings.Join([]string{v.Resource, v.Description}, " - ")
 }
 
+func (v *ConflictViolation) violation() {}
+
 type MissingFailure struct {
 	error
 }
@@ -491,6 +614,10 @@ func (e *MissingFailure) Unwrap() error {
 	return e.error
 }
 
+func (e *MissingFailure) Code() Code {
+	return CodeNotFound
+}
+
 type PermissionFailure struct {
 	error
 }
@@ -508,8 +635,16 @@ func (e *PermissionFailure) Unwrap() error {
 	return e.error
 }
 
+func (e *PermissionFailure) Code() Code {
+	return CodePermissionDenied
+}
+
 type AuthenticationFailure struct {
 	error
+
+	// Challenges describe how the caller may authenticate, e.g. with a Bearer
+	// token. HTTP transports render them as WWW-Authenticate headers.
+	Challenges []Challenge
 }
 
 func (e *AuthenticationFailure) Error() string {
@@ -525,6 +660,10 @@ func (e *AuthenticationFailure) Unwrap() error {
 	return e.error
 }
 
+func (e *AuthenticationFailure) Code() Code {
+	return CodeUnauthenticated
+}
+
 type UnimplementedFailure struct {
 	error
 }
@@ -542,6 +681,110 @@ func (e *UnimplementedFailure) Unwrap() error {
 	return e.error
 }
 
+func (e *UnimplementedFailure) Code() Code {
+	return CodeUnimplemented
+}
+
+// InternalFailure indicates an unexpected failure of the service. Its message
+// never describes the underlying cause, which can only be retrieved by
+// unwrapping it.
+type InternalFailure struct {
+	error
+}
+
+func (e *InternalFailure) Error() string {
+	return "internal error"
+}
+
+func (e *InternalFailure) Is(target error) bool {
+	_, ok := target.(*InternalFailure)
+	return ok
+}
+
+func (e *InternalFailure) Unwrap() error {
+	return e.error
+}
+
+func (e *InternalFailure) Code() Code {
+	return CodeInternal
+}
+
+// DeadlineFailure indicates that an operation did not complete in time.
+type DeadlineFailure struct {
+	error
+}
+
+func (e *DeadlineFailure) Error() string {
+	return "deadline exceeded"
+}
+
+func (e *DeadlineFailure) Is(target error) bool {
+	_, ok := target.(*DeadlineFailure)
+	return ok
+}
+
+func (e *DeadlineFailure) Unwrap() error {
+	return e.error
+}
+
+func (e *DeadlineFailure) Code() Code {
+	return CodeDeadlineExceeded
+}
+
+// A Violation describes a single violation carried by a fault. It is one of
+// *FieldViolation, *PreconditionViolation, *ConflictViolation or
+// *QuotaViolation.
+type Violation interface {
+	fmt.Stringer
+	violation()
+}
+
+// ViolationsOf returns the violations carried by the fault of `err`, in
+// order, whatever its category. It returns nil when the fault doesn't carry
+// any, e.g. when it is not a BadRequest, a PreconditionFailure, a
+// ConflictFailure or a QuotaFailure.
+//
+// Example:
+//
+//	for _, v := range faults.ViolationsOf(err) {
+//		switch v := v.(type) {
+//		case *faults.FieldViolation:
+//			form.SetError(v.Field, v.Description)
+//		default:
+//			banner.Add(v.String())
+//		}
+//	}
+func ViolationsOf(err error) []Violation {
+	var violations []Violation
+	switch CodeOf(err) {
+	case CodeBad:
+		if e, ok := AsBad(err); ok {
+			for _, v := range e.Violations {
+				violations = append(violations, v)
+			}
+		}
+	case CodeFailedPrecondition:
+		if e, ok := AsFailedPrecondition(err); ok {
+			for _, v := range e.Violations {
+				violations = append(violations, v)
+			}
+		}
+	case CodeAborted:
+		if e, ok := AsAborted(err); ok {
+			for _, v := range e.Violations {
+				violations = append(violations, v)
+			}
+		}
+	case CodeResourceExhausted:
+		if e, ok := AsResourceExhausted(err); ok {
+			for _, v := range e.Violations {
+				violations = append(violations, v)
+			}
+		}
+	}
+	return violations
+}
+
 // RetryInfo describes when the clients can retry a failed request.
 // Clients could ignore the recommendation here or retry when this information
 // is missing from error responses.
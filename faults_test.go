@@ -1,6 +1,9 @@
 package faults_test
 
 import (
+	"errors"
+	"fmt"
+	"reflect"
 	"testing"
 
 	"github.com/deixis/faults"
@@ -45,6 +48,14 @@ func TestIs(t *testing.T) {
 			Error: faults.ResourceExhausted(),
 			Is:    faults.IsResourceExhausted,
 		},
+		{
+			Error: faults.Internal,
+			Is:    faults.IsInternal,
+		},
+		{
+			Error: faults.DeadlineExceeded,
+			Is:    faults.IsDeadlineExceeded,
+		},
 	}
 
 	for i, test := range table {
@@ -117,6 +128,20 @@ func TestAs(t *testing.T) {
 				return ok
 			},
 		},
+		{
+			Error: faults.Internal,
+			As: func(err error) bool {
+				_, ok := faults.AsInternal(err)
+				return ok
+			},
+		},
+		{
+			Error: faults.DeadlineExceeded,
+			As: func(err error) bool {
+				_, ok := faults.AsDeadlineExceeded(err)
+				return ok
+			},
+		},
 	}
 
 	for i, test := range table {
@@ -125,3 +150,83 @@ func TestAs(t *testing.T) {
 		}
 	}
 }
+
+// TestAsChain ensures `As*` functions find faults anywhere in the error tree.
+func TestAsChain(t *testing.T) {
+	table := []error{
+		faults.NotFound,
+		fmt.Errorf("wrapped: %w", faults.NotFound),
+		faults.WithBad(faults.NotFound),
+		errors.Join(errors.New("boom"), fmt.Errorf("wrapped: %w", faults.NotFound)),
+	}
+
+	for i, err := range table {
+		if _, ok := faults.AsNotFound(err); !ok {
+			t.Errorf("%d - expect error As to return true for error %s", i, err)
+		}
+	}
+
+	if _, ok := faults.AsNotFound(errors.Join(errors.New("boom"), faults.Internal)); ok {
+		t.Error("expect error As to return false")
+	}
+}
+
+// TestGenerics ensures `IsType` and `AsType` work with built-in and custom
+// error types.
+func TestGenerics(t *testing.T) {
+	err := fmt.Errorf("wrapped: %w", faults.Bad(&faults.FieldViolation{Field: "email"}))
+
+	if !faults.IsType[*faults.BadRequest](err) {
+		t.Error("expect IsType to return true")
+	}
+	if faults.IsType[*faults.MissingFailure](err) {
+		t.Error("expect IsType to return false")
+	}
+
+	e, ok := faults.AsType[*faults.BadRequest](err)
+	if !ok || len(e.Violations) != 1 || e.Violations[0].Field != "email" {
+		t.Errorf("expect AsType to return the bad request, but got %v", e)
+	}
+	if _, ok := faults.AsType[*faults.QuotaFailure](err); ok {
+		t.Error("expect AsType to return false")
+	}
+
+	custom := errors.Join(errors.New("boom"), &customError{})
+	if _, ok := faults.AsType[*customError](custom); !ok {
+		t.Error("expect AsType to find a custom error type")
+	}
+}
+
+type customError struct{}
+
+func (e *customError) Error() string {
+	return "custom"
+}
+
+// TestViolationsOf ensures the violations of every category are returned.
+func TestViolationsOf(t *testing.T) {
+	field := faults.Field("email", "is required")
+	precondition := faults.Precondition("TOS", "example.com", "not accepted")
+	conflict := faults.Conflict("orders/42", "modified")
+	quota := faults.Quota("user:42", "daily limit")
+
+	table := []struct {
+		Error  error
+		Expect []faults.Violation
+	}{
+		{Error: faults.Bad(field), Expect: []faults.Violation{field}},
+		{Error: fmt.Errorf("signing up: %w", faults.FailedPrecondition(precondition)), Expect: []faults.Violation{precondition}},
+		{Error: faults.Aborted(conflict), Expect: []faults.Violation{conflict}},
+		{Error: faults.ResourceExhausted(quota), Expect: []faults.Violation{quota}},
+		{Error: faults.Bad(), Expect: nil},
+		{Error: faults.NotFound, Expect: nil},
+		{Error: errors.New("boom"), Expect: nil},
+		{Error: nil, Expect: nil},
+	}
+
+	for i, test := range table {
+		if violations := faults.ViolationsOf(test.Error); !reflect.DeepEqual(test.Expect, violations) {
+			t.Errorf("%d - expect violations %v, but got %v", i, test.Expect, violations)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+// Package faultsadmin records the most recent faults of a process in memory,
+// and exposes them to operators, so they can inspect the failures of a pod
+// without access to its logs.
+//
+// A Store is a trace sink: it records the faults reported by the tracing
+// hooks, by default those rendered by transports (see faults.EventRender).
+//
+//	store := faultsadmin.NewStore(1000)
+//	faults.SetTraceSink(store)
+//	adminMux.Handle("/debug/faults", store)
+//
+// The endpoint returns the messages of the faults, which may contain
+// implementation details, so it must only be exposed to operators.
+package faultsadmin
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultshttp"
+)
+
+// A Record describes a fault recorded by a Store.
+type Record struct {
+	Time    time.Time   `json:"time"`
+	Code    faults.Code `json:"code"`
+	Reason  string      `json:"reason,omitempty"`
+	Message string      `json:"message"`
+	// Detail describes the step at which the fault was recorded, e.g. the
+	// transport that rendered it.
+	Detail string `json:"detail,omitempty"`
+	// Caller is the location ("file:line") of the code that reported the
+	// fault, when known.
+	Caller string `json:"caller,omitempty"`
+}
+
+// A Query selects records. Zero fields match every record.
+type Query struct {
+	// Codes lists the categories of the records to return.
+	Codes []faults.Code
+	// Reason is the reason of the records to return (see faults.ReasonOf).
+	Reason string
+	// Since and Until bound the time of the records to return. Both bounds
+	// are inclusive.
+	Since time.Time
+	Until time.Time
+	// Limit is the maximum number of records to return.
+	Limit int
+}
+
+// match reports whether `r` is selected by `q`.
+func (q *Query) match(r *Record) bool {
+	if len(q.Codes) > 0 {
+		found := false
+		for _, code := range q.Codes {
+			found = found || code == r.Code
+		}
+		if !found {
+			return false
+		}
+	}
+	switch {
+	case q.Reason != "" && r.Reason != q.Reason:
+		return false
+	case !q.Since.IsZero() && r.Time.Before(q.Since):
+		return false
+	case !q.Until.IsZero() && r.Time.After(q.Until):
+		return false
+	}
+	return true
+}
+
+// A Store keeps the most recent faults in a ring buffer. It is safe for
+// concurrent use.
+type Store struct {
+	kinds []faults.EventKind
+
+	mu      sync.Mutex
+	records []Record
+	next    int
+	full    bool
+}
+
+// NewStore returns a store that keeps the `size` most recent faults reported
+// with one of the event kinds `kinds`, or with EventRender when none is
+// given.
+func NewStore(size int, kinds ...faults.EventKind) *Store {
+	if size <= 0 {
+		size = 1
+	}
+	if len(kinds) == 0 {
+		kinds = []faults.EventKind{faults.EventRender}
+	}
+	return &Store{kinds: kinds, records: make([]Record, size)}
+}
+
+// Trace records the fault of `ev` when its kind is recorded by the store. It
+// implements faults.TraceSink.
+func (s *Store) Trace(ev faults.Event) {
+	if ev.Err == nil {
+		return
+	}
+	for _, kind := range s.kinds {
+		if kind == ev.Kind {
+			s.add(Record{
+				Time:    ev.Time,
+				Code:    ev.Code,
+				Reason:  faults.ReasonOf(ev.Err),
+				Message: ev.Err.Error(),
+				Detail:  ev.Detail,
+				Caller:  ev.Caller,
+			})
+			return
+		}
+	}
+}
+
+// Add records `err`, for faults that are not reported by the tracing hooks.
+func (s *Store) Add(err error) {
+	if err == nil {
+		return
+	}
+	s.add(Record{
+		Time:    time.Now(),
+		Code:    faults.CodeOf(err),
+		Reason:  faults.ReasonOf(err),
+		Message: err.Error(),
+	})
+}
+
+func (s *Store) add(r Record) {
+	s.mu.Lock()
+	s.records[s.next] = r
+	s.next = (s.next + 1) % len(s.records)
+	s.full = s.full || s.next == 0
+	s.mu.Unlock()
+}
+
+// Query returns the records selected by `q`, from the most recent to the
+// oldest one.
+func (s *Store) Query(q Query) []Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := s.next
+	if s.full {
+		n = len(s.records)
+	}
+	records := []Record{}
+	for i := 1; i <= n; i++ {
+		r := &s.records[(s.next-i+len(s.records))%len(s.records)]
+		if !q.match(r) {
+			continue
+		}
+		records = append(records, *r)
+		if q.Limit > 0 && len(records) == q.Limit {
+			break
+		}
+	}
+	return records
+}
+
+// ServeHTTP responds with the records selected by the query parameters of
+// the request, as a JSON document:
+//
+//	GET /debug/faults?code=UNAVAILABLE&code=INTERNAL&since=15m&limit=50
+//
+//	{"records": [{"time": "...", "code": "UNAVAILABLE", "message": "..."}]}
+//
+// The parameters are the fields of Query: `code` (repeatable), `reason`,
+// `since` and `until` (RFC 3339 times, or durations relative to now, e.g.
+// "15m"), and `limit`.
+func (s *Store) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q, err := parseQuery(r)
+	if err != nil {
+		faultshttp.WriteProblem(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Records []Record `json:"records"`
+	}{s.Query(q)})
+}
+
+// parseQuery reads the query of `r`.
+func parseQuery(r *http.Request) (Query, error) {
+	values := r.URL.Query()
+	q := Query{Reason: values.Get("reason")}
+
+	var violations []*faults.FieldViolation
+	invalid := func(field, description string) {
+		violations = append(violations, &faults.FieldViolation{Field: field, Description: description})
+	}
+
+	for _, name := range values["code"] {
+		code, err := faults.ParseCode(name)
+		if err != nil {
+			invalid("code", "Unknown code "+strconv.Quote(name))
+			continue
+		}
+		q.Codes = append(q.Codes, code)
+	}
+	now := time.Now()
+	for _, bound := range []struct {
+		field string
+		t     *time.Time
+	}{{"since", &q.Since}, {"until", &q.Until}} {
+		v := values.Get(bound.field)
+		if v == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(v); err == nil {
+			*bound.t = now.Add(-d)
+		} else if *bound.t, err = time.Parse(time.RFC3339, v); err != nil {
+			invalid(bound.field, "Must be an RFC 3339 time or a duration")
+		}
+	}
+	if v := values.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			invalid("limit", "Must be a positive number")
+		}
+		q.Limit = limit
+	}
+
+	if len(violations) > 0 {
+		return q, faults.Bad(violations...)
+	}
+	return q, nil
+}
@@ -0,0 +1,170 @@
+package faultsadmin_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultsadmin"
+	"github.com/deixis/faults/faultshttp"
+)
+
+// TestStore ensures the most recent faults are kept, and can be queried.
+func TestStore(t *testing.T) {
+	store := faultsadmin.NewStore(3)
+	start := time.Now()
+	for _, err := range []error{
+		faults.NotFound,
+		faults.WithReason(faults.NotFound, "USER_NOT_FOUND"),
+		faults.Unavailable(0),
+		faults.Internal,
+		nil,
+	} {
+		store.Add(err)
+	}
+
+	table := []struct {
+		Query faultsadmin.Query
+		Codes []faults.Code
+	}{
+		{Codes: []faults.Code{faults.CodeInternal, faults.CodeUnavailable, faults.CodeNotFound}},
+		{Query: faultsadmin.Query{Limit: 2}, Codes: []faults.Code{faults.CodeInternal, faults.CodeUnavailable}},
+		{Query: faultsadmin.Query{Codes: []faults.Code{faults.CodeNotFound}}, Codes: []faults.Code{faults.CodeNotFound}},
+		{Query: faultsadmin.Query{Reason: "USER_NOT_FOUND"}, Codes: []faults.Code{faults.CodeNotFound}},
+		{Query: faultsadmin.Query{Reason: "NOPE"}, Codes: []faults.Code{}},
+		{Query: faultsadmin.Query{Since: start.Add(time.Hour)}, Codes: []faults.Code{}},
+		{Query: faultsadmin.Query{Until: start.Add(-time.Hour)}, Codes: []faults.Code{}},
+		{
+			Query: faultsadmin.Query{Since: start, Until: time.Now()},
+			Codes: []faults.Code{faults.CodeInternal, faults.CodeUnavailable, faults.CodeNotFound},
+		},
+	}
+
+	for i, test := range table {
+		records := store.Query(test.Query)
+		if len(records) != len(test.Codes) {
+			t.Errorf("%d - expect %d records, but got %d", i, len(test.Codes), len(records))
+			continue
+		}
+		for j, r := range records {
+			if r.Code != test.Codes[j] {
+				t.Errorf("%d - expect record %d to be %s, but got %s", i, j, test.Codes[j], r.Code)
+			}
+		}
+	}
+}
+
+// TestStoreTrace ensures the store records the faults rendered by
+// transports.
+func TestStoreTrace(t *testing.T) {
+	store := faultsadmin.NewStore(10)
+	ctx := faults.ContextWithTrace(context.Background(), store)
+
+	faults.Trace(ctx, faults.EventRender, faults.WithReason(faults.PermissionDenied, "NOT_OWNER"), "faultshttp: problem")
+	faults.Trace(ctx, faults.EventRetry, faults.Unavailable(0), "retry in 1s")
+
+	records := store.Query(faultsadmin.Query{})
+	if len(records) != 1 {
+		t.Fatalf("expect 1 record, but got %d", len(records))
+	}
+	r := records[0]
+	if r.Code != faults.CodePermissionDenied || r.Reason != "NOT_OWNER" || r.Detail != "faultshttp: problem" {
+		t.Errorf("expect rendered fault to be recorded, but got %+v", r)
+	}
+	if r.Caller == "" || r.Time.IsZero() {
+		t.Errorf("expect caller and time to be recorded, but got %+v", r)
+	}
+
+	store = faultsadmin.NewStore(10, faults.EventRetry)
+	store.Trace(faults.Event{Kind: faults.EventRetry, Err: faults.Unavailable(0), Code: faults.CodeUnavailable})
+	store.Trace(faults.Event{Kind: faults.EventRender, Err: faults.NotFound, Code: faults.CodeNotFound})
+	if records := store.Query(faultsadmin.Query{}); len(records) != 1 || records[0].Code != faults.CodeUnavailable {
+		t.Errorf("expect only retries to be recorded, but got %+v", records)
+	}
+}
+
+// TestStoreConcurrent ensures the store can be fed and queried concurrently.
+func TestStoreConcurrent(t *testing.T) {
+	store := faultsadmin.NewStore(16)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				store.Add(faults.Unavailable(0))
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				store.Query(faultsadmin.Query{Limit: 4})
+			}
+		}()
+	}
+	wg.Wait()
+
+	if records := store.Query(faultsadmin.Query{}); len(records) != 16 {
+		t.Errorf("expect store to be full, but got %d records", len(records))
+	}
+}
+
+// TestServeHTTP ensures records are exposed as JSON, and invalid queries
+// rejected.
+func TestServeHTTP(t *testing.T) {
+	store := faultsadmin.NewStore(10)
+	store.Add(faults.WithReason(faults.NotFound, "USER_NOT_FOUND"))
+	store.Add(faults.WithInternal(errors.New("pq: connection refused")))
+	store.Add(faults.Unavailable(0))
+
+	table := []struct {
+		Query  string
+		Status int
+		Codes  []faults.Code
+	}{
+		{Query: "", Status: 200, Codes: []faults.Code{faults.CodeUnavailable, faults.CodeInternal, faults.CodeNotFound}},
+		{Query: "?code=not_found&code=INTERNAL", Status: 200, Codes: []faults.Code{faults.CodeInternal, faults.CodeNotFound}},
+		{Query: "?reason=USER_NOT_FOUND&since=1h", Status: 200, Codes: []faults.Code{faults.CodeNotFound}},
+		{Query: "?until=2000-01-01T00:00:00Z", Status: 200, Codes: []faults.Code{}},
+		{Query: "?limit=1", Status: 200, Codes: []faults.Code{faults.CodeUnavailable}},
+		{Query: "?code=NOPE", Status: 400},
+		{Query: "?since=yesterday&limit=-1", Status: 400},
+	}
+
+	for i, test := range table {
+		rec := httptest.NewRecorder()
+		store.ServeHTTP(rec, httptest.NewRequest("GET", "/debug/faults"+test.Query, nil))
+		if rec.Code != test.Status {
+			t.Errorf("%d - expect status %d, but got %d", i, test.Status, rec.Code)
+			continue
+		}
+		if test.Status != 200 {
+			err := faultshttp.ReadProblem(rec.Result())
+			if e, ok := faults.AsBad(err); !ok || len(e.Violations) == 0 {
+				t.Errorf("%d - expect violations, but got %v", i, err)
+			}
+			continue
+		}
+
+		var doc struct {
+			Records []faultsadmin.Record `json:"records"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&doc); err != nil {
+			t.Fatalf("%d - expect JSON document, but got %s", i, err)
+		}
+		if len(doc.Records) != len(test.Codes) {
+			t.Errorf("%d - expect %d records, but got %d", i, len(test.Codes), len(doc.Records))
+			continue
+		}
+		for j, r := range doc.Records {
+			if r.Code != test.Codes[j] {
+				t.Errorf("%d - expect record %d to be %s, but got %s", i, j, test.Codes[j], r.Code)
+			}
+		}
+	}
+}
@@ -0,0 +1,419 @@
+// Package faultscatalog generates the faults of a service from a catalog of
+// its errors, so hundreds of reasons stay consistent without being written
+// by hand. Each error of the catalog gets a typed constructor, a stable
+// reason constant, a predicate, and an entry in a Markdown reference.
+//
+// A catalog is written in YAML (or JSON):
+//
+//	package: billing
+//	domain: billing.example.com
+//	errors:
+//	  - name: CardDeclined
+//	    reason: CARD_DECLINED
+//	    category: FAILED_PRECONDITION
+//	    message: card declined
+//	    description: The card was declined by its issuer.
+//	    metadata: [card_last4]
+//	  - name: ProcessorUnavailable
+//	    reason: PROCESSOR_UNAVAILABLE
+//	    category: UNAVAILABLE
+//	    description: The payment processor can't be reached.
+//	    retry: 30s
+//
+// The generator, github.com/deixis/faults/cmd/faultsgen, is meant to be run
+// by go generate:
+//
+//	//go:generate go run github.com/deixis/faults/cmd/faultsgen -in catalog.yaml -out catalog_gen.go -doc CATALOG.md
+//
+// The generated code only depends on github.com/deixis/faults:
+//
+//	const ReasonCardDeclined = "CARD_DECLINED"
+//	func CardDeclined(cause error, cardLast4 string) error
+//	func IsCardDeclined(err error) bool
+package faultscatalog
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/deixis/faults"
+	"gopkg.in/yaml.v2"
+)
+
+// A Catalog lists the errors of a service.
+type Catalog struct {
+	// Package is the name of the package of the generated code. It defaults
+	// to the package running go generate.
+	Package string `yaml:"package" json:"package"`
+	// Domain is the domain of the reasons of the errors, if they are scoped,
+	// e.g. "billing.example.com" (see faults.ErrorInfo). Unscoped reasons
+	// belong to the domain of the process (see faults.SetDomain).
+	Domain string `yaml:"domain" json:"domain"`
+	// Errors are the errors of the service, in the order of the generated
+	// code and documentation.
+	Errors []Error `yaml:"errors" json:"errors"`
+}
+
+// An Error describes an error of a service, which is a fault of a category
+// with a reason of its own.
+type Error struct {
+	// Name is the name of the constructor of the error, e.g. "CardDeclined".
+	// It must be an exported Go identifier.
+	Name string `yaml:"name" json:"name"`
+	// Reason is the stable reason of the error, in upper snake case, e.g.
+	// "CARD_DECLINED" (see faults.ErrorInfo).
+	Reason string `yaml:"reason" json:"reason"`
+	// Category is the name of the category of the error, e.g.
+	// "FAILED_PRECONDITION". It must be one of the categories of faults.
+	Category string `yaml:"category" json:"category"`
+	// Message is the message of the error, if any. It defaults to the
+	// message of its category.
+	Message string `yaml:"message,omitempty" json:"message,omitempty"`
+	// Description tells what the error means to its callers, for the
+	// documentation.
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	// Metadata lists the keys of the metadata of the reason, e.g.
+	// "card_last4", which are parameters of the constructor.
+	Metadata []string `yaml:"metadata,omitempty" json:"metadata,omitempty"`
+	// Retry is the delay advised before retrying, e.g. "30s", for the
+	// UNAVAILABLE and RESOURCE_EXHAUSTED categories.
+	Retry string `yaml:"retry,omitempty" json:"retry,omitempty"`
+	// Permanent marks the error as not retryable (see faults.Permanent).
+	Permanent bool `yaml:"permanent,omitempty" json:"permanent,omitempty"`
+	// Help lists links to documentation about the error (see faults.Help).
+	Help []Link `yaml:"help,omitempty" json:"help,omitempty"`
+}
+
+// A Link points to documentation about an error.
+type Link struct {
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	URL         string `yaml:"url" json:"url"`
+}
+
+// Parse parses the catalog `data`, written in YAML or JSON, and validates it.
+func Parse(data []byte) (*Catalog, error) {
+	c := &Catalog{}
+	if err := yaml.UnmarshalStrict(data, c); err != nil {
+		return nil, fmt.Errorf("faultscatalog: %w", err)
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// reasonPattern matches the reasons in upper snake case.
+var reasonPattern = regexp.MustCompile(`^[A-Z][A-Z0-9]*(_[A-Z0-9]+)*$`)
+
+// codeNames maps the categories of faults to the names of their constants.
+var codeNames = map[faults.Code]string{
+	faults.CodeBad:                "CodeBad",
+	faults.CodeNotFound:           "CodeNotFound",
+	faults.CodePermissionDenied:   "CodePermissionDenied",
+	faults.CodeUnauthenticated:    "CodeUnauthenticated",
+	faults.CodeFailedPrecondition: "CodeFailedPrecondition",
+	faults.CodeAborted:            "CodeAborted",
+	faults.CodeResourceExhausted:  "CodeResourceExhausted",
+	faults.CodeUnavailable:        "CodeUnavailable",
+	faults.CodeUnimplemented:      "CodeUnimplemented",
+	faults.CodeInternal:           "CodeInternal",
+	faults.CodeDeadlineExceeded:   "CodeDeadlineExceeded",
+}
+
+// Validate reports the first error of `c` that would prevent its code from
+// being generated, or from being consistent, e.g. two errors with the same
+// reason.
+func (c *Catalog) Validate() error {
+	if c.Package != "" && !token.IsIdentifier(c.Package) {
+		return fmt.Errorf("faultscatalog: invalid package name %q", c.Package)
+	}
+	if strings.ContainsFunc(c.Domain, unicode.IsSpace) {
+		return fmt.Errorf("faultscatalog: invalid domain %q", c.Domain)
+	}
+	names := map[string]bool{}
+	reasons := map[string]bool{}
+	for i, e := range c.Errors {
+		if err := e.validate(); err != nil {
+			return fmt.Errorf("faultscatalog: errors[%d] (%s): %w", i, e.Name, err)
+		}
+		if names[e.Name] {
+			return fmt.Errorf("faultscatalog: errors[%d] (%s): duplicate name", i, e.Name)
+		}
+		if reasons[e.Reason] {
+			return fmt.Errorf("faultscatalog: errors[%d] (%s): duplicate reason %s", i, e.Name, e.Reason)
+		}
+		names[e.Name] = true
+		reasons[e.Reason] = true
+	}
+	return nil
+}
+
+func (e *Error) validate() error {
+	if !token.IsIdentifier(e.Name) || !token.IsExported(e.Name) {
+		return fmt.Errorf("invalid name %q, expect an exported Go identifier", e.Name)
+	}
+	if !reasonPattern.MatchString(e.Reason) {
+		return fmt.Errorf("invalid reason %q, expect upper snake case", e.Reason)
+	}
+	code, err := faults.ParseCode(e.Category)
+	if err != nil || codeNames[code] == "" {
+		return fmt.Errorf("unknown category %q", e.Category)
+	}
+	params := map[string]bool{}
+	for _, k := range e.Metadata {
+		p := paramName(k)
+		if !token.IsIdentifier(p) {
+			return fmt.Errorf("invalid metadata key %q", k)
+		}
+		if params[p] {
+			return fmt.Errorf("duplicate parameter %s for metadata key %q", p, k)
+		}
+		params[p] = true
+	}
+	if e.Retry != "" {
+		d, err := time.ParseDuration(e.Retry)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid retry delay %q", e.Retry)
+		}
+		if code != faults.CodeUnavailable && code != faults.CodeResourceExhausted {
+			return fmt.Errorf("retry delay of category %s, expect UNAVAILABLE or RESOURCE_EXHAUSTED", code)
+		}
+	}
+	for _, l := range e.Help {
+		if l.URL == "" {
+			return fmt.Errorf("help link without URL")
+		}
+	}
+	return nil
+}
+
+// paramName returns the name of the parameter of the metadata key `key`, in
+// lower camel case, e.g. "cardLast4" for "card_last4", and "invoiceID" for
+// "invoice_id".
+func paramName(key string) string {
+	words := strings.FieldsFunc(key, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b strings.Builder
+	for i, w := range words {
+		switch {
+		case i == 0:
+			b.WriteString(strings.ToLower(w[:1]) + w[1:])
+		case initialisms[strings.ToLower(w)]:
+			b.WriteString(strings.ToUpper(w))
+		default:
+			b.WriteString(strings.ToUpper(w[:1]) + w[1:])
+		}
+	}
+	p := b.String()
+	if token.IsKeyword(p) || reserved[p] {
+		p += "Value"
+	}
+	return p
+}
+
+// initialisms lists the words written in upper case in Go identifiers.
+var initialisms = map[string]bool{
+	"api": true, "http": true, "id": true, "ip": true, "json": true,
+	"sku": true, "uri": true, "url": true, "uuid": true,
+}
+
+// reserved lists the identifiers of the generated code that can't name
+// parameters.
+var reserved = map[string]bool{"cause": true, "err": true, "faults": true, "time": true}
+
+// Generate returns the Go source of the constructors, reasons and predicates
+// of the errors of `c`, in the package `c.Package`, or `pkg` when it is not
+// set. `source` names the catalog in the header of the generated file.
+func (c *Catalog) Generate(pkg, source string) ([]byte, error) {
+	if c.Package != "" {
+		pkg = c.Package
+	}
+	if !token.IsIdentifier(pkg) {
+		return nil, fmt.Errorf("faultscatalog: invalid package name %q", pkg)
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by faultsgen from %s. DO NOT EDIT.\n\n", source)
+	fmt.Fprintf(&b, "package %s\n\n", pkg)
+	if c.needsTime() {
+		b.WriteString("import (\n\t\"time\"\n\n\t\"github.com/deixis/faults\"\n)\n\n")
+	} else {
+		b.WriteString("import \"github.com/deixis/faults\"\n\n")
+	}
+	if len(c.Errors) == 0 {
+		return format.Source(b.Bytes())
+	}
+
+	if c.Domain != "" {
+		fmt.Fprintf(&b, "// Domain is the domain of the reasons of the catalog.\nconst Domain = %q\n\n", c.Domain)
+	}
+	b.WriteString("// Reasons of the errors of the catalog.\nconst (\n")
+	for _, e := range c.Errors {
+		comment(&b, "\t", fmt.Sprintf("Reason%s is the reason of the %s errors.", e.Name, e.Name))
+		fmt.Fprintf(&b, "\tReason%s = %q\n", e.Name, e.Reason)
+	}
+	b.WriteString(")\n\n")
+
+	b.WriteString("// Reasons lists the reasons of the errors of the catalog.\nvar Reasons = []string{\n")
+	for _, e := range c.Errors {
+		fmt.Fprintf(&b, "\tReason%s,\n", e.Name)
+	}
+	b.WriteString("}\n")
+
+	for _, e := range c.Errors {
+		e.generate(&b, c.Domain)
+	}
+	return format.Source(b.Bytes())
+}
+
+// needsTime reports whether the generated code of `c` refers to the time
+// package.
+func (c *Catalog) needsTime() bool {
+	for _, e := range c.Errors {
+		if e.Retry != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// generate writes the constructor and the predicate of `e`, whose reason is
+// scoped by `domain`, if any.
+func (e *Error) generate(b *bytes.Buffer, domain string) {
+	code, _ := faults.ParseCode(e.Category)
+
+	b.WriteString("\n")
+	comment(b, "", fmt.Sprintf("%s returns a fault of the category %s with the reason %s, which wraps `cause`. %s",
+		e.Name, code, e.Reason, e.Description))
+
+	params := []string{"cause error"}
+	for _, k := range e.Metadata {
+		params = append(params, paramName(k)+" string")
+	}
+	fmt.Fprintf(b, "func %s(%s) error {\n", e.Name, strings.Join(params, ", "))
+
+	opts := []string{"faults.WithCause(cause)"}
+	if e.Retry != "" {
+		d, _ := time.ParseDuration(e.Retry)
+		opts = append(opts, "faults.WithRetry("+duration(d)+")")
+	}
+	if len(e.Help) > 0 {
+		var links []string
+		for _, l := range e.Help {
+			links = append(links, fmt.Sprintf("faults.Link{Description: %q, URL: %q}", l.Description, l.URL))
+		}
+		opts = append(opts, "faults.WithHelp("+strings.Join(links, ", ")+")")
+	}
+	if len(opts) == 1 {
+		fmt.Fprintf(b, "\terr := faults.New(faults.%s, %q, %s)\n", codeNames[code], e.Message, opts[0])
+	} else {
+		fmt.Fprintf(b, "\terr := faults.New(faults.%s, %q,\n\t\t%s,\n\t)\n", codeNames[code], e.Message, strings.Join(opts, ",\n\t\t"))
+	}
+
+	reason := "Reason" + e.Name
+	if domain != "" {
+		reason = `Domain + "/" + ` + reason
+	}
+	if len(e.Metadata) == 0 {
+		fmt.Fprintf(b, "\terr = faults.WithReason(err, %s)\n", reason)
+	} else {
+		fmt.Fprintf(b, "\terr = faults.WithErrorInfo(err, %s, map[string]string{\n", reason)
+		for _, k := range e.Metadata {
+			fmt.Fprintf(b, "\t\t%q: %s,\n", k, paramName(k))
+		}
+		b.WriteString("\t})\n")
+	}
+	if e.Permanent {
+		b.WriteString("\terr = faults.Permanent(err)\n")
+	}
+	b.WriteString("\treturn err\n}\n")
+
+	b.WriteString("\n")
+	comment(b, "", fmt.Sprintf("Is%s reports whether `err` is a fault with the reason %s.", e.Name, e.Reason))
+	if domain == "" {
+		fmt.Fprintf(b, "func Is%s(err error) bool {\n\treturn faults.ReasonOf(err) == Reason%s\n}\n", e.Name, e.Name)
+	} else {
+		fmt.Fprintf(b, "func Is%s(err error) bool {\n\treturn faults.ReasonOf(err) == Reason%s && faults.DomainOf(err) == Domain\n}\n", e.Name, e.Name)
+	}
+}
+
+// duration returns the Go expression of `d`, e.g. "30 * time.Second".
+func duration(d time.Duration) string {
+	for _, unit := range []struct {
+		d    time.Duration
+		name string
+	}{
+		{time.Hour, "time.Hour"},
+		{time.Minute, "time.Minute"},
+		{time.Second, "time.Second"},
+		{time.Millisecond, "time.Millisecond"},
+		{time.Microsecond, "time.Microsecond"},
+	} {
+		if d%unit.d == 0 {
+			return fmt.Sprintf("%d * %s", d/unit.d, unit.name)
+		}
+	}
+	return fmt.Sprintf("%d * time.Nanosecond", d)
+}
+
+// comment writes `text` as a comment indented by `indent`, wrapped at 80
+// columns.
+func comment(b *bytes.Buffer, indent, text string) {
+	line := indent + "//"
+	for _, word := range strings.Fields(text) {
+		if len(line)+1+len(word) > 80 && line != indent+"//" {
+			b.WriteString(line + "\n")
+			line = indent + "//"
+		}
+		line += " " + word
+	}
+	b.WriteString(line + "\n")
+}
+
+// Markdown returns the reference documentation of the errors of `c`, as a
+// Markdown table listing their reason, category, HTTP status, gRPC code,
+// metadata and description.
+func (c *Catalog) Markdown() []byte {
+	var b bytes.Buffer
+	b.WriteString("<!-- Code generated by faultsgen. DO NOT EDIT. -->\n\n")
+	if c.Package != "" {
+		fmt.Fprintf(&b, "# Errors of %s\n\n", c.Package)
+	} else {
+		b.WriteString("# Errors\n\n")
+	}
+	if c.Domain != "" {
+		fmt.Fprintf(&b, "Reasons are scoped by the domain `%s`.\n\n", c.Domain)
+	}
+	b.WriteString("| Reason | Category | HTTP status | gRPC code | Metadata | Description |\n")
+	b.WriteString("| --- | --- | --- | --- | --- | --- |\n")
+	for _, e := range c.Errors {
+		code, _ := faults.ParseCode(e.Category)
+		d, _ := faults.Describe(code)
+		var metadata []string
+		for _, k := range e.Metadata {
+			metadata = append(metadata, "`"+k+"`")
+		}
+		description := e.Description
+		for _, l := range e.Help {
+			text := l.Description
+			if text == "" {
+				text = l.URL
+			}
+			description = strings.TrimSpace(description + " [" + text + "](" + l.URL + ")")
+		}
+		fmt.Fprintf(&b, "| `%s` | `%s` | %d | %d | %s | %s |\n",
+			e.Reason, code, d.HTTPStatus, d.GRPCCode, strings.Join(metadata, ", "),
+			strings.ReplaceAll(description, "|", `\|`))
+	}
+	return b.Bytes()
+}
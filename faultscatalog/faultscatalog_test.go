@@ -0,0 +1,205 @@
+package faultscatalog_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultscatalog"
+	"github.com/deixis/faults/faultscatalog/internal/billing"
+	"gopkg.in/yaml.v2"
+)
+
+// TestGenerate ensures the generated files of the billing catalog are up to
+// date, so they reflect the output of the generator.
+func TestGenerate(t *testing.T) {
+	data, err := os.ReadFile("internal/billing/catalog.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := faultscatalog.Parse(data)
+	if err != nil {
+		t.Fatalf("expect catalog to be valid, but got %s", err)
+	}
+
+	src, err := c.Generate("", "catalog.yaml")
+	if err != nil {
+		t.Fatalf("expect code to be generated, but got %s", err)
+	}
+	if expect, _ := os.ReadFile("internal/billing/catalog_gen.go"); !bytes.Equal(src, expect) {
+		t.Errorf("expect generated code to match catalog_gen.go, but got\n%s", src)
+	}
+	if expect, _ := os.ReadFile("internal/billing/CATALOG.md"); !bytes.Equal(c.Markdown(), expect) {
+		t.Errorf("expect documentation to match CATALOG.md, but got\n%s", c.Markdown())
+	}
+}
+
+// TestGenerated ensures the generated constructors build faults of their
+// category, with their reason and details.
+func TestGenerated(t *testing.T) {
+	cause := errors.New("issuer said no")
+
+	err := billing.CardDeclined(cause, "4242", "insufficient_funds")
+	if !faults.IsFailedPrecondition(err) || !billing.IsCardDeclined(err) || billing.IsAccountClosed(err) {
+		t.Errorf("expect declined card, but got %s %v", faults.CodeOf(err), err)
+	}
+	if err.Error() != "card declined" || !errors.Is(err, cause) {
+		t.Errorf("expect message and cause, but got %v", err)
+	}
+	e, _ := faults.AsErrorInfo(err)
+	expect := map[string]string{"card_last4": "4242", "decline_code": "insufficient_funds"}
+	if e == nil || !reflect.DeepEqual(e.Metadata, expect) {
+		t.Errorf("expect metadata %v, but got %v", expect, e)
+	}
+	if domain := faults.DomainOf(err); domain != billing.Domain {
+		t.Errorf("expect domain %s, but got %q", billing.Domain, domain)
+	}
+	if billing.IsCardDeclined(faults.WithReason(faults.FailedPrecondition(), "payments.example.com/CARD_DECLINED")) {
+		t.Error("expect reasons of other domains not to match")
+	}
+	if links := faults.HelpOf(err); len(links) != 1 || links[0].URL != "https://example.com/docs/declines" {
+		t.Errorf("expect help link, but got %v", links)
+	}
+
+	err = billing.ProcessorUnavailable(nil)
+	if advice := faults.RetryAdvice(err); !advice.Retryable || advice.Delay != 30*time.Second {
+		t.Errorf("expect retry in 30s, but got %+v", advice)
+	}
+	if err := billing.AccountClosed(nil); !faults.IsPermanent(err) || faults.ReasonOf(err) != billing.ReasonAccountClosed {
+		t.Errorf("expect permanent closed account, but got %v", err)
+	}
+	if err := billing.InvoiceNotFound(nil, "inv_1"); err.Error() != "resource not found" || !faults.IsNotFound(err) {
+		t.Errorf("expect message of the category, but got %v", err)
+	}
+	if len(billing.Reasons) != 4 {
+		t.Errorf("expect 4 reasons, but got %v", billing.Reasons)
+	}
+}
+
+// TestValidate ensures inconsistent catalogs are rejected.
+func TestValidate(t *testing.T) {
+	table := []struct {
+		Catalog string
+		Expect  string
+	}{
+		{
+			Catalog: "package: 1billing",
+			Expect:  `invalid package name "1billing"`,
+		},
+		{
+			Catalog: "domain: billing example",
+			Expect:  `invalid domain "billing example"`,
+		},
+		{
+			Catalog: "errors: [{name: cardDeclined, reason: CARD_DECLINED, category: ABORTED}]",
+			Expect:  `errors[0] (cardDeclined): invalid name "cardDeclined"`,
+		},
+		{
+			Catalog: "errors: [{name: CardDeclined, reason: card-declined, category: ABORTED}]",
+			Expect:  `invalid reason "card-declined"`,
+		},
+		{
+			Catalog: "errors: [{name: CardDeclined, reason: CARD_DECLINED, category: TEAPOT}]",
+			Expect:  `unknown category "TEAPOT"`,
+		},
+		{
+			Catalog: "errors: [{name: CardDeclined, reason: CARD_DECLINED, category: OK}]",
+			Expect:  `unknown category "OK"`,
+		},
+		{
+			Catalog: "errors: [{name: A, reason: A, category: ABORTED}, {name: A, reason: B, category: ABORTED}]",
+			Expect:  "errors[1] (A): duplicate name",
+		},
+		{
+			Catalog: "errors: [{name: A, reason: A, category: ABORTED}, {name: B, reason: A, category: ABORTED}]",
+			Expect:  "errors[1] (B): duplicate reason A",
+		},
+		{
+			Catalog: "errors: [{name: A, reason: A, category: ABORTED, metadata: [card_id, card-id]}]",
+			Expect:  `duplicate parameter cardID for metadata key "card-id"`,
+		},
+		{
+			Catalog: "errors: [{name: A, reason: A, category: ABORTED, metadata: [4digits]}]",
+			Expect:  `invalid metadata key "4digits"`,
+		},
+		{
+			Catalog: "errors: [{name: A, reason: A, category: ABORTED, retry: 1s}]",
+			Expect:  "retry delay of category ABORTED",
+		},
+		{
+			Catalog: "errors: [{name: A, reason: A, category: UNAVAILABLE, retry: soon}]",
+			Expect:  `invalid retry delay "soon"`,
+		},
+		{
+			Catalog: "errors: [{name: A, reason: A, category: ABORTED, help: [{description: Docs}]}]",
+			Expect:  "help link without URL",
+		},
+		{
+			Catalog: "errors: [{name: A, reason: A, category: ABORTED, severity: high}]",
+			Expect:  "field severity not found",
+		},
+	}
+
+	for i, test := range table {
+		_, err := faultscatalog.Parse([]byte(test.Catalog))
+		if err == nil || !strings.Contains(err.Error(), test.Expect) {
+			t.Errorf("%d - expect error %q, but got %v", i, test.Expect, err)
+		}
+	}
+}
+
+// TestGenerateParams ensures metadata keys are converted to valid parameters.
+func TestGenerateParams(t *testing.T) {
+	c, err := faultscatalog.Parse([]byte(`{"errors": [{"name": "A", "reason": "A", "category": "ABORTED", "metadata": ["type", "err", "order.url"]}]}`))
+	if err != nil {
+		t.Fatalf("expect catalog to be valid, but got %s", err)
+	}
+	src, err := c.Generate("orders", "catalog.json")
+	if err != nil {
+		t.Fatalf("expect code to be generated, but got %s", err)
+	}
+	if expect := "func A(cause error, typeValue string, errValue string, orderURL string) error"; !strings.Contains(string(src), expect) {
+		t.Errorf("expect %s, but got\n%s", expect, src)
+	}
+	if !strings.HasPrefix(string(src), "// Code generated by faultsgen from catalog.json. DO NOT EDIT.\n\npackage orders\n") {
+		t.Errorf("expect header of generated code, but got\n%s", src)
+	}
+	if _, err := c.Generate("", "catalog.json"); err == nil {
+		t.Errorf("expect package name to be required")
+	}
+}
+
+// stackUnmarshaler captures its stack when it is unmarshalled, so stacks
+// can go through the code of a dependency. The core has none, so stacks with
+// frames of modules are tested here, with the YAML decoder of catalogs.
+type stackUnmarshaler []faults.Frame
+
+func (s *stackUnmarshaler) UnmarshalYAML(func(interface{}) error) error {
+	*s = faults.CallStack(0)
+	return nil
+}
+
+// TestCallStackDependencies ensures the files of modules are trimmed up to
+// their import path (see faults.StackOptions).
+func TestCallStackDependencies(t *testing.T) {
+	faults.SetStackOptions(faults.StackOptions{TrimDependencies: true})
+	defer faults.SetStackOptions(faults.StackOptions{})
+
+	var s stackUnmarshaler
+	if err := yaml.Unmarshal([]byte("a: 1"), &s); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range s {
+		if strings.HasPrefix(f.Function, "gopkg.in/") && !strings.HasPrefix(f.File, "gopkg.in/yaml.v2@") {
+			t.Errorf("expect file of the module to be trimmed, but got %s", f)
+		}
+	}
+	if len(s) < 2 || !strings.HasPrefix(s[1].File, "gopkg.in/yaml.v2@") {
+		t.Errorf("expect frames of the module, but got %v", s)
+	}
+}
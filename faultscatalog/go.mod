@@ -0,0 +1,16 @@
+module github.com/deixis/faults/faultscatalog
+
+go 1.22.0
+
+replace github.com/deixis/faults => ../
+
+require (
+	github.com/deixis/faults v0.0.0-00010101000000-000000000000
+	gopkg.in/yaml.v2 v2.4.0
+)
+
+require (
+	github.com/kr/pretty v0.3.1 // indirect
+	github.com/rogpeppe/go-internal v1.12.0 // indirect
+	gopkg.in/check.v1 v1.0.0-20201130134442-10cb98267c6c // indirect
+)
@@ -0,0 +1,6 @@
+// Package billing is a catalog of errors used to test the code generated by
+// faultsgen.
+package billing
+
+// faultsgen is a module of its own, run from its directory
+//go:generate go run -C ../../../cmd/faultsgen . -in ../../faultscatalog/internal/billing/catalog.yaml -out ../../faultscatalog/internal/billing/catalog_gen.go -doc ../../faultscatalog/internal/billing/CATALOG.md
@@ -0,0 +1,104 @@
+// Code generated by faultsgen from catalog.yaml. DO NOT EDIT.
+
+package billing
+
+import (
+	"time"
+
+	"github.com/deixis/faults"
+)
+
+// Domain is the domain of the reasons of the catalog.
+const Domain = "billing.example.com"
+
+// Reasons of the errors of the catalog.
+const (
+	// ReasonCardDeclined is the reason of the CardDeclined errors.
+	ReasonCardDeclined = "CARD_DECLINED"
+	// ReasonInvoiceNotFound is the reason of the InvoiceNotFound errors.
+	ReasonInvoiceNotFound = "INVOICE_NOT_FOUND"
+	// ReasonProcessorUnavailable is the reason of the ProcessorUnavailable errors.
+	ReasonProcessorUnavailable = "PROCESSOR_UNAVAILABLE"
+	// ReasonAccountClosed is the reason of the AccountClosed errors.
+	ReasonAccountClosed = "ACCOUNT_CLOSED"
+)
+
+// Reasons lists the reasons of the errors of the catalog.
+var Reasons = []string{
+	ReasonCardDeclined,
+	ReasonInvoiceNotFound,
+	ReasonProcessorUnavailable,
+	ReasonAccountClosed,
+}
+
+// CardDeclined returns a fault of the category FAILED_PRECONDITION with the
+// reason CARD_DECLINED, which wraps `cause`. The card was declined by its
+// issuer.
+func CardDeclined(cause error, cardLast4 string, declineCode string) error {
+	err := faults.New(faults.CodeFailedPrecondition, "card declined",
+		faults.WithCause(cause),
+		faults.WithHelp(faults.Link{Description: "Declined cards", URL: "https://example.com/docs/declines"}),
+	)
+	err = faults.WithErrorInfo(err, Domain+"/"+ReasonCardDeclined, map[string]string{
+		"card_last4":   cardLast4,
+		"decline_code": declineCode,
+	})
+	return err
+}
+
+// IsCardDeclined reports whether `err` is a fault with the reason
+// CARD_DECLINED.
+func IsCardDeclined(err error) bool {
+	return faults.ReasonOf(err) == ReasonCardDeclined && faults.DomainOf(err) == Domain
+}
+
+// InvoiceNotFound returns a fault of the category NOT_FOUND with the reason
+// INVOICE_NOT_FOUND, which wraps `cause`. The invoice doesn't exist, or was
+// deleted.
+func InvoiceNotFound(cause error, invoiceID string) error {
+	err := faults.New(faults.CodeNotFound, "", faults.WithCause(cause))
+	err = faults.WithErrorInfo(err, Domain+"/"+ReasonInvoiceNotFound, map[string]string{
+		"invoice_id": invoiceID,
+	})
+	return err
+}
+
+// IsInvoiceNotFound reports whether `err` is a fault with the reason
+// INVOICE_NOT_FOUND.
+func IsInvoiceNotFound(err error) bool {
+	return faults.ReasonOf(err) == ReasonInvoiceNotFound && faults.DomainOf(err) == Domain
+}
+
+// ProcessorUnavailable returns a fault of the category UNAVAILABLE with the
+// reason PROCESSOR_UNAVAILABLE, which wraps `cause`. The payment processor
+// can't be reached.
+func ProcessorUnavailable(cause error) error {
+	err := faults.New(faults.CodeUnavailable, "payment processor unavailable",
+		faults.WithCause(cause),
+		faults.WithRetry(30*time.Second),
+	)
+	err = faults.WithReason(err, Domain+"/"+ReasonProcessorUnavailable)
+	return err
+}
+
+// IsProcessorUnavailable reports whether `err` is a fault with the reason
+// PROCESSOR_UNAVAILABLE.
+func IsProcessorUnavailable(err error) bool {
+	return faults.ReasonOf(err) == ReasonProcessorUnavailable && faults.DomainOf(err) == Domain
+}
+
+// AccountClosed returns a fault of the category PERMISSION_DENIED with the
+// reason ACCOUNT_CLOSED, which wraps `cause`. The account was closed, and can't
+// be billed anymore.
+func AccountClosed(cause error) error {
+	err := faults.New(faults.CodePermissionDenied, "", faults.WithCause(cause))
+	err = faults.WithReason(err, Domain+"/"+ReasonAccountClosed)
+	err = faults.Permanent(err)
+	return err
+}
+
+// IsAccountClosed reports whether `err` is a fault with the reason
+// ACCOUNT_CLOSED.
+func IsAccountClosed(err error) bool {
+	return faults.ReasonOf(err) == ReasonAccountClosed && faults.DomainOf(err) == Domain
+}
@@ -0,0 +1,52 @@
+// Package faultschi renders faults with `github.com/go-chi/render`, so chi
+// services can respond with faults like with any other payload:
+//
+//	r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+//		user, err := users.Get(r.Context(), chi.URLParam(r, "id"))
+//		if err != nil {
+//			render.Render(w, r, faultschi.Render(err))
+//			return
+//		}
+//		render.JSON(w, r, user)
+//	})
+//
+// The response has the HTTP status of the category of the fault, the
+// Retry-After and WWW-Authenticate headers it advises, and the members of the
+// problem details document describing it (see faultshttp.NewProblem). The
+// document is encoded by the responder of render, which picks its content
+// type, e.g. `application/json` by default.
+package faultschi
+
+import (
+	"net/http"
+
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultshttp"
+	"github.com/go-chi/render"
+)
+
+// A Response is the payload rendering a fault. It is created with Render.
+type Response struct {
+	*faultshttp.Problem
+
+	err error
+}
+
+// Render returns the payload rendering `err`.
+func Render(err error) render.Renderer {
+	return &Response{err: err}
+}
+
+// Render prepares the response, before it is written by the responder of
+// render. The rendering is reported to the trace sinks as EventRender (see
+// faults.Trace).
+func (resp *Response) Render(w http.ResponseWriter, r *http.Request) error {
+	faults.Trace(r.Context(), faults.EventRender, resp.err, "faultschi: problem")
+
+	resp.Problem = faultshttp.NewProblem(r, resp.err)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	faults.SetRetryAfter(w.Header(), resp.err)
+	faults.SetWWWAuthenticate(w.Header(), resp.err)
+	render.Status(r, resp.Status)
+	return nil
+}
@@ -0,0 +1,78 @@
+package faultschi_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultschi"
+	"github.com/deixis/faults/faultshttp"
+	"github.com/go-chi/render"
+)
+
+// TestRender ensures faults are rendered with the HTTP status of their
+// category and the members of their problem details document.
+func TestRender(t *testing.T) {
+	table := []struct {
+		Error      error
+		Status     int
+		Code       faults.Code
+		Detail     string
+		RetryAfter string
+	}{
+		{
+			Error:  faults.Bad(&faults.FieldViolation{Field: "email", Description: "Field required"}),
+			Status: http.StatusBadRequest,
+			Code:   faults.CodeBad,
+			Detail: "Field required",
+		},
+		{
+			Error:  faults.NotFound,
+			Status: http.StatusNotFound,
+			Code:   faults.CodeNotFound,
+			Detail: "resource not found",
+		},
+		{
+			Error:      faults.Unavailable(2 * time.Second),
+			Status:     http.StatusServiceUnavailable,
+			Code:       faults.CodeUnavailable,
+			RetryAfter: "2",
+		},
+		{
+			Error:  faults.WithInternal(errors.New("pq: relation \"users\" does not exist")),
+			Status: http.StatusInternalServerError,
+			Code:   faults.CodeInternal,
+		},
+	}
+
+	for i, test := range table {
+		rec := httptest.NewRecorder()
+		if err := render.Render(rec, httptest.NewRequest("GET", "/users/42", nil), faultschi.Render(test.Error)); err != nil {
+			t.Fatalf("%d - expect fault to be rendered, but got %s", i, err)
+		}
+
+		if rec.Code != test.Status {
+			t.Errorf("%d - expect status %d, but got %d", i, test.Status, rec.Code)
+		}
+		if ra := rec.Header().Get("Retry-After"); ra != test.RetryAfter {
+			t.Errorf("%d - expect Retry-After %q, but got %q", i, test.RetryAfter, ra)
+		}
+		var p faultshttp.Problem
+		if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+			t.Fatalf("%d - expect problem details document, but got %s", i, err)
+		}
+		if code := faults.CodeOf(p.Err()); code != test.Code {
+			t.Errorf("%d - expect code %s, but got %s", i, test.Code, code)
+		}
+		if p.Detail != test.Detail {
+			t.Errorf("%d - expect detail %q, but got %q", i, test.Detail, p.Detail)
+		}
+		if p.Instance != "/users/42" {
+			t.Errorf("%d - expect instance /users/42, but got %s", i, p.Instance)
+		}
+	}
+}
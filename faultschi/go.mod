@@ -0,0 +1,12 @@
+module github.com/deixis/faults/faultschi
+
+go 1.22.0
+
+replace github.com/deixis/faults => ../
+
+require (
+	github.com/deixis/faults v0.0.0-00010101000000-000000000000
+	github.com/go-chi/render v1.0.3
+)
+
+require github.com/ajg/form v1.5.1 // indirect
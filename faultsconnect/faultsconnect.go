@@ -0,0 +1,85 @@
+// Package faultsconnect bridges faults with Connect (see
+// `connectrpc.com/connect`), so services using Connect over HTTP get the same
+// fault mapping as plain gRPC services (see faultsgrpc).
+//
+// ToError converts a fault to a connect.Error with the code of its category
+// and the standard error details matching its violations, its reason and its
+// retry delay. FromError converts a connect.Error back to a fault on the
+// client side. NewInterceptor performs both conversions for every call:
+//
+//	interceptors := connect.WithInterceptors(faultsconnect.NewInterceptor())
+//	mux.Handle(usersv1connect.NewUsersHandler(srv, interceptors))
+//	client := usersv1connect.NewUsersClient(http.DefaultClient, url, interceptors)
+package faultsconnect
+
+import (
+	"context"
+	"errors"
+
+	"connectrpc.com/connect"
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultsgrpc"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// ToError converts `err` to a connect.Error. The code and the details of the
+// error are the ones of the status returned by faultsgrpc.ToStatus, so the
+// message of server faults is sanitized as well.
+//
+// Errors that are not faults, but that already are connect errors, are
+// returned as they are, and context errors are converted to Canceled and
+// DeadlineExceeded errors, like Connect does. It returns nil when `err` is
+// nil.
+func ToError(err error) *connect.Error {
+	if err == nil {
+		return nil
+	}
+	if faults.CodeOf(err) == faults.CodeUnknown {
+		var connectErr *connect.Error
+		switch {
+		case errors.As(err, &connectErr):
+			return connectErr
+		case errors.Is(err, context.Canceled):
+			return connect.NewError(connect.CodeCanceled, err)
+		case errors.Is(err, context.DeadlineExceeded):
+			return connect.NewError(connect.CodeDeadlineExceeded, err)
+		}
+	}
+
+	st := faultsgrpc.ToStatus(err).Proto()
+	connectErr := connect.NewError(connect.Code(st.GetCode()), errors.New(st.GetMessage()))
+	for _, detail := range st.GetDetails() {
+		if d, err := connect.NewErrorDetail(detail); err == nil {
+			connectErr.AddDetail(d)
+		}
+	}
+	return connectErr
+}
+
+// FromError converts the connect.Error wrapped by `err` back to a fault, with
+// the details attached by ToError (see faultsgrpc.FromStatus).
+//
+// Errors that already have a category (see faults.CodeOf), errors that are
+// not connect errors, and connect errors without a fault category, such as
+// Canceled, are returned as they are.
+func FromError(err error) error {
+	if err == nil || faults.CodeOf(err) != faults.CodeUnknown {
+		return err
+	}
+	var connectErr *connect.Error
+	if !errors.As(err, &connectErr) || faultsgrpc.CodeOf(codes.Code(connectErr.Code())) == faults.CodeUnknown {
+		return err
+	}
+
+	st := &spb.Status{Code: int32(connectErr.Code()), Message: connectErr.Message()}
+	for _, d := range connectErr.Details() {
+		st.Details = append(st.Details, &anypb.Any{
+			TypeUrl: "type.googleapis.com/" + d.Type(),
+			Value:   d.Bytes(),
+		})
+	}
+	return faultsgrpc.FromStatus(status.FromProto(st))
+}
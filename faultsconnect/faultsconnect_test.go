@@ -0,0 +1,113 @@
+package faultsconnect_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultsconnect"
+	"github.com/deixis/faults/faultstest"
+)
+
+// TestToError ensures faults are converted to connect errors.
+func TestToError(t *testing.T) {
+	table := []struct {
+		Error   error
+		Code    connect.Code
+		Message string
+		Details int
+	}{
+		{Error: faults.NotFound, Code: connect.CodeNotFound, Message: "resource not found"},
+		{
+			Error:   faults.Bad(&faults.FieldViolation{Field: "email", Description: "is required"}),
+			Code:    connect.CodeInvalidArgument,
+			Message: faults.Bad(&faults.FieldViolation{Field: "email", Description: "is required"}).Error(),
+			Details: 1,
+		},
+		{Error: faults.WithInternal(errors.New("db password leaked")), Code: connect.CodeInternal, Message: "internal error"},
+		{Error: faults.Unavailable(time.Second), Code: connect.CodeUnavailable, Details: 1},
+		{Error: errors.New("boom"), Code: connect.CodeUnknown, Message: "unknown error"},
+		{Error: connect.NewError(connect.CodeAlreadyExists, errors.New("taken")), Code: connect.CodeAlreadyExists, Message: "taken"},
+		{Error: context.Canceled, Code: connect.CodeCanceled, Message: context.Canceled.Error()},
+		{Error: context.DeadlineExceeded, Code: connect.CodeDeadlineExceeded, Message: context.DeadlineExceeded.Error()},
+	}
+
+	for i, test := range table {
+		err := faultsconnect.ToError(test.Error)
+		if err.Code() != test.Code {
+			t.Errorf("%d - expect code %s, but got %s", i, test.Code, err.Code())
+		}
+		if test.Message != "" && err.Message() != test.Message {
+			t.Errorf("%d - expect message %q, but got %q", i, test.Message, err.Message())
+		}
+		if len(err.Details()) != test.Details {
+			t.Errorf("%d - expect %d details, but got %d", i, test.Details, len(err.Details()))
+		}
+	}
+
+	if err := faultsconnect.ToError(nil); err != nil {
+		t.Errorf("expect nil, but got %v", err)
+	}
+}
+
+// TestFromError ensures connect errors are converted back to faults.
+func TestFromError(t *testing.T) {
+	table := []struct {
+		Error error
+		Code  faults.Code
+	}{
+		{Error: nil, Code: faults.CodeOK},
+		{Error: connect.NewError(connect.CodeNotFound, errors.New("no such user")), Code: faults.CodeNotFound},
+		{Error: connect.NewError(connect.CodeAlreadyExists, errors.New("taken")), Code: faults.CodeAborted},
+		{Error: connect.NewError(connect.CodeUnauthenticated, errors.New("expired")), Code: faults.CodeUnauthenticated},
+		{Error: connect.NewError(connect.CodeCanceled, errors.New("canceled")), Code: faults.CodeUnknown},
+		{Error: errors.New("boom"), Code: faults.CodeUnknown},
+		{Error: faults.PermissionDenied, Code: faults.CodePermissionDenied},
+	}
+
+	for i, test := range table {
+		err := faultsconnect.FromError(test.Error)
+		if code := faults.CodeOf(err); code != test.Code {
+			t.Errorf("%d - expect code %s, but got %s", i, test.Code, code)
+		}
+	}
+
+	err := connect.NewError(connect.CodeCanceled, errors.New("canceled"))
+	if connect.CodeOf(faultsconnect.FromError(err)) != connect.CodeCanceled {
+		t.Error("expect connect error without category to be returned as is")
+	}
+}
+
+// TestConformance ensures connect errors follow the semantics of the core.
+func TestConformance(t *testing.T) {
+	faultstest.ConformanceSuite{
+		RoundTrip: func(err error) error {
+			if err == nil {
+				return nil
+			}
+			return faultsconnect.FromError(faultsconnect.ToError(err))
+		},
+		Status: func(err error) int {
+			return int(faultsconnect.ToError(err).Code())
+		},
+		ExpectedStatus: func(code faults.Code) int {
+			d, _ := faults.Describe(code)
+			return int(d.GRPCCode)
+		},
+		Render: func(err error) string {
+			return faultsconnect.ToError(err).Message()
+		},
+		Encode: func(err error) []byte {
+			var data []byte
+			for _, d := range faultsconnect.ToError(err).Details() {
+				data = append(data, d.Bytes()...)
+			}
+			return data
+		},
+		RetryInfo: true,
+		Details:   true,
+	}.Run(t)
+}
@@ -0,0 +1,22 @@
+module github.com/deixis/faults/faultsconnect
+
+go 1.22.0
+
+replace github.com/deixis/faults => ../
+
+replace github.com/deixis/faults/faultsgrpc => ../faultsgrpc
+
+require (
+	connectrpc.com/connect v1.18.1
+	github.com/deixis/faults v0.0.0-00010101000000-000000000000
+	github.com/deixis/faults/faultsgrpc v0.0.0-00010101000000-000000000000
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20241219192143-6b3ec007d9bb
+	google.golang.org/grpc v1.70.0
+	google.golang.org/protobuf v1.36.0
+)
+
+require (
+	golang.org/x/net v0.33.0 // indirect
+	golang.org/x/sys v0.28.0 // indirect
+	golang.org/x/text v0.21.0 // indirect
+)
@@ -0,0 +1,66 @@
+package faultsconnect
+
+import (
+	"context"
+
+	"connectrpc.com/connect"
+	"github.com/deixis/faults"
+)
+
+// NewInterceptor returns an interceptor converting errors on both sides of a
+// call. On the handler side, the errors returned by handlers are converted to
+// connect errors (see ToError), so handlers only deal with faults. On the
+// client side, the errors returned by calls are converted back to faults (see
+// FromError), so their results can be inspected directly with the faults API.
+//
+// Conversions on the handler side are reported to the trace sinks as
+// EventRender (see faults.Trace).
+func NewInterceptor() connect.Interceptor {
+	return interceptor{}
+}
+
+type interceptor struct{}
+
+func (interceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		resp, err := next(ctx, req)
+		if err == nil {
+			return resp, nil
+		}
+		if req.Spec().IsClient {
+			return resp, FromError(err)
+		}
+		return resp, toError(ctx, err)
+	}
+}
+
+func (interceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return func(ctx context.Context, spec connect.Spec) connect.StreamingClientConn {
+		return &clientConn{StreamingClientConn: next(ctx, spec)}
+	}
+}
+
+func (interceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		if err := next(ctx, conn); err != nil {
+			return toError(ctx, err)
+		}
+		return nil
+	}
+}
+
+// clientConn is a connect.StreamingClientConn converting receive failures to
+// faults.
+type clientConn struct {
+	connect.StreamingClientConn
+}
+
+func (c *clientConn) Receive(m interface{}) error {
+	return FromError(c.StreamingClientConn.Receive(m))
+}
+
+// toError converts the error `err` returned by a handler to a connect error.
+func toError(ctx context.Context, err error) error {
+	faults.Trace(ctx, faults.EventRender, err, "faultsconnect: error")
+	return ToError(err)
+}
@@ -0,0 +1,64 @@
+package faultsconnect_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultsconnect"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// TestInterceptor ensures faults returned by handlers are restored by
+// clients.
+func TestInterceptor(t *testing.T) {
+	table := []struct {
+		Error error
+		Code  faults.Code
+		Delay time.Duration
+	}{
+		{Error: nil, Code: faults.CodeOK},
+		{Error: faults.NotFound, Code: faults.CodeNotFound},
+		{Error: faults.Bad(&faults.FieldViolation{Field: "name", Code: faults.ViolationRequired}), Code: faults.CodeBad},
+		{Error: faults.Unavailable(2 * time.Second), Code: faults.CodeUnavailable, Delay: 2 * time.Second},
+		{Error: errors.New("boom"), Code: faults.CodeUnknown},
+	}
+
+	for i, test := range table {
+		interceptors := connect.WithInterceptors(faultsconnect.NewInterceptor())
+		mux := http.NewServeMux()
+		mux.Handle("/users.v1.Users/Get", connect.NewUnaryHandler("/users.v1.Users/Get",
+			func(ctx context.Context, req *connect.Request[wrapperspb.StringValue]) (*connect.Response[wrapperspb.StringValue], error) {
+				if test.Error != nil {
+					return nil, test.Error
+				}
+				return connect.NewResponse(req.Msg), nil
+			},
+			interceptors,
+		))
+		srv := httptest.NewServer(mux)
+		defer srv.Close()
+
+		client := connect.NewClient[wrapperspb.StringValue, wrapperspb.StringValue](
+			srv.Client(), srv.URL+"/users.v1.Users/Get", interceptors,
+		)
+		_, err := client.CallUnary(context.Background(), connect.NewRequest(wrapperspb.String("42")))
+		if code := faults.CodeOf(err); code != test.Code {
+			t.Errorf("%d - expect code %s, but got %s", i, test.Code, code)
+		}
+		if delay := faults.RetryAdvice(err).Delay; delay != test.Delay {
+			t.Errorf("%d - expect retry delay %s, but got %s", i, test.Delay, delay)
+		}
+		if test.Code == faults.CodeBad {
+			bad, ok := faults.AsBad(err)
+			if !ok || len(bad.Violations) != 1 || bad.Violations[0].Code != faults.ViolationRequired {
+				t.Errorf("%d - expect required violation, but got %v", i, err)
+			}
+		}
+	}
+}
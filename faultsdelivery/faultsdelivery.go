@@ -0,0 +1,177 @@
+// Package faultsdelivery classifies the failures of outbound deliveries, such
+// as emails sent over SMTP and webhooks sent over HTTP, so notification
+// services can schedule redeliveries from the category of a failure.
+//
+// Transient failures are classified as Unavailable, which is retryable (see
+// faults.RetryAdvice), while permanent failures are classified with a
+// category that is not retryable, e.g. NotFound for an unknown mailbox or a
+// webhook endpoint that is gone.
+package faultsdelivery
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/textproto"
+
+	"github.com/deixis/faults"
+)
+
+// SMTPClassifier classifies the replies of SMTP servers, as returned by
+// `net/smtp` in a `*textproto.Error`:
+//
+//   - 4xx transient replies are classified as Unavailable
+//   - 530, 534, 535 and 538 are classified as Unauthenticated
+//   - 550, 551 and 553 (mailbox unavailable) are classified as NotFound
+//   - 552 (storage exceeded) is classified as ResourceExhausted
+//   - 521, 554 and 556 (rejected) are classified as PermissionDenied
+//   - 500, 501, 503 and 555 (syntax errors) are classified as Bad
+//   - 502 and 504 (not implemented) are classified as Unimplemented
+//
+// It can be registered with faults.RegisterClassifier, or applied explicitly
+// with FromSMTP.
+var SMTPClassifier faults.Classifier = faults.ClassifierFunc(classifySMTP)
+
+func classifySMTP(err error) (faults.Code, bool) {
+	var reply *textproto.Error
+	if !errors.As(err, &reply) {
+		return faults.CodeUnknown, false
+	}
+
+	switch reply.Code {
+	case 530, 534, 535, 538:
+		return faults.CodeUnauthenticated, true
+	case 550, 551, 553:
+		return faults.CodeNotFound, true
+	case 552:
+		return faults.CodeResourceExhausted, true
+	case 521, 554, 556:
+		return faults.CodePermissionDenied, true
+	case 500, 501, 503, 555:
+		return faults.CodeBad, true
+	case 502, 504:
+		return faults.CodeUnimplemented, true
+	}
+	if reply.Code >= 400 && reply.Code < 500 {
+		return faults.CodeUnavailable, true
+	}
+	return faults.CodeUnknown, false
+}
+
+// FromSMTP wraps the error returned by an SMTP client with the fault matching
+// the reply of the server (see SMTPClassifier). Network failures are
+// classified as Unavailable, and timeouts as DeadlineExceeded wrapped with
+// Unavailable, since a delivery that timed out can be attempted again.
+//
+// `err` is returned as is when it already carries a fault, or when it is not
+// recognised.
+func FromSMTP(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := faults.FaultClassifier.Classify(err); ok {
+		return err
+	}
+
+	code, ok := classifySMTP(err)
+	if !ok {
+		return fromNetwork(err)
+	}
+	if d, ok := faults.Describe(code); ok && d.New != nil {
+		return d.New(err)
+	}
+	return err
+}
+
+// A StatusError is the error returned by FromWebhook when the endpoint
+// responds with an unexpected status.
+type StatusError struct {
+	// StatusCode is the HTTP status code of the response, e.g. 503.
+	StatusCode int
+	// Status is the HTTP status of the response, e.g. "503 Service
+	// Unavailable".
+	Status string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("webhook: unexpected status %s", e.Status)
+}
+
+// FromWebhook classifies the outcome of a webhook delivery. It is meant to be
+// used with the values returned by `http.Client.Do`:
+//
+//	resp, err := client.Do(req)
+//	if err := faultsdelivery.FromWebhook(resp, err); err != nil {
+//		if advice := faults.RetryAdvice(err); advice.Retryable {
+//			// Schedule a redelivery...
+//		}
+//		return err
+//	}
+//
+// It returns nil for 2xx responses. Otherwise:
+//
+//   - 408 and 5xx responses are classified as Unavailable, except 501 which
+//     is classified as Unimplemented
+//   - 429 responses are classified as ResourceExhausted, and are retryable
+//     after the delay advised by the Retry-After header
+//   - 401 responses are classified as Unauthenticated, and 403 responses as
+//     PermissionDenied
+//   - 404 and 410 responses are classified as NotFound
+//   - other responses are classified as Bad, since they are not expected to
+//     succeed if delivered again
+//
+// Network failures are classified as Unavailable, and timeouts as
+// DeadlineExceeded wrapped with Unavailable. It returns nil when there is
+// neither a response nor an error.
+func FromWebhook(resp *http.Response, err error) error {
+	if err != nil {
+		return fromNetwork(err)
+	}
+	if resp == nil || resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	statusErr := &StatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+	if statusErr.Status == "" {
+		statusErr.Status = fmt.Sprintf("%d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+
+	switch code := resp.StatusCode; {
+	case code == http.StatusTooManyRequests:
+		err := faults.WithResourceExhausted(statusErr)
+		if e, ok := faults.AsResourceExhausted(err); ok {
+			e.RetryInfo.RetryDelay = faults.ParseRetryAfter(resp.Header.Get("Retry-After"))
+		}
+		return err
+	case code == http.StatusRequestTimeout:
+		return faults.WithUnavailable(statusErr, 0)
+	case code == http.StatusNotImplemented:
+		return faults.WithUnimplemented(statusErr)
+	case code >= 500:
+		return faults.WithUnavailable(statusErr, faults.ParseRetryAfter(resp.Header.Get("Retry-After")))
+	case code == http.StatusUnauthorized:
+		return faults.WithUnauthenticated(statusErr)
+	case code == http.StatusForbidden:
+		return faults.WithPermissionDenied(statusErr)
+	case code == http.StatusNotFound, code == http.StatusGone:
+		return faults.WithNotFound(statusErr)
+	}
+	return faults.WithBad(statusErr)
+}
+
+// fromNetwork classifies the network failure `err`.
+func fromNetwork(err error) error {
+	if _, ok := faults.FaultClassifier.Classify(err); ok {
+		return err
+	}
+
+	var netErr net.Error
+	if !errors.As(err, &netErr) {
+		return err
+	}
+	if netErr.Timeout() {
+		return faults.WithUnavailable(faults.WithDeadlineExceeded(err), 0)
+	}
+	return faults.WithUnavailable(err, 0)
+}
@@ -0,0 +1,162 @@
+package faultsdelivery_test
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/textproto"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultsdelivery"
+)
+
+// TestFromSMTP ensures SMTP replies are classified with their retry advice.
+func TestFromSMTP(t *testing.T) {
+	table := []struct {
+		Error     error
+		Code      faults.Code
+		Retryable bool
+	}{
+		{Error: nil, Code: faults.CodeOK},
+		{Error: errors.New("boom"), Code: faults.CodeUnknown},
+		{Error: faults.NotFound, Code: faults.CodeNotFound},
+		{Error: smtpError(421), Code: faults.CodeUnavailable, Retryable: true},
+		{Error: smtpError(451), Code: faults.CodeUnavailable, Retryable: true},
+		{Error: smtpError(452), Code: faults.CodeUnavailable, Retryable: true},
+		{Error: smtpError(535), Code: faults.CodeUnauthenticated},
+		{Error: smtpError(550), Code: faults.CodeNotFound},
+		{Error: smtpError(552), Code: faults.CodeResourceExhausted},
+		{Error: smtpError(554), Code: faults.CodePermissionDenied},
+		{Error: smtpError(501), Code: faults.CodeBad},
+		{Error: smtpError(502), Code: faults.CodeUnimplemented},
+		{Error: smtpError(599), Code: faults.CodeUnknown},
+		{Error: &net.OpError{Op: "dial", Err: os.ErrDeadlineExceeded}, Code: faults.CodeUnavailable, Retryable: true},
+		{Error: &net.OpError{Op: "dial", Err: errors.New("refused")}, Code: faults.CodeUnavailable, Retryable: true},
+	}
+
+	for i, test := range table {
+		err := faultsdelivery.FromSMTP(test.Error)
+		if code := faults.CodeOf(err); code != test.Code {
+			t.Errorf("%d - expect code %s, but got %s", i, test.Code, code)
+		}
+		if retryable := faults.IsRetryable(err); retryable != test.Retryable {
+			t.Errorf("%d - expect retryable %t, but got %t", i, test.Retryable, retryable)
+		}
+		if test.Error != nil && !errors.Is(err, test.Error) {
+			t.Errorf("%d - expect SMTP error to be wrapped", i)
+		}
+	}
+}
+
+// TestSMTPClassifier ensures SMTP replies can be classified without being
+// wrapped.
+func TestSMTPClassifier(t *testing.T) {
+	if code, ok := faultsdelivery.SMTPClassifier.Classify(smtpError(550)); !ok || code != faults.CodeNotFound {
+		t.Errorf("expect code %s, but got %s (%t)", faults.CodeNotFound, code, ok)
+	}
+	if _, ok := faultsdelivery.SMTPClassifier.Classify(errors.New("boom")); ok {
+		t.Error("expect unknown error not to be classified")
+	}
+}
+
+// TestFromWebhook ensures webhook responses are classified with their retry
+// advice.
+func TestFromWebhook(t *testing.T) {
+	timeout := &net.OpError{Op: "read", Err: os.ErrDeadlineExceeded}
+
+	table := []struct {
+		Response *http.Response
+		Error    error
+		Code     faults.Code
+		Advice   faults.Advice
+	}{
+		{Response: response(200, ""), Code: faults.CodeOK},
+		{Response: response(204, ""), Code: faults.CodeOK},
+		{Response: response(400, ""), Code: faults.CodeBad},
+		{Response: response(401, ""), Code: faults.CodeUnauthenticated},
+		{Response: response(403, ""), Code: faults.CodePermissionDenied},
+		{Response: response(404, ""), Code: faults.CodeNotFound},
+		{Response: response(410, ""), Code: faults.CodeNotFound},
+		{Response: response(302, ""), Code: faults.CodeBad},
+		{
+			Response: response(408, ""),
+			Code:     faults.CodeUnavailable,
+			Advice:   faults.Advice{Retryable: true},
+		},
+		{Response: response(429, ""), Code: faults.CodeResourceExhausted},
+		{
+			Response: response(429, "30"),
+			Code:     faults.CodeResourceExhausted,
+			Advice:   faults.Advice{Retryable: true, Delay: 30 * time.Second},
+		},
+		{
+			Response: response(503, "5"),
+			Code:     faults.CodeUnavailable,
+			Advice:   faults.Advice{Retryable: true, Delay: 5 * time.Second},
+		},
+		{
+			Response: response(500, ""),
+			Code:     faults.CodeUnavailable,
+			Advice:   faults.Advice{Retryable: true},
+		},
+		{Response: response(501, ""), Code: faults.CodeUnimplemented},
+		{
+			Error:  timeout,
+			Code:   faults.CodeUnavailable,
+			Advice: faults.Advice{Retryable: true},
+		},
+		{Error: errors.New("boom"), Code: faults.CodeUnknown},
+		{Response: nil, Error: nil, Code: faults.CodeOK},
+	}
+
+	for i, test := range table {
+		err := faultsdelivery.FromWebhook(test.Response, test.Error)
+		if code := faults.CodeOf(err); code != test.Code {
+			t.Errorf("%d - expect code %s, but got %s", i, test.Code, code)
+		}
+		if advice := faults.RetryAdvice(err); advice != test.Advice {
+			t.Errorf("%d - expect advice %+v, but got %+v", i, test.Advice, advice)
+		}
+	}
+
+	err := faultsdelivery.FromWebhook(nil, timeout)
+	if !faults.IsDeadlineExceeded(err) {
+		t.Error("expect timeout to be classified as deadline exceeded")
+	}
+
+	var statusErr *faultsdelivery.StatusError
+	err = faultsdelivery.FromWebhook(response(404, ""), nil)
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != 404 {
+		t.Errorf("expect status error to be wrapped, but got %v", err)
+	}
+}
+
+// TestFromWebhookDebug ensures retry delays are kept in debug mode, where
+// faults carry a DebugInfo.
+func TestFromWebhookDebug(t *testing.T) {
+	faults.SetDebug(true)
+	defer faults.SetDebug(false)
+
+	err := faultsdelivery.FromWebhook(response(429, "30"), nil)
+	if !faults.IsResourceExhausted(err) {
+		t.Fatalf("expect resource exhausted, but got %v", err)
+	}
+	if delay := faults.RetryAdvice(err).Delay; delay != 30*time.Second {
+		t.Errorf("expect retry delay 30s, but got %s", delay)
+	}
+}
+
+func smtpError(code int) error {
+	return &textproto.Error{Code: code, Msg: "reply"}
+}
+
+func response(status int, retryAfter string) *http.Response {
+	resp := &http.Response{StatusCode: status, Header: http.Header{}}
+	if retryAfter != "" {
+		resp.Header.Set("Retry-After", retryAfter)
+	}
+	return resp
+}
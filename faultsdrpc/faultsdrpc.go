@@ -0,0 +1,140 @@
+// Package faultsdrpc converts faults to DRPC errors (see `storj.io/drpc`),
+// and back.
+//
+// A DRPC error frame only carries a numeric code (see
+// `storj.io/drpc/drpcerr`) and a message. The code of a fault is the gRPC
+// code of its category (see faults.Descriptor), following the convention of
+// DRPC itself, so the category survives any DRPC peer. ToDetailedError also
+// appends the binary representation of the fault (see faults.Encode) to the
+// message, so its violations, its reason and its retry delay survive as well
+// when both sides use this package.
+//
+// Handler and Conn apply the conversions on both sides of a call:
+//
+//	srv := drpcserver.New(&faultsdrpc.Handler{Handler: mux, Details: true})
+//	client := pb.NewDRPCUsersClient(&faultsdrpc.Conn{Conn: conn})
+package faultsdrpc
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/deixis/faults"
+	"storj.io/drpc/drpcerr"
+)
+
+// detailsSeparator separates the message of a DRPC error from the binary
+// representation of the fault appended by ToDetailedError.
+const detailsSeparator = "\x00"
+
+// ToError converts `err` to a DRPC error carrying the code of its category.
+//
+// Like for HTTP responses, the message of `err` is only used for client
+// faults. Server faults are described by the message of their category alone
+// (see faults.Sanitize). It returns nil when `err` is nil.
+func ToError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return drpcerr.WithCode(errors.New(message(err)), CodeOf(err))
+}
+
+// ToDetailedError is like ToError, but it also appends the binary
+// representation of the fault to the message, after a NUL character. Peers
+// that don't use FromError see it as trailing bytes, so it should only be
+// used when both sides use this package.
+func ToDetailedError(err error) error {
+	if err == nil {
+		return nil
+	}
+	outbound := err
+	if faults.IsServerFault(err) {
+		outbound = faults.Sanitize(err)
+	}
+	details, encodeErr := faults.Encode(outbound)
+	if encodeErr != nil {
+		return ToError(err)
+	}
+	return drpcerr.WithCode(errors.New(message(err)+detailsSeparator+string(details)), CodeOf(err))
+}
+
+// FromError converts the DRPC error `err` back to a fault.
+//
+// Errors produced by ToDetailedError are restored with their details, and
+// the other errors carrying a code are classified from it (see CategoryOf).
+// Errors that already have a category (see faults.CodeOf), and errors without
+// a code, such as transport failures, are returned as they are.
+func FromError(err error) error {
+	if err == nil || faults.CodeOf(err) != faults.CodeUnknown {
+		return err
+	}
+	code := drpcerr.Code(err)
+	if code == 0 {
+		return err
+	}
+
+	msg, details, ok := strings.Cut(err.Error(), detailsSeparator)
+	if ok {
+		if fault := faults.Decode([]byte(details)); faults.CodeOf(fault) != faults.CodeUnknown {
+			return fault
+		}
+	}
+	parent := errors.New(msg)
+	if d, ok := faults.Describe(CategoryOf(code)); ok && d.New != nil {
+		return d.New(parent)
+	}
+	return drpcerr.WithCode(parent, code)
+}
+
+// CodeOf returns the DRPC error code matching the category of `err`, which is
+// the gRPC code of the category. It returns 0 when `err` is nil.
+func CodeOf(err error) uint64 {
+	if err == nil {
+		return 0
+	}
+	if d, ok := faults.Describe(faults.CodeOf(err)); ok {
+		return uint64(d.GRPCCode)
+	}
+	return 2 // Unknown
+}
+
+// CategoryOf returns the fault category of the DRPC error code `code`, read
+// as a gRPC code. Codes without a category, such as Canceled, are classified
+// as Unknown.
+func CategoryOf(code uint64) faults.Code {
+	switch code {
+	case 0:
+		return faults.CodeOK
+	case 3, 11: // InvalidArgument, OutOfRange
+		return faults.CodeBad
+	case 4:
+		return faults.CodeDeadlineExceeded
+	case 5:
+		return faults.CodeNotFound
+	case 6, 10: // AlreadyExists, Aborted
+		return faults.CodeAborted
+	case 7:
+		return faults.CodePermissionDenied
+	case 8:
+		return faults.CodeResourceExhausted
+	case 9:
+		return faults.CodeFailedPrecondition
+	case 12:
+		return faults.CodeUnimplemented
+	case 13, 15: // Internal, DataLoss
+		return faults.CodeInternal
+	case 14:
+		return faults.CodeUnavailable
+	case 16:
+		return faults.CodeUnauthenticated
+	}
+	return faults.CodeUnknown
+}
+
+// message returns the message of `err` as seen by the peer.
+func message(err error) string {
+	if faults.IsServerFault(err) {
+		return faults.Sanitize(err).Error()
+	}
+	return err.Error()
+}
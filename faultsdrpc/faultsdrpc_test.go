@@ -0,0 +1,131 @@
+package faultsdrpc_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultsdrpc"
+	"github.com/deixis/faults/faultstest"
+	"storj.io/drpc/drpcerr"
+	"storj.io/drpc/drpcwire"
+)
+
+// wire sends `err` through a DRPC error frame.
+func wire(err error) error {
+	if err == nil {
+		return nil
+	}
+	return drpcwire.UnmarshalError(drpcwire.MarshalError(err))
+}
+
+// TestRoundTrip ensures the category of faults survives a DRPC error frame.
+func TestRoundTrip(t *testing.T) {
+	table := []struct {
+		Error   error
+		Code    uint64
+		Message string
+	}{
+		{Error: faults.Bad(), Code: 3, Message: faults.Bad().Error()},
+		{Error: faults.NotFound, Code: 5, Message: "resource not found"},
+		{Error: faults.PermissionDenied, Code: 7},
+		{Error: faults.Unauthenticated, Code: 16},
+		{Error: faults.FailedPrecondition(), Code: 9},
+		{Error: faults.Aborted(), Code: 10},
+		{Error: faults.ResourceExhausted(), Code: 8},
+		{Error: faults.Unavailable(time.Second), Code: 14},
+		{Error: faults.DeadlineExceeded, Code: 4},
+		{Error: faults.Unimplemented, Code: drpcerr.Unimplemented},
+		{Error: faults.WithInternal(errors.New("db password leaked")), Code: 13, Message: "internal error"},
+		{Error: errors.New("boom"), Code: 2, Message: "unknown error"},
+	}
+
+	for i, test := range table {
+		err := wire(faultsdrpc.ToError(test.Error))
+		if code := drpcerr.Code(err); code != test.Code {
+			t.Errorf("%d - expect DRPC code %d, but got %d", i, test.Code, code)
+		}
+		if test.Message != "" && err.Error() != test.Message {
+			t.Errorf("%d - expect message %q, but got %q", i, test.Message, err)
+		}
+
+		err = faultsdrpc.FromError(err)
+		if code := faults.CodeOf(err); code != faults.CodeOf(test.Error) {
+			t.Errorf("%d - expect code %s, but got %s", i, faults.CodeOf(test.Error), code)
+		}
+	}
+
+	if faultsdrpc.ToError(nil) != nil || faultsdrpc.ToDetailedError(nil) != nil {
+		t.Error("expect nil")
+	}
+}
+
+// TestToDetailedError ensures the details of faults survive a DRPC error
+// frame.
+func TestToDetailedError(t *testing.T) {
+	fault := faults.WithReason(
+		faults.Bad(&faults.FieldViolation{Field: "email", Code: faults.ViolationRequired}),
+		"INVALID_FORM",
+	)
+	err := faultsdrpc.FromError(wire(faultsdrpc.ToDetailedError(fault)))
+	bad, ok := faults.AsBad(err)
+	if !ok || len(bad.Violations) != 1 || bad.Violations[0].Field != "email" {
+		t.Errorf("expect email violation, but got %v", err)
+	}
+	if reason := faults.ReasonOf(err); reason != "INVALID_FORM" {
+		t.Errorf("expect reason INVALID_FORM, but got %q", reason)
+	}
+
+	err = faultsdrpc.FromError(wire(faultsdrpc.ToDetailedError(faults.Unavailable(2 * time.Second))))
+	if delay := faults.RetryAdvice(err).Delay; delay != 2*time.Second {
+		t.Errorf("expect retry delay 2s, but got %s", delay)
+	}
+
+	err = faultsdrpc.FromError(wire(faultsdrpc.ToDetailedError(faults.WithInternal(errors.New("db password leaked")))))
+	if !faults.IsInternal(err) || err.Error() != "internal error" {
+		t.Errorf("expect sanitized internal error, but got %q", err)
+	}
+}
+
+// TestFromError ensures DRPC errors that were not produced by this package
+// are classified too.
+func TestFromError(t *testing.T) {
+	table := []struct {
+		Error error
+		Code  faults.Code
+	}{
+		{Error: nil, Code: faults.CodeOK},
+		{Error: drpcerr.WithCode(errors.New("no such user"), 5), Code: faults.CodeNotFound},
+		{Error: drpcerr.WithCode(errors.New("taken"), 6), Code: faults.CodeAborted},
+		{Error: drpcerr.WithCode(errors.New("canceled"), 1), Code: faults.CodeUnknown},
+		{Error: drpcerr.WithCode(errors.New("custom"), 1042), Code: faults.CodeUnknown},
+		{Error: errors.New("connection reset"), Code: faults.CodeUnknown},
+		{Error: faults.NotFound, Code: faults.CodeNotFound},
+	}
+
+	for i, test := range table {
+		err := faultsdrpc.FromError(test.Error)
+		if code := faults.CodeOf(err); code != test.Code {
+			t.Errorf("%d - expect code %s, but got %s", i, test.Code, code)
+		}
+		if test.Code == faults.CodeUnknown && drpcerr.Code(err) != drpcerr.Code(test.Error) {
+			t.Errorf("%d - expect DRPC code to be kept, but got %d", i, drpcerr.Code(err))
+		}
+	}
+}
+
+// TestConformance ensures detailed DRPC errors follow the semantics of the
+// core.
+func TestConformance(t *testing.T) {
+	faultstest.ConformanceSuite{
+		RoundTrip: func(err error) error {
+			return faultsdrpc.FromError(wire(faultsdrpc.ToDetailedError(err)))
+		},
+		Render: func(err error) string {
+			return faultsdrpc.ToError(err).Error()
+		},
+		RetryInfo: true,
+		Details:   true,
+	}.Run(t)
+}
@@ -0,0 +1,12 @@
+module github.com/deixis/faults/faultsdrpc
+
+go 1.22.0
+
+replace github.com/deixis/faults => ../
+
+require (
+	github.com/deixis/faults v0.0.0-00010101000000-000000000000
+	storj.io/drpc v0.0.34
+)
+
+require github.com/zeebo/errs v1.2.2 // indirect
@@ -0,0 +1,66 @@
+package faultsdrpc
+
+import (
+	"context"
+
+	"github.com/deixis/faults"
+	"storj.io/drpc"
+)
+
+// A Handler is a drpc.Handler converting the errors returned by the handler it
+// wraps, e.g. a drpcmux.Mux, into DRPC errors, so services only deal with
+// faults.
+//
+// Conversions are reported to the trace sinks as EventRender (see
+// faults.Trace).
+type Handler struct {
+	drpc.Handler
+
+	// Details converts errors with ToDetailedError instead of ToError.
+	Details bool
+}
+
+// HandleRPC handles the rpc `rpc` with the wrapped handler.
+func (h *Handler) HandleRPC(stream drpc.Stream, rpc string) error {
+	err := h.Handler.HandleRPC(stream, rpc)
+	if err == nil {
+		return nil
+	}
+
+	faults.Trace(stream.Context(), faults.EventRender, err, "faultsdrpc: error")
+	if h.Details {
+		return ToDetailedError(err)
+	}
+	return ToError(err)
+}
+
+// A Conn is a drpc.Conn converting the errors returned by calls back to faults
+// (see FromError), so their results can be inspected directly with the faults
+// API.
+type Conn struct {
+	drpc.Conn
+}
+
+// Invoke issues a unary rpc with the wrapped connection.
+func (c *Conn) Invoke(ctx context.Context, rpc string, enc drpc.Encoding, in, out drpc.Message) error {
+	return FromError(c.Conn.Invoke(ctx, rpc, enc, in, out))
+}
+
+// NewStream starts a stream with the wrapped connection. The errors returned
+// when receiving messages are converted too.
+func (c *Conn) NewStream(ctx context.Context, rpc string, enc drpc.Encoding) (drpc.Stream, error) {
+	stream, err := c.Conn.NewStream(ctx, rpc, enc)
+	if err != nil {
+		return nil, FromError(err)
+	}
+	return &clientStream{Stream: stream}, nil
+}
+
+// clientStream is a drpc.Stream converting receive failures to faults.
+type clientStream struct {
+	drpc.Stream
+}
+
+func (s *clientStream) MsgRecv(msg drpc.Message, enc drpc.Encoding) error {
+	return FromError(s.Stream.MsgRecv(msg, enc))
+}
@@ -0,0 +1,107 @@
+package faultsdrpc_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultsdrpc"
+	"storj.io/drpc"
+	"storj.io/drpc/drpcerr"
+)
+
+// TestHandler ensures the errors returned by handlers are converted into
+// DRPC errors.
+func TestHandler(t *testing.T) {
+	table := []struct {
+		Details bool
+		Error   error
+		Code    uint64
+	}{
+		{Error: nil, Code: 0},
+		{Error: faults.NotFound, Code: 5},
+		{Error: faults.Bad(&faults.FieldViolation{Field: "email"}), Details: true, Code: 3},
+		{Error: errors.New("boom"), Code: 2},
+	}
+
+	for i, test := range table {
+		h := &faultsdrpc.Handler{
+			Handler: handlerFunc(func(drpc.Stream, string) error { return test.Error }),
+			Details: test.Details,
+		}
+		err := h.HandleRPC(&stream{}, "/users.v1.Users/Get")
+		if code := drpcerr.Code(err); code != test.Code {
+			t.Errorf("%d - expect DRPC code %d, but got %d", i, test.Code, code)
+		}
+		if code := faults.CodeOf(faultsdrpc.FromError(err)); code != faults.CodeOf(test.Error) {
+			t.Errorf("%d - expect code %s, but got %s", i, faults.CodeOf(test.Error), code)
+		}
+	}
+}
+
+// TestConn ensures the errors returned by calls are converted back to faults.
+func TestConn(t *testing.T) {
+	conn := &faultsdrpc.Conn{Conn: &fakeConn{err: faultsdrpc.ToError(faults.PermissionDenied)}}
+	if err := conn.Invoke(context.Background(), "/users.v1.Users/Get", nil, nil, nil); !faults.IsPermissionDenied(err) {
+		t.Errorf("expect permission denied, but got %v", err)
+	}
+	if _, err := conn.NewStream(context.Background(), "/users.v1.Users/List", nil); !faults.IsPermissionDenied(err) {
+		t.Errorf("expect permission denied, but got %v", err)
+	}
+
+	table := []struct {
+		Error error
+		Code  faults.Code
+	}{
+		{Error: nil, Code: faults.CodeOK},
+		{Error: io.EOF, Code: faults.CodeUnknown},
+		{Error: faultsdrpc.ToError(faults.Unauthenticated), Code: faults.CodeUnauthenticated},
+	}
+	for i, test := range table {
+		conn := &faultsdrpc.Conn{Conn: &fakeConn{stream: &stream{err: test.Error}}}
+		s, err := conn.NewStream(context.Background(), "/users.v1.Users/List", nil)
+		if err != nil {
+			t.Fatalf("%d - expect stream to be opened, but got %s", i, err)
+		}
+		err = s.MsgRecv(nil, nil)
+		if code := faults.CodeOf(err); code != test.Code {
+			t.Errorf("%d - expect code %s, but got %s", i, test.Code, code)
+		}
+		if test.Error == io.EOF && err != io.EOF {
+			t.Errorf("%d - expect EOF to be returned as is, but got %v", i, err)
+		}
+	}
+}
+
+type handlerFunc func(drpc.Stream, string) error
+
+func (f handlerFunc) HandleRPC(stream drpc.Stream, rpc string) error { return f(stream, rpc) }
+
+// stream is a drpc.Stream failing to receive messages with `err`.
+type stream struct {
+	drpc.Stream
+	err error
+}
+
+func (s *stream) Context() context.Context                  { return context.Background() }
+func (s *stream) MsgRecv(drpc.Message, drpc.Encoding) error { return s.err }
+
+// fakeConn is a drpc.Conn failing calls with `err`, and opening `stream`.
+type fakeConn struct {
+	drpc.Conn
+	err    error
+	stream drpc.Stream
+}
+
+func (c *fakeConn) Invoke(context.Context, string, drpc.Encoding, drpc.Message, drpc.Message) error {
+	return c.err
+}
+
+func (c *fakeConn) NewStream(context.Context, string, drpc.Encoding) (drpc.Stream, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c.stream, nil
+}
@@ -0,0 +1,94 @@
+// Package faultsecho renders the errors of Echo handlers (see
+// `github.com/labstack/echo/v4`) with faultshttp.
+//
+// Handlers return faults as usual, and the error handler renders them with
+// the HTTP status of their category and a problem details document
+// describing them:
+//
+//	e := echo.New()
+//	e.HTTPErrorHandler = faultsecho.HTTPErrorHandler
+//	e.GET("/users/:id", func(c echo.Context) error {
+//		user, err := users.Get(c.Request().Context(), c.Param("id"))
+//		if err != nil {
+//			return err
+//		}
+//		return c.JSON(http.StatusOK, user)
+//	})
+//
+// The errors produced by Echo itself, such as the ones of its router and of
+// its binder, are converted to the fault matching their status.
+package faultsecho
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultshttp"
+	"github.com/labstack/echo/v4"
+)
+
+// HTTPErrorHandler renders errors as problem details documents (see
+// faultshttp.WriteProblem).
+var HTTPErrorHandler echo.HTTPErrorHandler = NewHTTPErrorHandler(faultshttp.DefaultErrorHandler)
+
+// NewHTTPErrorHandler returns an Echo error handler rendering errors with
+// `eh`, e.g. to render them as JSON:API error documents:
+//
+//	e.HTTPErrorHandler = faultsecho.NewHTTPErrorHandler(
+//		&faultshttp.ErrorHandler{Encoder: faultshttp.JSONAPIEncoder},
+//	)
+//
+// Errors returned after the response was committed can't be rendered
+// anymore, and are ignored.
+func NewHTTPErrorHandler(eh *faultshttp.ErrorHandler) echo.HTTPErrorHandler {
+	return func(err error, c echo.Context) {
+		if c.Response().Committed {
+			return
+		}
+		eh.WriteError(c.Response(), c.Request(), FromError(err))
+	}
+}
+
+// FromError converts an error returned by an Echo handler to a fault.
+//
+// An echo.HTTPError is converted to its internal error when it has a
+// category (see faults.CodeOf), or to the fault matching its status otherwise
+// (see faults.CodeOfHTTPStatus), with its message. Other errors, including
+// faults, are returned as is.
+func FromError(err error) error {
+	var he *echo.HTTPError
+	if !errors.As(err, &he) {
+		return err
+	}
+	if he.Internal != nil && faults.CodeOf(he.Internal) != faults.CodeUnknown {
+		return he.Internal
+	}
+
+	message := http.StatusText(he.Code)
+	if he.Message != nil {
+		message = fmt.Sprint(he.Message)
+	}
+	parent := &httpError{message: message, err: err}
+	if d, ok := faults.Describe(faults.CodeOfHTTPStatus(he.Code)); ok && d.New != nil {
+		return d.New(parent)
+	}
+	return parent
+}
+
+// httpError carries the message of an echo.HTTPError, while keeping it in the
+// chain, so its internal error can still be inspected by the hook of the
+// ErrorHandler, e.g. for logging.
+type httpError struct {
+	message string
+	err     error
+}
+
+func (e *httpError) Error() string {
+	return e.message
+}
+
+func (e *httpError) Unwrap() error {
+	return e.err
+}
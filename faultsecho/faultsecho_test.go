@@ -0,0 +1,130 @@
+package faultsecho_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultsecho"
+	"github.com/deixis/faults/faultshttp"
+	"github.com/labstack/echo/v4"
+)
+
+// TestHTTPErrorHandler ensures handler errors are rendered as problem details
+// documents with the HTTP status of their category.
+func TestHTTPErrorHandler(t *testing.T) {
+	table := []struct {
+		Error  error
+		Status int
+		Code   faults.Code
+		Detail string
+	}{
+		{
+			Error:  faults.Bad(&faults.FieldViolation{Field: "email", Description: "Field required"}),
+			Status: http.StatusBadRequest,
+			Code:   faults.CodeBad,
+			Detail: "Field required",
+		},
+		{
+			Error:  fmt.Errorf("get user: %w", faults.NotFound),
+			Status: http.StatusNotFound,
+			Code:   faults.CodeNotFound,
+			Detail: "get user: resource not found",
+		},
+		{
+			Error:  faults.Unavailable(2 * time.Second),
+			Status: http.StatusServiceUnavailable,
+			Code:   faults.CodeUnavailable,
+		},
+		{
+			Error:  echo.NewHTTPError(http.StatusBadRequest, "invalid JSON body"),
+			Status: http.StatusBadRequest,
+			Code:   faults.CodeBad,
+			Detail: "bad request: invalid JSON body",
+		},
+		{
+			Error:  echo.NewHTTPError(http.StatusUnauthorized).SetInternal(faults.PermissionDenied),
+			Status: http.StatusForbidden,
+			Code:   faults.CodePermissionDenied,
+			Detail: "permission denied",
+		},
+		{
+			Error:  echo.ErrNotFound,
+			Status: http.StatusNotFound,
+			Code:   faults.CodeNotFound,
+			Detail: "resource not found",
+		},
+		{
+			Error:  errors.New("pq: relation \"users\" does not exist"),
+			Status: http.StatusInternalServerError,
+			Code:   faults.CodeUnknown,
+		},
+	}
+
+	e := echo.New()
+	e.HTTPErrorHandler = faultsecho.HTTPErrorHandler
+	for i, test := range table {
+		e.GET(fmt.Sprintf("/test/%d", i), func(echo.Context) error {
+			return test.Error
+		})
+	}
+
+	for i, test := range table {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest("GET", fmt.Sprintf("/test/%d", i), nil))
+
+		if rec.Code != test.Status {
+			t.Errorf("%d - expect status %d, but got %d", i, test.Status, rec.Code)
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != faultshttp.ProblemContentType {
+			t.Errorf("%d - expect problem details, but got %s", i, ct)
+		}
+		var p faultshttp.Problem
+		if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+			t.Fatalf("%d - expect problem details document, but got %s", i, err)
+		}
+		if code := faults.CodeOf(p.Err()); code != test.Code {
+			t.Errorf("%d - expect code %s, but got %s", i, test.Code, code)
+		}
+		if p.Detail != test.Detail {
+			t.Errorf("%d - expect detail %q, but got %q", i, test.Detail, p.Detail)
+		}
+	}
+}
+
+// TestRetryAfter ensures availability faults advise a retry delay.
+func TestRetryAfter(t *testing.T) {
+	e := echo.New()
+	e.HTTPErrorHandler = faultsecho.HTTPErrorHandler
+	e.GET("/", func(echo.Context) error {
+		return faults.Unavailable(2 * time.Second)
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if ra := rec.Header().Get("Retry-After"); ra != "2" {
+		t.Errorf("expect Retry-After 2, but got %q", ra)
+	}
+}
+
+// TestCommitted ensures errors returned after the response was committed are
+// not rendered.
+func TestCommitted(t *testing.T) {
+	e := echo.New()
+	e.HTTPErrorHandler = faultsecho.HTTPErrorHandler
+	e.GET("/", func(c echo.Context) error {
+		c.String(http.StatusOK, "partial")
+		return faults.Internal
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "partial" {
+		t.Errorf("expect committed response to be kept, but got %d %q", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,61 @@
+// Package faultsexpvar counts the faults rendered by a service, by category,
+// and publishes the counters with expvar, for the services that don't run
+// Prometheus (see the faultsmetrics package for the ones that do).
+//
+// It is kept out of the core, as importing expvar registers the /debug/vars
+// handler on http.DefaultServeMux.
+//
+//	c := faultsexpvar.New("faults")
+//	srv := &http.Server{Handler: c.Middleware(mux)}
+//
+// The counters are served by the handler of expvar, e.g. on /debug/vars, as
+// an object keyed by the names of the codes:
+//
+//	"faults": {"BAD_REQUEST": 12, "NOT_FOUND": 3}
+package faultsexpvar
+
+import (
+	"expvar"
+	"net/http"
+
+	"github.com/deixis/faults"
+)
+
+// Counters count the faults rendered by a service, by code. Like the metrics
+// of faultsmetrics, they are fed by the tracing hooks of faults (see
+// faults.Trace): Middleware attaches them to the context of each HTTP
+// request, and the transports without a middleware can report to them as the
+// process-wide trace sink (see faults.SetTraceSink).
+type Counters struct {
+	m *expvar.Map
+}
+
+// New returns counters published with expvar under `name`. Like
+// expvar.Publish, it panics when `name` is already in use.
+func New(name string) *Counters {
+	return &Counters{m: expvar.NewMap(name)}
+}
+
+// Trace counts the faults rendered (see faults.EventRender). It makes
+// Counters a faults.TraceSink.
+func (c *Counters) Trace(ev faults.Event) {
+	if ev.Kind == faults.EventRender && ev.Err != nil {
+		c.m.Add(ev.Code.String(), 1)
+	}
+}
+
+// Count returns the number of faults of the category `code` rendered so far.
+func (c *Counters) Count(code faults.Code) int64 {
+	if v, ok := c.m.Get(code.String()).(*expvar.Int); ok {
+		return v.Value()
+	}
+	return 0
+}
+
+// Middleware returns an HTTP handler that calls `next`, and counts the faults
+// it renders, e.g. with faultshttp.
+func (c *Counters) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(faults.ContextWithTrace(r.Context(), c)))
+	})
+}
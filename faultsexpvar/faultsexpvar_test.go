@@ -0,0 +1,46 @@
+package faultsexpvar_test
+
+import (
+	"context"
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultsexpvar"
+)
+
+// TestCounters ensures the faults rendered while serving requests are
+// counted, and published with expvar.
+func TestCounters(t *testing.T) {
+	c := faultsexpvar.New("faults_test_counters")
+	h := c.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		faults.Trace(r.Context(), faults.EventRender, faults.NotFound, "test: problem")
+		faults.Trace(r.Context(), faults.EventClassify, faults.Internal, "")
+	}))
+	for i := 0; i < 2; i++ {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/42", nil))
+	}
+	c.Trace(faults.Event{Kind: faults.EventRender, Err: faults.Unavailable(0), Code: faults.CodeUnavailable})
+	faults.Trace(context.Background(), faults.EventRender, faults.NotFound, "test: problem")
+
+	table := []struct {
+		Code   faults.Code
+		Expect int64
+	}{
+		{Code: faults.CodeNotFound, Expect: 2},
+		{Code: faults.CodeUnavailable, Expect: 1},
+		{Code: faults.CodeInternal, Expect: 0},
+	}
+	for i, test := range table {
+		if n := c.Count(test.Code); n != test.Expect {
+			t.Errorf("%d - expect %d %s faults, but got %d", i, test.Expect, test.Code, n)
+		}
+	}
+
+	expect := `{"NOT_FOUND": 2, "UNAVAILABLE": 1}`
+	if v := expvar.Get("faults_test_counters").String(); v != expect {
+		t.Errorf("expect published counters %s, but got %s", expect, v)
+	}
+}
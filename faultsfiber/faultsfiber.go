@@ -0,0 +1,84 @@
+// Package faultsfiber renders the errors of Fiber handlers (see
+// `github.com/gofiber/fiber/v2`) with the encoders of faultshttp.
+//
+// Handlers return faults as usual, and the error handler renders them with
+// the HTTP status of their category, a problem details document describing
+// them, and the Retry-After and WWW-Authenticate headers they advise:
+//
+//	app := fiber.New(fiber.Config{ErrorHandler: faultsfiber.ErrorHandler})
+//	app.Get("/users/:id", func(c *fiber.Ctx) error {
+//		user, err := users.Get(c.UserContext(), c.Params("id"))
+//		if err != nil {
+//			return err
+//		}
+//		return c.JSON(user)
+//	})
+//
+// The errors produced by Fiber itself, such as the ones of its router, are
+// converted to the fault matching their status.
+package faultsfiber
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultshttp"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ErrorHandler renders errors as problem details documents (see
+// faultshttp.ProblemEncoder).
+var ErrorHandler fiber.ErrorHandler = NewErrorHandler(faultshttp.ProblemEncoder)
+
+// NewErrorHandler returns a Fiber error handler rendering errors with `enc`,
+// e.g. to render them as JSON:API error documents:
+//
+//	app := fiber.New(fiber.Config{
+//		ErrorHandler: faultsfiber.NewErrorHandler(faultshttp.JSONAPIEncoder),
+//	})
+//
+// The rendering is reported to the trace sinks as EventRender (see
+// faults.Trace).
+func NewErrorHandler(enc faultshttp.Encoder) fiber.ErrorHandler {
+	return func(c *fiber.Ctx, err error) error {
+		err = FromError(err)
+		faults.Trace(c.UserContext(), faults.EventRender, err, "faultsfiber: "+enc.ContentType())
+
+		h := http.Header{}
+		faults.SetRetryAfter(h, err)
+		faults.SetWWWAuthenticate(h, err)
+		for k, values := range h {
+			for _, v := range values {
+				c.Response().Header.Add(k, v)
+			}
+		}
+		c.Set(fiber.HeaderContentType, enc.ContentType())
+		c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+		c.Status(faults.HTTPStatus(err))
+
+		// Encoders only read the context and the path of the request, e.g.
+		// for the instance of a problem details document
+		r := (&http.Request{Method: c.Method(), URL: &url.URL{Path: c.Path()}}).WithContext(c.UserContext())
+		return enc.Encode(c.Response().BodyWriter(), r, err)
+	}
+}
+
+// FromError converts an error returned by a Fiber handler to a fault.
+//
+// A fiber.Error is converted to the fault matching its status (see
+// faults.CodeOfHTTPStatus), with its message. Other errors, including faults,
+// are returned as is.
+func FromError(err error) error {
+	var fe *fiber.Error
+	if !errors.As(err, &fe) {
+		return err
+	}
+
+	parent := errors.New(fe.Message)
+	if d, ok := faults.Describe(faults.CodeOfHTTPStatus(fe.Code)); ok && d.New != nil {
+		return d.New(parent)
+	}
+	return parent
+}
@@ -0,0 +1,114 @@
+package faultsfiber_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultsfiber"
+	"github.com/deixis/faults/faultshttp"
+	"github.com/gofiber/fiber/v2"
+)
+
+// TestErrorHandler ensures handler errors are rendered as problem details
+// documents with the HTTP status of their category.
+func TestErrorHandler(t *testing.T) {
+	table := []struct {
+		Error      error
+		Status     int
+		Code       faults.Code
+		Detail     string
+		RetryAfter string
+	}{
+		{
+			Error:  faults.Bad(&faults.FieldViolation{Field: "email", Description: "Field required"}),
+			Status: http.StatusBadRequest,
+			Code:   faults.CodeBad,
+			Detail: "Field required",
+		},
+		{
+			Error:  fmt.Errorf("get user: %w", faults.NotFound),
+			Status: http.StatusNotFound,
+			Code:   faults.CodeNotFound,
+			Detail: "get user: resource not found",
+		},
+		{
+			Error:      faults.Unavailable(2 * time.Second),
+			Status:     http.StatusServiceUnavailable,
+			Code:       faults.CodeUnavailable,
+			RetryAfter: "2",
+		},
+		{
+			Error:  fiber.NewError(http.StatusConflict, "version mismatch"),
+			Status: http.StatusConflict,
+			Code:   faults.CodeAborted,
+			Detail: "conflict: version mismatch",
+		},
+		{
+			Error:  errors.New("pq: relation \"users\" does not exist"),
+			Status: http.StatusInternalServerError,
+			Code:   faults.CodeUnknown,
+		},
+	}
+
+	app := fiber.New(fiber.Config{ErrorHandler: faultsfiber.ErrorHandler})
+	for i, test := range table {
+		app.Get(fmt.Sprintf("/test/%d", i), func(*fiber.Ctx) error {
+			return test.Error
+		})
+	}
+
+	for i, test := range table {
+		resp, err := app.Test(httptest.NewRequest("GET", fmt.Sprintf("/test/%d", i), nil))
+		if err != nil {
+			t.Fatalf("%d - expect response, but got %s", i, err)
+		}
+
+		if resp.StatusCode != test.Status {
+			t.Errorf("%d - expect status %d, but got %d", i, test.Status, resp.StatusCode)
+		}
+		if ct := resp.Header.Get("Content-Type"); ct != faultshttp.ProblemContentType {
+			t.Errorf("%d - expect problem details, but got %s", i, ct)
+		}
+		if ra := resp.Header.Get("Retry-After"); ra != test.RetryAfter {
+			t.Errorf("%d - expect Retry-After %q, but got %q", i, test.RetryAfter, ra)
+		}
+		var p faultshttp.Problem
+		if err := json.NewDecoder(resp.Body).Decode(&p); err != nil {
+			t.Fatalf("%d - expect problem details document, but got %s", i, err)
+		}
+		if code := faults.CodeOf(p.Err()); code != test.Code {
+			t.Errorf("%d - expect code %s, but got %s", i, test.Code, code)
+		}
+		if p.Detail != test.Detail {
+			t.Errorf("%d - expect detail %q, but got %q", i, test.Detail, p.Detail)
+		}
+		if expect := fmt.Sprintf("/test/%d", i); p.Instance != expect {
+			t.Errorf("%d - expect instance %s, but got %s", i, expect, p.Instance)
+		}
+	}
+}
+
+// TestRouting ensures the errors of the router are rendered too.
+func TestRouting(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: faultsfiber.NewErrorHandler(faultshttp.JSONAPIEncoder)})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/unknown", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expect status 404, but got %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != faultshttp.JSONAPIContentType {
+		t.Errorf("expect JSON:API document, but got %s", ct)
+	}
+	if err := faultshttp.ReadJSONAPI(resp); !faults.IsNotFound(err) {
+		t.Errorf("expect not found, but got %s", faults.CodeOf(err))
+	}
+}
@@ -0,0 +1,72 @@
+// Package faultsgateway renders the errors of a gRPC-Gateway (see
+// `github.com/grpc-ecosystem/grpc-gateway/v2/runtime`) with faultshttp,
+// instead of the default format of the gateway.
+//
+// The errors reaching the gateway are gRPC statuses returned by the backend.
+// They are converted back to faults with the standard error details they
+// carry (see faultsgrpc.FromStatus), so the response has the HTTP status of the category of the
+// fault, its violations, its reason and its retry delay, exactly as if the
+// fault was rendered by an HTTP service:
+//
+//	mux := runtime.NewServeMux(runtime.WithErrorHandler(faultsgateway.ErrorHandler))
+package faultsgateway
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultsgrpc"
+	"github.com/deixis/faults/faultshttp"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorHandler renders errors as problem details documents (see
+// faultshttp.WriteProblem).
+var ErrorHandler runtime.ErrorHandlerFunc = NewErrorHandler(faultshttp.DefaultErrorHandler)
+
+// NewErrorHandler returns a gateway error handler rendering errors with `eh`,
+// e.g. to render them as JSON:API error documents:
+//
+//	mux := runtime.NewServeMux(runtime.WithErrorHandler(faultsgateway.NewErrorHandler(
+//		&faultshttp.ErrorHandler{Encoder: faultshttp.JSONAPIEncoder},
+//	)))
+func NewErrorHandler(eh *faultshttp.ErrorHandler) runtime.ErrorHandlerFunc {
+	return func(
+		ctx context.Context,
+		mux *runtime.ServeMux,
+		m runtime.Marshaler,
+		w http.ResponseWriter,
+		r *http.Request,
+		err error,
+	) {
+		// The gateway sets them for streaming responses, which don't apply to
+		// an error document
+		w.Header().Del("Trailer")
+		w.Header().Del("Transfer-Encoding")
+
+		eh.WriteError(w, r, FromError(err))
+	}
+}
+
+// FromError converts an error returned through the gateway to a fault.
+//
+// Errors that already have a category (see faults.CodeOf) are returned as is.
+// gRPC statuses, including the ones wrapped by a runtime.HTTPStatusError, are
+// converted with faultsgrpc.FromStatus. Other errors are returned as is.
+func FromError(err error) error {
+	if err == nil || faults.CodeOf(err) != faults.CodeUnknown {
+		return err
+	}
+	var statusErr *runtime.HTTPStatusError
+	if errors.As(err, &statusErr) {
+		err = statusErr.Err
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	return faultsgrpc.FromStatus(st)
+}
@@ -0,0 +1,158 @@
+package faultsgateway_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultsgateway"
+	"github.com/deixis/faults/faultshttp"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// TestErrorHandler ensures gRPC statuses are rendered as problem details
+// documents with the HTTP status of their category.
+func TestErrorHandler(t *testing.T) {
+	table := []struct {
+		Error  error
+		Status int
+		Code   faults.Code
+		Delay  time.Duration
+	}{
+		{
+			Error: withDetails(t, status.New(codes.InvalidArgument, "invalid user"), &errdetails.BadRequest{
+				FieldViolations: []*errdetails.BadRequest_FieldViolation{
+					{Field: "email", Reason: "REQUIRED", Description: "Field required"},
+				},
+			}),
+			Status: http.StatusBadRequest,
+			Code:   faults.CodeBad,
+		},
+		{
+			Error:  status.Error(codes.NotFound, "no such user"),
+			Status: http.StatusNotFound,
+			Code:   faults.CodeNotFound,
+		},
+		{
+			Error: withDetails(t, status.New(codes.Unavailable, "maintenance"), &errdetails.RetryInfo{
+				RetryDelay: durationpb.New(2 * time.Second),
+			}),
+			Status: http.StatusServiceUnavailable,
+			Code:   faults.CodeUnavailable,
+			Delay:  2 * time.Second,
+		},
+		{
+			Error: withDetails(t, status.New(codes.ResourceExhausted, "slow down"),
+				&errdetails.QuotaFailure{Violations: []*errdetails.QuotaFailure_Violation{
+					{Subject: "user:42", Description: "Daily limit"},
+				}},
+				&errdetails.RetryInfo{RetryDelay: durationpb.New(time.Minute)},
+			),
+			Status: http.StatusTooManyRequests,
+			Code:   faults.CodeResourceExhausted,
+			Delay:  time.Minute,
+		},
+		{
+			Error:  status.Error(codes.Internal, "pq: relation \"users\" does not exist"),
+			Status: http.StatusInternalServerError,
+			Code:   faults.CodeInternal,
+		},
+		{
+			Error:  &runtime.HTTPStatusError{HTTPStatus: http.StatusNotFound, Err: status.Error(codes.NotFound, "not found")},
+			Status: http.StatusNotFound,
+			Code:   faults.CodeNotFound,
+		},
+		{
+			Error:  faults.PermissionDenied,
+			Status: http.StatusForbidden,
+			Code:   faults.CodePermissionDenied,
+		},
+		{
+			Error:  errors.New("boom"),
+			Status: http.StatusInternalServerError,
+			Code:   faults.CodeUnknown,
+		},
+	}
+
+	for i, test := range table {
+		rec := httptest.NewRecorder()
+		faultsgateway.ErrorHandler(context.Background(), nil, nil, rec, httptest.NewRequest("GET", "/users/42", nil), test.Error)
+
+		if rec.Code != test.Status {
+			t.Errorf("%d - expect status %d, but got %d", i, test.Status, rec.Code)
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != faultshttp.ProblemContentType {
+			t.Errorf("%d - expect problem details, but got %s", i, ct)
+		}
+		err := faultshttp.ReadProblem(rec.Result())
+		if code := faults.CodeOf(err); code != test.Code {
+			t.Errorf("%d - expect code %s, but got %s", i, test.Code, code)
+		}
+		if delay := faults.RetryAdvice(err).Delay; delay != test.Delay {
+			t.Errorf("%d - expect retry delay %s, but got %s", i, test.Delay, delay)
+		}
+	}
+}
+
+// TestFromError ensures the details of gRPC statuses are restored.
+func TestFromError(t *testing.T) {
+	err := faultsgateway.FromError(withDetails(t, status.New(codes.FailedPrecondition, "insufficient funds"),
+		&errdetails.PreconditionFailure{Violations: []*errdetails.PreconditionFailure_Violation{
+			{Type: "BALANCE", Subject: "account:42", Description: "Insufficient funds"},
+		}},
+		&errdetails.ErrorInfo{Reason: "INSUFFICIENT_FUNDS", Metadata: map[string]string{"missing": "12.50"}},
+	))
+
+	e, ok := faults.AsFailedPrecondition(err)
+	if !ok || len(e.Violations) != 1 || e.Violations[0].Subject != "account:42" {
+		t.Errorf("expect precondition violation to be restored, but got %v", err)
+	}
+	if reason := faults.ReasonOf(err); reason != "INSUFFICIENT_FUNDS" {
+		t.Errorf("expect reason INSUFFICIENT_FUNDS, but got %q", reason)
+	}
+
+	err = faultsgateway.FromError(withDetails(t, status.New(codes.InvalidArgument, "invalid"), &errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{
+			{Field: "email", Reason: "REQUIRED", Description: "Field required"},
+		},
+	}))
+	if e, ok := faults.AsBad(err); !ok || len(e.Violations) != 1 || e.Violations[0].Code != faults.ViolationRequired {
+		t.Errorf("expect field violation code to be restored, but got %v", err)
+	}
+
+	if err := faultsgateway.FromError(nil); err != nil {
+		t.Errorf("expect nil, but got %s", err)
+	}
+}
+
+// TestServeMux ensures the handler is honoured by the gateway, including for
+// routing errors.
+func TestServeMux(t *testing.T) {
+	mux := runtime.NewServeMux(runtime.WithErrorHandler(faultsgateway.ErrorHandler))
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/unknown", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expect status 404, but got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != faultshttp.ProblemContentType {
+		t.Errorf("expect problem details, but got %s", ct)
+	}
+}
+
+func withDetails(t *testing.T, st *status.Status, details ...protoadapt.MessageV1) error {
+	st, err := st.WithDetails(details...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return st.Err()
+}
@@ -0,0 +1,162 @@
+// Package faultsgqlgen converts faults to GraphQL errors for servers built
+// with gqlgen (github.com/99designs/gqlgen), and back for Go clients.
+//
+// ErrorPresenter is a `graphql.ErrorPresenterFunc` describing each fault with
+// the `extensions` member of the GraphQL error: the name of its category as
+// `code`, its reason, its violations and whether and when it may be retried.
+// Field violations also carry the path of the invalid argument, derived from
+// their Field, so clients can highlight the offending input:
+//
+//	{
+//	  "message": "bad request: email is required",
+//	  "path": ["createUser"],
+//	  "extensions": {
+//	    "code": "BAD_REQUEST",
+//	    "retryable": false,
+//	    "violations": [
+//	      {"field": "input.email", "path": ["input", "email"], "description": "is required"}
+//	    ]
+//	  }
+//	}
+//
+// It is installed on the server with SetErrorPresenter:
+//
+//	srv := handler.NewDefaultServer(generated.NewExecutableSchema(cfg))
+//	srv.SetErrorPresenter(faultsgqlgen.ErrorPresenter)
+package faultsgqlgen
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultshttp"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// A Violation describes a single violation of a fault in the `violations`
+// extension. Path is the path of the field of a field violation, e.g.
+// ["items", 0, "name"] for "items[0].name".
+type Violation struct {
+	faultshttp.Violation
+	Path ast.Path `json:"path,omitempty"`
+}
+
+// ErrorPresenter returns the GraphQL error describing `err`, which occurred
+// while resolving the field of `ctx`.
+//
+// Like for HTTP responses, the message of `err` is only used for client
+// faults. Server faults, and errors that are not faults, are described by the
+// message of their category alone (see faults.Sanitize). Errors that already
+// are GraphQL errors, such as the parsing and validation errors of gqlgen,
+// are returned as is.
+func ErrorPresenter(ctx context.Context, err error) *gqlerror.Error {
+	var gqlErr *gqlerror.Error
+	if errors.As(err, &gqlErr) && faults.CodeOf(err) == faults.CodeUnknown {
+		return gqlErr
+	}
+	faults.Trace(ctx, faults.EventRender, err, "faultsgqlgen: error")
+
+	e := graphql.DefaultErrorPresenter(ctx, err)
+	if e == gqlErr {
+		// The fault is wrapped by a GraphQL error, e.g. by graphql.ErrorOnPath,
+		// which is copied since it may be presented again
+		clone := *e
+		clone.Extensions = make(map[string]interface{}, len(e.Extensions))
+		for k, v := range e.Extensions {
+			clone.Extensions[k] = v
+		}
+		e = &clone
+	}
+	if faults.IsServerFault(err) {
+		e.Message = faults.Sanitize(err).Error()
+	}
+	e.Extensions = Extensions(err, e.Extensions)
+	return e
+}
+
+// Extensions adds the extensions describing `err` to `extensions`, and
+// returns it. A new map is allocated when `extensions` is nil.
+//
+// It is meant for custom presenters, e.g. to add members of their own to the
+// errors presented by ErrorPresenter.
+func Extensions(err error, extensions map[string]interface{}) map[string]interface{} {
+	if extensions == nil {
+		extensions = map[string]interface{}{}
+	}
+	p := faultshttp.NewProblem(nil, err)
+	extensions["code"] = p.Code
+	if p.Reason != "" {
+		extensions["reason"] = p.Reason
+		if p.Domain != "" {
+			extensions["domain"] = p.Domain
+		}
+		if len(p.Metadata) > 0 {
+			extensions["metadata"] = p.Metadata
+		}
+	}
+	extensions["retryable"] = faults.RetryAdvice(err).Retryable
+	if p.RetryDelay > 0 {
+		extensions["retryDelay"] = p.RetryDelay
+	}
+	if p.Permanent {
+		extensions["permanent"] = true
+	}
+	if len(p.Violations) > 0 {
+		violations := make([]Violation, len(p.Violations))
+		for i, v := range p.Violations {
+			violations[i] = Violation{Violation: v, Path: FieldPath(v.Field)}
+		}
+		extensions["violations"] = violations
+	}
+	return extensions
+}
+
+// FieldPath returns the GraphQL path of the field `field` of a field
+// violation, e.g. ["items", 0, "name"] for "items[0].name". It returns nil
+// when `field` is empty.
+func FieldPath(field string) ast.Path {
+	var path ast.Path
+	segments := strings.FieldsFunc(field, func(r rune) bool {
+		return r == '.' || r == '[' || r == ']'
+	})
+	for _, segment := range segments {
+		if i, err := strconv.Atoi(segment); err == nil && len(path) > 0 {
+			path = append(path, ast.PathIndex(i))
+			continue
+		}
+		path = append(path, ast.PathName(segment))
+	}
+	return path
+}
+
+// FromError converts the GraphQL error `e` back to the fault it describes,
+// for Go clients of a server using ErrorPresenter. The category is read from
+// the `code` extension. Errors without a known code are classified as Unknown.
+//
+// It returns nil when `e` is nil.
+func FromError(e *gqlerror.Error) error {
+	if e == nil {
+		return nil
+	}
+	p := &faultshttp.Problem{Detail: e.Message}
+	if len(e.Extensions) > 0 {
+		// Extensions decoded from a response hold generic JSON values, so
+		// they are decoded again like the extension members of a problem
+		// details document
+		b, err := json.Marshal(e.Extensions)
+		if err == nil {
+			json.Unmarshal(b, p)
+		}
+		p.Detail = e.Message
+	}
+	if _, err := faults.ParseCode(p.Code); err != nil {
+		return errors.New(e.Message)
+	}
+	return p.Err()
+}
@@ -0,0 +1,203 @@
+package faultsgqlgen_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultsgqlgen"
+	"github.com/deixis/faults/faultstest"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// TestErrorPresenter ensures faults are presented with their category and
+// retry hints.
+func TestErrorPresenter(t *testing.T) {
+	table := []struct {
+		Error      error
+		Message    string
+		Code       string
+		Retryable  bool
+		RetryDelay int64
+	}{
+		{
+			Error:   faults.WithBad(errors.New("email is required")),
+			Message: "bad request: email is required",
+			Code:    "BAD_REQUEST",
+		},
+		{
+			Error:   faults.WithNotFound(errors.New("no such user")),
+			Message: "resource not found",
+			Code:    "NOT_FOUND",
+		},
+		{
+			Error:      faults.Unavailable(2 * time.Second),
+			Message:    "service temporarily unavailable, retry in 2s",
+			Code:       "UNAVAILABLE",
+			Retryable:  true,
+			RetryDelay: 2000,
+		},
+		{
+			Error:   faults.WithInternal(errors.New("pq: connection refused")),
+			Message: "internal error",
+			Code:    "INTERNAL",
+		},
+		{Error: errors.New("boom"), Message: "unknown error", Code: "UNKNOWN"},
+	}
+
+	for i, test := range table {
+		e := faultsgqlgen.ErrorPresenter(context.Background(), test.Error)
+		if e.Message != test.Message {
+			t.Errorf("%d - expect message %q, but got %q", i, test.Message, e.Message)
+		}
+		if code := e.Extensions["code"]; code != test.Code {
+			t.Errorf("%d - expect code %s, but got %v", i, test.Code, code)
+		}
+		if retryable := e.Extensions["retryable"]; retryable != test.Retryable {
+			t.Errorf("%d - expect retryable %t, but got %v", i, test.Retryable, retryable)
+		}
+		if delay, _ := e.Extensions["retryDelay"].(int64); delay != test.RetryDelay {
+			t.Errorf("%d - expect retry delay %d, but got %d", i, test.RetryDelay, delay)
+		}
+		if !errors.Is(e, test.Error) {
+			t.Errorf("%d - expect presented error to wrap the fault", i)
+		}
+	}
+}
+
+// TestErrorPresenterPath ensures errors are presented with the path of the
+// field being resolved.
+func TestErrorPresenterPath(t *testing.T) {
+	ctx := graphql.WithFieldContext(context.Background(), &graphql.FieldContext{
+		Field: graphql.CollectedField{Field: &ast.Field{Alias: "user"}},
+	})
+	e := faultsgqlgen.ErrorPresenter(ctx, faults.NotFound)
+	if expect := (ast.Path{ast.PathName("user")}); !reflect.DeepEqual(e.Path, expect) {
+		t.Errorf("expect path %s, but got %s", expect, e.Path)
+	}
+
+	// Faults wrapped by a GraphQL error keep its path and extensions
+	wrapped := &gqlerror.Error{
+		Err:        faults.NotFound,
+		Message:    "no such user",
+		Path:       ast.Path{ast.PathName("users"), ast.PathIndex(1)},
+		Extensions: map[string]interface{}{"service": "users"},
+	}
+	e = faultsgqlgen.ErrorPresenter(ctx, wrapped)
+	if !reflect.DeepEqual(e.Path, wrapped.Path) {
+		t.Errorf("expect path %s, but got %s", wrapped.Path, e.Path)
+	}
+	if e.Message != "no such user" {
+		t.Errorf("expect message to be kept, but got %q", e.Message)
+	}
+	if e.Extensions["service"] != "users" || e.Extensions["code"] != "NOT_FOUND" {
+		t.Errorf("expect extensions to be merged, but got %v", e.Extensions)
+	}
+	if _, ok := wrapped.Extensions["code"]; ok {
+		t.Errorf("expect wrapping error to be left untouched, but got %v", wrapped.Extensions)
+	}
+
+	// Errors of gqlgen itself are presented as is
+	validation := gqlerror.Errorf("cannot query field")
+	if e := faultsgqlgen.ErrorPresenter(ctx, validation); e != validation {
+		t.Errorf("expect GraphQL error to be returned as is, but got %v", e)
+	}
+}
+
+// TestViolations ensures field violations are presented with the path of
+// their field.
+func TestViolations(t *testing.T) {
+	err := faults.Bad(
+		&faults.FieldViolation{Field: "input.email", Code: "REQUIRED", Description: "is required"},
+		&faults.FieldViolation{Field: "input.items[0].name", Description: "is too long"},
+	)
+	e := faultsgqlgen.ErrorPresenter(context.Background(), err)
+
+	b, _ := json.Marshal(e.Extensions["violations"])
+	expect := `[` +
+		`{"field":"input.email","code":"REQUIRED","description":"is required","path":["input","email"]},` +
+		`{"field":"input.items[0].name","description":"is too long","path":["input","items",0,"name"]}` +
+		`]`
+	if string(b) != expect {
+		t.Errorf("expect violations %s, but got %s", expect, b)
+	}
+}
+
+// TestFieldPath ensures field paths are split into segments.
+func TestFieldPath(t *testing.T) {
+	table := []struct {
+		Field string
+		Path  ast.Path
+	}{
+		{Field: "", Path: nil},
+		{Field: "email", Path: ast.Path{ast.PathName("email")}},
+		{Field: "user.email", Path: ast.Path{ast.PathName("user"), ast.PathName("email")}},
+		{
+			Field: "items[2].name",
+			Path:  ast.Path{ast.PathName("items"), ast.PathIndex(2), ast.PathName("name")},
+		},
+		{Field: "0", Path: ast.Path{ast.PathName("0")}},
+	}
+
+	for i, test := range table {
+		if path := faultsgqlgen.FieldPath(test.Field); !reflect.DeepEqual(path, test.Path) {
+			t.Errorf("%d - expect path %s, but got %s", i, test.Path, path)
+		}
+	}
+}
+
+// TestFromError ensures GraphQL errors that were not presented by
+// ErrorPresenter are classified as Unknown.
+func TestFromError(t *testing.T) {
+	table := []*gqlerror.Error{
+		gqlerror.Errorf("cannot query field"),
+		{Message: "teapot", Extensions: map[string]interface{}{"code": "GRAPHQL_VALIDATION_FAILED"}},
+		{Message: "odd", Extensions: map[string]interface{}{"code": 42}},
+	}
+
+	for i, e := range table {
+		err := faultsgqlgen.FromError(e)
+		if code := faults.CodeOf(err); code != faults.CodeUnknown {
+			t.Errorf("%d - expect code %s, but got %s", i, faults.CodeUnknown, code)
+		}
+		if err.Error() != e.Message {
+			t.Errorf("%d - expect message %q, but got %q", i, e.Message, err)
+		}
+	}
+
+	if err := faultsgqlgen.FromError(nil); err != nil {
+		t.Errorf("expect nil error, but got %v", err)
+	}
+}
+
+// TestConformance ensures GraphQL errors follow the semantics of the core.
+func TestConformance(t *testing.T) {
+	present := func(err error) []byte {
+		b, _ := json.Marshal(faultsgqlgen.ErrorPresenter(context.Background(), err))
+		return b
+	}
+
+	faultstest.ConformanceSuite{
+		RoundTrip: func(err error) error {
+			if err == nil {
+				return nil
+			}
+			var e gqlerror.Error
+			if err := json.Unmarshal(present(err), &e); err != nil {
+				t.Fatal(err)
+			}
+			return faultsgqlgen.FromError(&e)
+		},
+		Render: func(err error) string {
+			return string(present(err))
+		},
+		RetryInfo: true,
+		Details:   true,
+	}.Run(t)
+}
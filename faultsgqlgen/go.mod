@@ -0,0 +1,19 @@
+module github.com/deixis/faults/faultsgqlgen
+
+go 1.22.0
+
+replace github.com/deixis/faults => ../
+
+require (
+	github.com/99designs/gqlgen v0.17.49
+	github.com/deixis/faults v0.0.0-00010101000000-000000000000
+	github.com/vektah/gqlparser/v2 v2.5.16
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.2-0.20180830191138-d8f796af33cc // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/pmezard/go-difflib v1.0.1-0.20181226105442-5d4384ee4fb2 // indirect
+	github.com/sosodev/duration v1.3.1 // indirect
+	github.com/stretchr/testify v1.10.0 // indirect
+)
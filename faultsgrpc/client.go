@@ -0,0 +1,82 @@
+package faultsgrpc
+
+import (
+	"context"
+	"io"
+
+	"github.com/deixis/faults"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryClientInterceptor returns a unary client interceptor converting the
+// statuses returned by calls back to faults (see FromStatus), so their
+// results can be inspected directly with the faults API:
+//
+//	conn, err := grpc.NewClient(target,
+//		grpc.WithChainUnaryInterceptor(
+//			faultsgrpc.UnaryClientInterceptor(),
+//			faultsgrpc.UnaryClientRetryInterceptor(policies),
+//		),
+//	)
+//	// ...
+//	if _, err := client.GetUser(ctx, req); faults.IsNotFound(err) {
+//		// ...
+//	}
+//
+// Statuses without a fault category, such as Canceled, and errors that are
+// not statuses are returned as they are. The retry interceptor relies on
+// statuses, so it must be chained after this one.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply interface{},
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		return fromError(invoker(ctx, method, req, reply, cc, opts...))
+	}
+}
+
+// StreamClientInterceptor returns a stream client interceptor converting the
+// statuses returned when opening a stream, and when receiving its messages,
+// back to faults. See UnaryClientInterceptor.
+func StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(
+		ctx context.Context,
+		desc *grpc.StreamDesc,
+		cc *grpc.ClientConn,
+		method string,
+		streamer grpc.Streamer,
+		opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			return nil, fromError(err)
+		}
+		return &clientStream{ClientStream: cs}, nil
+	}
+}
+
+// clientStream is a grpc.ClientStream converting receive failures to faults.
+type clientStream struct {
+	grpc.ClientStream
+}
+
+func (s *clientStream) RecvMsg(m interface{}) error {
+	return fromError(s.ClientStream.RecvMsg(m))
+}
+
+// fromError converts the status error `err` to a fault. It returns the other
+// errors, including io.EOF, as they are.
+func fromError(err error) error {
+	if err == nil || err == io.EOF {
+		return err
+	}
+	if st, ok := status.FromError(err); ok && CodeOf(st.Code()) != faults.CodeUnknown {
+		return FromStatus(st)
+	}
+	return err
+}
@@ -0,0 +1,100 @@
+package faultsgrpc_test
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultsgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TestUnaryClientInterceptor ensures the statuses returned by calls are
+// converted back to faults.
+func TestUnaryClientInterceptor(t *testing.T) {
+	table := []struct {
+		Error error
+		Fault faults.Code
+		Code  codes.Code
+	}{
+		{Error: nil, Fault: faults.CodeOK, Code: codes.OK},
+		{Error: status.Error(codes.NotFound, "no such user"), Fault: faults.CodeNotFound},
+		{Error: faultsgrpc.ToStatus(faults.Unavailable(time.Second)).Err(), Fault: faults.CodeUnavailable},
+		{Error: status.Error(codes.Canceled, "canceled"), Fault: faults.CodeUnknown, Code: codes.Canceled},
+		{Error: io.ErrUnexpectedEOF, Fault: faults.CodeUnknown, Code: codes.Unknown},
+	}
+
+	for i, test := range table {
+		invoker := func(context.Context, string, interface{}, interface{}, *grpc.ClientConn, ...grpc.CallOption) error {
+			return test.Error
+		}
+
+		err := faultsgrpc.UnaryClientInterceptor()(context.Background(), "/users.v1.Users/Get", nil, nil, nil, invoker)
+		if code := faults.CodeOf(err); code != test.Fault {
+			t.Errorf("%d - expect fault %s, but got %s", i, test.Fault, code)
+		}
+		if test.Fault == faults.CodeUnknown && status.Code(err) != test.Code {
+			t.Errorf("%d - expect status code %s, but got %s", i, test.Code, status.Code(err))
+		}
+	}
+
+	invoker := func(context.Context, string, interface{}, interface{}, *grpc.ClientConn, ...grpc.CallOption) error {
+		return faultsgrpc.ToStatus(faults.Unavailable(2 * time.Second)).Err()
+	}
+	err := faultsgrpc.UnaryClientInterceptor()(context.Background(), "/users.v1.Users/Get", nil, nil, nil, invoker)
+	if delay := faults.RetryAdvice(err).Delay; delay != 2*time.Second {
+		t.Errorf("expect retry delay 2s, but got %s", delay)
+	}
+}
+
+// TestStreamClientInterceptor ensures the statuses returned when opening a
+// stream, and when receiving its messages, are converted back to faults.
+func TestStreamClientInterceptor(t *testing.T) {
+	desc := &grpc.StreamDesc{StreamName: "List", ServerStreams: true}
+
+	streamer := func(context.Context, *grpc.StreamDesc, *grpc.ClientConn, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+		return nil, status.Error(codes.PermissionDenied, "denied")
+	}
+	if _, err := faultsgrpc.StreamClientInterceptor()(context.Background(), desc, nil, "/users.v1.Users/List", streamer); !faults.IsPermissionDenied(err) {
+		t.Errorf("expect permission denied, but got %v", err)
+	}
+
+	table := []struct {
+		Error error
+		Fault faults.Code
+	}{
+		{Error: nil, Fault: faults.CodeOK},
+		{Error: io.EOF, Fault: faults.CodeUnknown},
+		{Error: status.Error(codes.Unauthenticated, "expired"), Fault: faults.CodeUnauthenticated},
+	}
+
+	for i, test := range table {
+		streamer := func(context.Context, *grpc.StreamDesc, *grpc.ClientConn, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+			return &recvStream{err: test.Error}, nil
+		}
+
+		cs, err := faultsgrpc.StreamClientInterceptor()(context.Background(), desc, nil, "/users.v1.Users/List", streamer)
+		if err != nil {
+			t.Fatalf("%d - expect stream to be opened, but got %s", i, err)
+		}
+		err = cs.RecvMsg(nil)
+		if code := faults.CodeOf(err); code != test.Fault {
+			t.Errorf("%d - expect fault %s, but got %s", i, test.Fault, code)
+		}
+		if test.Error == io.EOF && err != io.EOF {
+			t.Errorf("%d - expect EOF to be returned as is, but got %v", i, err)
+		}
+	}
+}
+
+// recvStream is a grpc.ClientStream failing to receive messages with `err`.
+type recvStream struct {
+	grpc.ClientStream
+	err error
+}
+
+func (s *recvStream) RecvMsg(m interface{}) error { return s.err }
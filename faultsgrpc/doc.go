@@ -0,0 +1,20 @@
+// Package faultsgrpc bridges faults with gRPC, so services and their clients
+// share the same taxonomy and retry behaviour.
+//
+// ToStatus converts a fault to a gRPC status, with the code of its category
+// and the standard error details (`google.golang.org/genproto/googleapis/rpc/errdetails`)
+// matching its violations, its reason and its retry delay. FromStatus
+// converts a status back to a fault on the client side:
+//
+//	resp, err := client.GetUser(ctx, req)
+//	if err := faultsgrpc.FromStatus(status.Convert(err)); faults.IsNotFound(err) {
+//		// ...
+//	}
+//
+// UnaryClientInterceptor and StreamClientInterceptor perform this conversion
+// for every call.
+//
+// On the server side, UnaryServerInterceptor and StreamServerInterceptor
+// perform the conversion for every handler, so handlers only ever return
+// faults.
+package faultsgrpc
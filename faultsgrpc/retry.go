@@ -0,0 +1,219 @@
+package faultsgrpc
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/deixis/faults"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// A RetryPolicy describes how failed calls are retried. It mirrors the
+// `retryPolicy` of a gRPC service config.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the original
+	// one. Calls are not retried when it is lower than 2.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between two attempts.
+	MaxBackoff time.Duration
+	// BackoffMultiplier is the factor by which the delay grows after each
+	// attempt.
+	BackoffMultiplier float64
+	// RetryableStatusCodes lists the status codes that are retried.
+	RetryableStatusCodes []codes.Code
+}
+
+// retryable reports whether `code` is retried by the policy.
+func (p RetryPolicy) retryable(code codes.Code) bool {
+	for _, c := range p.RetryableStatusCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the backoff strategy of the policy.
+func (p RetryPolicy) backoff() faults.Backoff {
+	return faults.Backoff{
+		Base:       p.InitialBackoff,
+		Max:        p.MaxBackoff,
+		Multiplier: p.BackoffMultiplier,
+		Jitter:     faults.DefaultBackoff.Jitter,
+	}
+}
+
+// RetryPolicies maps methods to their retry policy. Keys are either a full
+// method name ("/package.Service/Method"), a service name followed by a
+// slash ("/package.Service/") to apply a policy to every method of the
+// service, or an empty string to apply a policy to every method.
+type RetryPolicies map[string]RetryPolicy
+
+// lookup returns the policy of the full method name `method`.
+func (p RetryPolicies) lookup(method string) (RetryPolicy, bool) {
+	if policy, ok := p[method]; ok {
+		return policy, true
+	}
+	if i := strings.LastIndexByte(method, '/'); i > 0 {
+		if policy, ok := p[method[:i+1]]; ok {
+			return policy, true
+		}
+	}
+	policy, ok := p[""]
+	return policy, ok
+}
+
+// ParseRetryPolicies returns the retry policies of the gRPC service config
+// `serviceConfig`, encoded in JSON.
+//
+// Example:
+//
+//	policies, err := faultsgrpc.ParseRetryPolicies(`{
+//	  "methodConfig": [{
+//	    "name": [{"service": "users.v1.Users"}],
+//	    "retryPolicy": {
+//	      "maxAttempts": 4,
+//	      "initialBackoff": "0.1s",
+//	      "maxBackoff": "1s",
+//	      "backoffMultiplier": 2,
+//	      "retryableStatusCodes": ["UNAVAILABLE"]
+//	    }
+//	  }]
+//	}`)
+func ParseRetryPolicies(serviceConfig string) (RetryPolicies, error) {
+	var config struct {
+		MethodConfig []struct {
+			Name []struct {
+				Service string `json:"service"`
+				Method  string `json:"method"`
+			} `json:"name"`
+			RetryPolicy *struct {
+				MaxAttempts          int          `json:"maxAttempts"`
+				InitialBackoff       string       `json:"initialBackoff"`
+				MaxBackoff           string       `json:"maxBackoff"`
+				BackoffMultiplier    float64      `json:"backoffMultiplier"`
+				RetryableStatusCodes []codes.Code `json:"retryableStatusCodes"`
+			} `json:"retryPolicy"`
+		} `json:"methodConfig"`
+	}
+	if err := json.Unmarshal([]byte(serviceConfig), &config); err != nil {
+		return nil, err
+	}
+
+	policies := RetryPolicies{}
+	for _, mc := range config.MethodConfig {
+		if mc.RetryPolicy == nil {
+			continue
+		}
+
+		policy := RetryPolicy{
+			MaxAttempts:          mc.RetryPolicy.MaxAttempts,
+			BackoffMultiplier:    mc.RetryPolicy.BackoffMultiplier,
+			RetryableStatusCodes: mc.RetryPolicy.RetryableStatusCodes,
+		}
+		var err error
+		if policy.InitialBackoff, err = parseDuration(mc.RetryPolicy.InitialBackoff); err != nil {
+			return nil, err
+		}
+		if policy.MaxBackoff, err = parseDuration(mc.RetryPolicy.MaxBackoff); err != nil {
+			return nil, err
+		}
+
+		for _, name := range mc.Name {
+			if name.Service == "" {
+				policies[""] = policy
+				continue
+			}
+			policies["/"+name.Service+"/"+name.Method] = policy
+		}
+	}
+	return policies, nil
+}
+
+// parseDuration parses a duration of a service config, e.g. "0.1s".
+func parseDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// UnaryClientRetryInterceptor returns a client interceptor that retries unary
+// calls according to `policies`.
+//
+// The delay between two attempts follows the backoff of the policy, unless
+// the status of the failed attempt carries a RetryInfo detail, in which case
+// the delay advised by the server is used instead. This allows servers to
+// slow their clients down, e.g. while they are overloaded. Errors marked with
+// faults.Permanent, e.g. by an inner interceptor, are never retried.
+//
+// The built-in retries of gRPC should be disabled with `grpc.WithDisableRetry`
+// to prevent calls from being retried twice:
+//
+//	conn, err := grpc.NewClient(target,
+//		grpc.WithDisableRetry(),
+//		grpc.WithUnaryInterceptor(faultsgrpc.UnaryClientRetryInterceptor(policies)),
+//	)
+//
+// Retry decisions are reported to the trace sinks as EventRetry (see
+// faults.Trace).
+func UnaryClientRetryInterceptor(policies RetryPolicies) grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply interface{},
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		policy, ok := policies.lookup(method)
+		if !ok || policy.MaxAttempts < 2 {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		backoff := policy.backoff()
+
+		for attempt := 0; ; attempt++ {
+			err := invoker(ctx, method, req, reply, cc, opts...)
+			if err == nil {
+				return nil
+			}
+
+			st := status.Convert(err)
+			if !policy.retryable(st.Code()) || faults.IsPermanent(err) || attempt+1 >= policy.MaxAttempts {
+				faults.Trace(ctx, faults.EventRetry, err, "give up")
+				return err
+			}
+
+			delay := backoff.Delay(attempt)
+			if d, ok := retryDelay(st); ok {
+				delay = d
+			}
+			faults.Trace(ctx, faults.EventRetry, err, "retry in "+delay.String())
+
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return err
+			case <-timer.C:
+			}
+		}
+	}
+}
+
+// retryDelay returns the delay advised by the RetryInfo detail of `st`.
+func retryDelay(st *status.Status) (time.Duration, bool) {
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok && info.GetRetryDelay() != nil {
+			return info.GetRetryDelay().AsDuration(), true
+		}
+	}
+	return 0, false
+}
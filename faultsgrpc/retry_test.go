@@ -0,0 +1,183 @@
+package faultsgrpc_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultsgrpc"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// TestParseRetryPolicies ensures retry policies are read from a service
+// config.
+func TestParseRetryPolicies(t *testing.T) {
+	policies, err := faultsgrpc.ParseRetryPolicies(`{
+	  "methodConfig": [{
+	    "name": [{"service": "users.v1.Users"}, {"service": "orders.v1.Orders", "method": "Get"}],
+	    "retryPolicy": {
+	      "maxAttempts": 4,
+	      "initialBackoff": "0.1s",
+	      "maxBackoff": "1s",
+	      "backoffMultiplier": 2,
+	      "retryableStatusCodes": ["UNAVAILABLE", "RESOURCE_EXHAUSTED"]
+	    }
+	  }, {
+	    "name": [{"service": "orders.v1.Orders", "method": "Create"}],
+	    "timeout": "1s"
+	  }]
+	}`)
+	if err != nil {
+		t.Fatalf("expect service config to be parsed, but got %s", err)
+	}
+
+	policy := faultsgrpc.RetryPolicy{
+		MaxAttempts:          4,
+		InitialBackoff:       100 * time.Millisecond,
+		MaxBackoff:           time.Second,
+		BackoffMultiplier:    2,
+		RetryableStatusCodes: []codes.Code{codes.Unavailable, codes.ResourceExhausted},
+	}
+	expect := faultsgrpc.RetryPolicies{
+		"/users.v1.Users/":      policy,
+		"/orders.v1.Orders/Get": policy,
+	}
+	if !reflect.DeepEqual(expect, policies) {
+		t.Errorf("expect policies %v, but got %v", expect, policies)
+	}
+
+	if _, err := faultsgrpc.ParseRetryPolicies(`{"methodConfig": [{"retryPolicy": {"initialBackoff": "soon"}}]}`); err == nil {
+		t.Error("expect invalid duration to be rejected")
+	}
+}
+
+// TestUnaryClientRetryInterceptor ensures calls are retried according to
+// their policy, with the delays advised by the server.
+func TestUnaryClientRetryInterceptor(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+	notFound := status.Error(codes.NotFound, "not found")
+	st, err := status.New(codes.ResourceExhausted, "slow down").WithDetails(&errdetails.RetryInfo{
+		RetryDelay: durationpb.New(time.Millisecond),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exhausted := st.Err()
+
+	fast := faultsgrpc.RetryPolicy{
+		MaxAttempts:          3,
+		InitialBackoff:       time.Millisecond,
+		RetryableStatusCodes: []codes.Code{codes.Unavailable, codes.ResourceExhausted},
+	}
+	slow := fast
+	slow.InitialBackoff = time.Hour
+
+	table := []struct {
+		Policies faultsgrpc.RetryPolicies
+		Method   string
+		Errors   []error
+		Attempts int
+		Code     codes.Code
+	}{
+		{
+			Policies: faultsgrpc.RetryPolicies{"": fast},
+			Errors:   []error{unavailable, nil},
+			Attempts: 2,
+			Code:     codes.OK,
+		},
+		{
+			Policies: faultsgrpc.RetryPolicies{"": fast},
+			Errors:   []error{unavailable, unavailable, unavailable, nil},
+			Attempts: 3,
+			Code:     codes.Unavailable,
+		},
+		{
+			Policies: faultsgrpc.RetryPolicies{"": fast},
+			Errors:   []error{notFound, nil},
+			Attempts: 1,
+			Code:     codes.NotFound,
+		},
+		{
+			Policies: faultsgrpc.RetryPolicies{"": fast},
+			Errors:   []error{faults.Permanent(unavailable), nil},
+			Attempts: 1,
+			Code:     codes.Unavailable,
+		},
+		{
+			// The advised delay overrides the backoff of the policy
+			Policies: faultsgrpc.RetryPolicies{"": slow},
+			Errors:   []error{exhausted, exhausted, nil},
+			Attempts: 3,
+			Code:     codes.OK,
+		},
+		{
+			Policies: faultsgrpc.RetryPolicies{"/test.Service/": fast},
+			Method:   "/test.Service/Get",
+			Errors:   []error{unavailable, nil},
+			Attempts: 2,
+			Code:     codes.OK,
+		},
+		{
+			Policies: faultsgrpc.RetryPolicies{"/other.Service/": fast},
+			Method:   "/test.Service/Get",
+			Errors:   []error{unavailable, nil},
+			Attempts: 1,
+			Code:     codes.Unavailable,
+		},
+	}
+
+	for i, test := range table {
+		method := test.Method
+		if method == "" {
+			method = "/test.Service/Get"
+		}
+
+		var attempts int
+		invoker := func(context.Context, string, interface{}, interface{}, *grpc.ClientConn, ...grpc.CallOption) error {
+			err := test.Errors[attempts]
+			attempts++
+			return err
+		}
+
+		interceptor := faultsgrpc.UnaryClientRetryInterceptor(test.Policies)
+		err := interceptor(context.Background(), method, nil, nil, nil, invoker)
+		if code := status.Code(err); code != test.Code {
+			t.Errorf("%d - expect code %s, but got %s", i, test.Code, code)
+		}
+		if attempts != test.Attempts {
+			t.Errorf("%d - expect %d attempts, but got %d", i, test.Attempts, attempts)
+		}
+	}
+}
+
+// TestUnaryClientRetryInterceptorContext ensures retries stop when the
+// context of the call is done.
+func TestUnaryClientRetryInterceptorContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	var attempts int
+	invoker := func(context.Context, string, interface{}, interface{}, *grpc.ClientConn, ...grpc.CallOption) error {
+		attempts++
+		return status.Error(codes.Unavailable, "unavailable")
+	}
+
+	interceptor := faultsgrpc.UnaryClientRetryInterceptor(faultsgrpc.RetryPolicies{"": {
+		MaxAttempts:          5,
+		InitialBackoff:       time.Hour,
+		RetryableStatusCodes: []codes.Code{codes.Unavailable},
+	}})
+	err := interceptor(ctx, "/test.Service/Get", nil, nil, nil, invoker)
+	if code := status.Code(err); code != codes.Unavailable {
+		t.Errorf("expect code %s, but got %s", codes.Unavailable, code)
+	}
+	if attempts != 1 {
+		t.Errorf("expect 1 attempt, but got %d", attempts)
+	}
+}
@@ -0,0 +1,130 @@
+package faultsgrpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/deixis/faults"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// A ServerInterceptor converts the errors returned by handlers into gRPC
+// statuses (see ToStatus), so handlers only deal with faults:
+//
+//	srv := grpc.NewServer(
+//		grpc.UnaryInterceptor(faultsgrpc.UnaryServerInterceptor()),
+//		grpc.StreamInterceptor(faultsgrpc.StreamServerInterceptor()),
+//	)
+//
+// Conversions are reported to the trace sinks as EventRender (see
+// faults.Trace).
+type ServerInterceptor struct {
+	// ContextErrors converts the context errors returned by handlers, e.g.
+	// when a call they made was interrupted by the deadline of the incoming
+	// call. context.DeadlineExceeded becomes a DeadlineExceeded fault, and
+	// context.Canceled a Canceled status, instead of an Unknown one.
+	ContextErrors bool
+	// RecoverPanics converts the panics of handlers into InternalFailures
+	// carrying the stack and the value of the panic (see faults.Recover),
+	// which are converted like the errors returned by handlers, instead of
+	// crashing the server.
+	RecoverPanics bool
+}
+
+// DefaultServerInterceptor is the interceptor used by UnaryServerInterceptor
+// and StreamServerInterceptor.
+var DefaultServerInterceptor = &ServerInterceptor{ContextErrors: true}
+
+// UnaryServerInterceptor returns the unary interceptor of
+// DefaultServerInterceptor.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return DefaultServerInterceptor.Unary()
+}
+
+// StreamServerInterceptor returns the stream interceptor of
+// DefaultServerInterceptor.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return DefaultServerInterceptor.Stream()
+}
+
+// Unary returns a unary server interceptor converting the errors returned by
+// handlers into statuses.
+func (s *ServerInterceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		resp, err := s.handle(ctx, req, handler)
+		if err != nil {
+			return resp, s.convert(ctx, err)
+		}
+		return resp, nil
+	}
+}
+
+// Stream returns a stream server interceptor converting the errors returned by
+// handlers into statuses.
+//
+// The errors returned by SendMsg are converted to faults (see FromStatus), so
+// a handler can inspect a mid-stream failure with the faults API and return
+// it as is, with its details. Statuses without a fault category, such as
+// Canceled, are left untouched so their code is preserved.
+func (s *ServerInterceptor) Stream() grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		if err := s.handleStream(srv, &serverStream{ServerStream: ss}, handler); err != nil {
+			return s.convert(ss.Context(), err)
+		}
+		return nil
+	}
+}
+
+// handle calls the unary handler `handler`, and recovers from its panics when
+// RecoverPanics is set.
+func (s *ServerInterceptor) handle(ctx context.Context, req interface{}, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	if s.RecoverPanics {
+		defer faults.Recover(&err)
+	}
+	return handler(ctx, req)
+}
+
+// handleStream calls the stream handler `handler`, and recovers from its
+// panics when RecoverPanics is set.
+func (s *ServerInterceptor) handleStream(srv interface{}, ss grpc.ServerStream, handler grpc.StreamHandler) (err error) {
+	if s.RecoverPanics {
+		defer faults.Recover(&err)
+	}
+	return handler(srv, ss)
+}
+
+// serverStream is a grpc.ServerStream converting send failures to faults.
+type serverStream struct {
+	grpc.ServerStream
+}
+
+func (s *serverStream) SendMsg(m interface{}) error {
+	return fromError(s.ServerStream.SendMsg(m))
+}
+
+// convert converts the error `err` returned by a handler into a status
+// error.
+func (s *ServerInterceptor) convert(ctx context.Context, err error) error {
+	if s.ContextErrors && faults.CodeOf(err) == faults.CodeUnknown {
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			err = faults.WithDeadlineExceeded(err)
+		case errors.Is(err, context.Canceled):
+			return status.FromContextError(err).Err()
+		}
+	}
+
+	faults.Trace(ctx, faults.EventRender, err, "faultsgrpc: status")
+	return ToStatus(err).Err()
+}
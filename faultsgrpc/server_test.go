@@ -0,0 +1,152 @@
+package faultsgrpc_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultsgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TestUnaryServerInterceptor ensures the errors returned by handlers are
+// converted into statuses.
+func TestUnaryServerInterceptor(t *testing.T) {
+	table := []struct {
+		Interceptor *faultsgrpc.ServerInterceptor
+		Error       error
+		Code        codes.Code
+		Fault       faults.Code
+	}{
+		{Interceptor: faultsgrpc.DefaultServerInterceptor, Error: nil, Code: codes.OK, Fault: faults.CodeOK},
+		{Interceptor: faultsgrpc.DefaultServerInterceptor, Error: faults.NotFound, Code: codes.NotFound, Fault: faults.CodeNotFound},
+		{Interceptor: faultsgrpc.DefaultServerInterceptor, Error: faults.Bad(&faults.FieldViolation{Field: "name"}), Code: codes.InvalidArgument, Fault: faults.CodeBad},
+		{Interceptor: faultsgrpc.DefaultServerInterceptor, Error: errors.New("boom"), Code: codes.Unknown, Fault: faults.CodeUnknown},
+		{Interceptor: faultsgrpc.DefaultServerInterceptor, Error: status.Error(codes.Aborted, "busy"), Code: codes.Aborted, Fault: faults.CodeAborted},
+		{Interceptor: faultsgrpc.DefaultServerInterceptor, Error: fmt.Errorf("query: %w", context.DeadlineExceeded), Code: codes.DeadlineExceeded, Fault: faults.CodeDeadlineExceeded},
+		{Interceptor: faultsgrpc.DefaultServerInterceptor, Error: context.Canceled, Code: codes.Canceled, Fault: faults.CodeUnknown},
+		{Interceptor: &faultsgrpc.ServerInterceptor{}, Error: context.DeadlineExceeded, Code: codes.Unknown, Fault: faults.CodeUnknown},
+		{Interceptor: &faultsgrpc.ServerInterceptor{}, Error: context.Canceled, Code: codes.Unknown, Fault: faults.CodeUnknown},
+	}
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/users.v1.Users/Get"}
+	for i, test := range table {
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			return "resp", test.Error
+		}
+
+		resp, err := test.Interceptor.Unary()(context.Background(), "req", info, handler)
+		if resp != "resp" {
+			t.Errorf("%d - expect handler response, but got %v", i, resp)
+		}
+		if code := status.Code(err); code != test.Code {
+			t.Errorf("%d - expect status code %s, but got %s", i, test.Code, code)
+		}
+		if code := faults.CodeOf(faultsgrpc.FromStatus(status.Convert(err))); code != test.Fault {
+			t.Errorf("%d - expect fault %s, but got %s", i, test.Fault, code)
+		}
+	}
+}
+
+// TestUnaryServerInterceptorDetails ensures statuses carry the details of the
+// faults returned by handlers.
+func TestUnaryServerInterceptorDetails(t *testing.T) {
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, faults.Bad(&faults.FieldViolation{Field: "email", Description: "is required"})
+	}
+
+	_, err := faultsgrpc.UnaryServerInterceptor()(context.Background(), nil, &grpc.UnaryServerInfo{}, handler)
+	var bad *faults.BadRequest
+	if !errors.As(faultsgrpc.FromStatus(status.Convert(err)), &bad) {
+		t.Fatalf("expect bad request, but got %v", err)
+	}
+	if len(bad.Violations) != 1 || bad.Violations[0].Field != "email" {
+		t.Errorf("expect email violation, but got %v", bad.Violations)
+	}
+}
+
+// TestStreamServerInterceptor ensures the errors returned by stream handlers
+// and by SendMsg are converted.
+func TestStreamServerInterceptor(t *testing.T) {
+	table := []struct {
+		SendError error
+		Error     error
+		Code      codes.Code
+		Fault     faults.Code
+	}{
+		{Code: codes.OK, Fault: faults.CodeOK},
+		{Error: faults.NotFound, Code: codes.NotFound, Fault: faults.CodeNotFound},
+		{Error: context.DeadlineExceeded, Code: codes.DeadlineExceeded, Fault: faults.CodeDeadlineExceeded},
+		{SendError: status.Error(codes.ResourceExhausted, "message too large"), Code: codes.ResourceExhausted, Fault: faults.CodeResourceExhausted},
+		{SendError: status.Error(codes.Canceled, "canceled"), Code: codes.Canceled, Fault: faults.CodeUnknown},
+		{SendError: faultsgrpc.ToStatus(faults.Unavailable(0)).Err(), Code: codes.Unavailable, Fault: faults.CodeUnavailable},
+	}
+
+	info := &grpc.StreamServerInfo{FullMethod: "/users.v1.Users/List"}
+	for i, test := range table {
+		var sendFault faults.Code
+		handler := func(srv interface{}, ss grpc.ServerStream) error {
+			if err := ss.SendMsg("user"); err != nil {
+				sendFault = faults.CodeOf(err)
+				return err
+			}
+			return test.Error
+		}
+
+		ss := &stream{ctx: context.Background(), err: test.SendError}
+		err := faultsgrpc.StreamServerInterceptor()(nil, ss, info, handler)
+		if code := status.Code(err); code != test.Code {
+			t.Errorf("%d - expect status code %s, but got %s", i, test.Code, code)
+		}
+		if code := faults.CodeOf(faultsgrpc.FromStatus(status.Convert(err))); code != test.Fault {
+			t.Errorf("%d - expect fault %s, but got %s", i, test.Fault, code)
+		}
+		if test.SendError != nil && sendFault != test.Fault {
+			t.Errorf("%d - expect send failure %s, but got %s", i, test.Fault, sendFault)
+		}
+	}
+}
+
+// stream is a grpc.ServerStream failing to send messages with `err`.
+type stream struct {
+	grpc.ServerStream
+	ctx context.Context
+	err error
+}
+
+func (s *stream) Context() context.Context    { return s.ctx }
+func (s *stream) SendMsg(m interface{}) error { return s.err }
+
+// TestServerInterceptorRecover ensures the panics of handlers are converted
+// into Internal statuses when they are recovered.
+func TestServerInterceptorRecover(t *testing.T) {
+	s := &faultsgrpc.ServerInterceptor{RecoverPanics: true}
+
+	unary := func(ctx context.Context, req interface{}) (interface{}, error) {
+		panic("boom")
+	}
+	_, err := s.Unary()(context.Background(), "req", &grpc.UnaryServerInfo{}, unary)
+	if code := status.Code(err); code != codes.Internal {
+		t.Errorf("expect status code %s, but got %s", codes.Internal, code)
+	}
+
+	streaming := func(srv interface{}, ss grpc.ServerStream) error {
+		panic("boom")
+	}
+	err = s.Stream()(nil, &stream{ctx: context.Background()}, &grpc.StreamServerInfo{}, streaming)
+	if code := status.Code(err); code != codes.Internal {
+		t.Errorf("expect status code %s, but got %s", codes.Internal, code)
+	}
+
+	// Panics are not recovered by default
+	defer func() {
+		if r := recover(); r != "boom" {
+			t.Errorf("expect panic, but got %v", r)
+		}
+	}()
+	faultsgrpc.UnaryServerInterceptor()(context.Background(), "req", &grpc.UnaryServerInfo{}, unary)
+}
@@ -0,0 +1,272 @@
+package faultsgrpc
+
+import (
+	"errors"
+
+	"github.com/deixis/faults"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// ToStatus returns the gRPC status describing `err`, with the code of its
+// category (see faults.Descriptor) and the standard error details matching
+// what it carries:
+//
+//   - BadRequest for the violations of Bad, with the code of each violation
+//     as its reason.
+//   - PreconditionFailure for the violations of FailedPrecondition.
+//   - QuotaFailure for the violations of ResourceExhausted.
+//   - ResourceInfo for the violations of Aborted.
+//   - RetryInfo for the retry delay it advises (see faults.RetryAdvice).
+//   - ErrorInfo for its reason and metadata (see faults.ErrorInfo).
+//   - Help for the links to its documentation (see faults.Help).
+//
+// The details follow this order, and are marshalled deterministically, so
+// the status of a fault is always encoded with the same bytes.
+//
+// Like for HTTP responses, the message of `err` is only used for client
+// faults. Server faults are described by the message of their category
+// alone (see faults.Sanitize). Errors that are not faults, but that already
+// are gRPC statuses, e.g. the ones returned by a downstream call, are
+// returned as they are.
+//
+// It returns an OK status when `err` is nil.
+func ToStatus(err error) *status.Status {
+	if err == nil {
+		return status.New(codes.OK, "")
+	}
+	code := faults.CodeOf(err)
+	if code == faults.CodeUnknown {
+		if st, ok := status.FromError(err); ok {
+			return st
+		}
+	}
+
+	grpcCode := codes.Unknown
+	if d, ok := faults.Describe(code); ok {
+		grpcCode = codes.Code(d.GRPCCode)
+	}
+	message := err.Error()
+	if faults.IsServerFault(err) {
+		message = faults.Sanitize(err).Error()
+	}
+	st := status.New(grpcCode, message)
+
+	var details []proto.Message
+	switch code {
+	case faults.CodeBad:
+		if e, ok := faults.AsBad(err); ok && len(e.Violations) > 0 {
+			d := &errdetails.BadRequest{}
+			for _, v := range e.Violations {
+				d.FieldViolations = append(d.FieldViolations, &errdetails.BadRequest_FieldViolation{
+					Field:       v.Field,
+					Reason:      v.Code,
+					Description: v.Description,
+				})
+			}
+			details = append(details, d)
+		}
+	case faults.CodeFailedPrecondition:
+		if e, ok := faults.AsFailedPrecondition(err); ok && len(e.Violations) > 0 {
+			d := &errdetails.PreconditionFailure{}
+			for _, v := range e.Violations {
+				d.Violations = append(d.Violations, &errdetails.PreconditionFailure_Violation{
+					Type:        v.Type,
+					Subject:     v.Subject,
+					Description: v.Description,
+				})
+			}
+			details = append(details, d)
+		}
+	case faults.CodeResourceExhausted:
+		if e, ok := faults.AsResourceExhausted(err); ok && len(e.Violations) > 0 {
+			d := &errdetails.QuotaFailure{}
+			for _, v := range e.Violations {
+				d.Violations = append(d.Violations, &errdetails.QuotaFailure_Violation{
+					Subject:     v.Subject,
+					Description: v.Description,
+				})
+			}
+			details = append(details, d)
+		}
+	case faults.CodeAborted:
+		if e, ok := faults.AsAborted(err); ok {
+			for _, v := range e.Violations {
+				details = append(details, &errdetails.ResourceInfo{
+					ResourceName: v.Resource,
+					Description:  v.Description,
+				})
+			}
+		}
+	}
+	if advice := faults.RetryAdvice(err); advice.Delay > 0 {
+		details = append(details, &errdetails.RetryInfo{RetryDelay: durationpb.New(advice.Delay)})
+	}
+	if e, ok := faults.AsErrorInfo(err); ok {
+		details = append(details, &errdetails.ErrorInfo{Reason: e.Reason, Domain: e.Domain, Metadata: e.Metadata})
+	}
+	if links := faults.HelpOf(err); len(links) > 0 {
+		d := &errdetails.Help{}
+		for _, l := range links {
+			d.Links = append(d.Links, &errdetails.Help_Link{Description: l.Description, Url: l.URL})
+		}
+		details = append(details, d)
+	}
+
+	if len(details) > 0 {
+		// Details are marshalled deterministically, so the metadata of
+		// ErrorInfo is always written in the same order
+		p := st.Proto()
+		for _, d := range details {
+			a := &anypb.Any{}
+			if err := anypb.MarshalFrom(a, d, proto.MarshalOptions{Deterministic: true}); err == nil {
+				p.Details = append(p.Details, a)
+			}
+		}
+		st = status.FromProto(p)
+	}
+	return st
+}
+
+// FromStatus converts `st` back to the fault it describes, so the result of
+// a call can be inspected with the functions of the faults package, e.g.
+// faults.IsNotFound or faults.RetryAdvice. The category is derived from the
+// code of the status, and completed with the standard error details listed
+// by ToStatus. The message of the status becomes the message of the error
+// wrapped by the fault.
+//
+// It returns nil when `st` is nil or OK.
+func FromStatus(st *status.Status) error {
+	if st == nil || st.Code() == codes.OK {
+		return nil
+	}
+	parent := errors.New(st.Message())
+
+	delay, _ := retryDelay(st)
+	var (
+		fault error
+		info  *errdetails.ErrorInfo
+		links []faults.Link
+	)
+	for _, detail := range st.Details() {
+		switch d := detail.(type) {
+		case *errdetails.ErrorInfo:
+			info = d
+		case *errdetails.Help:
+			for _, l := range d.GetLinks() {
+				links = append(links, faults.Link{Description: l.GetDescription(), URL: l.GetUrl()})
+			}
+		}
+	}
+
+	switch code := CodeOf(st.Code()); code {
+	case faults.CodeBad:
+		var violations []*faults.FieldViolation
+		for _, detail := range st.Details() {
+			if d, ok := detail.(*errdetails.BadRequest); ok {
+				for _, v := range d.GetFieldViolations() {
+					violations = append(violations, &faults.FieldViolation{
+						Field:       v.GetField(),
+						Code:        v.GetReason(),
+						Description: v.GetDescription(),
+					})
+				}
+			}
+		}
+		fault = faults.WithBad(parent, violations...)
+	case faults.CodeFailedPrecondition:
+		var violations []*faults.PreconditionViolation
+		for _, detail := range st.Details() {
+			if d, ok := detail.(*errdetails.PreconditionFailure); ok {
+				for _, v := range d.GetViolations() {
+					violations = append(violations, &faults.PreconditionViolation{
+						Type:        v.GetType(),
+						Subject:     v.GetSubject(),
+						Description: v.GetDescription(),
+					})
+				}
+			}
+		}
+		fault = faults.WithFailedPrecondition(parent, violations...)
+	case faults.CodeResourceExhausted:
+		var violations []*faults.QuotaViolation
+		for _, detail := range st.Details() {
+			if d, ok := detail.(*errdetails.QuotaFailure); ok {
+				for _, v := range d.GetViolations() {
+					violations = append(violations, &faults.QuotaViolation{
+						Subject:     v.GetSubject(),
+						Description: v.GetDescription(),
+					})
+				}
+			}
+		}
+		fault = faults.WithResourceExhausted(parent, violations...)
+		if e, ok := faults.AsResourceExhausted(fault); ok {
+			e.RetryInfo.RetryDelay = delay
+		}
+	case faults.CodeAborted:
+		var violations []*faults.ConflictViolation
+		for _, detail := range st.Details() {
+			if d, ok := detail.(*errdetails.ResourceInfo); ok {
+				violations = append(violations, &faults.ConflictViolation{
+					Resource:    d.GetResourceName(),
+					Description: d.GetDescription(),
+				})
+			}
+		}
+		fault = faults.WithAborted(parent, violations...)
+	case faults.CodeUnavailable:
+		fault = faults.WithUnavailable(parent, delay)
+	default:
+		fault = parent
+		if d, ok := faults.Describe(code); ok && d.New != nil {
+			fault = d.New(parent)
+		}
+	}
+
+	if len(links) > 0 {
+		fault = faults.AttachHelp(fault, links...)
+	}
+	if info != nil && info.GetReason() != "" {
+		fault = faults.WithScopedErrorInfo(fault, info.GetDomain(), info.GetReason(), info.GetMetadata())
+	}
+	return fault
+}
+
+// CodeOf returns the category of the gRPC status code `code`, which reverses
+// the gRPC code of the standard categories. Codes that don't have a category
+// of their own are mapped to the closest one, e.g. AlreadyExists to Aborted
+// and OutOfRange to Bad.
+func CodeOf(code codes.Code) faults.Code {
+	switch code {
+	case codes.OK:
+		return faults.CodeOK
+	case codes.InvalidArgument, codes.OutOfRange:
+		return faults.CodeBad
+	case codes.NotFound:
+		return faults.CodeNotFound
+	case codes.PermissionDenied:
+		return faults.CodePermissionDenied
+	case codes.Unauthenticated:
+		return faults.CodeUnauthenticated
+	case codes.FailedPrecondition:
+		return faults.CodeFailedPrecondition
+	case codes.Aborted, codes.AlreadyExists:
+		return faults.CodeAborted
+	case codes.ResourceExhausted:
+		return faults.CodeResourceExhausted
+	case codes.Unavailable:
+		return faults.CodeUnavailable
+	case codes.Unimplemented:
+		return faults.CodeUnimplemented
+	case codes.Internal, codes.DataLoss:
+		return faults.CodeInternal
+	case codes.DeadlineExceeded:
+		return faults.CodeDeadlineExceeded
+	}
+	return faults.CodeUnknown
+}
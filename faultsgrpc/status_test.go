@@ -0,0 +1,172 @@
+package faultsgrpc_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultsgrpc"
+	"github.com/deixis/faults/faultstest"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// TestToStatus ensures faults are converted to statuses with the code of
+// their category and the matching error details.
+func TestToStatus(t *testing.T) {
+	secret := errors.New("pq: relation \"users\" does not exist")
+
+	table := []struct {
+		Error   error
+		Code    codes.Code
+		Message string
+		Details int
+	}{
+		{Error: nil, Code: codes.OK},
+		{
+			Error:   faults.Bad(&faults.FieldViolation{Field: "email", Code: faults.ViolationRequired, Description: "Field required"}),
+			Code:    codes.InvalidArgument,
+			Message: "Field required",
+			Details: 1,
+		},
+		{Error: faults.NotFound, Code: codes.NotFound, Message: "resource not found"},
+		{
+			Error:   faults.WithReason(faults.PermissionDenied, "ACCOUNT_LOCKED"),
+			Code:    codes.PermissionDenied,
+			Message: "permission denied",
+			Details: 1,
+		},
+		{
+			Error:   faults.WithUnavailable(secret, time.Second),
+			Code:    codes.Unavailable,
+			Message: "service temporarily unavailable, retry in 1s",
+			Details: 1,
+		},
+		{Error: faults.WithInternal(secret), Code: codes.Internal, Message: "internal error"},
+		{Error: status.Error(codes.DataLoss, "corrupted"), Code: codes.DataLoss, Message: "corrupted"},
+		{Error: secret, Code: codes.Unknown, Message: "unknown error"},
+	}
+
+	for i, test := range table {
+		st := faultsgrpc.ToStatus(test.Error)
+		if st.Code() != test.Code {
+			t.Errorf("%d - expect code %s, but got %s", i, test.Code, st.Code())
+		}
+		if st.Message() != test.Message {
+			t.Errorf("%d - expect message %q, but got %q", i, test.Message, st.Message())
+		}
+		if len(st.Details()) != test.Details {
+			t.Errorf("%d - expect %d details, but got %v", i, test.Details, st.Details())
+		}
+	}
+
+	st := faultsgrpc.ToStatus(faults.Bad(&faults.FieldViolation{Field: "email", Code: faults.ViolationRequired}))
+	d, ok := st.Details()[0].(*errdetails.BadRequest)
+	if !ok || d.GetFieldViolations()[0].GetReason() != faults.ViolationRequired {
+		t.Errorf("expect violation code as the reason of the field violation, but got %v", st.Details())
+	}
+}
+
+// TestHelp ensures links to documentation survive a status.
+func TestHelp(t *testing.T) {
+	links := []faults.Link{{Description: "Enable the API", URL: "https://example.com/docs/enable"}}
+	fault := faults.AttachHelp(faults.WithReason(faults.PermissionDenied, "API_DISABLED"), links...)
+
+	st := faultsgrpc.ToStatus(fault)
+	d, ok := st.Details()[1].(*errdetails.Help)
+	if !ok || d.GetLinks()[0].GetUrl() != links[0].URL {
+		t.Fatalf("expect Help detail after ErrorInfo, but got %v", st.Details())
+	}
+
+	err := faultsgrpc.FromStatus(st)
+	if !faults.IsPermissionDenied(err) || faults.ReasonOf(err) != "API_DISABLED" {
+		t.Errorf("expect permission denied with reason API_DISABLED, but got %v", err)
+	}
+	if got := faults.HelpOf(err); !reflect.DeepEqual(got, links) {
+		t.Errorf("expect links %v, but got %v", links, got)
+	}
+}
+
+// TestDomain ensures the domains of reasons are carried by the ErrorInfo
+// details of statuses.
+func TestDomain(t *testing.T) {
+	st := faultsgrpc.ToStatus(faults.WithReason(faults.FailedPrecondition(), "payments.example.com/INSUFFICIENT_FUNDS"))
+	d, ok := st.Details()[0].(*errdetails.ErrorInfo)
+	if !ok || d.GetDomain() != "payments.example.com" || d.GetReason() != "INSUFFICIENT_FUNDS" {
+		t.Fatalf("expect ErrorInfo detail with domain, but got %v", st.Details())
+	}
+
+	err := faultsgrpc.FromStatus(st)
+	if faults.ReasonOf(err) != "INSUFFICIENT_FUNDS" || faults.DomainOf(err) != "payments.example.com" {
+		t.Errorf("expect reason payments.example.com/INSUFFICIENT_FUNDS, but got %q in %q", faults.ReasonOf(err), faults.DomainOf(err))
+	}
+}
+
+// TestFromStatus ensures statuses that were not produced by ToStatus are
+// classified too.
+func TestFromStatus(t *testing.T) {
+	table := []struct {
+		Status *status.Status
+		Code   faults.Code
+	}{
+		{Status: nil, Code: faults.CodeOK},
+		{Status: status.New(codes.OK, ""), Code: faults.CodeOK},
+		{Status: status.New(codes.Canceled, "canceled"), Code: faults.CodeUnknown},
+		{Status: status.New(codes.OutOfRange, "page 12"), Code: faults.CodeBad},
+		{Status: status.New(codes.AlreadyExists, "user:42"), Code: faults.CodeAborted},
+		{Status: status.New(codes.DataLoss, "corrupted"), Code: faults.CodeInternal},
+		{Status: status.New(codes.Unavailable, "maintenance"), Code: faults.CodeUnavailable},
+	}
+
+	for i, test := range table {
+		err := faultsgrpc.FromStatus(test.Status)
+		if code := faults.CodeOf(err); code != test.Code {
+			t.Errorf("%d - expect code %s, but got %s", i, test.Code, code)
+		}
+	}
+}
+
+// TestStatusDebug ensures retry delays survive a round trip in debug mode,
+// where faults carry a DebugInfo.
+func TestStatusDebug(t *testing.T) {
+	faults.SetDebug(true)
+	defer faults.SetDebug(false)
+
+	fault := faults.Build(faults.CodeResourceExhausted).Quota("user:42", "Daily limit reached").Retryable(30 * time.Second).Err()
+	err := faultsgrpc.FromStatus(faultsgrpc.ToStatus(fault))
+	if !faults.IsResourceExhausted(err) {
+		t.Fatalf("expect resource exhausted, but got %v", err)
+	}
+	if delay := faults.RetryAdvice(err).Delay; delay != 30*time.Second {
+		t.Errorf("expect retry delay 30s, but got %s", delay)
+	}
+}
+
+// TestStatusConformance ensures statuses follow the semantics of the core.
+func TestStatusConformance(t *testing.T) {
+	faultstest.ConformanceSuite{
+		RoundTrip: func(err error) error {
+			return faultsgrpc.FromStatus(faultsgrpc.ToStatus(err))
+		},
+		Status: func(err error) int {
+			return int(faultsgrpc.ToStatus(err).Code())
+		},
+		ExpectedStatus: func(code faults.Code) int {
+			d, _ := faults.Describe(code)
+			return int(d.GRPCCode)
+		},
+		Render: func(err error) string {
+			return faultsgrpc.ToStatus(err).Message()
+		},
+		Encode: func(err error) []byte {
+			data, _ := proto.Marshal(faultsgrpc.ToStatus(err).Proto())
+			return data
+		},
+		RetryInfo: true,
+		Details:   true,
+	}.Run(t)
+}
@@ -0,0 +1,46 @@
+// Package faultshttp renders faults as HTTP responses, and reads them back on
+// the client side.
+//
+// Faults are rendered as problem details documents (RFC 9457), with the
+// category, the reason, the violations and the retry delay of the fault as
+// extension members:
+//
+//	HTTP/1.1 400 Bad Request
+//	Content-Type: application/problem+json
+//
+//	{
+//	  "type": "about:blank",
+//	  "title": "Bad Request",
+//	  "status": 400,
+//	  "detail": "Field required",
+//	  "instance": "/users",
+//	  "code": "BAD_REQUEST",
+//	  "violations": [{"field": "email", "description": "Field required"}]
+//	}
+//
+// The shape of each violation follows the fields of the corresponding Go
+// type, with lower camel case names. OpenAPI returns the OpenAPI 3 schemas
+// and responses describing these documents for each category, so API
+// specifications can document them.
+//
+// Handlers can also return their errors instead of rendering them, and let an
+// ErrorHandler write the response:
+//
+//	mux.Handle("GET /users/{id}", faultshttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+//		user, err := users.Get(r.Context(), r.PathValue("id"))
+//		if err != nil {
+//			return err
+//		}
+//		return json.NewEncoder(w).Encode(user)
+//	}))
+//
+// The ErrorHandler renders problem details documents by default; its Encoder
+// selects another representation, e.g. JSONAPIEncoder for JSON:API error
+// documents, whose field violations point to the attributes of the request
+// document with `source.pointer`, ODataEncoder for OData error envelopes, or
+// SOAPEncoder for SOAP 1.2 faults.
+//
+// On the client side, Transport converts error responses back to faults (see
+// ReadProblem, ReadJSONAPI, ReadOData and ReadSOAP), and RetryTransport also
+// retries idempotent requests failing with a retryable fault.
+package faultshttp
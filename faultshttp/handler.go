@@ -0,0 +1,170 @@
+package faultshttp
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/deixis/faults"
+)
+
+// An Encoder encodes faults in the body of HTTP responses.
+type Encoder interface {
+	// ContentType returns the media type of the documents produced by the
+	// encoder.
+	ContentType() string
+	// Encode writes the document describing `err`, which occurred while
+	// serving `r`, to `w`.
+	Encode(w io.Writer, r *http.Request, err error) error
+}
+
+// An ErrorHandler converts the errors returned by handlers to HTTP responses.
+//
+// The status of the response is the HTTP status of the category of the error
+// (see faults.HTTPStatus), and a Retry-After header is set when the fault
+// advises a retry delay. The body is written by the encoder.
+type ErrorHandler struct {
+	// Encoder encodes the body of the responses. ProblemEncoder is used when
+	// it is nil.
+	Encoder Encoder
+	// Hook is called with the error returned by a handler before it is
+	// rendered. The error it returns is rendered instead, which allows errors
+	// to be logged, classified (e.g. with faults.FromStd) or replaced. The
+	// response is not written when it returns nil.
+	Hook func(r *http.Request, err error) error
+}
+
+// DefaultErrorHandler is the error handler used by HandlerFunc.
+var DefaultErrorHandler = &ErrorHandler{}
+
+// Handle returns an HTTP handler that calls `fn`, and converts the error it
+// returns to a response.
+//
+// Example:
+//
+//	eh := &faultshttp.ErrorHandler{
+//		Hook: func(r *http.Request, err error) error {
+//			if faults.IsServerFault(err) {
+//				log.Printf("%s %s: %s", r.Method, r.URL, err)
+//			}
+//			return faults.FromStd(err)
+//		},
+//	}
+//	mux.Handle("GET /users/{id}", eh.Handle(func(w http.ResponseWriter, r *http.Request) error {
+//		user, err := users.Get(r.Context(), r.PathValue("id"))
+//		if err != nil {
+//			return err
+//		}
+//		return json.NewEncoder(w).Encode(user)
+//	}))
+//
+// When `fn` has already started writing the response before returning an
+// error, the error can't be rendered anymore. It is only passed to the hook.
+func (h *ErrorHandler) Handle(fn func(w http.ResponseWriter, r *http.Request) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := &responseWriter{ResponseWriter: w}
+		err := fn(rw, r)
+		if err == nil {
+			return
+		}
+		if rw.wroteHeader {
+			if h.Hook != nil {
+				h.Hook(r, err)
+			}
+			return
+		}
+		h.WriteError(w, r, err)
+	})
+}
+
+// WriteError writes the response describing `err` to `w`.
+func (h *ErrorHandler) WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	if h.Hook != nil {
+		if err = h.Hook(r, err); err == nil {
+			return
+		}
+	}
+
+	enc := h.Encoder
+	if enc == nil {
+		enc = ProblemEncoder
+	}
+	faults.Trace(r.Context(), faults.EventRender, err, "faultshttp: "+enc.ContentType())
+
+	w.Header().Set("Content-Type", enc.ContentType())
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	faults.SetRetryAfter(w.Header(), err)
+	faults.SetWWWAuthenticate(w.Header(), err)
+	w.WriteHeader(faults.HTTPStatus(err))
+	enc.Encode(w, r, err)
+}
+
+// Recover returns an HTTP handler that calls `next`, and converts its panics
+// into responses, like the errors returned by the handlers of Handle. Panics
+// become InternalFailures carrying the stack and the value of the panic (see
+// faults.Recover), so they reach the hook, e.g. to be logged, and are
+// rendered like any other server fault:
+//
+//	eh := &faultshttp.ErrorHandler{Hook: logError}
+//	srv := &http.Server{Handler: eh.Recover(mux)}
+//
+// The http.ErrAbortHandler panics, which abort the response on purpose, are
+// let through.
+func (h *ErrorHandler) Recover(next http.Handler) http.Handler {
+	return h.Handle(func(w http.ResponseWriter, r *http.Request) (err error) {
+		defer func() {
+			if errors.Is(err, http.ErrAbortHandler) {
+				panic(http.ErrAbortHandler)
+			}
+		}()
+		defer faults.Recover(&err)
+		next.ServeHTTP(w, r)
+		return nil
+	})
+}
+
+// A HandlerFunc is an HTTP handler that returns an error. The error is
+// converted to a response by DefaultErrorHandler.
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+// ServeHTTP calls f(w, r), and writes the error it returns, if any.
+func (f HandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	DefaultErrorHandler.Handle(f).ServeHTTP(w, r)
+}
+
+// responseWriter records whether the response has been started. It forwards
+// Flush and Hijack to the writer it wraps, e.g. for server-sent events and
+// WebSockets.
+type responseWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseWriter) Flush() {
+	w.wroteHeader = true
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err == nil {
+		w.wroteHeader = true
+	}
+	return conn, rw, err
+}
+
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,283 @@
+package faultshttp_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultshttp"
+)
+
+// TestHandlerFunc ensures the errors returned by handlers are converted to
+// responses.
+func TestHandlerFunc(t *testing.T) {
+	table := []struct {
+		Error      error
+		Status     int
+		RetryAfter string
+	}{
+		{Error: faults.NotFound, Status: http.StatusNotFound},
+		{Error: faults.Bad(), Status: http.StatusBadRequest},
+		{Error: faults.Unavailable(1500 * time.Millisecond), Status: http.StatusServiceUnavailable, RetryAfter: "2"},
+		{Error: faults.Unavailable(0), Status: http.StatusServiceUnavailable},
+		{Error: errors.New("boom"), Status: http.StatusInternalServerError},
+	}
+
+	for i, test := range table {
+		h := faultshttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+			return test.Error
+		})
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", "/users", nil))
+
+		if rec.Code != test.Status {
+			t.Errorf("%d - expect status %d, but got %d", i, test.Status, rec.Code)
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != faultshttp.ProblemContentType {
+			t.Errorf("%d - expect content type %s, but got %s", i, faultshttp.ProblemContentType, ct)
+		}
+		if ra := rec.Header().Get("Retry-After"); ra != test.RetryAfter {
+			t.Errorf("%d - expect Retry-After %q, but got %q", i, test.RetryAfter, ra)
+		}
+
+		var p faultshttp.Problem
+		if err := json.NewDecoder(rec.Body).Decode(&p); err != nil {
+			t.Fatalf("%d - expect problem document, but got %s", i, err)
+		}
+		if p.Status != test.Status {
+			t.Errorf("%d - expect problem status %d, but got %d", i, test.Status, p.Status)
+		}
+	}
+}
+
+// TestHandlerFuncSuccess ensures responses are left untouched when handlers
+// succeed.
+func TestHandlerFuncSuccess(t *testing.T) {
+	h := faultshttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, "created")
+		return nil
+	})
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("POST", "/users", nil))
+
+	if rec.Code != http.StatusCreated || rec.Body.String() != "created" {
+		t.Errorf("expect handler response, but got %d %q", rec.Code, rec.Body)
+	}
+}
+
+// TestErrorHandlerHook ensures the hook can observe and replace errors.
+func TestErrorHandlerHook(t *testing.T) {
+	var seen []error
+	eh := &faultshttp.ErrorHandler{
+		Hook: func(r *http.Request, err error) error {
+			seen = append(seen, err)
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return faults.WithNotFound(err)
+		},
+	}
+
+	table := []struct {
+		Handler func(w http.ResponseWriter, r *http.Request) error
+		Status  int
+		Body    string
+	}{
+		{
+			Handler: func(w http.ResponseWriter, r *http.Request) error {
+				return errors.New("no rows")
+			},
+			Status: http.StatusNotFound,
+		},
+		{
+			Handler: func(w http.ResponseWriter, r *http.Request) error {
+				return io.EOF
+			},
+			Status: http.StatusOK,
+		},
+		{
+			Handler: func(w http.ResponseWriter, r *http.Request) error {
+				io.WriteString(w, "partial")
+				return errors.New("write failed")
+			},
+			Status: http.StatusOK,
+			Body:   "partial",
+		},
+	}
+
+	for i, test := range table {
+		seen = nil
+		rec := httptest.NewRecorder()
+		eh.Handle(test.Handler).ServeHTTP(rec, httptest.NewRequest("GET", "/users/42", nil))
+
+		if rec.Code != test.Status {
+			t.Errorf("%d - expect status %d, but got %d", i, test.Status, rec.Code)
+		}
+		if test.Body != "" && rec.Body.String() != test.Body {
+			t.Errorf("%d - expect body %q, but got %q", i, test.Body, rec.Body)
+		}
+		if len(seen) != 1 {
+			t.Errorf("%d - expect hook to be called once, but got %d", i, len(seen))
+		}
+	}
+}
+
+// TestErrorHandlerRecover ensures the panics of handlers are rendered as
+// internal failures, and reach the hook with their stack.
+func TestErrorHandlerRecover(t *testing.T) {
+	var seen error
+	eh := &faultshttp.ErrorHandler{
+		Hook: func(r *http.Request, err error) error {
+			seen = err
+			return err
+		},
+	}
+
+	table := []struct {
+		Handler http.HandlerFunc
+		Status  int
+		Body    string
+		Panic   bool
+	}{
+		{
+			Handler: func(w http.ResponseWriter, r *http.Request) { panic("boom") },
+			Status:  http.StatusInternalServerError,
+			Panic:   true,
+		},
+		{
+			Handler: func(w http.ResponseWriter, r *http.Request) {
+				io.WriteString(w, "partial")
+				panic("boom")
+			},
+			Status: http.StatusOK,
+			Body:   "partial",
+			Panic:  true,
+		},
+		{
+			Handler: func(w http.ResponseWriter, r *http.Request) { io.WriteString(w, "ok") },
+			Status:  http.StatusOK,
+			Body:    "ok",
+		},
+	}
+
+	for i, test := range table {
+		seen = nil
+		rec := httptest.NewRecorder()
+		eh.Recover(test.Handler).ServeHTTP(rec, httptest.NewRequest("GET", "/users/42", nil))
+
+		if rec.Code != test.Status {
+			t.Errorf("%d - expect status %d, but got %d", i, test.Status, rec.Code)
+		}
+		if test.Body != "" && rec.Body.String() != test.Body {
+			t.Errorf("%d - expect body %q, but got %q", i, test.Body, rec.Body)
+		}
+		if test.Panic && (!faults.IsInternal(seen) || faults.StackOf(seen) == nil) {
+			t.Errorf("%d - expect internal failure with stack, but got %v", i, seen)
+		}
+		if !test.Panic && seen != nil {
+			t.Errorf("%d - expect no error, but got %v", i, seen)
+		}
+		if strings.Contains(rec.Body.String(), "boom") {
+			t.Errorf("%d - expect panic value to stay private, but got %q", i, rec.Body)
+		}
+	}
+}
+
+// TestErrorHandlerRecoverAbort ensures http.ErrAbortHandler panics are let
+// through.
+func TestErrorHandlerRecoverAbort(t *testing.T) {
+	defer func() {
+		if r := recover(); r != http.ErrAbortHandler {
+			t.Errorf("expect abort panic, but got %v", r)
+		}
+	}()
+
+	h := faultshttp.DefaultErrorHandler.Recover(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+}
+
+// TestErrorHandlerFlush ensures handlers can flush their responses, e.g. to
+// stream server-sent events.
+func TestErrorHandlerFlush(t *testing.T) {
+	h := faultshttp.DefaultErrorHandler.Recover(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		f, ok := w.(http.Flusher)
+		if !ok {
+			t.Fatal("expect response writer to be a flusher")
+		}
+		f.Flush()
+		io.WriteString(w, "data: ready\n\n")
+		f.Flush()
+	}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/events", nil))
+
+	if !rec.Flushed || rec.Body.String() != "data: ready\n\n" {
+		t.Errorf("expect flushed events, but got %v %q", rec.Flushed, rec.Body)
+	}
+}
+
+// TestErrorHandlerHijack ensures handlers can take over the connection, e.g.
+// to upgrade it to a WebSocket.
+func TestErrorHandlerHijack(t *testing.T) {
+	srv := httptest.NewServer(faultshttp.DefaultErrorHandler.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		hj, ok := w.(http.Hijacker)
+		if !ok {
+			return faults.Unimplemented
+		}
+		conn, rw, err := hj.Hijack()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n")
+		return rw.Flush()
+	}))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("expect response, but got %s", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Errorf("expect status 101, but got %d", resp.StatusCode)
+	}
+}
+
+// TestErrorHandlerEncoder ensures the body can be rendered by a custom encoder.
+func TestErrorHandlerEncoder(t *testing.T) {
+	eh := &faultshttp.ErrorHandler{Encoder: textEncoder{}}
+	rec := httptest.NewRecorder()
+	eh.WriteError(rec, httptest.NewRequest("GET", "/", nil), faults.PermissionDenied)
+
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("expect status %d, but got %d", http.StatusForbidden, rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "text/plain; charset=utf-8" {
+		t.Errorf("expect text content type, but got %s", ct)
+	}
+	if body := strings.TrimSpace(rec.Body.String()); body != "PERMISSION_DENIED" {
+		t.Errorf("expect code in body, but got %q", body)
+	}
+}
+
+type textEncoder struct{}
+
+func (textEncoder) ContentType() string {
+	return "text/plain; charset=utf-8"
+}
+
+func (textEncoder) Encode(w io.Writer, r *http.Request, err error) error {
+	_, werr := io.WriteString(w, faults.CodeOf(err).String()+"\n")
+	return werr
+}
@@ -0,0 +1,228 @@
+package faultshttp
+
+import (
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/deixis/faults"
+)
+
+// JSONAPIContentType is the media type of JSON:API documents.
+const JSONAPIContentType = "application/vnd.api+json"
+
+// JSONAPIEncoder encodes faults as JSON:API error documents, for clients
+// that consume JSON:API:
+//
+//	eh := &faultshttp.ErrorHandler{Encoder: faultshttp.JSONAPIEncoder}
+//
+// The document lists one error object per violation of the fault, or a
+// single one when it has none. Each object carries the same members as the
+// problem details document of the fault (see NewProblem): field violations
+// point to the attribute they are about with `source.pointer` (e.g.
+// "/data/attributes/user/email" for the field "user.email"), while the
+// reason, the metadata, the retry delay, the permanent marker, the truncation
+// marker and the other violation fields, such as the code of a field violation, are `meta`
+// members.
+var JSONAPIEncoder Encoder = jsonAPIEncoder{}
+
+// A JSONAPIDocument is a JSON:API document carrying errors.
+type JSONAPIDocument struct {
+	Errors []JSONAPIError `json:"errors"`
+}
+
+// A JSONAPIError is a JSON:API error object.
+type JSONAPIError struct {
+	Status string            `json:"status,omitempty"`
+	Code   string            `json:"code,omitempty"`
+	Title  string            `json:"title,omitempty"`
+	Detail string            `json:"detail,omitempty"`
+	Source *JSONAPISource    `json:"source,omitempty"`
+	Meta   map[string]string `json:"meta,omitempty"`
+}
+
+// A JSONAPISource identifies the part of the request an error is about.
+type JSONAPISource struct {
+	Pointer   string `json:"pointer,omitempty"`
+	Parameter string `json:"parameter,omitempty"`
+	Header    string `json:"header,omitempty"`
+}
+
+type jsonAPIEncoder struct{}
+
+func (jsonAPIEncoder) ContentType() string {
+	return JSONAPIContentType
+}
+
+func (jsonAPIEncoder) Encode(w io.Writer, r *http.Request, err error) error {
+	return json.NewEncoder(w).Encode(NewJSONAPIDocument(r, err))
+}
+
+// NewJSONAPIDocument returns the JSON:API document describing `err`, which
+// occurred while serving `r`.
+func NewJSONAPIDocument(r *http.Request, err error) *JSONAPIDocument {
+	p := NewProblem(r, err)
+
+	base := JSONAPIError{
+		Status: strconv.Itoa(p.Status),
+		Code:   p.Code,
+		Title:  p.Title,
+		Detail: p.Detail,
+	}
+	meta := map[string]string{}
+	if p.Reason != "" {
+		meta["reason"] = p.Reason
+	}
+	if p.Domain != "" {
+		meta["domain"] = p.Domain
+	}
+	for k, v := range p.Metadata {
+		meta["metadata."+k] = v
+	}
+	if p.RetryDelay > 0 {
+		meta["retryDelay"] = strconv.FormatInt(p.RetryDelay, 10)
+	}
+	if p.Permanent {
+		meta["permanent"] = "true"
+	}
+	if p.Truncated {
+		meta["truncated"] = "true"
+	}
+
+	doc := &JSONAPIDocument{}
+	if len(p.Violations) == 0 {
+		base.Meta = withMeta(meta, nil)
+		doc.Errors = append(doc.Errors, base)
+		return doc
+	}
+	for _, v := range p.Violations {
+		e := base
+		e.Detail = v.Description
+		if v.Field != "" {
+			e.Source = &JSONAPISource{Pointer: fieldPointer(v.Field)}
+		}
+		e.Meta = withMeta(meta, map[string]string{
+			"code":     v.Code,
+			"type":     v.Type,
+			"subject":  v.Subject,
+			"resource": v.Resource,
+		})
+		doc.Errors = append(doc.Errors, e)
+	}
+	return doc
+}
+
+// withMeta returns a copy of `meta` with the non-empty members of `extra`,
+// or nil when it is empty.
+func withMeta(meta, extra map[string]string) map[string]string {
+	m := make(map[string]string, len(meta)+len(extra))
+	for k, v := range meta {
+		m[k] = v
+	}
+	for k, v := range extra {
+		if v != "" {
+			m[k] = v
+		}
+	}
+	if len(m) == 0 {
+		return nil
+	}
+	return m
+}
+
+// Problem converts `d` to the problem details document describing the same
+// fault, which can then be converted to a fault with Problem.Err.
+func (d *JSONAPIDocument) Problem() *Problem {
+	p := &Problem{}
+	for i, e := range d.Errors {
+		if i == 0 {
+			p.Status, _ = strconv.Atoi(e.Status)
+			p.Code = e.Code
+			p.Title = e.Title
+			p.Detail = e.Detail
+			for k, v := range e.Meta {
+				switch {
+				case k == "reason":
+					p.Reason = v
+				case k == "domain":
+					p.Domain = v
+				case k == "retryDelay":
+					p.RetryDelay, _ = strconv.ParseInt(v, 10, 64)
+				case k == "permanent":
+					p.Permanent, _ = strconv.ParseBool(v)
+				case k == "truncated":
+					p.Truncated, _ = strconv.ParseBool(v)
+				case strings.HasPrefix(k, "metadata."):
+					if p.Metadata == nil {
+						p.Metadata = map[string]string{}
+					}
+					p.Metadata[strings.TrimPrefix(k, "metadata.")] = v
+				}
+			}
+		}
+
+		v := Violation{
+			Code:        e.Meta["code"],
+			Type:        e.Meta["type"],
+			Subject:     e.Meta["subject"],
+			Resource:    e.Meta["resource"],
+			Description: e.Detail,
+		}
+		if e.Source != nil && e.Source.Pointer != "" {
+			v.Field = pointerField(e.Source.Pointer)
+		}
+		if v.Field != "" || v.Code != "" || v.Type != "" || v.Subject != "" || v.Resource != "" {
+			p.Violations = append(p.Violations, v)
+		}
+	}
+	return p
+}
+
+// ReadJSONAPI reconstructs the fault described by `resp`, like ReadProblem,
+// for services rendering JSON:API error documents. Responses that are not
+// JSON:API documents are converted with ReadProblem.
+func ReadJSONAPI(resp *http.Response) error {
+	if resp == nil || resp.StatusCode < 400 {
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || mediaType != JSONAPIContentType || resp.Body == nil {
+		return ReadProblem(resp)
+	}
+
+	var doc JSONAPIDocument
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxProblemSize)).Decode(&doc); err != nil {
+		doc = JSONAPIDocument{}
+	}
+	p := doc.Problem()
+	if p.Status == 0 {
+		p.Status = resp.StatusCode
+	}
+	if p.RetryDelay == 0 {
+		p.RetryDelay = faults.ParseRetryAfter(resp.Header.Get("Retry-After")).Milliseconds()
+	}
+	fault := p.Err()
+	if e, ok := faults.AsUnauthenticated(fault); ok {
+		e.Challenges = faults.ParseWWWAuthenticate(resp.Header)
+	}
+	return fault
+}
+
+// attributesPointer is the JSON pointer of the attributes of the primary
+// resource of a JSON:API document.
+const attributesPointer = "/data/attributes"
+
+// fieldPointer returns the JSON pointer to the attribute `field`, e.g.
+// "/data/attributes/items/0/name" for "items[0].name", whatever the notation
+// of `field`.
+func fieldPointer(field string) string {
+	return attributesPointer + faults.ParseFieldPath(field).Pointer()
+}
+
+// pointerField reverses fieldPointer.
+func pointerField(pointer string) string {
+	return faults.ParseFieldPath(strings.TrimPrefix(pointer, attributesPointer)).String()
+}
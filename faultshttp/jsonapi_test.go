@@ -0,0 +1,156 @@
+package faultshttp_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultshttp"
+	"github.com/deixis/faults/faultstest"
+)
+
+// TestJSONAPIEncoder ensures faults are rendered as JSON:API error documents.
+func TestJSONAPIEncoder(t *testing.T) {
+	table := []struct {
+		Error    error
+		Document faultshttp.JSONAPIDocument
+	}{
+		{
+			Error: faults.Bad(
+				&faults.FieldViolation{Field: "email", Description: "Field required"},
+				&faults.FieldViolation{Field: "items[0].name", Code: faults.ViolationTooLong, Description: "Too long"},
+			),
+			Document: faultshttp.JSONAPIDocument{Errors: []faultshttp.JSONAPIError{
+				{
+					Status: "400",
+					Code:   "BAD_REQUEST",
+					Title:  "Bad Request",
+					Detail: "Field required",
+					Source: &faultshttp.JSONAPISource{Pointer: "/data/attributes/email"},
+				},
+				{
+					Status: "400",
+					Code:   "BAD_REQUEST",
+					Title:  "Bad Request",
+					Detail: "Too long",
+					Source: &faultshttp.JSONAPISource{Pointer: "/data/attributes/items/0/name"},
+					Meta:   map[string]string{"code": "TOO_LONG"},
+				},
+			}},
+		},
+		{
+			Error: faults.WithErrorInfo(
+				faults.FailedPrecondition(&faults.PreconditionViolation{
+					Type:        "BALANCE",
+					Subject:     "account:42",
+					Description: "Insufficient funds",
+				}),
+				"INSUFFICIENT_FUNDS",
+				map[string]string{"missing": "12.50"},
+			),
+			Document: faultshttp.JSONAPIDocument{Errors: []faultshttp.JSONAPIError{
+				{
+					Status: "400",
+					Code:   "FAILED_PRECONDITION",
+					Title:  "Bad Request",
+					Detail: "Insufficient funds",
+					Meta: map[string]string{
+						"reason":           "INSUFFICIENT_FUNDS",
+						"metadata.missing": "12.50",
+						"type":             "BALANCE",
+						"subject":          "account:42",
+					},
+				},
+			}},
+		},
+		{
+			Error: faults.WithUnavailable(errors.New("dial tcp 10.0.0.1:5432: connection refused"), time.Second),
+			Document: faultshttp.JSONAPIDocument{Errors: []faultshttp.JSONAPIError{
+				{
+					Status: "503",
+					Code:   "UNAVAILABLE",
+					Title:  "Service Unavailable",
+					Meta:   map[string]string{"retryDelay": "1000"},
+				},
+			}},
+		},
+	}
+
+	eh := &faultshttp.ErrorHandler{Encoder: faultshttp.JSONAPIEncoder}
+	for i, test := range table {
+		rec := httptest.NewRecorder()
+		eh.WriteError(rec, httptest.NewRequest("POST", "/users", nil), test.Error)
+
+		if ct := rec.Header().Get("Content-Type"); ct != faultshttp.JSONAPIContentType {
+			t.Errorf("%d - expect content type %s, but got %s", i, faultshttp.JSONAPIContentType, ct)
+		}
+		var doc faultshttp.JSONAPIDocument
+		if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+			t.Fatalf("%d - expect JSON:API document, but got %s", i, err)
+		}
+		if !reflect.DeepEqual(doc, test.Document) {
+			t.Errorf("%d - expect document %+v, but got %+v", i, test.Document, doc)
+		}
+	}
+}
+
+// TestReadJSONAPI ensures JSON:API error documents are converted back to
+// faults.
+func TestReadJSONAPI(t *testing.T) {
+	rec := httptest.NewRecorder()
+	eh := &faultshttp.ErrorHandler{Encoder: faultshttp.JSONAPIEncoder}
+	eh.WriteError(rec, httptest.NewRequest("POST", "/users", nil), faults.Bad(
+		&faults.FieldViolation{Field: "user.email", Description: "Field required"},
+		&faults.FieldViolation{Field: "items[0].name", Description: "Too long"},
+	))
+
+	err := faultshttp.ReadJSONAPI(rec.Result())
+	e, ok := faults.AsBad(err)
+	if !ok {
+		t.Fatalf("expect bad request, but got %s", faults.CodeOf(err))
+	}
+	expect := []string{"user.email", "items[0].name"}
+	if len(e.Violations) != len(expect) {
+		t.Fatalf("expect %d violations, but got %d", len(expect), len(e.Violations))
+	}
+	for i, v := range e.Violations {
+		if v.Field != expect[i] {
+			t.Errorf("%d - expect field %s, but got %s", i, expect[i], v.Field)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	faultshttp.WriteProblem(rec, httptest.NewRequest("GET", "/", nil), faults.NotFound)
+	if err := faultshttp.ReadJSONAPI(rec.Result()); !faults.IsNotFound(err) {
+		t.Errorf("expect problem details to be read, but got %s", faults.CodeOf(err))
+	}
+}
+
+// TestJSONAPIConformance ensures JSON:API error documents follow the
+// semantics of the core.
+func TestJSONAPIConformance(t *testing.T) {
+	write := func(err error) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		eh := &faultshttp.ErrorHandler{Encoder: faultshttp.JSONAPIEncoder}
+		eh.WriteError(rec, httptest.NewRequest("GET", "/", nil), err)
+		return rec
+	}
+
+	faultstest.ConformanceSuite{
+		RoundTrip: func(err error) error {
+			return faultshttp.ReadJSONAPI(write(err).Result())
+		},
+		Status: func(err error) int {
+			return write(err).Code
+		},
+		Render: func(err error) string {
+			return write(err).Body.String()
+		},
+		RetryInfo: true,
+		Details:   true,
+	}.Run(t)
+}
@@ -0,0 +1,187 @@
+package faultshttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/deixis/faults"
+)
+
+// ODataEncoder encodes faults as OData error envelopes, as described by the
+// Microsoft REST API guidelines, for clients that expect them:
+//
+//	eh := &faultshttp.ErrorHandler{Encoder: faultshttp.ODataEncoder}
+//
+// The code of the error is the category of the fault, e.g. "NOT_FOUND", and
+// each violation of the fault is listed as a detail, whose target is the
+// field, subject or resource it is about, and whose code is the code or the
+// type of the violation, if any. The reason, its domain, the metadata, the
+// retry delay, the permanent marker and the truncation marker of the fault are
+// members of the inner error.
+//
+//	{
+//	  "error": {
+//	    "code": "BAD_REQUEST",
+//	    "message": "Field required",
+//	    "details": [{"code": "BAD_REQUEST", "message": "Field required", "target": "email"}]
+//	  }
+//	}
+var ODataEncoder Encoder = odataEncoder{}
+
+// An ODataEnvelope is the body of an OData error response.
+type ODataEnvelope struct {
+	Error ODataError `json:"error"`
+}
+
+// An ODataError is an OData error object, or one of its details.
+type ODataError struct {
+	Code       string           `json:"code"`
+	Message    string           `json:"message"`
+	Target     string           `json:"target,omitempty"`
+	Details    []ODataError     `json:"details,omitempty"`
+	InnerError *ODataInnerError `json:"innererror,omitempty"`
+}
+
+// An ODataInnerError carries the members of a fault that don't have an
+// equivalent in the OData error object.
+type ODataInnerError struct {
+	// Code is the reason attached to the fault, if any (see
+	// faults.ErrorInfo).
+	Code string `json:"code,omitempty"`
+	// Domain is the domain of the reason, if any (see faults.DomainOf).
+	Domain string `json:"domain,omitempty"`
+	// Metadata is the metadata attached to the reason of the fault, if any.
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// RetryDelay is the delay advised before retrying, in milliseconds.
+	RetryDelay int64 `json:"retryDelay,omitempty"`
+	// Permanent reports whether the fault must not be retried, whatever its
+	// category (see faults.Permanent).
+	Permanent bool `json:"permanent,omitempty"`
+	// Truncated reports whether details of the fault were dropped to fit the
+	// limits of the envelope (see faults.Limits).
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+type odataEncoder struct{}
+
+func (odataEncoder) ContentType() string {
+	return "application/json"
+}
+
+func (odataEncoder) Encode(w io.Writer, r *http.Request, err error) error {
+	return json.NewEncoder(w).Encode(NewODataEnvelope(r, err))
+}
+
+// NewODataEnvelope returns the OData error envelope describing `err`, which
+// occurred while serving `r`. Like NewProblem, it only exposes the message
+// of client faults.
+func NewODataEnvelope(r *http.Request, err error) *ODataEnvelope {
+	p := NewProblem(r, err)
+
+	e := ODataError{Code: p.Code, Message: p.Detail}
+	if e.Message == "" {
+		e.Message = p.Title
+	}
+	for _, v := range p.Violations {
+		detail := ODataError{Code: p.Code, Message: v.Description, Target: v.Field}
+		if v.Code != "" {
+			detail.Code = v.Code
+		} else if v.Type != "" {
+			detail.Code = v.Type
+		}
+		if detail.Target == "" {
+			detail.Target = v.Subject
+		}
+		if detail.Target == "" {
+			detail.Target = v.Resource
+		}
+		e.Details = append(e.Details, detail)
+	}
+	if p.Reason != "" || p.RetryDelay > 0 || p.Permanent || p.Truncated {
+		e.InnerError = &ODataInnerError{
+			Code:       p.Reason,
+			Domain:     p.Domain,
+			Metadata:   p.Metadata,
+			RetryDelay: p.RetryDelay,
+			Permanent:  p.Permanent,
+			Truncated:  p.Truncated,
+		}
+	}
+	return &ODataEnvelope{Error: e}
+}
+
+// Problem converts `e`, received with the HTTP status `status`, to the
+// problem details document describing the same fault, which can then be
+// converted to a fault with Problem.Err.
+func (e *ODataEnvelope) Problem(status int) *Problem {
+	p := &Problem{
+		Status: status,
+		Title:  http.StatusText(status),
+		Code:   e.Error.Code,
+		Detail: e.Error.Message,
+	}
+	if inner := e.Error.InnerError; inner != nil {
+		p.Reason = inner.Code
+		p.Domain = inner.Domain
+		p.Metadata = inner.Metadata
+		p.RetryDelay = inner.RetryDelay
+		p.Permanent = inner.Permanent
+		p.Truncated = inner.Truncated
+	}
+	for _, d := range e.Error.Details {
+		// The target is the field, the subject or the resource of the
+		// violation depending on the category, which only keeps the relevant
+		// one when the fault is restored
+		v := Violation{
+			Field:       d.Target,
+			Subject:     d.Target,
+			Resource:    d.Target,
+			Description: d.Message,
+		}
+		if d.Code != e.Error.Code {
+			v.Code = d.Code
+			v.Type = d.Code
+		}
+		p.Violations = append(p.Violations, v)
+	}
+	return p
+}
+
+// ReadOData reconstructs the fault described by `resp`, like ReadProblem,
+// for services rendering OData error envelopes. Problem details documents
+// are converted with ReadProblem, and responses without an envelope with
+// faults.FromHTTPResponse, which reads their body too, e.g. to decode a fault
+// encoded with faults.EncodeJSON.
+func ReadOData(resp *http.Response) error {
+	if resp == nil || resp.StatusCode < 400 {
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" || resp.Body == nil {
+		return ReadProblem(resp)
+	}
+
+	// The body is read once, so the responses without an envelope can be
+	// described by the body they carry. The extra byte lets
+	// faults.FromHTTPResponse tell when the body was cut.
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxProblemSize+1))
+	var envelope ODataEnvelope
+	if err != nil || json.Unmarshal(body, &envelope) != nil ||
+		(envelope.Error.Code == "" && envelope.Error.Message == "") {
+		fallback := *resp
+		fallback.Body = io.NopCloser(bytes.NewReader(body))
+		return faults.FromHTTPResponse(&fallback)
+	}
+	p := envelope.Problem(resp.StatusCode)
+	if p.RetryDelay == 0 {
+		p.RetryDelay = faults.ParseRetryAfter(resp.Header.Get("Retry-After")).Milliseconds()
+	}
+	fault := p.Err()
+	if e, ok := faults.AsUnauthenticated(fault); ok {
+		e.Challenges = faults.ParseWWWAuthenticate(resp.Header)
+	}
+	return fault
+}
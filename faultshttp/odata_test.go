@@ -0,0 +1,183 @@
+package faultshttp_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultshttp"
+	"github.com/deixis/faults/faultstest"
+)
+
+// TestODataEncoder ensures faults are rendered as OData error envelopes.
+func TestODataEncoder(t *testing.T) {
+	table := []struct {
+		Error    error
+		Envelope faultshttp.ODataEnvelope
+	}{
+		{
+			Error: faults.Bad(
+				&faults.FieldViolation{Field: "email", Code: faults.ViolationRequired, Description: "Field required"},
+				&faults.FieldViolation{Field: "name", Description: "Too long"},
+			),
+			Envelope: faultshttp.ODataEnvelope{Error: faultshttp.ODataError{
+				Code:    "BAD_REQUEST",
+				Message: "Field required. Too long",
+				Details: []faultshttp.ODataError{
+					{Code: "REQUIRED", Message: "Field required", Target: "email"},
+					{Code: "BAD_REQUEST", Message: "Too long", Target: "name"},
+				},
+			}},
+		},
+		{
+			Error: faults.WithErrorInfo(
+				faults.FailedPrecondition(&faults.PreconditionViolation{
+					Type:        "BALANCE",
+					Subject:     "account:42",
+					Description: "Insufficient funds",
+				}),
+				"INSUFFICIENT_FUNDS",
+				map[string]string{"missing": "12.50"},
+			),
+			Envelope: faultshttp.ODataEnvelope{Error: faultshttp.ODataError{
+				Code:    "FAILED_PRECONDITION",
+				Message: "Insufficient funds",
+				Details: []faultshttp.ODataError{
+					{Code: "BALANCE", Message: "Insufficient funds", Target: "account:42"},
+				},
+				InnerError: &faultshttp.ODataInnerError{
+					Code:     "INSUFFICIENT_FUNDS",
+					Metadata: map[string]string{"missing": "12.50"},
+				},
+			}},
+		},
+		{
+			Error: faults.WithUnavailable(errors.New("dial tcp 10.0.0.1:5432: connection refused"), time.Second),
+			Envelope: faultshttp.ODataEnvelope{Error: faultshttp.ODataError{
+				Code:       "UNAVAILABLE",
+				Message:    "Service Unavailable",
+				InnerError: &faultshttp.ODataInnerError{RetryDelay: 1000},
+			}},
+		},
+	}
+
+	eh := &faultshttp.ErrorHandler{Encoder: faultshttp.ODataEncoder}
+	for i, test := range table {
+		rec := httptest.NewRecorder()
+		eh.WriteError(rec, httptest.NewRequest("POST", "/users", nil), test.Error)
+
+		if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("%d - expect content type application/json, but got %s", i, ct)
+		}
+		var envelope faultshttp.ODataEnvelope
+		if err := json.Unmarshal(rec.Body.Bytes(), &envelope); err != nil {
+			t.Fatalf("%d - expect OData envelope, but got %s", i, err)
+		}
+		if !reflect.DeepEqual(envelope, test.Envelope) {
+			t.Errorf("%d - expect envelope %+v, but got %+v", i, test.Envelope, envelope)
+		}
+	}
+}
+
+// TestReadOData ensures OData error envelopes are converted back to faults,
+// including the ones that were not rendered by this package.
+func TestReadOData(t *testing.T) {
+	table := []struct {
+		Status int
+		Body   string
+		Code   faults.Code
+	}{
+		{
+			Status: http.StatusNotFound,
+			Body:   `{"error":{"code":"NOT_FOUND","message":"No such user"}}`,
+			Code:   faults.CodeNotFound,
+		},
+		{
+			// Service-defined codes fall back to the status
+			Status: http.StatusForbidden,
+			Body:   `{"error":{"code":"Forbidden","message":"Access denied"}}`,
+			Code:   faults.CodePermissionDenied,
+		},
+		{
+			Status: http.StatusServiceUnavailable,
+			Body:   `{"message":"not an envelope"}`,
+			Code:   faults.CodeUnavailable,
+		},
+	}
+
+	for i, test := range table {
+		resp := &http.Response{
+			StatusCode: test.Status,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(test.Body)),
+		}
+		if code := faults.CodeOf(faultshttp.ReadOData(resp)); code != test.Code {
+			t.Errorf("%d - expect code %s, but got %s", i, test.Code, code)
+		}
+	}
+
+	data, _ := faults.EncodeJSON(faults.Bad(faults.Field("email", "is required")))
+	resp := &http.Response{
+		StatusCode: http.StatusBadRequest,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader(data)),
+	}
+	if e, ok := faults.AsBad(faultshttp.ReadOData(resp)); !ok || len(e.Violations) != 1 || e.Violations[0].Field != "email" {
+		t.Errorf("expect the body to be decoded without an envelope, but got %v", e)
+	}
+
+	rec := httptest.NewRecorder()
+	faultshttp.WriteProblem(rec, httptest.NewRequest("GET", "/", nil), faults.NotFound)
+	if err := faultshttp.ReadOData(rec.Result()); !faults.IsNotFound(err) {
+		t.Errorf("expect problem details to be read, but got %s", faults.CodeOf(err))
+	}
+}
+
+// TestODataDomain ensures the domains of reasons survive OData error
+// envelopes.
+func TestODataDomain(t *testing.T) {
+	rec := httptest.NewRecorder()
+	eh := &faultshttp.ErrorHandler{Encoder: faultshttp.ODataEncoder}
+	eh.WriteError(rec, httptest.NewRequest("POST", "/charges", nil), faults.WithReason(faults.FailedPrecondition(), "payments.example.com/NO_CARD"))
+
+	if !strings.Contains(rec.Body.String(), `"innererror":{"code":"NO_CARD","domain":"payments.example.com"}`) {
+		t.Errorf("expect domain in the inner error, but got %s", rec.Body)
+	}
+	err := faultshttp.ReadOData(rec.Result())
+	if reason, domain := faults.ReasonOf(err), faults.DomainOf(err); reason != "NO_CARD" || domain != "payments.example.com" {
+		t.Errorf("expect reason NO_CARD of payments.example.com, but got %q of %q", reason, domain)
+	}
+}
+
+// TestODataConformance ensures OData error envelopes follow the semantics of
+// the core.
+func TestODataConformance(t *testing.T) {
+	write := func(err error) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		eh := &faultshttp.ErrorHandler{Encoder: faultshttp.ODataEncoder}
+		eh.WriteError(rec, httptest.NewRequest("GET", "/", nil), err)
+		return rec
+	}
+
+	faultstest.ConformanceSuite{
+		RoundTrip: func(err error) error {
+			return faultshttp.ReadOData(write(err).Result())
+		},
+		Status: func(err error) int {
+			return write(err).Code
+		},
+		Render: func(err error) string {
+			return write(err).Body.String()
+		},
+		RetryInfo: true,
+		Details:   true,
+	}.Run(t)
+}
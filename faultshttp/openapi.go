@@ -0,0 +1,207 @@
+package faultshttp
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/deixis/faults"
+)
+
+// OpenAPIComponents holds the OpenAPI 3 components describing the problem
+// details documents rendered by this package, meant to be merged into the
+// `components` of an API specification.
+//
+// It is encoded to JSON as OpenAPI expects it, e.g. to generate the
+// specification of a service:
+//
+//	b, err := json.MarshalIndent(faultshttp.OpenAPI(), "", "  ")
+//
+// Operations then refer to the response of each category they can fail
+// with, e.g. `{"$ref": "#/components/responses/NotFound"}` for a 404.
+type OpenAPIComponents struct {
+	Schemas   map[string]*OpenAPISchema   `json:"schemas"`
+	Responses map[string]*OpenAPIResponse `json:"responses"`
+}
+
+// An OpenAPISchema is an OpenAPI 3 schema object. Only the keywords needed to
+// describe problem details documents are supported.
+type OpenAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Description          string                    `json:"description,omitempty"`
+	Enum                 []interface{}             `json:"enum,omitempty"`
+	Properties           map[string]*OpenAPISchema `json:"properties,omitempty"`
+	Required             []string                  `json:"required,omitempty"`
+	Items                *OpenAPISchema            `json:"items,omitempty"`
+	AdditionalProperties *OpenAPISchema            `json:"additionalProperties,omitempty"`
+	AllOf                []*OpenAPISchema          `json:"allOf,omitempty"`
+}
+
+// An OpenAPIResponse is an OpenAPI 3 response object.
+type OpenAPIResponse struct {
+	Description string                       `json:"description"`
+	Headers     map[string]*OpenAPIHeader    `json:"headers,omitempty"`
+	Content     map[string]*OpenAPIMediaType `json:"content"`
+}
+
+// An OpenAPIHeader is an OpenAPI 3 header object.
+type OpenAPIHeader struct {
+	Description string         `json:"description,omitempty"`
+	Schema      *OpenAPISchema `json:"schema"`
+}
+
+// An OpenAPIMediaType is an OpenAPI 3 media type object.
+type OpenAPIMediaType struct {
+	Schema *OpenAPISchema `json:"schema"`
+}
+
+// OpenAPI returns the OpenAPI 3 components describing the problem details
+// documents rendered for each category, including the ones registered with
+// faults.Register.
+//
+// The `Problem`, `Violation` and `Attachment` schemas describe the document
+// itself (see Problem). Each category then has a schema restricting its
+// status and its code, and a response with that schema, named after the
+// category in upper camel case, e.g. `NotFoundProblem` and `NotFound` for
+// NOT_FOUND. The responses of categories advising a retry delay or carrying
+// challenges also document the Retry-After and WWW-Authenticate headers.
+func OpenAPI() *OpenAPIComponents {
+	c := &OpenAPIComponents{
+		Schemas: map[string]*OpenAPISchema{
+			"Problem":    problemSchema(),
+			"Violation":  violationSchema(),
+			"Attachment": attachmentSchema(),
+			"Link":       linkSchema(),
+		},
+		Responses: map[string]*OpenAPIResponse{},
+	}
+
+	// Codes are allocated sequentially, so every category is visited,
+	// including the custom ones
+	for code := faults.CodeUnknown; ; code++ {
+		d, ok := faults.Describe(code)
+		if !ok {
+			break
+		}
+		name := openAPIName(d.Name)
+		c.Schemas[name+"Problem"] = &OpenAPISchema{
+			AllOf: []*OpenAPISchema{
+				{Ref: "#/components/schemas/Problem"},
+				{
+					Type: "object",
+					Properties: map[string]*OpenAPISchema{
+						"status": {Type: "integer", Enum: []interface{}{d.HTTPStatus}},
+						"code":   {Type: "string", Enum: []interface{}{d.Name}},
+					},
+				},
+			},
+		}
+
+		resp := &OpenAPIResponse{
+			Description: http.StatusText(d.HTTPStatus) + " (" + d.Name + ")",
+			Content: map[string]*OpenAPIMediaType{
+				ProblemContentType: {Schema: &OpenAPISchema{Ref: "#/components/schemas/" + name + "Problem"}},
+			},
+		}
+		switch code {
+		case faults.CodeUnavailable, faults.CodeResourceExhausted:
+			resp.Headers = map[string]*OpenAPIHeader{
+				"Retry-After": {
+					Description: "Number of seconds to wait before retrying",
+					Schema:      &OpenAPISchema{Type: "integer"},
+				},
+			}
+		case faults.CodeUnauthenticated:
+			resp.Headers = map[string]*OpenAPIHeader{
+				"WWW-Authenticate": {
+					Description: "Authentication challenges the client can satisfy",
+					Schema:      &OpenAPISchema{Type: "string"},
+				},
+			}
+		}
+		c.Responses[name] = resp
+	}
+	return c
+}
+
+func problemSchema() *OpenAPISchema {
+	return &OpenAPISchema{
+		Type:        "object",
+		Description: "Problem details document (RFC 9457)",
+		Properties: map[string]*OpenAPISchema{
+			"type":        {Type: "string", Format: "uri-reference", Description: "URI reference identifying the problem type"},
+			"title":       {Type: "string", Description: "Short summary of the problem type"},
+			"status":      {Type: "integer", Description: "HTTP status code of the response"},
+			"detail":      {Type: "string", Description: "Explanation specific to this occurrence of the problem"},
+			"instance":    {Type: "string", Format: "uri-reference", Description: "URI reference identifying this occurrence of the problem"},
+			"code":        {Type: "string", Description: "Category of the fault, e.g. NOT_FOUND"},
+			"reason":      {Type: "string", Description: "Machine-readable cause of the failure"},
+			"domain":      {Type: "string", Description: "Namespace of the reason, e.g. the service defining it"},
+			"metadata":    {Type: "object", AdditionalProperties: &OpenAPISchema{Type: "string"}, Description: "Details about the reason"},
+			"retryDelay":  {Type: "integer", Format: "int64", Description: "Delay advised before retrying, in milliseconds"},
+			"permanent":   {Type: "boolean", Description: "Whether the failure must not be retried, whatever its category"},
+			"violations":  {Type: "array", Items: &OpenAPISchema{Ref: "#/components/schemas/Violation"}},
+			"attachments": {Type: "array", Items: &OpenAPISchema{Ref: "#/components/schemas/Attachment"}},
+			"help":        {Type: "array", Items: &OpenAPISchema{Ref: "#/components/schemas/Link"}},
+			"truncated":   {Type: "boolean", Description: "Whether details of the failure were dropped to fit the limits of the document"},
+		},
+		Required: []string{"type", "title", "status", "code"},
+	}
+}
+
+func violationSchema() *OpenAPISchema {
+	return &OpenAPISchema{
+		Type:        "object",
+		Description: "Violation of a fault. Only the members relevant to its category are set",
+		Properties: map[string]*OpenAPISchema{
+			"field":       {Type: "string", Description: "Path of the invalid field of a bad request"},
+			"code":        {Type: "string", Description: "Machine-readable code of the invalid field, e.g. REQUIRED"},
+			"type":        {Type: "string", Description: "Type of a failed precondition"},
+			"subject":     {Type: "string", Description: "Subject of a failed precondition or of an exhausted quota"},
+			"resource":    {Type: "string", Description: "Resource on which a conflict occurred"},
+			"description": {Type: "string"},
+			"template":    {Type: "string", Description: "Template of the description of an invalid field, e.g. must be at most {max} characters"},
+			"params":      {Type: "object", AdditionalProperties: &OpenAPISchema{Type: "string"}, Description: "Values of the placeholders of the template, by name"},
+		},
+	}
+}
+
+func attachmentSchema() *OpenAPISchema {
+	return &OpenAPISchema{
+		Type:        "object",
+		Description: "Detail attached to a fault",
+		Properties: map[string]*OpenAPISchema{
+			"type":  {Type: "string"},
+			"value": {Description: "JSON encoding of the detail"},
+			"error": {Type: "string", Description: "Why the detail could not be encoded"},
+		},
+		Required: []string{"type"},
+	}
+}
+
+// openAPIName converts a category name to upper camel case, e.g. NotFound
+// for NOT_FOUND.
+func openAPIName(name string) string {
+	var b strings.Builder
+	for _, word := range strings.Split(name, "_") {
+		if word == "" {
+			continue
+		}
+		b.WriteString(word[:1])
+		b.WriteString(strings.ToLower(word[1:]))
+	}
+	return b.String()
+}
+
+func linkSchema() *OpenAPISchema {
+	return &OpenAPISchema{
+		Type:        "object",
+		Description: "Link to documentation about a fault",
+		Properties: map[string]*OpenAPISchema{
+			"description": {Type: "string"},
+			"url":         {Type: "string", Format: "uri"},
+		},
+		Required: []string{"url"},
+	}
+}
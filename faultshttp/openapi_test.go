@@ -0,0 +1,72 @@
+package faultshttp_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/deixis/faults/faultshttp"
+)
+
+// TestOpenAPI ensures the components describe every category, and only refer
+// to schemas they define.
+func TestOpenAPI(t *testing.T) {
+	c := faultshttp.OpenAPI()
+
+	table := []struct {
+		Response string
+		Status   int
+		Header   string
+	}{
+		{Response: "BadRequest", Status: 400},
+		{Response: "NotFound", Status: 404},
+		{Response: "Unauthenticated", Status: 401, Header: "WWW-Authenticate"},
+		{Response: "ResourceExhausted", Status: 429, Header: "Retry-After"},
+		{Response: "Unavailable", Status: 503, Header: "Retry-After"},
+		{Response: "Internal", Status: 500},
+	}
+	for i, test := range table {
+		resp, ok := c.Responses[test.Response]
+		if !ok {
+			t.Errorf("%d - expect response %s", i, test.Response)
+			continue
+		}
+		if test.Header != "" && resp.Headers[test.Header] == nil {
+			t.Errorf("%d - expect header %s", i, test.Header)
+		}
+		schema := c.Schemas[test.Response+"Problem"]
+		if schema == nil || len(schema.AllOf) != 2 {
+			t.Errorf("%d - expect schema %sProblem", i, test.Response)
+			continue
+		}
+		if status := schema.AllOf[1].Properties["status"].Enum; len(status) != 1 || status[0] != test.Status {
+			t.Errorf("%d - expect status %d, but got %v", i, test.Status, status)
+		}
+	}
+
+	b, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Schemas   map[string]json.RawMessage `json:"schemas"`
+		Responses map[string]json.RawMessage `json:"responses"`
+	}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		t.Fatal(err)
+	}
+	for _, ref := range refs(string(b)) {
+		if _, ok := doc.Schemas[strings.TrimPrefix(ref, "#/components/schemas/")]; !ok {
+			t.Errorf("expect schema %s to be defined", ref)
+		}
+	}
+}
+
+// refs returns the schema references found in `s`.
+func refs(s string) []string {
+	var refs []string
+	for _, part := range strings.Split(s, `"$ref":"`)[1:] {
+		refs = append(refs, part[:strings.IndexByte(part, '"')])
+	}
+	return refs
+}
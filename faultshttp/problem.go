@@ -0,0 +1,463 @@
+package faultshttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/deixis/faults"
+)
+
+// ProblemContentType is the media type of problem details documents.
+const ProblemContentType = "application/problem+json"
+
+// A Problem is a problem details document (RFC 9457) describing a fault.
+type Problem struct {
+	// Type is a URI reference that identifies the problem type. It is
+	// "about:blank" when the problem has no additional semantics beyond the
+	// HTTP status.
+	Type string `json:"type,omitempty"`
+	// Title is a short summary of the problem type.
+	Title string `json:"title,omitempty"`
+	// Status is the HTTP status code of the response.
+	Status int `json:"status,omitempty"`
+	// Detail is an explanation specific to this occurrence of the problem.
+	Detail string `json:"detail,omitempty"`
+	// Instance is a URI reference that identifies this occurrence of the
+	// problem.
+	Instance string `json:"instance,omitempty"`
+
+	// Code is the name of the category of the fault, e.g. "NOT_FOUND".
+	Code string `json:"code,omitempty"`
+	// Reason is the reason attached to the fault, if any (see
+	// faults.ErrorInfo).
+	Reason string `json:"reason,omitempty"`
+	// Domain is the domain of the reason, if it is scoped (see
+	// faults.ErrorInfo).
+	Domain string `json:"domain,omitempty"`
+	// Metadata is the metadata attached to the reason of the fault, if any.
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// RetryDelay is the delay advised before retrying, in milliseconds.
+	RetryDelay int64 `json:"retryDelay,omitempty"`
+	// Permanent reports whether the fault must not be retried, whatever its
+	// category (see faults.Permanent).
+	Permanent bool `json:"permanent,omitempty"`
+	// Violations lists the violations carried by the fault.
+	Violations []Violation `json:"violations,omitempty"`
+	// Attachments lists the details attached to the fault (see
+	// faults.Attachment).
+	Attachments []Attachment `json:"attachments,omitempty"`
+	// Help lists the links to documentation about the fault (see
+	// faults.Help).
+	Help []Link `json:"help,omitempty"`
+	// Truncated reports whether details of the fault were dropped to fit the
+	// limits of the document (see faults.Limits).
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// A Violation describes a single violation of a fault. Only the fields of
+// the corresponding Go type are set, e.g. Field and Description for a
+// faults.FieldViolation.
+type Violation struct {
+	Field       string `json:"field,omitempty" xml:"field,omitempty"`
+	Code        string `json:"code,omitempty" xml:"code,omitempty"`
+	Type        string `json:"type,omitempty" xml:"type,omitempty"`
+	Subject     string `json:"subject,omitempty" xml:"subject,omitempty"`
+	Resource    string `json:"resource,omitempty" xml:"resource,omitempty"`
+	Description string `json:"description,omitempty" xml:"description,omitempty"`
+	// Template is the template the description of a field violation was
+	// rendered from, and Params are its parameters, so clients can render it
+	// again, e.g. in their language (see faults.ExpandTemplate). SOAP faults
+	// don't carry them.
+	Template string            `json:"template,omitempty" xml:"-"`
+	Params   map[string]string `json:"params,omitempty" xml:"-"`
+}
+
+// A FieldNotation is the notation of the fields of the violations of the
+// documents rendered by this package (see SetFieldNotation).
+type FieldNotation int32
+
+const (
+	// DottedFields renders fields in the notation of faults.FieldViolation,
+	// which is the notation of protobuf field paths, e.g. "items[3].sku", like
+	// gRPC clients expect. It is the default.
+	DottedFields FieldNotation = iota
+	// PointerFields renders fields as JSON pointers (RFC 6901), e.g.
+	// "/items/3/sku", like many REST clients expect.
+	PointerFields
+)
+
+// fieldNotation is the notation of the fields of the rendered documents.
+var fieldNotation atomic.Int32
+
+// SetFieldNotation sets the notation of the fields of the violations of the
+// documents rendered by this package, process-wide, e.g. for REST clients
+// that locate invalid fields with JSON pointers:
+//
+//	func main() {
+//		faultshttp.SetFieldNotation(faultshttp.PointerFields)
+//		// ...
+//	}
+//
+// Documents are decoded whatever the notation of their fields (see
+// faults.ParseFieldPath), and JSON:API documents always locate fields with
+// pointers.
+func SetFieldNotation(n FieldNotation) {
+	fieldNotation.Store(int32(n))
+}
+
+// renderField returns `field` in the notation set with SetFieldNotation.
+func renderField(field string) string {
+	if FieldNotation(fieldNotation.Load()) == PointerFields {
+		return faults.FieldPath(field).Pointer()
+	}
+	return field
+}
+
+// A Link points to documentation about a fault.
+type Link struct {
+	Description string `json:"description,omitempty"`
+	URL         string `json:"url"`
+}
+
+// An Attachment is a detail attached to a fault. Value is the JSON encoding
+// of the detail. When the detail can't be marshalled, e.g. because it
+// contains a cycle or a channel, Value is empty and Error describes the
+// failure instead, so a single bad attachment doesn't prevent the fault from
+// being rendered.
+type Attachment struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value,omitempty"`
+	Error string          `json:"error,omitempty"`
+}
+
+// NewProblem returns the problem details document describing `err`, which
+// occurred while serving `r`.
+//
+// The message, the attachments and the help links of `err` are only rendered
+// for client faults (see faults.IsClientFault). Server faults are described
+// by their status alone, since their details may leak implementation
+// details, such as the query that failed.
+//
+// Faults exceeding the limits on the number of violations or the length of
+// descriptions are truncated first (see faults.Truncate), in which case
+// their attachments and their help links are dropped.
+func NewProblem(r *http.Request, err error) *Problem {
+	err = faults.Truncate(err)
+	status := faults.HTTPStatus(err)
+	p := &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Code:   faults.CodeOf(err).String(),
+	}
+	if r != nil && r.URL != nil {
+		p.Instance = r.URL.Path
+	}
+	if faults.IsClientFault(err) {
+		p.Detail = err.Error()
+	}
+	if e, ok := faults.AsErrorInfo(err); ok {
+		p.Reason = e.Reason
+		p.Domain = e.Domain
+		p.Metadata = e.Metadata
+	}
+	if advice := faults.RetryAdvice(err); advice.Delay > 0 {
+		p.RetryDelay = advice.Delay.Milliseconds()
+	}
+	p.Permanent = faults.IsPermanent(err)
+	p.Truncated = faults.IsTruncated(err)
+	p.Violations = violationsOf(err)
+	if !faults.IsClientFault(err) {
+		return p
+	}
+	for _, a := range faults.AttachmentsOf(err) {
+		p.Attachments = append(p.Attachments, marshalAttachment(a))
+	}
+	for _, l := range faults.HelpOf(err) {
+		p.Help = append(p.Help, Link{Description: l.Description, URL: l.URL})
+	}
+	return p
+}
+
+// marshalAttachment encodes `a`, or returns a marker describing why it can't
+// be encoded.
+func marshalAttachment(a *faults.Attachment) (attachment Attachment) {
+	attachment.Type = a.Type
+	defer func() {
+		// A panicking MarshalJSON method must not take the response down
+		if r := recover(); r != nil {
+			attachment.Value = nil
+			attachment.Error = fmt.Sprintf("faultshttp: attachment can't be marshalled: %v", r)
+		}
+	}()
+
+	b, err := json.Marshal(a.Value)
+	if err != nil {
+		attachment.Error = "faultshttp: attachment can't be marshalled: " + err.Error()
+		return attachment
+	}
+	attachment.Value = b
+	return attachment
+}
+
+// WriteProblem writes the problem details document describing `err` to `w`,
+// with the HTTP status of its category (see faults.HTTPStatus).
+//
+// Example:
+//
+//	func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+//		user, err := h.users.Get(r.Context(), r.PathValue("id"))
+//		if err != nil {
+//			faultshttp.WriteProblem(w, r, err)
+//			return
+//		}
+//		// ...
+//	}
+//
+// The rendering is reported to the trace sinks as EventRender (see
+// faults.Trace).
+func WriteProblem(w http.ResponseWriter, r *http.Request, err error) {
+	p := NewProblem(r, err)
+	if r != nil {
+		faults.Trace(r.Context(), faults.EventRender, err, "faultshttp: problem")
+	}
+
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	faults.SetRetryAfter(w.Header(), err)
+	faults.SetWWWAuthenticate(w.Header(), err)
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// ProblemEncoder encodes faults as problem details documents (see
+// NewProblem). It is the default encoder of ErrorHandler.
+var ProblemEncoder Encoder = problemEncoder{}
+
+type problemEncoder struct{}
+
+func (problemEncoder) ContentType() string {
+	return ProblemContentType
+}
+
+func (problemEncoder) Encode(w io.Writer, r *http.Request, err error) error {
+	return json.NewEncoder(w).Encode(NewProblem(r, err))
+}
+
+// violationsOf returns the violations carried by the fault of `err`.
+func violationsOf(err error) []Violation {
+	var violations []Violation
+	for _, v := range faults.ViolationsOf(err) {
+		switch v := v.(type) {
+		case *faults.FieldViolation:
+			violations = append(violations, Violation{
+				Field:       renderField(v.Field),
+				Code:        v.Code,
+				Description: v.Description,
+				Template:    v.Template,
+				Params:      v.Params,
+			})
+		case *faults.PreconditionViolation:
+			violations = append(violations, Violation{
+				Type:        v.Type,
+				Subject:     v.Subject,
+				Description: v.Description,
+			})
+		case *faults.ConflictViolation:
+			violations = append(violations, Violation{
+				Resource:    v.Resource,
+				Description: v.Description,
+			})
+		case *faults.QuotaViolation:
+			violations = append(violations, Violation{
+				Subject:     v.Subject,
+				Description: v.Description,
+			})
+		}
+	}
+	return violations
+}
+
+// maxProblemSize is the maximum number of bytes read from the body of a
+// response to decode a problem details document, the same as
+// faults.FromHTTPResponse.
+const maxProblemSize = faults.MaxBodySize
+
+// ReadProblem reconstructs the fault described by `resp`, so HTTP clients can
+// inspect failures with the same functions as servers, e.g. faults.IsNotFound
+// or faults.RetryAdvice. It returns nil when the status of `resp` is not an
+// error (below 400).
+//
+// Problem details documents are converted with Problem.Err, completed with
+// the retry delay of the Retry-After header when the document doesn't advise
+// one, and with the challenges of the WWW-Authenticate headers. Other
+// responses are converted with faults.FromHTTPResponse. The body is consumed,
+// but not closed.
+//
+// Example:
+//
+//	resp, err := client.Do(req)
+//	if err != nil {
+//		return err
+//	}
+//	defer resp.Body.Close()
+//	if err := faultshttp.ReadProblem(resp); err != nil {
+//		return err
+//	}
+func ReadProblem(resp *http.Response) error {
+	if resp == nil || resp.StatusCode < 400 {
+		return nil
+	}
+	if !isProblem(resp.Header.Get("Content-Type")) || resp.Body == nil {
+		return faults.FromHTTPResponse(resp)
+	}
+
+	var p Problem
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxProblemSize)).Decode(&p); err != nil {
+		p = Problem{}
+	}
+	if p.Status == 0 {
+		p.Status = resp.StatusCode
+	}
+	if p.RetryDelay == 0 {
+		p.RetryDelay = faults.ParseRetryAfter(resp.Header.Get("Retry-After")).Milliseconds()
+	}
+	err := p.Err()
+	if e, ok := faults.AsUnauthenticated(err); ok {
+		e.Challenges = faults.ParseWWWAuthenticate(resp.Header)
+	}
+	return err
+}
+
+// isProblem reports whether `contentType` is the media type of problem
+// details documents.
+func isProblem(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == ProblemContentType
+}
+
+// Err converts `p` back to the fault it describes. The category is read from
+// the Code extension member, or derived from the status when it is missing
+// (see faults.CodeOfHTTPStatus). The detail of the problem becomes the
+// message of the error wrapped by the fault.
+//
+// Attachments are restored with their JSON encoding (a json.RawMessage) as
+// value, while the markers of attachments that could not be marshalled are
+// dropped.
+func (p *Problem) Err() error {
+	code, err := faults.ParseCode(p.Code)
+	if err != nil {
+		code = faults.CodeOfHTTPStatus(p.Status)
+	}
+
+	message := p.Detail
+	if message == "" {
+		message = p.Title
+	}
+	if message == "" {
+		message = http.StatusText(p.Status)
+	}
+	parent := errors.New(message)
+
+	var fault error
+	if build, ok := builders[code]; ok {
+		fault = build(parent, p)
+	} else if d, ok := faults.Describe(code); ok && d.New != nil {
+		fault = d.New(parent)
+	} else {
+		fault = parent
+	}
+
+	// Attachments are restored from the innermost, so they are found in the
+	// same order as on the server
+	for i := len(p.Attachments) - 1; i >= 0; i-- {
+		if a := p.Attachments[i]; a.Error == "" {
+			fault = faults.Attach(fault, a.Type, a.Value)
+		}
+	}
+	if len(p.Help) > 0 {
+		links := make([]faults.Link, len(p.Help))
+		for i, l := range p.Help {
+			links[i] = faults.Link{Description: l.Description, URL: l.URL}
+		}
+		fault = faults.AttachHelp(fault, links...)
+	}
+	if p.Reason != "" {
+		fault = faults.WithScopedErrorInfo(fault, p.Domain, p.Reason, p.Metadata)
+	}
+	if p.Permanent {
+		fault = faults.Permanent(fault)
+	}
+	if p.Truncated {
+		fault = faults.Truncated(fault)
+	}
+	return fault
+}
+
+var builders = map[faults.Code]func(parent error, p *Problem) error{
+	faults.CodeUnavailable: func(parent error, p *Problem) error {
+		return faults.WithUnavailable(parent, p.retryDelay())
+	},
+	faults.CodeBad: func(parent error, p *Problem) error {
+		var violations []*faults.FieldViolation
+		for _, v := range p.Violations {
+			violations = append(violations, &faults.FieldViolation{
+				Field:       faults.ParseFieldPath(v.Field).String(),
+				Code:        v.Code,
+				Description: v.Description,
+				Template:    v.Template,
+				Params:      v.Params,
+			})
+		}
+		return faults.WithBad(parent, violations...)
+	},
+	faults.CodeFailedPrecondition: func(parent error, p *Problem) error {
+		var violations []*faults.PreconditionViolation
+		for _, v := range p.Violations {
+			violations = append(violations, &faults.PreconditionViolation{
+				Type:        v.Type,
+				Subject:     v.Subject,
+				Description: v.Description,
+			})
+		}
+		return faults.WithFailedPrecondition(parent, violations...)
+	},
+	faults.CodeAborted: func(parent error, p *Problem) error {
+		var violations []*faults.ConflictViolation
+		for _, v := range p.Violations {
+			violations = append(violations, &faults.ConflictViolation{
+				Resource:    v.Resource,
+				Description: v.Description,
+			})
+		}
+		return faults.WithAborted(parent, violations...)
+	},
+	faults.CodeResourceExhausted: func(parent error, p *Problem) error {
+		var violations []*faults.QuotaViolation
+		for _, v := range p.Violations {
+			violations = append(violations, &faults.QuotaViolation{
+				Subject:     v.Subject,
+				Description: v.Description,
+			})
+		}
+		err := faults.WithResourceExhausted(parent, violations...)
+		if e, ok := faults.AsResourceExhausted(err); ok {
+			e.RetryInfo.RetryDelay = p.retryDelay()
+		}
+		return err
+	},
+}
+
+// retryDelay returns the retry delay advised by `p`.
+func (p *Problem) retryDelay() time.Duration {
+	if p.RetryDelay <= 0 {
+		return 0
+	}
+	return time.Duration(p.RetryDelay) * time.Millisecond
+}
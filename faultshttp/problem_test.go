@@ -0,0 +1,560 @@
+package faultshttp_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultshttp"
+	"github.com/deixis/faults/faultstest"
+)
+
+// TestWriteProblem ensures faults are rendered as problem details documents.
+func TestWriteProblem(t *testing.T) {
+	quota := faults.ResourceExhausted(&faults.QuotaViolation{Subject: "user:42", Description: "Daily limit"})
+	quota.(*faults.QuotaFailure).RetryInfo.RetryDelay = 2 * time.Second
+
+	table := []struct {
+		Error   error
+		Problem faultshttp.Problem
+	}{
+		{
+			Error: faults.Bad(&faults.FieldViolation{Field: "email", Code: faults.ViolationRequired, Description: "Field required"}),
+			Problem: faultshttp.Problem{
+				Type:     "about:blank",
+				Title:    "Bad Request",
+				Status:   400,
+				Detail:   "Field required",
+				Instance: "/users",
+				Code:     "BAD_REQUEST",
+				Violations: []faultshttp.Violation{
+					{Field: "email", Code: "REQUIRED", Description: "Field required"},
+				},
+			},
+		},
+		{
+			Error: faults.WithErrorInfo(
+				faults.FailedPrecondition(&faults.PreconditionViolation{
+					Type:        "BALANCE",
+					Subject:     "account:42",
+					Description: "Insufficient funds",
+				}),
+				"INSUFFICIENT_FUNDS",
+				map[string]string{"missing": "12.50"},
+			),
+			Problem: faultshttp.Problem{
+				Type:     "about:blank",
+				Title:    "Bad Request",
+				Status:   400,
+				Detail:   "Insufficient funds",
+				Instance: "/users",
+				Code:     "FAILED_PRECONDITION",
+				Reason:   "INSUFFICIENT_FUNDS",
+				Metadata: map[string]string{"missing": "12.50"},
+				Violations: []faultshttp.Violation{
+					{Type: "BALANCE", Subject: "account:42", Description: "Insufficient funds"},
+				},
+			},
+		},
+		{
+			Error: quota,
+			Problem: faultshttp.Problem{
+				Type:       "about:blank",
+				Title:      "Too Many Requests",
+				Status:     429,
+				Detail:     quota.Error(),
+				Instance:   "/users",
+				Code:       "RESOURCE_EXHAUSTED",
+				RetryDelay: 2000,
+				Violations: []faultshttp.Violation{
+					{Subject: "user:42", Description: "Daily limit"},
+				},
+			},
+		},
+		{
+			Error: faults.WithUnavailable(errors.New("dial tcp 10.0.0.1:5432: connection refused"), time.Second),
+			Problem: faultshttp.Problem{
+				Type:       "about:blank",
+				Title:      "Service Unavailable",
+				Status:     503,
+				Instance:   "/users",
+				Code:       "UNAVAILABLE",
+				RetryDelay: 1000,
+			},
+		},
+		{
+			Error: errors.New("pq: relation \"users\" does not exist"),
+			Problem: faultshttp.Problem{
+				Type:     "about:blank",
+				Title:    "Internal Server Error",
+				Status:   500,
+				Instance: "/users",
+				Code:     "UNKNOWN",
+			},
+		},
+	}
+
+	for i, test := range table {
+		rec := httptest.NewRecorder()
+		faultshttp.WriteProblem(rec, httptest.NewRequest("POST", "/users?dry_run=1", nil), test.Error)
+
+		if rec.Code != test.Problem.Status {
+			t.Errorf("%d - expect status %d, but got %d", i, test.Problem.Status, rec.Code)
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != faultshttp.ProblemContentType {
+			t.Errorf("%d - expect content type %s, but got %s", i, faultshttp.ProblemContentType, ct)
+		}
+
+		var p faultshttp.Problem
+		if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+			t.Fatalf("%d - expect problem to be decoded, but got %s", i, err)
+		}
+		if !reflect.DeepEqual(test.Problem, p) {
+			t.Errorf("%d - expect problem %+v, but got %+v", i, test.Problem, p)
+		}
+	}
+}
+
+// TestWriteProblemTrace ensures renderings are reported to the trace sink of
+// the request.
+func TestWriteProblemTrace(t *testing.T) {
+	var events []faults.Event
+	sink := faults.TraceFunc(func(ev faults.Event) {
+		events = append(events, ev)
+	})
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r = r.WithContext(faults.ContextWithTrace(r.Context(), sink))
+	faultshttp.WriteProblem(httptest.NewRecorder(), r, faults.NotFound)
+
+	if len(events) != 1 || events[0].Kind != faults.EventRender || events[0].Code != faults.CodeNotFound {
+		t.Errorf("expect render event, but got %+v", events)
+	}
+}
+
+// TestReadProblem ensures faults survive a problem details document.
+func TestReadProblem(t *testing.T) {
+	quota := faults.ResourceExhausted(&faults.QuotaViolation{Subject: "user:42", Description: "Daily limit"})
+	quota.(*faults.QuotaFailure).RetryInfo.RetryDelay = 2 * time.Second
+
+	table := []error{
+		faults.Bad(&faults.FieldViolation{Field: "email", Description: "Field required"}),
+		faults.FailedPrecondition(&faults.PreconditionViolation{Type: "TOS", Subject: "user:42", Description: "Terms not accepted"}),
+		faults.Aborted(&faults.ConflictViolation{Resource: "order:42", Description: "Version mismatch"}),
+		quota,
+		faults.WithErrorInfo(faults.NotFound, "USER_NOT_FOUND", map[string]string{"id": "42"}),
+		faults.PermissionDenied,
+		faults.Unauthenticated,
+		faults.Unavailable(time.Second),
+		faults.Unimplemented,
+		faults.Internal,
+		faults.DeadlineExceeded,
+	}
+
+	for i, fault := range table {
+		rec := httptest.NewRecorder()
+		faultshttp.WriteProblem(rec, httptest.NewRequest("GET", "/", nil), fault)
+
+		err := faultshttp.ReadProblem(rec.Result())
+		if code := faults.CodeOf(err); code != faults.CodeOf(fault) {
+			t.Errorf("%d - expect code %s, but got %s", i, faults.CodeOf(fault), code)
+		}
+		if reason := faults.ReasonOf(err); reason != faults.ReasonOf(fault) {
+			t.Errorf("%d - expect reason %q, but got %q", i, faults.ReasonOf(fault), reason)
+		}
+		if advice := faults.RetryAdvice(err); advice != faults.RetryAdvice(fault) {
+			t.Errorf("%d - expect advice %+v, but got %+v", i, faults.RetryAdvice(fault), advice)
+		}
+
+		expect := faultshttp.NewProblem(nil, fault)
+		if got := faultshttp.NewProblem(nil, err); !reflect.DeepEqual(expect.Violations, got.Violations) {
+			t.Errorf("%d - expect violations %+v, but got %+v", i, expect.Violations, got.Violations)
+		}
+	}
+}
+
+// cyclic is a detail that refers to itself.
+type cyclic struct {
+	Next *cyclic
+}
+
+// brokenMarshaler is a detail whose MarshalJSON method fails.
+type brokenMarshaler struct {
+	Panic bool
+}
+
+func (m brokenMarshaler) MarshalJSON() ([]byte, error) {
+	if m.Panic {
+		panic("boom")
+	}
+	return nil, errors.New("boom")
+}
+
+// TestWriteProblemAttachments ensures attachments that can't be marshalled
+// are replaced with a marker, without affecting the rest of the document.
+func TestWriteProblemAttachments(t *testing.T) {
+	loop := &cyclic{}
+	loop.Next = loop
+
+	table := []struct {
+		Value any
+		JSON  string
+		Error bool
+	}{
+		{Value: map[string]int{"version": 3}, JSON: `{"version":3}`},
+		{Value: "reload", JSON: `"reload"`},
+		{Value: nil, JSON: `null`},
+		{Value: make(chan int), Error: true},
+		{Value: func() {}, Error: true},
+		{Value: loop, Error: true},
+		{Value: brokenMarshaler{}, Error: true},
+		{Value: brokenMarshaler{Panic: true}, Error: true},
+	}
+
+	for i, test := range table {
+		fault := faults.Attach(faults.WithNotFound(errors.New("no such order")), "order", test.Value)
+		rec := httptest.NewRecorder()
+		faultshttp.WriteProblem(rec, httptest.NewRequest("GET", "/orders/42", nil), fault)
+
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("%d - expect status %d, but got %d", i, http.StatusNotFound, rec.Code)
+		}
+		var p faultshttp.Problem
+		if err := json.NewDecoder(rec.Body).Decode(&p); err != nil {
+			t.Fatalf("%d - expect problem document, but got %s", i, err)
+		}
+		if p.Code != "NOT_FOUND" || len(p.Attachments) != 1 {
+			t.Fatalf("%d - expect NOT_FOUND with 1 attachment, but got %s with %d", i, p.Code, len(p.Attachments))
+		}
+
+		a := p.Attachments[0]
+		if a.Type != "order" {
+			t.Errorf("%d - expect attachment type order, but got %q", i, a.Type)
+		}
+		if test.Error {
+			if a.Error == "" || a.Value != nil {
+				t.Errorf("%d - expect marker, but got %+v", i, a)
+			}
+			continue
+		}
+		if a.Error != "" || string(a.Value) != test.JSON {
+			t.Errorf("%d - expect value %s, but got %+v", i, test.JSON, a)
+		}
+	}
+}
+
+// TestWriteProblemServerFault ensures the attachments and the help links of
+// server faults are not rendered.
+func TestWriteProblemServerFault(t *testing.T) {
+	fault := faults.Attach(faults.Internal, "query", "SELECT * FROM secrets")
+	fault = faults.AttachHelp(fault, faults.Link{Description: "Runbook", URL: "https://example.com/runbooks/db"})
+	rec := httptest.NewRecorder()
+	faultshttp.WriteProblem(rec, httptest.NewRequest("GET", "/orders/42", nil), fault)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("expect status %d, but got %d", http.StatusInternalServerError, rec.Code)
+	}
+	if body := rec.Body.String(); strings.Contains(body, "attachments") || strings.Contains(body, "secrets") || strings.Contains(body, "help") {
+		t.Errorf("expect no attachments and no help, but got %s", body)
+	}
+}
+
+// TestReadProblemAttachments ensures attachments are restored in order, and
+// markers are dropped.
+func TestReadProblemAttachments(t *testing.T) {
+	fault := faults.Attach(faults.Aborted(), "order", map[string]int{"version": 3})
+	fault = faults.Attach(fault, "broken", make(chan int))
+	fault = faults.Attach(fault, "hint", "reload")
+
+	rec := httptest.NewRecorder()
+	faultshttp.WriteProblem(rec, httptest.NewRequest("PUT", "/orders/42", nil), fault)
+	err := faultshttp.ReadProblem(rec.Result())
+
+	attachments := faults.AttachmentsOf(err)
+	if len(attachments) != 2 {
+		t.Fatalf("expect 2 attachments, but got %d", len(attachments))
+	}
+	for i, expect := range []struct{ Type, JSON string }{
+		{Type: "hint", JSON: `"reload"`},
+		{Type: "order", JSON: `{"version":3}`},
+	} {
+		value, _ := attachments[i].Value.(json.RawMessage)
+		if attachments[i].Type != expect.Type || string(value) != expect.JSON {
+			t.Errorf("%d - expect %s %s, but got %s %s", i, expect.Type, expect.JSON, attachments[i].Type, value)
+		}
+	}
+	if !faults.IsAborted(err) {
+		t.Errorf("expect code %s, but got %s", faults.CodeAborted, faults.CodeOf(err))
+	}
+}
+
+// TestRetryAfter ensures the retry delay is rendered as a Retry-After
+// header, which is used when the document doesn't advise a delay.
+func TestRetryAfter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	faultshttp.WriteProblem(rec, httptest.NewRequest("GET", "/", nil), faults.Unavailable(2500*time.Millisecond))
+	if ra := rec.Header().Get("Retry-After"); ra != "3" {
+		t.Errorf("expect Retry-After 3, but got %q", ra)
+	}
+	if delay := faults.RetryAdvice(faultshttp.ReadProblem(rec.Result())).Delay; delay != 2500*time.Millisecond {
+		t.Errorf("expect document delay to win, but got %s", delay)
+	}
+
+	table := []struct {
+		Status int
+		Code   faults.Code
+	}{
+		{Status: http.StatusServiceUnavailable, Code: faults.CodeUnavailable},
+		{Status: http.StatusTooManyRequests, Code: faults.CodeResourceExhausted},
+	}
+	for i, test := range table {
+		resp := &http.Response{
+			StatusCode: test.Status,
+			Header: http.Header{
+				"Content-Type": []string{faultshttp.ProblemContentType},
+				"Retry-After":  []string{"5"},
+			},
+			Body: io.NopCloser(strings.NewReader(`{"status":` + strconv.Itoa(test.Status) + `}`)),
+		}
+		err := faultshttp.ReadProblem(resp)
+		if code := faults.CodeOf(err); code != test.Code {
+			t.Errorf("%d - expect code %s, but got %s", i, test.Code, code)
+		}
+		if delay := faults.RetryAdvice(err).Delay; delay != 5*time.Second {
+			t.Errorf("%d - expect retry delay 5s, but got %s", i, delay)
+		}
+	}
+}
+
+// TestRetryAfterDebug ensures retry delays survive a round trip in debug mode,
+// where faults carry a DebugInfo.
+func TestRetryAfterDebug(t *testing.T) {
+	faults.SetDebug(true)
+	defer faults.SetDebug(false)
+
+	rec := httptest.NewRecorder()
+	fault := faults.Build(faults.CodeResourceExhausted).Quota("user:42", "Daily limit reached").Retryable(30 * time.Second).Err()
+	faultshttp.WriteProblem(rec, httptest.NewRequest("GET", "/", nil), fault)
+	err := faultshttp.ReadProblem(rec.Result())
+	if !faults.IsResourceExhausted(err) {
+		t.Fatalf("expect resource exhausted, but got %v", err)
+	}
+	if delay := faults.RetryAdvice(err).Delay; delay != 30*time.Second {
+		t.Errorf("expect retry delay 30s, but got %s", delay)
+	}
+}
+
+// TestPermanent ensures permanent faults are rendered without a retry hint,
+// and are not retried by clients.
+func TestPermanent(t *testing.T) {
+	rec := httptest.NewRecorder()
+	fault := faults.Permanent(faults.Unavailable(time.Second))
+	faultshttp.WriteProblem(rec, httptest.NewRequest("GET", "/", nil), fault)
+
+	if ra := rec.Header().Get("Retry-After"); ra != "" {
+		t.Errorf("expect no Retry-After, but got %q", ra)
+	}
+	err := faultshttp.ReadProblem(rec.Result())
+	if !faults.IsUnavailable(err) {
+		t.Errorf("expect unavailable, but got %s", faults.CodeOf(err))
+	}
+	if !faults.IsPermanent(err) {
+		t.Errorf("expect permanent marker to be read back")
+	}
+}
+
+// TestHelp ensures links to documentation are rendered, and read back.
+func TestHelp(t *testing.T) {
+	links := []faults.Link{{Description: "Enable the API", URL: "https://example.com/docs/enable"}}
+	rec := httptest.NewRecorder()
+	faultshttp.WriteProblem(rec, httptest.NewRequest("GET", "/", nil), faults.AttachHelp(faults.PermissionDenied, links...))
+
+	if !strings.Contains(rec.Body.String(), `"help":[{"description":"Enable the API","url":"https://example.com/docs/enable"}]`) {
+		t.Errorf("expect help member, but got %s", rec.Body)
+	}
+	err := faultshttp.ReadProblem(rec.Result())
+	if !faults.IsPermissionDenied(err) {
+		t.Errorf("expect permission denied, but got %s", faults.CodeOf(err))
+	}
+	if got := faults.HelpOf(err); !reflect.DeepEqual(got, links) {
+		t.Errorf("expect links %v, but got %v", links, got)
+	}
+}
+
+// TestFieldNotation ensures fields are rendered in the notation set with
+// SetFieldNotation, and are read back whatever their notation.
+func TestFieldNotation(t *testing.T) {
+	defer faultshttp.SetFieldNotation(faultshttp.DottedFields)
+
+	table := []struct {
+		Notation faultshttp.FieldNotation
+		Expect   string
+	}{
+		{Notation: faultshttp.DottedFields, Expect: `"field":"items[3].sku"`},
+		{Notation: faultshttp.PointerFields, Expect: `"field":"/items/3/sku"`},
+	}
+
+	for i, test := range table {
+		faultshttp.SetFieldNotation(test.Notation)
+		field := faults.FieldPath("items").Index(3).Child("sku")
+		rec := httptest.NewRecorder()
+		faultshttp.WriteProblem(rec, httptest.NewRequest("POST", "/", nil), faults.WithBad(nil, faults.Field(field.String(), "Unknown SKU")))
+
+		if !strings.Contains(rec.Body.String(), test.Expect) {
+			t.Errorf("%d - expect %s, but got %s", i, test.Expect, rec.Body)
+		}
+		e, ok := faults.AsBad(faultshttp.ReadProblem(rec.Result()))
+		if !ok || len(e.Violations) != 1 {
+			t.Fatalf("%d - expect a bad request with a violation, but got %v", i, e)
+		}
+		if got := e.Violations[0].Field; got != field.String() {
+			t.Errorf("%d - expect field %q, but got %q", i, field, got)
+		}
+	}
+}
+
+// TestTemplate ensures the templates of violations and their parameters are
+// rendered, and are read back.
+func TestTemplate(t *testing.T) {
+	fault := faults.Bad(faults.FieldTemplate("name", "must be at most {max} characters", map[string]string{"max": "50"}))
+	rec := httptest.NewRecorder()
+	faultshttp.WriteProblem(rec, httptest.NewRequest("POST", "/", nil), fault)
+
+	expect := `"template":"must be at most {max} characters","params":{"max":"50"}`
+	if !strings.Contains(rec.Body.String(), expect) {
+		t.Errorf("expect %s, but got %s", expect, rec.Body)
+	}
+	e, ok := faults.AsBad(faultshttp.ReadProblem(rec.Result()))
+	if want, _ := faults.AsBad(fault); !ok || !reflect.DeepEqual(e.Violations, want.Violations) {
+		t.Errorf("expect violations %v, but got %v", want.Violations, e)
+	}
+}
+
+// TestDomain ensures the domains of reasons are carried by the documents
+// whose readers restore reasons.
+func TestDomain(t *testing.T) {
+	table := []struct {
+		Encoder faultshttp.Encoder
+		Read    func(*http.Response) error
+	}{
+		{Encoder: faultshttp.ProblemEncoder, Read: faultshttp.ReadProblem},
+		{Encoder: faultshttp.SOAPEncoder, Read: faultshttp.ReadSOAP},
+		{Encoder: faultshttp.JSONAPIEncoder, Read: faultshttp.ReadJSONAPI},
+	}
+
+	fault := faults.WithReason(faults.NotFound, "accounts.example.com/USER_NOT_FOUND")
+	for i, test := range table {
+		rec := httptest.NewRecorder()
+		eh := &faultshttp.ErrorHandler{Encoder: test.Encoder}
+		eh.WriteError(rec, httptest.NewRequest("GET", "/users/42", nil), fault)
+
+		err := test.Read(rec.Result())
+		if faults.ReasonOf(err) != "USER_NOT_FOUND" || faults.DomainOf(err) != "accounts.example.com" {
+			t.Errorf("%d - expect reason accounts.example.com/USER_NOT_FOUND, but got %q in %q", i, faults.ReasonOf(err), faults.DomainOf(err))
+		}
+	}
+}
+
+// TestTruncated ensures faults exceeding the limits are rendered truncated,
+// and are read back as such.
+func TestTruncated(t *testing.T) {
+	violations := make([]*faults.FieldViolation, 10000)
+	for i := range violations {
+		violations[i] = &faults.FieldViolation{Field: "rows[" + strconv.Itoa(i) + "].email", Description: "Must be a valid email address"}
+	}
+	rec := httptest.NewRecorder()
+	faultshttp.WriteProblem(rec, httptest.NewRequest("POST", "/imports", nil), faults.Bad(violations...))
+
+	var p faultshttp.Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+		t.Fatal(err)
+	}
+	if !p.Truncated || len(p.Violations) != faults.DefaultLimits.MaxViolations {
+		t.Errorf("expect %d violations and a truncation marker, but got %d (%t)", faults.DefaultLimits.MaxViolations, len(p.Violations), p.Truncated)
+	}
+	err := faultshttp.ReadProblem(rec.Result())
+	if !faults.IsBad(err) || !faults.IsTruncated(err) {
+		t.Errorf("expect truncated bad request, but got %v", err)
+	}
+}
+
+// TestWWWAuthenticate ensures challenges are rendered as WWW-Authenticate
+// headers, and read back.
+func TestWWWAuthenticate(t *testing.T) {
+	challenge := faults.Challenge{Scheme: "Bearer", Realm: "api", Params: map[string]string{"error": "invalid_token"}}
+	rec := httptest.NewRecorder()
+	fault := faults.WithReason(faults.WithUnauthenticated(nil, challenge), "TOKEN_EXPIRED")
+	faultshttp.WriteProblem(rec, httptest.NewRequest("GET", "/", nil), fault)
+
+	if h := rec.Header().Get("WWW-Authenticate"); h != `Bearer realm="api", error="invalid_token"` {
+		t.Errorf("expect Bearer challenge, but got %q", h)
+	}
+	err := faultshttp.ReadProblem(rec.Result())
+	if got := faults.ChallengesOf(err); len(got) != 1 || !reflect.DeepEqual(got[0], challenge) {
+		t.Errorf("expect challenge %+v, but got %+v", challenge, got)
+	}
+	if faults.ReasonOf(err) != "TOKEN_EXPIRED" {
+		t.Errorf("expect reason TOKEN_EXPIRED, but got %q", faults.ReasonOf(err))
+	}
+}
+
+// TestReadProblemFallback ensures responses that are not problem details
+// documents are classified from their status.
+func TestReadProblemFallback(t *testing.T) {
+	table := []struct {
+		Status      int
+		ContentType string
+		Body        string
+		Code        faults.Code
+	}{
+		{Status: 200, Code: faults.CodeOK},
+		{Status: 404, ContentType: "text/plain", Body: "no such user", Code: faults.CodeNotFound},
+		{Status: 503, ContentType: "text/html", Body: "<h1>Down</h1>", Code: faults.CodeUnavailable},
+		{Status: 400, ContentType: faultshttp.ProblemContentType, Body: "{", Code: faults.CodeBad},
+		{Status: 409, ContentType: faultshttp.ProblemContentType, Body: `{"title":"Conflict"}`, Code: faults.CodeAborted},
+		{Status: 400, ContentType: faultshttp.ProblemContentType, Body: `{"code":"NOT_A_CODE"}`, Code: faults.CodeBad},
+	}
+
+	for i, test := range table {
+		resp := &http.Response{
+			StatusCode: test.Status,
+			Header:     http.Header{"Content-Type": []string{test.ContentType}},
+			Body:       io.NopCloser(strings.NewReader(test.Body)),
+		}
+		if code := faults.CodeOf(faultshttp.ReadProblem(resp)); code != test.Code {
+			t.Errorf("%d - expect code %s, but got %s", i, test.Code, code)
+		}
+	}
+}
+
+// TestConformance ensures problem details documents follow the semantics of
+// the core.
+func TestConformance(t *testing.T) {
+	write := func(err error) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		faultshttp.WriteProblem(rec, httptest.NewRequest("GET", "/", nil), err)
+		return rec
+	}
+
+	faultstest.ConformanceSuite{
+		RoundTrip: func(err error) error {
+			return faultshttp.ReadProblem(write(err).Result())
+		},
+		Status: func(err error) int {
+			return write(err).Code
+		},
+		Render: func(err error) string {
+			return write(err).Body.String()
+		},
+		RetryInfo: true,
+		Details:   true,
+	}.Run(t)
+}
@@ -0,0 +1,131 @@
+package faultshttp
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/deixis/faults"
+)
+
+// DefaultMaxAttempts is the number of attempts made by RetryTransport when
+// none is given.
+const DefaultMaxAttempts = 3
+
+// RetryTransport is an http.RoundTripper that retries requests failing with a
+// retryable fault, i.e. Unavailable, or ResourceExhausted with a retry delay
+// (see faults.RetryAdvice).
+//
+// Example:
+//
+//	client := &http.Client{Transport: &faultshttp.RetryTransport{MaxAttempts: 5}}
+//
+// The delay between two attempts is the longest of the backoff delay and the
+// RetryInfo delay of the fault. It gives up early when the delay would exceed
+// the deadline of the request context.
+//
+// Only idempotent requests are retried: requests with a GET, HEAD, OPTIONS,
+// TRACE, PUT or DELETE method, and requests with an `Idempotency-Key` or
+// `X-Idempotency-Key` header, as with http.Transport. Requests with a body
+// must also be rewindable (see http.Request.GetBody), which is the case of
+// those created by http.NewRequest with common body types.
+//
+// Like Transport, the final error response is returned as a fault.
+type RetryTransport struct {
+	// Base is the transport used to send requests. http.DefaultTransport is
+	// used when it is nil.
+	Base http.RoundTripper
+	// Decode converts error responses to faults. ReadProblem is used when it
+	// is nil. A response is returned as is when Decode returns nil.
+	Decode func(resp *http.Response) error
+	// MaxAttempts is the maximum number of attempts, including the first one.
+	// DefaultMaxAttempts is used when it is zero.
+	MaxAttempts int
+	// Backoff computes the delay between attempts. faults.DefaultBackoff is
+	// used when it is nil.
+	Backoff *faults.Backoff
+}
+
+// RoundTrip sends `req`, and sends it again as long as it fails with a
+// retryable fault.
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	tr := &Transport{Base: t.Base, Decode: t.Decode}
+	maxAttempts := t.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+	if !isReplayable(req) {
+		maxAttempts = 1
+	}
+	backoff := faults.DefaultBackoff
+	if t.Backoff != nil {
+		backoff = *t.Backoff
+	}
+	ctx := req.Context()
+
+	for attempt := 0; ; attempt++ {
+		r := req
+		if attempt > 0 {
+			r = req.Clone(ctx)
+			if req.Body != nil && req.Body != http.NoBody {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				r.Body = body
+			}
+		}
+
+		resp, err := tr.RoundTrip(r)
+		if err == nil {
+			return resp, nil
+		}
+
+		advice := faults.RetryAdvice(err)
+		if !advice.Retryable || advice.Restart || attempt+1 >= maxAttempts {
+			faults.Trace(ctx, faults.EventRetry, err, "give up")
+			return nil, err
+		}
+		delay := backoff.Delay(attempt)
+		if advice.Delay > delay {
+			delay = advice.Delay
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			faults.Trace(ctx, faults.EventRetry, err, "give up: deadline")
+			return nil, err
+		}
+		faults.Trace(ctx, faults.EventRetry, err, "retry in "+delay.String())
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, err
+		case <-timer.C:
+		}
+	}
+}
+
+// isReplayable reports whether `req` can be sent again.
+func isReplayable(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions,
+		http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	_, ok := req.Header["Idempotency-Key"]
+	if !ok {
+		_, ok = req.Header["X-Idempotency-Key"]
+	}
+	return ok
+}
+
+// drain discards what is left of `body`, so the connection can be reused,
+// and closes it.
+func drain(body io.ReadCloser) {
+	io.Copy(io.Discard, io.LimitReader(body, maxProblemSize))
+	body.Close()
+}
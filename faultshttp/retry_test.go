@@ -0,0 +1,141 @@
+package faultshttp_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultshttp"
+)
+
+// flaky serves the given errors, one per request, then succeeds.
+type flaky struct {
+	mu     sync.Mutex
+	errors []error
+	bodies []string
+}
+
+func (f *flaky) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	f.mu.Lock()
+	f.bodies = append(f.bodies, string(body))
+	attempt := len(f.bodies)
+	f.mu.Unlock()
+
+	if attempt <= len(f.errors) {
+		faultshttp.WriteProblem(w, r, f.errors[attempt-1])
+		return
+	}
+	io.WriteString(w, "ok")
+}
+
+func exhausted(delay time.Duration) error {
+	err := faults.ResourceExhausted()
+	err.(*faults.QuotaFailure).RetryInfo.RetryDelay = delay
+	return err
+}
+
+// TestRetryTransport ensures retryable faults are retried.
+func TestRetryTransport(t *testing.T) {
+	table := []struct {
+		Method   string
+		Header   string
+		Errors   []error
+		Code     faults.Code
+		Attempts int
+	}{
+		{Method: "GET", Code: faults.CodeOK, Attempts: 1},
+		{Method: "GET", Errors: []error{faults.Unavailable(0)}, Code: faults.CodeOK, Attempts: 2},
+		{
+			Method:   "GET",
+			Errors:   []error{faults.Unavailable(0), exhausted(5 * time.Millisecond)},
+			Code:     faults.CodeOK,
+			Attempts: 3,
+		},
+		{
+			Method:   "GET",
+			Errors:   []error{faults.Unavailable(0), faults.Unavailable(0), faults.Unavailable(0)},
+			Code:     faults.CodeUnavailable,
+			Attempts: 3,
+		},
+		{Method: "GET", Errors: []error{faults.ResourceExhausted()}, Code: faults.CodeResourceExhausted, Attempts: 1},
+		{Method: "GET", Errors: []error{faults.Aborted()}, Code: faults.CodeAborted, Attempts: 1},
+		{Method: "GET", Errors: []error{faults.NotFound}, Code: faults.CodeNotFound, Attempts: 1},
+		{Method: "POST", Errors: []error{faults.Unavailable(0)}, Code: faults.CodeUnavailable, Attempts: 1},
+		{Method: "POST", Header: "Idempotency-Key", Errors: []error{faults.Unavailable(0)}, Code: faults.CodeOK, Attempts: 2},
+		{Method: "PUT", Errors: []error{faults.Unavailable(0)}, Code: faults.CodeOK, Attempts: 2},
+	}
+
+	for i, test := range table {
+		f := &flaky{errors: test.Errors}
+		srv := httptest.NewServer(f)
+
+		client := &http.Client{Transport: &faultshttp.RetryTransport{
+			Backoff: &faults.Backoff{Base: time.Millisecond},
+		}}
+		req, _ := http.NewRequest(test.Method, srv.URL, strings.NewReader("payload"))
+		if test.Header != "" {
+			req.Header.Set(test.Header, "42")
+		}
+		resp, err := client.Do(req)
+		if resp != nil {
+			resp.Body.Close()
+		}
+		srv.Close()
+
+		if code := faults.CodeOf(err); code != test.Code {
+			t.Errorf("%d - expect code %s, but got %s", i, test.Code, code)
+		}
+		if len(f.bodies) != test.Attempts {
+			t.Errorf("%d - expect %d attempts, but got %d", i, test.Attempts, len(f.bodies))
+		}
+		for _, body := range f.bodies {
+			if body != "payload" {
+				t.Errorf("%d - expect body to be sent on every attempt, but got %q", i, body)
+			}
+		}
+	}
+}
+
+// TestRetryTransportDelay ensures the advised retry delay is respected, and
+// that retries stop before the deadline of the request.
+func TestRetryTransportDelay(t *testing.T) {
+	f := &flaky{errors: []error{faults.Unavailable(50 * time.Millisecond)}}
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+
+	client := &http.Client{Transport: &faultshttp.RetryTransport{
+		Backoff: &faults.Backoff{Base: time.Millisecond},
+	}}
+
+	start := time.Now()
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("expect success, but got %s", err)
+	}
+	resp.Body.Close()
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("expect retry to wait for the advised delay, but got %s", elapsed)
+	}
+
+	f = &flaky{errors: []error{faults.Unavailable(time.Hour)}}
+	srv2 := httptest.NewServer(f)
+	defer srv2.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", srv2.URL, nil)
+	if _, err := client.Do(req); !faults.IsUnavailable(err) {
+		t.Errorf("expect Unavailable, but got %v", err)
+	}
+	if len(f.bodies) != 1 {
+		t.Errorf("expect no retry past the deadline, but got %d attempts", len(f.bodies))
+	}
+}
@@ -0,0 +1,251 @@
+package faultshttp
+
+import (
+	"encoding/xml"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/deixis/faults"
+)
+
+const (
+	// SOAPContentType is the media type of SOAP 1.2 messages.
+	SOAPContentType = "application/soap+xml"
+	// SOAPNamespace is the namespace of the elements of SOAP 1.2 envelopes.
+	SOAPNamespace = "http://www.w3.org/2003/05/soap-envelope"
+	// SOAPDetailNamespace is the namespace of the subcodes and of the detail
+	// entries of the SOAP faults rendered by SOAPEncoder.
+	SOAPDetailNamespace = "urn:deixis:faults"
+)
+
+// SOAPEncoder encodes faults as SOAP 1.2 Fault elements, for partners that
+// still integrate with SOAP services:
+//
+//	eh := &faultshttp.ErrorHandler{Encoder: faultshttp.SOAPEncoder}
+//
+// The code of the fault is env:Sender for client faults, and env:Receiver
+// otherwise, with the category of the fault as subcode, e.g. "f:NOT_FOUND".
+// The reason is the message of the fault, which is only exposed for client
+// faults, like with problem details documents. The violations, the reason,
+// the metadata, the retry delay, the permanent marker and the truncation
+// marker of the fault are detail entries, in the SOAPDetailNamespace
+// namespace. The templates of the descriptions of violations are not
+// carried.
+//
+//	<Envelope xmlns="http://www.w3.org/2003/05/soap-envelope" xmlns:env="http://www.w3.org/2003/05/soap-envelope" xmlns:f="urn:deixis:faults">
+//	  <Body>
+//	    <Fault>
+//	      <Code>
+//	        <Value>env:Sender</Value>
+//	        <Subcode><Value>f:BAD_REQUEST</Value></Subcode>
+//	      </Code>
+//	      <Reason><Text xml:lang="en">Field required</Text></Reason>
+//	      <Detail>
+//	        <violation xmlns="urn:deixis:faults"><field>email</field><description>Field required</description></violation>
+//	      </Detail>
+//	    </Fault>
+//	  </Body>
+//	</Envelope>
+var SOAPEncoder Encoder = soapEncoder{}
+
+// A SOAPEnvelope is the body of a SOAP 1.2 fault response.
+type SOAPEnvelope struct {
+	XMLName xml.Name `xml:"http://www.w3.org/2003/05/soap-envelope Envelope"`
+	Body    SOAPBody `xml:"Body"`
+}
+
+// A SOAPBody is the body of a SOAP envelope.
+type SOAPBody struct {
+	Fault *SOAPFault `xml:"Fault"`
+}
+
+// A SOAPFault is a SOAP 1.2 Fault element.
+type SOAPFault struct {
+	Code   SOAPCode    `xml:"Code"`
+	Reason SOAPReason  `xml:"Reason"`
+	Detail *SOAPDetail `xml:"Detail,omitempty"`
+}
+
+// A SOAPCode is the code of a SOAP fault, or one of its subcodes. Values are
+// qualified names, e.g. "env:Sender".
+type SOAPCode struct {
+	Value   string    `xml:"Value"`
+	Subcode *SOAPCode `xml:"Subcode,omitempty"`
+}
+
+// A SOAPReason lists the descriptions of a SOAP fault, in any language.
+type SOAPReason struct {
+	Text []SOAPText `xml:"Text"`
+}
+
+// A SOAPText is the description of a SOAP fault in a language.
+type SOAPText struct {
+	Lang string `xml:"http://www.w3.org/XML/1998/namespace lang,attr"`
+	Text string `xml:",chardata"`
+}
+
+// A SOAPDetail carries the members of a fault that don't have an equivalent
+// in the SOAP Fault element.
+type SOAPDetail struct {
+	// Violations lists the violations carried by the fault.
+	Violations []Violation `xml:"urn:deixis:faults violation,omitempty"`
+	// Reason is the reason attached to the fault, if any (see
+	// faults.ErrorInfo).
+	Reason string `xml:"urn:deixis:faults reason,omitempty"`
+	// Domain is the domain of the reason, if it is scoped (see
+	// faults.ErrorInfo).
+	Domain string `xml:"urn:deixis:faults domain,omitempty"`
+	// Metadata is the metadata attached to the reason of the fault, if any,
+	// sorted by key.
+	Metadata []SOAPMetadata `xml:"urn:deixis:faults metadata,omitempty"`
+	// RetryDelay is the delay advised before retrying, in milliseconds.
+	RetryDelay int64 `xml:"urn:deixis:faults retryDelay,omitempty"`
+	// Permanent reports whether the fault must not be retried, whatever its
+	// category (see faults.Permanent).
+	Permanent bool `xml:"urn:deixis:faults permanent,omitempty"`
+	// Truncated reports whether details of the fault were dropped to fit the
+	// limits of the envelope (see faults.Limits).
+	Truncated bool `xml:"urn:deixis:faults truncated,omitempty"`
+}
+
+// A SOAPMetadata is an entry of the metadata attached to the reason of a
+// fault.
+type SOAPMetadata struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+type soapEncoder struct{}
+
+func (soapEncoder) ContentType() string {
+	return SOAPContentType + "; charset=utf-8"
+}
+
+func (soapEncoder) Encode(w io.Writer, r *http.Request, err error) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	return xml.NewEncoder(w).Encode(NewSOAPEnvelope(r, err))
+}
+
+// MarshalXML declares the prefixes of the qualified names used as codes.
+func (e SOAPEnvelope) MarshalXML(enc *xml.Encoder, start xml.StartElement) error {
+	type envelope SOAPEnvelope
+	start.Name = xml.Name{Space: SOAPNamespace, Local: "Envelope"}
+	start.Attr = append(start.Attr,
+		xml.Attr{Name: xml.Name{Local: "xmlns:env"}, Value: SOAPNamespace},
+		xml.Attr{Name: xml.Name{Local: "xmlns:f"}, Value: SOAPDetailNamespace},
+	)
+	return enc.EncodeElement(envelope(e), start)
+}
+
+// NewSOAPEnvelope returns the SOAP envelope describing `err`, which occurred
+// while serving `r`. Like NewProblem, it only exposes the message of client
+// faults.
+func NewSOAPEnvelope(r *http.Request, err error) *SOAPEnvelope {
+	p := NewProblem(r, err)
+
+	f := &SOAPFault{
+		Code: SOAPCode{
+			Value:   "env:Receiver",
+			Subcode: &SOAPCode{Value: "f:" + p.Code},
+		},
+		Reason: SOAPReason{Text: []SOAPText{{Lang: "en", Text: p.Detail}}},
+	}
+	if faults.IsClientFault(err) {
+		f.Code.Value = "env:Sender"
+	}
+	if f.Reason.Text[0].Text == "" {
+		f.Reason.Text[0].Text = p.Title
+	}
+	if len(p.Violations) > 0 || p.Reason != "" || p.RetryDelay > 0 || p.Permanent || p.Truncated {
+		f.Detail = &SOAPDetail{
+			Violations: p.Violations,
+			Reason:     p.Reason,
+			Domain:     p.Domain,
+			RetryDelay: p.RetryDelay,
+			Permanent:  p.Permanent,
+			Truncated:  p.Truncated,
+		}
+		keys := make([]string, 0, len(p.Metadata))
+		for k := range p.Metadata {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			f.Detail.Metadata = append(f.Detail.Metadata, SOAPMetadata{Key: k, Value: p.Metadata[k]})
+		}
+	}
+	return &SOAPEnvelope{Body: SOAPBody{Fault: f}}
+}
+
+// Problem converts `e`, received with the HTTP status `status`, to the
+// problem details document describing the same fault, which can then be
+// converted to a fault with Problem.Err.
+//
+// The category is read from the first subcode, whatever the prefix of its
+// name. Faults without a known subcode, e.g. rendered by other SOAP stacks,
+// are categorised by their status.
+func (e *SOAPEnvelope) Problem(status int) *Problem {
+	p := &Problem{
+		Status: status,
+		Title:  http.StatusText(status),
+	}
+	f := e.Body.Fault
+	if f == nil {
+		return p
+	}
+	if sub := f.Code.Subcode; sub != nil {
+		p.Code = sub.Value[strings.IndexByte(sub.Value, ':')+1:]
+	}
+	if len(f.Reason.Text) > 0 {
+		p.Detail = strings.TrimSpace(f.Reason.Text[0].Text)
+	}
+	if d := f.Detail; d != nil {
+		p.Violations = d.Violations
+		p.Reason = d.Reason
+		p.Domain = d.Domain
+		p.RetryDelay = d.RetryDelay
+		p.Permanent = d.Permanent
+		p.Truncated = d.Truncated
+		for _, m := range d.Metadata {
+			if p.Metadata == nil {
+				p.Metadata = map[string]string{}
+			}
+			p.Metadata[m.Key] = m.Value
+		}
+	}
+	return p
+}
+
+// ReadSOAP reconstructs the fault described by `resp`, like ReadProblem, for
+// services rendering SOAP 1.2 faults. Problem details documents are
+// converted with ReadProblem, and responses without a SOAP fault with
+// faults.FromHTTPResponse.
+func ReadSOAP(resp *http.Response) error {
+	if resp == nil || resp.StatusCode < 400 {
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || mediaType != SOAPContentType || resp.Body == nil {
+		return ReadProblem(resp)
+	}
+
+	var envelope SOAPEnvelope
+	if err := xml.NewDecoder(io.LimitReader(resp.Body, maxProblemSize)).Decode(&envelope); err != nil ||
+		envelope.Body.Fault == nil {
+		return faults.FromHTTPResponse(resp)
+	}
+	p := envelope.Problem(resp.StatusCode)
+	if p.RetryDelay == 0 {
+		p.RetryDelay = faults.ParseRetryAfter(resp.Header.Get("Retry-After")).Milliseconds()
+	}
+	fault := p.Err()
+	if e, ok := faults.AsUnauthenticated(fault); ok {
+		e.Challenges = faults.ParseWWWAuthenticate(resp.Header)
+	}
+	return fault
+}
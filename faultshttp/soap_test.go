@@ -0,0 +1,185 @@
+package faultshttp_test
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultshttp"
+	"github.com/deixis/faults/faultstest"
+)
+
+// TestSOAPEncoder ensures faults are rendered as SOAP 1.2 faults.
+func TestSOAPEncoder(t *testing.T) {
+	table := []struct {
+		Error error
+		Fault faultshttp.SOAPFault
+	}{
+		{
+			Error: faults.Bad(
+				&faults.FieldViolation{Field: "email", Code: faults.ViolationRequired, Description: "Field required"},
+				&faults.FieldViolation{Field: "name", Description: "Too long"},
+			),
+			Fault: faultshttp.SOAPFault{
+				Code: faultshttp.SOAPCode{
+					Value:   "env:Sender",
+					Subcode: &faultshttp.SOAPCode{Value: "f:BAD_REQUEST"},
+				},
+				Reason: faultshttp.SOAPReason{Text: []faultshttp.SOAPText{{Lang: "en", Text: "Field required. Too long"}}},
+				Detail: &faultshttp.SOAPDetail{Violations: []faultshttp.Violation{
+					{Field: "email", Code: "REQUIRED", Description: "Field required"},
+					{Field: "name", Description: "Too long"},
+				}},
+			},
+		},
+		{
+			Error: faults.WithErrorInfo(faults.NotFound, "USER_NOT_FOUND", map[string]string{"tenant": "acme", "id": "42"}),
+			Fault: faultshttp.SOAPFault{
+				Code: faultshttp.SOAPCode{
+					Value:   "env:Sender",
+					Subcode: &faultshttp.SOAPCode{Value: "f:NOT_FOUND"},
+				},
+				Reason: faultshttp.SOAPReason{Text: []faultshttp.SOAPText{{Lang: "en", Text: "resource not found"}}},
+				Detail: &faultshttp.SOAPDetail{
+					Reason: "USER_NOT_FOUND",
+					Metadata: []faultshttp.SOAPMetadata{
+						{Key: "id", Value: "42"},
+						{Key: "tenant", Value: "acme"},
+					},
+				},
+			},
+		},
+		{
+			Error: faults.WithUnavailable(errors.New("dial tcp 10.0.0.1:5432: connection refused"), time.Second),
+			Fault: faultshttp.SOAPFault{
+				Code: faultshttp.SOAPCode{
+					Value:   "env:Receiver",
+					Subcode: &faultshttp.SOAPCode{Value: "f:UNAVAILABLE"},
+				},
+				Reason: faultshttp.SOAPReason{Text: []faultshttp.SOAPText{{Lang: "en", Text: "Service Unavailable"}}},
+				Detail: &faultshttp.SOAPDetail{RetryDelay: 1000},
+			},
+		},
+	}
+
+	eh := &faultshttp.ErrorHandler{Encoder: faultshttp.SOAPEncoder}
+	for i, test := range table {
+		rec := httptest.NewRecorder()
+		eh.WriteError(rec, httptest.NewRequest("POST", "/users", nil), test.Error)
+
+		if ct := rec.Header().Get("Content-Type"); ct != "application/soap+xml; charset=utf-8" {
+			t.Errorf("%d - expect content type application/soap+xml, but got %s", i, ct)
+		}
+		var envelope faultshttp.SOAPEnvelope
+		if err := xml.Unmarshal(rec.Body.Bytes(), &envelope); err != nil {
+			t.Fatalf("%d - expect SOAP envelope, but got %s", i, err)
+		}
+		if envelope.Body.Fault == nil {
+			t.Fatalf("%d - expect SOAP fault, but got %s", i, rec.Body)
+		}
+		if !reflect.DeepEqual(*envelope.Body.Fault, test.Fault) {
+			t.Errorf("%d - expect fault %+v, but got %+v", i, test.Fault, *envelope.Body.Fault)
+		}
+	}
+}
+
+// TestSOAPEncoderPrefixes ensures the prefixes of the codes are declared.
+func TestSOAPEncoderPrefixes(t *testing.T) {
+	rec := httptest.NewRecorder()
+	faultshttp.SOAPEncoder.Encode(rec, nil, faults.NotFound)
+
+	body := rec.Body.String()
+	for _, decl := range []string{
+		`xmlns:env="http://www.w3.org/2003/05/soap-envelope"`,
+		`xmlns:f="urn:deixis:faults"`,
+	} {
+		if !strings.Contains(body, decl) {
+			t.Errorf("expect %s to be declared, but got %s", decl, body)
+		}
+	}
+}
+
+// TestReadSOAP ensures SOAP faults are converted back to faults, including
+// the ones that were not rendered by this package.
+func TestReadSOAP(t *testing.T) {
+	table := []struct {
+		Status int
+		Body   string
+		Code   faults.Code
+	}{
+		{
+			Status: http.StatusNotFound,
+			Body: `<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope" xmlns:x="urn:deixis:faults">
+				<soap:Body><soap:Fault>
+					<soap:Code><soap:Value>soap:Sender</soap:Value><soap:Subcode><soap:Value>x:NOT_FOUND</soap:Value></soap:Subcode></soap:Code>
+					<soap:Reason><soap:Text xml:lang="en">No such user</soap:Text></soap:Reason>
+				</soap:Fault></soap:Body>
+			</soap:Envelope>`,
+			Code: faults.CodeNotFound,
+		},
+		{
+			// Faults of other SOAP stacks fall back to the status
+			Status: http.StatusForbidden,
+			Body: `<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope">
+				<soap:Body><soap:Fault>
+					<soap:Code><soap:Value>soap:Sender</soap:Value></soap:Code>
+					<soap:Reason><soap:Text xml:lang="en">Access denied</soap:Text></soap:Reason>
+				</soap:Fault></soap:Body>
+			</soap:Envelope>`,
+			Code: faults.CodePermissionDenied,
+		},
+		{
+			Status: http.StatusServiceUnavailable,
+			Body:   `<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope"><soap:Body/></soap:Envelope>`,
+			Code:   faults.CodeUnavailable,
+		},
+	}
+
+	for i, test := range table {
+		resp := &http.Response{
+			StatusCode: test.Status,
+			Header:     http.Header{"Content-Type": []string{`application/soap+xml; charset="utf-8"`}},
+			Body:       io.NopCloser(strings.NewReader(test.Body)),
+		}
+		if code := faults.CodeOf(faultshttp.ReadSOAP(resp)); code != test.Code {
+			t.Errorf("%d - expect code %s, but got %s", i, test.Code, code)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	faultshttp.WriteProblem(rec, httptest.NewRequest("GET", "/", nil), faults.NotFound)
+	if err := faultshttp.ReadSOAP(rec.Result()); !faults.IsNotFound(err) {
+		t.Errorf("expect problem details to be read, but got %s", faults.CodeOf(err))
+	}
+}
+
+// TestSOAPConformance ensures SOAP faults follow the semantics of the core.
+func TestSOAPConformance(t *testing.T) {
+	write := func(err error) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		eh := &faultshttp.ErrorHandler{Encoder: faultshttp.SOAPEncoder}
+		eh.WriteError(rec, httptest.NewRequest("GET", "/", nil), err)
+		return rec
+	}
+
+	faultstest.ConformanceSuite{
+		RoundTrip: func(err error) error {
+			return faultshttp.ReadSOAP(write(err).Result())
+		},
+		Status: func(err error) int {
+			return write(err).Code
+		},
+		Render: func(err error) string {
+			return write(err).Body.String()
+		},
+		RetryInfo: true,
+		Details:   true,
+	}.Run(t)
+}
@@ -0,0 +1,56 @@
+package faultshttp
+
+import "net/http"
+
+// Transport is an http.RoundTripper that converts error responses to faults,
+// so client libraries built on net/http surface faults without inspecting
+// responses themselves.
+//
+// Example:
+//
+//	client := &http.Client{Transport: &faultshttp.Transport{}}
+//	resp, err := client.Get("https://api.example.com/users/42")
+//	if faults.IsNotFound(err) {
+//		...
+//	}
+//
+// Responses with a status of 400 or above are decoded, their body is closed,
+// and the fault is returned instead of the response. Other responses,
+// including redirects, are returned as is.
+//
+// Note that http.Client wraps the errors returned by its transport with a
+// *url.Error, which keeps the fault reachable with the functions of this
+// module and with `errors.As`.
+type Transport struct {
+	// Base is the transport used to send requests. http.DefaultTransport is
+	// used when it is nil.
+	Base http.RoundTripper
+	// Decode converts error responses to faults. ReadProblem is used when it
+	// is nil. A response is returned as is when Decode returns nil.
+	Decode func(resp *http.Response) error
+}
+
+// RoundTrip sends `req`, and converts the response to a fault when it is an
+// error response.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil || resp.StatusCode < 400 {
+		return resp, err
+	}
+
+	decode := t.Decode
+	if decode == nil {
+		decode = ReadProblem
+	}
+	if err := decode(resp); err != nil {
+		if resp.Body != nil {
+			drain(resp.Body)
+		}
+		return nil, err
+	}
+	return resp, nil
+}
@@ -0,0 +1,113 @@
+package faultshttp_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultshttp"
+)
+
+// TestTransport ensures error responses are surfaced as faults by clients.
+func TestTransport(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("/problem", faultshttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		return faults.Unavailable(time.Second)
+	}))
+	mux.HandleFunc("/text", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "no such user", http.StatusNotFound)
+	})
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	})
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/ok", http.StatusFound)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client := &http.Client{Transport: &faultshttp.Transport{}}
+
+	table := []struct {
+		Path    string
+		Code    faults.Code
+		Delay   time.Duration
+		Message string
+	}{
+		{Path: "/problem", Code: faults.CodeUnavailable, Delay: time.Second},
+		{Path: "/text", Code: faults.CodeNotFound, Message: "no such user"},
+		{Path: "/ok", Code: faults.CodeOK},
+		{Path: "/redirect", Code: faults.CodeOK},
+	}
+
+	for i, test := range table {
+		resp, err := client.Get(srv.URL + test.Path)
+		if code := faults.CodeOf(err); code != test.Code {
+			t.Errorf("%d - expect code %s, but got %s", i, test.Code, code)
+		}
+		if err != nil {
+			if resp != nil {
+				t.Errorf("%d - expect no response with an error", i)
+			}
+			var urlErr *url.Error
+			if !errors.As(err, &urlErr) {
+				t.Errorf("%d - expect client error, but got %T", i, err)
+			}
+			if delay := faults.RetryAdvice(err).Delay; delay != test.Delay {
+				t.Errorf("%d - expect retry delay %s, but got %s", i, test.Delay, delay)
+			}
+			if test.Message != "" && !hasMessage(err, test.Message) {
+				t.Errorf("%d - expect message %q in the chain of %q", i, test.Message, err)
+			}
+			continue
+		}
+
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != "ok" {
+			t.Errorf("%d - expect response body, but got %q", i, body)
+		}
+	}
+}
+
+// TestTransportDecode ensures a custom decoder can be used, and can let error
+// responses through.
+func TestTransportDecode(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "gone", http.StatusGone)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: &faultshttp.Transport{
+		Decode: func(resp *http.Response) error {
+			if resp.StatusCode == http.StatusGone {
+				return nil
+			}
+			return faults.FromHTTPResponse(resp)
+		},
+	}}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("expect response, but got %s", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusGone {
+		t.Errorf("expect status %d, but got %d", http.StatusGone, resp.StatusCode)
+	}
+}
+
+// hasMessage reports whether an error of the tree of `err` has the message
+// `msg`.
+func hasMessage(err error, msg string) bool {
+	found := false
+	faults.Walk(err, func(err error) bool {
+		found = err.Error() == msg
+		return !found
+	})
+	return found
+}
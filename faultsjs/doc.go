@@ -0,0 +1,16 @@
+// Package faultsjs bridges faults with JavaScript `Error` objects, for Go
+// programs compiled to WebAssembly (`GOOS=js GOARCH=wasm`) that consume the
+// same APIs as backend services.
+//
+// A fault is converted to an `Error` whose `cause` is a plain object
+// describing it:
+//
+//	{
+//	  code: "BAD_REQUEST",
+//	  retryDelay: 0, // milliseconds
+//	  violations: [{field: "email", description: "Field required"}]
+//	}
+//
+// The shape of each violation follows the fields of the corresponding Go
+// type, with lower camel case names.
+package faultsjs
@@ -0,0 +1,195 @@
+//go:build js && wasm
+
+package faultsjs
+
+import (
+	"errors"
+	"syscall/js"
+	"time"
+
+	"github.com/deixis/faults"
+)
+
+// ToJS converts `err` to a JavaScript `Error` object. The fault carried by
+// `err`, if any, is described by the `cause` property of the error.
+//
+// A nil error is converted to `null`.
+func ToJS(err error) js.Value {
+	if err == nil {
+		return js.Null()
+	}
+
+	cause := map[string]interface{}{
+		"code": faults.CodeOf(err).String(),
+	}
+	if advice := faults.RetryAdvice(err); advice.Delay > 0 {
+		cause["retryDelay"] = advice.Delay.Milliseconds()
+	}
+	if violations := violationsOf(err); len(violations) > 0 {
+		cause["violations"] = violations
+	}
+
+	options := map[string]interface{}{"cause": cause}
+	return js.Global().Get("Error").New(err.Error(), options)
+}
+
+// FromJS converts a JavaScript value back to an error.
+//
+// An `Error` created by ToJS is converted back to the fault it describes.
+// Any other `Error` is converted to a plain error with the same message.
+// `null` and `undefined` are converted to a nil error.
+func FromJS(v js.Value) error {
+	if v.IsNull() || v.IsUndefined() {
+		return nil
+	}
+	if v.Type() != js.TypeObject {
+		return errors.New(v.String())
+	}
+
+	message := v.Get("message").String()
+	cause := v.Get("cause")
+	if cause.Type() != js.TypeObject {
+		return errors.New(message)
+	}
+
+	code := cause.Get("code")
+	if code.Type() != js.TypeString {
+		return errors.New(message)
+	}
+	c, err := faults.ParseCode(code.String())
+	if err != nil {
+		return errors.New(message)
+	}
+	if build, ok := builders[c]; ok {
+		return build(cause)
+	}
+	if d, ok := faults.Describe(c); ok && d.New != nil {
+		return d.New(errors.New(message))
+	}
+	return errors.New(message)
+}
+
+var builders = map[faults.Code]func(cause js.Value) error{
+	faults.CodeNotFound: func(js.Value) error {
+		return faults.NotFound
+	},
+	faults.CodePermissionDenied: func(js.Value) error {
+		return faults.PermissionDenied
+	},
+	faults.CodeUnauthenticated: func(js.Value) error {
+		return faults.Unauthenticated
+	},
+	faults.CodeUnimplemented: func(js.Value) error {
+		return faults.Unimplemented
+	},
+	faults.CodeInternal: func(js.Value) error {
+		return faults.Internal
+	},
+	faults.CodeDeadlineExceeded: func(js.Value) error {
+		return faults.DeadlineExceeded
+	},
+	faults.CodeUnavailable: func(cause js.Value) error {
+		return faults.Unavailable(retryDelay(cause))
+	},
+	faults.CodeBad: func(cause js.Value) error {
+		var violations []*faults.FieldViolation
+		eachViolation(cause, func(v js.Value) {
+			violations = append(violations, &faults.FieldViolation{
+				Field:       str(v, "field"),
+				Code:        str(v, "code"),
+				Description: str(v, "description"),
+			})
+		})
+		return faults.Bad(violations...)
+	},
+	faults.CodeFailedPrecondition: func(cause js.Value) error {
+		var violations []*faults.PreconditionViolation
+		eachViolation(cause, func(v js.Value) {
+			violations = append(violations, &faults.PreconditionViolation{
+				Type:        str(v, "type"),
+				Subject:     str(v, "subject"),
+				Description: str(v, "description"),
+			})
+		})
+		return faults.FailedPrecondition(violations...)
+	},
+	faults.CodeAborted: func(cause js.Value) error {
+		var violations []*faults.ConflictViolation
+		eachViolation(cause, func(v js.Value) {
+			violations = append(violations, &faults.ConflictViolation{
+				Resource:    str(v, "resource"),
+				Description: str(v, "description"),
+			})
+		})
+		return faults.Aborted(violations...)
+	},
+	faults.CodeResourceExhausted: func(cause js.Value) error {
+		e := &faults.QuotaFailure{
+			RetryInfo: faults.RetryInfo{RetryDelay: retryDelay(cause)},
+		}
+		eachViolation(cause, func(v js.Value) {
+			e.Violations = append(e.Violations, &faults.QuotaViolation{
+				Subject:     str(v, "subject"),
+				Description: str(v, "description"),
+			})
+		})
+		return e
+	},
+}
+
+func violationsOf(err error) []interface{} {
+	var violations []interface{}
+	for _, v := range faults.ViolationsOf(err) {
+		switch v := v.(type) {
+		case *faults.FieldViolation:
+			violations = append(violations, map[string]interface{}{
+				"field":       v.Field,
+				"code":        v.Code,
+				"description": v.Description,
+			})
+		case *faults.PreconditionViolation:
+			violations = append(violations, map[string]interface{}{
+				"type":        v.Type,
+				"subject":     v.Subject,
+				"description": v.Description,
+			})
+		case *faults.ConflictViolation:
+			violations = append(violations, map[string]interface{}{
+				"resource":    v.Resource,
+				"description": v.Description,
+			})
+		case *faults.QuotaViolation:
+			violations = append(violations, map[string]interface{}{
+				"subject":     v.Subject,
+				"description": v.Description,
+			})
+		}
+	}
+	return violations
+}
+
+func eachViolation(cause js.Value, fn func(v js.Value)) {
+	violations := cause.Get("violations")
+	if violations.Type() != js.TypeObject {
+		return
+	}
+	for i := 0; i < violations.Length(); i++ {
+		if v := violations.Index(i); v.Type() == js.TypeObject {
+			fn(v)
+		}
+	}
+}
+
+func retryDelay(cause js.Value) time.Duration {
+	if d := cause.Get("retryDelay"); d.Type() == js.TypeNumber {
+		return time.Duration(d.Int()) * time.Millisecond
+	}
+	return 0
+}
+
+func str(v js.Value, key string) string {
+	if s := v.Get(key); s.Type() == js.TypeString {
+		return s.String()
+	}
+	return ""
+}
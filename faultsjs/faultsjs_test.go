@@ -0,0 +1,65 @@
+//go:build js && wasm
+
+package faultsjs_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultsjs"
+)
+
+// TestRoundTrip ensures faults survive a conversion to a JS Error and back.
+func TestRoundTrip(t *testing.T) {
+	table := []error{
+		faults.NotFound,
+		faults.PermissionDenied,
+		faults.Unauthenticated,
+		faults.Unimplemented,
+		faults.Internal,
+		faults.DeadlineExceeded,
+		faults.Unavailable(3 * time.Second),
+		faults.Bad(&faults.FieldViolation{Field: "email", Description: "Field required"}),
+		faults.FailedPrecondition(&faults.PreconditionViolation{
+			Type:        "TOS",
+			Subject:     "example.com",
+			Description: "Terms of service not accepted",
+		}),
+		faults.Aborted(&faults.ConflictViolation{Resource: "user:1", Description: "version mismatch"}),
+		&faults.QuotaFailure{
+			Violations: []*faults.QuotaViolation{{Subject: "project:x", Description: "daily reads exceeded"}},
+			RetryInfo:  faults.RetryInfo{RetryDelay: time.Minute},
+		},
+	}
+
+	for i, err := range table {
+		v := faultsjs.ToJS(err)
+		if cause := v.Get("cause").Get("code").String(); cause != faults.CodeOf(err).String() {
+			t.Errorf("%d - expect cause code %s, but got %s", i, faults.CodeOf(err), cause)
+		}
+
+		got := faultsjs.FromJS(v)
+		if !reflect.DeepEqual(err, got) {
+			t.Errorf("%d - expect error %#v, but got %#v", i, err, got)
+		}
+	}
+}
+
+// TestFromPlainJS ensures values that do not describe a fault are converted
+// to plain errors.
+func TestFromPlainJS(t *testing.T) {
+	if err := faultsjs.FromJS(faultsjs.ToJS(nil)); err != nil {
+		t.Errorf("expect nil error, but got %s", err)
+	}
+
+	err := faultsjs.FromJS(faultsjs.ToJS(errors.New("boom")))
+	if err == nil || err.Error() != "boom" {
+		t.Fatalf("expect error boom, but got %v", err)
+	}
+	if code := faults.CodeOf(err); code != faults.CodeUnknown {
+		t.Errorf("expect code %s, but got %s", faults.CodeUnknown, code)
+	}
+}
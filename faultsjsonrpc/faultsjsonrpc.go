@@ -0,0 +1,244 @@
+// Package faultsjsonrpc converts faults to JSON-RPC 2.0 error objects, and
+// back.
+//
+// Categories that have an equivalent among the error codes defined by the
+// specification are mapped to it, e.g. Bad to "Invalid params", while the
+// others are mapped to a code of the range reserved for implementation-defined
+// server errors (-32000 to -32099):
+//
+//	Code     Category
+//	-32000   UNKNOWN, and registered categories
+//	-32001   NOT_FOUND
+//	-32002   PERMISSION_DENIED
+//	-32003   UNAUTHENTICATED
+//	-32004   FAILED_PRECONDITION
+//	-32005   ABORTED
+//	-32006   RESOURCE_EXHAUSTED
+//	-32007   UNAVAILABLE
+//	-32008   DEADLINE_EXCEEDED
+//	-32601   UNIMPLEMENTED
+//	-32602   BAD_REQUEST
+//	-32603   INTERNAL
+//
+// The `data` member of the error object carries the exact category, the
+// violations, the reason and the retry delay of the fault, so a client using
+// this package can restore the original fault:
+//
+//	{
+//	  "code": -32602,
+//	  "message": "bad request: email is required",
+//	  "data": {
+//	    "code": "BAD_REQUEST",
+//	    "violations": [{"field": "email", "description": "is required"}]
+//	  }
+//	}
+package faultsjsonrpc
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultshttp"
+)
+
+// Error codes defined by the JSON-RPC 2.0 specification.
+const (
+	ParseError     = -32700
+	InvalidRequest = -32600
+	MethodNotFound = -32601
+	InvalidParams  = -32602
+	InternalError  = -32603
+)
+
+// Error codes of the server error range used for the categories that don't
+// have an equivalent in the specification.
+const (
+	ServerError             = -32000
+	NotFoundError           = -32001
+	PermissionDeniedError   = -32002
+	UnauthenticatedError    = -32003
+	FailedPreconditionError = -32004
+	AbortedError            = -32005
+	ResourceExhaustedError  = -32006
+	UnavailableError        = -32007
+	DeadlineExceededError   = -32008
+)
+
+// An ErrorObject is the `error` member of a JSON-RPC 2.0 response.
+type ErrorObject struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    *Data  `json:"data,omitempty"`
+}
+
+// Data is the structured value of the `data` member of an ErrorObject. Its
+// members follow the extension members of faultshttp.Problem.
+type Data struct {
+	// Code is the name of the category of the fault, e.g. "NOT_FOUND".
+	Code string `json:"code"`
+	// Reason is the reason attached to the fault, if any (see
+	// faults.ErrorInfo).
+	Reason string `json:"reason,omitempty"`
+	// Domain is the domain of the reason, if it is scoped (see
+	// faults.ErrorInfo).
+	Domain string `json:"domain,omitempty"`
+	// Metadata is the metadata attached to the reason of the fault, if any.
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// RetryDelay is the delay advised before retrying, in milliseconds.
+	RetryDelay int64 `json:"retryDelay,omitempty"`
+	// Permanent reports whether the fault must not be retried, whatever its
+	// category (see faults.Permanent).
+	Permanent bool `json:"permanent,omitempty"`
+	// Violations lists the violations carried by the fault.
+	Violations []faultshttp.Violation `json:"violations,omitempty"`
+	// Attachments lists the details attached to the fault (see
+	// faults.Attachment).
+	Attachments []faultshttp.Attachment `json:"attachments,omitempty"`
+}
+
+// NewErrorObject returns the error object describing `err`. It returns nil
+// when `err` is nil.
+//
+// Like for HTTP responses, the message of `err` is only used for client
+// faults. Server faults are described by the message of their category alone
+// (see faults.Sanitize).
+func NewErrorObject(err error) *ErrorObject {
+	if err == nil {
+		return nil
+	}
+	message := err.Error()
+	if faults.IsServerFault(err) {
+		message = faults.Sanitize(err).Error()
+	}
+
+	p := faultshttp.NewProblem(nil, err)
+	return &ErrorObject{
+		Code:    ErrorCode(err),
+		Message: message,
+		Data: &Data{
+			Code:        p.Code,
+			Reason:      p.Reason,
+			Domain:      p.Domain,
+			Metadata:    p.Metadata,
+			RetryDelay:  p.RetryDelay,
+			Permanent:   p.Permanent,
+			Violations:  p.Violations,
+			Attachments: p.Attachments,
+		},
+	}
+}
+
+// Err converts `e` back to the fault it describes. The category is read from
+// the `data` member, or derived from the error code when it is missing (see
+// CodeOf). The message of the error object becomes the message of the error
+// wrapped by the fault.
+func (e *ErrorObject) Err() error {
+	if e == nil {
+		return nil
+	}
+	p := &faultshttp.Problem{Detail: e.Message}
+	if e.Data != nil {
+		p.Code = e.Data.Code
+		p.Reason = e.Data.Reason
+		p.Domain = e.Data.Domain
+		p.Metadata = e.Data.Metadata
+		p.RetryDelay = e.Data.RetryDelay
+		p.Permanent = e.Data.Permanent
+		p.Violations = e.Data.Violations
+		p.Attachments = e.Data.Attachments
+	}
+	if _, err := faults.ParseCode(p.Code); err != nil {
+		p.Code = CodeOf(e.Code).String()
+	}
+	if p.Detail == "" {
+		p.Detail = "jsonrpc: error " + p.Code
+	}
+	return p.Err()
+}
+
+// Encode returns the JSON encoding of the error object describing `err`, to
+// be used as the `error` member of a response. It returns `null` when `err` is
+// nil.
+func Encode(err error) ([]byte, error) {
+	return json.Marshal(NewErrorObject(err))
+}
+
+// Decode converts the JSON encoding of the `error` member of a response back
+// to a fault (see ErrorObject.Err). It returns nil when `data` is empty or
+// `null`.
+func Decode(data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	var e *ErrorObject
+	if err := json.Unmarshal(data, &e); err != nil {
+		return errors.New("jsonrpc: invalid error object: " + err.Error())
+	}
+	return e.Err()
+}
+
+// ErrorCode returns the error code matching the category of `err`. It returns
+// 0 when `err` is nil.
+func ErrorCode(err error) int {
+	switch faults.CodeOf(err) {
+	case faults.CodeOK:
+		return 0
+	case faults.CodeBad:
+		return InvalidParams
+	case faults.CodeNotFound:
+		return NotFoundError
+	case faults.CodePermissionDenied:
+		return PermissionDeniedError
+	case faults.CodeUnauthenticated:
+		return UnauthenticatedError
+	case faults.CodeFailedPrecondition:
+		return FailedPreconditionError
+	case faults.CodeAborted:
+		return AbortedError
+	case faults.CodeResourceExhausted:
+		return ResourceExhaustedError
+	case faults.CodeUnavailable:
+		return UnavailableError
+	case faults.CodeDeadlineExceeded:
+		return DeadlineExceededError
+	case faults.CodeUnimplemented:
+		return MethodNotFound
+	case faults.CodeInternal:
+		return InternalError
+	}
+	return ServerError
+}
+
+// CodeOf returns the fault category of the error code `code`. Codes produced
+// by other implementations, including the remaining codes of the server error
+// range, are classified as Unknown.
+func CodeOf(code int) faults.Code {
+	switch code {
+	case 0:
+		return faults.CodeOK
+	case ParseError, InvalidRequest, InvalidParams:
+		return faults.CodeBad
+	case NotFoundError:
+		return faults.CodeNotFound
+	case PermissionDeniedError:
+		return faults.CodePermissionDenied
+	case UnauthenticatedError:
+		return faults.CodeUnauthenticated
+	case FailedPreconditionError:
+		return faults.CodeFailedPrecondition
+	case AbortedError:
+		return faults.CodeAborted
+	case ResourceExhaustedError:
+		return faults.CodeResourceExhausted
+	case UnavailableError:
+		return faults.CodeUnavailable
+	case DeadlineExceededError:
+		return faults.CodeDeadlineExceeded
+	case MethodNotFound:
+		return faults.CodeUnimplemented
+	case InternalError:
+		return faults.CodeInternal
+	}
+	return faults.CodeUnknown
+}
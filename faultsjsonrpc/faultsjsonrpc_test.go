@@ -0,0 +1,141 @@
+package faultsjsonrpc_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultsjsonrpc"
+	"github.com/deixis/faults/faultstest"
+)
+
+// TestRoundTrip ensures faults survive a conversion to a JSON-RPC error
+// object, and back.
+func TestRoundTrip(t *testing.T) {
+	table := []struct {
+		Error     error
+		ErrorCode int
+		Delay     time.Duration
+	}{
+		{Error: faults.Bad(&faults.FieldViolation{Field: "email"}), ErrorCode: faultsjsonrpc.InvalidParams},
+		{Error: faults.NotFound, ErrorCode: faultsjsonrpc.NotFoundError},
+		{Error: faults.PermissionDenied, ErrorCode: faultsjsonrpc.PermissionDeniedError},
+		{Error: faults.Unauthenticated, ErrorCode: faultsjsonrpc.UnauthenticatedError},
+		{Error: faults.FailedPrecondition(), ErrorCode: faultsjsonrpc.FailedPreconditionError},
+		{Error: faults.Aborted(), ErrorCode: faultsjsonrpc.AbortedError},
+		{Error: faults.ResourceExhausted(), ErrorCode: faultsjsonrpc.ResourceExhaustedError},
+		{Error: faults.Unavailable(3 * time.Second), ErrorCode: faultsjsonrpc.UnavailableError, Delay: 3 * time.Second},
+		{Error: faults.DeadlineExceeded, ErrorCode: faultsjsonrpc.DeadlineExceededError},
+		{Error: faults.Unimplemented, ErrorCode: faultsjsonrpc.MethodNotFound},
+		{Error: faults.Internal, ErrorCode: faultsjsonrpc.InternalError},
+		{Error: errors.New("boom"), ErrorCode: faultsjsonrpc.ServerError},
+	}
+
+	for i, test := range table {
+		data, err := faultsjsonrpc.Encode(test.Error)
+		if err != nil {
+			t.Fatalf("%d - expect error object to be encoded, but got %s", i, err)
+		}
+		var obj faultsjsonrpc.ErrorObject
+		if err := json.Unmarshal(data, &obj); err != nil {
+			t.Fatalf("%d - expect valid JSON, but got %s", i, err)
+		}
+		if obj.Code != test.ErrorCode {
+			t.Errorf("%d - expect error code %d, but got %d", i, test.ErrorCode, obj.Code)
+		}
+
+		err = faultsjsonrpc.Decode(data)
+		if code := faults.CodeOf(err); code != faults.CodeOf(test.Error) {
+			t.Errorf("%d - expect code %s, but got %s", i, faults.CodeOf(test.Error), code)
+		}
+		if delay := faults.RetryAdvice(err).Delay; delay != test.Delay {
+			t.Errorf("%d - expect retry delay %s, but got %s", i, test.Delay, delay)
+		}
+	}
+
+	if data, _ := faultsjsonrpc.Encode(nil); string(data) != "null" {
+		t.Errorf("expect null, but got %s", data)
+	}
+	if err := faultsjsonrpc.Decode([]byte("null")); err != nil {
+		t.Errorf("expect nil, but got %v", err)
+	}
+	if err := faultsjsonrpc.Decode([]byte("{")); err == nil {
+		t.Error("expect invalid error object to be rejected")
+	}
+}
+
+// TestDecode ensures error objects that were not produced by Encode are
+// classified too.
+func TestDecode(t *testing.T) {
+	table := []struct {
+		JSON string
+		Code faults.Code
+	}{
+		{JSON: `{"code": -32700, "message": "Parse error"}`, Code: faults.CodeBad},
+		{JSON: `{"code": -32600, "message": "Invalid Request"}`, Code: faults.CodeBad},
+		{JSON: `{"code": -32601, "message": "Method not found"}`, Code: faults.CodeUnimplemented},
+		{JSON: `{"code": -32603, "message": "Internal error"}`, Code: faults.CodeInternal},
+		{JSON: `{"code": -32042, "message": "Custom"}`, Code: faults.CodeUnknown},
+		{JSON: `{"code": 42, "message": "Application error"}`, Code: faults.CodeUnknown},
+		{JSON: `{"code": -32001, "message": ""}`, Code: faults.CodeNotFound},
+		{JSON: `{"code": -32603, "message": "denied", "data": {"code": "PERMISSION_DENIED"}}`, Code: faults.CodePermissionDenied},
+		{JSON: `{"code": -32002, "message": "denied", "data": {"code": "NOT_A_CODE"}}`, Code: faults.CodePermissionDenied},
+	}
+
+	for i, test := range table {
+		err := faultsjsonrpc.Decode([]byte(test.JSON))
+		if code := faults.CodeOf(err); code != test.Code {
+			t.Errorf("%d - expect code %s, but got %s", i, test.Code, code)
+		}
+	}
+}
+
+// TestNewErrorObject ensures the data member describes the fault.
+func TestNewErrorObject(t *testing.T) {
+	err := faults.WithErrorInfo(
+		faults.Bad(&faults.FieldViolation{Field: "email", Code: faults.ViolationRequired, Description: "is required"}),
+		"EMAIL_REQUIRED", map[string]string{"form": "signup"},
+	)
+	obj := faultsjsonrpc.NewErrorObject(err)
+	if obj.Message != err.Error() {
+		t.Errorf("expect message %q, but got %q", err, obj.Message)
+	}
+	if obj.Data.Code != "BAD_REQUEST" || obj.Data.Reason != "EMAIL_REQUIRED" || obj.Data.Metadata["form"] != "signup" {
+		t.Errorf("expect data to describe the fault, but got %+v", obj.Data)
+	}
+	if len(obj.Data.Violations) != 1 || obj.Data.Violations[0].Code != faults.ViolationRequired {
+		t.Errorf("expect required violation, but got %+v", obj.Data.Violations)
+	}
+
+	obj = faultsjsonrpc.NewErrorObject(faults.WithReason(faults.NotFound, "accounts.example.com/USER_NOT_FOUND"))
+	if obj.Data.Domain != "accounts.example.com" || faults.DomainOf(obj.Err()) != "accounts.example.com" {
+		t.Errorf("expect domain of the reason, but got %+v", obj.Data)
+	}
+
+	obj = faultsjsonrpc.NewErrorObject(faults.Attach(faults.WithInternal(errors.New("db password leaked")), "query", "SELECT * FROM secrets"))
+	if obj.Message != "internal error" {
+		t.Errorf("expect sanitized message, but got %q", obj.Message)
+	}
+	if len(obj.Data.Attachments) != 0 {
+		t.Errorf("expect no attachments, but got %+v", obj.Data.Attachments)
+	}
+	if faultsjsonrpc.NewErrorObject(nil) != nil {
+		t.Error("expect nil error object")
+	}
+}
+
+// TestConformance ensures error objects follow the semantics of the core.
+func TestConformance(t *testing.T) {
+	faultstest.ConformanceSuite{
+		RoundTrip: func(err error) error {
+			return faultsjsonrpc.NewErrorObject(err).Err()
+		},
+		Render: func(err error) string {
+			return faultsjsonrpc.NewErrorObject(err).Message
+		},
+		RetryInfo: true,
+		Details:   true,
+	}.Run(t)
+}
@@ -0,0 +1,286 @@
+// Package faultsk8s converts faults to Kubernetes API statuses (see
+// `k8s.io/apimachinery/pkg/apis/meta/v1.Status`), and back, for operators,
+// controllers and admission webhooks built on client-go.
+//
+// Categories are mapped to the status reasons checked by client-go (see
+// `k8s.io/apimachinery/pkg/api/errors`), e.g. NotFound to "NotFound" and
+// Aborted to "Conflict", so `apierrors.IsNotFound` and its siblings keep
+// working with the statuses built by ToStatus. Violations become the causes
+// of the status, and retry delays its RetryAfterSeconds, rounded up to the
+// second. Statuses have no room for reasons (see faults.ErrorInfo), so they
+// are not carried:
+//
+//	obj, err := client.Get(ctx, name, metav1.GetOptions{})
+//	if err := faultsk8s.FromStatusError(err); faults.IsNotFound(err) {
+//		// ...
+//	}
+package faultsk8s
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/deixis/faults"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// StatusReasonFailedPrecondition is the status reason of FailedPrecondition
+// faults, which have no equivalent among the reasons of Kubernetes. Its code
+// is 400, so client-go classifies it as a bad request.
+const StatusReasonFailedPrecondition metav1.StatusReason = "FailedPrecondition"
+
+// ToStatus returns the Kubernetes status describing `err`. It returns a
+// Success status when `err` is nil.
+//
+// Like for HTTP responses, the message of `err` is only used for client
+// faults. Server faults are described by the message of their category alone
+// (see faults.Sanitize). Errors that are not faults, but that already are
+// Kubernetes API errors, are returned with their own status.
+func ToStatus(err error) *metav1.Status {
+	if err == nil {
+		return &metav1.Status{Status: metav1.StatusSuccess, Code: 200}
+	}
+	code := faults.CodeOf(err)
+	var apiStatus apierrors.APIStatus
+	if code == faults.CodeUnknown && errors.As(err, &apiStatus) {
+		st := apiStatus.Status()
+		return &st
+	}
+
+	message := err.Error()
+	if faults.IsServerFault(err) {
+		message = faults.Sanitize(err).Error()
+	}
+	st := &metav1.Status{
+		Status:  metav1.StatusFailure,
+		Message: message,
+		Reason:  reasons[code],
+		Code:    int32(faults.HTTPStatus(err)),
+	}
+
+	var causes []metav1.StatusCause
+	switch code {
+	case faults.CodeBad:
+		if e, ok := faults.AsBad(err); ok && len(e.Violations) > 0 {
+			st.Reason = metav1.StatusReasonInvalid
+			st.Code = 422
+			for _, v := range e.Violations {
+				causes = append(causes, metav1.StatusCause{
+					Type:    causeType(v.Code),
+					Message: v.Description,
+					Field:   v.Field,
+				})
+			}
+		}
+	case faults.CodeFailedPrecondition:
+		if e, ok := faults.AsFailedPrecondition(err); ok {
+			for _, v := range e.Violations {
+				causes = append(causes, metav1.StatusCause{
+					Type:    metav1.CauseType(v.Type),
+					Message: v.Description,
+					Field:   v.Subject,
+				})
+			}
+		}
+	case faults.CodeAborted:
+		if e, ok := faults.AsAborted(err); ok {
+			for _, v := range e.Violations {
+				causes = append(causes, metav1.StatusCause{Message: v.Description, Field: v.Resource})
+			}
+		}
+	case faults.CodeResourceExhausted:
+		if e, ok := faults.AsResourceExhausted(err); ok {
+			for _, v := range e.Violations {
+				causes = append(causes, metav1.StatusCause{Message: v.Description, Field: v.Subject})
+			}
+		}
+	}
+
+	var retryAfter int32
+	if advice := faults.RetryAdvice(err); advice.Delay > 0 {
+		retryAfter = int32(math.Ceil(advice.Delay.Seconds()))
+	}
+	if len(causes) > 0 || retryAfter > 0 {
+		st.Details = &metav1.StatusDetails{Causes: causes, RetryAfterSeconds: retryAfter}
+	}
+	return st
+}
+
+// FromStatusError converts the Kubernetes API error wrapped by `err`, e.g. a
+// `*apierrors.StatusError` returned by client-go, to a fault (see
+// FromStatus). The API error is wrapped by the fault, so the helpers of
+// `k8s.io/apimachinery/pkg/api/errors` keep working with it.
+//
+// Errors that already have a category (see faults.CodeOf), and errors that
+// are not API errors, are returned as they are.
+func FromStatusError(err error) error {
+	if err == nil || faults.CodeOf(err) != faults.CodeUnknown {
+		return err
+	}
+	var apiStatus apierrors.APIStatus
+	if !errors.As(err, &apiStatus) {
+		return err
+	}
+	st := apiStatus.Status()
+	return fromStatus(err, &st)
+}
+
+// FromStatus converts the Kubernetes status `st` to a fault, with the
+// violations described by its causes and the retry delay of its
+// RetryAfterSeconds. The category is derived from its reason, or from its
+// code when the reason is unknown (see CodeOf).
+//
+// It returns nil when `st` is nil or does not describe a failure.
+func FromStatus(st *metav1.Status) error {
+	if st == nil || st.Status == metav1.StatusSuccess || (st.Status == "" && st.Code < 400) {
+		return nil
+	}
+	return fromStatus(&apierrors.StatusError{ErrStatus: *st}, st)
+}
+
+func fromStatus(parent error, st *metav1.Status) error {
+	var (
+		causes []metav1.StatusCause
+		delay  time.Duration
+	)
+	if st.Details != nil {
+		causes = st.Details.Causes
+		delay = time.Duration(st.Details.RetryAfterSeconds) * time.Second
+	}
+
+	switch code := CodeOf(st.Reason, st.Code); code {
+	case faults.CodeBad:
+		var violations []*faults.FieldViolation
+		for _, c := range causes {
+			violations = append(violations, &faults.FieldViolation{
+				Field:       c.Field,
+				Code:        violationCode(c.Type),
+				Description: c.Message,
+			})
+		}
+		return faults.WithBad(parent, violations...)
+	case faults.CodeFailedPrecondition:
+		var violations []*faults.PreconditionViolation
+		for _, c := range causes {
+			violations = append(violations, &faults.PreconditionViolation{
+				Type:        string(c.Type),
+				Subject:     c.Field,
+				Description: c.Message,
+			})
+		}
+		return faults.WithFailedPrecondition(parent, violations...)
+	case faults.CodeAborted:
+		var violations []*faults.ConflictViolation
+		for _, c := range causes {
+			violations = append(violations, &faults.ConflictViolation{Resource: c.Field, Description: c.Message})
+		}
+		return faults.WithAborted(parent, violations...)
+	case faults.CodeResourceExhausted:
+		var violations []*faults.QuotaViolation
+		for _, c := range causes {
+			violations = append(violations, &faults.QuotaViolation{Subject: c.Field, Description: c.Message})
+		}
+		err := faults.WithResourceExhausted(parent, violations...)
+		if e, ok := faults.AsResourceExhausted(err); ok {
+			e.RetryInfo.RetryDelay = delay
+		}
+		return err
+	case faults.CodeUnavailable:
+		return faults.WithUnavailable(parent, delay)
+	default:
+		if d, ok := faults.Describe(code); ok && d.New != nil {
+			return d.New(parent)
+		}
+	}
+	return parent
+}
+
+// CodeOf returns the fault category of the status reason `reason`. Unknown
+// reasons are classified from the HTTP status `code` (see
+// faults.CodeOfHTTPStatus).
+func CodeOf(reason metav1.StatusReason, code int32) faults.Code {
+	switch reason {
+	case metav1.StatusReasonBadRequest, metav1.StatusReasonInvalid,
+		metav1.StatusReasonNotAcceptable, metav1.StatusReasonUnsupportedMediaType,
+		metav1.StatusReasonRequestEntityTooLarge:
+		return faults.CodeBad
+	case metav1.StatusReasonUnauthorized:
+		return faults.CodeUnauthenticated
+	case metav1.StatusReasonForbidden:
+		return faults.CodePermissionDenied
+	case metav1.StatusReasonNotFound, metav1.StatusReasonGone:
+		return faults.CodeNotFound
+	case metav1.StatusReasonAlreadyExists, metav1.StatusReasonConflict:
+		return faults.CodeAborted
+	case metav1.StatusReasonExpired, StatusReasonFailedPrecondition:
+		return faults.CodeFailedPrecondition
+	case metav1.StatusReasonTooManyRequests:
+		return faults.CodeResourceExhausted
+	case metav1.StatusReasonServerTimeout, metav1.StatusReasonServiceUnavailable:
+		return faults.CodeUnavailable
+	case metav1.StatusReasonTimeout:
+		return faults.CodeDeadlineExceeded
+	case metav1.StatusReasonMethodNotAllowed:
+		return faults.CodeUnimplemented
+	case metav1.StatusReasonInternalError:
+		return faults.CodeInternal
+	}
+	if code == 500 {
+		// Internal errors have their own reason
+		return faults.CodeUnknown
+	}
+	return faults.CodeOfHTTPStatus(int(code))
+}
+
+// reasons maps the categories of the faults package to status reasons.
+// Other categories use the Unknown reason, in which case clients classify the
+// status from its code.
+var reasons = map[faults.Code]metav1.StatusReason{
+	faults.CodeBad:                metav1.StatusReasonBadRequest,
+	faults.CodeNotFound:           metav1.StatusReasonNotFound,
+	faults.CodePermissionDenied:   metav1.StatusReasonForbidden,
+	faults.CodeUnauthenticated:    metav1.StatusReasonUnauthorized,
+	faults.CodeFailedPrecondition: StatusReasonFailedPrecondition,
+	faults.CodeAborted:            metav1.StatusReasonConflict,
+	faults.CodeResourceExhausted:  metav1.StatusReasonTooManyRequests,
+	faults.CodeUnavailable:        metav1.StatusReasonServiceUnavailable,
+	faults.CodeUnimplemented:      metav1.StatusReasonMethodNotAllowed,
+	faults.CodeInternal:           metav1.StatusReasonInternalError,
+	faults.CodeDeadlineExceeded:   metav1.StatusReasonTimeout,
+}
+
+// causeTypes maps well-known violation codes to the cause types of
+// Kubernetes.
+var causeTypes = map[string]metav1.CauseType{
+	faults.ViolationRequired:   metav1.CauseTypeFieldValueRequired,
+	faults.ViolationTooLong:    metav1.CauseTypeTooLong,
+	faults.ViolationNotAllowed: metav1.CauseTypeFieldValueNotSupported,
+	faults.ViolationDuplicate:  metav1.CauseTypeFieldValueDuplicate,
+}
+
+// causeType returns the cause type of the violation code `code`. Violations
+// without a code are invalid values, and other codes are used as is.
+func causeType(code string) metav1.CauseType {
+	if t, ok := causeTypes[code]; ok {
+		return t
+	}
+	if code == "" {
+		return metav1.CauseTypeFieldValueInvalid
+	}
+	return metav1.CauseType(code)
+}
+
+// violationCode returns the violation code of the cause type `t`.
+func violationCode(t metav1.CauseType) string {
+	for code, ct := range causeTypes {
+		if ct == t {
+			return code
+		}
+	}
+	if t == metav1.CauseTypeFieldValueInvalid {
+		return ""
+	}
+	return string(t)
+}
@@ -0,0 +1,187 @@
+package faultsk8s_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultsk8s"
+	"github.com/deixis/faults/faultstest"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// TestToStatus ensures faults are converted to statuses understood by
+// client-go.
+func TestToStatus(t *testing.T) {
+	table := []struct {
+		Error  error
+		Reason metav1.StatusReason
+		Code   int32
+		Check  func(error) bool
+	}{
+		{Error: faults.Bad(), Reason: metav1.StatusReasonBadRequest, Code: 400, Check: apierrors.IsBadRequest},
+		{Error: faults.Bad(&faults.FieldViolation{Field: "spec.replicas"}), Reason: metav1.StatusReasonInvalid, Code: 422, Check: apierrors.IsInvalid},
+		{Error: faults.NotFound, Reason: metav1.StatusReasonNotFound, Code: 404, Check: apierrors.IsNotFound},
+		{Error: faults.PermissionDenied, Reason: metav1.StatusReasonForbidden, Code: 403, Check: apierrors.IsForbidden},
+		{Error: faults.Unauthenticated, Reason: metav1.StatusReasonUnauthorized, Code: 401, Check: apierrors.IsUnauthorized},
+		{Error: faults.Aborted(), Reason: metav1.StatusReasonConflict, Code: 409, Check: apierrors.IsConflict},
+		{Error: faults.ResourceExhausted(), Reason: metav1.StatusReasonTooManyRequests, Code: 429, Check: apierrors.IsTooManyRequests},
+		{Error: faults.Unavailable(time.Second), Reason: metav1.StatusReasonServiceUnavailable, Code: 503, Check: apierrors.IsServiceUnavailable},
+		{Error: faults.DeadlineExceeded, Reason: metav1.StatusReasonTimeout, Code: 504, Check: apierrors.IsTimeout},
+		{Error: faults.Unimplemented, Reason: metav1.StatusReasonMethodNotAllowed, Code: 501, Check: apierrors.IsMethodNotSupported},
+		{Error: faults.Internal, Reason: metav1.StatusReasonInternalError, Code: 500, Check: apierrors.IsInternalError},
+		{Error: faults.FailedPrecondition(), Reason: faultsk8s.StatusReasonFailedPrecondition, Code: 400, Check: apierrors.IsBadRequest},
+		{Error: errors.New("boom"), Reason: metav1.StatusReasonUnknown, Code: 500, Check: func(err error) bool { return apierrors.ReasonForError(err) == metav1.StatusReasonUnknown }},
+		{Error: apierrors.NewGone("expired"), Reason: metav1.StatusReasonGone, Code: 410, Check: apierrors.IsGone},
+	}
+
+	for i, test := range table {
+		st := faultsk8s.ToStatus(test.Error)
+		if st.Status != metav1.StatusFailure {
+			t.Errorf("%d - expect failure, but got %q", i, st.Status)
+		}
+		if st.Reason != test.Reason {
+			t.Errorf("%d - expect reason %q, but got %q", i, test.Reason, st.Reason)
+		}
+		if st.Code != test.Code {
+			t.Errorf("%d - expect code %d, but got %d", i, test.Code, st.Code)
+		}
+		if !test.Check(&apierrors.StatusError{ErrStatus: *st}) {
+			t.Errorf("%d - expect status to be recognised by client-go, but got %+v", i, st)
+		}
+	}
+
+	if st := faultsk8s.ToStatus(nil); st.Status != metav1.StatusSuccess {
+		t.Errorf("expect success, but got %q", st.Status)
+	}
+	if st := faultsk8s.ToStatus(faults.WithInternal(errors.New("db password leaked"))); st.Message != "internal error" {
+		t.Errorf("expect sanitized message, but got %q", st.Message)
+	}
+}
+
+// TestRetryAfter ensures retry delays are carried by RetryAfterSeconds.
+func TestRetryAfter(t *testing.T) {
+	table := []struct {
+		Error error
+		Delay time.Duration
+	}{
+		{Error: faults.Unavailable(1500 * time.Millisecond), Delay: 2 * time.Second},
+		{Error: faults.Unavailable(0), Delay: 0},
+	}
+
+	for i, test := range table {
+		err := faultsk8s.FromStatus(faultsk8s.ToStatus(test.Error))
+		if delay := faults.RetryAdvice(err).Delay; delay != test.Delay {
+			t.Errorf("%d - expect retry delay %s, but got %s", i, test.Delay, delay)
+		}
+	}
+
+	err := faultsk8s.FromStatusError(apierrors.NewTooManyRequests("slow down", 3))
+	if !faults.IsResourceExhausted(err) {
+		t.Fatalf("expect resource exhausted, but got %v", err)
+	}
+	if delay := faults.RetryAdvice(err).Delay; delay != 3*time.Second {
+		t.Errorf("expect retry delay 3s, but got %s", delay)
+	}
+}
+
+// TestRetryAfterDebug ensures retry delays survive a round trip in debug mode,
+// where faults carry a DebugInfo.
+func TestRetryAfterDebug(t *testing.T) {
+	faults.SetDebug(true)
+	defer faults.SetDebug(false)
+
+	fault := faults.Build(faults.CodeResourceExhausted).Quota("user:42", "Daily limit reached").Retryable(30 * time.Second).Err()
+	err := faultsk8s.FromStatus(faultsk8s.ToStatus(fault))
+	if !faults.IsResourceExhausted(err) {
+		t.Fatalf("expect resource exhausted, but got %v", err)
+	}
+	if delay := faults.RetryAdvice(err).Delay; delay != 30*time.Second {
+		t.Errorf("expect retry delay 30s, but got %s", delay)
+	}
+}
+
+// TestFromStatusError ensures the errors of client-go are classified.
+func TestFromStatusError(t *testing.T) {
+	resource := schema.GroupResource{Group: "apps", Resource: "deployments"}
+	table := []struct {
+		Error error
+		Code  faults.Code
+	}{
+		{Error: nil, Code: faults.CodeOK},
+		{Error: apierrors.NewNotFound(resource, "web"), Code: faults.CodeNotFound},
+		{Error: apierrors.NewAlreadyExists(resource, "web"), Code: faults.CodeAborted},
+		{Error: apierrors.NewConflict(resource, "web", errors.New("modified")), Code: faults.CodeAborted},
+		{Error: apierrors.NewForbidden(resource, "web", errors.New("rbac")), Code: faults.CodePermissionDenied},
+		{Error: apierrors.NewUnauthorized("expired"), Code: faults.CodeUnauthenticated},
+		{Error: apierrors.NewBadRequest("bad"), Code: faults.CodeBad},
+		{Error: apierrors.NewServerTimeout(resource, "get", 2), Code: faults.CodeUnavailable},
+		{Error: apierrors.NewTimeoutError("slow", 0), Code: faults.CodeDeadlineExceeded},
+		{Error: apierrors.NewInternalError(errors.New("etcd")), Code: faults.CodeInternal},
+		{Error: apierrors.NewGenericServerResponse(418, "get", resource, "web", "teapot", 0, false), Code: faults.CodeBad},
+		{Error: apierrors.NewGenericServerResponse(500, "get", resource, "web", "boom", 0, false), Code: faults.CodeInternal},
+		{Error: fmt.Errorf("get: %w", apierrors.NewNotFound(resource, "web")), Code: faults.CodeNotFound},
+		{Error: errors.New("connection refused"), Code: faults.CodeUnknown},
+	}
+
+	for i, test := range table {
+		err := faultsk8s.FromStatusError(test.Error)
+		if code := faults.CodeOf(err); code != test.Code {
+			t.Errorf("%d - expect code %s, but got %s", i, test.Code, code)
+		}
+		if test.Error != nil && !errors.Is(err, test.Error) {
+			t.Errorf("%d - expect API error to be wrapped", i)
+		}
+	}
+}
+
+// TestInvalid ensures field violations are carried by the causes of Invalid
+// statuses.
+func TestInvalid(t *testing.T) {
+	fault := faults.Bad(
+		&faults.FieldViolation{Field: "spec.replicas", Code: faults.ViolationRequired, Description: "is required"},
+		&faults.FieldViolation{Field: "metadata.name", Code: faults.ViolationFormat, Description: "must be a DNS label"},
+		&faults.FieldViolation{Field: "spec.image", Description: "is invalid"},
+	)
+	st := faultsk8s.ToStatus(fault)
+	expect := []metav1.CauseType{metav1.CauseTypeFieldValueRequired, "FORMAT", metav1.CauseTypeFieldValueInvalid}
+	for i, c := range st.Details.Causes {
+		if c.Type != expect[i] {
+			t.Errorf("%d - expect cause type %s, but got %s", i, expect[i], c.Type)
+		}
+	}
+
+	err := faultsk8s.FromStatus(st)
+	bad, ok := faults.AsBad(err)
+	if !ok || len(bad.Violations) != 3 {
+		t.Fatalf("expect 3 violations, but got %v", err)
+	}
+	expectBad, _ := faults.AsBad(fault)
+	for i, v := range bad.Violations {
+		if !reflect.DeepEqual(v, expectBad.Violations[i]) {
+			t.Errorf("%d - expect violation %v, but got %v", i, expectBad.Violations[i], v)
+		}
+	}
+
+	if err := faultsk8s.FromStatus(&metav1.Status{Status: metav1.StatusSuccess}); err != nil {
+		t.Errorf("expect nil, but got %v", err)
+	}
+}
+
+// TestConformance ensures statuses follow the semantics of the core.
+func TestConformance(t *testing.T) {
+	faultstest.ConformanceSuite{
+		RoundTrip: func(err error) error {
+			return faultsk8s.FromStatus(faultsk8s.ToStatus(err))
+		},
+		Render: func(err error) string {
+			return faultsk8s.ToStatus(err).Message
+		},
+		RetryInfo: true,
+	}.Run(t)
+}
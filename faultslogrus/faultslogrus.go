@@ -0,0 +1,162 @@
+// Package faultslogrus attaches the details of faults to logrus entries.
+//
+// The Hook recognises the faults logged with an entry, e.g. with
+// logrus.WithError, and adds their code, whether they are retryable and their
+// violations as fields of the entry, so the logs of services still on logrus
+// can be queried like the structured logs of the other integrations:
+//
+//	logrus.AddHook(&faultslogrus.Hook{})
+//	logrus.WithError(err).Error("charging card")
+//
+// With logrus.JSONFormatter, a bad request is logged as:
+//
+//	{"error":"email is required","fault_code":"BAD_REQUEST","retryable":false,"violations":[{"description":"is required","field":"email"}],...}
+package faultslogrus
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/deixis/faults"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// CodeKey is the field carrying the code of a fault, e.g. "NOT_FOUND".
+	CodeKey = "fault_code"
+	// RetryableKey is the field reporting whether a fault is retryable (see
+	// faults.IsRetryable).
+	RetryableKey = "retryable"
+	// RetryDelayKey is the field carrying the retry delay of a fault, if any.
+	RetryDelayKey = "retry_delay"
+	// ReasonKey is the field carrying the reason of a fault, if any.
+	ReasonKey = "reason"
+	// DomainKey is the field carrying the domain of the reason of a fault, if
+	// any.
+	DomainKey = "domain"
+	// ViolationsKey is the field carrying the violations of a fault, if any,
+	// as a list of maps, e.g. {"field": "email", "description": "is
+	// required"}. The parameters of the template of a violation are a map of
+	// their own, under "params".
+	ViolationsKey = "violations"
+)
+
+// Fields returns the fields describing the fault carried by `err`: its code,
+// whether it is retryable, and when they are set, its retry delay, its reason,
+// the domain of its reason and its violations. It returns nil when `err` does
+// not carry a fault.
+//
+//	logrus.WithError(err).WithFields(faultslogrus.Fields(err)).Error("charging card")
+func Fields(err error) logrus.Fields {
+	var f faults.Fault
+	if !errors.As(err, &f) {
+		return nil
+	}
+
+	advice := faults.RetryAdvice(err)
+	fields := logrus.Fields{
+		CodeKey:      faults.CodeOf(err).String(),
+		RetryableKey: advice.Retryable,
+	}
+	if advice.Delay > 0 {
+		fields[RetryDelayKey] = advice.Delay
+	}
+	if info, ok := faults.AsErrorInfo(err); ok {
+		fields[ReasonKey] = info.Reason
+		if info.Domain != "" {
+			fields[DomainKey] = info.Domain
+		}
+	}
+	if v := violations(err); len(v) > 0 {
+		fields[ViolationsKey] = v
+	}
+	return fields
+}
+
+// A Hook adds the fields describing the faults logged with entries (see
+// Fields). The error of an entry is looked up under logrus.ErrorKey first,
+// then in the other fields, by name. Only the first fault found is described,
+// and the fields already set on the entry are kept.
+type Hook struct {
+	// LogLevels are the levels of the entries the hook applies to. It applies
+	// to every level when it is empty.
+	LogLevels []logrus.Level
+}
+
+func (h *Hook) Levels() []logrus.Level {
+	if len(h.LogLevels) == 0 {
+		return logrus.AllLevels
+	}
+	return h.LogLevels
+}
+
+func (h *Hook) Fire(e *logrus.Entry) error {
+	for k, v := range Fields(errorOf(e.Data)) {
+		if _, ok := e.Data[k]; !ok {
+			e.Data[k] = v
+		}
+	}
+	return nil
+}
+
+// errorOf returns the first error carrying a fault found in `data`, or nil.
+func errorOf(data logrus.Fields) error {
+	if err, ok := data[logrus.ErrorKey].(error); ok && isFault(err) {
+		return err
+	}
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err, ok := data[k].(error); ok && isFault(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// isFault reports whether `err` carries a fault.
+func isFault(err error) bool {
+	var f faults.Fault
+	return errors.As(err, &f)
+}
+
+// violations returns the violations of the fault carried by `err`, with their
+// members that are set.
+func violations(err error) []map[string]interface{} {
+	var v []map[string]interface{}
+	for _, x := range faults.ViolationsOf(err) {
+		m := map[string]interface{}{}
+		add := func(members ...string) {
+			for i := 0; i < len(members); i += 2 {
+				if members[i+1] != "" {
+					m[members[i]] = members[i+1]
+				}
+			}
+		}
+
+		var source *faults.Frame
+		switch x := x.(type) {
+		case *faults.FieldViolation:
+			add("field", x.Field, "code", x.Code, "description", x.Description, "template", x.Template)
+			if len(x.Params) > 0 {
+				m["params"] = x.Params
+			}
+			source = x.Source
+		case *faults.PreconditionViolation:
+			add("type", x.Type, "subject", x.Subject, "description", x.Description)
+			source = x.Source
+		case *faults.ConflictViolation:
+			add("resource", x.Resource, "description", x.Description)
+		case *faults.QuotaViolation:
+			add("subject", x.Subject, "description", x.Description)
+		}
+		if source != nil {
+			m["source"] = source.String()
+		}
+		v = append(v, m)
+	}
+	return v
+}
@@ -0,0 +1,143 @@
+package faultslogrus_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultslogrus"
+	"github.com/sirupsen/logrus"
+)
+
+// TestFields ensures faults are described by fields.
+func TestFields(t *testing.T) {
+	table := []struct {
+		Error  error
+		Expect logrus.Fields
+	}{
+		{
+			Error:  faults.NotFound,
+			Expect: logrus.Fields{"fault_code": "NOT_FOUND", "retryable": false},
+		},
+		{
+			Error: faults.Bad(faults.Field("email", "is required")),
+			Expect: logrus.Fields{
+				"fault_code": "BAD_REQUEST",
+				"retryable":  false,
+				"violations": []map[string]interface{}{{"field": "email", "description": "is required"}},
+			},
+		},
+		{
+			Error: faults.WithReason(fmt.Errorf("charging: %w", faults.Unavailable(2*time.Second)), "payments.example.com/MAINTENANCE"),
+			Expect: logrus.Fields{
+				"fault_code":  "UNAVAILABLE",
+				"retryable":   true,
+				"retry_delay": 2 * time.Second,
+				"reason":      "MAINTENANCE",
+				"domain":      "payments.example.com",
+			},
+		},
+		{Error: errors.New("boom"), Expect: nil},
+		{Error: nil, Expect: nil},
+	}
+
+	for i, test := range table {
+		if fields := faultslogrus.Fields(test.Error); !reflect.DeepEqual(test.Expect, fields) {
+			t.Errorf("%d - expect fields %v, but got %v", i, test.Expect, fields)
+		}
+	}
+}
+
+// TestHook ensures the faults logged with entries are described by their
+// fields.
+func TestHook(t *testing.T) {
+	table := []struct {
+		Fields logrus.Fields
+		Expect map[string]interface{}
+	}{
+		{
+			Fields: logrus.Fields{"error": faults.Bad(faults.Field("email", "is required"))},
+			Expect: map[string]interface{}{
+				"error":      "is required",
+				"fault_code": "BAD_REQUEST",
+				"retryable":  false,
+				"violations": []interface{}{map[string]interface{}{"field": "email", "description": "is required"}},
+			},
+		},
+		{
+			Fields: logrus.Fields{"user": 42, "cause": faults.NotFound},
+			Expect: map[string]interface{}{"user": 42.0, "cause": "resource not found", "fault_code": "NOT_FOUND", "retryable": false},
+		},
+		{
+			Fields: logrus.Fields{"error": faults.NotFound, "retryable": "maybe"},
+			Expect: map[string]interface{}{"error": "resource not found", "fault_code": "NOT_FOUND", "retryable": "maybe"},
+		},
+		{
+			Fields: logrus.Fields{"error": errors.New("boom")},
+			Expect: map[string]interface{}{"error": "boom"},
+		},
+	}
+
+	for i, test := range table {
+		var buf bytes.Buffer
+		logger := logrus.New()
+		logger.Out = &buf
+		logger.Formatter = &logrus.JSONFormatter{DisableTimestamp: true}
+		logger.AddHook(&faultslogrus.Hook{})
+		logger.WithFields(test.Fields).Error("failed")
+
+		var entry map[string]interface{}
+		if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+			t.Fatalf("%d - expect JSON entry, but got %s", i, err)
+		}
+		delete(entry, "level")
+		delete(entry, "msg")
+		if !reflect.DeepEqual(test.Expect, entry) {
+			t.Errorf("%d - expect entry %v, but got %v", i, test.Expect, entry)
+		}
+	}
+}
+
+// TestHookLevels ensures the hook only applies to its levels.
+func TestHookLevels(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logrus.New()
+	logger.Out = &buf
+	logger.Formatter = &logrus.JSONFormatter{DisableTimestamp: true}
+	logger.AddHook(&faultslogrus.Hook{LogLevels: []logrus.Level{logrus.ErrorLevel}})
+	logger.WithError(faults.NotFound).Warn("failed")
+
+	if bytes.Contains(buf.Bytes(), []byte("fault_code")) {
+		t.Errorf("expect entry not to be described, but got %s", buf.String())
+	}
+}
+
+// TestFieldsViolations ensures the templates, the parameters and the sources
+// of violations are described.
+func TestFieldsViolations(t *testing.T) {
+	defer faults.SetRecordHops(false)
+	faults.SetRecordHops(true)
+	err := faults.Bad(faults.FieldTemplate("name", "must be at most {max} characters",
+		map[string]string{"max": "50"}, faults.ViolationTooLong))
+
+	violations, _ := faultslogrus.Fields(err)[faultslogrus.ViolationsKey].([]map[string]interface{})
+	if len(violations) != 1 {
+		t.Fatalf("expect 1 violation, but got %v", violations)
+	}
+	v := violations[0]
+	if v["template"] != "must be at most {max} characters" {
+		t.Errorf("expect template, but got %v", v["template"])
+	}
+	if expect := map[string]string{"max": "50"}; !reflect.DeepEqual(expect, v["params"]) {
+		t.Errorf("expect params %v, but got %v", expect, v["params"])
+	}
+	if source, _ := v["source"].(string); !strings.Contains(source, "faultslogrus_test.go") {
+		t.Errorf("expect source in faultslogrus_test.go, but got %q", source)
+	}
+}
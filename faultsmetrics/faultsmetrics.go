@@ -0,0 +1,189 @@
+// Package faultsmetrics exposes Prometheus metrics describing the faults
+// rendered by a service, by category, by reason and by transport, so
+// exporters don't need to parse error messages.
+//
+// Metrics is a prometheus.Collector fed by the tracing hooks of faults (see
+// faults.Trace): its middlewares attach it to the context of each request,
+// and count the faults rendered by the transports while serving it.
+//
+//	m := faultsmetrics.New()
+//	prometheus.MustRegister(m)
+//
+//	// HTTP
+//	srv := &http.Server{Handler: m.Middleware(mux)}
+//
+//	// gRPC, before the interceptors rendering faults
+//	grpc.NewServer(
+//		grpc.ChainUnaryInterceptor(m.UnaryServerInterceptor(), faultsgrpc.UnaryServerInterceptor()),
+//		grpc.ChainStreamInterceptor(m.StreamServerInterceptor(), faultsgrpc.StreamServerInterceptor()),
+//	)
+//
+// The transports without a middleware can report to Metrics as the
+// process-wide trace sink instead (see faults.SetTraceSink). The faults
+// rendered under a middleware are still counted once.
+package faultsmetrics
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/deixis/faults"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+)
+
+// Metrics counts the faults rendered by a service. It exposes:
+//
+//   - faults_rendered_total, the number of faults rendered, by transport,
+//     code, domain and reason, e.g. {transport="http", code="NOT_FOUND",
+//     domain="", reason=""};
+//   - faults_request_duration_seconds, the duration of the requests served
+//     by the middlewares, by transport and by the code of the fault they
+//     rendered, or "OK" when they rendered none.
+//
+// The transport is the name of the package that rendered the fault, without
+// its "faults" prefix, e.g. "http" for faultshttp or "grpc" for faultsgrpc
+// (see faults.Event.Detail).
+type Metrics struct {
+	rendered *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+// New returns metrics with no fault counted yet. They must be registered to
+// be exposed, e.g. with prometheus.MustRegister.
+func New() *Metrics {
+	return &Metrics{
+		rendered: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "faults",
+			Name:      "rendered_total",
+			Help:      "Number of faults rendered, by transport, code, domain and reason.",
+		}, []string{"transport", "code", "domain", "reason"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "faults",
+			Name:      "request_duration_seconds",
+			Help:      "Duration of the requests served, by transport and by the code of the fault rendered.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"transport", "code"}),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.rendered.Describe(ch)
+	m.duration.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.rendered.Collect(ch)
+	m.duration.Collect(ch)
+}
+
+// Trace counts the faults rendered (see faults.EventRender). It makes Metrics
+// a faults.TraceSink.
+func (m *Metrics) Trace(ev faults.Event) {
+	if ev.Kind != faults.EventRender || ev.Err == nil {
+		return
+	}
+	var domain, reason string
+	if info, ok := faults.AsErrorInfo(ev.Err); ok {
+		domain, reason = info.Domain, info.Reason
+	}
+	m.rendered.WithLabelValues(Transport(ev), ev.Code.String(), domain, reason).Inc()
+}
+
+// Transport returns the name of the transport that rendered the fault of
+// `ev`, from the package named by its detail, e.g. "http" for
+// "faultshttp: problem". It returns "unknown" when the detail names none.
+func Transport(ev faults.Event) string {
+	name, _, _ := strings.Cut(ev.Detail, ":")
+	if name = strings.TrimPrefix(name, "faults"); name == "" || strings.ContainsRune(name, ' ') {
+		return "unknown"
+	}
+	return name
+}
+
+// Middleware returns an HTTP handler that calls `next`, and records the
+// faults it renders, e.g. with faultshttp, and its duration.
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := m.record()
+		next.ServeHTTP(w, r.WithContext(m.context(r.Context(), rec)))
+		rec.observe("http")
+	})
+}
+
+// UnaryServerInterceptor returns a unary server interceptor recording the
+// faults rendered by the interceptors it precedes, e.g. the ones of
+// faultsgrpc, and the duration of the calls.
+func (m *Metrics) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		rec := m.record()
+		defer rec.observe("grpc")
+		return handler(m.context(ctx, rec), req)
+	}
+}
+
+// StreamServerInterceptor is like UnaryServerInterceptor, for streams.
+func (m *Metrics) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		rec := m.record()
+		defer rec.observe("grpc")
+		return handler(srv, &serverStream{ServerStream: ss, ctx: m.context(ss.Context(), rec)})
+	}
+}
+
+// record returns the recorder of a request starting now.
+func (m *Metrics) record() *recorder {
+	return &recorder{metrics: m, start: time.Now()}
+}
+
+// context returns a copy of `ctx` reporting the faults rendered to `m`, and
+// to the recorder `rec`. The faults are counted by `m` itself, so they are
+// only counted once when `m` is also the process-wide sink.
+func (m *Metrics) context(ctx context.Context, rec *recorder) context.Context {
+	return faults.ContextWithTrace(ctx, faults.MultiSink(m, rec))
+}
+
+// recorder is the trace sink of a single request. It remembers the code of
+// the last fault rendered while serving it.
+type recorder struct {
+	metrics *Metrics
+	start   time.Time
+	code    atomic.Int64
+}
+
+func (r *recorder) Trace(ev faults.Event) {
+	if ev.Kind == faults.EventRender && ev.Err != nil {
+		r.code.Store(int64(ev.Code))
+	}
+}
+
+// observe records the duration of the request served over `transport`.
+func (r *recorder) observe(transport string) {
+	code := faults.Code(r.code.Load())
+	r.metrics.duration.WithLabelValues(transport, code.String()).Observe(time.Since(r.start).Seconds())
+}
+
+// serverStream is a grpc.ServerStream with the context of its recorder.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
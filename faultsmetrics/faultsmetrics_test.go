@@ -0,0 +1,184 @@
+package faultsmetrics_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultsexpvar"
+	"github.com/deixis/faults/faultsgrpc"
+	"github.com/deixis/faults/faultshttp"
+	"github.com/deixis/faults/faultsmetrics"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/grpc"
+)
+
+// TestMiddleware ensures the faults rendered by HTTP handlers are counted.
+func TestMiddleware(t *testing.T) {
+	m := faultsmetrics.New()
+	h := m.Middleware(faultshttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		if r.URL.Path == "/ok" {
+			return nil
+		}
+		return faults.WithReason(faults.NotFound, "payments.example.com/CARD_NOT_FOUND")
+	}))
+	for _, path := range []string{"/cards/1", "/cards/2", "/ok"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	families := gather(t, m)
+	rendered := labels{"transport": "http", "code": "NOT_FOUND", "domain": "payments.example.com", "reason": "CARD_NOT_FOUND"}
+	if n := counter(families, "faults_rendered_total", rendered); n != 2 {
+		t.Errorf("expect 2 faults rendered, but got %v", n)
+	}
+	if n := histogram(families, "faults_request_duration_seconds", labels{"transport": "http", "code": "NOT_FOUND"}); n != 2 {
+		t.Errorf("expect 2 failed requests, but got %d", n)
+	}
+	if n := histogram(families, "faults_request_duration_seconds", labels{"transport": "http", "code": "OK"}); n != 1 {
+		t.Errorf("expect 1 successful request, but got %d", n)
+	}
+}
+
+// TestMiddlewareStacked ensures the faults are counted when the middleware is
+// stacked with other middlewares attaching trace sinks.
+func TestMiddlewareStacked(t *testing.T) {
+	m := faultsmetrics.New()
+	c := faultsexpvar.New("faultsmetrics_test_stacked")
+	h := m.Middleware(c.Middleware(faultshttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		return faults.NotFound
+	})))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/cards/1", nil))
+
+	families := gather(t, m)
+	if n := counter(families, "faults_rendered_total", labels{"transport": "http", "code": "NOT_FOUND", "domain": "", "reason": ""}); n != 1 {
+		t.Errorf("expect 1 fault rendered, but got %v", n)
+	}
+	if n := c.Count(faults.CodeNotFound); n != 1 {
+		t.Errorf("expect 1 fault counted, but got %d", n)
+	}
+}
+
+// TestMiddlewareGlobal ensures the faults are counted once when the metrics
+// are also the process-wide sink.
+func TestMiddlewareGlobal(t *testing.T) {
+	m := faultsmetrics.New()
+	faults.SetTraceSink(m)
+	defer faults.SetTraceSink(nil)
+
+	h := m.Middleware(faultshttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		return faults.NotFound
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/cards/1", nil))
+
+	families := gather(t, m)
+	if n := counter(families, "faults_rendered_total", labels{"transport": "http", "code": "NOT_FOUND", "domain": "", "reason": ""}); n != 1 {
+		t.Errorf("expect 1 fault rendered, but got %v", n)
+	}
+}
+
+// TestUnaryServerInterceptor ensures the faults rendered by gRPC handlers are
+// counted.
+func TestUnaryServerInterceptor(t *testing.T) {
+	m := faultsmetrics.New()
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, faults.Unavailable(0)
+	}
+	interceptor := faultsgrpc.UnaryServerInterceptor()
+	_, err := m.UnaryServerInterceptor()(context.Background(), nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return interceptor(ctx, req, &grpc.UnaryServerInfo{}, handler)
+	})
+	if err == nil {
+		t.Fatal("expect error, but got nil")
+	}
+
+	families := gather(t, m)
+	if n := counter(families, "faults_rendered_total", labels{"transport": "grpc", "code": "UNAVAILABLE", "domain": "", "reason": ""}); n != 1 {
+		t.Errorf("expect 1 fault rendered, but got %v", n)
+	}
+	if n := histogram(families, "faults_request_duration_seconds", labels{"transport": "grpc", "code": "UNAVAILABLE"}); n != 1 {
+		t.Errorf("expect 1 failed call, but got %d", n)
+	}
+}
+
+// TestTrace ensures the metrics count render events as a trace sink, and
+// name their transport.
+func TestTrace(t *testing.T) {
+	m := faultsmetrics.New()
+	m.Trace(faults.Event{Kind: faults.EventRender, Err: faults.Internal, Code: faults.CodeInternal, Detail: "faultsnats: error"})
+	m.Trace(faults.Event{Kind: faults.EventCreate, Err: faults.Internal, Code: faults.CodeInternal})
+
+	families := gather(t, m)
+	if n := counter(families, "faults_rendered_total", labels{"transport": "nats", "code": "INTERNAL", "domain": "", "reason": ""}); n != 1 {
+		t.Errorf("expect 1 fault rendered, but got %v", n)
+	}
+
+	table := []struct {
+		Detail string
+		Expect string
+	}{
+		{Detail: "faultshttp: application/problem+json", Expect: "http"},
+		{Detail: "faultsgrpc: status", Expect: "grpc"},
+		{Detail: "custom", Expect: "custom"},
+		{Detail: "", Expect: "unknown"},
+	}
+	for i, test := range table {
+		if transport := faultsmetrics.Transport(faults.Event{Detail: test.Detail}); transport != test.Expect {
+			t.Errorf("%d - expect transport %q, but got %q", i, test.Expect, transport)
+		}
+	}
+}
+
+// gather returns the metric families of `m`.
+func gather(t *testing.T, m *faultsmetrics.Metrics) []*dto.MetricFamily {
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(m)
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("expect metrics, but got %s", err)
+	}
+	return families
+}
+
+// labels are the labels of a metric, by name.
+type labels map[string]string
+
+// find returns the metric of the family `name` with the labels `l`.
+func find(families []*dto.MetricFamily, name string, l labels) *dto.Metric {
+	for _, f := range families {
+		if f.GetName() != name {
+			continue
+		}
+		for _, metric := range f.GetMetric() {
+			if labelsOf(metric) == fmt.Sprint(l) {
+				return metric
+			}
+		}
+	}
+	return nil
+}
+
+func labelsOf(metric *dto.Metric) string {
+	l := labels{}
+	for _, pair := range metric.GetLabel() {
+		l[pair.GetName()] = pair.GetValue()
+	}
+	return fmt.Sprint(l)
+}
+
+func counter(families []*dto.MetricFamily, name string, l labels) float64 {
+	if metric := find(families, name, l); metric != nil {
+		return metric.GetCounter().GetValue()
+	}
+	return 0
+}
+
+func histogram(families []*dto.MetricFamily, name string, l labels) uint64 {
+	if metric := find(families, name, l); metric != nil {
+		return metric.GetHistogram().GetSampleCount()
+	}
+	return 0
+}
@@ -0,0 +1,28 @@
+module github.com/deixis/faults/faultsmetrics
+
+go 1.22.0
+
+replace github.com/deixis/faults => ../
+
+replace github.com/deixis/faults/faultsgrpc => ../faultsgrpc
+
+require (
+	github.com/deixis/faults v0.0.0-00010101000000-000000000000
+	github.com/deixis/faults/faultsgrpc v0.0.0-00010101000000-000000000000
+	github.com/prometheus/client_golang v1.20.5
+	github.com/prometheus/client_model v0.6.1
+	google.golang.org/grpc v1.70.0
+)
+
+require (
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/munnerz/goautoneg v0.0.0-20191010083416-a7dc8b61c822 // indirect
+	github.com/prometheus/common v0.55.0 // indirect
+	github.com/prometheus/procfs v0.15.1 // indirect
+	golang.org/x/net v0.33.0 // indirect
+	golang.org/x/sys v0.28.0 // indirect
+	golang.org/x/text v0.21.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20241219192143-6b3ec007d9bb // indirect
+	google.golang.org/protobuf v1.36.0 // indirect
+)
@@ -0,0 +1,172 @@
+// Package faultsmobile exposes faults to iOS and Android apps through
+// gomobile bindings.
+//
+// The API surface is restricted to types supported by `gomobile bind`: no
+// variadic functions, no slices other than byte slices, and no interfaces
+// returning interfaces. Violations are therefore accessed by index.
+//
+// Example (Kotlin):
+//
+//	val fault = Faultsmobile.inspect(err)
+//	if (fault.isRetryable) {
+//	    scheduleRetry(fault.retryDelayMillis())
+//	}
+package faultsmobile
+
+import "github.com/deixis/faults"
+
+// Codes of the fault categories, as returned by Fault.Code.
+const (
+	CodeUnknown            = "UNKNOWN"
+	CodeBad                = "BAD_REQUEST"
+	CodeNotFound           = "NOT_FOUND"
+	CodePermissionDenied   = "PERMISSION_DENIED"
+	CodeUnauthenticated    = "UNAUTHENTICATED"
+	CodeFailedPrecondition = "FAILED_PRECONDITION"
+	CodeAborted            = "ABORTED"
+	CodeResourceExhausted  = "RESOURCE_EXHAUSTED"
+	CodeUnavailable        = "UNAVAILABLE"
+	CodeUnimplemented      = "UNIMPLEMENTED"
+	CodeInternal           = "INTERNAL"
+	CodeDeadlineExceeded   = "DEADLINE_EXCEEDED"
+)
+
+// userMessages are generic messages that are safe to display to end users.
+var userMessages = map[faults.Code]string{
+	faults.CodeUnknown:            "Something went wrong. Please try again later.",
+	faults.CodeBad:                "The request contains invalid information.",
+	faults.CodeNotFound:           "The requested item could not be found.",
+	faults.CodePermissionDenied:   "You are not allowed to perform this action.",
+	faults.CodeUnauthenticated:    "Please sign in to continue.",
+	faults.CodeFailedPrecondition: "This action cannot be performed right now.",
+	faults.CodeAborted:            "The item was modified in the meantime. Please try again.",
+	faults.CodeResourceExhausted:  "Too many requests. Please try again later.",
+	faults.CodeUnavailable:        "The service is temporarily unavailable. Please try again later.",
+	faults.CodeUnimplemented:      "This feature is not available yet.",
+	faults.CodeInternal:           "Something went wrong. Please try again later.",
+	faults.CodeDeadlineExceeded:   "The service took too long to respond. Please try again later.",
+}
+
+// Fault describes an error returned by a backend service.
+type Fault struct {
+	err    error
+	code   faults.Code
+	advice faults.Advice
+	vs     []*Violation
+}
+
+// Inspect returns a description of `err`. It returns nil when `err` is nil.
+func Inspect(err error) *Fault {
+	if err == nil {
+		return nil
+	}
+
+	return &Fault{
+		err:    err,
+		code:   faults.CodeOf(err),
+		advice: faults.RetryAdvice(err),
+		vs:     violationsOf(err),
+	}
+}
+
+// Code returns the category of the fault (e.g. CodeNotFound).
+func (f *Fault) Code() string {
+	return f.code.String()
+}
+
+// Message returns the error message of the fault. It is meant for developers
+// and logs, not for end users (see UserMessage).
+func (f *Fault) Message() string {
+	return f.err.Error()
+}
+
+// UserMessage returns a generic message describing the category of the
+// fault, which is safe to display to end users.
+func (f *Fault) UserMessage() string {
+	if msg, ok := userMessages[f.code]; ok {
+		return msg
+	}
+	return userMessages[faults.CodeUnknown]
+}
+
+// IsClientFault reports whether the fault was caused by the app.
+func (f *Fault) IsClientFault() bool {
+	return faults.IsClientFault(f.err)
+}
+
+// IsServerFault reports whether the fault was caused by the service.
+func (f *Fault) IsServerFault() bool {
+	return faults.IsServerFault(f.err)
+}
+
+// IsRetryable reports whether the failed operation may succeed if attempted
+// again.
+func (f *Fault) IsRetryable() bool {
+	return f.advice.Retryable
+}
+
+// ShouldRestart reports whether the app should restart the whole sequence of
+// operations rather than just retrying the failing call.
+func (f *Fault) ShouldRestart() bool {
+	return f.advice.Restart
+}
+
+// RetryDelayMillis returns the minimum delay advised by the service before
+// retrying, in milliseconds. It returns 0 when no delay was advised.
+func (f *Fault) RetryDelayMillis() int64 {
+	return f.advice.Delay.Milliseconds()
+}
+
+// ViolationCount returns the number of violations attached to the fault.
+func (f *Fault) ViolationCount() int {
+	return len(f.vs)
+}
+
+// Violation returns the violation at index `i`, or nil when `i` is out of
+// range.
+func (f *Fault) Violation(i int) *Violation {
+	if i < 0 || i >= len(f.vs) {
+		return nil
+	}
+	return f.vs[i]
+}
+
+// Violation describes a single violation attached to a fault. Only the
+// fields relevant to the category of the fault are set.
+type Violation struct {
+	// Field is the path of the invalid field of a bad request.
+	Field string
+	// Code is the machine-readable code of the invalid field of a bad
+	// request, e.g. "REQUIRED" (see faults.FieldViolation).
+	Code string
+	// Type is the type of a failed precondition.
+	Type string
+	// Subject is the subject of a failed precondition or of an exhausted
+	// quota.
+	Subject string
+	// Resource is the resource on which a conflict occurred.
+	Resource string
+	// Description describes the violation.
+	Description string
+}
+
+func violationsOf(err error) []*Violation {
+	var vs []*Violation
+	for _, v := range faults.ViolationsOf(err) {
+		switch v := v.(type) {
+		case *faults.FieldViolation:
+			vs = append(vs, &Violation{Field: v.Field, Code: v.Code, Description: v.Description})
+		case *faults.PreconditionViolation:
+			vs = append(vs, &Violation{
+				Type:        v.Type,
+				Subject:     v.Subject,
+				Description: v.Description,
+			})
+		case *faults.ConflictViolation:
+			vs = append(vs, &Violation{Resource: v.Resource, Description: v.Description})
+		case *faults.QuotaViolation:
+			vs = append(vs, &Violation{Subject: v.Subject, Description: v.Description})
+		}
+	}
+	return vs
+}
@@ -0,0 +1,76 @@
+package faultsmobile_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultsmobile"
+)
+
+// TestCodes ensures the exported codes match the names of the core codes.
+func TestCodes(t *testing.T) {
+	table := map[string]faults.Code{
+		faultsmobile.CodeUnknown:            faults.CodeUnknown,
+		faultsmobile.CodeBad:                faults.CodeBad,
+		faultsmobile.CodeNotFound:           faults.CodeNotFound,
+		faultsmobile.CodePermissionDenied:   faults.CodePermissionDenied,
+		faultsmobile.CodeUnauthenticated:    faults.CodeUnauthenticated,
+		faultsmobile.CodeFailedPrecondition: faults.CodeFailedPrecondition,
+		faultsmobile.CodeAborted:            faults.CodeAborted,
+		faultsmobile.CodeResourceExhausted:  faults.CodeResourceExhausted,
+		faultsmobile.CodeUnavailable:        faults.CodeUnavailable,
+		faultsmobile.CodeUnimplemented:      faults.CodeUnimplemented,
+		faultsmobile.CodeInternal:           faults.CodeInternal,
+		faultsmobile.CodeDeadlineExceeded:   faults.CodeDeadlineExceeded,
+	}
+
+	for name, code := range table {
+		if name != code.String() {
+			t.Errorf("expect code %s, but got %s", code, name)
+		}
+	}
+}
+
+// TestInspect ensures faults are described with binding-safe accessors.
+func TestInspect(t *testing.T) {
+	if f := faultsmobile.Inspect(nil); f != nil {
+		t.Errorf("expect nil fault, but got %v", f)
+	}
+
+	f := faultsmobile.Inspect(faults.Unavailable(2 * time.Second))
+	if f.Code() != faultsmobile.CodeUnavailable {
+		t.Errorf("expect code %s, but got %s", faultsmobile.CodeUnavailable, f.Code())
+	}
+	if !f.IsRetryable() || f.ShouldRestart() {
+		t.Errorf("expect fault to be retryable without restart")
+	}
+	if f.RetryDelayMillis() != 2000 {
+		t.Errorf("expect retry delay 2000, but got %d", f.RetryDelayMillis())
+	}
+	if !f.IsServerFault() || f.IsClientFault() {
+		t.Errorf("expect a server fault")
+	}
+
+	f = faultsmobile.Inspect(faults.Bad(
+		&faults.FieldViolation{Field: "email", Code: faults.ViolationRequired, Description: "Field required"},
+	))
+	if f.ViolationCount() != 1 {
+		t.Fatalf("expect 1 violation, but got %d", f.ViolationCount())
+	}
+	if v := f.Violation(0); v.Field != "email" || v.Code != "REQUIRED" || v.Description != "Field required" {
+		t.Errorf("expect email violation, but got %+v", v)
+	}
+	if v := f.Violation(1); v != nil {
+		t.Errorf("expect nil violation out of range, but got %+v", v)
+	}
+	if f.UserMessage() == "" || f.Message() != "Field required" {
+		t.Errorf("expect messages to be set, but got %q and %q", f.UserMessage(), f.Message())
+	}
+
+	f = faultsmobile.Inspect(errors.New("boom"))
+	if f.Code() != faultsmobile.CodeUnknown || f.IsRetryable() {
+		t.Errorf("expect unknown non-retryable fault, but got %s", f.Code())
+	}
+}
@@ -0,0 +1,230 @@
+// Package faultsmqtt converts faults to MQTT v5 reason codes and user
+// properties, and back.
+//
+// MQTT acknowledgements (PUBACK, SUBACK, DISCONNECT, ...) only carry a reason
+// code, and the set of reason codes is geared towards broker failures.
+// Categories that have an equivalent are mapped to it, e.g. PermissionDenied
+// to "Not authorized", while the others are mapped to "Implementation specific
+// error". The exact category and the retry delay are carried by user
+// properties, along with the binary encoding of the fault (see
+// faults.EncodeHeader), so a device or a backend using this package can
+// restore the original fault, with its violations and its reason.
+//
+// The package does not depend on any MQTT client library. Reason codes are
+// plain bytes, and user properties are key/value pairs that can be copied to
+// and from the packet types of the library in use.
+package faultsmqtt
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/deixis/faults"
+)
+
+// A ReasonCode is an MQTT v5 reason code.
+type ReasonCode byte
+
+// Reason codes defined by the MQTT v5 specification.
+const (
+	Success                             ReasonCode = 0x00
+	UnspecifiedError                    ReasonCode = 0x80
+	MalformedPacket                     ReasonCode = 0x81
+	ProtocolError                       ReasonCode = 0x82
+	ImplementationSpecificError         ReasonCode = 0x83
+	UnsupportedProtocolVersion          ReasonCode = 0x84
+	ClientIdentifierNotValid            ReasonCode = 0x85
+	BadUserNameOrPassword               ReasonCode = 0x86
+	NotAuthorized                       ReasonCode = 0x87
+	ServerUnavailable                   ReasonCode = 0x88
+	ServerBusy                          ReasonCode = 0x89
+	Banned                              ReasonCode = 0x8A
+	ServerShuttingDown                  ReasonCode = 0x8B
+	BadAuthenticationMethod             ReasonCode = 0x8C
+	KeepAliveTimeout                    ReasonCode = 0x8D
+	SessionTakenOver                    ReasonCode = 0x8E
+	TopicFilterInvalid                  ReasonCode = 0x8F
+	TopicNameInvalid                    ReasonCode = 0x90
+	PacketIdentifierInUse               ReasonCode = 0x91
+	PacketIdentifierNotFound            ReasonCode = 0x92
+	ReceiveMaximumExceeded              ReasonCode = 0x93
+	TopicAliasInvalid                   ReasonCode = 0x94
+	PacketTooLarge                      ReasonCode = 0x95
+	MessageRateTooHigh                  ReasonCode = 0x96
+	QuotaExceeded                       ReasonCode = 0x97
+	AdministrativeAction                ReasonCode = 0x98
+	PayloadFormatInvalid                ReasonCode = 0x99
+	RetainNotSupported                  ReasonCode = 0x9A
+	QoSNotSupported                     ReasonCode = 0x9B
+	UseAnotherServer                    ReasonCode = 0x9C
+	ServerMoved                         ReasonCode = 0x9D
+	SharedSubscriptionsNotSupported     ReasonCode = 0x9E
+	ConnectionRateExceeded              ReasonCode = 0x9F
+	MaximumConnectTime                  ReasonCode = 0xA0
+	SubscriptionIdentifiersNotSupported ReasonCode = 0xA1
+	WildcardSubscriptionsNotSupported   ReasonCode = 0xA2
+)
+
+// IsError reports whether `c` indicates a failure.
+func (c ReasonCode) IsError() bool {
+	return c >= 0x80
+}
+
+// A Property is an MQTT v5 user property.
+type Property struct {
+	Key   string
+	Value string
+}
+
+const (
+	// CodeProperty is the user property carrying the exact fault code, e.g.
+	// "NOT_FOUND".
+	CodeProperty = "fault-code"
+	// RetryDelayProperty is the user property carrying the retry delay, in
+	// milliseconds.
+	RetryDelayProperty = "fault-retry-delay"
+	// FaultProperty is the user property carrying the fault, encoded with
+	// faults.EncodeHeader.
+	FaultProperty = "fault-bin"
+)
+
+// ReasonCodeOf returns the reason code matching the category of `err`. It
+// returns Success when `err` is nil.
+func ReasonCodeOf(err error) ReasonCode {
+	switch faults.CodeOf(err) {
+	case faults.CodeOK:
+		return Success
+	case faults.CodeUnknown:
+		return UnspecifiedError
+	case faults.CodeBad:
+		return PayloadFormatInvalid
+	case faults.CodePermissionDenied:
+		return NotAuthorized
+	case faults.CodeUnauthenticated:
+		return BadUserNameOrPassword
+	case faults.CodeResourceExhausted:
+		return QuotaExceeded
+	case faults.CodeUnavailable:
+		return ServerUnavailable
+	}
+	return ImplementationSpecificError
+}
+
+// Encode returns the reason code, the reason string and the user properties
+// describing `err`, e.g. to acknowledge a message that could not be
+// processed:
+//
+//	code, reason, props := faultsmqtt.Encode(err)
+//	ack := &paho.Puback{ReasonCode: byte(code), Properties: &paho.PubackProperties{
+//		ReasonString: reason,
+//		User:         toUserProperties(props),
+//	}}
+//
+// Server faults are described by their category only (see faults.Sanitize).
+// It returns Success and no properties when `err` is nil.
+func Encode(err error) (code ReasonCode, reason string, props []Property) {
+	if err == nil {
+		return Success, "", nil
+	}
+
+	outbound := faults.Sanitize(err)
+	c := faults.CodeOf(err)
+	props = []Property{{Key: CodeProperty, Value: c.String()}}
+	if advice := faults.RetryAdvice(err); advice.Delay > 0 {
+		props = append(props, Property{
+			Key:   RetryDelayProperty,
+			Value: strconv.FormatInt(advice.Delay.Milliseconds(), 10),
+		})
+	}
+	props = append(props, Property{Key: FaultProperty, Value: faults.EncodeHeader(outbound)})
+	return ReasonCodeOf(err), outbound.Error(), props
+}
+
+// Decode converts a reason code, its reason string and its user properties
+// back to a fault. It returns nil when `code` does not indicate a failure.
+//
+// Acknowledgements produced with Encode are restored from the fault they
+// carry. Others are classified from their reason code (see CodeOf).
+func Decode(code ReasonCode, reason string, props []Property) error {
+	if !code.IsError() {
+		return nil
+	}
+	if v := property(props, FaultProperty); v != "" {
+		return faults.DecodeHeader(v)
+	}
+	if reason == "" {
+		reason = "mqtt: reason code 0x" + strconv.FormatUint(uint64(code), 16)
+	}
+	parent := errors.New(reason)
+
+	c, err := faults.ParseCode(property(props, CodeProperty))
+	if err != nil {
+		c = CodeOf(code)
+	}
+
+	var delay time.Duration
+	if ms, err := strconv.ParseInt(property(props, RetryDelayProperty), 10, 64); err == nil && ms > 0 {
+		delay = time.Duration(ms) * time.Millisecond
+	}
+
+	switch c {
+	case faults.CodeUnavailable:
+		return faults.WithUnavailable(parent, delay)
+	case faults.CodeResourceExhausted:
+		err := faults.WithResourceExhausted(parent)
+		if e, ok := faults.AsResourceExhausted(err); ok {
+			e.RetryInfo.RetryDelay = delay
+		}
+		return err
+	}
+	if d, ok := faults.Describe(c); ok && d.New != nil {
+		return d.New(parent)
+	}
+	return parent
+}
+
+// CodeOf returns the fault category of the reason code `code`. It returns
+// faults.CodeOK when `code` does not indicate a failure.
+func CodeOf(code ReasonCode) faults.Code {
+	switch code {
+	case MalformedPacket, ProtocolError, ClientIdentifierNotValid,
+		TopicFilterInvalid, TopicNameInvalid, TopicAliasInvalid,
+		PacketTooLarge, PayloadFormatInvalid:
+		return faults.CodeBad
+	case BadUserNameOrPassword, BadAuthenticationMethod:
+		return faults.CodeUnauthenticated
+	case NotAuthorized, Banned:
+		return faults.CodePermissionDenied
+	case ServerUnavailable, ServerBusy, ServerShuttingDown,
+		UseAnotherServer, ServerMoved:
+		return faults.CodeUnavailable
+	case ReceiveMaximumExceeded, MessageRateTooHigh, QuotaExceeded,
+		ConnectionRateExceeded:
+		return faults.CodeResourceExhausted
+	case UnsupportedProtocolVersion, RetainNotSupported, QoSNotSupported,
+		SharedSubscriptionsNotSupported, SubscriptionIdentifiersNotSupported,
+		WildcardSubscriptionsNotSupported:
+		return faults.CodeUnimplemented
+	case KeepAliveTimeout, MaximumConnectTime:
+		return faults.CodeDeadlineExceeded
+	case PacketIdentifierInUse, SessionTakenOver:
+		return faults.CodeAborted
+	case PacketIdentifierNotFound:
+		return faults.CodeNotFound
+	}
+	if !code.IsError() {
+		return faults.CodeOK
+	}
+	return faults.CodeUnknown
+}
+
+// property returns the value of the first user property named `key`.
+func property(props []Property, key string) string {
+	for _, p := range props {
+		if p.Key == key {
+			return p.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,155 @@
+package faultsmqtt_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultsmqtt"
+	"github.com/deixis/faults/faultstest"
+)
+
+// TestRoundTrip ensures faults survive a conversion to a reason code and user
+// properties, and back.
+func TestRoundTrip(t *testing.T) {
+	table := []struct {
+		Error      error
+		ReasonCode faultsmqtt.ReasonCode
+		Delay      time.Duration
+	}{
+		{Error: faults.Bad(), ReasonCode: faultsmqtt.PayloadFormatInvalid},
+		{Error: faults.NotFound, ReasonCode: faultsmqtt.ImplementationSpecificError},
+		{Error: faults.PermissionDenied, ReasonCode: faultsmqtt.NotAuthorized},
+		{Error: faults.Unauthenticated, ReasonCode: faultsmqtt.BadUserNameOrPassword},
+		{Error: faults.FailedPrecondition(), ReasonCode: faultsmqtt.ImplementationSpecificError},
+		{Error: faults.Aborted(), ReasonCode: faultsmqtt.ImplementationSpecificError},
+		{Error: faults.ResourceExhausted(), ReasonCode: faultsmqtt.QuotaExceeded},
+		{
+			Error:      faults.Unavailable(3 * time.Second),
+			ReasonCode: faultsmqtt.ServerUnavailable,
+			Delay:      3 * time.Second,
+		},
+		{Error: faults.DeadlineExceeded, ReasonCode: faultsmqtt.ImplementationSpecificError},
+		{Error: faults.Unimplemented, ReasonCode: faultsmqtt.ImplementationSpecificError},
+		{Error: faults.Internal, ReasonCode: faultsmqtt.ImplementationSpecificError},
+		{Error: errors.New("boom"), ReasonCode: faultsmqtt.UnspecifiedError},
+	}
+
+	for i, test := range table {
+		code, reason, props := faultsmqtt.Encode(test.Error)
+		if code != test.ReasonCode {
+			t.Errorf("%d - expect reason code %#x, but got %#x", i, test.ReasonCode, code)
+		}
+		if expect := faults.Sanitize(test.Error).Error(); reason != expect {
+			t.Errorf("%d - expect reason %q, but got %q", i, expect, reason)
+		}
+
+		err := faultsmqtt.Decode(code, reason, props)
+		if c := faults.CodeOf(err); c != faults.CodeOf(test.Error) {
+			t.Errorf("%d - expect code %s, but got %s", i, faults.CodeOf(test.Error), c)
+		}
+		if delay := faults.RetryAdvice(err).Delay; delay != test.Delay {
+			t.Errorf("%d - expect retry delay %s, but got %s", i, test.Delay, delay)
+		}
+	}
+
+	if code, _, props := faultsmqtt.Encode(nil); code != faultsmqtt.Success || props != nil {
+		t.Errorf("expect success without properties, but got %#x %v", code, props)
+	}
+}
+
+// TestRoundTripDetails ensures the details of faults survive a round trip,
+// and that server faults don't leak their cause.
+func TestRoundTripDetails(t *testing.T) {
+	fault := faults.Permanent(faults.WithReason(
+		faults.Bad(&faults.FieldViolation{Field: "name", Description: "Required"}),
+		"example.com/NAME_REQUIRED",
+	))
+	err := faultsmqtt.Decode(faultsmqtt.Encode(fault))
+	bad, ok := faults.AsBad(err)
+	if !ok {
+		t.Fatalf("expect bad request, but got %v", err)
+	}
+	if len(bad.Violations) != 1 || bad.Violations[0].Field != "name" {
+		t.Errorf("expect violation of name, but got %v", bad.Violations)
+	}
+	if reason := faults.ReasonOf(err); reason != "NAME_REQUIRED" {
+		t.Errorf("expect reason NAME_REQUIRED, but got %q", reason)
+	}
+	if !faults.IsPermanent(err) {
+		t.Error("expect permanent fault")
+	}
+
+	_, reason, props := faultsmqtt.Encode(faults.WithInternal(errors.New("db password leaked")))
+	if reason != "internal error" {
+		t.Errorf("expect sanitized reason, but got %q", reason)
+	}
+	if err := faultsmqtt.Decode(faultsmqtt.UnspecifiedError, reason, props); err.Error() != "internal error" {
+		t.Errorf("expect sanitized fault, but got %q", err)
+	}
+}
+
+// TestRoundTripDebug ensures retry delays survive a round trip in debug mode,
+// where faults carry a DebugInfo.
+func TestRoundTripDebug(t *testing.T) {
+	faults.SetDebug(true)
+	defer faults.SetDebug(false)
+
+	fault := faults.Build(faults.CodeResourceExhausted).Quota("user:42", "Daily limit reached").Retryable(30 * time.Second).Err()
+	err := faultsmqtt.Decode(faultsmqtt.Encode(fault))
+	if !faults.IsResourceExhausted(err) {
+		t.Fatalf("expect resource exhausted, but got %v", err)
+	}
+	if delay := faults.RetryAdvice(err).Delay; delay != 30*time.Second {
+		t.Errorf("expect retry delay 30s, but got %s", delay)
+	}
+}
+
+// TestDecode ensures reason codes that were not produced by Encode are
+// classified too.
+func TestDecode(t *testing.T) {
+	table := []struct {
+		ReasonCode faultsmqtt.ReasonCode
+		Code       faults.Code
+	}{
+		{ReasonCode: faultsmqtt.Success, Code: faults.CodeOK},
+		{ReasonCode: 0x10, Code: faults.CodeOK},
+		{ReasonCode: faultsmqtt.UnspecifiedError, Code: faults.CodeUnknown},
+		{ReasonCode: faultsmqtt.ImplementationSpecificError, Code: faults.CodeUnknown},
+		{ReasonCode: faultsmqtt.TopicNameInvalid, Code: faults.CodeBad},
+		{ReasonCode: faultsmqtt.BadAuthenticationMethod, Code: faults.CodeUnauthenticated},
+		{ReasonCode: faultsmqtt.Banned, Code: faults.CodePermissionDenied},
+		{ReasonCode: faultsmqtt.ServerBusy, Code: faults.CodeUnavailable},
+		{ReasonCode: faultsmqtt.MessageRateTooHigh, Code: faults.CodeResourceExhausted},
+		{ReasonCode: faultsmqtt.QoSNotSupported, Code: faults.CodeUnimplemented},
+		{ReasonCode: faultsmqtt.KeepAliveTimeout, Code: faults.CodeDeadlineExceeded},
+		{ReasonCode: faultsmqtt.PacketIdentifierInUse, Code: faults.CodeAborted},
+		{ReasonCode: faultsmqtt.PacketIdentifierNotFound, Code: faults.CodeNotFound},
+		{ReasonCode: 0xFF, Code: faults.CodeUnknown},
+	}
+
+	for i, test := range table {
+		err := faultsmqtt.Decode(test.ReasonCode, "", nil)
+		if code := faults.CodeOf(err); code != test.Code {
+			t.Errorf("%d - expect code %s, but got %s", i, test.Code, code)
+		}
+	}
+
+	props := []faultsmqtt.Property{{Key: faultsmqtt.CodeProperty, Value: "NOT_A_CODE"}}
+	err := faultsmqtt.Decode(faultsmqtt.NotAuthorized, "denied", props)
+	if !faults.IsPermissionDenied(err) {
+		t.Errorf("expect unknown code property to be ignored, but got %s", faults.CodeOf(err))
+	}
+}
+
+// TestConformance ensures reason codes and user properties follow the
+// semantics of the core.
+func TestConformance(t *testing.T) {
+	faultstest.ConformanceSuite{
+		RoundTrip: func(err error) error {
+			return faultsmqtt.Decode(faultsmqtt.Encode(err))
+		},
+		RetryInfo: true,
+	}.Run(t)
+}
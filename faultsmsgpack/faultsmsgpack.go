@@ -0,0 +1,131 @@
+// Package faultsmsgpack converts faults to MessagePack, and back, for event
+// buses and queues whose payloads are encoded with MessagePack, e.g. to
+// carry typed failures in negative acknowledgements.
+//
+// It lives in a module of its own, so the core doesn't depend on a
+// MessagePack library (github.com/vmihailenco/msgpack).
+//
+// A Fault follows the schema of the JSON representation of faults, with the
+// same keys and the same values (see faults.EncodeJSON), and can be a member
+// of larger payloads:
+//
+//	type Nack struct {
+//		ID    string               `msgpack:"id"`
+//		Fault *faultsmsgpack.Fault `msgpack:"fault,omitempty"`
+//	}
+//
+//	msg := Nack{ID: job.ID, Fault: faultsmsgpack.NewFault(faults.Sanitize(err))}
+package faultsmsgpack
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/deixis/faults"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// A Fault is the MessagePack representation of a fault.
+type Fault struct {
+	// Version is the version of the representation (see faults.SchemaVersion).
+	Version int `msgpack:"version" json:"version"`
+	// Code is the name of the category of the fault, e.g. "NOT_FOUND".
+	Code string `msgpack:"code" json:"code"`
+	// Message is the message of the fault.
+	Message string `msgpack:"message,omitempty" json:"message,omitempty"`
+	// Cause is the message of the error wrapped by the fault, if any.
+	Cause string `msgpack:"cause,omitempty" json:"cause,omitempty"`
+	// Violations lists the violations carried by the fault.
+	Violations []Violation `msgpack:"violations,omitempty" json:"violations,omitempty"`
+	// RetryDelay is the delay advised before retrying, in milliseconds.
+	RetryDelay int64 `msgpack:"retryDelay,omitempty" json:"retryDelay,omitempty"`
+	// Reason is the reason attached to the fault, if any (see
+	// faults.ErrorInfo).
+	Reason string `msgpack:"reason,omitempty" json:"reason,omitempty"`
+	// Domain is the domain of the reason, if it is scoped (see
+	// faults.ErrorInfo).
+	Domain string `msgpack:"domain,omitempty" json:"domain,omitempty"`
+	// Metadata is the metadata attached to the reason of the fault, if any.
+	Metadata map[string]string `msgpack:"metadata,omitempty" json:"metadata,omitempty"`
+	// Permanent reports whether the fault must not be retried, whatever its
+	// category (see faults.Permanent).
+	Permanent bool `msgpack:"permanent,omitempty" json:"permanent,omitempty"`
+	// Challenges lists the authentication challenges of the fault, as written
+	// in a WWW-Authenticate header.
+	Challenges []string `msgpack:"challenges,omitempty" json:"challenges,omitempty"`
+	// Truncated reports whether details of the fault were dropped to fit the
+	// limits of the encoding (see faults.Limits).
+	Truncated bool `msgpack:"truncated,omitempty" json:"truncated,omitempty"`
+}
+
+// A Violation describes a single violation of a fault. Only the fields of
+// the corresponding Go type are set, e.g. Field, Code, Description, Template
+// and Params for a faults.FieldViolation.
+type Violation struct {
+	Field       string            `msgpack:"field,omitempty" json:"field,omitempty"`
+	Code        string            `msgpack:"code,omitempty" json:"code,omitempty"`
+	Type        string            `msgpack:"type,omitempty" json:"type,omitempty"`
+	Subject     string            `msgpack:"subject,omitempty" json:"subject,omitempty"`
+	Resource    string            `msgpack:"resource,omitempty" json:"resource,omitempty"`
+	Description string            `msgpack:"description,omitempty" json:"description,omitempty"`
+	Template    string            `msgpack:"template,omitempty" json:"template,omitempty"`
+	Params      map[string]string `msgpack:"params,omitempty" json:"params,omitempty"`
+}
+
+// NewFault returns the representation of `err`. It returns nil when `err` is
+// nil.
+//
+// Like with faults.EncodeJSON, the message of `err` is represented as is.
+// Faults crossing a trust boundary should be sanitized first (see
+// faults.Sanitize).
+func NewFault(err error) *Fault {
+	if err == nil {
+		return nil
+	}
+	f := &Fault{}
+	data, _ := faults.EncodeJSON(err)
+	if json.Unmarshal(data, f) != nil {
+		return &Fault{Version: faults.SchemaVersion, Code: faults.CodeOf(err).String(), Message: err.Error()}
+	}
+	return f
+}
+
+// Err converts `f` back to the fault it describes. Categories that are not
+// registered in this process are converted to plain errors carrying the
+// original message.
+func (f *Fault) Err() error {
+	if f == nil {
+		return nil
+	}
+	data, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	return faults.DecodeJSON(data)
+}
+
+// Marshal returns the MessagePack encoding of `err`. Maps are encoded with
+// sorted keys, so the encoding of a fault is always the same.
+//
+// It returns the encoding of nil when `err` is nil.
+func Marshal(err error) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetSortMapKeys(true)
+	if err := enc.Encode(NewFault(err)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal converts the MessagePack encoding produced by Marshal back to a
+// fault. It returns nil when `data` is the encoding of nil, and an Unknown
+// error when `data` is malformed.
+func Unmarshal(data []byte) error {
+	var f *Fault
+	if err := msgpack.Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("faultsmsgpack: malformed encoding: %w", err)
+	}
+	return f.Err()
+}
@@ -0,0 +1,149 @@
+package faultsmsgpack_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultsmsgpack"
+	"github.com/deixis/faults/faultstest"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// TestRoundTrip ensures faults survive their MessagePack encoding.
+func TestRoundTrip(t *testing.T) {
+	table := []error{
+		faults.Bad(&faults.FieldViolation{Field: "email", Code: faults.ViolationRequired, Description: "is required"}),
+		faults.WithNotFound(errors.New("no such user")),
+		faults.WithUnauthenticated(errors.New("token expired"), faults.Challenge{Scheme: "Bearer", Realm: "api"}),
+		faults.Aborted(&faults.ConflictViolation{Resource: "order:42", Description: "version mismatch"}),
+		faults.Unavailable(1500 * time.Millisecond),
+		faults.Permanent(faults.WithErrorInfo(faults.Internal, "DB_DOWN", map[string]string{"db": "users"})),
+		errors.New("boom"),
+	}
+
+	for i, fault := range table {
+		data, err := faultsmsgpack.Marshal(fault)
+		if err != nil {
+			t.Fatalf("%d - expect fault to be encoded, but got %s", i, err)
+		}
+
+		err = faultsmsgpack.Unmarshal(data)
+		if err.Error() != fault.Error() {
+			t.Errorf("%d - expect message %q, but got %q", i, fault, err)
+		}
+		if code := faults.CodeOf(err); code != faults.CodeOf(fault) {
+			t.Errorf("%d - expect code %s, but got %s", i, faults.CodeOf(fault), code)
+		}
+		if expect, permanent := faults.IsPermanent(fault), faults.IsPermanent(err); permanent != expect {
+			t.Errorf("%d - expect permanent %t, but got %t", i, expect, permanent)
+		}
+		if expect, challenges := faults.ChallengesOf(fault), faults.ChallengesOf(err); !reflect.DeepEqual(expect, challenges) {
+			t.Errorf("%d - expect challenges %v, but got %v", i, expect, challenges)
+		}
+	}
+
+	data, _ := faultsmsgpack.Marshal(nil)
+	if err := faultsmsgpack.Unmarshal(data); err != nil {
+		t.Errorf("expect nil error, but got %v", err)
+	}
+	if err := faultsmsgpack.Unmarshal([]byte{0xc1}); err == nil || faults.CodeOf(err) != faults.CodeUnknown {
+		t.Errorf("expect malformed encoding to be rejected, but got %v", err)
+	}
+}
+
+// TestTemplate ensures the templates of violations and their parameters
+// survive the MessagePack encoding.
+func TestTemplate(t *testing.T) {
+	fault := faults.Bad(faults.FieldTemplate("name", "must be at most {max} characters", map[string]string{"max": "50"}))
+
+	data, _ := faultsmsgpack.Marshal(fault)
+	e, ok := faults.AsBad(faultsmsgpack.Unmarshal(data))
+	if !ok {
+		t.Fatalf("expect bad request")
+	}
+	expect, _ := faults.AsBad(fault)
+	if !reflect.DeepEqual(e.Violations, expect.Violations) {
+		t.Errorf("expect violations %v, but got %v", expect.Violations, e.Violations)
+	}
+}
+
+// TestDomain ensures the domains of reasons are round-tripped.
+func TestDomain(t *testing.T) {
+	fault := faults.WithReason(faults.Internal, "db.example.com/DB_DOWN")
+
+	data, _ := faultsmsgpack.Marshal(fault)
+	if err := faultsmsgpack.Unmarshal(data); faults.ReasonOf(err) != "DB_DOWN" || faults.DomainOf(err) != "db.example.com" {
+		t.Errorf("expect reason db.example.com/DB_DOWN, but got %q in %q", faults.ReasonOf(err), faults.DomainOf(err))
+	}
+}
+
+// TestNack ensures faults can be members of larger payloads.
+func TestNack(t *testing.T) {
+	type nack struct {
+		ID    string               `msgpack:"id"`
+		Fault *faultsmsgpack.Fault `msgpack:"fault,omitempty"`
+	}
+
+	fault := faults.ResourceExhausted(&faults.QuotaViolation{Subject: "tenant:acme", Description: "too many jobs"})
+	data, err := msgpack.Marshal(nack{ID: "job-1", Fault: faultsmsgpack.NewFault(fault)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var n nack
+	if err := msgpack.Unmarshal(data, &n); err != nil {
+		t.Fatal(err)
+	}
+	e, ok := faults.AsResourceExhausted(n.Fault.Err())
+	if !ok {
+		t.Fatalf("expect resource exhausted, but got %v", n.Fault.Err())
+	}
+	expect, _ := faults.AsResourceExhausted(fault)
+	if !reflect.DeepEqual(e.Violations, expect.Violations) {
+		t.Errorf("expect violations %v, but got %v", expect.Violations, e.Violations)
+	}
+
+	// The keys are those of the JSON representation
+	var m map[string]interface{}
+	msgpack.Unmarshal(data, &m)
+	f, _ := m["fault"].(map[string]interface{})
+	if f["code"] != "RESOURCE_EXHAUSTED" || f["version"] == nil || f["violations"] == nil {
+		t.Errorf("expect keys of the JSON representation, but got %v", f)
+	}
+}
+
+// TestMarshal ensures the encoding of a fault is always the same.
+func TestMarshal(t *testing.T) {
+	metadata := map[string]string{}
+	for _, k := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		metadata[k] = k
+	}
+	fault := faults.WithErrorInfo(faults.NotFound, "X", metadata)
+
+	a, _ := faultsmsgpack.Marshal(fault)
+	for i := 0; i < 10; i++ {
+		if b, _ := faultsmsgpack.Marshal(fault); string(a) != string(b) {
+			t.Fatal("expect encoding to be deterministic")
+		}
+	}
+}
+
+// TestConformance ensures the MessagePack encoding follows the semantics of
+// the core.
+func TestConformance(t *testing.T) {
+	faultstest.ConformanceSuite{
+		RoundTrip: func(err error) error {
+			data, _ := faultsmsgpack.Marshal(err)
+			return faultsmsgpack.Unmarshal(data)
+		},
+		Encode: func(err error) []byte {
+			data, _ := faultsmsgpack.Marshal(err)
+			return data
+		},
+		RetryInfo: true,
+		Details:   true,
+	}.Run(t)
+}
@@ -0,0 +1,135 @@
+// Package faultsnats converts faults to NATS micro service error responses,
+// and back.
+//
+// The micro framework describes an error response with a code and a
+// description header. The code is the HTTP status of the fault category (see
+// faults.HTTPStatus), which keeps responses readable by any NATS client, while
+// the exact category and the retry delay are carried by additional headers so
+// a Go requester can restore the original fault.
+package faultsnats
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/deixis/faults"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/micro"
+)
+
+const (
+	// CodeHeader is the header carrying the exact fault code of an error
+	// response, e.g. "NOT_FOUND".
+	CodeHeader = "Nats-Fault-Code"
+	// RetryDelayHeader is the header carrying the retry delay of an error
+	// response, in milliseconds.
+	RetryDelayHeader = "Nats-Fault-Retry-Delay"
+)
+
+// Respond responds to `req` with the error response matching `err`.
+//
+// Example:
+//
+//	func (s *Service) Handle(req micro.Request) {
+//		user, err := s.users.Get(string(req.Data()))
+//		if err != nil {
+//			faultsnats.Respond(req, err)
+//			return
+//		}
+//		req.RespondJSON(user)
+//	}
+func Respond(req micro.Request, err error) error {
+	code, description, headers := Encode(err)
+	return req.Error(code, description, nil, micro.WithHeaders(headers))
+}
+
+// Encode returns the code, description and additional headers of the error
+// response matching `err`, for callers that build the response themselves.
+// Server faults are described by their category only (see faults.Sanitize).
+func Encode(err error) (code, description string, headers micro.Headers) {
+	c := faults.CodeOf(err)
+	headers = micro.Headers{CodeHeader: []string{c.String()}}
+	if advice := faults.RetryAdvice(err); advice.Delay > 0 {
+		headers[RetryDelayHeader] = []string{strconv.FormatInt(advice.Delay.Milliseconds(), 10)}
+	}
+
+	description = c.String()
+	if err != nil {
+		if msg := faults.Sanitize(err).Error(); msg != "" {
+			description = msg
+		}
+	}
+	return strconv.Itoa(faults.HTTPStatus(err)), description, headers
+}
+
+// FromMsg converts the error response `msg` back to a fault. It returns nil
+// when `msg` is not an error response.
+//
+// Responses produced by Respond are restored to their exact category. Other
+// error responses are classified from their code, when it is an HTTP status
+// (see faults.CodeOfHTTPStatus), or as Unknown otherwise.
+func FromMsg(msg *nats.Msg) error {
+	if msg == nil || msg.Header == nil {
+		return nil
+	}
+	status := msg.Header.Get(micro.ErrorCodeHeader)
+	description := msg.Header.Get(micro.ErrorHeader)
+	if status == "" && description == "" {
+		return nil
+	}
+	if description == "" {
+		description = "service error " + status
+	}
+	parent := errors.New(description)
+
+	code, err := faults.ParseCode(msg.Header.Get(CodeHeader))
+	if err != nil {
+		code = faults.CodeUnknown
+		if n, err := strconv.Atoi(status); err == nil {
+			code = faults.CodeOfHTTPStatus(n)
+		}
+	}
+
+	var delay time.Duration
+	if ms, err := strconv.ParseInt(msg.Header.Get(RetryDelayHeader), 10, 64); err == nil && ms > 0 {
+		delay = time.Duration(ms) * time.Millisecond
+	}
+
+	switch code {
+	case faults.CodeUnavailable:
+		return faults.WithUnavailable(parent, delay)
+	case faults.CodeResourceExhausted:
+		err := faults.WithResourceExhausted(parent)
+		if e, ok := faults.AsResourceExhausted(err); ok {
+			e.RetryInfo.RetryDelay = delay
+		}
+		return err
+	}
+	if d, ok := faults.Describe(code); ok && d.New != nil {
+		return d.New(parent)
+	}
+	return parent
+}
+
+// FromReply converts the outcome of a NATS request to a fault. It is meant to
+// be used with the values returned by `nats.Conn.Request`:
+//
+//	msg, err := nc.Request("users.get", []byte(id), time.Second)
+//	if err := faultsnats.FromReply(msg, err); err != nil {
+//		return err
+//	}
+//
+// Request timeouts are converted to DeadlineExceeded, and requests without
+// responders to Unavailable. Other request errors are returned as is.
+func FromReply(msg *nats.Msg, err error) error {
+	switch {
+	case err == nil:
+		return FromMsg(msg)
+	case errors.Is(err, nats.ErrTimeout):
+		return faults.WithDeadlineExceeded(err)
+	case errors.Is(err, nats.ErrNoResponders):
+		return faults.WithUnavailable(err, 0)
+	}
+	return err
+}
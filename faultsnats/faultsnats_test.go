@@ -0,0 +1,185 @@
+package faultsnats_test
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultsnats"
+	"github.com/deixis/faults/faultstest"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/micro"
+)
+
+// TestRoundTrip ensures faults survive a micro error response.
+func TestRoundTrip(t *testing.T) {
+	table := []struct {
+		Error  error
+		Status string
+		Delay  time.Duration
+	}{
+		{Error: faults.Bad(), Status: "400"},
+		{Error: faults.NotFound, Status: "404"},
+		{Error: faults.PermissionDenied, Status: "403"},
+		{Error: faults.Unauthenticated, Status: "401"},
+		{Error: faults.FailedPrecondition(), Status: "400"},
+		{Error: faults.Aborted(), Status: "409"},
+		{Error: faults.Unavailable(2 * time.Second), Status: "503", Delay: 2 * time.Second},
+		{Error: faults.DeadlineExceeded, Status: "504"},
+		{Error: faults.Unimplemented, Status: "501"},
+		{Error: faults.Internal, Status: "500"},
+		{Error: errors.New("boom"), Status: "500"},
+	}
+
+	for i, test := range table {
+		req := &recorder{}
+		if err := faultsnats.Respond(req, test.Error); err != nil {
+			t.Fatalf("%d - expect response to be sent, but got %s", i, err)
+		}
+		if status := req.msg.Header.Get(micro.ErrorCodeHeader); status != test.Status {
+			t.Errorf("%d - expect status %s, but got %s", i, test.Status, status)
+		}
+		if desc, expect := req.msg.Header.Get(micro.ErrorHeader), faults.Sanitize(test.Error).Error(); desc != expect {
+			t.Errorf("%d - expect description %q, but got %q", i, expect, desc)
+		}
+
+		err := faultsnats.FromMsg(req.msg)
+		if code := faults.CodeOf(err); code != faults.CodeOf(test.Error) {
+			t.Errorf("%d - expect code %s, but got %s", i, faults.CodeOf(test.Error), code)
+		}
+		if delay := faults.RetryAdvice(err).Delay; delay != test.Delay {
+			t.Errorf("%d - expect retry delay %s, but got %s", i, test.Delay, delay)
+		}
+	}
+}
+
+// TestRoundTripDebug ensures retry delays survive a round trip in debug mode,
+// where faults carry a DebugInfo.
+func TestRoundTripDebug(t *testing.T) {
+	faults.SetDebug(true)
+	defer faults.SetDebug(false)
+
+	req := &recorder{}
+	fault := faults.Build(faults.CodeResourceExhausted).Quota("user:42", "Daily limit reached").Retryable(30 * time.Second).Err()
+	if err := faultsnats.Respond(req, fault); err != nil {
+		t.Fatalf("expect response to be sent, but got %s", err)
+	}
+	err := faultsnats.FromMsg(req.msg)
+	if !faults.IsResourceExhausted(err) {
+		t.Fatalf("expect resource exhausted, but got %v", err)
+	}
+	if delay := faults.RetryAdvice(err).Delay; delay != 30*time.Second {
+		t.Errorf("expect retry delay 30s, but got %s", delay)
+	}
+}
+
+// TestEncodeSanitize ensures the descriptions of server faults don't leak
+// their cause.
+func TestEncodeSanitize(t *testing.T) {
+	_, description, _ := faultsnats.Encode(faults.WithInternal(errors.New("db password leaked")))
+	if description != "internal error" {
+		t.Errorf("expect sanitized description, but got %q", description)
+	}
+	if _, description, _ := faultsnats.Encode(faults.Bad()); description != faults.Bad().Error() {
+		t.Errorf("expect client fault description, but got %q", description)
+	}
+}
+
+// TestFromMsg ensures error responses that were not produced by Respond are
+// classified too.
+func TestFromMsg(t *testing.T) {
+	table := []struct {
+		Msg  *nats.Msg
+		Code faults.Code
+	}{
+		{Msg: nil, Code: faults.CodeOK},
+		{Msg: &nats.Msg{Data: []byte("ok")}, Code: faults.CodeOK},
+		{Msg: errorMsg("404", "no such user"), Code: faults.CodeNotFound},
+		{Msg: errorMsg("429", "slow down"), Code: faults.CodeResourceExhausted},
+		{Msg: errorMsg("503", "maintenance"), Code: faults.CodeUnavailable},
+		{Msg: errorMsg("418", "teapot"), Code: faults.CodeBad},
+		{Msg: errorMsg("E_FOO", "foo"), Code: faults.CodeUnknown},
+	}
+
+	for i, test := range table {
+		err := faultsnats.FromMsg(test.Msg)
+		if code := faults.CodeOf(err); code != test.Code {
+			t.Errorf("%d - expect code %s, but got %s", i, test.Code, code)
+		}
+	}
+}
+
+// TestFromReply ensures request failures are classified.
+func TestFromReply(t *testing.T) {
+	table := []struct {
+		Msg   *nats.Msg
+		Error error
+		Code  faults.Code
+	}{
+		{Msg: &nats.Msg{}, Code: faults.CodeOK},
+		{Msg: errorMsg("404", "no such user"), Code: faults.CodeNotFound},
+		{Error: nats.ErrTimeout, Code: faults.CodeDeadlineExceeded},
+		{Error: nats.ErrNoResponders, Code: faults.CodeUnavailable},
+		{Error: nats.ErrConnectionClosed, Code: faults.CodeUnknown},
+	}
+
+	for i, test := range table {
+		err := faultsnats.FromReply(test.Msg, test.Error)
+		if code := faults.CodeOf(err); code != test.Code {
+			t.Errorf("%d - expect code %s, but got %s", i, test.Code, code)
+		}
+		if test.Error != nil && !errors.Is(err, test.Error) {
+			t.Errorf("%d - expect request error to be wrapped", i)
+		}
+	}
+}
+
+func errorMsg(status, description string) *nats.Msg {
+	return &nats.Msg{Header: nats.Header{
+		micro.ErrorCodeHeader: []string{status},
+		micro.ErrorHeader:     []string{description},
+	}}
+}
+
+// recorder is a micro.Request that records the error response.
+type recorder struct {
+	micro.Request
+	msg *nats.Msg
+}
+
+func (r *recorder) Error(code, description string, data []byte, opts ...micro.RespondOpt) error {
+	r.msg = &nats.Msg{Data: data, Header: nats.Header{
+		micro.ErrorCodeHeader: []string{code},
+		micro.ErrorHeader:     []string{description},
+	}}
+	for _, opt := range opts {
+		opt(r.msg)
+	}
+	return nil
+}
+
+// TestConformance ensures micro error responses follow the semantics of the
+// core.
+func TestConformance(t *testing.T) {
+	respond := func(err error) *nats.Msg {
+		req := &recorder{}
+		faultsnats.Respond(req, err)
+		return req.msg
+	}
+
+	faultstest.ConformanceSuite{
+		RoundTrip: func(err error) error {
+			if err == nil {
+				return nil
+			}
+			return faultsnats.FromMsg(respond(err))
+		},
+		Status: func(err error) int {
+			status, _ := strconv.Atoi(respond(err).Header.Get(micro.ErrorCodeHeader))
+			return status
+		},
+		RetryInfo: true,
+	}.Run(t)
+}
@@ -0,0 +1,60 @@
+// Package faultspb provides Protocol Buffers messages describing faults (see
+// faults.proto), so they can be embedded in messages of their own, e.g. the
+// results of asynchronous jobs, and not only in gRPC statuses:
+//
+//	message JobResult {
+//	  string id = 1;
+//	  deixis.faults.v1.Fault fault = 2;
+//	}
+//
+// A Fault carries the category, the message, the violations, the reason and
+// its domain, the retry delay and the challenges of a fault. Its field numbers are those of
+// the binary encoding of faults (see faults.Encode), so both representations
+// are interchangeable.
+//
+// The metadata of a Fault is a map, which proto.Marshal writes in any order.
+// Messages meant to be byte-stable, e.g. compared by contract tests, should be
+// marshalled deterministically:
+//
+//	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+package faultspb
+
+import (
+	"github.com/deixis/faults"
+	"google.golang.org/protobuf/proto"
+)
+
+// ToProto returns the message describing `err`. It returns nil when `err` is
+// nil.
+//
+// Like with faults.Encode, the message of `err` is described as is. Faults
+// crossing a trust boundary should be sanitized first (see faults.Sanitize).
+func ToProto(err error) *Fault {
+	if err == nil {
+		return nil
+	}
+	data, _ := faults.Encode(err)
+	f := &Fault{}
+	if proto.Unmarshal(data, f) != nil {
+		// The binary encoding is a valid Fault message by design, so this
+		// would only happen if both definitions were out of sync
+		return &Fault{Code: faults.CodeOf(err).String(), Message: err.Error()}
+	}
+	return f
+}
+
+// FromProto converts `f` back to the fault it describes. Categories that are
+// not registered in this process are converted to plain errors carrying the
+// original message.
+//
+// It returns nil when `f` is nil or empty.
+func FromProto(f *Fault) error {
+	if f == nil {
+		return nil
+	}
+	data, err := proto.Marshal(f)
+	if err != nil {
+		return err
+	}
+	return faults.Decode(data)
+}
@@ -0,0 +1,153 @@
+package faultspb_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultspb"
+	"github.com/deixis/faults/faultstest"
+	"google.golang.org/protobuf/proto"
+)
+
+// TestRoundTrip ensures faults survive a conversion to a message, and its
+// wire format.
+func TestRoundTrip(t *testing.T) {
+	table := []error{
+		faults.Bad(&faults.FieldViolation{Field: "email", Code: faults.ViolationRequired, Description: "is required"}),
+		faults.WithNotFound(errors.New("no such user")),
+		faults.WithUnauthenticated(errors.New("token expired"), faults.Challenge{Scheme: "Bearer", Realm: "api"}),
+		faults.FailedPrecondition(&faults.PreconditionViolation{Type: "TOS", Subject: "user:42", Description: "not accepted"}),
+		faults.Aborted(&faults.ConflictViolation{Resource: "order:42", Description: "version mismatch"}),
+		faults.Unavailable(1500 * time.Millisecond),
+		faults.WithErrorInfo(faults.Internal, "DB_DOWN", map[string]string{"db": "users"}),
+		errors.New("boom"),
+	}
+
+	for i, fault := range table {
+		data, err := proto.Marshal(faultspb.ToProto(fault))
+		if err != nil {
+			t.Fatalf("%d - expect message to be marshalled, but got %s", i, err)
+		}
+		var f faultspb.Fault
+		if err := proto.Unmarshal(data, &f); err != nil {
+			t.Fatalf("%d - expect message to be unmarshalled, but got %s", i, err)
+		}
+
+		err = faultspb.FromProto(&f)
+		if err.Error() != fault.Error() {
+			t.Errorf("%d - expect message %q, but got %q", i, fault, err)
+		}
+		if code := faults.CodeOf(err); code != faults.CodeOf(fault) {
+			t.Errorf("%d - expect code %s, but got %s", i, faults.CodeOf(fault), code)
+		}
+		if expect, reason := faults.ReasonOf(fault), faults.ReasonOf(err); reason != expect {
+			t.Errorf("%d - expect reason %q, but got %q", i, expect, reason)
+		}
+	}
+
+	if f := faultspb.ToProto(nil); f != nil {
+		t.Errorf("expect nil message, but got %v", f)
+	}
+	if err := faultspb.FromProto(nil); err != nil {
+		t.Errorf("expect nil error, but got %v", err)
+	}
+}
+
+// TestToProto ensures messages describe the details of faults.
+func TestToProto(t *testing.T) {
+	err := faults.Permanent(faults.WithErrorInfo(faults.Bad(
+		&faults.FieldViolation{Field: "email", Code: faults.ViolationRequired, Description: "is required"},
+	), "INVALID_FORM", map[string]string{"form": "signup"}))
+
+	expect := &faultspb.Fault{
+		Code:     "BAD_REQUEST",
+		Message:  "is required",
+		Reason:   "INVALID_FORM",
+		Metadata: map[string]string{"form": "signup"},
+		Violations: []*faultspb.Violation{
+			{Field: "email", Code: "REQUIRED", Description: "is required"},
+		},
+		Permanent: true,
+		Version:   faults.SchemaVersion,
+	}
+	if f := faultspb.ToProto(err); !proto.Equal(f, expect) {
+		t.Errorf("expect %v, but got %v", expect, f)
+	}
+}
+
+// TestToProtoDomain ensures the domains of reasons are described, and
+// restored.
+func TestToProtoDomain(t *testing.T) {
+	f := faultspb.ToProto(faults.WithReason(faults.NotFound, "accounts.example.com/USER_NOT_FOUND"))
+	if f.Reason != "USER_NOT_FOUND" || f.Domain != "accounts.example.com" {
+		t.Errorf("expect reason USER_NOT_FOUND of accounts.example.com, but got %q of %q", f.Reason, f.Domain)
+	}
+	if domain := faults.DomainOf(faultspb.FromProto(f)); domain != "accounts.example.com" {
+		t.Errorf("expect domain accounts.example.com, but got %q", domain)
+	}
+}
+
+// TestToProtoTemplate ensures the templates of violations are described,
+// and restored.
+func TestToProtoTemplate(t *testing.T) {
+	params := map[string]string{"max": "50"}
+	f := faultspb.ToProto(faults.Bad(faults.FieldTemplate("name", "must be at most {max} characters", params)))
+	if len(f.Violations) != 1 {
+		t.Fatalf("expect 1 violation, but got %v", f.Violations)
+	}
+	if v := f.Violations[0]; v.Template != "must be at most {max} characters" || !reflect.DeepEqual(v.Params, params) {
+		t.Errorf("expect template with params %v, but got %q with %v", params, v.Template, v.Params)
+	}
+
+	e, ok := faults.AsBad(faultspb.FromProto(f))
+	if !ok || len(e.Violations) != 1 {
+		t.Fatalf("expect bad request with 1 violation, but got %v", e)
+	}
+	if v := e.Violations[0]; v.Template != "must be at most {max} characters" || !reflect.DeepEqual(v.Params, params) {
+		t.Errorf("expect template with params %v, but got %q with %v", params, v.Template, v.Params)
+	}
+}
+
+// TestEncoding ensures messages and the binary encoding of faults are
+// interchangeable.
+func TestEncoding(t *testing.T) {
+	fault := faults.ResourceExhausted(&faults.QuotaViolation{Subject: "project:42", Description: "daily limit"})
+
+	data, _ := faults.Encode(fault)
+	var f faultspb.Fault
+	if err := proto.Unmarshal(data, &f); err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(&f, faultspb.ToProto(fault)) {
+		t.Errorf("expect encoding to be a Fault message, but got %v", &f)
+	}
+
+	data, _ = proto.Marshal(faultspb.ToProto(fault))
+	err := faults.Decode(data)
+	e, ok := faults.AsResourceExhausted(err)
+	if !ok {
+		t.Fatalf("expect resource exhausted, but got %v", err)
+	}
+	expect, _ := faults.AsResourceExhausted(fault)
+	if !reflect.DeepEqual(e.Violations, expect.Violations) {
+		t.Errorf("expect violations %v, but got %v", expect.Violations, e.Violations)
+	}
+}
+
+// TestConformance ensures messages follow the semantics of the core.
+func TestConformance(t *testing.T) {
+	faultstest.ConformanceSuite{
+		RoundTrip: func(err error) error {
+			return faultspb.FromProto(faultspb.ToProto(err))
+		},
+		Encode: func(err error) []byte {
+			data, _ := proto.MarshalOptions{Deterministic: true}.Marshal(faultspb.ToProto(err))
+			return data
+		},
+		RetryInfo: true,
+		Details:   true,
+	}.Run(t)
+}
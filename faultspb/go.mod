@@ -0,0 +1,12 @@
+module github.com/deixis/faults/faultspb
+
+go 1.22.0
+
+replace github.com/deixis/faults => ../
+
+require (
+	github.com/deixis/faults v0.0.0-00010101000000-000000000000
+	google.golang.org/protobuf v1.36.0
+)
+
+require github.com/google/go-cmp v0.6.0 // indirect
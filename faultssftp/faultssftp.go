@@ -0,0 +1,128 @@
+// Package faultssftp classifies the errors returned by SFTP clients and
+// servers built with github.com/pkg/sftp.
+package faultssftp
+
+import (
+	"errors"
+	"os"
+
+	"github.com/deixis/faults"
+	"github.com/pkg/sftp"
+)
+
+// Status codes defined by the SFTP protocol. pkg/sftp only exports the ones
+// of version 3, while servers may send the ones of later versions.
+const (
+	fxNoSuchFile          = 2
+	fxPermissionDenied    = 3
+	fxBadMessage          = 5
+	fxNoConnection        = 6
+	fxConnectionLost      = 7
+	fxOpUnsupported       = 8
+	fxNoSuchPath          = 10
+	fxFileAlreadyExists   = 11
+	fxWriteProtect        = 12
+	fxNoMedia             = 13
+	fxNoSpaceOnFilesystem = 14
+	fxQuotaExceeded       = 15
+	fxLockConflict        = 17
+	fxDirNotEmpty         = 18
+	fxNotADirectory       = 19
+	fxInvalidFilename     = 20
+	fxLinkLoop            = 21
+	fxFileIsADirectory    = 24
+)
+
+// Classifier classifies the status errors of the SFTP protocol, as well as
+// the standard errors pkg/sftp converts them to:
+//
+//   - no such file or path is classified as NotFound
+//   - permission denied and write-protected are classified as
+//     PermissionDenied
+//   - file already exists and lock conflicts are classified as Aborted
+//   - no space left and quota exceeded are classified as ResourceExhausted
+//   - a non-empty directory, or a file that is (not) a directory, is
+//     classified as FailedPrecondition
+//   - bad messages and invalid file names are classified as Bad
+//   - lost connections (and no connection) are classified as Unavailable
+//   - unsupported operations are classified as Unimplemented
+//
+// It can be registered with faults.RegisterClassifier, or applied explicitly
+// with FromSFTP.
+var Classifier faults.Classifier = faults.ClassifierFunc(classify)
+
+func classify(err error) (faults.Code, bool) {
+	var status *sftp.StatusError
+	if errors.As(err, &status) {
+		return statusCode(status.Code)
+	}
+
+	switch {
+	case errors.Is(err, os.ErrNotExist), errors.Is(err, sftp.ErrSSHFxNoSuchFile):
+		return faults.CodeNotFound, true
+	case errors.Is(err, os.ErrPermission), errors.Is(err, sftp.ErrSSHFxPermissionDenied):
+		return faults.CodePermissionDenied, true
+	case errors.Is(err, os.ErrExist):
+		return faults.CodeAborted, true
+	case errors.Is(err, sftp.ErrSSHFxConnectionLost), errors.Is(err, sftp.ErrSSHFxNoConnection):
+		return faults.CodeUnavailable, true
+	case errors.Is(err, sftp.ErrSSHFxOpUnsupported):
+		return faults.CodeUnimplemented, true
+	case errors.Is(err, sftp.ErrSSHFxBadMessage):
+		return faults.CodeBad, true
+	}
+	return faults.CodeUnknown, false
+}
+
+// statusCode returns the category of the SFTP status code `code`.
+func statusCode(code uint32) (faults.Code, bool) {
+	switch code {
+	case fxNoSuchFile, fxNoSuchPath, fxNoMedia:
+		return faults.CodeNotFound, true
+	case fxPermissionDenied, fxWriteProtect:
+		return faults.CodePermissionDenied, true
+	case fxFileAlreadyExists, fxLockConflict:
+		return faults.CodeAborted, true
+	case fxNoSpaceOnFilesystem, fxQuotaExceeded:
+		return faults.CodeResourceExhausted, true
+	case fxDirNotEmpty, fxNotADirectory, fxFileIsADirectory, fxLinkLoop:
+		return faults.CodeFailedPrecondition, true
+	case fxBadMessage, fxInvalidFilename:
+		return faults.CodeBad, true
+	case fxNoConnection, fxConnectionLost:
+		return faults.CodeUnavailable, true
+	case fxOpUnsupported:
+		return faults.CodeUnimplemented, true
+	}
+	return faults.CodeUnknown, false
+}
+
+// FromSFTP wraps `err` with the fault matching the SFTP error it carries (see
+// Classifier).
+//
+// Example:
+//
+//	f, err := client.Open(path)
+//	if err != nil {
+//		return faultssftp.FromSFTP(err) // NotFound when there is no such file
+//	}
+//
+// `err` is returned as is when it already carries a fault, or when it is not
+// recognised.
+func FromSFTP(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := faults.FaultClassifier.Classify(err); ok {
+		return err
+	}
+
+	code, ok := classify(err)
+	if !ok {
+		return err
+	}
+	if d, ok := faults.Describe(code); ok && d.New != nil {
+		return d.New(err)
+	}
+	return err
+}
@@ -0,0 +1,55 @@
+package faultssftp_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultssftp"
+	"github.com/pkg/sftp"
+)
+
+// TestFromSFTP ensures SFTP errors are classified.
+func TestFromSFTP(t *testing.T) {
+	table := []struct {
+		Error error
+		Code  faults.Code
+	}{
+		{Error: nil, Code: faults.CodeOK},
+		{Error: errors.New("boom"), Code: faults.CodeUnknown},
+		{Error: faults.Bad(), Code: faults.CodeBad},
+		{Error: &os.PathError{Op: "open", Path: "/a", Err: os.ErrNotExist}, Code: faults.CodeNotFound},
+		{Error: &os.PathError{Op: "open", Path: "/a", Err: os.ErrPermission}, Code: faults.CodePermissionDenied},
+		{Error: sftp.ErrSSHFxConnectionLost, Code: faults.CodeUnavailable},
+		{Error: sftp.ErrSSHFxOpUnsupported, Code: faults.CodeUnimplemented},
+		{Error: &sftp.StatusError{Code: 2}, Code: faults.CodeNotFound},
+		{Error: &sftp.StatusError{Code: 3}, Code: faults.CodePermissionDenied},
+		{Error: &sftp.StatusError{Code: 4}, Code: faults.CodeUnknown},
+		{Error: &sftp.StatusError{Code: 11}, Code: faults.CodeAborted},
+		{Error: &sftp.StatusError{Code: 15}, Code: faults.CodeResourceExhausted},
+		{Error: &sftp.StatusError{Code: 18}, Code: faults.CodeFailedPrecondition},
+		{Error: fmt.Errorf("remove: %w", &sftp.StatusError{Code: 20}), Code: faults.CodeBad},
+	}
+
+	for i, test := range table {
+		err := faultssftp.FromSFTP(test.Error)
+		if code := faults.CodeOf(err); code != test.Code {
+			t.Errorf("%d - expect code %s, but got %s", i, test.Code, code)
+		}
+		if test.Error != nil && !errors.Is(err, test.Error) {
+			t.Errorf("%d - expect SFTP error to be wrapped", i)
+		}
+	}
+}
+
+// TestClassifier ensures SFTP errors can be classified without being wrapped.
+func TestClassifier(t *testing.T) {
+	if code, ok := faultssftp.Classifier.Classify(&sftp.StatusError{Code: 2}); !ok || code != faults.CodeNotFound {
+		t.Errorf("expect code %s, but got %s (%t)", faults.CodeNotFound, code, ok)
+	}
+	if _, ok := faultssftp.Classifier.Classify(errors.New("boom")); ok {
+		t.Error("expect unknown error not to be classified")
+	}
+}
@@ -0,0 +1,163 @@
+// Package faultsstorage classifies the errors of object storage services that
+// implement the Amazon S3 API, such as Amazon S3 itself, Google Cloud Storage
+// (XML API), Cloudflare R2 or MinIO.
+//
+// The package does not depend on any SDK. Errors are recognised from the
+// methods they expose: `ErrorCode() string`, which is implemented by the API
+// errors of the AWS SDK for Go v2, and `HTTPStatusCode() int`, which is
+// implemented by its response errors. Errors of SDKs that expose the error
+// code differently can be converted with FromS3Code.
+package faultsstorage
+
+import (
+	"errors"
+
+	"github.com/deixis/faults"
+)
+
+// S3Classifier classifies the errors of S3-compatible services, from their
+// error code when they expose one, or from their HTTP status otherwise (see
+// CodeOf):
+//
+//   - NoSuchKey, NoSuchBucket and other missing resources are classified as
+//     NotFound
+//   - AccessDenied is classified as PermissionDenied
+//   - InvalidAccessKeyId, SignatureDoesNotMatch and expired tokens are
+//     classified as Unauthenticated
+//   - PreconditionFailed, raised by conditional requests, and BucketNotEmpty
+//     are classified as FailedPrecondition
+//   - BucketAlreadyExists, ConditionalRequestConflict and OperationAborted
+//     are classified as Aborted
+//   - SlowDown, ServiceUnavailable, InternalError and RequestTimeout are
+//     classified as Unavailable, since they are retryable
+//   - malformed requests, e.g. InvalidArgument or EntityTooLarge, are
+//     classified as Bad
+//
+// It can be registered with faults.RegisterClassifier, or applied explicitly
+// with FromS3.
+var S3Classifier faults.Classifier = faults.ClassifierFunc(classifyS3)
+
+func classifyS3(err error) (faults.Code, bool) {
+	var apiErr interface{ ErrorCode() string }
+	if errors.As(err, &apiErr) {
+		if code, ok := CodeOf(apiErr.ErrorCode()); ok {
+			return code, true
+		}
+	}
+
+	var respErr interface{ HTTPStatusCode() int }
+	if errors.As(err, &respErr) {
+		return statusCode(respErr.HTTPStatusCode())
+	}
+	return faults.CodeUnknown, false
+}
+
+// CodeOf returns the category of the S3 error code `errorCode`, e.g.
+// "NoSuchKey", or false when it is not recognised.
+func CodeOf(errorCode string) (faults.Code, bool) {
+	switch errorCode {
+	case "NoSuchKey", "NoSuchBucket", "NoSuchUpload", "NoSuchVersion",
+		"NoSuchLifecycleConfiguration", "NoSuchBucketPolicy", "NotFound":
+		return faults.CodeNotFound, true
+	case "AccessDenied", "AllAccessDisabled", "AccountProblem",
+		"Forbidden":
+		return faults.CodePermissionDenied, true
+	case "InvalidAccessKeyId", "SignatureDoesNotMatch", "ExpiredToken",
+		"InvalidToken", "TokenRefreshRequired":
+		return faults.CodeUnauthenticated, true
+	case "PreconditionFailed", "BucketNotEmpty", "InvalidObjectState":
+		return faults.CodeFailedPrecondition, true
+	case "BucketAlreadyExists", "BucketAlreadyOwnedByYou",
+		"ConditionalRequestConflict", "OperationAborted":
+		return faults.CodeAborted, true
+	case "TooManyBuckets":
+		return faults.CodeResourceExhausted, true
+	case "SlowDown", "ServiceUnavailable", "InternalError", "RequestTimeout",
+		"Throttling", "ThrottlingException", "RequestLimitExceeded":
+		return faults.CodeUnavailable, true
+	case "InvalidArgument", "InvalidRequest", "InvalidRange", "InvalidDigest",
+		"BadDigest", "InvalidBucketName", "KeyTooLongError", "MalformedXML",
+		"EntityTooLarge", "EntityTooSmall", "InvalidPart", "InvalidPartOrder",
+		"MissingContentLength", "IncompleteBody":
+		return faults.CodeBad, true
+	case "NotImplemented":
+		return faults.CodeUnimplemented, true
+	}
+	return faults.CodeUnknown, false
+}
+
+// statusCode returns the category of the HTTP status `status`.
+func statusCode(status int) (faults.Code, bool) {
+	switch status {
+	case 400:
+		return faults.CodeBad, true
+	case 401:
+		return faults.CodeUnauthenticated, true
+	case 403:
+		return faults.CodePermissionDenied, true
+	case 404:
+		return faults.CodeNotFound, true
+	case 409:
+		return faults.CodeAborted, true
+	case 412:
+		return faults.CodeFailedPrecondition, true
+	case 429, 500, 502, 503, 504:
+		return faults.CodeUnavailable, true
+	case 501:
+		return faults.CodeUnimplemented, true
+	}
+	return faults.CodeUnknown, false
+}
+
+// FromS3 wraps `err` with the fault matching the S3 error it carries (see
+// S3Classifier).
+//
+// Example:
+//
+//	out, err := client.GetObject(ctx, &s3.GetObjectInput{Bucket: &bucket, Key: &key})
+//	if err != nil {
+//		return faultsstorage.FromS3(err) // NotFound when there is no such key
+//	}
+//
+// `err` is returned as is when it already carries a fault, or when it is not
+// recognised.
+func FromS3(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := faults.FaultClassifier.Classify(err); ok {
+		return err
+	}
+
+	code, ok := classifyS3(err)
+	if !ok {
+		return err
+	}
+	return wrap(err, code)
+}
+
+// FromS3Code is like FromS3, but with the S3 error code `errorCode` of `err`,
+// for SDKs that expose it differently, e.g. with MinIO:
+//
+//	faultsstorage.FromS3Code(err, minio.ToErrorResponse(err).Code)
+func FromS3Code(err error, errorCode string) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := faults.FaultClassifier.Classify(err); ok {
+		return err
+	}
+
+	code, ok := CodeOf(errorCode)
+	if !ok {
+		return err
+	}
+	return wrap(err, code)
+}
+
+func wrap(err error, code faults.Code) error {
+	if d, ok := faults.Describe(code); ok && d.New != nil {
+		return d.New(err)
+	}
+	return err
+}
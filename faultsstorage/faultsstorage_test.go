@@ -0,0 +1,91 @@
+package faultsstorage_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultsstorage"
+)
+
+// apiError mimics the API errors of the AWS SDK for Go v2.
+type apiError struct {
+	code string
+}
+
+func (e *apiError) Error() string     { return "api error " + e.code }
+func (e *apiError) ErrorCode() string { return e.code }
+
+// responseError mimics the response errors of the AWS SDK for Go v2.
+type responseError struct {
+	status int
+	err    error
+}
+
+func (e *responseError) Error() string       { return "response error" }
+func (e *responseError) Unwrap() error       { return e.err }
+func (e *responseError) HTTPStatusCode() int { return e.status }
+
+// TestFromS3 ensures S3 errors are classified from their error code, or from
+// their HTTP status.
+func TestFromS3(t *testing.T) {
+	table := []struct {
+		Error     error
+		Code      faults.Code
+		Retryable bool
+	}{
+		{Error: nil, Code: faults.CodeOK},
+		{Error: errors.New("boom"), Code: faults.CodeUnknown},
+		{Error: faults.Bad(), Code: faults.CodeBad},
+		{Error: &apiError{"NoSuchKey"}, Code: faults.CodeNotFound},
+		{Error: &apiError{"NoSuchBucket"}, Code: faults.CodeNotFound},
+		{Error: &apiError{"AccessDenied"}, Code: faults.CodePermissionDenied},
+		{Error: &apiError{"SignatureDoesNotMatch"}, Code: faults.CodeUnauthenticated},
+		{Error: &apiError{"PreconditionFailed"}, Code: faults.CodeFailedPrecondition},
+		{Error: &apiError{"ConditionalRequestConflict"}, Code: faults.CodeAborted, Retryable: true},
+		{Error: &apiError{"SlowDown"}, Code: faults.CodeUnavailable, Retryable: true},
+		{Error: &apiError{"InternalError"}, Code: faults.CodeUnavailable, Retryable: true},
+		{Error: &apiError{"EntityTooLarge"}, Code: faults.CodeBad},
+		{Error: &apiError{"NotImplemented"}, Code: faults.CodeUnimplemented},
+		{Error: &apiError{"SomethingElse"}, Code: faults.CodeUnknown},
+		{
+			Error: &responseError{status: 404, err: &apiError{"NoSuchKey"}},
+			Code:  faults.CodeNotFound,
+		},
+		{
+			Error: &responseError{status: 412, err: &apiError{"SomethingElse"}},
+			Code:  faults.CodeFailedPrecondition,
+		},
+		{Error: &responseError{status: 503}, Code: faults.CodeUnavailable, Retryable: true},
+		{Error: &responseError{status: 418}, Code: faults.CodeUnknown},
+	}
+
+	for i, test := range table {
+		err := faultsstorage.FromS3(test.Error)
+		if code := faults.CodeOf(err); code != test.Code {
+			t.Errorf("%d - expect code %s, but got %s", i, test.Code, code)
+		}
+		if retryable := faults.IsRetryable(err); retryable != test.Retryable {
+			t.Errorf("%d - expect retryable %t, but got %t", i, test.Retryable, retryable)
+		}
+		if test.Error != nil && !errors.Is(err, test.Error) {
+			t.Errorf("%d - expect S3 error to be wrapped", i)
+		}
+	}
+}
+
+// TestFromS3Code ensures errors of other SDKs can be classified from their
+// error code.
+func TestFromS3Code(t *testing.T) {
+	boom := errors.New("The specified key does not exist.")
+
+	if err := faultsstorage.FromS3Code(boom, "NoSuchKey"); !faults.IsNotFound(err) {
+		t.Errorf("expect code %s, but got %s", faults.CodeNotFound, faults.CodeOf(err))
+	}
+	if err := faultsstorage.FromS3Code(boom, ""); err != boom {
+		t.Errorf("expect unknown error code to be ignored, but got %v", err)
+	}
+	if err := faultsstorage.FromS3Code(nil, "NoSuchKey"); err != nil {
+		t.Errorf("expect nil error, but got %v", err)
+	}
+}
@@ -0,0 +1,242 @@
+// Package faultstest provides utilities to test code built on faults.
+package faultstest
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+)
+
+// Secret is a message wrapped by the server faults used by ConformanceSuite.
+// It must never appear in the rendering of a fault.
+const Secret = "pq: password authentication failed for user \"admin\""
+
+// A ConformanceSuite verifies that a mapping between faults and a transport
+// representation, such as a renderer and its parser, follows the semantics of
+// the core:
+//
+//   - faults survive a round trip with their category, and optionally with
+//     their retry delay and their details (violations and reason)
+//   - each category is mapped to the expected transport status
+//   - the messages of server faults are not rendered, since they may leak
+//     implementation details
+//   - faults are always encoded with the same bytes, whatever the order in
+//     which their metadata is iterated, so responses can be compared by
+//     contract tests and cached
+//
+// Example:
+//
+//	func TestConformance(t *testing.T) {
+//		faultstest.ConformanceSuite{
+//			RoundTrip: func(err error) error {
+//				return mytransport.Decode(mytransport.Encode(err))
+//			},
+//			RetryInfo: true,
+//		}.Run(t)
+//	}
+type ConformanceSuite struct {
+	// RoundTrip converts `err` to the transport representation of the
+	// mapping under test, and back. It is required.
+	RoundTrip func(err error) error
+	// Status returns the transport status of `err`, e.g. an HTTP status. The
+	// status mapping is not verified when it is nil.
+	Status func(err error) int
+	// ExpectedStatus returns the transport status expected for a category. It
+	// defaults to the HTTP status of the category (see faults.HTTPStatus).
+	ExpectedStatus func(code faults.Code) int
+	// Render returns the rendering of `err`, e.g. the body of an HTTP
+	// response. Renderings are not verified when it is nil.
+	Render func(err error) string
+	// Encode returns the complete transport representation of `err`, e.g.
+	// the serialized status of a gRPC call. Encodings are verified to be
+	// deterministic when it is set, or Render when it is nil.
+	Encode func(err error) []byte
+	// Codes lists the categories supported by the mapping. It defaults to
+	// every category of the faults package.
+	Codes []faults.Code
+	// RetryInfo reports whether the mapping preserves retry delays.
+	RetryInfo bool
+	// Details reports whether the mapping preserves violations and reasons.
+	Details bool
+}
+
+// Run runs the suite as subtests of `t`.
+func (s ConformanceSuite) Run(t *testing.T) {
+	t.Helper()
+	if s.RoundTrip == nil {
+		t.Fatal("faultstest: ConformanceSuite.RoundTrip is required")
+	}
+
+	t.Run("RoundTrip", s.testRoundTrip)
+	if s.Status != nil {
+		t.Run("Status", s.testStatus)
+	}
+	if s.Render != nil {
+		t.Run("Sanitization", s.testSanitization)
+	}
+	if s.Encode != nil || s.Render != nil {
+		t.Run("Determinism", s.testDeterminism)
+	}
+}
+
+func (s ConformanceSuite) testRoundTrip(t *testing.T) {
+	if err := s.RoundTrip(nil); err != nil {
+		t.Errorf("expect nil error to round trip, but got %v", err)
+	}
+
+	for _, fault := range s.samples() {
+		err := s.RoundTrip(fault)
+		if code := faults.CodeOf(err); code != faults.CodeOf(fault) {
+			t.Errorf("%s - expect code %s, but got %s", describe(fault), faults.CodeOf(fault), code)
+			continue
+		}
+		if expect := faults.RetryAdvice(fault); s.RetryInfo && faults.RetryAdvice(err) != expect {
+			t.Errorf("%s - expect advice %+v, but got %+v", describe(fault), expect, faults.RetryAdvice(err))
+		}
+		if !s.Details {
+			continue
+		}
+		if expect := faults.ReasonOf(fault); faults.ReasonOf(err) != expect {
+			t.Errorf("%s - expect reason %q, but got %q", describe(fault), expect, faults.ReasonOf(err))
+		}
+		if expect := faults.ViolationsOf(fault); !reflect.DeepEqual(expect, faults.ViolationsOf(err)) {
+			t.Errorf("%s - expect violations %v, but got %v", describe(fault), expect, faults.ViolationsOf(err))
+		}
+	}
+}
+
+func (s ConformanceSuite) testStatus(t *testing.T) {
+	expected := s.ExpectedStatus
+	if expected == nil {
+		expected = func(code faults.Code) int {
+			d, _ := faults.Describe(code)
+			return d.HTTPStatus
+		}
+	}
+
+	for _, fault := range s.samples() {
+		expect := expected(faults.CodeOf(fault))
+		if status := s.Status(fault); status != expect {
+			t.Errorf("%s - expect status %d, but got %d", describe(fault), expect, status)
+		}
+	}
+}
+
+func (s ConformanceSuite) testSanitization(t *testing.T) {
+	for _, fault := range s.samples() {
+		if !faults.IsServerFault(fault) {
+			continue
+		}
+		if out := s.Render(fault); strings.Contains(out, Secret) {
+			t.Errorf("%s - expect message not to be rendered, but got %s", describe(fault), out)
+		}
+	}
+}
+
+func (s ConformanceSuite) testDeterminism(t *testing.T) {
+	encode := s.Encode
+	if encode == nil {
+		encode = func(err error) []byte { return []byte(s.Render(err)) }
+	}
+
+	// Enough metadata for the iteration order of the map to vary
+	metadata := map[string]string{}
+	for i := 0; i < 16; i++ {
+		metadata[fmt.Sprintf("key%02d", i)] = fmt.Sprintf("value%02d", i)
+	}
+	samples := s.samples()
+	if err := faults.WithErrorInfo(faults.Bad(
+		&faults.FieldViolation{Field: "email", Code: faults.ViolationRequired, Description: "Field required"},
+		&faults.FieldViolation{Field: "name", Code: faults.ViolationTooLong, Description: "Too long"},
+	), "INVALID_FORM", metadata); s.supported(err) {
+		samples = append(samples, err)
+	}
+
+	for _, fault := range samples {
+		expect := encode(fault)
+		for i := 0; i < 10; i++ {
+			if got := encode(fault); string(got) != string(expect) {
+				t.Errorf("%s - expect encoding to be stable, but got %q and %q", describe(fault), expect, got)
+				break
+			}
+		}
+	}
+}
+
+// samples returns the faults of the supported categories used by the suite.
+func (s ConformanceSuite) samples() []error {
+	var samples []error
+	for _, err := range Samples() {
+		if s.supported(err) {
+			samples = append(samples, err)
+		}
+	}
+	return samples
+}
+
+// supported reports whether the category of `err` is supported by the
+// mapping.
+func (s ConformanceSuite) supported(err error) bool {
+	if len(s.Codes) == 0 {
+		return true
+	}
+	code := faults.CodeOf(err)
+	for _, c := range s.Codes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Samples returns a set of faults covering every category of the faults
+// package, with and without details. Server faults wrap Secret.
+func Samples() []error {
+	quota := faults.WithResourceExhausted(errors.New("daily limit reached"),
+		&faults.QuotaViolation{Subject: "user:42", Description: "Daily limit reached"},
+	)
+	faults.MustAs[*faults.QuotaFailure](quota).RetryInfo.RetryDelay = 30 * time.Second
+	secret := errors.New(Secret)
+
+	return []error{
+		faults.Bad(),
+		faults.Bad(
+			&faults.FieldViolation{Field: "email", Code: faults.ViolationRequired, Description: "Field required"},
+			&faults.FieldViolation{Field: "name", Code: faults.ViolationTooLong, Description: "Too long"},
+		),
+		faults.WithReason(faults.NotFound, "USER_NOT_FOUND"),
+		faults.NotFound,
+		faults.PermissionDenied,
+		faults.Unauthenticated,
+		faults.FailedPrecondition(&faults.PreconditionViolation{
+			Type:        "TOS",
+			Subject:     "user:42",
+			Description: "Terms of service not accepted",
+		}),
+		faults.WithErrorInfo(faults.FailedPrecondition(), "INSUFFICIENT_FUNDS", map[string]string{"missing": "12.50"}),
+		faults.Aborted(&faults.ConflictViolation{Resource: "order:42", Description: "Version mismatch"}),
+		faults.ResourceExhausted(),
+		quota,
+		faults.Unavailable(0),
+		faults.WithUnavailable(secret, 2*time.Second),
+		faults.Unimplemented,
+		faults.WithInternal(secret),
+		fmt.Errorf("%s: %w", Secret, faults.Internal),
+		faults.WithDeadlineExceeded(secret),
+		secret,
+	}
+}
+
+// describe describes `err` in failure messages.
+func describe(err error) string {
+	s := faults.CodeOf(err).String()
+	if reason := faults.ReasonOf(err); reason != "" {
+		s += "/" + reason
+	}
+	return fmt.Sprintf("%s (%q)", s, err)
+}
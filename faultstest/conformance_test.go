@@ -0,0 +1,57 @@
+package faultstest_test
+
+import (
+	"testing"
+
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultstest"
+)
+
+// TestConformanceSuite ensures the suite accepts the identity mapping.
+func TestConformanceSuite(t *testing.T) {
+	faultstest.ConformanceSuite{
+		RoundTrip: func(err error) error { return err },
+		Status:    faults.HTTPStatus,
+		Render: func(err error) string {
+			if faults.IsServerFault(err) {
+				return faults.CodeOf(err).String()
+			}
+			return err.Error()
+		},
+		RetryInfo: true,
+		Details:   true,
+	}.Run(t)
+}
+
+// TestConformanceSuiteDebug ensures the suite runs in debug mode, where
+// faults carry a DebugInfo.
+func TestConformanceSuiteDebug(t *testing.T) {
+	faults.SetDebug(true)
+	defer faults.SetDebug(false)
+
+	faultstest.ConformanceSuite{
+		RoundTrip: func(err error) error { return err },
+		Status:    faults.HTTPStatus,
+		Render: func(err error) string {
+			if faults.IsServerFault(err) {
+				return faults.CodeOf(err).String()
+			}
+			return err.Error()
+		},
+		RetryInfo: true,
+		Details:   true,
+	}.Run(t)
+}
+
+// TestSamples ensures the samples cover every category.
+func TestSamples(t *testing.T) {
+	covered := map[faults.Code]bool{}
+	for _, err := range faultstest.Samples() {
+		covered[faults.CodeOf(err)] = true
+	}
+	for code := faults.CodeUnknown; code <= faults.CodeDeadlineExceeded; code++ {
+		if !covered[code] {
+			t.Errorf("expect samples to cover %s", code)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package faultstest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+)
+
+// A Contract declares how an API operation is expected to fail. Zero fields
+// are not verified, except Code.
+type Contract struct {
+	// Code is the expected category.
+	Code faults.Code
+	// Reason is the expected reason (see faults.ReasonOf).
+	Reason string
+	// Domain is the expected domain of the reason (see faults.DomainOf).
+	Domain string
+	// Metadata lists the metadata keys that must be attached to the reason
+	// (see faults.ErrorInfo).
+	Metadata []string
+	// Violations lists the violations that must be carried by the fault,
+	// identified by the field of a FieldViolation, the subject of a
+	// PreconditionViolation or a QuotaViolation, or the resource of a
+	// ConflictViolation. Other violations are allowed.
+	Violations []string
+	// RetryDelay is the minimum retry delay the fault must advise (see
+	// faults.RetryAdvice).
+	RetryDelay time.Duration
+	// HTTPStatus is the expected HTTP status (see faults.HTTPStatus).
+	HTTPStatus int
+	// Status returns the transport status of the fault, for mappings other
+	// than HTTP, e.g. a gRPC code. It is compared to ExpectedStatus.
+	Status func(err error) int
+	// ExpectedStatus is the status Status must return. It is only verified
+	// when Status is set.
+	ExpectedStatus int
+}
+
+// AssertContract verifies that `err` fulfils `spec`, and reports every
+// mismatch to `t`. It reports whether `err` fulfils `spec`.
+//
+// Example:
+//
+//	func TestReserveOutOfStock(t *testing.T) {
+//		_, err := store.Reserve(ctx, "sku-1", 1000)
+//		faultstest.AssertContract(t, faultstest.Contract{
+//			Code:       faults.CodeFailedPrecondition,
+//			Reason:     "OUT_OF_STOCK",
+//			Metadata:   []string{"available"},
+//			Violations: []string{"sku:sku-1"},
+//			HTTPStatus: http.StatusBadRequest,
+//		}, err)
+//	}
+func AssertContract(t testing.TB, spec Contract, err error) bool {
+	t.Helper()
+
+	if err == nil {
+		if spec.Code == faults.CodeOK {
+			return true
+		}
+		t.Errorf("expect %s fault, but got nil", spec.Code)
+		return false
+	}
+
+	ok := true
+	fail := func(format string, args ...interface{}) {
+		t.Helper()
+		t.Errorf("%s - "+format, append([]interface{}{describe(err)}, args...)...)
+		ok = false
+	}
+
+	if code := faults.CodeOf(err); code != spec.Code {
+		fail("expect code %s, but got %s", spec.Code, code)
+	}
+	if spec.Reason != "" {
+		if reason := faults.ReasonOf(err); reason != spec.Reason {
+			fail("expect reason %q, but got %q", spec.Reason, reason)
+		}
+	}
+	if spec.Domain != "" {
+		if domain := faults.DomainOf(err); domain != spec.Domain {
+			fail("expect domain %q, but got %q", spec.Domain, domain)
+		}
+	}
+	if len(spec.Metadata) > 0 {
+		var metadata map[string]string
+		if e, found := faults.AsErrorInfo(err); found {
+			metadata = e.Metadata
+		}
+		for _, key := range spec.Metadata {
+			if _, found := metadata[key]; !found {
+				fail("expect metadata %q", key)
+			}
+		}
+	}
+	if len(spec.Violations) > 0 {
+		ids := violationIDs(err)
+		for _, id := range spec.Violations {
+			if !ids[id] {
+				fail("expect violation %q", id)
+			}
+		}
+	}
+	if spec.RetryDelay > 0 {
+		if delay := faults.RetryAdvice(err).Delay; delay < spec.RetryDelay {
+			fail("expect retry delay of at least %s, but got %s", spec.RetryDelay, delay)
+		}
+	}
+	if spec.HTTPStatus != 0 {
+		if status := faults.HTTPStatus(err); status != spec.HTTPStatus {
+			fail("expect HTTP status %d, but got %d", spec.HTTPStatus, status)
+		}
+	}
+	if spec.Status != nil {
+		if status := spec.Status(err); status != spec.ExpectedStatus {
+			fail("expect status %d, but got %d", spec.ExpectedStatus, status)
+		}
+	}
+	return ok
+}
+
+// violationIDs returns the identifiers of the violations carried by `err`.
+func violationIDs(err error) map[string]bool {
+	ids := map[string]bool{}
+	for _, v := range faults.ViolationsOf(err) {
+		switch v := v.(type) {
+		case *faults.FieldViolation:
+			ids[v.Field] = true
+		case *faults.PreconditionViolation:
+			ids[v.Subject] = true
+		case *faults.ConflictViolation:
+			ids[v.Resource] = true
+		case *faults.QuotaViolation:
+			ids[v.Subject] = true
+		}
+	}
+	return ids
+}
@@ -0,0 +1,103 @@
+package faultstest_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultstest"
+)
+
+// recorder is a testing.TB that records failures.
+type recorder struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+// TestAssertContract ensures every mismatch with a contract is reported.
+func TestAssertContract(t *testing.T) {
+	outOfStock := faults.WithErrorInfo(
+		faults.FailedPrecondition(&faults.PreconditionViolation{
+			Type:        "STOCK",
+			Subject:     "sku:42",
+			Description: "Out of stock",
+		}),
+		"OUT_OF_STOCK",
+		map[string]string{"available": "0"},
+	)
+	spec := faultstest.Contract{
+		Code:       faults.CodeFailedPrecondition,
+		Reason:     "OUT_OF_STOCK",
+		Metadata:   []string{"available"},
+		Violations: []string{"sku:42"},
+		HTTPStatus: http.StatusBadRequest,
+	}
+
+	table := []struct {
+		Spec     faultstest.Contract
+		Error    error
+		Failures int
+	}{
+		{Spec: spec, Error: outOfStock},
+		{Spec: spec, Error: fmt.Errorf("reserve: %w", outOfStock)},
+		{Spec: faultstest.Contract{}, Error: nil},
+		{Spec: spec, Error: nil, Failures: 1},
+		// Wrong category, so wrong status
+		{Spec: spec, Error: faults.WithErrorInfo(faults.Aborted(), "OUT_OF_STOCK", map[string]string{"available": "0"}), Failures: 3},
+		{Spec: spec, Error: faults.WithReason(faults.FailedPrecondition(), "SOLD_OUT"), Failures: 3},
+		{
+			Spec:     faultstest.Contract{Code: faults.CodeBad, Violations: []string{"email", "name"}},
+			Error:    faults.Bad(&faults.FieldViolation{Field: "email"}, &faults.FieldViolation{Field: "age"}),
+			Failures: 1,
+		},
+		{
+			Spec:  faultstest.Contract{Code: faults.CodeAborted, Violations: []string{"order:42"}},
+			Error: faults.Aborted(&faults.ConflictViolation{Resource: "order:42"}),
+		},
+		{
+			Spec:  faultstest.Contract{Code: faults.CodeUnavailable, RetryDelay: time.Second},
+			Error: faults.Unavailable(2 * time.Second),
+		},
+		{
+			Spec:     faultstest.Contract{Code: faults.CodeUnavailable, RetryDelay: time.Second},
+			Error:    faults.Unavailable(0),
+			Failures: 1,
+		},
+		{
+			Spec: faultstest.Contract{
+				Code:           faults.CodeNotFound,
+				Status:         func(err error) int { return 5 },
+				ExpectedStatus: 5,
+			},
+			Error: faults.NotFound,
+		},
+		{
+			Spec: faultstest.Contract{
+				Code:           faults.CodeNotFound,
+				Status:         func(err error) int { return 2 },
+				ExpectedStatus: 5,
+			},
+			Error:    faults.NotFound,
+			Failures: 1,
+		},
+	}
+
+	for i, test := range table {
+		r := &recorder{TB: t}
+		ok := faultstest.AssertContract(r, test.Spec, test.Error)
+		if len(r.failures) != test.Failures {
+			t.Errorf("%d - expect %d failures, but got %q", i, test.Failures, r.failures)
+		}
+		if ok != (test.Failures == 0) {
+			t.Errorf("%d - expect result %t, but got %t", i, test.Failures == 0, ok)
+		}
+	}
+}
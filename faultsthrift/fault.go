@@ -0,0 +1,405 @@
+package faultsthrift
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultshttp"
+)
+
+// A Fault is a Thrift exception describing a fault with its details, for
+// services that declare it in their IDL instead of relying on application
+// exceptions, which only carry a type id and a message. It is the Go
+// implementation of the `Fault` exception of `faults.thrift`:
+//
+//	exception Fault {
+//	  1: i32 typeId
+//	  2: string code
+//	  3: string message
+//	  4: i64 retryDelay
+//	  5: string reason
+//	  6: map<string, string> metadata
+//	  7: list<Violation> violations
+//	  8: bool permanent
+//	}
+//
+// so it can be declared by services (`throws (1: faults.Fault fault)`) and
+// read by clients written in any language.
+type Fault struct {
+	// TypeID is the exception type id matching the category of the fault
+	// (see Mapping).
+	TypeID int32
+	// Code is the name of the category of the fault, e.g. "NOT_FOUND".
+	Code string
+	// Message is the message of the fault.
+	Message string
+	// RetryDelay is the delay advised before retrying, in milliseconds.
+	RetryDelay int64
+	// Reason is the reason attached to the fault, if any (see
+	// faults.ErrorInfo).
+	Reason string
+	// Metadata is the metadata attached to the reason of the fault, if any.
+	Metadata map[string]string
+	// Violations lists the violations carried by the fault.
+	Violations []*Violation
+	// Permanent reports whether the fault must not be retried, whatever its
+	// category (see faults.Permanent).
+	Permanent bool
+}
+
+// A Violation describes a single violation of a Fault. Only the fields of
+// the corresponding Go type are set, e.g. Field and Description for a
+// faults.FieldViolation.
+//
+//	struct Violation {
+//	  1: string field
+//	  2: string code
+//	  3: string type
+//	  4: string subject
+//	  5: string resource
+//	  6: string description
+//	}
+type Violation struct {
+	Field       string
+	Code        string
+	Type        string
+	Subject     string
+	Resource    string
+	Description string
+}
+
+// ToFault converts `err` to a Fault exception. It returns nil when `err` is
+// nil.
+//
+// Like for HTTP responses, the message of `err` is only used for client
+// faults. Server faults are described by the message of their category alone
+// (see faults.Sanitize).
+func (c Converter) ToFault(err error) *Fault {
+	if err == nil {
+		return nil
+	}
+	message := err.Error()
+	if faults.IsServerFault(err) {
+		message = faults.Sanitize(err).Error()
+	}
+
+	p := faultshttp.NewProblem(nil, err)
+	f := &Fault{
+		TypeID:     c.mapping().TypeID(faults.CodeOf(err)),
+		Code:       p.Code,
+		Message:    message,
+		RetryDelay: p.RetryDelay,
+		Reason:     p.Reason,
+		Metadata:   p.Metadata,
+		Permanent:  p.Permanent,
+	}
+	for _, v := range p.Violations {
+		f.Violations = append(f.Violations, &Violation{
+			Field:       v.Field,
+			Code:        v.Code,
+			Type:        v.Type,
+			Subject:     v.Subject,
+			Resource:    v.Resource,
+			Description: v.Description,
+		})
+	}
+	return f
+}
+
+// ToFault converts `err` to a Fault exception with DefaultMapping. See
+// Converter.ToFault.
+func ToFault(err error) *Fault {
+	return Converter{}.ToFault(err)
+}
+
+// Err converts `f` back to the fault it describes. The category is read from
+// Code, or derived from TypeID with DefaultMapping when it is missing.
+func (f *Fault) Err() error {
+	if f == nil {
+		return nil
+	}
+	p := &faultshttp.Problem{
+		Code:       f.Code,
+		Detail:     f.Message,
+		RetryDelay: f.RetryDelay,
+		Reason:     f.Reason,
+		Metadata:   f.Metadata,
+		Permanent:  f.Permanent,
+	}
+	if _, err := faults.ParseCode(p.Code); err != nil {
+		p.Code = DefaultMapping.Code(f.TypeID).String()
+	}
+	if p.Detail == "" {
+		p.Detail = "thrift: fault " + p.Code
+	}
+	for _, v := range f.Violations {
+		p.Violations = append(p.Violations, faultshttp.Violation{
+			Field:       v.Field,
+			Code:        v.Code,
+			Type:        v.Type,
+			Subject:     v.Subject,
+			Resource:    v.Resource,
+			Description: v.Description,
+		})
+	}
+	return p.Err()
+}
+
+// Error returns the message of the fault.
+func (f *Fault) Error() string {
+	return f.Message
+}
+
+// TExceptionType reports that Fault is an exception defined in an IDL.
+func (f *Fault) TExceptionType() thrift.TExceptionType {
+	return thrift.TExceptionTypeCompiled
+}
+
+// Write writes `f` to `oprot`.
+func (f *Fault) Write(ctx context.Context, oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin(ctx, "Fault"); err != nil {
+		return err
+	}
+	if err := writeField(ctx, oprot, "typeId", thrift.I32, 1, func() error {
+		return oprot.WriteI32(ctx, f.TypeID)
+	}); err != nil {
+		return err
+	}
+	if err := writeString(ctx, oprot, "code", 2, f.Code); err != nil {
+		return err
+	}
+	if err := writeString(ctx, oprot, "message", 3, f.Message); err != nil {
+		return err
+	}
+	if f.RetryDelay != 0 {
+		if err := writeField(ctx, oprot, "retryDelay", thrift.I64, 4, func() error {
+			return oprot.WriteI64(ctx, f.RetryDelay)
+		}); err != nil {
+			return err
+		}
+	}
+	if err := writeString(ctx, oprot, "reason", 5, f.Reason); err != nil {
+		return err
+	}
+	if len(f.Metadata) > 0 {
+		if err := writeField(ctx, oprot, "metadata", thrift.MAP, 6, func() error {
+			if err := oprot.WriteMapBegin(ctx, thrift.STRING, thrift.STRING, len(f.Metadata)); err != nil {
+				return err
+			}
+			// Keys are sorted, so a fault is always written with the same
+			// bytes
+			keys := make([]string, 0, len(f.Metadata))
+			for k := range f.Metadata {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				if err := oprot.WriteString(ctx, k); err != nil {
+					return err
+				}
+				if err := oprot.WriteString(ctx, f.Metadata[k]); err != nil {
+					return err
+				}
+			}
+			return oprot.WriteMapEnd(ctx)
+		}); err != nil {
+			return err
+		}
+	}
+	if len(f.Violations) > 0 {
+		if err := writeField(ctx, oprot, "violations", thrift.LIST, 7, func() error {
+			if err := oprot.WriteListBegin(ctx, thrift.STRUCT, len(f.Violations)); err != nil {
+				return err
+			}
+			for _, v := range f.Violations {
+				if err := v.Write(ctx, oprot); err != nil {
+					return err
+				}
+			}
+			return oprot.WriteListEnd(ctx)
+		}); err != nil {
+			return err
+		}
+	}
+	if f.Permanent {
+		if err := writeField(ctx, oprot, "permanent", thrift.BOOL, 8, func() error {
+			return oprot.WriteBool(ctx, f.Permanent)
+		}); err != nil {
+			return err
+		}
+	}
+	if err := oprot.WriteFieldStop(ctx); err != nil {
+		return err
+	}
+	return oprot.WriteStructEnd(ctx)
+}
+
+// Read reads `f` from `iprot`. Unknown fields are skipped.
+func (f *Fault) Read(ctx context.Context, iprot thrift.TProtocol) error {
+	return readStruct(ctx, iprot, func(id int16, typ thrift.TType) (bool, error) {
+		var err error
+		switch {
+		case id == 1 && typ == thrift.I32:
+			f.TypeID, err = iprot.ReadI32(ctx)
+		case id == 2 && typ == thrift.STRING:
+			f.Code, err = iprot.ReadString(ctx)
+		case id == 3 && typ == thrift.STRING:
+			f.Message, err = iprot.ReadString(ctx)
+		case id == 4 && typ == thrift.I64:
+			f.RetryDelay, err = iprot.ReadI64(ctx)
+		case id == 5 && typ == thrift.STRING:
+			f.Reason, err = iprot.ReadString(ctx)
+		case id == 6 && typ == thrift.MAP:
+			var size int
+			if _, _, size, err = iprot.ReadMapBegin(ctx); err != nil {
+				return true, err
+			}
+			f.Metadata = make(map[string]string, size)
+			for i := 0; i < size; i++ {
+				k, err := iprot.ReadString(ctx)
+				if err != nil {
+					return true, err
+				}
+				v, err := iprot.ReadString(ctx)
+				if err != nil {
+					return true, err
+				}
+				f.Metadata[k] = v
+			}
+			err = iprot.ReadMapEnd(ctx)
+		case id == 7 && typ == thrift.LIST:
+			var size int
+			if _, size, err = iprot.ReadListBegin(ctx); err != nil {
+				return true, err
+			}
+			for i := 0; i < size; i++ {
+				v := &Violation{}
+				if err := v.Read(ctx, iprot); err != nil {
+					return true, err
+				}
+				f.Violations = append(f.Violations, v)
+			}
+			err = iprot.ReadListEnd(ctx)
+		case id == 8 && typ == thrift.BOOL:
+			f.Permanent, err = iprot.ReadBool(ctx)
+		default:
+			return false, nil
+		}
+		return true, err
+	})
+}
+
+// Write writes `v` to `oprot`.
+func (v *Violation) Write(ctx context.Context, oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin(ctx, "Violation"); err != nil {
+		return err
+	}
+	for i, s := range v.fields() {
+		if err := writeString(ctx, oprot, violationFields[i], int16(i+1), *s); err != nil {
+			return err
+		}
+	}
+	if err := oprot.WriteFieldStop(ctx); err != nil {
+		return err
+	}
+	return oprot.WriteStructEnd(ctx)
+}
+
+// Read reads `v` from `iprot`. Unknown fields are skipped.
+func (v *Violation) Read(ctx context.Context, iprot thrift.TProtocol) error {
+	fields := v.fields()
+	return readStruct(ctx, iprot, func(id int16, typ thrift.TType) (bool, error) {
+		if id < 1 || int(id) > len(fields) || typ != thrift.STRING {
+			return false, nil
+		}
+		s, err := iprot.ReadString(ctx)
+		*fields[id-1] = s
+		return true, err
+	})
+}
+
+// violationFields lists the names of the fields of a Violation, in order.
+var violationFields = []string{"field", "code", "type", "subject", "resource", "description"}
+
+func (v *Violation) fields() []*string {
+	return []*string{&v.Field, &v.Code, &v.Type, &v.Subject, &v.Resource, &v.Description}
+}
+
+// writeField writes the field `id` with `value`.
+func writeField(ctx context.Context, oprot thrift.TProtocol, name string, typ thrift.TType, id int16, value func() error) error {
+	if err := oprot.WriteFieldBegin(ctx, name, typ, id); err != nil {
+		return err
+	}
+	if err := value(); err != nil {
+		return err
+	}
+	return oprot.WriteFieldEnd(ctx)
+}
+
+// writeString writes the string field `id`, unless `s` is empty.
+func writeString(ctx context.Context, oprot thrift.TProtocol, name string, id int16, s string) error {
+	if s == "" {
+		return nil
+	}
+	return writeField(ctx, oprot, name, thrift.STRING, id, func() error {
+		return oprot.WriteString(ctx, s)
+	})
+}
+
+// readStruct reads a struct from `iprot`, calling `field` with each of its
+// fields. Fields that `field` reports as unknown are skipped.
+func readStruct(ctx context.Context, iprot thrift.TProtocol, field func(id int16, typ thrift.TType) (bool, error)) error {
+	if _, err := iprot.ReadStructBegin(ctx); err != nil {
+		return err
+	}
+	for {
+		_, typ, id, err := iprot.ReadFieldBegin(ctx)
+		if err != nil {
+			return err
+		}
+		if typ == thrift.STOP {
+			break
+		}
+		ok, err := field(id, typ)
+		if err != nil {
+			return fmt.Errorf("faultsthrift: field %d: %w", id, err)
+		}
+		if !ok {
+			if err := iprot.Skip(ctx, typ); err != nil {
+				return err
+			}
+		}
+		if err := iprot.ReadFieldEnd(ctx); err != nil {
+			return err
+		}
+	}
+	return iprot.ReadStructEnd(ctx)
+}
+
+// MarshalFault serializes the Fault describing `err` with the binary
+// protocol, e.g. to carry it in a header of a transport that does not speak
+// Thrift.
+func MarshalFault(err error) ([]byte, error) {
+	f := ToFault(err)
+	if f == nil {
+		return nil, nil
+	}
+	return thrift.NewTSerializer().Write(context.Background(), f)
+}
+
+// UnmarshalFault deserializes a Fault produced by MarshalFault, and converts
+// it back to the fault it describes. It returns nil when `data` is empty.
+func UnmarshalFault(data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	f := &Fault{}
+	if err := thrift.NewTDeserializer().Read(context.Background(), f, data); err != nil {
+		return errors.New("faultsthrift: invalid fault: " + err.Error())
+	}
+	return f.Err()
+}
@@ -0,0 +1,123 @@
+package faultsthrift_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultstest"
+	"github.com/deixis/faults/faultsthrift"
+)
+
+// TestFault ensures faults survive a serialized Fault exception.
+func TestFault(t *testing.T) {
+	table := []struct {
+		Error  error
+		TypeID int32
+	}{
+		{Error: faults.Bad(&faults.FieldViolation{Field: "email", Code: faults.ViolationRequired}), TypeID: thrift.VALIDATION_FAILED},
+		{Error: faults.NotFound, TypeID: 105},
+		{Error: faults.FailedPrecondition(&faults.PreconditionViolation{Type: "TOS", Subject: "user:42"}), TypeID: 109},
+		{Error: faults.Aborted(&faults.ConflictViolation{Resource: "order:42"}), TypeID: 110},
+		{Error: faults.ResourceExhausted(&faults.QuotaViolation{Subject: "project:42"}), TypeID: 108},
+		{Error: faults.Unavailable(2 * time.Second), TypeID: 114},
+		{Error: faults.Permanent(faults.Internal), TypeID: thrift.INTERNAL_ERROR},
+		{Error: faults.WithErrorInfo(faults.PermissionDenied, "NOT_OWNER", map[string]string{"owner": "42"}), TypeID: 107},
+	}
+
+	for i, test := range table {
+		f := faultsthrift.ToFault(test.Error)
+		if f.TypeID != test.TypeID {
+			t.Errorf("%d - expect type id %d, but got %d", i, test.TypeID, f.TypeID)
+		}
+
+		data, err := thrift.NewTSerializer().Write(context.Background(), f)
+		if err != nil {
+			t.Fatalf("%d - expect fault to be serialized, but got %s", i, err)
+		}
+		read := &faultsthrift.Fault{}
+		if err := thrift.NewTDeserializer().Read(context.Background(), read, data); err != nil {
+			t.Fatalf("%d - expect fault to be deserialized, but got %s", i, err)
+		}
+		if !reflect.DeepEqual(f, read) {
+			t.Errorf("%d - expect fault %+v, but got %+v", i, f, read)
+		}
+
+		err = faultsthrift.FromException(read)
+		if code := faults.CodeOf(err); code != faults.CodeOf(test.Error) {
+			t.Errorf("%d - expect code %s, but got %s", i, faults.CodeOf(test.Error), code)
+		}
+		if expect, delay := faults.RetryAdvice(test.Error).Delay, faults.RetryAdvice(err).Delay; delay != expect {
+			t.Errorf("%d - expect retry delay %s, but got %s", i, expect, delay)
+		}
+		if expect, reason := faults.ReasonOf(test.Error), faults.ReasonOf(err); reason != expect {
+			t.Errorf("%d - expect reason %q, but got %q", i, expect, reason)
+		}
+	}
+
+	if faultsthrift.ToFault(nil) != nil {
+		t.Error("expect nil fault")
+	}
+	if f := faultsthrift.ToFault(faults.WithInternal(errors.New("db password leaked"))); f.Message != "internal error" {
+		t.Errorf("expect sanitized message, but got %q", f.Message)
+	}
+}
+
+// TestFaultCompatibility ensures Fault exceptions written by other
+// implementations are read too.
+func TestFaultCompatibility(t *testing.T) {
+	// Only the type id is set, and an unknown field is present
+	buf := thrift.NewTMemoryBuffer()
+	prot := thrift.NewTBinaryProtocolConf(buf, nil)
+	ctx := context.Background()
+	prot.WriteStructBegin(ctx, "Fault")
+	prot.WriteFieldBegin(ctx, "typeId", thrift.I32, 1)
+	prot.WriteI32(ctx, 105)
+	prot.WriteFieldEnd(ctx)
+	prot.WriteFieldBegin(ctx, "extra", thrift.DOUBLE, 42)
+	prot.WriteDouble(ctx, 1.5)
+	prot.WriteFieldEnd(ctx)
+	prot.WriteFieldStop(ctx)
+	prot.WriteStructEnd(ctx)
+
+	f := &faultsthrift.Fault{}
+	if err := f.Read(ctx, prot); err != nil {
+		t.Fatalf("expect fault to be read, but got %s", err)
+	}
+	if err := f.Err(); !faults.IsNotFound(err) {
+		t.Errorf("expect not found, but got %v", err)
+	}
+}
+
+// TestMarshalFault ensures faults survive MarshalFault.
+func TestMarshalFault(t *testing.T) {
+	if data, err := faultsthrift.MarshalFault(nil); data != nil || err != nil {
+		t.Errorf("expect no data, but got %x %v", data, err)
+	}
+	if err := faultsthrift.UnmarshalFault(nil); err != nil {
+		t.Errorf("expect nil, but got %v", err)
+	}
+	if err := faultsthrift.UnmarshalFault([]byte{0xff}); err == nil {
+		t.Error("expect invalid fault to be rejected")
+	}
+
+	faultstest.ConformanceSuite{
+		RoundTrip: func(err error) error {
+			data, _ := faultsthrift.MarshalFault(err)
+			return faultsthrift.UnmarshalFault(data)
+		},
+		Render: func(err error) string {
+			return faultsthrift.ToFault(err).Message
+		},
+		Encode: func(err error) []byte {
+			data, _ := faultsthrift.MarshalFault(err)
+			return data
+		},
+		RetryInfo: true,
+		Details:   true,
+	}.Run(t)
+}
@@ -0,0 +1,36 @@
+// Fault describes a fault of github.com/deixis/faults with its details. It
+// can be included by services to declare it in their `throws` clauses:
+//
+//   include "faults.thrift"
+//
+//   service Users {
+//     User get(1: string id) throws (1: faults.Fault fault)
+//   }
+namespace go faultsthrift
+
+// Violation describes a single violation of a fault. Only the fields of the
+// corresponding violation type are set.
+struct Violation {
+  1: string field
+  2: string code
+  3: string type
+  4: string subject
+  5: string resource
+  6: string description
+}
+
+exception Fault {
+  // Exception type id matching the category, as used for application
+  // exceptions
+  1: i32 typeId
+  // Name of the category, e.g. "NOT_FOUND"
+  2: string code
+  3: string message
+  // Delay advised before retrying, in milliseconds
+  4: i64 retryDelay
+  5: string reason
+  6: map<string, string> metadata
+  7: list<Violation> violations
+  // Whether the fault must not be retried, whatever its category
+  8: bool permanent
+}
@@ -0,0 +1,192 @@
+// Package faultsthrift converts faults to Apache Thrift application
+// exceptions, and back.
+//
+// Thrift only defines a handful of exception types, which mostly describe
+// protocol failures. Categories that have an equivalent are mapped to it,
+// e.g. Unimplemented to UNKNOWN_METHOD, while the others are mapped to type
+// ids starting at TypeIDBase. A custom Mapping can be used when a service
+// relies on other conventions.
+package faultsthrift
+
+import (
+	"errors"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/deixis/faults"
+)
+
+// TypeIDBase is the first exception type id used by DefaultMapping for
+// categories that don't have an equivalent in Thrift. The type id of such a
+// category is TypeIDBase plus its gRPC code (see faults.Descriptor).
+const TypeIDBase int32 = 100
+
+// A Mapping converts fault codes to Thrift exception type ids, and back.
+type Mapping interface {
+	// TypeID returns the exception type id of `code`.
+	TypeID(code faults.Code) int32
+	// Code returns the fault code of the exception type id `typeID`.
+	Code(typeID int32) faults.Code
+}
+
+// DefaultMapping is the mapping used when none is given.
+var DefaultMapping Mapping = defaultMapping{}
+
+type defaultMapping struct{}
+
+func (defaultMapping) TypeID(code faults.Code) int32 {
+	switch code {
+	case faults.CodeOK, faults.CodeUnknown:
+		return thrift.UNKNOWN_APPLICATION_EXCEPTION
+	case faults.CodeUnimplemented:
+		return thrift.UNKNOWN_METHOD
+	case faults.CodeInternal:
+		return thrift.INTERNAL_ERROR
+	case faults.CodeBad:
+		return thrift.VALIDATION_FAILED
+	}
+
+	if d, ok := faults.Describe(code); ok {
+		return TypeIDBase + int32(d.GRPCCode)
+	}
+	return thrift.UNKNOWN_APPLICATION_EXCEPTION
+}
+
+// grpcCodes maps gRPC codes back to the categories of the faults package.
+var grpcCodes = map[uint32]faults.Code{}
+
+func init() {
+	for _, code := range []faults.Code{
+		faults.CodeNotFound,
+		faults.CodePermissionDenied,
+		faults.CodeUnauthenticated,
+		faults.CodeFailedPrecondition,
+		faults.CodeAborted,
+		faults.CodeResourceExhausted,
+		faults.CodeUnavailable,
+		faults.CodeDeadlineExceeded,
+	} {
+		d, _ := faults.Describe(code)
+		grpcCodes[d.GRPCCode] = code
+	}
+}
+
+func (defaultMapping) Code(typeID int32) faults.Code {
+	switch typeID {
+	case thrift.UNKNOWN_APPLICATION_EXCEPTION:
+		return faults.CodeUnknown
+	case thrift.UNKNOWN_METHOD:
+		return faults.CodeUnimplemented
+	case thrift.VALIDATION_FAILED:
+		return faults.CodeBad
+	case thrift.INVALID_MESSAGE_TYPE_EXCEPTION,
+		thrift.WRONG_METHOD_NAME,
+		thrift.BAD_SEQUENCE_ID,
+		thrift.MISSING_RESULT,
+		thrift.INTERNAL_ERROR,
+		thrift.PROTOCOL_ERROR,
+		thrift.INVALID_TRANSFORM,
+		thrift.INVALID_PROTOCOL,
+		thrift.UNSUPPORTED_CLIENT_TYPE:
+		return faults.CodeInternal
+	}
+
+	if typeID < TypeIDBase {
+		return faults.CodeUnknown
+	}
+	if code, ok := grpcCodes[uint32(typeID-TypeIDBase)]; ok {
+		return code
+	}
+	return faults.CodeUnknown
+}
+
+// A Converter converts faults to Thrift exceptions, and back, with a custom
+// mapping.
+type Converter struct {
+	// Mapping converts codes to exception type ids. DefaultMapping is used
+	// when it is nil.
+	Mapping Mapping
+}
+
+// ToException converts `err` to an application exception whose type id
+// matches its category. It returns nil when `err` is nil.
+//
+// Like for faults, the message of `err` is only used for client faults.
+// Server faults are described by the message of their category alone (see
+// faults.Sanitize).
+//
+// An error that is already an application exception, and that does not carry
+// a fault, is returned as is.
+func (c Converter) ToException(err error) thrift.TApplicationException {
+	if err == nil {
+		return nil
+	}
+
+	code := faults.CodeOf(err)
+	var ex thrift.TApplicationException
+	if code == faults.CodeUnknown && errors.As(err, &ex) {
+		return ex
+	}
+	message := err.Error()
+	if faults.IsServerFault(err) {
+		message = faults.Sanitize(err).Error()
+	}
+	return thrift.NewTApplicationException(c.mapping().TypeID(code), message)
+}
+
+// FromException converts a Thrift exception back to a fault. The exception is
+// wrapped by the fault, so it can still be retrieved with `errors.As`. Fault
+// exceptions are converted with Fault.Err instead, to restore their details.
+//
+// Transport exceptions are converted too: timeouts become DeadlineExceeded,
+// and other transport failures become Unavailable. Any other error, including
+// errors that already carry a fault, is returned as is.
+func (c Converter) FromException(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := faults.FaultClassifier.Classify(err); ok {
+		return err
+	}
+
+	var fault *Fault
+	if errors.As(err, &fault) {
+		return fault.Err()
+	}
+
+	var appEx thrift.TApplicationException
+	if errors.As(err, &appEx) {
+		d, ok := faults.Describe(c.mapping().Code(appEx.TypeId()))
+		if !ok || d.New == nil {
+			return err
+		}
+		return d.New(err)
+	}
+
+	var transportEx thrift.TTransportException
+	if errors.As(err, &transportEx) {
+		if transportEx.TypeId() == thrift.TIMED_OUT {
+			return faults.WithDeadlineExceeded(err)
+		}
+		return faults.WithUnavailable(err, 0)
+	}
+	return err
+}
+
+func (c Converter) mapping() Mapping {
+	if c.Mapping != nil {
+		return c.Mapping
+	}
+	return DefaultMapping
+}
+
+// ToException converts `err` to an application exception with
+// DefaultMapping. See Converter.ToException.
+func ToException(err error) thrift.TApplicationException {
+	return Converter{}.ToException(err)
+}
+
+// FromException converts a Thrift exception back to a fault with
+// DefaultMapping. See Converter.FromException.
+func FromException(err error) error {
+	return Converter{}.FromException(err)
+}
@@ -0,0 +1,124 @@
+package faultsthrift_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultstest"
+	"github.com/deixis/faults/faultsthrift"
+)
+
+// TestRoundTrip ensures faults survive a conversion to a Thrift exception and
+// back.
+func TestRoundTrip(t *testing.T) {
+	table := []struct {
+		Error  error
+		TypeID int32
+	}{
+		{Error: faults.Bad(), TypeID: thrift.VALIDATION_FAILED},
+		{Error: faults.NotFound, TypeID: 105},
+		{Error: faults.PermissionDenied, TypeID: 107},
+		{Error: faults.Unauthenticated, TypeID: 116},
+		{Error: faults.FailedPrecondition(), TypeID: 109},
+		{Error: faults.Aborted(), TypeID: 110},
+		{Error: faults.ResourceExhausted(), TypeID: 108},
+		{Error: faults.Unavailable(time.Second), TypeID: 114},
+		{Error: faults.DeadlineExceeded, TypeID: 104},
+		{Error: faults.Unimplemented, TypeID: thrift.UNKNOWN_METHOD},
+		{Error: faults.Internal, TypeID: thrift.INTERNAL_ERROR},
+	}
+
+	for i, test := range table {
+		ex := faultsthrift.ToException(test.Error)
+		if ex.TypeId() != test.TypeID {
+			t.Errorf("%d - expect type id %d, but got %d", i, test.TypeID, ex.TypeId())
+		}
+		if ex.Error() != test.Error.Error() {
+			t.Errorf("%d - expect message %q, but got %q", i, test.Error, ex)
+		}
+
+		err := faultsthrift.FromException(ex)
+		if code := faults.CodeOf(err); code != faults.CodeOf(test.Error) {
+			t.Errorf("%d - expect code %s, but got %s", i, faults.CodeOf(test.Error), code)
+		}
+		var got thrift.TApplicationException
+		if !errors.As(err, &got) || got != ex {
+			t.Errorf("%d - expect exception to be wrapped", i)
+		}
+	}
+}
+
+// TestToExceptionSanitize ensures the messages of server faults don't leak
+// their cause.
+func TestToExceptionSanitize(t *testing.T) {
+	ex := faultsthrift.ToException(faults.WithInternal(errors.New("db password leaked")))
+	if ex.Error() != "internal error" {
+		t.Errorf("expect sanitized message, but got %q", ex)
+	}
+}
+
+// TestFromException ensures exceptions that were not produced from a fault
+// are classified too.
+func TestFromException(t *testing.T) {
+	table := []struct {
+		Error error
+		Code  faults.Code
+	}{
+		{Error: nil, Code: faults.CodeOK},
+		{Error: errors.New("boom"), Code: faults.CodeUnknown},
+		{Error: faults.NotFound, Code: faults.CodeNotFound},
+		{Error: thrift.NewTApplicationException(thrift.UNKNOWN_APPLICATION_EXCEPTION, "boom"), Code: faults.CodeUnknown},
+		{Error: thrift.NewTApplicationException(thrift.PROTOCOL_ERROR, "boom"), Code: faults.CodeInternal},
+		{Error: thrift.NewTApplicationException(42, "boom"), Code: faults.CodeUnknown},
+		{Error: thrift.NewTApplicationException(199, "boom"), Code: faults.CodeUnknown},
+		{Error: thrift.NewTTransportException(thrift.TIMED_OUT, "timeout"), Code: faults.CodeDeadlineExceeded},
+		{Error: thrift.NewTTransportException(thrift.NOT_OPEN, "not open"), Code: faults.CodeUnavailable},
+	}
+
+	for i, test := range table {
+		err := faultsthrift.FromException(test.Error)
+		if code := faults.CodeOf(err); code != test.Code {
+			t.Errorf("%d - expect code %s, but got %s", i, test.Code, code)
+		}
+	}
+}
+
+// upperMapping maps every fault to the same exception type id.
+type upperMapping struct{}
+
+func (upperMapping) TypeID(code faults.Code) int32 { return 7000 + int32(code) }
+func (upperMapping) Code(typeID int32) faults.Code { return faults.Code(typeID - 7000) }
+
+// TestConverter ensures a custom mapping can be plugged.
+func TestConverter(t *testing.T) {
+	c := faultsthrift.Converter{Mapping: upperMapping{}}
+
+	ex := c.ToException(faults.NotFound)
+	if ex.TypeId() != 7000+int32(faults.CodeNotFound) {
+		t.Errorf("expect custom type id, but got %d", ex.TypeId())
+	}
+	if code := faults.CodeOf(c.FromException(ex)); code != faults.CodeNotFound {
+		t.Errorf("expect code %s, but got %s", faults.CodeNotFound, code)
+	}
+
+	plain := thrift.NewTApplicationException(thrift.WRONG_METHOD_NAME, "wrong")
+	if ex := c.ToException(plain); ex != plain {
+		t.Errorf("expect plain exception to be returned as is, but got %v", ex)
+	}
+}
+
+// TestConformance ensures application exceptions follow the semantics of the
+// core.
+func TestConformance(t *testing.T) {
+	faultstest.ConformanceSuite{
+		RoundTrip: func(err error) error {
+			if err == nil {
+				return nil
+			}
+			return faultsthrift.FromException(faultsthrift.ToException(err))
+		},
+	}.Run(t)
+}
@@ -0,0 +1,10 @@
+module github.com/deixis/faults/faultsthrift
+
+go 1.22.0
+
+replace github.com/deixis/faults => ../
+
+require (
+	github.com/apache/thrift v0.21.0
+	github.com/deixis/faults v0.0.0-00010101000000-000000000000
+)
@@ -0,0 +1,160 @@
+// Package faultszap provides zap fields to log faults in a structured way.
+package faultszap
+
+import (
+	"sort"
+
+	"github.com/deixis/faults"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Flatten returns a field that logs the tree of `err` as an array of
+// structured entries (see faults.Flatten).
+func Flatten(key string, err error) zap.Field {
+	return zap.Array(key, entries(faults.Flatten(err)))
+}
+
+type entries faults.Entries
+
+func (e entries) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for i := range e {
+		if err := enc.AppendObject(entry(e[i])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type entry faults.Entry
+
+func (e entry) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("code", e.Code.String())
+	enc.AddString("message", e.Message)
+	return nil
+}
+
+// Error returns a field that logs `err` under the key "error", like
+// zap.Error, as a structured object (see Object). It is a no-op for a nil
+// error.
+func Error(err error) zap.Field {
+	return NamedError("error", err)
+}
+
+// NamedError is like Error, with the key `key`.
+func NamedError(key string, err error) zap.Field {
+	if err == nil {
+		return zap.Skip()
+	}
+	return zap.Object(key, Object(err))
+}
+
+// Object returns the object logging `err`, with its message, its code, and
+// when they are set, its reason, the domain of its reason, its retry advice
+// and its violations. With a JSON encoder, a bad request is logged as:
+//
+//	{"message":"email is required","code":"BAD_REQUEST","violations":[{"field":"email","description":"is required"}]}
+func Object(err error) zapcore.ObjectMarshaler {
+	return fault{err}
+}
+
+type fault struct {
+	err error
+}
+
+func (f fault) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("message", f.err.Error())
+	enc.AddString("code", faults.CodeOf(f.err).String())
+	if info, ok := faults.AsErrorInfo(f.err); ok {
+		enc.AddString("reason", info.Reason)
+		if info.Domain != "" {
+			enc.AddString("domain", info.Domain)
+		}
+	}
+	if advice := faults.RetryAdvice(f.err); advice.Retryable {
+		enc.AddBool("retryable", true)
+		if advice.Delay > 0 {
+			enc.AddDuration("retry_delay", advice.Delay)
+		}
+	}
+	if v := violationsOf(f.err); len(v) > 0 {
+		return enc.AddArray("violations", v)
+	}
+	return nil
+}
+
+// violationsOf returns the violations of the fault `err` carries, if any.
+func violationsOf(err error) violations {
+	var v violations
+	for _, x := range faults.ViolationsOf(err) {
+		v = append(v, violation{x})
+	}
+	return v
+}
+
+type violations []violation
+
+func (v violations) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for i := range v {
+		if err := enc.AppendObject(v[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// violation logs the members of a violation, in order. Empty members are not
+// logged.
+type violation struct {
+	faults.Violation
+}
+
+func (v violation) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	var source *faults.Frame
+	switch x := v.Violation.(type) {
+	case *faults.FieldViolation:
+		addStrings(enc, "field", x.Field, "code", x.Code, "description", x.Description, "template", x.Template)
+		if len(x.Params) > 0 {
+			if err := enc.AddObject("params", params(x.Params)); err != nil {
+				return err
+			}
+		}
+		source = x.Source
+	case *faults.PreconditionViolation:
+		addStrings(enc, "type", x.Type, "subject", x.Subject, "description", x.Description)
+		source = x.Source
+	case *faults.ConflictViolation:
+		addStrings(enc, "resource", x.Resource, "description", x.Description)
+	case *faults.QuotaViolation:
+		addStrings(enc, "subject", x.Subject, "description", x.Description)
+	}
+	if source != nil {
+		enc.AddString("source", source.String())
+	}
+	return nil
+}
+
+// addStrings adds the members listed as key/value pairs by `members` to
+// `enc`, unless they are empty.
+func addStrings(enc zapcore.ObjectEncoder, members ...string) {
+	for i := 0; i < len(members); i += 2 {
+		if members[i+1] != "" {
+			enc.AddString(members[i], members[i+1])
+		}
+	}
+}
+
+// params logs the parameters of the template of a violation.
+type params map[string]string
+
+func (p params) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	keys := make([]string, 0, len(p))
+	for k := range p {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		enc.AddString(k, p[k])
+	}
+	return nil
+}
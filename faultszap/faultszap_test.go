@@ -0,0 +1,113 @@
+package faultszap_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+	"github.com/deixis/faults/faultszap"
+	"go.uber.org/zap/zapcore"
+)
+
+// TestFlatten ensures joined faults are logged as an array of entries.
+func TestFlatten(t *testing.T) {
+	enc := zapcore.NewMapObjectEncoder()
+	err := errors.Join(faults.NotFound, faults.Unavailable(0))
+	faultszap.Flatten("errors", err).AddTo(enc)
+
+	expect := []interface{}{
+		map[string]interface{}{"code": "NOT_FOUND", "message": "resource not found"},
+		map[string]interface{}{"code": "UNAVAILABLE", "message": "service temporarily unavailable"},
+	}
+	if !reflect.DeepEqual(expect, enc.Fields["errors"]) {
+		t.Errorf("expect field %v, but got %v", expect, enc.Fields["errors"])
+	}
+}
+
+// TestError ensures faults are logged as structured objects.
+func TestError(t *testing.T) {
+	table := []struct {
+		Error  error
+		Expect map[string]interface{}
+	}{
+		{
+			Error:  faults.NotFound,
+			Expect: map[string]interface{}{"message": "resource not found", "code": "NOT_FOUND"},
+		},
+		{
+			Error: faults.Bad(faults.Field("email", "is required")),
+			Expect: map[string]interface{}{
+				"message": "is required",
+				"code":    "BAD_REQUEST",
+				"violations": []interface{}{
+					map[string]interface{}{"field": "email", "description": "is required"},
+				},
+			},
+		},
+		{
+			Error: faults.WithReason(faults.Unavailable(2*time.Second), "payments.example.com/MAINTENANCE"),
+			Expect: map[string]interface{}{
+				"message":     "service temporarily unavailable, retry in 2s",
+				"code":        "UNAVAILABLE",
+				"reason":      "MAINTENANCE",
+				"domain":      "payments.example.com",
+				"retryable":   true,
+				"retry_delay": 2 * time.Second,
+			},
+		},
+		{
+			Error:  fmt.Errorf("charging: %w", faults.Internal),
+			Expect: map[string]interface{}{"message": "charging: internal error", "code": "INTERNAL"},
+		},
+	}
+
+	for i, test := range table {
+		enc := zapcore.NewMapObjectEncoder()
+		faultszap.Error(test.Error).AddTo(enc)
+		if !reflect.DeepEqual(test.Expect, enc.Fields["error"]) {
+			t.Errorf("%d - expect field %v, but got %v", i, test.Expect, enc.Fields["error"])
+		}
+	}
+}
+
+// TestNamedError ensures the key can be chosen, and nil errors are not
+// logged.
+func TestNamedError(t *testing.T) {
+	enc := zapcore.NewMapObjectEncoder()
+	faultszap.NamedError("cause", faults.NotFound).AddTo(enc)
+	faultszap.Error(nil).AddTo(enc)
+
+	if _, ok := enc.Fields["cause"]; !ok || len(enc.Fields) != 1 {
+		t.Errorf("expect cause field only, but got %v", enc.Fields)
+	}
+}
+
+// TestErrorViolations ensures the templates, the parameters and the sources
+// of violations are logged.
+func TestErrorViolations(t *testing.T) {
+	defer faults.SetRecordHops(false)
+	faults.SetRecordHops(true)
+	err := faults.Bad(faults.FieldTemplate("name", "must be at most {max} characters",
+		map[string]string{"max": "50"}, faults.ViolationTooLong))
+
+	enc := zapcore.NewMapObjectEncoder()
+	faultszap.Error(err).AddTo(enc)
+	violations := enc.Fields["error"].(map[string]interface{})["violations"].([]interface{})
+	if len(violations) != 1 {
+		t.Fatalf("expect 1 violation, but got %v", violations)
+	}
+	v := violations[0].(map[string]interface{})
+	if v["template"] != "must be at most {max} characters" {
+		t.Errorf("expect template, but got %v", v["template"])
+	}
+	if expect := map[string]interface{}{"max": "50"}; !reflect.DeepEqual(expect, v["params"]) {
+		t.Errorf("expect params %v, but got %v", expect, v["params"])
+	}
+	if source, _ := v["source"].(string); !strings.Contains(source, "faultszap_test.go") {
+		t.Errorf("expect source in faultszap_test.go, but got %q", source)
+	}
+}
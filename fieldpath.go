@@ -0,0 +1,88 @@
+package faults
+
+import (
+	"strconv"
+	"strings"
+)
+
+// A FieldPath is the path to a field of a request, in the notation of
+// FieldViolation.Field, which is the notation of protobuf field paths, e.g.
+// "items[3].sku". It is composed segment by segment, so nested paths don't
+// need to be concatenated by hand:
+//
+//	path := faults.FieldPath("items").Index(3).Child("sku")
+//	c.Check(item.SKU != "", path.String(), "SKU required")
+//
+// Transports whose clients expect JSON pointers render paths with Pointer.
+type FieldPath string
+
+// Child returns the path to the field `name` of the message at `p`, or the
+// path to the top-level field `name` when `p` is empty.
+func (p FieldPath) Child(name string) FieldPath {
+	if p == "" {
+		return FieldPath(name)
+	}
+	return p + "." + FieldPath(name)
+}
+
+// Index returns the path to the element `i` of the list at `p`.
+func (p FieldPath) Index(i int) FieldPath {
+	return p + "[" + FieldPath(strconv.Itoa(i)) + "]"
+}
+
+// String returns the path in the notation of FieldViolation.Field.
+func (p FieldPath) String() string {
+	return string(p)
+}
+
+// Pointer returns the path as a JSON pointer (RFC 6901), e.g. "/items/3/sku"
+// for "items[3].sku". It returns "", which points to the whole document, for
+// the empty path.
+func (p FieldPath) Pointer() string {
+	var b strings.Builder
+	for _, segment := range p.segments() {
+		b.WriteByte('/')
+		b.WriteString(strings.NewReplacer("~", "~0", "/", "~1").Replace(segment))
+	}
+	return b.String()
+}
+
+// segments splits `p` into its segments, e.g. "items", "3" and "sku".
+func (p FieldPath) segments() []string {
+	return strings.FieldsFunc(string(p), func(r rune) bool {
+		return r == '.' || r == '[' || r == ']'
+	})
+}
+
+// ParseFieldPath returns the path `field`, written either in the notation of
+// FieldViolation.Field or as a JSON pointer, e.g. "/items/3/sku", like
+// fields received from clients of other conventions. In a pointer, the
+// segments made of digits are indices, except the first one.
+func ParseFieldPath(field string) FieldPath {
+	if !strings.HasPrefix(field, "/") {
+		return FieldPath(field)
+	}
+	var p FieldPath
+	for _, segment := range strings.Split(field[1:], "/") {
+		segment = strings.NewReplacer("~1", "/", "~0", "~").Replace(segment)
+		if isIndex(segment) && p != "" {
+			p += "[" + FieldPath(segment) + "]"
+			continue
+		}
+		p = p.Child(segment)
+	}
+	return p
+}
+
+// isIndex reports whether `segment` is made of digits only.
+func isIndex(segment string) bool {
+	if segment == "" {
+		return false
+	}
+	for _, r := range segment {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,79 @@
+package faults_test
+
+import (
+	"testing"
+
+	"github.com/deixis/faults"
+)
+
+// TestFieldPath ensures paths are composed in the notation of field
+// violations, and rendered as JSON pointers.
+func TestFieldPath(t *testing.T) {
+	table := []struct {
+		Path          faults.FieldPath
+		ExpectString  string
+		ExpectPointer string
+	}{
+		{
+			Path:          "",
+			ExpectString:  "",
+			ExpectPointer: "",
+		},
+		{
+			Path:          faults.FieldPath("email"),
+			ExpectString:  "email",
+			ExpectPointer: "/email",
+		},
+		{
+			Path:          faults.FieldPath("items").Index(3).Child("sku"),
+			ExpectString:  "items[3].sku",
+			ExpectPointer: "/items/3/sku",
+		},
+		{
+			Path:          faults.FieldPath("").Child("address").Child("city"),
+			ExpectString:  "address.city",
+			ExpectPointer: "/address/city",
+		},
+		{
+			Path:          faults.FieldPath("matrix").Index(0).Index(1),
+			ExpectString:  "matrix[0][1]",
+			ExpectPointer: "/matrix/0/1",
+		},
+		{
+			Path:          faults.FieldPath("labels").Child("a/b~c"),
+			ExpectString:  "labels.a/b~c",
+			ExpectPointer: "/labels/a~1b~0c",
+		},
+	}
+
+	for i, test := range table {
+		if got := test.Path.String(); got != test.ExpectString {
+			t.Errorf("%d - expect string %q, but got %q", i, test.ExpectString, got)
+		}
+		if got := test.Path.Pointer(); got != test.ExpectPointer {
+			t.Errorf("%d - expect pointer %q, but got %q", i, test.ExpectPointer, got)
+		}
+	}
+}
+
+// TestParseFieldPath ensures fields are parsed whatever their notation.
+func TestParseFieldPath(t *testing.T) {
+	table := []struct {
+		Field  string
+		Expect faults.FieldPath
+	}{
+		{Field: "", Expect: ""},
+		{Field: "items[3].sku", Expect: "items[3].sku"},
+		{Field: "/items/3/sku", Expect: "items[3].sku"},
+		{Field: "/matrix/0/1", Expect: "matrix[0][1]"},
+		{Field: "/labels/a~1b~0c", Expect: "labels.a/b~c"},
+		{Field: "/0/name", Expect: "0.name"},
+		{Field: "/items/-1", Expect: "items.-1"},
+	}
+
+	for i, test := range table {
+		if got := faults.ParseFieldPath(test.Field); got != test.Expect {
+			t.Errorf("%d - expect %q, but got %q", i, test.Expect, got)
+		}
+	}
+}
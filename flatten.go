@@ -0,0 +1,56 @@
+package faults
+
+import "errors"
+
+// An Entry is the flat representation of a single failure found in an error
+// tree.
+type Entry struct {
+	// Code is the category of the failure.
+	Code Code `json:"code"`
+	// Message is the error message of the failure.
+	Message string `json:"message"`
+}
+
+// Entries is the flat representation of an error tree.
+type Entries []Entry
+
+// Flatten turns the tree of `err` into a list of entries, one per failure
+// joined with `errors.Join` (or any error implementing `Unwrap() []error`).
+// An error without joined failures produces a single entry.
+//
+// Entries are ordered depth-first, in the order the failures were joined, so
+// the output is stable across calls. When a branch does not carry a fault
+// itself, it inherits the code of the fault wrapping the join, if any.
+//
+// Flatten is meant for logging, where a list of structured entries is easier
+// to query than one concatenated message.
+func Flatten(err error) Entries {
+	if err == nil {
+		return nil
+	}
+	return flatten(err, CodeUnknown, nil)
+}
+
+func flatten(err error, inherited Code, entries Entries) Entries {
+	outer, found := inherited, false
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if f, ok := e.(Fault); ok && !found {
+			outer, found = f.Code(), true
+		}
+
+		if x, ok := e.(interface{ Unwrap() []error }); ok {
+			for _, err := range x.Unwrap() {
+				if err != nil {
+					entries = flatten(err, outer, entries)
+				}
+			}
+			return entries
+		}
+	}
+
+	code := CodeOf(err)
+	if code == CodeUnknown {
+		code = inherited
+	}
+	return append(entries, Entry{Code: code, Message: err.Error()})
+}
@@ -0,0 +1,61 @@
+package faults_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/deixis/faults"
+)
+
+// TestFlatten ensures error trees are flattened depth-first, in join order.
+func TestFlatten(t *testing.T) {
+	table := []struct {
+		Error   error
+		Entries faults.Entries
+	}{
+		{
+			Error:   nil,
+			Entries: nil,
+		},
+		{
+			Error: faults.NotFound,
+			Entries: faults.Entries{
+				{Code: faults.CodeNotFound, Message: "resource not found"},
+			},
+		},
+		{
+			Error: errors.Join(faults.NotFound, faults.Unavailable(0)),
+			Entries: faults.Entries{
+				{Code: faults.CodeNotFound, Message: "resource not found"},
+				{Code: faults.CodeUnavailable, Message: "service temporarily unavailable"},
+			},
+		},
+		{
+			Error: fmt.Errorf("sync: %w", errors.Join(
+				faults.PermissionDenied,
+				errors.Join(errors.New("boom"), faults.Unimplemented),
+			)),
+			Entries: faults.Entries{
+				{Code: faults.CodePermissionDenied, Message: "permission denied"},
+				{Code: faults.CodeUnknown, Message: "boom"},
+				{Code: faults.CodeUnimplemented, Message: "unimplemented (yet)"},
+			},
+		},
+		{
+			Error: faults.WithBad(errors.Join(errors.New("a"), faults.NotFound)),
+			Entries: faults.Entries{
+				{Code: faults.CodeBad, Message: "a"},
+				{Code: faults.CodeNotFound, Message: "resource not found"},
+			},
+		},
+	}
+
+	for i, test := range table {
+		entries := faults.Flatten(test.Error)
+		if !reflect.DeepEqual(test.Entries, entries) {
+			t.Errorf("%d - expect entries %v, but got %v", i, test.Entries, entries)
+		}
+	}
+}
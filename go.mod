@@ -1,3 +1,3 @@
 module github.com/deixis/faults
 
-go 1.14
+go 1.22.0
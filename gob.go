@@ -0,0 +1,235 @@
+//go:build !faults_minimal
+
+package faults
+
+import "encoding/gob"
+
+// The fault types, and ErrorInfo, are registered so they can be sent as
+// `error` values, e.g. in the replies of net/rpc services or the messages of
+// worker pools:
+//
+//	type Result struct {
+//		ID  string
+//		Err error
+//	}
+//
+// Their gob representation is their binary encoding (see Encode), which
+// keeps their message, their violations and their retry information, but
+// not their attachments. Other errors, such as those wrapped with
+// fmt.Errorf, are not registered, so they are better sent as the encoding of
+// the whole chain.
+func init() {
+	gob.Register(&AvailabilityFailure{})
+	gob.Register(&QuotaFailure{})
+	gob.Register(&PreconditionFailure{})
+	gob.Register(&BadRequest{})
+	gob.Register(&ConflictFailure{})
+	gob.Register(&MissingFailure{})
+	gob.Register(&PermissionFailure{})
+	gob.Register(&AuthenticationFailure{})
+	gob.Register(&UnimplementedFailure{})
+	gob.Register(&InternalFailure{})
+	gob.Register(&DeadlineFailure{})
+	gob.Register(&ErrorInfo{})
+}
+
+// gobEncode returns the gob representation of the fault `err`.
+func gobEncode(err error) ([]byte, error) {
+	return Encode(err)
+}
+
+// gobDecode decodes the gob representation of a fault of the category `code`,
+// which is about to be decoded into `target`.
+func gobDecode(data []byte, code Code, target error) (*envelope, error) {
+	e, err := decodeEnvelope(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.check(code, target); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// GobEncode implements gob.GobEncoder.
+func (e *AvailabilityFailure) GobEncode() ([]byte, error) {
+	return gobEncode(e)
+}
+
+// GobDecode implements gob.GobDecoder.
+func (e *AvailabilityFailure) GobDecode(data []byte) error {
+	env, err := gobDecode(data, CodeUnavailable, e)
+	if err != nil {
+		return err
+	}
+	*e = AvailabilityFailure{error: env.parent(), RetryInfo: env.retryInfo()}
+	return nil
+}
+
+// GobEncode implements gob.GobEncoder.
+func (e *QuotaFailure) GobEncode() ([]byte, error) {
+	return gobEncode(e)
+}
+
+// GobDecode implements gob.GobDecoder.
+func (e *QuotaFailure) GobDecode(data []byte) error {
+	env, err := gobDecode(data, CodeResourceExhausted, e)
+	if err != nil {
+		return err
+	}
+	*e = QuotaFailure{error: env.parent(), Violations: env.quotaViolations(), RetryInfo: env.retryInfo()}
+	return nil
+}
+
+// GobEncode implements gob.GobEncoder.
+func (e *PreconditionFailure) GobEncode() ([]byte, error) {
+	return gobEncode(e)
+}
+
+// GobDecode implements gob.GobDecoder.
+func (e *PreconditionFailure) GobDecode(data []byte) error {
+	env, err := gobDecode(data, CodeFailedPrecondition, e)
+	if err != nil {
+		return err
+	}
+	*e = PreconditionFailure{error: env.parent(), Violations: env.preconditionViolations()}
+	return nil
+}
+
+// GobEncode implements gob.GobEncoder.
+func (e *BadRequest) GobEncode() ([]byte, error) {
+	return gobEncode(e)
+}
+
+// GobDecode implements gob.GobDecoder.
+func (e *BadRequest) GobDecode(data []byte) error {
+	env, err := gobDecode(data, CodeBad, e)
+	if err != nil {
+		return err
+	}
+	*e = BadRequest{error: env.parent(), Violations: env.fieldViolations()}
+	return nil
+}
+
+// GobEncode implements gob.GobEncoder.
+func (e *ConflictFailure) GobEncode() ([]byte, error) {
+	return gobEncode(e)
+}
+
+// GobDecode implements gob.GobDecoder.
+func (e *ConflictFailure) GobDecode(data []byte) error {
+	env, err := gobDecode(data, CodeAborted, e)
+	if err != nil {
+		return err
+	}
+	*e = ConflictFailure{error: env.parent(), Violations: env.conflictViolations()}
+	return nil
+}
+
+// GobEncode implements gob.GobEncoder.
+func (e *MissingFailure) GobEncode() ([]byte, error) {
+	return gobEncode(e)
+}
+
+// GobDecode implements gob.GobDecoder.
+func (e *MissingFailure) GobDecode(data []byte) error {
+	env, err := gobDecode(data, CodeNotFound, e)
+	if err != nil {
+		return err
+	}
+	*e = MissingFailure{error: env.parent()}
+	return nil
+}
+
+// GobEncode implements gob.GobEncoder.
+func (e *PermissionFailure) GobEncode() ([]byte, error) {
+	return gobEncode(e)
+}
+
+// GobDecode implements gob.GobDecoder.
+func (e *PermissionFailure) GobDecode(data []byte) error {
+	env, err := gobDecode(data, CodePermissionDenied, e)
+	if err != nil {
+		return err
+	}
+	*e = PermissionFailure{error: env.parent()}
+	return nil
+}
+
+// GobEncode implements gob.GobEncoder.
+func (e *AuthenticationFailure) GobEncode() ([]byte, error) {
+	return gobEncode(e)
+}
+
+// GobDecode implements gob.GobDecoder.
+func (e *AuthenticationFailure) GobDecode(data []byte) error {
+	env, err := gobDecode(data, CodeUnauthenticated, e)
+	if err != nil {
+		return err
+	}
+	*e = AuthenticationFailure{error: env.parent(), Challenges: env.challenges()}
+	return nil
+}
+
+// GobEncode implements gob.GobEncoder.
+func (e *UnimplementedFailure) GobEncode() ([]byte, error) {
+	return gobEncode(e)
+}
+
+// GobDecode implements gob.GobDecoder.
+func (e *UnimplementedFailure) GobDecode(data []byte) error {
+	env, err := gobDecode(data, CodeUnimplemented, e)
+	if err != nil {
+		return err
+	}
+	*e = UnimplementedFailure{error: env.parent()}
+	return nil
+}
+
+// GobEncode implements gob.GobEncoder.
+func (e *InternalFailure) GobEncode() ([]byte, error) {
+	return gobEncode(e)
+}
+
+// GobDecode implements gob.GobDecoder.
+func (e *InternalFailure) GobDecode(data []byte) error {
+	env, err := gobDecode(data, CodeInternal, e)
+	if err != nil {
+		return err
+	}
+	*e = InternalFailure{error: env.parent()}
+	return nil
+}
+
+// GobEncode implements gob.GobEncoder.
+func (e *DeadlineFailure) GobEncode() ([]byte, error) {
+	return gobEncode(e)
+}
+
+// GobDecode implements gob.GobDecoder.
+func (e *DeadlineFailure) GobDecode(data []byte) error {
+	env, err := gobDecode(data, CodeDeadlineExceeded, e)
+	if err != nil {
+		return err
+	}
+	*e = DeadlineFailure{error: env.parent()}
+	return nil
+}
+
+// GobEncode implements gob.GobEncoder.
+func (e *ErrorInfo) GobEncode() ([]byte, error) {
+	return gobEncode(e)
+}
+
+// GobDecode implements gob.GobDecoder. The error wrapped by `e` is decoded as
+// the fault it was, or as a plain error.
+func (e *ErrorInfo) GobDecode(data []byte) error {
+	env, err := decodeEnvelope(data)
+	if err != nil {
+		return err
+	}
+	reason, domain, metadata := env.Reason, env.Domain, env.Metadata
+	env.Reason, env.Domain, env.Metadata = "", "", nil
+	*e = ErrorInfo{error: env.fault(), Reason: reason, Domain: domain, Metadata: metadata}
+	return nil
+}
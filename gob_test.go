@@ -0,0 +1,83 @@
+//go:build !faults_minimal
+
+package faults_test
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+)
+
+// TestGob ensures faults survive gob, as `error` values.
+func TestGob(t *testing.T) {
+	type result struct {
+		ID  string
+		Err error
+	}
+
+	table := []error{
+		faults.Bad(&faults.FieldViolation{Field: "email", Code: faults.ViolationRequired, Description: "is required"}),
+		faults.WithNotFound(errors.New("no such user")),
+		faults.PermissionDenied,
+		faults.WithUnauthenticated(errors.New("token expired"), faults.Challenge{Scheme: "Bearer", Realm: "api"}),
+		faults.FailedPrecondition(&faults.PreconditionViolation{Type: "TOS", Subject: "user:42", Description: "not accepted"}),
+		faults.Aborted(&faults.ConflictViolation{Resource: "order:42", Description: "version mismatch"}),
+		faults.ResourceExhausted(&faults.QuotaViolation{Subject: "project:42", Description: "daily limit"}),
+		faults.Unavailable(1500 * time.Millisecond),
+		faults.DeadlineExceeded,
+		faults.Unimplemented,
+		faults.WithInternal(errors.New("pq: connection refused")),
+		faults.WithErrorInfo(faults.NotFound, "USER_NOT_FOUND", map[string]string{"id": "42"}),
+		faults.WithReason(errors.New("boom"), "BOOM"),
+	}
+
+	for i, fault := range table {
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(result{ID: "job-1", Err: fault}); err != nil {
+			t.Fatalf("%d - expect fault to be encoded, but got %s", i, err)
+		}
+		var r result
+		if err := gob.NewDecoder(&buf).Decode(&r); err != nil {
+			t.Fatalf("%d - expect fault to be decoded, but got %s", i, err)
+		}
+
+		err := r.Err
+		if reflect.TypeOf(err) != reflect.TypeOf(fault) {
+			t.Errorf("%d - expect type %T, but got %T", i, fault, err)
+		}
+		if err.Error() != fault.Error() {
+			t.Errorf("%d - expect message %q, but got %q", i, fault, err)
+		}
+		if code := faults.CodeOf(err); code != faults.CodeOf(fault) {
+			t.Errorf("%d - expect code %s, but got %s", i, faults.CodeOf(fault), code)
+		}
+		if expect, delay := faults.RetryAdvice(fault).Delay, faults.RetryAdvice(err).Delay; delay != expect {
+			t.Errorf("%d - expect retry delay %s, but got %s", i, expect, delay)
+		}
+		if expect, reason := faults.ReasonOf(fault), faults.ReasonOf(err); reason != expect {
+			t.Errorf("%d - expect reason %q, but got %q", i, expect, reason)
+		}
+		if expect, challenges := faults.ChallengesOf(fault), faults.ChallengesOf(err); !reflect.DeepEqual(expect, challenges) {
+			t.Errorf("%d - expect challenges %v, but got %v", i, expect, challenges)
+		}
+	}
+}
+
+// TestGobDecode ensures gob representations of another category are
+// rejected.
+func TestGobDecode(t *testing.T) {
+	data, _ := faults.NotFound.(*faults.MissingFailure).GobEncode()
+
+	var e faults.BadRequest
+	if err := e.GobDecode(data); err == nil {
+		t.Error("expect representation of another category to be rejected")
+	}
+	if err := e.GobDecode([]byte{0x0a}); err == nil {
+		t.Error("expect malformed representation to be rejected")
+	}
+}
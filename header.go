@@ -0,0 +1,67 @@
+package faults
+
+import (
+	"encoding/base64"
+	"strings"
+)
+
+// HeaderName is the conventional name of the header carrying a fault encoded
+// with EncodeHeader. Like with gRPC metadata, the "-Bin" suffix tells that
+// its value is base64-encoded binary data.
+const HeaderName = "Fault-Bin"
+
+// maxHeaderSize is the maximum size, in bytes, of the binary encoding carried
+// by a header value, once encoded in base64. Most proxies reject headers
+// larger than 8 KiB.
+const maxHeaderSize = 4 << 10
+
+// EncodeHeader returns the binary representation of `err` (see Encode),
+// encoded in base64, so it fits in a single header value of HTTP, AMQP or
+// Kafka messages. It is meant for sidecars and proxies that forward faults
+// without touching the bodies of the messages.
+//
+//	h.Set(faults.HeaderName, faults.EncodeHeader(faults.Sanitize(err)))
+//
+// Faults are truncated to fit the limits set with SetLimits, and anyway the
+// size of a header value: violations are dropped, then messages shortened,
+// until the value fits in 4 KiB.
+//
+// It returns an empty string when `err` is nil.
+func EncodeHeader(err error) string {
+	if err == nil {
+		return ""
+	}
+	l := currentLimits()
+	if max := base64.StdEncoding.DecodedLen(maxHeaderSize); l.MaxSize <= 0 || l.MaxSize > max {
+		l.MaxSize = max
+	}
+	return base64.StdEncoding.EncodeToString(limitedTo(l, err, encodeEnvelope))
+}
+
+// DecodeHeader converts a header value produced by EncodeHeader back to a
+// fault, like Decode. Values encoded in base64 with or without padding are
+// accepted.
+//
+// It returns nil when `value` is empty, and an Unknown error when `value` is
+// malformed.
+func DecodeHeader(value string) error {
+	e, err := decodeHeader(value)
+	if e == nil {
+		return err
+	}
+	return e.fault()
+}
+
+// decodeHeader decodes the header value of a fault. It returns a nil
+// envelope when `value` is empty or malformed.
+func decodeHeader(value string) (*envelope, error) {
+	value = strings.TrimRight(strings.TrimSpace(value), "=")
+	if value == "" {
+		return nil, nil
+	}
+	data, err := base64.RawStdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errMalformed
+	}
+	return decodeEnvelope(data)
+}
@@ -0,0 +1,91 @@
+package faults_test
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+)
+
+// TestEncodeHeader ensures faults survive a header value.
+func TestEncodeHeader(t *testing.T) {
+	table := []error{
+		faults.Bad(&faults.FieldViolation{Field: "email", Code: faults.ViolationRequired, Description: "is required"}),
+		faults.NotFound,
+		faults.WithUnauthenticated(errors.New("token expired"), faults.Challenge{Scheme: "Bearer", Realm: "api"}),
+		faults.Unavailable(1500 * time.Millisecond),
+		faults.Permanent(faults.Unavailable(time.Second)),
+		faults.WithErrorInfo(faults.NotFound, "USER_NOT_FOUND", map[string]string{"id": "42"}),
+		errors.New("boom"),
+	}
+
+	for i, fault := range table {
+		value := faults.EncodeHeader(fault)
+		if strings.ContainsAny(value, "\r\n ") {
+			t.Errorf("%d - expect a single header value, but got %q", i, value)
+		}
+
+		err := faults.DecodeHeader(value)
+		if err == nil || err.Error() != fault.Error() {
+			t.Errorf("%d - expect message %q, but got %v", i, fault, err)
+		}
+		if code := faults.CodeOf(err); code != faults.CodeOf(fault) {
+			t.Errorf("%d - expect code %s, but got %s", i, faults.CodeOf(fault), code)
+		}
+		if expect, delay := faults.RetryAdvice(fault).Delay, faults.RetryAdvice(err).Delay; delay != expect {
+			t.Errorf("%d - expect retry delay %s, but got %s", i, expect, delay)
+		}
+		if expect, reason := faults.ReasonOf(fault), faults.ReasonOf(err); reason != expect {
+			t.Errorf("%d - expect reason %q, but got %q", i, expect, reason)
+		}
+		if expect, challenges := faults.ChallengesOf(fault), faults.ChallengesOf(err); !reflect.DeepEqual(expect, challenges) {
+			t.Errorf("%d - expect challenges %v, but got %v", i, expect, challenges)
+		}
+
+		// Values without padding are accepted too
+		if err := faults.DecodeHeader(strings.TrimRight(value, "=")); err == nil || err.Error() != fault.Error() {
+			t.Errorf("%d - expect unpadded value to be decoded, but got %v", i, err)
+		}
+	}
+}
+
+// TestEncodeHeaderSize ensures header values stay within the size accepted by
+// proxies.
+func TestEncodeHeaderSize(t *testing.T) {
+	value := faults.EncodeHeader(runaway(100, strings.Repeat("Must be a valid email address. ", 8)))
+	if len(value) > 4<<10 {
+		t.Errorf("expect value of at most 4 KiB, but got %d bytes", len(value))
+	}
+	err := faults.DecodeHeader(value)
+	if !faults.IsTruncated(err) {
+		t.Errorf("expect fault to be truncated, but got %v", err)
+	}
+	if e, ok := faults.AsBad(err); !ok || len(e.Violations) == 0 {
+		t.Errorf("expect first violations to be kept, but got %v", err)
+	}
+}
+
+// TestDecodeHeader ensures empty and malformed values are handled.
+func TestDecodeHeader(t *testing.T) {
+	if value := faults.EncodeHeader(nil); value != "" {
+		t.Errorf("expect empty value, but got %q", value)
+	}
+	if err := faults.DecodeHeader(""); err != nil {
+		t.Errorf("expect nil, but got %v", err)
+	}
+
+	table := []string{
+		"not base64!",
+		base64.StdEncoding.EncodeToString([]byte{0x0a, 0x05, 'a'}),
+	}
+	for i, value := range table {
+		err := faults.DecodeHeader(value)
+		if err == nil || faults.CodeOf(err) != faults.CodeUnknown {
+			t.Errorf("%d - expect malformed value to be rejected, but got %v", i, err)
+		}
+	}
+}
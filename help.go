@@ -0,0 +1,60 @@
+package faults
+
+// A Link points to documentation about a failure, e.g. how to fix a request
+// or how to enable a disabled API.
+type Link struct {
+	// Description describes what the link offers.
+	Description string
+	// URL is the URL of the link.
+	URL string
+}
+
+// Help links a failure to documentation, like the Help detail of gRPC.
+//
+// Help is a detail: it does not have a category of its own, and is meant to
+// be attached to a fault with AttachHelp, or built with the WithHelp option.
+// It is carried by gRPC statuses and problem details documents, but not by
+// the encodings of the core.
+type Help struct {
+	error
+
+	// Links lists the links to the documentation.
+	Links []Link
+}
+
+func (e *Help) Error() string {
+	if e.error == nil {
+		return "help"
+	}
+	return e.error.Error()
+}
+
+func (e *Help) Unwrap() error {
+	return e.error
+}
+
+// AttachHelp attaches links to documentation about `parent`, which keeps its
+// category.
+//
+// Example:
+//
+//	return faults.AttachHelp(faults.PermissionDenied, faults.Link{
+//		Description: "Enable the API",
+//		URL:         "https://example.com/docs/enable",
+//	})
+func AttachHelp(parent error, links ...Link) error {
+	return traced(EventWrap, &Help{error: parent, Links: links})
+}
+
+// HelpOf returns the links attached to the tree of `err`, from the outermost
+// to the innermost Help (see Walk).
+func HelpOf(err error) []Link {
+	var links []Link
+	Walk(err, func(err error) bool {
+		if e, ok := err.(*Help); ok {
+			links = append(links, e.Links...)
+		}
+		return true
+	})
+	return links
+}
@@ -0,0 +1,33 @@
+package faults_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/deixis/faults"
+)
+
+// TestHelp ensures links are attached to faults, which keep their category
+// and their message.
+func TestHelp(t *testing.T) {
+	enable := faults.Link{Description: "Enable the API", URL: "https://example.com/docs/enable"}
+	quotas := faults.Link{Description: "Request a higher quota", URL: "https://example.com/docs/quotas"}
+
+	err := faults.AttachHelp(faults.PermissionDenied, enable)
+	err = faults.AttachHelp(fmt.Errorf("listing invoices: %w", err), quotas)
+
+	if !faults.IsPermissionDenied(err) {
+		t.Errorf("expect code %s, but got %s", faults.CodePermissionDenied, faults.CodeOf(err))
+	}
+	if msg := err.Error(); msg != "listing invoices: permission denied" {
+		t.Errorf("expect message to be kept, but got %q", msg)
+	}
+	expect := []faults.Link{quotas, enable}
+	if got := faults.HelpOf(err); !reflect.DeepEqual(got, expect) {
+		t.Errorf("expect links %v, but got %v", expect, got)
+	}
+	if got := faults.HelpOf(faults.NotFound); got != nil {
+		t.Errorf("expect no links, but got %v", got)
+	}
+}
@@ -0,0 +1,98 @@
+package faults
+
+import (
+	"runtime"
+	"strconv"
+)
+
+// A Frame is a location in the code, e.g. where an error was wrapped.
+type Frame struct {
+	// Function is the fully qualified name of the function, e.g.
+	// "github.com/acme/orders.(*Service).Get".
+	Function string
+	// File is the path of the source file.
+	File string
+	// Line is the line in File.
+	Line int
+}
+
+// String returns the frame as "function file:line", or "file:line" when the
+// function is not known.
+func (f Frame) String() string {
+	location := f.File + ":" + strconv.Itoa(f.Line)
+	if f.Function == "" {
+		return location
+	}
+	return f.Function + " " + location
+}
+
+// SetRecordHops sets whether the wrappers of faults record the location of
+// their caller, e.g. of each call to WithNotFound or Messagef, so Hops can
+// tell the path an error took through the codebase. Unlike a stack trace,
+// each hop costs a single frame. It is disabled by default.
+//
+// The constructors of violations record where they were produced too (see
+// FieldViolation.Source), which tells which validator emitted a violation.
+//
+// Hops are recorded below the fault rather than around it, so the fault
+// keeps its type, and its message. They are not encoded by transports.
+func SetRecordHops(record bool) {
+	configure(func(c *Config) { c.RecordHops = record })
+}
+
+// Hops returns the locations where `err` was wrapped (see SetRecordHops),
+// from the last wrapper applied to the first, like the frames of a stack
+// trace. It returns nil when no hop was recorded.
+func Hops(err error) []Frame {
+	var frames []Frame
+	walk(err, func(err error) bool {
+		if h, ok := err.(*hop); ok {
+			frames = append(frames, h.frame)
+		}
+		return true
+	})
+	return frames
+}
+
+// hop records where its error was wrapped.
+type hop struct {
+	error
+	frame Frame
+}
+
+func (h *hop) Unwrap() error {
+	return h.error
+}
+
+// hopped returns `parent` with the location of the caller `skip` frames
+// above hopped, when hops are recorded and `parent` is not nil.
+func hopped(parent error, skip int) error {
+	if parent == nil || !settings().RecordHops {
+		return parent
+	}
+	return &hop{error: parent, frame: caller(skip)}
+}
+
+// sourceOf returns the location of the caller `skip` frames above the caller
+// of sourceOf, when hops are recorded, e.g. the source of a violation.
+func sourceOf(skip int) *Frame {
+	if !settings().RecordHops {
+		return nil
+	}
+	f := caller(skip + 1)
+	return &f
+}
+
+// caller returns the location of the caller `skip` frames above the caller
+// of caller, trimmed like the frames of stacks.
+func caller(skip int) Frame {
+	var f Frame
+	if pc, file, line, ok := runtime.Caller(skip + 1); ok {
+		if fn := runtime.FuncForPC(pc); fn != nil {
+			f.Function = fn.Name()
+		}
+		f.File = stackOptions().trim(f.Function, file)
+		f.Line = line
+	}
+	return f
+}
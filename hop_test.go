@@ -0,0 +1,114 @@
+package faults_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/deixis/faults"
+)
+
+// loadUser and getUser wrap errors like the layers of a service.
+func loadUser() error {
+	return faults.WithNotFound(errors.New("no such user"))
+}
+
+func getUser() error {
+	return faults.Messagef(loadUser(), "getting user %d", 42)
+}
+
+// TestHops ensures the location of each wrapper is recorded, from the last
+// one applied to the first.
+func TestHops(t *testing.T) {
+	faults.SetRecordHops(true)
+	defer faults.SetRecordHops(false)
+
+	err := fmt.Errorf("handler: %w", faults.WithReason(getUser(), "USER_NOT_FOUND"))
+	hops := faults.Hops(err)
+	expect := []string{"faults_test.TestHops", "faults_test.getUser", "faults_test.loadUser"}
+	if len(hops) != len(expect) {
+		t.Fatalf("expect %d hops, but got %v", len(expect), hops)
+	}
+	for i, fn := range expect {
+		if !strings.HasSuffix(hops[i].Function, fn) || !strings.HasSuffix(hops[i].File, "hop_test.go") || hops[i].Line == 0 {
+			t.Errorf("%d - expect hop in %s, but got %s", i, fn, hops[i])
+		}
+	}
+
+	// Hops don't change the faults they are recorded in
+	if _, ok := loadUser().(*faults.MissingFailure); !ok {
+		t.Errorf("expect missing failure, but got %T", loadUser())
+	}
+	if msg := err.Error(); msg != "handler: getting user 42: resource not found" {
+		t.Errorf("expect message to be kept, but got %q", msg)
+	}
+	if !faults.IsNotFound(err) || faults.ReasonOf(err) != "USER_NOT_FOUND" {
+		t.Errorf("expect fault details to be kept, but got %v", err)
+	}
+}
+
+// TestHopsBuilder ensures faults built around an error record where they
+// were built.
+func TestHopsBuilder(t *testing.T) {
+	faults.SetRecordHops(true)
+	defer faults.SetRecordHops(false)
+
+	table := []error{
+		faults.Build(faults.CodeAborted).Wrap(errors.New("version mismatch")).Err(),
+		faults.New(faults.CodeAborted, "", faults.WithCause(errors.New("version mismatch"))),
+		faults.WithNotFound(errors.New("no rows"), faults.WithMessage("loading user")),
+	}
+	for i, err := range table {
+		if hops := faults.Hops(err); len(hops) != 1 || !strings.HasSuffix(hops[0].Function, "faults_test.TestHopsBuilder") {
+			t.Errorf("%d - expect hop in TestHopsBuilder, but got %v", i, hops)
+		}
+	}
+	if hops := faults.Hops(faults.Build(faults.CodeAborted).Message("version mismatch").Err()); hops != nil {
+		t.Errorf("expect no hop for a created fault, but got %v", hops)
+	}
+}
+
+// TestHopsTrimmed ensures the files of hops are trimmed like the files of
+// stacks.
+func TestHopsTrimmed(t *testing.T) {
+	wd, _ := os.Getwd()
+	faults.SetRecordHops(true)
+	faults.SetStackOptions(faults.StackOptions{TrimPrefixes: []string{wd + "/"}})
+	defer faults.SetRecordHops(false)
+	defer faults.SetStackOptions(faults.StackOptions{})
+
+	if hops := faults.Hops(loadUser()); len(hops) != 1 || hops[0].File != "hop_test.go" {
+		t.Errorf("expect trimmed hop, but got %v", hops)
+	}
+}
+
+// TestHopsDisabled ensures no hop is recorded by default.
+func TestHopsDisabled(t *testing.T) {
+	parent := errors.New("no such user")
+	err := faults.WithNotFound(parent)
+	if hops := faults.Hops(err); hops != nil {
+		t.Errorf("expect no hop, but got %v", hops)
+	}
+	if errors.Unwrap(err) != parent {
+		t.Errorf("expect parent to be wrapped as is, but got %v", errors.Unwrap(err))
+	}
+}
+
+// TestFrame ensures frames are written like the locations of stack traces.
+func TestFrame(t *testing.T) {
+	table := []struct {
+		Frame  faults.Frame
+		Expect string
+	}{
+		{Frame: faults.Frame{Function: "main.run", File: "/src/main.go", Line: 12}, Expect: "main.run /src/main.go:12"},
+		{Frame: faults.Frame{File: "/src/main.go", Line: 12}, Expect: "/src/main.go:12"},
+	}
+
+	for i, test := range table {
+		if s := test.Frame.String(); s != test.Expect {
+			t.Errorf("%d - expect %q, but got %q", i, test.Expect, s)
+		}
+	}
+}
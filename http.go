@@ -0,0 +1,264 @@
+//go:build !faults_minimal
+
+package faults
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxBodySize is the maximum number of bytes read from the body of an HTTP
+// response to reconstruct a fault. It matches the maximum size of the faults
+// encoded with DefaultLimits, so the documents of servers using the default
+// limits are read whole.
+const MaxBodySize = 64 << 10
+
+// FromHTTPResponse reconstructs the fault described by `resp`, so HTTP
+// clients can inspect failures with the same functions as servers, e.g.
+// IsNotFound or RetryAdvice. It returns nil when the status of `resp` is not
+// an error (below 400).
+//
+// The status is mapped back to its category with CodeOfHTTPStatus.
+//
+// The message of the fault is read from the body when it is plain text, such
+// as the ones written by `http.Error`. Otherwise, the status text is used.
+// The body is consumed, but not closed. Bodies larger than MaxBodySize are
+// cut, and the fault is marked as truncated (see IsTruncated). The retry delay of Unavailable and
+// ResourceExhausted faults is read from the Retry-After header, and the
+// challenges of Unauthenticated faults from the WWW-Authenticate header, if
+// any.
+//
+// JSON bodies produced by EncodeJSON ("application/json"), and problem
+// details documents ("application/problem+json", see faultshttp), are
+// decoded instead, with their category, their violations, their reason and
+// their retry delay. The attachments and the help links of problem details
+// documents are only restored by faultshttp.ReadProblem.
+//
+// Responses carrying a fault in the Fault-Bin header (see SetFaultHeader),
+// e.g. forwarded by a proxy, are described by that fault instead, whatever
+// their status and their body, which is not read.
+//
+// Example:
+//
+//	resp, err := client.Do(req)
+//	if err != nil {
+//		return err
+//	}
+//	defer resp.Body.Close()
+//	if err := faults.FromHTTPResponse(resp); err != nil {
+//		return err
+//	}
+func FromHTTPResponse(resp *http.Response) error {
+	if resp == nil || resp.StatusCode < 400 {
+		return nil
+	}
+
+	if e, _ := decodeHeader(resp.Header.Get(HeaderName)); e != nil {
+		return e.fault()
+	}
+	body, truncated := responseBody(resp)
+	err := responseFault(resp, body)
+	if truncated {
+		return Truncated(err)
+	}
+	return err
+}
+
+// responseFault returns the fault described by `resp`, whose body is `body`.
+func responseFault(resp *http.Response, body []byte) error {
+	if e := responseEnvelope(resp, body); e != nil {
+		return e.fault()
+	}
+
+	parent := errors.New(responseMessage(resp, body))
+	code := CodeOfHTTPStatus(resp.StatusCode)
+	switch code {
+	case CodeUnauthenticated:
+		return WithUnauthenticated(parent, ParseWWWAuthenticate(resp.Header)...)
+	case CodeUnavailable:
+		return WithUnavailable(parent, ParseRetryAfter(resp.Header.Get("Retry-After")))
+	case CodeResourceExhausted:
+		err := WithResourceExhausted(parent)
+		if e, ok := err.(*QuotaFailure); ok {
+			e.RetryInfo.RetryDelay = ParseRetryAfter(resp.Header.Get("Retry-After"))
+		}
+		return err
+	}
+	if d, ok := Describe(code); ok && d.New != nil {
+		return d.New(parent)
+	}
+	return parent
+}
+
+// responseBody reads the body of `resp`, up to MaxBodySize bytes, when it may
+// describe a fault, i.e. when it is plain text, JSON, or of an unknown type.
+// It reports whether the body was cut.
+func responseBody(resp *http.Response) ([]byte, bool) {
+	if resp.Body == nil {
+		return nil, false
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "" {
+		mediaType, _, err := mime.ParseMediaType(ct)
+		if err != nil {
+			return nil, false
+		}
+		switch mediaType {
+		case "text/plain", "application/json", problemContentType:
+		default:
+			return nil, false
+		}
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, MaxBodySize+1))
+	if err != nil {
+		return nil, false
+	}
+	if len(body) > MaxBodySize {
+		return body[:MaxBodySize], true
+	}
+	return body, false
+}
+
+// SetRetryAfter sets the Retry-After header of an HTTP response when `err`
+// advises a retry delay (see RetryAdvice). The delay is rounded up to the
+// second, since the header doesn't have a finer resolution.
+func SetRetryAfter(h http.Header, err error) {
+	advice := RetryAdvice(err)
+	if advice.Delay <= 0 {
+		return
+	}
+	seconds := (advice.Delay + time.Second - 1) / time.Second
+	h.Set("Retry-After", strconv.FormatInt(int64(seconds), 10))
+}
+
+// SetFaultHeader sets the Fault-Bin header of an HTTP response or request to
+// the encoding of `err` (see EncodeHeader), so proxies and clients can forward
+// and reconstruct it without reading the body. It does nothing when `err` is
+// nil.
+func SetFaultHeader(h http.Header, err error) {
+	if err == nil {
+		return
+	}
+	h.Set(HeaderName, EncodeHeader(err))
+}
+
+// SetWWWAuthenticate adds a WWW-Authenticate header to an HTTP response for
+// each challenge carried by `err` (see AuthenticationFailure).
+func SetWWWAuthenticate(h http.Header, err error) {
+	for _, c := range ChallengesOf(err) {
+		h.Add("WWW-Authenticate", c.String())
+	}
+}
+
+// ParseWWWAuthenticate returns the challenges listed by the WWW-Authenticate
+// headers of `h` (see ParseChallenges).
+func ParseWWWAuthenticate(h http.Header) []Challenge {
+	var challenges []Challenge
+	for _, v := range h.Values("WWW-Authenticate") {
+		challenges = append(challenges, ParseChallenges(v)...)
+	}
+	return challenges
+}
+
+// ParseRetryAfter parses the value of a Retry-After header, which is either
+// a number of seconds or an HTTP date. It returns 0 when the value is empty,
+// invalid, or in the past.
+func ParseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds <= 0 || seconds > int64(math.MaxInt64/time.Second) {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// problemContentType is the media type of problem details documents (see
+// faultshttp.ProblemContentType).
+const problemContentType = "application/problem+json"
+
+// responseEnvelope returns the envelope of the fault described by the body of
+// `resp`, when it is encoded with EncodeJSON or is a problem details
+// document. It returns nil when it is neither.
+func responseEnvelope(resp *http.Response, body []byte) *envelope {
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || (mediaType != "application/json" && mediaType != problemContentType) {
+		return nil
+	}
+
+	// Problem details documents carry the members of the envelope as
+	// extension members, and describe the error wrapped by the fault
+	var doc struct {
+		envelope
+		Title  string `json:"title"`
+		Detail string `json:"detail"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil
+	}
+	e := &doc.envelope
+	_, err = ParseCode(e.Code)
+	switch {
+	case mediaType != problemContentType:
+		// Other JSON documents may have a code member of their own
+		if err != nil || e.Version <= 0 {
+			return nil
+		}
+	default:
+		if err != nil {
+			e.Code = CodeOfHTTPStatus(resp.StatusCode).String()
+		}
+		if e.Cause = doc.Detail; e.Cause == "" {
+			e.Cause = doc.Title
+		}
+		for i := range e.Violations {
+			if v := &e.Violations[i]; v.Field != "" {
+				v.Field = ParseFieldPath(v.Field).String()
+			}
+		}
+	}
+
+	if e.RetryDelay <= 0 {
+		e.RetryDelay = ParseRetryAfter(resp.Header.Get("Retry-After")).Milliseconds()
+	}
+	if len(e.Challenges) == 0 {
+		for _, c := range ParseWWWAuthenticate(resp.Header) {
+			e.Challenges = append(e.Challenges, c.String())
+		}
+	}
+	return e
+}
+
+// responseMessage returns the error message carried by `body`, the body of
+// `resp`, or its status when the body is empty or not plain text.
+func responseMessage(resp *http.Response, body []byte) string {
+	status := resp.Status
+	if status == "" {
+		status = strconv.Itoa(resp.StatusCode) + " " + http.StatusText(resp.StatusCode)
+	}
+
+	if ct := resp.Header.Get("Content-Type"); ct != "" {
+		if mediaType, _, err := mime.ParseMediaType(ct); err != nil || mediaType != "text/plain" {
+			return status
+		}
+	}
+	if msg := strings.TrimSpace(string(body)); msg != "" {
+		return msg
+	}
+	return status
+}
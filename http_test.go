@@ -0,0 +1,346 @@
+//go:build !faults_minimal
+
+package faults_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+)
+
+// TestFromHTTPResponse ensures faults survive an HTTP response.
+func TestFromHTTPResponse(t *testing.T) {
+	table := []error{
+		faults.Bad(),
+		faults.Unauthenticated,
+		faults.PermissionDenied,
+		faults.NotFound,
+		faults.Aborted(),
+		faults.ResourceExhausted(),
+		faults.Internal,
+		faults.Unimplemented,
+		faults.Unavailable(0),
+		faults.DeadlineExceeded,
+	}
+
+	for i, fault := range table {
+		rec := httptest.NewRecorder()
+		http.Error(rec, fault.Error(), faults.HTTPStatus(fault))
+
+		err := faults.FromHTTPResponse(rec.Result())
+		if code := faults.CodeOf(err); code != faults.CodeOf(fault) {
+			t.Errorf("%d - expect code %s, but got %s", i, faults.CodeOf(fault), code)
+		}
+		if msg := errors.Unwrap(err).Error(); msg != fault.Error() {
+			t.Errorf("%d - expect message %q, but got %q", i, fault, msg)
+		}
+	}
+}
+
+// TestFromHTTPResponseStatus ensures responses that were not produced from a
+// fault are classified too.
+func TestFromHTTPResponseStatus(t *testing.T) {
+	table := []struct {
+		Response *http.Response
+		Code     faults.Code
+		Message  string
+	}{
+		{Response: nil, Code: faults.CodeOK},
+		{Response: response(200, "", "ok"), Code: faults.CodeOK},
+		{Response: response(304, "", ""), Code: faults.CodeOK},
+		{Response: response(404, "", ""), Code: faults.CodeNotFound, Message: "404 Not Found"},
+		{Response: response(410, "", ""), Code: faults.CodeNotFound, Message: "410 Gone"},
+		{Response: response(412, "", ""), Code: faults.CodeFailedPrecondition, Message: "412 Precondition Failed"},
+		{Response: response(402, "", ""), Code: codePaymentRequired, Message: "402 Payment Required"},
+		{Response: response(418, "", ""), Code: faults.CodeBad, Message: "418 I'm a teapot"},
+		{Response: response(502, "", ""), Code: faults.CodeUnavailable, Message: "502 Bad Gateway"},
+		{Response: response(507, "", ""), Code: faults.CodeInternal, Message: "507 Insufficient Storage"},
+		{
+			Response: response(404, "text/plain; charset=utf-8", "no such user\n"),
+			Code:     faults.CodeNotFound,
+			Message:  "no such user",
+		},
+		{
+			Response: response(404, "text/html", "<h1>Not Found</h1>"),
+			Code:     faults.CodeNotFound,
+			Message:  "404 Not Found",
+		},
+	}
+
+	for i, test := range table {
+		err := faults.FromHTTPResponse(test.Response)
+		if code := faults.CodeOf(err); code != test.Code {
+			t.Errorf("%d - expect code %s, but got %s", i, test.Code, code)
+		}
+		if err == nil {
+			continue
+		}
+		if msg := errors.Unwrap(err).Error(); msg != test.Message {
+			t.Errorf("%d - expect message %q, but got %q", i, test.Message, msg)
+		}
+	}
+}
+
+// TestFromHTTPResponseJSON ensures the faults described by JSON bodies are
+// restored with their details.
+func TestFromHTTPResponseJSON(t *testing.T) {
+	fault := faults.WithReason(faults.Bad(&faults.FieldViolation{Field: "email", Description: "is required"}), "accounts.example.com/INVALID_FORM")
+	data, err := faults.EncodeJSON(fault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	problem := `{"type":"about:blank","title":"Too Many Requests","status":429,"detail":"slow down","code":"RESOURCE_EXHAUSTED",` +
+		`"violations":[{"subject":"user:42","description":"daily limit"}],"reason":"DAILY_LIMIT"}`
+
+	table := []struct {
+		Response *http.Response
+		Code     faults.Code
+		Message  string
+		Reason   string
+		Delay    time.Duration
+	}{
+		{
+			Response: response(400, "application/json", string(data)),
+			Code:     faults.CodeBad,
+			Message:  fault.Error(),
+			Reason:   "INVALID_FORM",
+		},
+		{
+			Response: response(429, "application/problem+json", problem),
+			Code:     faults.CodeResourceExhausted,
+			Message:  "slow down",
+			Reason:   "DAILY_LIMIT",
+			Delay:    30 * time.Second,
+		},
+		{
+			Response: response(404, "application/problem+json", `{"title":"Not Found","status":404,"detail":"no such user"}`),
+			Code:     faults.CodeNotFound,
+			Message:  "no such user",
+		},
+		{
+			Response: response(404, "application/json", `{"code":"E_USER","message":"no such user"}`),
+			Code:     faults.CodeNotFound,
+			Message:  "404 Not Found",
+		},
+		{
+			Response: response(500, "application/json", `{"version":`),
+			Code:     faults.CodeInternal,
+			Message:  "500 Internal Server Error",
+		},
+	}
+
+	for i, test := range table {
+		test.Response.Header.Set("Retry-After", "30")
+		err := faults.FromHTTPResponse(test.Response)
+		if code := faults.CodeOf(err); code != test.Code {
+			t.Errorf("%d - expect code %s, but got %s", i, test.Code, code)
+		}
+		if !describes(err, test.Message) {
+			t.Errorf("%d - expect message %q, but got %q", i, test.Message, err)
+		}
+		if reason := faults.ReasonOf(err); reason != test.Reason {
+			t.Errorf("%d - expect reason %q, but got %q", i, test.Reason, reason)
+		}
+		if delay := faults.RetryAdvice(err).Delay; delay != test.Delay {
+			t.Errorf("%d - expect retry delay %s, but got %s", i, test.Delay, delay)
+		}
+	}
+
+	bad, ok := faults.AsBad(faults.FromHTTPResponse(response(400, "application/json", string(data))))
+	if !ok || len(bad.Violations) != 1 || bad.Violations[0].Field != "email" {
+		t.Errorf("expect violation of email, but got %v", bad)
+	}
+	quota, ok := faults.AsResourceExhausted(faults.FromHTTPResponse(response(429, "application/problem+json", problem)))
+	if !ok || len(quota.Violations) != 1 || quota.Violations[0].Subject != "user:42" {
+		t.Errorf("expect quota violation of user:42, but got %v", quota)
+	}
+}
+
+// TestFromHTTPResponseLarge ensures large bodies are decoded whole, and the
+// faults described by bodies too large to be read are marked as truncated.
+func TestFromHTTPResponseLarge(t *testing.T) {
+	var violations []string
+	for i := 0; i < 80; i++ {
+		violations = append(violations, fmt.Sprintf(`{"field":"rows[%d].email","description":"%s"}`, i, strings.Repeat("x", 80)))
+	}
+	data := `{"title":"Bad Request","status":400,"code":"BAD_REQUEST","violations":[` + strings.Join(violations, ",") + `]}`
+	if len(data) <= 4<<10 {
+		t.Fatalf("expect body over 4KB, but got %d bytes", len(data))
+	}
+
+	err := faults.FromHTTPResponse(response(400, "application/problem+json", data))
+	if e, ok := faults.AsBad(err); !ok || len(e.Violations) != 80 {
+		t.Errorf("expect 80 violations, but got %v", err)
+	}
+	if faults.IsTruncated(err) {
+		t.Error("expect fault not to be truncated")
+	}
+
+	table := []*http.Response{
+		response(400, "application/problem+json", data+strings.Repeat(" ", faults.MaxBodySize)),
+		response(500, "text/plain", strings.Repeat("x", faults.MaxBodySize+1)),
+	}
+	for i, resp := range table {
+		err := faults.FromHTTPResponse(resp)
+		if code := faults.CodeOf(err); code != faults.CodeOfHTTPStatus(resp.StatusCode) {
+			t.Errorf("%d - expect code %s, but got %s", i, faults.CodeOfHTTPStatus(resp.StatusCode), code)
+		}
+		if !faults.IsTruncated(err) {
+			t.Errorf("%d - expect fault to be truncated", i)
+		}
+	}
+}
+
+// TestRetryAfter ensures retry delays survive the Retry-After header.
+func TestRetryAfter(t *testing.T) {
+	exhausted := faults.ResourceExhausted()
+	exhausted.(*faults.QuotaFailure).RetryInfo.RetryDelay = 3 * time.Second
+
+	table := []struct {
+		Error      error
+		RetryAfter string
+		Delay      time.Duration
+	}{
+		{Error: faults.Unavailable(2 * time.Second), RetryAfter: "2", Delay: 2 * time.Second},
+		{Error: faults.Unavailable(1500 * time.Millisecond), RetryAfter: "2", Delay: 2 * time.Second},
+		{Error: exhausted, RetryAfter: "3", Delay: 3 * time.Second},
+		{Error: faults.Unavailable(0)},
+		{Error: faults.ResourceExhausted()},
+		{Error: faults.NotFound},
+	}
+
+	for i, test := range table {
+		rec := httptest.NewRecorder()
+		faults.SetRetryAfter(rec.Header(), test.Error)
+		http.Error(rec, test.Error.Error(), faults.HTTPStatus(test.Error))
+
+		if ra := rec.Header().Get("Retry-After"); ra != test.RetryAfter {
+			t.Errorf("%d - expect Retry-After %q, but got %q", i, test.RetryAfter, ra)
+		}
+		err := faults.FromHTTPResponse(rec.Result())
+		if delay := faults.RetryAdvice(err).Delay; delay != test.Delay {
+			t.Errorf("%d - expect retry delay %s, but got %s", i, test.Delay, delay)
+		}
+	}
+}
+
+// TestParseRetryAfter ensures both forms of the Retry-After header are
+// parsed.
+func TestParseRetryAfter(t *testing.T) {
+	table := []struct {
+		Value string
+		Min   time.Duration
+		Max   time.Duration
+	}{
+		{Value: ""},
+		{Value: "0"},
+		{Value: "-1"},
+		{Value: "soon"},
+		{Value: "99999999999999999999"},
+		{Value: "120", Min: 2 * time.Minute, Max: 2 * time.Minute},
+		{Value: time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)},
+		{
+			Value: time.Now().Add(time.Hour).UTC().Format(http.TimeFormat),
+			Min:   58 * time.Minute,
+			Max:   time.Hour,
+		},
+	}
+
+	for i, test := range table {
+		if d := faults.ParseRetryAfter(test.Value); d < test.Min || d > test.Max {
+			t.Errorf("%d - expect delay in [%s, %s], but got %s", i, test.Min, test.Max, d)
+		}
+	}
+}
+
+// TestWWWAuthenticate ensures challenges survive the WWW-Authenticate header.
+func TestWWWAuthenticate(t *testing.T) {
+	challenges := []faults.Challenge{
+		{Scheme: "Bearer", Realm: "api", Params: map[string]string{"error": "invalid_token"}},
+		{Scheme: "Basic", Realm: "api"},
+	}
+	rec := httptest.NewRecorder()
+	fault := faults.WithUnauthenticated(nil, challenges...)
+	faults.SetWWWAuthenticate(rec.Header(), fault)
+	http.Error(rec, fault.Error(), faults.HTTPStatus(fault))
+
+	if values := rec.Header().Values("WWW-Authenticate"); len(values) != 2 {
+		t.Errorf("expect 2 WWW-Authenticate headers, but got %q", values)
+	}
+	err := faults.FromHTTPResponse(rec.Result())
+	if !faults.IsUnauthenticated(err) {
+		t.Errorf("expect code %s, but got %s", faults.CodeUnauthenticated, faults.CodeOf(err))
+	}
+	if got := faults.ChallengesOf(err); !reflect.DeepEqual(got, challenges) {
+		t.Errorf("expect challenges %+v, but got %+v", challenges, got)
+	}
+}
+
+// TestFaultHeader ensures faults forwarded in the Fault-Bin header prevail
+// over the status and the body of responses.
+func TestFaultHeader(t *testing.T) {
+	fault := faults.WithErrorInfo(faults.Bad(
+		&faults.FieldViolation{Field: "email", Code: faults.ViolationRequired, Description: "is required"},
+	), "INVALID_FORM", map[string]string{"form": "signup"})
+
+	rec := httptest.NewRecorder()
+	faults.SetFaultHeader(rec.Header(), fault)
+	http.Error(rec, "upstream failure", http.StatusBadGateway)
+
+	err := faults.FromHTTPResponse(rec.Result())
+	e, ok := faults.AsBad(err)
+	if !ok {
+		t.Fatalf("expect bad request, but got %s %v", faults.CodeOf(err), err)
+	}
+	expect, _ := faults.AsBad(fault)
+	if !reflect.DeepEqual(e.Violations, expect.Violations) {
+		t.Errorf("expect violations %v, but got %v", expect.Violations, e.Violations)
+	}
+	if reason := faults.ReasonOf(err); reason != "INVALID_FORM" {
+		t.Errorf("expect reason INVALID_FORM, but got %q", reason)
+	}
+
+	// Malformed headers are ignored
+	resp := response(http.StatusNotFound, "text/plain", "no such user")
+	resp.Header.Set(faults.HeaderName, "not base64!")
+	if err := faults.FromHTTPResponse(resp); !faults.IsNotFound(err) || errors.Unwrap(err).Error() != "no such user" {
+		t.Errorf("expect fault described by the response, but got %v", err)
+	}
+
+	h := http.Header{}
+	faults.SetFaultHeader(h, nil)
+	if _, ok := h[faults.HeaderName]; ok {
+		t.Error("expect no header for nil")
+	}
+}
+
+func response(status int, contentType, body string) *http.Response {
+	resp := &http.Response{
+		StatusCode: status,
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+	if contentType != "" {
+		resp.Header.Set("Content-Type", contentType)
+	}
+	return resp
+}
+
+// describes returns whether `err`, or one of the errors it wraps, has a
+// message containing `message`.
+func describes(err error, message string) bool {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if strings.Contains(err.Error(), message) {
+			return true
+		}
+	}
+	return false
+}
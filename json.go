@@ -0,0 +1,262 @@
+//go:build !faults_minimal
+
+package faults
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// EncodeJSON returns the JSON representation of `err`, which DecodeJSON
+// converts back to a fault. It is meant for HTTP bodies, message queues and
+// logs, where faults must be round-tripped without parsing their message.
+//
+// The representation is an object with the following members, of which only
+// "version" and "code" are always present:
+//
+//	{
+//	  "version": 4,
+//	  "code": "BAD_REQUEST",
+//	  "message": "bad request: email is required",
+//	  "cause": "email is required",
+//	  "violations": [
+//	    {"field": "email", "code": "REQUIRED", "description": "is required"},
+//	    {"field": "name", "description": "must be at most 50 characters", "template": "must be at most {max} characters", "params": {"max": "50"}}
+//	  ],
+//	  "retryDelay": 1500,
+//	  "reason": "INVALID_FORM",
+//	  "domain": "accounts.example.com",
+//	  "metadata": {"form": "signup"},
+//	  "permanent": true,
+//	  "challenges": ["Bearer realm=\"api\""],
+//	  "truncated": true
+//	}
+//
+// "cause" is the message of the error wrapped by the fault, "retryDelay" is
+// expressed in milliseconds, and challenges are written as in a
+// WWW-Authenticate header. "domain" is the domain of the reason, if it is
+// scoped (see ErrorInfo). Violations only have the members of their Go type,
+// e.g. "resource" and "description" for a ConflictViolation, and "template"
+// and "params" are the template of the description of a field violation and
+// its parameters (see FieldTemplate). "truncated" reports that details were
+// dropped to fit the limits set with SetLimits. "version" is the version of
+// the schema (see SchemaVersion): members may be added by later versions, but
+// not removed or changed.
+//
+// Like with Encode, the message of `err` is encoded as is, attachments are
+// not encoded, and faults exceeding the limits set with SetLimits are
+// truncated.
+//
+// It returns "null" when `err` is nil.
+func EncodeJSON(err error) ([]byte, error) {
+	if err == nil {
+		return []byte("null"), nil
+	}
+	return marshalJSON(err)
+}
+
+// DecodeJSON converts the JSON representation produced by EncodeJSON back to
+// a fault. Representations of later versions are decoded too, by skipping
+// the members that are not known to this version (see SchemaVersion), and
+// categories that are not registered in this process are decoded as plain
+// errors carrying the original message.
+//
+// It returns nil when `data` is "null", and an Unknown error when `data` is
+// malformed.
+func DecodeJSON(data []byte) error {
+	var e *envelope
+	if err := json.Unmarshal(data, &e); err != nil {
+		return fmt.Errorf("faults: malformed JSON encoding: %w", err)
+	}
+	if e == nil {
+		return nil
+	}
+	return e.fault()
+}
+
+func marshalJSON(err error) ([]byte, error) {
+	return limited(err, func(e *envelope) []byte {
+		// An envelope only has strings, numbers and booleans, which are
+		// always marshalled
+		b, _ := json.Marshal(e)
+		return b
+	}), nil
+}
+
+// unmarshalJSON decodes the JSON representation of a fault of the category
+// `code` into `target`.
+func unmarshalJSON(data []byte, code Code, target error) (*envelope, error) {
+	var e envelope
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, err
+	}
+	if err := e.check(code, target); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// MarshalJSON implements json.Marshaler (see EncodeJSON).
+func (e *AvailabilityFailure) MarshalJSON() ([]byte, error) {
+	return marshalJSON(e)
+}
+
+// UnmarshalJSON implements json.Unmarshaler (see EncodeJSON).
+func (e *AvailabilityFailure) UnmarshalJSON(data []byte) error {
+	env, err := unmarshalJSON(data, CodeUnavailable, e)
+	if err != nil {
+		return err
+	}
+	*e = AvailabilityFailure{error: env.parent(), RetryInfo: env.retryInfo()}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler (see EncodeJSON).
+func (e *QuotaFailure) MarshalJSON() ([]byte, error) {
+	return marshalJSON(e)
+}
+
+// UnmarshalJSON implements json.Unmarshaler (see EncodeJSON).
+func (e *QuotaFailure) UnmarshalJSON(data []byte) error {
+	env, err := unmarshalJSON(data, CodeResourceExhausted, e)
+	if err != nil {
+		return err
+	}
+	*e = QuotaFailure{error: env.parent(), Violations: env.quotaViolations(), RetryInfo: env.retryInfo()}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler (see EncodeJSON).
+func (e *PreconditionFailure) MarshalJSON() ([]byte, error) {
+	return marshalJSON(e)
+}
+
+// UnmarshalJSON implements json.Unmarshaler (see EncodeJSON).
+func (e *PreconditionFailure) UnmarshalJSON(data []byte) error {
+	env, err := unmarshalJSON(data, CodeFailedPrecondition, e)
+	if err != nil {
+		return err
+	}
+	*e = PreconditionFailure{error: env.parent(), Violations: env.preconditionViolations()}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler (see EncodeJSON).
+func (e *BadRequest) MarshalJSON() ([]byte, error) {
+	return marshalJSON(e)
+}
+
+// UnmarshalJSON implements json.Unmarshaler (see EncodeJSON).
+func (e *BadRequest) UnmarshalJSON(data []byte) error {
+	env, err := unmarshalJSON(data, CodeBad, e)
+	if err != nil {
+		return err
+	}
+	*e = BadRequest{error: env.parent(), Violations: env.fieldViolations()}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler (see EncodeJSON).
+func (e *ConflictFailure) MarshalJSON() ([]byte, error) {
+	return marshalJSON(e)
+}
+
+// UnmarshalJSON implements json.Unmarshaler (see EncodeJSON).
+func (e *ConflictFailure) UnmarshalJSON(data []byte) error {
+	env, err := unmarshalJSON(data, CodeAborted, e)
+	if err != nil {
+		return err
+	}
+	*e = ConflictFailure{error: env.parent(), Violations: env.conflictViolations()}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler (see EncodeJSON).
+func (e *MissingFailure) MarshalJSON() ([]byte, error) {
+	return marshalJSON(e)
+}
+
+// UnmarshalJSON implements json.Unmarshaler (see EncodeJSON).
+func (e *MissingFailure) UnmarshalJSON(data []byte) error {
+	env, err := unmarshalJSON(data, CodeNotFound, e)
+	if err != nil {
+		return err
+	}
+	*e = MissingFailure{error: env.parent()}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler (see EncodeJSON).
+func (e *PermissionFailure) MarshalJSON() ([]byte, error) {
+	return marshalJSON(e)
+}
+
+// UnmarshalJSON implements json.Unmarshaler (see EncodeJSON).
+func (e *PermissionFailure) UnmarshalJSON(data []byte) error {
+	env, err := unmarshalJSON(data, CodePermissionDenied, e)
+	if err != nil {
+		return err
+	}
+	*e = PermissionFailure{error: env.parent()}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler (see EncodeJSON).
+func (e *AuthenticationFailure) MarshalJSON() ([]byte, error) {
+	return marshalJSON(e)
+}
+
+// UnmarshalJSON implements json.Unmarshaler (see EncodeJSON).
+func (e *AuthenticationFailure) UnmarshalJSON(data []byte) error {
+	env, err := unmarshalJSON(data, CodeUnauthenticated, e)
+	if err != nil {
+		return err
+	}
+	*e = AuthenticationFailure{error: env.parent(), Challenges: env.challenges()}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler (see EncodeJSON).
+func (e *UnimplementedFailure) MarshalJSON() ([]byte, error) {
+	return marshalJSON(e)
+}
+
+// UnmarshalJSON implements json.Unmarshaler (see EncodeJSON).
+func (e *UnimplementedFailure) UnmarshalJSON(data []byte) error {
+	env, err := unmarshalJSON(data, CodeUnimplemented, e)
+	if err != nil {
+		return err
+	}
+	*e = UnimplementedFailure{error: env.parent()}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler (see EncodeJSON).
+func (e *InternalFailure) MarshalJSON() ([]byte, error) {
+	return marshalJSON(e)
+}
+
+// UnmarshalJSON implements json.Unmarshaler (see EncodeJSON).
+func (e *InternalFailure) UnmarshalJSON(data []byte) error {
+	env, err := unmarshalJSON(data, CodeInternal, e)
+	if err != nil {
+		return err
+	}
+	*e = InternalFailure{error: env.parent()}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler (see EncodeJSON).
+func (e *DeadlineFailure) MarshalJSON() ([]byte, error) {
+	return marshalJSON(e)
+}
+
+// UnmarshalJSON implements json.Unmarshaler (see EncodeJSON).
+func (e *DeadlineFailure) UnmarshalJSON(data []byte) error {
+	env, err := unmarshalJSON(data, CodeDeadlineExceeded, e)
+	if err != nil {
+		return err
+	}
+	*e = DeadlineFailure{error: env.parent()}
+	return nil
+}
@@ -0,0 +1,190 @@
+//go:build !faults_minimal
+
+package faults_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+)
+
+// TestEncodeJSON ensures faults survive their JSON representation.
+func TestEncodeJSON(t *testing.T) {
+	table := []error{
+		faults.Bad(&faults.FieldViolation{Field: "email", Code: faults.ViolationRequired, Description: "is required"}),
+		faults.WithBad(errors.New("email is required")),
+		faults.NotFound,
+		faults.WithNotFound(errors.New("no such user")),
+		faults.PermissionDenied,
+		faults.WithUnauthenticated(errors.New("token expired"), faults.Challenge{Scheme: "Bearer", Realm: "api"}),
+		faults.FailedPrecondition(&faults.PreconditionViolation{Type: "TOS", Subject: "user:42", Description: "not accepted"}),
+		faults.Aborted(&faults.ConflictViolation{Resource: "order:42", Description: "version mismatch"}),
+		faults.ResourceExhausted(&faults.QuotaViolation{Subject: "project:42", Description: "daily limit"}),
+		faults.Unavailable(1500 * time.Millisecond),
+		faults.DeadlineExceeded,
+		faults.Unimplemented,
+		faults.WithInternal(errors.New("pq: connection refused")),
+		errors.New("boom"),
+		faults.Permanent(faults.Unavailable(time.Second)),
+		faults.WithErrorInfo(faults.NotFound, "USER_NOT_FOUND", map[string]string{"id": "42"}),
+		fmt.Errorf("get user: %w", faults.NotFound),
+	}
+
+	for i, fault := range table {
+		data, err := faults.EncodeJSON(fault)
+		if err != nil {
+			t.Fatalf("%d - expect fault to be encoded, but got %s", i, err)
+		}
+
+		err = faults.DecodeJSON(data)
+		if err == nil {
+			t.Fatalf("%d - expect fault to be decoded", i)
+		}
+		if err.Error() != fault.Error() {
+			t.Errorf("%d - expect message %q, but got %q", i, fault, err)
+		}
+		if code := faults.CodeOf(err); code != faults.CodeOf(fault) {
+			t.Errorf("%d - expect code %s, but got %s", i, faults.CodeOf(fault), code)
+		}
+		if expect, delay := faults.RetryAdvice(fault).Delay, faults.RetryAdvice(err).Delay; delay != expect {
+			t.Errorf("%d - expect retry delay %s, but got %s", i, expect, delay)
+		}
+		if expect, permanent := faults.IsPermanent(fault), faults.IsPermanent(err); permanent != expect {
+			t.Errorf("%d - expect permanent %t, but got %t", i, expect, permanent)
+		}
+		if expect, reason := faults.ReasonOf(fault), faults.ReasonOf(err); reason != expect {
+			t.Errorf("%d - expect reason %q, but got %q", i, expect, reason)
+		}
+		if expect, challenges := faults.ChallengesOf(fault), faults.ChallengesOf(err); !reflect.DeepEqual(expect, challenges) {
+			t.Errorf("%d - expect challenges %v, but got %v", i, expect, challenges)
+		}
+	}
+
+	if data, _ := faults.EncodeJSON(nil); string(data) != "null" {
+		t.Errorf("expect null, but got %s", data)
+	}
+	if err := faults.DecodeJSON([]byte("null")); err != nil {
+		t.Errorf("expect nil, but got %v", err)
+	}
+}
+
+// TestEncodeJSONFormat ensures the JSON representation is stable.
+func TestEncodeJSONFormat(t *testing.T) {
+	fault := faults.WithErrorInfo(faults.WithBad(
+		errors.New("invalid form"),
+		&faults.FieldViolation{Field: "email", Code: faults.ViolationRequired, Description: "is required"},
+	), "INVALID_FORM", map[string]string{"form": "signup", "step": "1"})
+
+	data, err := faults.EncodeJSON(fault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `{"version":4,"code":"BAD_REQUEST","message":"is required: invalid form",` +
+		`"cause":"invalid form","violations":[{"field":"email","code":"REQUIRED","description":"is required"}],` +
+		`"reason":"INVALID_FORM","metadata":{"form":"signup","step":"1"}}`
+	if string(data) != expect {
+		t.Errorf("expect %s, but got %s", expect, data)
+	}
+}
+
+// TestEncodeJSONTemplate ensures the templates of violations and their
+// parameters are members of the JSON representation, and are decoded.
+func TestEncodeJSONTemplate(t *testing.T) {
+	fault := faults.Bad(faults.FieldTemplate("name", "must be at most {max} characters", map[string]string{"max": "50"}))
+
+	data, _ := faults.EncodeJSON(fault)
+	expect := `"violations":[{"field":"name","description":"must be at most 50 characters",` +
+		`"template":"must be at most {max} characters","params":{"max":"50"}}]`
+	if !strings.Contains(string(data), expect) {
+		t.Errorf("expect %s, but got %s", expect, data)
+	}
+	e, ok := faults.AsBad(faults.DecodeJSON(data))
+	if want, _ := faults.AsBad(fault); !ok || !reflect.DeepEqual(e.Violations, want.Violations) {
+		t.Errorf("expect violations %v, but got %v", want.Violations, e)
+	}
+}
+
+// TestMarshalJSON ensures fault types can be marshalled and unmarshalled on
+// their own, e.g. as members of a larger document.
+func TestMarshalJSON(t *testing.T) {
+	type result struct {
+		Bad         *faults.BadRequest          `json:"bad"`
+		Quota       *faults.QuotaFailure        `json:"quota"`
+		Unavailable *faults.AvailabilityFailure `json:"unavailable"`
+		Missing     *faults.MissingFailure      `json:"missing"`
+	}
+	in := result{}
+	in.Bad, _ = faults.AsBad(faults.Bad(&faults.FieldViolation{Field: "email", Description: "is required"}))
+	in.Quota, _ = faults.AsResourceExhausted(faults.ResourceExhausted(&faults.QuotaViolation{Subject: "project:42"}))
+	in.Unavailable, _ = faults.AsType[*faults.AvailabilityFailure](faults.Unavailable(time.Second))
+
+	data, err := json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out result
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(in.Bad.Violations, out.Bad.Violations) || in.Bad.Error() != out.Bad.Error() {
+		t.Errorf("expect %v, but got %v", in.Bad, out.Bad)
+	}
+	if !reflect.DeepEqual(in.Quota.Violations, out.Quota.Violations) {
+		t.Errorf("expect %v, but got %v", in.Quota.Violations, out.Quota.Violations)
+	}
+	if out.Unavailable.RetryInfo.RetryDelay != time.Second {
+		t.Errorf("expect retry delay %s, but got %s", time.Second, out.Unavailable.RetryInfo.RetryDelay)
+	}
+	if out.Missing != nil {
+		t.Errorf("expect nil fault, but got %v", out.Missing)
+	}
+
+	// A fault wrapping a reason keeps it
+	data, _ = json.Marshal(faults.WithNotFound(faults.WithReason(errors.New("no such user"), "USER_NOT_FOUND")))
+	var missing faults.MissingFailure
+	if err := json.Unmarshal(data, &missing); err != nil {
+		t.Fatal(err)
+	}
+	if reason := faults.ReasonOf(&missing); reason != "USER_NOT_FOUND" {
+		t.Errorf("expect reason to be restored, but got %q", reason)
+	}
+}
+
+// TestUnmarshalJSON ensures representations that don't match the type are
+// rejected.
+func TestUnmarshalJSON(t *testing.T) {
+	table := []struct {
+		Data   string
+		Target error
+	}{
+		{Data: `{"version":1,"code":"NOT_FOUND"}`, Target: &faults.BadRequest{}},
+		{Data: `{"version":1,"code":"NOPE"}`, Target: &faults.InternalFailure{}},
+		{Data: `{"version":1,"code":42}`, Target: &faults.MissingFailure{}},
+	}
+
+	for i, test := range table {
+		if err := json.Unmarshal([]byte(test.Data), test.Target); err == nil {
+			t.Errorf("%d - expect %s to be rejected", i, test.Data)
+		}
+	}
+
+	for i, data := range []string{`{`, `[]`, `{"version":1,"code":"NOT_FOUND","permanent":"yes"}`} {
+		err := faults.DecodeJSON([]byte(data))
+		if err == nil || faults.CodeOf(err) != faults.CodeUnknown {
+			t.Errorf("%d - expect %s to be rejected, but got %v", i, data, err)
+		}
+	}
+
+	// Unknown members are skipped, and unknown categories are decoded as plain
+	// errors
+	err := faults.DecodeJSON([]byte(`{"version":1,"code":"NOPE","message":"hi","extra":true}`))
+	if faults.CodeOf(err) != faults.CodeUnknown || err.Error() != "hi" {
+		t.Errorf("expect plain error, but got %v", err)
+	}
+}
@@ -0,0 +1,158 @@
+package faults
+
+import "unicode/utf8"
+
+// Limits bounds the size of the faults encoded for transport, so a single
+// fault, e.g. a bad request listing every row of an uploaded file, can't
+// produce huge responses or messages. A limit of zero disables it.
+//
+// Faults exceeding the limits are encoded with their first violations and
+// their shortened messages, and are marked as truncated (see IsTruncated).
+type Limits struct {
+	// MaxViolations is the maximum number of violations encoded. The first
+	// ones are kept.
+	MaxViolations int
+	// MaxDescriptionLength is the maximum length, in bytes, of the message of
+	// a fault, of the message of the error it wraps, and of the description
+	// and the template of each of its violations. Longer templates are
+	// dropped with their parameters, as they can't be shortened.
+	MaxDescriptionLength int
+	// MaxSize is the maximum size, in bytes, of an encoded fault. Violations
+	// are dropped, then messages are shortened, until the encoding fits. The
+	// other details, such as the reason and its metadata, are always kept, so
+	// it is a best effort.
+	MaxSize int
+}
+
+// DefaultLimits are the limits applied by default. The maximum size matches
+// the size of the documents read by the clients of faultshttp.
+var DefaultLimits = Limits{
+	MaxViolations:        100,
+	MaxDescriptionLength: 1 << 10,
+	MaxSize:              64 << 10,
+}
+
+// SetLimits sets the limits applied by Encode, EncodeJSON, EncodeCBOR,
+// EncodeText and Truncate. Limits{} disables them.
+//
+// It is meant to be called once, when the program starts.
+func SetLimits(l Limits) {
+	configure(func(c *Config) { c.Limits = l })
+}
+
+func currentLimits() Limits {
+	return settings().Limits
+}
+
+// truncated marks the error it wraps as truncated (see Truncated).
+type truncated struct {
+	error
+}
+
+func (e *truncated) Unwrap() error {
+	return e.error
+}
+
+// Truncated marks `parent` as an incomplete description of a fault, whose
+// details were truncated to fit the limits of an encoding (see Limits). It
+// is meant for the decoders of encodings carrying a truncation indicator.
+func Truncated(parent error) error {
+	if parent == nil {
+		return nil
+	}
+	return traced(EventWrap, &truncated{error: parent})
+}
+
+// IsTruncated reports whether `err` was decoded from a truncated encoding,
+// i.e. some of its violations or part of its messages are missing.
+func IsTruncated(err error) bool {
+	_, ok := as[*truncated](err)
+	return ok
+}
+
+// Truncate applies the limits on the number of violations and the length of
+// descriptions (see SetLimits) to `err`, for renderers that describe faults
+// in formats of their own, e.g. faultshttp.
+//
+// It returns `err` as is when it is within the limits. Otherwise, it returns
+// the fault rebuilt from its truncated details, like Decode would, and
+// marked as truncated.
+func Truncate(err error) error {
+	if err == nil {
+		return nil
+	}
+	e := envelopeOf(err)
+	e.Truncated = false
+	e.limit(currentLimits())
+	if !e.Truncated {
+		return err
+	}
+	return e.fault()
+}
+
+// limited returns the encoding of `err` by `encode`, within the current
+// limits.
+func limited(err error, encode func(e *envelope) []byte) []byte {
+	return limitedTo(currentLimits(), err, encode)
+}
+
+// limitedTo returns the encoding of `err` by `encode`, within the limits `l`.
+func limitedTo(l Limits, err error, encode func(e *envelope) []byte) []byte {
+	e := envelopeOf(err)
+	e.limit(l)
+	b := encode(e)
+	for l.MaxSize > 0 && len(b) > l.MaxSize && e.shrink() {
+		b = encode(e)
+	}
+	return b
+}
+
+// limit applies the limits on the number of violations and the length of
+// descriptions of `l` to `e`.
+func (e *envelope) limit(l Limits) {
+	if l.MaxViolations > 0 && len(e.Violations) > l.MaxViolations {
+		e.Violations = e.Violations[:l.MaxViolations]
+		e.Truncated = true
+	}
+	if n := l.MaxDescriptionLength; n > 0 {
+		e.Message = e.cut(e.Message, n)
+		e.Cause = e.cut(e.Cause, n)
+		for i := range e.Violations {
+			v := &e.Violations[i]
+			v.Description = e.cut(v.Description, n)
+			if len(v.Template) > n {
+				v.Template, v.Params = "", nil
+				e.Truncated = true
+			}
+		}
+	}
+}
+
+// shrink halves the violations of `e`, or its messages when it has none
+// left. It returns false when neither can be shrunk further.
+func (e *envelope) shrink() bool {
+	switch {
+	case len(e.Violations) > 0:
+		e.Violations = e.Violations[:len(e.Violations)/2]
+	case e.Message != "" || e.Cause != "":
+		e.Message = e.cut(e.Message, len(e.Message)/2)
+		e.Cause = e.cut(e.Cause, len(e.Cause)/2)
+	default:
+		return false
+	}
+	e.Truncated = true
+	return true
+}
+
+// cut returns the first `n` bytes of `s`, without splitting a character, and
+// marks `e` as truncated when `s` is longer.
+func (e *envelope) cut(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	e.Truncated = true
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,151 @@
+package faults_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/deixis/faults"
+)
+
+// runaway returns a bad request with `n` violations, such as produced by a
+// validator going through every row of a file.
+func runaway(n int, description string) error {
+	violations := make([]*faults.FieldViolation, n)
+	for i := range violations {
+		violations[i] = &faults.FieldViolation{Field: fmt.Sprintf("rows[%d].email", i), Description: description}
+	}
+	return faults.Bad(violations...)
+}
+
+// TestLimits ensures the encodings of faults exceeding the limits are
+// truncated, and marked as such.
+func TestLimits(t *testing.T) {
+	faults.SetLimits(faults.Limits{MaxViolations: 10, MaxDescriptionLength: 16, MaxSize: 1 << 10})
+	defer faults.SetLimits(faults.DefaultLimits)
+
+	encodings := []struct {
+		Name   string
+		Encode func(error) ([]byte, error)
+		Decode func([]byte) error
+	}{
+		{Name: "binary", Encode: faults.Encode, Decode: faults.Decode},
+		{Name: "CBOR", Encode: faults.EncodeCBOR, Decode: faults.DecodeCBOR},
+		{Name: "text", Encode: faults.EncodeText, Decode: faults.DecodeText},
+	}
+
+	for _, enc := range encodings {
+		data, _ := enc.Encode(runaway(1000, "Must be a valid email address"))
+		if len(data) > 1<<10 {
+			t.Errorf("%s - expect at most %d bytes, but got %d", enc.Name, 1<<10, len(data))
+		}
+		err := enc.Decode(data)
+		if !faults.IsTruncated(err) {
+			t.Errorf("%s - expect fault to be truncated", enc.Name)
+		}
+		e, ok := faults.AsBad(err)
+		if !ok {
+			t.Fatalf("%s - expect bad request, but got %v", enc.Name, err)
+		}
+		if n := len(e.Violations); n > 10 || n == 0 {
+			t.Errorf("%s - expect up to 10 violations, but got %d", enc.Name, n)
+		}
+		if v := e.Violations[0]; v.Field != "rows[0].email" || v.Description != "Must be a valid " {
+			t.Errorf("%s - expect first violation to be kept and shortened, but got %v", enc.Name, v)
+		}
+		if n := len(err.Error()); n > 16 {
+			t.Errorf("%s - expect message of at most 16 bytes, but got %d", enc.Name, n)
+		}
+
+		// Faults within the limits are left alone
+		data, _ = enc.Encode(runaway(1, "Required"))
+		if err := enc.Decode(data); faults.IsTruncated(err) {
+			t.Errorf("%s - expect fault not to be truncated, but got %v", enc.Name, err)
+		}
+	}
+}
+
+// TestLimitsTemplate ensures the templates exceeding the limits are dropped
+// with their parameters, as they can't be shortened.
+func TestLimitsTemplate(t *testing.T) {
+	faults.SetLimits(faults.Limits{MaxDescriptionLength: 16})
+	defer faults.SetLimits(faults.DefaultLimits)
+
+	data, _ := faults.Encode(faults.Bad(
+		faults.FieldTemplate("name", "at most {max}", map[string]string{"max": "50"}),
+		faults.FieldTemplate("bio", "must be at most {max} characters", map[string]string{"max": "500"}),
+	))
+	err := faults.Decode(data)
+	e, ok := faults.AsBad(err)
+	if !ok || len(e.Violations) != 2 {
+		t.Fatalf("expect bad request with 2 violations, but got %v", err)
+	}
+	if v := e.Violations[0]; v.Template != "at most {max}" || v.Params["max"] != "50" {
+		t.Errorf("expect first template to be kept, but got %#v", v)
+	}
+	if v := e.Violations[1]; v.Template != "" || v.Params != nil {
+		t.Errorf("expect second template to be dropped, but got %#v", v)
+	}
+	if !faults.IsTruncated(err) {
+		t.Errorf("expect fault to be truncated")
+	}
+}
+
+// TestLimitsSize ensures faults are shrunk until they fit the maximum size.
+func TestLimitsSize(t *testing.T) {
+	faults.SetLimits(faults.Limits{MaxSize: 256})
+	defer faults.SetLimits(faults.DefaultLimits)
+
+	data, _ := faults.Encode(runaway(1000, strings.Repeat("x", 100)))
+	if len(data) > 256 {
+		t.Errorf("expect at most 256 bytes, but got %d", len(data))
+	}
+	if err := faults.Decode(data); !faults.IsBad(err) || !faults.IsTruncated(err) {
+		t.Errorf("expect truncated bad request, but got %v", err)
+	}
+
+	// Messages are shortened once the violations are gone
+	data, _ = faults.Encode(faults.WithInternal(fmt.Errorf("%s", strings.Repeat("é", 1000))))
+	if len(data) > 256 {
+		t.Errorf("expect at most 256 bytes, but got %d", len(data))
+	}
+	if err := faults.Decode(data); !utf8.ValidString(err.Error()) || !faults.IsTruncated(err) {
+		t.Errorf("expect valid truncated message, but got %q", err)
+	}
+
+	faults.SetLimits(faults.Limits{})
+	data, _ = faults.Encode(runaway(1000, "Required"))
+	if err := faults.Decode(data); faults.IsTruncated(err) {
+		t.Error("expect limits to be disabled")
+	}
+}
+
+// TestTruncate ensures faults can be truncated for other renderers.
+func TestTruncate(t *testing.T) {
+	faults.SetLimits(faults.Limits{MaxViolations: 3})
+	defer faults.SetLimits(faults.DefaultLimits)
+
+	fault := runaway(2, "Required")
+	if err := faults.Truncate(fault); err != fault {
+		t.Errorf("expect fault within the limits to be kept, but got %v", err)
+	}
+
+	err := faults.Truncate(faults.WithErrorInfo(runaway(5, "Required"), "INVALID_ROWS", nil))
+	e, ok := faults.AsBad(err)
+	if !ok || len(e.Violations) != 3 {
+		t.Fatalf("expect bad request with 3 violations, but got %v", err)
+	}
+	if !faults.IsTruncated(err) || faults.ReasonOf(err) != "INVALID_ROWS" {
+		t.Errorf("expect truncated fault keeping its reason, but got %v", err)
+	}
+
+	// The marker survives the encodings
+	data, _ := faults.Encode(err)
+	if !faults.IsTruncated(faults.Decode(data)) {
+		t.Error("expect truncation marker to be encoded")
+	}
+	if faults.Truncated(nil) != nil {
+		t.Error("expect nil error")
+	}
+}
@@ -0,0 +1,163 @@
+//go:build !faults_minimal
+
+package faults
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// FlattenAttr returns an attribute that logs the tree of `err` as a list of
+// structured entries (see Flatten).
+//
+// With `slog.JSONHandler`, the attribute is rendered as an array of objects:
+//
+//	"errors":[{"code":"BAD_REQUEST","message":"..."},{"code":"UNAVAILABLE","message":"..."}]
+func FlattenAttr(key string, err error) slog.Attr {
+	return slog.Any(key, Flatten(err))
+}
+
+// LogValue returns the structured representation of `err` for slog: a group
+// with its message, its code, and when they are set, its reason, the domain
+// of its reason, its retry delay and its violations, so logs can be queried
+// by category or by reason.
+//
+// The faults, and the errors carrying a reason, implement slog.LogValuer with
+// LogValue, so logging them as is produces the group:
+//
+//	logger.Error("charging card", "err", err)
+//
+// With `slog.JSONHandler`, a bad request is rendered as:
+//
+//	"err":{"message":"email is required","code":"BAD_REQUEST","violations":[{"field":"email","description":"is required"}]}
+//
+// Errors wrapping a fault, e.g. with fmt.Errorf, must be logged with
+// slog.Any(key, faults.LogValue(err)), since slog only resolves the values
+// implementing slog.LogValuer themselves.
+func LogValue(err error) slog.Value {
+	if err == nil {
+		return slog.AnyValue(nil)
+	}
+	return slog.GroupValue(append([]slog.Attr{slog.String("message", err.Error())}, logAttrs(err)...)...)
+}
+
+// logAttrs returns the structured fields of `err`, from its code.
+func logAttrs(err error) []slog.Attr {
+	e := envelopeOf(err)
+	attrs := []slog.Attr{slog.String("code", e.Code)}
+	if e.Reason != "" {
+		attrs = append(attrs, slog.String("reason", e.Reason))
+	}
+	if e.Domain != "" {
+		attrs = append(attrs, slog.String("domain", e.Domain))
+	}
+	if advice := RetryAdvice(err); advice.Delay > 0 {
+		attrs = append(attrs, slog.Duration("retry_delay", advice.Delay.Round(time.Millisecond)))
+	}
+	if len(e.Violations) > 0 {
+		attrs = append(attrs, slog.Any("violations", e.Violations))
+	}
+	return attrs
+}
+
+// The faults, and the errors carrying a reason, implement slog.LogValuer with
+// LogValue.
+
+func (e *BadRequest) LogValue() slog.Value            { return LogValue(e) }
+func (e *PreconditionFailure) LogValue() slog.Value   { return LogValue(e) }
+func (e *ConflictFailure) LogValue() slog.Value       { return LogValue(e) }
+func (e *QuotaFailure) LogValue() slog.Value          { return LogValue(e) }
+func (e *AvailabilityFailure) LogValue() slog.Value   { return LogValue(e) }
+func (e *MissingFailure) LogValue() slog.Value        { return LogValue(e) }
+func (e *PermissionFailure) LogValue() slog.Value     { return LogValue(e) }
+func (e *AuthenticationFailure) LogValue() slog.Value { return LogValue(e) }
+func (e *UnimplementedFailure) LogValue() slog.Value  { return LogValue(e) }
+func (e *InternalFailure) LogValue() slog.Value       { return LogValue(e) }
+func (e *DeadlineFailure) LogValue() slog.Value       { return LogValue(e) }
+func (e *ErrorInfo) LogValue() slog.Value             { return LogValue(e) }
+
+// A LogHandler is a slog.Handler expanding the faults logged through it into
+// a canonical "fault" group, so the logs of a whole service can be queried by
+// "fault.code", "fault.retryable" or "fault.violations", whatever the key the
+// faults were logged with:
+//
+//	logger := slog.New(faults.NewLogHandler(slog.NewJSONHandler(os.Stderr, nil)))
+//	logger.Error("charging card", "err", err)
+//
+// produces, for a bad request:
+//
+//	"err":"email is required","fault":{"code":"BAD_REQUEST","retryable":false,"violations":[{"field":"email","description":"is required"}]}
+//
+// The first attribute whose value is an error carrying a fault, even wrapped,
+// is replaced with the message of the error, and the group is added to the
+// record with the code of the fault, whether it is retryable (see
+// IsRetryable), and when they are set, its reason, the domain of its reason,
+// its retry delay and its violations. The attributes added with
+// slog.Logger.With are expanded the same way. Other records are passed on as
+// they are.
+type LogHandler struct {
+	next slog.Handler
+}
+
+// NewLogHandler returns a LogHandler passing the records to `next`.
+func NewLogHandler(next slog.Handler) *LogHandler {
+	return &LogHandler{next: next}
+}
+
+func (h *LogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *LogHandler) Handle(ctx context.Context, r slog.Record) error {
+	attrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	expanded, ok := expandFault(attrs)
+	if !ok {
+		return h.next.Handle(ctx, r)
+	}
+	out := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	out.AddAttrs(expanded...)
+	return h.next.Handle(ctx, out)
+}
+
+func (h *LogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if expanded, ok := expandFault(attrs); ok {
+		attrs = expanded
+	}
+	return &LogHandler{next: h.next.WithAttrs(attrs)}
+}
+
+func (h *LogHandler) WithGroup(name string) slog.Handler {
+	return &LogHandler{next: h.next.WithGroup(name)}
+}
+
+// expandFault returns a copy of `attrs` where the first attribute carrying a
+// fault is replaced with its message, followed by the "fault" group, and
+// whether an attribute carries a fault.
+func expandFault(attrs []slog.Attr) ([]slog.Attr, bool) {
+	for i, a := range attrs {
+		if k := a.Value.Kind(); k != slog.KindAny && k != slog.KindLogValuer {
+			continue
+		}
+		err, ok := a.Value.Any().(error)
+		if !ok {
+			continue
+		}
+		if _, ok := as[Fault](err); !ok {
+			continue
+		}
+
+		fields := logAttrs(err)
+		fields = append([]slog.Attr{fields[0], slog.Bool("retryable", IsRetryable(err))}, fields[1:]...)
+		expanded := make([]slog.Attr, 0, len(attrs)+1)
+		expanded = append(expanded, attrs[:i]...)
+		expanded = append(expanded, slog.String(a.Key, err.Error()))
+		expanded = append(expanded, attrs[i+1:]...)
+		return append(expanded, slog.Attr{Key: "fault", Value: slog.GroupValue(fields...)}), true
+	}
+	return attrs, false
+}
@@ -0,0 +1,109 @@
+//go:build !faults_minimal
+
+package faults_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+)
+
+// TestFlattenAttr ensures joined faults are logged as an array of entries.
+func TestFlattenAttr(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	err := errors.Join(faults.NotFound, faults.Unavailable(0))
+	logger.Error("failed", faults.FlattenAttr("errors", err))
+
+	expect := `"errors":[{"code":"NOT_FOUND","message":"resource not found"},` +
+		`{"code":"UNAVAILABLE","message":"service temporarily unavailable"}]`
+	if !strings.Contains(buf.String(), expect) {
+		t.Errorf("expect log to contain %s, but got %s", expect, buf.String())
+	}
+}
+
+// TestLogValue ensures faults are logged as groups of structured fields.
+func TestLogValue(t *testing.T) {
+	table := []struct {
+		Value  any
+		Expect string
+	}{
+		{
+			Value:  faults.NotFound,
+			Expect: `"err":{"message":"resource not found","code":"NOT_FOUND"}`,
+		},
+		{
+			Value:  faults.Bad(faults.Field("email", "is required")),
+			Expect: `"err":{"message":"is required","code":"BAD_REQUEST","violations":[{"field":"email","description":"is required"}]}`,
+		},
+		{
+			Value:  faults.Unavailable(1500 * time.Millisecond),
+			Expect: `"err":{"message":"service temporarily unavailable, retry in 1.5s","code":"UNAVAILABLE","retry_delay":1500000000}`,
+		},
+		{
+			Value:  faults.WithReason(faults.PermissionDenied, "payments.example.com/CARD_BLOCKED"),
+			Expect: `"err":{"message":"permission denied","code":"PERMISSION_DENIED","reason":"CARD_BLOCKED","domain":"payments.example.com"}`,
+		},
+		{
+			Value:  faults.LogValue(fmt.Errorf("charging: %w", faults.Internal)),
+			Expect: `"err":{"message":"charging: internal error","code":"INTERNAL"}`,
+		},
+	}
+
+	for i, test := range table {
+		var buf bytes.Buffer
+		slog.New(slog.NewJSONHandler(&buf, nil)).Error("failed", "err", test.Value)
+		if !strings.Contains(buf.String(), test.Expect) {
+			t.Errorf("%d - expect log to contain %s, but got %s", i, test.Expect, buf.String())
+		}
+	}
+}
+
+// TestLogHandler ensures the faults logged through the handler are expanded
+// into the canonical group.
+func TestLogHandler(t *testing.T) {
+	table := []struct {
+		Args   []any
+		Expect string
+	}{
+		{
+			Args:   []any{"err", faults.Bad(faults.Field("email", "is required"))},
+			Expect: `"err":"is required","fault":{"code":"BAD_REQUEST","retryable":false,"violations":[{"field":"email","description":"is required"}]}`,
+		},
+		{
+			Args:   []any{"user", 42, "error", fmt.Errorf("charging: %w", faults.Unavailable(2*time.Second))},
+			Expect: `"user":42,"error":"charging: service temporarily unavailable, retry in 2s","fault":{"code":"UNAVAILABLE","retryable":true,"retry_delay":2000000000}`,
+		},
+		{
+			Args:   []any{"err", errors.New("boom")},
+			Expect: `"err":"boom"}`,
+		},
+	}
+
+	for i, test := range table {
+		var buf bytes.Buffer
+		slog.New(faults.NewLogHandler(slog.NewJSONHandler(&buf, nil))).Error("failed", test.Args...)
+		if !strings.Contains(buf.String(), test.Expect) {
+			t.Errorf("%d - expect log to contain %s, but got %s", i, test.Expect, buf.String())
+		}
+	}
+}
+
+// TestLogHandlerWith ensures the attributes of loggers are expanded too.
+func TestLogHandlerWith(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(faults.NewLogHandler(slog.NewJSONHandler(&buf, nil)))
+	logger.With("err", faults.NotFound).WithGroup("request").Info("done", "id", 42)
+
+	expect := `"err":"resource not found","fault":{"code":"NOT_FOUND","retryable":false},"request":{"id":42}`
+	if !strings.Contains(buf.String(), expect) {
+		t.Errorf("expect log to contain %s, but got %s", expect, buf.String())
+	}
+}
@@ -0,0 +1,107 @@
+package faults
+
+import (
+	"container/list"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Memoize returns a classifier that caches the results of `c`, for
+// classifiers that are expensive to run, such as a legacy classifier matching
+// messages against regular expressions, or one inspecting errors with
+// reflection.
+//
+// Results are keyed by `key`, and at most `size` results are kept, the least
+// recently used ones being evicted first. When `key` is nil, errors are keyed
+// by their Fingerprint. When `key` returns false, the result is not cached.
+//
+// Example:
+//
+//	faults.RegisterClassifier(faults.Memoize(legacyClassifier, 4096, nil))
+//
+// `c` must return the same result for errors sharing a key.
+func Memoize(c Classifier, size int, key func(err error) (string, bool)) Classifier {
+	if key == nil {
+		key = func(err error) (string, bool) {
+			return Fingerprint(err), true
+		}
+	}
+	return &memoClassifier{
+		classifier: c,
+		key:        key,
+		size:       size,
+		entries:    map[string]*list.Element{},
+		lru:        list.New(),
+	}
+}
+
+type memoClassifier struct {
+	classifier Classifier
+	key        func(err error) (string, bool)
+	size       int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+}
+
+type memoEntry struct {
+	key  string
+	code Code
+	ok   bool
+}
+
+func (m *memoClassifier) Classify(err error) (Code, bool) {
+	key, cacheable := m.key(err)
+	if !cacheable || m.size <= 0 {
+		return m.classifier.Classify(err)
+	}
+
+	m.mu.Lock()
+	if el, ok := m.entries[key]; ok {
+		m.lru.MoveToFront(el)
+		e := el.Value.(*memoEntry)
+		m.mu.Unlock()
+		return e.code, e.ok
+	}
+	m.mu.Unlock()
+
+	// The classifier runs unlocked, so a slow classification doesn't block
+	// the cached ones
+	code, ok := m.classifier.Classify(err)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if el, found := m.entries[key]; found {
+		m.lru.MoveToFront(el)
+		return code, ok
+	}
+	m.entries[key] = m.lru.PushFront(&memoEntry{key: key, code: code, ok: ok})
+	if m.lru.Len() > m.size {
+		oldest := m.lru.Back()
+		m.lru.Remove(oldest)
+		delete(m.entries, oldest.Value.(*memoEntry).key)
+	}
+	return code, ok
+}
+
+// Fingerprint returns a key identifying the shape of `err`: the types of the
+// errors found in its tree (see Walk), followed by its message.
+//
+// Errors with the same fingerprint are expected to be classified the same
+// way. Messages embedding identifiers (e.g. "user 42 not found") produce
+// distinct fingerprints; Memoize accepts a coarser key for such errors.
+func Fingerprint(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	walk(err, func(err error) bool {
+		fmt.Fprintf(&b, "%T;", err)
+		return true
+	})
+	b.WriteString(err.Error())
+	return b.String()
+}
@@ -0,0 +1,178 @@
+package faults_test
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+	"testing"
+
+	"github.com/deixis/faults"
+)
+
+// legacyPatterns mimics a legacy classifier matching error messages.
+var legacyPatterns = []struct {
+	Pattern *regexp.Regexp
+	Code    faults.Code
+}{
+	{Pattern: regexp.MustCompile(`(?i)\b(no such|unknown) (user|account|file)\b`), Code: faults.CodeNotFound},
+	{Pattern: regexp.MustCompile(`(?i)\bpermission denied\b|\baccess is denied\b`), Code: faults.CodePermissionDenied},
+	{Pattern: regexp.MustCompile(`(?i)\b(connection (refused|reset)|broken pipe)\b`), Code: faults.CodeUnavailable},
+	{Pattern: regexp.MustCompile(`(?i)\btoo many (requests|connections)\b`), Code: faults.CodeResourceExhausted},
+	{Pattern: regexp.MustCompile(`(?i)\b(i/o )?timeout\b|deadline exceeded`), Code: faults.CodeDeadlineExceeded},
+}
+
+func classifyLegacy(err error) (faults.Code, bool) {
+	msg := err.Error()
+	for _, p := range legacyPatterns {
+		if p.Pattern.MatchString(msg) {
+			return p.Code, true
+		}
+	}
+	return faults.CodeUnknown, false
+}
+
+// countingClassifier counts the errors it classifies.
+type countingClassifier struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (c *countingClassifier) Classify(err error) (faults.Code, bool) {
+	c.mu.Lock()
+	c.calls++
+	c.mu.Unlock()
+	return classifyLegacy(err)
+}
+
+// TestMemoize ensures results are cached, and the least recently used ones
+// evicted.
+func TestMemoize(t *testing.T) {
+	counter := &countingClassifier{}
+	c := faults.Memoize(counter, 2, nil)
+
+	table := []struct {
+		Error error
+		Code  faults.Code
+		OK    bool
+		Calls int
+	}{
+		{Error: errors.New("no such user"), Code: faults.CodeNotFound, OK: true, Calls: 1},
+		{Error: errors.New("no such user"), Code: faults.CodeNotFound, OK: true, Calls: 1},
+		{Error: errors.New("boom"), Code: faults.CodeUnknown, OK: false, Calls: 2},
+		{Error: errors.New("boom"), Code: faults.CodeUnknown, OK: false, Calls: 2},
+		{Error: fmt.Errorf("dial: %w", errors.New("connection refused")), Code: faults.CodeUnavailable, OK: true, Calls: 3},
+		// Same message, different types
+		{Error: errors.New("dial: connection refused"), Code: faults.CodeUnavailable, OK: true, Calls: 4},
+		// Evicted
+		{Error: errors.New("no such user"), Code: faults.CodeNotFound, OK: true, Calls: 5},
+	}
+
+	for i, test := range table {
+		code, ok := c.Classify(test.Error)
+		if code != test.Code || ok != test.OK {
+			t.Errorf("%d - expect %s %t, but got %s %t", i, test.Code, test.OK, code, ok)
+		}
+		if counter.calls != test.Calls {
+			t.Errorf("%d - expect %d classifications, but got %d", i, test.Calls, counter.calls)
+		}
+	}
+}
+
+// TestMemoizeKey ensures custom keys are used, and errors without a key are
+// not cached.
+func TestMemoizeKey(t *testing.T) {
+	counter := &countingClassifier{}
+	c := faults.Memoize(counter, 16, func(err error) (string, bool) {
+		var e *vendorError
+		if !errors.As(err, &e) {
+			return "", false
+		}
+		return fmt.Sprint(e.Status), true
+	})
+
+	for i := 0; i < 3; i++ {
+		c.Classify(&vendorError{Status: 503})
+		c.Classify(errors.New("no such user"))
+	}
+	if counter.calls != 4 {
+		t.Errorf("expect 4 classifications, but got %d", counter.calls)
+	}
+}
+
+// TestMemoizeConcurrent ensures the cache can be shared by goroutines.
+func TestMemoizeConcurrent(t *testing.T) {
+	c := faults.Memoize(faults.ClassifierFunc(classifyLegacy), 4, nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				err := fmt.Errorf("call %d: permission denied", (i+j)%8)
+				if code, _ := c.Classify(err); code != faults.CodePermissionDenied {
+					t.Errorf("%d - expect code %s, but got %s", i, faults.CodePermissionDenied, code)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+// TestFingerprint ensures fingerprints distinguish the shape of errors.
+func TestFingerprint(t *testing.T) {
+	base := errors.New("boom")
+
+	table := []struct {
+		A, B  error
+		Equal bool
+	}{
+		{A: nil, B: nil, Equal: true},
+		{A: errors.New("boom"), B: errors.New("boom"), Equal: true},
+		{A: errors.New("boom"), B: errors.New("bang"), Equal: false},
+		{A: faults.WithNotFound(base), B: faults.WithNotFound(errors.New("boom")), Equal: true},
+		{A: faults.WithNotFound(base), B: faults.WithInternal(base), Equal: false},
+		{A: fmt.Errorf("boom"), B: fmt.Errorf("%w", base), Equal: false},
+	}
+
+	for i, test := range table {
+		if equal := faults.Fingerprint(test.A) == faults.Fingerprint(test.B); equal != test.Equal {
+			t.Errorf("%d - expect equal fingerprints to be %t, but got %t", i, test.Equal, equal)
+		}
+	}
+}
+
+var benchErrors = []error{
+	errors.New("no such user"),
+	fmt.Errorf("dial tcp 10.0.0.1:5432: %w", errors.New("connection refused")),
+	errors.New("read: i/o timeout"),
+	errors.New("too many requests"),
+	errors.New("something unexpected happened while processing the request"),
+}
+
+func BenchmarkClassifyLegacy(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		classifyLegacy(benchErrors[i%len(benchErrors)])
+	}
+}
+
+func BenchmarkMemoize(b *testing.B) {
+	c := faults.Memoize(faults.ClassifierFunc(classifyLegacy), 1024, nil)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		c.Classify(benchErrors[i%len(benchErrors)])
+	}
+}
+
+func BenchmarkMemoizeParallel(b *testing.B) {
+	c := faults.Memoize(faults.ClassifierFunc(classifyLegacy), 1024, nil)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			c.Classify(benchErrors[i%len(benchErrors)])
+		}
+	})
+}
@@ -0,0 +1,106 @@
+package faults
+
+import "errors"
+
+// AppendViolations returns a fault of the category of `err`, carrying the
+// violations of `err` followed by `violations`, e.g. to complete a bad
+// request with the violations found by another validator. Like with the
+// methods of Builder, the violations the category can't carry are ignored.
+//
+// The result is a new fault, which wraps the error wrapped by the fault of
+// `err`, and carries its reason, its retry delay, its permanent marker and
+// its help links. Other wrappers of `err`, e.g. added with fmt.Errorf, are
+// dropped. `err` itself is left untouched.
+//
+// It returns `err` as is when it is nil, or when its category doesn't carry
+// violations.
+func AppendViolations(err error, violations ...Violation) error {
+	b, ok := builderOf(err)
+	if !ok {
+		return err
+	}
+	b.Violations(violations...)
+	_, err = b.build(1)
+	return traced(EventWrap, err)
+}
+
+// Merge combines `errs` into a single error, e.g. the results of validators
+// that run independently. Nil errors are skipped.
+//
+// When all the errors are faults of the same category carrying violations,
+// e.g. bad requests, the result is the first fault, with the violations of
+// all of them, in order (see AppendViolations). Otherwise, the errors are
+// joined with errors.Join, and the category of the result is resolved by
+// CodeOf.
+//
+// It returns nil when all the errors are nil.
+func Merge(errs ...error) error {
+	var nonNil []error
+	for _, err := range errs {
+		if err != nil {
+			nonNil = append(nonNil, err)
+		}
+	}
+	switch len(nonNil) {
+	case 0:
+		return nil
+	case 1:
+		return nonNil[0]
+	}
+
+	b, ok := builderOf(nonNil[0])
+	for _, err := range nonNil[1:] {
+		if !ok || CodeOf(err) != b.code {
+			return errors.Join(nonNil...)
+		}
+	}
+	for _, err := range nonNil[1:] {
+		b.Violations(ViolationsOf(err)...)
+	}
+	_, err := b.build(1)
+	return traced(EventWrap, err)
+}
+
+// builderOf returns a builder of a copy of the fault of `err`, when its
+// category carries violations.
+func builderOf(err error) (*Builder, bool) {
+	code := CodeOf(err)
+	b := &Builder{code: code}
+	switch code {
+	case CodeBad, CodeFailedPrecondition, CodeAborted, CodeResourceExhausted:
+	default:
+		return nil, false
+	}
+	found := false
+	walk(err, func(err error) bool {
+		// Wrappers above the fault are applied again to the copy, while the
+		// ones below it are kept with its parent
+		switch e := err.(type) {
+		case *ErrorInfo:
+			if b.reason == "" {
+				b.reason, b.domain, b.metadata = e.Reason, e.Domain, e.Metadata
+			}
+		case *permanent:
+			b.permanent = true
+		case *Help:
+			b.links = append(b.links, e.Links...)
+		}
+		f, ok := err.(Fault)
+		if !ok || f.Code() != code {
+			return true
+		}
+		found = true
+		if u, ok := f.(interface{ Unwrap() error }); ok {
+			b.parent = u.Unwrap()
+		}
+		if e, ok := f.(*QuotaFailure); ok {
+			b.retryDelay = e.RetryInfo.RetryDelay
+		}
+		return false
+	})
+	if !found {
+		return nil, false
+	}
+	b.Violations(ViolationsOf(err)...)
+	return b, true
+}
@@ -0,0 +1,80 @@
+package faults_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+)
+
+// TestAppendViolations ensures violations are appended to a copy of faults,
+// which keep their details.
+func TestAppendViolations(t *testing.T) {
+	cause := errors.New("invalid form")
+	name := faults.Field("name", "Field required")
+	email := faults.Field("email", "Field required")
+
+	fault := faults.WithBad(cause, name)
+	err := faults.AppendViolations(faults.Permanent(faults.WithReason(fault, "INVALID_FORM")), email, faults.Quota("project:42", "ignored"))
+
+	e, ok := faults.AsBad(err)
+	if !ok {
+		t.Fatalf("expect bad request, but got %v", err)
+	}
+	if expect := []*faults.FieldViolation{name, email}; !reflect.DeepEqual(e.Violations, expect) {
+		t.Errorf("expect violations %v, but got %v", expect, e.Violations)
+	}
+	if !errors.Is(err, cause) || faults.ReasonOf(err) != "INVALID_FORM" || !faults.IsPermanent(err) {
+		t.Errorf("expect cause, reason and permanent marker to be kept, but got %v", err)
+	}
+	if e, _ := faults.AsBad(fault); len(e.Violations) != 1 {
+		t.Errorf("expect original fault to be left untouched, but got %v", e.Violations)
+	}
+
+	quota := faults.AppendViolations(faults.Build(faults.CodeResourceExhausted).Retryable(time.Minute).Err(), faults.Quota("project:42", "daily limit"))
+	if e, ok := faults.AsResourceExhausted(quota); !ok || len(e.Violations) != 1 || e.RetryInfo.RetryDelay != time.Minute {
+		t.Errorf("expect quota violation and retry delay, but got %v", quota)
+	}
+
+	// Faults whose category doesn't carry violations are returned as is
+	for i, err := range []error{nil, faults.NotFound, cause} {
+		if got := faults.AppendViolations(err, email); got != err {
+			t.Errorf("%d - expect %v, but got %v", i, err, got)
+		}
+	}
+}
+
+// TestMerge ensures the violations of faults of the same category are
+// combined into one fault.
+func TestMerge(t *testing.T) {
+	name := faults.Field("name", "Field required")
+	email := faults.Field("email", "Field required")
+	tos := faults.Precondition("TOS", "user:42", "not accepted")
+	age := faults.Precondition("AGE", "user:42", "under 18")
+
+	err := faults.Merge(faults.Bad(name), nil, faults.Bad(email))
+	if e, ok := faults.AsBad(err); !ok || !reflect.DeepEqual(e.Violations, []*faults.FieldViolation{name, email}) {
+		t.Errorf("expect bad request with both violations, but got %v", err)
+	}
+
+	err = faults.Merge(faults.FailedPrecondition(tos), faults.FailedPrecondition(age))
+	if e, ok := faults.AsFailedPrecondition(err); !ok || !reflect.DeepEqual(e.Violations, []*faults.PreconditionViolation{tos, age}) {
+		t.Errorf("expect failed precondition with both violations, but got %v", err)
+	}
+
+	// Faults of different categories are joined
+	bad, precondition := faults.Bad(name), faults.FailedPrecondition(tos)
+	err = faults.Merge(bad, precondition)
+	if !errors.Is(err, bad) || !errors.Is(err, precondition) {
+		t.Errorf("expect both faults to be joined, but got %v", err)
+	}
+
+	if err := faults.Merge(nil, nil); err != nil {
+		t.Errorf("expect nil, but got %v", err)
+	}
+	if err := faults.Merge(nil, bad); err != bad {
+		t.Errorf("expect single fault as is, but got %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+package faults
+
+import "fmt"
+
+// annotated prefixes the message of the error it wraps with some context
+// (see Messagef).
+type annotated struct {
+	error
+	context string
+}
+
+func (e *annotated) Error() string {
+	return e.context + ": " + e.error.Error()
+}
+
+func (e *annotated) Unwrap() error {
+	return e.error
+}
+
+// Messagef attaches human context to `err`, formatted according to `format`,
+// which prefixes its message like fmt.Errorf("...: %w", err) would. `err`
+// keeps its category and its details, so
+//
+//	return faults.Messagef(faults.NotFound, "loading profile %s", id)
+//
+// is a NotFound fault with the message "loading profile 42: resource not
+// found". The context is part of the message, so Sanitize drops it for
+// server faults, like the rest of their message.
+//
+// It returns nil when `err` is nil.
+func Messagef(err error, format string, args ...any) error {
+	if err == nil {
+		return nil
+	}
+	return traced(EventWrap, annotate(hopped(err, 2), format, args))
+}
+
+// WithPermissionDeniedf wraps `parent` with a `PermissionFailure`, with the
+// context formatted according to `format` (see Messagef)
+func WithPermissionDeniedf(parent error, format string, args ...any) error {
+	return traced(EventWrap, annotate(&PermissionFailure{hopped(parent, 2)}, format, args))
+}
+
+// WithNotFoundf wraps `parent` with a `MissingFailure`, with the context
+// formatted according to `format` (see Messagef)
+//
+// Example:
+//
+//	if errors.Is(err, sql.ErrNoRows) {
+//		return faults.WithNotFoundf(err, "loading profile %s", id)
+//	}
+func WithNotFoundf(parent error, format string, args ...any) error {
+	return traced(EventWrap, annotate(&MissingFailure{hopped(parent, 2)}, format, args))
+}
+
+// WithUnimplementedf wraps `parent` with an `UnimplementedFailure`, with the
+// context formatted according to `format` (see Messagef)
+func WithUnimplementedf(parent error, format string, args ...any) error {
+	return traced(EventWrap, annotate(&UnimplementedFailure{hopped(parent, 2)}, format, args))
+}
+
+// WithInternalf wraps `parent` with an `InternalFailure`, with the context
+// formatted according to `format` (see Messagef)
+func WithInternalf(parent error, format string, args ...any) error {
+	return traced(EventWrap, annotate(&InternalFailure{hopped(parent, 2)}, format, args))
+}
+
+// WithDeadlineExceededf wraps `parent` with a `DeadlineFailure`, with the
+// context formatted according to `format` (see Messagef)
+func WithDeadlineExceededf(parent error, format string, args ...any) error {
+	return traced(EventWrap, annotate(&DeadlineFailure{hopped(parent, 2)}, format, args))
+}
+
+func annotate(err error, format string, args []any) error {
+	return &annotated{error: err, context: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,99 @@
+package faults_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/deixis/faults"
+)
+
+// TestMessagef ensures context is attached to faults, which keep their
+// category and their details.
+func TestMessagef(t *testing.T) {
+	cause := errors.New("sql: no rows in result set")
+
+	table := []struct {
+		Error   error
+		Code    faults.Code
+		Message string
+	}{
+		{
+			Error:   faults.Messagef(faults.NotFound, "loading profile %s", "42"),
+			Code:    faults.CodeNotFound,
+			Message: "loading profile 42: resource not found",
+		},
+		{
+			Error:   faults.Messagef(faults.Bad(&faults.FieldViolation{Field: "email", Description: "Field required"}), "signing up"),
+			Code:    faults.CodeBad,
+			Message: "signing up: Field required",
+		},
+		{
+			Error:   faults.Messagef(faults.Messagef(faults.Internal, "saving %d rows", 3), "importing %q", "users.csv"),
+			Code:    faults.CodeInternal,
+			Message: `importing "users.csv": saving 3 rows: internal error`,
+		},
+		{
+			Error:   faults.WithNotFoundf(cause, "loading profile %s", "42"),
+			Code:    faults.CodeNotFound,
+			Message: "loading profile 42: resource not found",
+		},
+		{
+			Error:   faults.WithPermissionDeniedf(nil, "deleting invoice %d", 7),
+			Code:    faults.CodePermissionDenied,
+			Message: "deleting invoice 7: permission denied",
+		},
+		{
+			Error:   faults.WithUnimplementedf(nil, "exporting as %s", "xlsx"),
+			Code:    faults.CodeUnimplemented,
+			Message: "exporting as xlsx: unimplemented (yet)",
+		},
+		{
+			Error:   faults.WithInternalf(cause, "loading profile %s", "42"),
+			Code:    faults.CodeInternal,
+			Message: "loading profile 42: internal error",
+		},
+		{
+			Error:   faults.WithDeadlineExceededf(nil, "calling %s", "billing"),
+			Code:    faults.CodeDeadlineExceeded,
+			Message: "calling billing: deadline exceeded",
+		},
+	}
+
+	for i, test := range table {
+		if code := faults.CodeOf(test.Error); code != test.Code {
+			t.Errorf("%d - expect code %s, but got %s", i, test.Code, code)
+		}
+		if msg := test.Error.Error(); msg != test.Message {
+			t.Errorf("%d - expect message %q, but got %q", i, test.Message, msg)
+		}
+	}
+
+	err := faults.WithNotFoundf(cause, "loading profile %s", "42")
+	if !errors.Is(err, cause) || !faults.IsNotFound(err) {
+		t.Errorf("expect the fault and its cause to be found, but got %v", err)
+	}
+	if _, ok := faults.AsBad(table[1].Error); !ok {
+		t.Errorf("expect details to be kept, but got %v", table[1].Error)
+	}
+	if err := faults.Messagef(nil, "loading profile %s", "42"); err != nil {
+		t.Errorf("expect nil, but got %v", err)
+	}
+}
+
+// TestMessagefSanitize ensures the context of server faults doesn't leak.
+func TestMessagefSanitize(t *testing.T) {
+	err := faults.Sanitize(faults.Messagef(faults.Internal, "querying %s", "users"))
+	if msg := err.Error(); msg != "internal error" {
+		t.Errorf("expect context to be dropped, but got %q", msg)
+	}
+
+	err = faults.Sanitize(faults.Messagef(faults.NotFound, "loading profile %s", "42"))
+	if msg := err.Error(); msg != "loading profile 42: resource not found" {
+		t.Errorf("expect context of client faults to be kept, but got %q", msg)
+	}
+
+	data, _ := faults.Encode(faults.Messagef(faults.NotFound, "loading profile %s", "42"))
+	if err := faults.Decode(data); !faults.IsNotFound(err) || err.Error() != "loading profile 42: resource not found" {
+		t.Errorf("expect context to survive the binary encoding, but got %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package faults
+
+import "fmt"
+
+// MustAs is like AsType, but panics when no error in the tree of `err` is of
+// type `T`. It is meant for tests and initialisation paths, where a fault of
+// another shape is a programming error:
+//
+//	e := faults.MustAs[*faults.BadRequest](err)
+//	if len(e.Violations) != 2 {
+//		t.Errorf("expect 2 violations, but got %d", len(e.Violations))
+//	}
+//
+// The panic message names the expected type, and the category and the
+// message of `err`.
+func MustAs[T error](err error) T {
+	e, ok := as[T](err)
+	if !ok {
+		if err == nil {
+			panic(fmt.Sprintf("faults: expect %T, but got nil", e))
+		}
+		panic(fmt.Sprintf("faults: expect %T, but got %s error %q", e, CodeOf(err), err))
+	}
+	return e
+}
+
+// MustCode returns `err` when its category is `code`, and panics otherwise,
+// e.g. to check the faults returned by the steps of a test before asserting
+// their details:
+//
+//	err := faults.MustCode(svc.Reserve(ctx, item), faults.CodeAborted)
+//
+// Like with CodeOf, a nil `err` is of the category CodeOK.
+func MustCode(err error, code Code) error {
+	switch c := CodeOf(err); {
+	case c == code:
+		return err
+	case err == nil:
+		panic(fmt.Sprintf("faults: expect %s error, but got nil", code))
+	default:
+		panic(fmt.Sprintf("faults: expect %s error, but got %s error %q", code, c, err))
+	}
+}
+
+// MustParseCode is like ParseCode, but panics when no code is named `name`,
+// e.g. to initialise package variables from names known at build time:
+//
+//	var codeQuotaExceeded = faults.MustParseCode("RESOURCE_EXHAUSTED")
+func MustParseCode(name string) Code {
+	code, err := ParseCode(name)
+	if err != nil {
+		panic(err.Error())
+	}
+	return code
+}
@@ -0,0 +1,90 @@
+package faults_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/deixis/faults"
+)
+
+// mustPanic returns the message `fn` panics with, or "" when it returns.
+func mustPanic(fn func()) (msg string) {
+	defer func() {
+		if r := recover(); r != nil {
+			msg = fmt.Sprint(r)
+		}
+	}()
+	fn()
+	return ""
+}
+
+// TestMustAs ensures MustAs returns the fault of the expected type, and
+// panics with a clear message otherwise.
+func TestMustAs(t *testing.T) {
+	err := fmt.Errorf("save: %w", faults.Bad(faults.Field("email", "Field required")))
+	if e := faults.MustAs[*faults.BadRequest](err); len(e.Violations) != 1 {
+		t.Errorf("expect bad request with 1 violation, but got %v", e)
+	}
+
+	table := []struct {
+		Err    error
+		Expect string
+	}{
+		{
+			Err:    faults.NotFound,
+			Expect: `faults: expect *faults.BadRequest, but got NOT_FOUND error "resource not found"`,
+		},
+		{
+			Err:    errors.New("boom"),
+			Expect: `faults: expect *faults.BadRequest, but got UNKNOWN error "boom"`,
+		},
+		{
+			Err:    nil,
+			Expect: `faults: expect *faults.BadRequest, but got nil`,
+		},
+	}
+
+	for i, test := range table {
+		msg := mustPanic(func() { faults.MustAs[*faults.BadRequest](test.Err) })
+		if msg != test.Expect {
+			t.Errorf("%d - expect panic %q, but got %q", i, test.Expect, msg)
+		}
+	}
+}
+
+// TestMustCode ensures MustCode returns errors of the expected category, and
+// panics otherwise.
+func TestMustCode(t *testing.T) {
+	if err := faults.MustCode(faults.NotFound, faults.CodeNotFound); err != faults.NotFound {
+		t.Errorf("expect error to be returned as is, but got %v", err)
+	}
+	if err := faults.MustCode(nil, faults.CodeOK); err != nil {
+		t.Errorf("expect nil, but got %v", err)
+	}
+
+	table := []struct {
+		Err    error
+		Expect string
+	}{
+		{Err: faults.NotFound, Expect: `faults: expect ABORTED error, but got NOT_FOUND error "resource not found"`},
+		{Err: nil, Expect: `faults: expect ABORTED error, but got nil`},
+	}
+
+	for i, test := range table {
+		msg := mustPanic(func() { faults.MustCode(test.Err, faults.CodeAborted) })
+		if msg != test.Expect {
+			t.Errorf("%d - expect panic %q, but got %q", i, test.Expect, msg)
+		}
+	}
+}
+
+// TestMustParseCode ensures MustParseCode panics on unknown names.
+func TestMustParseCode(t *testing.T) {
+	if code := faults.MustParseCode("not_found"); code != faults.CodeNotFound {
+		t.Errorf("expect %s, but got %s", faults.CodeNotFound, code)
+	}
+	if msg := mustPanic(func() { faults.MustParseCode("NOPE") }); msg != `faults: unknown code "NOPE"` {
+		t.Errorf("expect panic, but got %q", msg)
+	}
+}
@@ -0,0 +1,64 @@
+package faults
+
+import "time"
+
+// An Option sets a detail of a fault built with Build. Options let faults be
+// described by the details they carry, whatever their category, so new
+// details can be added without changing the signature of constructors:
+//
+//	return faults.Build(faults.CodeUnavailable, faults.WithRetry(time.Second)).Err()
+//
+// The constructors that don't take violations accept options too, e.g.
+// WithNotFound or WithUnavailable. The ones taking violations, e.g. WithBad,
+// already end with a variadic argument, so their faults are built with Build
+// or New when they need other details.
+type Option func(b *Builder)
+
+// With applies `opts` to `b`.
+func (b *Builder) With(opts ...Option) *Builder {
+	for _, opt := range opts {
+		if opt != nil {
+			opt(b)
+		}
+	}
+	return b
+}
+
+// WithMessage sets the message of the error wrapped by the fault (see
+// Builder.Message).
+func WithMessage(message string) Option {
+	return func(b *Builder) {
+		b.Message(message)
+	}
+}
+
+// WithCause sets the error wrapped by the fault (see Builder.Wrap).
+func WithCause(err error) Option {
+	return func(b *Builder) {
+		b.Wrap(err)
+	}
+}
+
+// WithRetry sets the delay advised before retrying the fault (see
+// Builder.Retryable).
+func WithRetry(delay time.Duration) Option {
+	return func(b *Builder) {
+		b.Retryable(delay)
+	}
+}
+
+// WithViolations adds violations to the fault. Like with the methods of
+// Builder, the violations the category can't carry are ignored, e.g. field
+// violations of an Aborted fault.
+func WithViolations(violations ...Violation) Option {
+	return func(b *Builder) {
+		b.Violations(violations...)
+	}
+}
+
+// WithHelp attaches links to documentation to the fault (see Help).
+func WithHelp(links ...Link) Option {
+	return func(b *Builder) {
+		b.Help(links...)
+	}
+}
@@ -0,0 +1,123 @@
+package faults_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+)
+
+// TestOptions ensures options set the details of built faults.
+func TestOptions(t *testing.T) {
+	cause := errors.New("dial tcp: connection refused")
+	links := []faults.Link{{Description: "Status page", URL: "https://status.example.com"}}
+
+	err := faults.Build(faults.CodeUnavailable,
+		faults.WithCause(cause),
+		faults.WithMessage("loading profile"),
+		faults.WithRetry(2*time.Second),
+		faults.WithHelp(links...),
+		nil,
+	).Err()
+
+	if !faults.IsUnavailable(err) || !errors.Is(err, cause) {
+		t.Errorf("expect unavailable fault wrapping its cause, but got %s %v", faults.CodeOf(err), err)
+	}
+	if e, ok := faults.AsUnavailable(err); !ok || e.Unwrap().Error() != "loading profile: dial tcp: connection refused" {
+		t.Errorf("expect message of the cause to be prefixed, but got %v", e)
+	}
+	if delay := faults.RetryAdvice(err).Delay; delay != 2*time.Second {
+		t.Errorf("expect retry delay 2s, but got %s", delay)
+	}
+	if got := faults.HelpOf(err); !reflect.DeepEqual(got, links) {
+		t.Errorf("expect links %v, but got %v", links, got)
+	}
+}
+
+// TestConstructorOptions ensures options set the details of the faults
+// returned by constructors.
+func TestConstructorOptions(t *testing.T) {
+	cause := errors.New("dial tcp: connection refused")
+	links := []faults.Link{{Description: "Status page", URL: "https://status.example.com"}}
+
+	table := []struct {
+		Error error
+		Code  faults.Code
+		Delay time.Duration
+	}{
+		{Error: faults.WithUnavailable(cause, 2*time.Second, faults.WithHelp(links...)), Code: faults.CodeUnavailable, Delay: 2 * time.Second},
+		{Error: faults.Unavailable(time.Second, faults.WithCause(cause), faults.WithHelp(links...)), Code: faults.CodeUnavailable, Delay: time.Second},
+		{Error: faults.WithNotFound(cause, faults.WithHelp(links...)), Code: faults.CodeNotFound},
+		{Error: faults.WithPermissionDenied(cause, faults.WithHelp(links...)), Code: faults.CodePermissionDenied},
+		{Error: faults.WithUnimplemented(cause, faults.WithHelp(links...)), Code: faults.CodeUnimplemented},
+		{Error: faults.WithInternal(cause, faults.WithHelp(links...)), Code: faults.CodeInternal},
+		{Error: faults.WithDeadlineExceeded(cause, faults.WithHelp(links...)), Code: faults.CodeDeadlineExceeded},
+	}
+
+	for i, test := range table {
+		if code := faults.CodeOf(test.Error); code != test.Code || !errors.Is(test.Error, cause) {
+			t.Errorf("%d - expect %s fault wrapping its cause, but got %s %v", i, test.Code, code, test.Error)
+		}
+		if delay := faults.RetryAdvice(test.Error).Delay; delay != test.Delay {
+			t.Errorf("%d - expect retry delay %s, but got %s", i, test.Delay, delay)
+		}
+		if got := faults.HelpOf(test.Error); !reflect.DeepEqual(got, links) {
+			t.Errorf("%d - expect links %v, but got %v", i, links, got)
+		}
+	}
+}
+
+// TestConstructorOptionsTrace ensures the faults returned by constructors
+// with options are reported as wrapped by the caller of the constructor.
+func TestConstructorOptionsTrace(t *testing.T) {
+	rec := &recorder{}
+	faults.SetTraceSink(rec)
+	defer faults.SetTraceSink(nil)
+
+	faults.WithNotFound(errors.New("no rows"), faults.WithMessage("loading user"))
+
+	if len(rec.events) != 1 {
+		t.Fatalf("expect 1 event, but got %d", len(rec.events))
+	}
+	if ev := rec.events[0]; ev.Kind != faults.EventWrap || !strings.Contains(ev.Caller, "option_test.go") {
+		t.Errorf("expect wrap event in option_test.go, but got %s at %s", ev.Kind, ev.Caller)
+	}
+}
+
+// TestWithViolations ensures violations are dispatched to the categories
+// carrying them.
+func TestWithViolations(t *testing.T) {
+	violations := []faults.Violation{
+		&faults.FieldViolation{Field: "email", Description: "Field required"},
+		&faults.PreconditionViolation{Type: "TOS", Subject: "user:42", Description: "not accepted"},
+		&faults.ConflictViolation{Resource: "invoice:123", Description: "version mismatch"},
+		&faults.QuotaViolation{Subject: "project:42", Description: "daily limit"},
+	}
+
+	table := []struct {
+		Code   faults.Code
+		Expect error
+	}{
+		{Code: faults.CodeBad, Expect: faults.Bad(violations[0].(*faults.FieldViolation))},
+		{Code: faults.CodeFailedPrecondition, Expect: faults.FailedPrecondition(violations[1].(*faults.PreconditionViolation))},
+		{Code: faults.CodeAborted, Expect: faults.Aborted(violations[2].(*faults.ConflictViolation))},
+		{Code: faults.CodeResourceExhausted, Expect: faults.ResourceExhausted(violations[3].(*faults.QuotaViolation))},
+		{Code: faults.CodeNotFound, Expect: faults.WithNotFound(nil)},
+	}
+
+	for i, test := range table {
+		err := faults.Build(test.Code, faults.WithViolations(violations...)).Err()
+		if !reflect.DeepEqual(err, test.Expect) {
+			t.Errorf("%d - expect %#v, but got %#v", i, test.Expect, err)
+		}
+	}
+
+	// Options can also be applied to a builder
+	err := faults.Build(faults.CodeBad).Field("name", "Too long").With(faults.WithViolations(violations[0])).Err()
+	if e, ok := faults.AsBad(err); !ok || len(e.Violations) != 2 {
+		t.Errorf("expect 2 violations, but got %v", err)
+	}
+}
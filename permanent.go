@@ -0,0 +1,35 @@
+package faults
+
+// permanent marks the error it wraps as not retryable (see Permanent).
+type permanent struct {
+	error
+}
+
+func (e *permanent) Unwrap() error {
+	return e.error
+}
+
+// Permanent marks `parent` as not retryable, regardless of its category. It
+// keeps its category, but RetryAdvice reports it as not retryable and advises
+// no delay, so neither Backoff nor the transports retry it, and no retry hint
+// (e.g. Retry-After) is rendered for it.
+//
+// It is meant for failures whose category is usually transient, but that are
+// known not to recover, e.g. a resource that is being decommissioned:
+//
+//	if svc.Decommissioned() {
+//		return faults.Permanent(faults.Unavailable(0))
+//	}
+func Permanent(parent error) error {
+	if parent == nil {
+		return nil
+	}
+	return traced(EventWrap, &permanent{error: parent})
+}
+
+// IsPermanent reports whether `err` was marked as not retryable with
+// Permanent.
+func IsPermanent(err error) bool {
+	_, ok := as[*permanent](err)
+	return ok
+}
@@ -0,0 +1,40 @@
+package faults_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+)
+
+// TestPermanent ensures permanent faults keep their category, but are never
+// retried.
+func TestPermanent(t *testing.T) {
+	table := []struct {
+		Error     error
+		Code      faults.Code
+		Permanent bool
+	}{
+		{Error: faults.Unavailable(time.Second), Code: faults.CodeUnavailable},
+		{Error: faults.Permanent(faults.Unavailable(time.Second)), Code: faults.CodeUnavailable, Permanent: true},
+		{Error: fmt.Errorf("get: %w", faults.Permanent(faults.Aborted())), Code: faults.CodeAborted, Permanent: true},
+		{Error: faults.Permanent(faults.NotFound), Code: faults.CodeNotFound, Permanent: true},
+	}
+
+	for i, test := range table {
+		if code := faults.CodeOf(test.Error); code != test.Code {
+			t.Errorf("%d - expect code %s, but got %s", i, test.Code, code)
+		}
+		if permanent := faults.IsPermanent(test.Error); permanent != test.Permanent {
+			t.Errorf("%d - expect permanent %t, but got %t", i, test.Permanent, permanent)
+		}
+		if test.Permanent && faults.IsRetryable(test.Error) {
+			t.Errorf("%d - expect permanent fault not to be retryable", i)
+		}
+	}
+
+	if err := faults.Permanent(nil); err != nil {
+		t.Errorf("expect nil, but got %s", err)
+	}
+}
@@ -0,0 +1,40 @@
+package faults
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Recover converts a panic of the calling function into an InternalFailure
+// assigned to `err`, so panics flow through the same error pipeline as the
+// faults returned by the function. It must be deferred:
+//
+//	func (s *Service) Import(ctx context.Context, r io.Reader) (err error) {
+//		defer faults.Recover(&err)
+//		// ...
+//	}
+//
+// The fault is described as "panic: <value>: internal error" and carries a
+// DebugInfo with the stack of the panic, and the panic value as Detail. It
+// wraps the panic value when it is an error, e.g. a runtime.Error, which can
+// then be found with errors.Is or errors.As. Like any server fault, it is
+// reduced to its category by Sanitize.
+//
+// It does nothing when the function doesn't panic.
+func Recover(err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	parent, ok := r.(error)
+	if !ok {
+		parent = errors.New(fmt.Sprint(r))
+	}
+	detail := "panic: " + fmt.Sprint(r)
+	*err = traced(EventCreate, &DebugInfo{
+		error: &annotated{error: &InternalFailure{parent}, context: detail},
+		// The frames of the runtime raising the panic are dropped
+		Stack:  callStack(1),
+		Detail: detail,
+	})
+}
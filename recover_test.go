@@ -0,0 +1,73 @@
+package faults_test
+
+import (
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/deixis/faults"
+)
+
+// importRows panics like a buggy import would.
+func importRows(rows []string) (err error) {
+	defer faults.Recover(&err)
+	_ = rows[len(rows)]
+	return nil
+}
+
+// TestRecover ensures panics are converted into internal failures carrying
+// the stack and the value of the panic.
+func TestRecover(t *testing.T) {
+	err := importRows(nil)
+	if !faults.IsInternal(err) {
+		t.Fatalf("expect internal failure, but got %s %v", faults.CodeOf(err), err)
+	}
+	if msg := err.Error(); !strings.HasPrefix(msg, "panic: runtime error: index out of range") || !strings.HasSuffix(msg, ": internal error") {
+		t.Errorf("expect panic message, but got %q", msg)
+	}
+	var re runtime.Error
+	if !errors.As(err, &re) {
+		t.Errorf("expect panic value to be wrapped, but got %v", err)
+	}
+
+	info, ok := faults.AsDebugInfo(err)
+	if !ok || !strings.HasPrefix(info.Detail, "panic: runtime error") {
+		t.Fatalf("expect panic value as debug info, but got %v", info)
+	}
+	if len(info.Stack) < 2 || !strings.HasSuffix(info.Stack[0].Function, "faults_test.importRows") || !strings.HasSuffix(info.Stack[1].Function, "faults_test.TestRecover") {
+		t.Errorf("expect stack from importRows, but got %v", info.Stack)
+	}
+	if msg := faults.Sanitize(err).Error(); msg != "internal error" {
+		t.Errorf("expect panic to be sanitized, but got %q", msg)
+	}
+}
+
+// TestRecoverValues ensures any panic value is converted, and that functions
+// that don't panic keep their error.
+func TestRecoverValues(t *testing.T) {
+	table := []struct {
+		Fn     func() error
+		Expect string
+	}{
+		{Fn: func() error { panic("boom") }, Expect: "panic: boom: internal error"},
+		{Fn: func() error { panic(42) }, Expect: "panic: 42: internal error"},
+		{Fn: func() error { panic(faults.NotFound) }, Expect: "panic: resource not found: internal error"},
+		{Fn: func() error { return faults.NotFound }, Expect: "resource not found"},
+		{Fn: func() error { return nil }, Expect: ""},
+	}
+
+	for i, test := range table {
+		err := func() (err error) {
+			defer faults.Recover(&err)
+			return test.Fn()
+		}()
+		var msg string
+		if err != nil {
+			msg = err.Error()
+		}
+		if msg != test.Expect {
+			t.Errorf("%d - expect %q, but got %q", i, test.Expect, msg)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package faults
+
+// public carries the public message of the error it wraps (see
+// WithPublicMessage).
+type public struct {
+	error
+	message string
+}
+
+func (e *public) Unwrap() error {
+	return e.error
+}
+
+// WithPublicMessage attaches `message` to `parent` as its public message,
+// i.e. the message that is safe to show to the caller, while `parent` keeps
+// its own message, with the full detail of what happened and of the errors
+// it wraps, for logs.
+//
+//	err = faults.WithInternalf(err, "charging card of user %s", id)
+//	return faults.WithPublicMessage(err, "the payment could not be processed")
+//
+// The public message is used by Sanitize for server faults, and by Redact
+// for every fault. It returns nil when `parent` is nil.
+func WithPublicMessage(parent error, message string) error {
+	if parent == nil {
+		return nil
+	}
+	return traced(EventWrap, &public{error: hopped(parent, 2), message: message})
+}
+
+// PublicMessage returns the public message of `err`: the one attached with
+// WithPublicMessage if any, the message of `err` for client faults, and the
+// message of its category alone otherwise (see Sanitize).
+func PublicMessage(err error) string {
+	if err == nil {
+		return ""
+	}
+	if message, ok := publicMessage(err); ok {
+		return message
+	}
+	return Sanitize(err).Error()
+}
+
+// Redact returns a copy of `err` that only carries what the caller may see,
+// e.g. before it is serialized at the edge of the system.
+//
+// Faults carrying a public message (see WithPublicMessage) are replaced with
+// a fault of the same category that is described by their public message
+// and doesn't wrap anything, but that keeps their details: their violations,
+// their reason, their retry delay, their challenges and their permanent
+// marker. Other faults are sanitized (see Sanitize). Like with Sanitize, the
+// original error can still be retrieved with InternalCause.
+func Redact(err error) error {
+	message, ok := publicMessage(err)
+	if !ok || IsServerFault(err) {
+		return Sanitize(err)
+	}
+	e := envelopeOf(err)
+	e.Message = message
+	e.Cause = ""
+	return &sanitized{error: e.fault(), raw: err}
+}
+
+// publicMessage returns the outermost public message found in the tree of
+// `err`.
+func publicMessage(err error) (string, bool) {
+	if e, ok := as[*public](err); ok {
+		return e.message, true
+	}
+	return "", false
+}
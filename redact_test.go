@@ -0,0 +1,123 @@
+package faults_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+)
+
+// TestPublicMessage ensures the public message of faults is kept apart from
+// their message.
+func TestPublicMessage(t *testing.T) {
+	secret := errors.New(`pq: duplicate key value violates unique constraint "users_email_key"`)
+
+	table := []struct {
+		Error   error
+		Public  string
+		Message string
+	}{
+		{
+			Error:   faults.WithPublicMessage(faults.WithInternal(secret), "the account could not be created"),
+			Public:  "the account could not be created",
+			Message: "internal error",
+		},
+		{
+			Error:   faults.WithPublicMessage(faults.Messagef(faults.WithAborted(secret), "inserting user"), "email already in use"),
+			Public:  "email already in use",
+			Message: "inserting user: conflict: " + secret.Error(),
+		},
+		{
+			Error:   faults.Bad(&faults.FieldViolation{Field: "email", Description: "Field required"}),
+			Public:  "Field required",
+			Message: "Field required",
+		},
+		{Error: faults.WithInternal(secret), Public: "internal error", Message: "internal error"},
+		{Error: secret, Public: "unknown error", Message: secret.Error()},
+	}
+
+	for i, test := range table {
+		if public := faults.PublicMessage(test.Error); public != test.Public {
+			t.Errorf("%d - expect public message %q, but got %q", i, test.Public, public)
+		}
+		if msg := test.Error.Error(); msg != test.Message {
+			t.Errorf("%d - expect message %q, but got %q", i, test.Message, msg)
+		}
+	}
+
+	if err := faults.WithPublicMessage(nil, "nothing"); err != nil {
+		t.Errorf("expect nil, but got %v", err)
+	}
+	if public := faults.PublicMessage(nil); public != "" {
+		t.Errorf("expect empty public message, but got %q", public)
+	}
+}
+
+// TestRedact ensures redacted faults only carry their public message and
+// their details.
+func TestRedact(t *testing.T) {
+	secret := errors.New(`pq: duplicate key value violates unique constraint "users_email_key"`)
+	fault := faults.WithReason(faults.WithAborted(secret,
+		&faults.ConflictViolation{Resource: "user:42", Description: "email already in use"},
+	), "EMAIL_TAKEN")
+	fault = faults.WithPublicMessage(faults.Permanent(fault), "the account already exists")
+
+	err := faults.Redact(fault)
+	if msg := err.Error(); msg != "the account already exists" {
+		t.Errorf("expect public message, but got %q", msg)
+	}
+	if errors.Is(err, secret) {
+		t.Error("expect internal cause to be stripped")
+	}
+	faults.Walk(err, func(e error) bool {
+		if strings.Contains(e.Error(), "pq:") {
+			t.Errorf("expect no internal message in the chain, but got %q", e)
+		}
+		return true
+	})
+
+	e, ok := faults.AsAborted(err)
+	if !ok {
+		t.Fatalf("expect aborted fault, but got %s", faults.CodeOf(err))
+	}
+	expect := []*faults.ConflictViolation{{Resource: "user:42", Description: "email already in use"}}
+	if !reflect.DeepEqual(e.Violations, expect) {
+		t.Errorf("expect violations %v, but got %v", expect, e.Violations)
+	}
+	if faults.ReasonOf(err) != "EMAIL_TAKEN" || !faults.IsPermanent(err) {
+		t.Errorf("expect reason and permanent marker to be kept, but got %q %t", faults.ReasonOf(err), faults.IsPermanent(err))
+	}
+
+	// Server faults are sanitized, and described by their public message
+	err = faults.Redact(faults.WithPublicMessage(faults.WithUnavailable(secret, time.Second), "payments are paused"))
+	if !faults.IsUnavailable(err) || err.Error() != "payments are paused" || errors.Is(err, secret) {
+		t.Errorf("expect sanitized unavailable fault, but got %v", err)
+	}
+	if delay := faults.RetryAdvice(err).Delay; delay != time.Second {
+		t.Errorf("expect retry delay 1s, but got %s", delay)
+	}
+
+	// Client faults without public message are kept as is
+	bad := faults.Bad(&faults.FieldViolation{Field: "email", Description: "Field required"})
+	if err := faults.Redact(bad); err != bad {
+		t.Errorf("expect client fault to be kept, but got %v", err)
+	}
+	if err := faults.Redact(nil); err != nil {
+		t.Errorf("expect nil, but got %v", err)
+	}
+}
+
+// TestRedactInternalCause ensures the original error of redacted faults can
+// be retrieved by trusted callers.
+func TestRedactInternalCause(t *testing.T) {
+	faults.SetTrustInternalCause(true)
+	defer faults.SetTrustInternalCause(false)
+
+	fault := faults.WithPublicMessage(faults.NotFound, "no such account")
+	if err := faults.InternalCause(faults.Redact(fault)); err != fault {
+		t.Errorf("expect original error, but got %v", err)
+	}
+}
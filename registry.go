@@ -0,0 +1,290 @@
+package faults
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// A Descriptor describes a category of faults, and how it maps to transports.
+type Descriptor struct {
+	// Name is the stable name of the category, e.g. "NOT_FOUND". It is used to
+	// identify the category on the wire and in configuration files, so it must
+	// be unique and written in upper snake case.
+	Name string
+	// Server reports whether faults of this category are caused by the service
+	// rather than by its caller (see IsServerFault).
+	Server bool
+	// HTTPStatus is the HTTP status code of the category. It defaults to 500
+	// for server faults and 400 for client faults.
+	HTTPStatus int
+	// GRPCCode is the gRPC status code of the category, as defined by
+	// `google.golang.org/grpc/codes`. It defaults to Unknown (2).
+	GRPCCode uint32
+	// New creates a fault of this category that wraps `parent`. It is used by
+	// decoders to reconstruct faults received from another process.
+	New func(parent error) error
+}
+
+// firstCustomCode is the first code allocated to registered categories. Codes
+// below it are reserved for the categories of this package.
+const firstCustomCode Code = 100
+
+// A registrySnapshot is an immutable state of the registry. Registrations
+// copy the current snapshot and swap it, so lookups on the hot path (e.g.
+// Code.String in every transport mapping) never wait for a lock.
+type registrySnapshot struct {
+	descriptors map[Code]Descriptor
+	names       map[string]Code
+	// statuses maps HTTP statuses to the first custom code registered with
+	// them.
+	statuses map[int]Code
+	// overrides maps codes to the HTTP statuses set with SetHTTPStatus, and
+	// reverse maps these statuses back to their codes.
+	overrides map[Code]int
+	reverse   map[int]Code
+	next      Code
+}
+
+// clone returns a copy of `s` that can be modified.
+func (s *registrySnapshot) clone() *registrySnapshot {
+	c := &registrySnapshot{
+		descriptors: make(map[Code]Descriptor, len(s.descriptors)+1),
+		names:       make(map[string]Code, len(s.names)+1),
+		statuses:    make(map[int]Code, len(s.statuses)+1),
+		overrides:   make(map[Code]int, len(s.overrides)+1),
+		reverse:     make(map[int]Code, len(s.reverse)+1),
+		next:        s.next,
+	}
+	for code, d := range s.descriptors {
+		c.descriptors[code] = d
+	}
+	for name, code := range s.names {
+		c.names[name] = code
+	}
+	for status, code := range s.statuses {
+		c.statuses[status] = code
+	}
+	for code, status := range s.overrides {
+		c.overrides[code] = status
+	}
+	for status, code := range s.reverse {
+		c.reverse[status] = code
+	}
+	return c
+}
+
+var (
+	// registryMu serialises registrations
+	registryMu sync.Mutex
+	registry   atomic.Pointer[registrySnapshot]
+)
+
+func init() {
+	builtins := map[Code]Descriptor{
+		CodeOK:      {Name: "OK", HTTPStatus: 200, GRPCCode: 0},
+		CodeUnknown: {Name: "UNKNOWN", Server: true, HTTPStatus: 500, GRPCCode: 2},
+		CodeBad: {
+			Name: "BAD_REQUEST", HTTPStatus: 400, GRPCCode: 3,
+			New: func(parent error) error { return WithBad(parent) },
+		},
+		CodeNotFound: {
+			Name: "NOT_FOUND", HTTPStatus: 404, GRPCCode: 5,
+			New: func(parent error) error { return WithNotFound(parent) },
+		},
+		CodePermissionDenied: {
+			Name: "PERMISSION_DENIED", HTTPStatus: 403, GRPCCode: 7,
+			New: func(parent error) error { return WithPermissionDenied(parent) },
+		},
+		CodeUnauthenticated: {
+			Name: "UNAUTHENTICATED", HTTPStatus: 401, GRPCCode: 16,
+			New: func(parent error) error { return WithUnauthenticated(parent) },
+		},
+		CodeFailedPrecondition: {
+			Name: "FAILED_PRECONDITION", HTTPStatus: 400, GRPCCode: 9,
+			New: func(parent error) error { return WithFailedPrecondition(parent) },
+		},
+		CodeAborted: {
+			Name: "ABORTED", HTTPStatus: 409, GRPCCode: 10,
+			New: func(parent error) error { return WithAborted(parent) },
+		},
+		CodeResourceExhausted: {
+			Name: "RESOURCE_EXHAUSTED", HTTPStatus: 429, GRPCCode: 8,
+			New: func(parent error) error { return WithResourceExhausted(parent) },
+		},
+		CodeUnavailable: {
+			Name: "UNAVAILABLE", Server: true, HTTPStatus: 503, GRPCCode: 14,
+			New: func(parent error) error { return WithUnavailable(parent, 0) },
+		},
+		CodeUnimplemented: {
+			Name: "UNIMPLEMENTED", Server: true, HTTPStatus: 501, GRPCCode: 12,
+			New: func(parent error) error { return WithUnimplemented(parent) },
+		},
+		CodeInternal: {
+			Name: "INTERNAL", Server: true, HTTPStatus: 500, GRPCCode: 13,
+			New: func(parent error) error { return WithInternal(parent) },
+		},
+		CodeDeadlineExceeded: {
+			Name: "DEADLINE_EXCEEDED", Server: true, HTTPStatus: 504, GRPCCode: 4,
+			New: func(parent error) error { return WithDeadlineExceeded(parent) },
+		},
+	}
+	snap := &registrySnapshot{
+		descriptors: map[Code]Descriptor{},
+		names:       map[string]Code{},
+		statuses:    map[int]Code{},
+		overrides:   map[Code]int{},
+		reverse:     map[int]Code{},
+		next:        firstCustomCode,
+	}
+	for code, d := range builtins {
+		snap.descriptors[code] = d
+		snap.names[d.Name] = code
+	}
+	registry.Store(snap)
+}
+
+// Register registers a new category of faults and returns its code. It
+// allows third-party packages to define their own fault types, which are
+// then handled like the ones of this package by CodeOf and the transports.
+//
+// A custom fault type must implement Fault, and return the registered code:
+//
+//	var CodePaymentRequired = faults.Register(faults.Descriptor{
+//		Name:       "PAYMENT_REQUIRED",
+//		HTTPStatus: http.StatusPaymentRequired,
+//		GRPCCode:   uint32(codes.FailedPrecondition),
+//		New: func(parent error) error {
+//			return &PaymentFailure{parent}
+//		},
+//	})
+//
+//	type PaymentFailure struct {
+//		error
+//	}
+//
+//	func (e *PaymentFailure) Error() string { return "payment required" }
+//	func (e *PaymentFailure) Unwrap() error { return e.error }
+//	func (e *PaymentFailure) Code() faults.Code { return CodePaymentRequired }
+//
+// The numeric value of a registered code depends on the registration order,
+// so only its name must be used to identify it outside of the process.
+//
+// Register is meant to be called when the program starts, e.g. when
+// initialising a package variable. It panics if the name is invalid or is
+// already registered.
+func Register(d Descriptor) Code {
+	if !validName(d.Name) {
+		panic(fmt.Sprintf("faults: invalid category name %q", d.Name))
+	}
+	if d.HTTPStatus == 0 {
+		d.HTTPStatus = 400
+		if d.Server {
+			d.HTTPStatus = 500
+		}
+	}
+	if d.GRPCCode == 0 {
+		d.GRPCCode = 2
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	cur := registry.Load()
+	if _, ok := cur.names[d.Name]; ok {
+		panic(fmt.Sprintf("faults: category %s already registered", d.Name))
+	}
+
+	code := cur.next
+	snap := cur.clone()
+	snap.next++
+	snap.descriptors[code] = d
+	snap.names[d.Name] = code
+	if _, ok := snap.statuses[d.HTTPStatus]; !ok {
+		snap.statuses[d.HTTPStatus] = code
+	}
+	registry.Store(snap)
+	return code
+}
+
+// Describe returns the descriptor of `code`, or false when it is not
+// registered. The HTTP status of the descriptor reflects SetHTTPStatus.
+func Describe(code Code) (Descriptor, bool) {
+	snap := registry.Load()
+	d, ok := snap.descriptors[code]
+	if status, found := snap.overrides[code]; found && ok {
+		d.HTTPStatus = status
+	}
+	return d, ok
+}
+
+// SetHTTPStatus overrides the HTTP status of the category `code`, for
+// applications whose API contract differs from the default mapping, e.g.
+// 412 for FailedPrecondition rather than 400:
+//
+//	faults.SetHTTPStatus(faults.CodeFailedPrecondition, http.StatusPreconditionFailed)
+//
+// The status is used by HTTPStatus, and therefore by every HTTP renderer, and
+// it is mapped back to `code` by CodeOfHTTPStatus. When several categories
+// are set to the same status, the last one set is mapped back. A zero status
+// restores the status of the descriptor.
+//
+// It is meant to be called when the program starts, e.g. from an `init`
+// function. It panics if `code` is not registered, or if `status` is not an
+// error status (4xx or 5xx).
+func SetHTTPStatus(code Code, status int) {
+	if status != 0 && (status < 400 || status > 599) {
+		panic(fmt.Sprintf("faults: invalid HTTP status %d for %s", status, code))
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	cur := registry.Load()
+	if _, ok := cur.descriptors[code]; !ok {
+		panic(fmt.Sprintf("faults: code %d is not registered", code))
+	}
+
+	snap := cur.clone()
+	if old, ok := snap.overrides[code]; ok && snap.reverse[old] == code {
+		delete(snap.reverse, old)
+	}
+	delete(snap.overrides, code)
+	if status != 0 {
+		snap.overrides[code] = status
+		snap.reverse[status] = code
+	}
+	registry.Store(snap)
+}
+
+// overriddenCodeOfStatus returns the category whose HTTP status was set to
+// `status` with SetHTTPStatus, or false when there is none.
+func overriddenCodeOfStatus(status int) (Code, bool) {
+	code, ok := registry.Load().reverse[status]
+	return code, ok
+}
+
+// validName reports whether `name` is written in upper snake case, e.g.
+// "PAYMENT_REQUIRED".
+func validName(name string) bool {
+	if name == "" || name[0] < 'A' || name[0] > 'Z' || name[len(name)-1] == '_' {
+		return false
+	}
+	for i := 1; i < len(name); i++ {
+		switch c := name[i]; {
+		case c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '_' && name[i-1] != '_':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// customCodeOfStatus returns the registered category whose HTTP status is
+// `status`, or false when there is none. When several categories share the
+// same status, the first registered one wins.
+func customCodeOfStatus(status int) (Code, bool) {
+	code, ok := registry.Load().statuses[status]
+	return code, ok
+}
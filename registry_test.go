@@ -0,0 +1,236 @@
+package faults_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/deixis/faults"
+)
+
+var codePaymentRequired = faults.Register(faults.Descriptor{
+	Name:       "PAYMENT_REQUIRED",
+	HTTPStatus: 402,
+	New: func(parent error) error {
+		return &paymentFailure{parent}
+	},
+})
+
+// paymentFailure is a custom fault type registered by a third-party package.
+type paymentFailure struct {
+	error
+}
+
+func (e *paymentFailure) Error() string {
+	return "payment required"
+}
+
+func (e *paymentFailure) Unwrap() error {
+	return e.error
+}
+
+func (e *paymentFailure) Code() faults.Code {
+	return codePaymentRequired
+}
+
+// TestRegister ensures custom fault types are handled like built-in ones.
+func TestRegister(t *testing.T) {
+	err := fmt.Errorf("checkout: %w", &paymentFailure{})
+
+	if code := faults.CodeOf(err); code != codePaymentRequired {
+		t.Errorf("expect code %s, but got %s", codePaymentRequired, code)
+	}
+	if name := codePaymentRequired.String(); name != "PAYMENT_REQUIRED" {
+		t.Errorf("expect name PAYMENT_REQUIRED, but got %s", name)
+	}
+	if !faults.IsClientFault(err) || faults.IsServerFault(err) {
+		t.Error("expect a client fault")
+	}
+
+	d, ok := faults.Describe(codePaymentRequired)
+	if !ok {
+		t.Fatal("expect code to be registered")
+	}
+	if d.HTTPStatus != 402 || d.GRPCCode != 2 {
+		t.Errorf("expect mappings 402 and 2, but got %d and %d", d.HTTPStatus, d.GRPCCode)
+	}
+	if code := faults.CodeOf(d.New(nil)); code != codePaymentRequired {
+		t.Errorf("expect New to create a fault with code %s, but got %s", codePaymentRequired, code)
+	}
+}
+
+// TestDescribeBuiltins ensures every built-in code is registered, and can be
+// reconstructed.
+func TestDescribeBuiltins(t *testing.T) {
+	table := []faults.Code{
+		faults.CodeBad,
+		faults.CodeNotFound,
+		faults.CodePermissionDenied,
+		faults.CodeUnauthenticated,
+		faults.CodeFailedPrecondition,
+		faults.CodeAborted,
+		faults.CodeResourceExhausted,
+		faults.CodeUnavailable,
+		faults.CodeUnimplemented,
+		faults.CodeInternal,
+		faults.CodeDeadlineExceeded,
+	}
+
+	for i, code := range table {
+		d, ok := faults.Describe(code)
+		if !ok {
+			t.Errorf("%d - expect code %d to be registered", i, code)
+			continue
+		}
+		if got := faults.CodeOf(d.New(nil)); got != code {
+			t.Errorf("%d - expect New to create a fault with code %s, but got %s", i, code, got)
+		}
+	}
+}
+
+// TestRegisterInvalid ensures invalid or duplicate names are rejected.
+func TestRegisterInvalid(t *testing.T) {
+	table := []string{"", "lower", "_LEADING", "TRAILING_", "DOUBLE__UNDERSCORE", "NOT_FOUND"}
+
+	for i, name := range table {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%d - expect Register to panic for name %q", i, name)
+				}
+			}()
+			faults.Register(faults.Descriptor{Name: name})
+		}()
+	}
+}
+
+// TestSetHTTPStatus ensures the HTTP status of categories can be overridden,
+// and restored.
+func TestSetHTTPStatus(t *testing.T) {
+	defer faults.SetHTTPStatus(faults.CodeFailedPrecondition, 0)
+	defer faults.SetHTTPStatus(codePaymentRequired, 0)
+
+	faults.SetHTTPStatus(faults.CodeFailedPrecondition, 412)
+	faults.SetHTTPStatus(codePaymentRequired, 409)
+
+	table := []struct {
+		Error  error
+		Status int
+	}{
+		{Error: faults.FailedPrecondition(), Status: 412},
+		{Error: &paymentFailure{}, Status: 409},
+		{Error: faults.Bad(), Status: 400},
+		{Error: faults.Aborted(), Status: 409},
+	}
+	for i, test := range table {
+		if status := faults.HTTPStatus(test.Error); status != test.Status {
+			t.Errorf("%d - expect status %d, but got %d", i, test.Status, status)
+		}
+	}
+	if d, _ := faults.Describe(faults.CodeFailedPrecondition); d.HTTPStatus != 412 {
+		t.Errorf("expect descriptor status 412, but got %d", d.HTTPStatus)
+	}
+	if code := faults.CodeOfHTTPStatus(409); code != codePaymentRequired {
+		t.Errorf("expect 409 to be mapped back to %s, but got %s", codePaymentRequired, code)
+	}
+
+	faults.SetHTTPStatus(codePaymentRequired, 0)
+	if status := faults.HTTPStatus(&paymentFailure{}); status != 402 {
+		t.Errorf("expect status to be restored to 402, but got %d", status)
+	}
+	if code := faults.CodeOfHTTPStatus(409); code != faults.CodeAborted {
+		t.Errorf("expect 409 to be mapped back to %s, but got %s", faults.CodeAborted, code)
+	}
+
+	for i, status := range []int{200, 302, 600} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%d - expect SetHTTPStatus to panic for status %d", i, status)
+				}
+			}()
+			faults.SetHTTPStatus(faults.CodeBad, status)
+		}()
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expect SetHTTPStatus to panic for an unregistered code")
+			}
+		}()
+		faults.SetHTTPStatus(faults.Code(9999), 400)
+	}()
+}
+
+// TestConcurrentRegistry ensures the registries can be read while categories,
+// classifiers and precedences are registered. It is meant to be run with the
+// race detector.
+func TestConcurrentRegistry(t *testing.T) {
+	defer faults.SetClassifiers()
+	defer faults.SetPrecedence(nil)
+
+	const writers, readers = 4, 8
+	var wg sync.WaitGroup
+	codes := make(chan faults.Code, writers*10)
+
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 10; i++ {
+				codes <- faults.Register(faults.Descriptor{
+					Name:       fmt.Sprintf("CONCURRENT_%d_%d", w, i),
+					HTTPStatus: 400,
+				})
+				faults.RegisterClassifier(faults.ClassifierFunc(classifyVendor))
+				faults.SetPrecedence(faults.Innermost)
+				faults.SetPrecedence(nil)
+			}
+		}(w)
+	}
+
+	err := fmt.Errorf("call: %w", faults.WithNotFound(&vendorError{Status: 503}))
+	for r := 0; r < readers; r++ {
+		wg.Add(1)
+		go func(r int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				if code := faults.CodeOf(err); code != faults.CodeNotFound {
+					t.Errorf("%d - expect code %s, but got %s", r, faults.CodeNotFound, code)
+					return
+				}
+				if _, err := faults.ParseCode("not_found"); err != nil {
+					t.Errorf("%d - expect NOT_FOUND to be registered, but got %s", r, err)
+					return
+				}
+				faults.CodeOf(&vendorError{Status: 404})
+				faults.CodeOfHTTPStatus(402)
+				faults.Describe(faults.CodeUnavailable)
+			}
+		}(r)
+	}
+	wg.Wait()
+	close(codes)
+
+	seen := map[faults.Code]bool{}
+	for code := range codes {
+		if seen[code] {
+			t.Errorf("expect unique codes, but got %s twice", code)
+		}
+		seen[code] = true
+		if _, err := faults.ParseCode(code.String()); err != nil {
+			t.Errorf("expect %s to be registered, but got %s", code, err)
+		}
+	}
+}
+
+func BenchmarkCodeOfParallel(b *testing.B) {
+	err := fmt.Errorf("call: %w", faults.WithNotFound(nil))
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if faults.CodeOf(err).String() != "NOT_FOUND" {
+				b.Fatal("expect NOT_FOUND")
+			}
+		}
+	})
+}
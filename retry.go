@@ -0,0 +1,55 @@
+package faults
+
+import "time"
+
+// Advice describes whether, when and how a failed operation can be retried.
+type Advice struct {
+	// Retryable reports whether the operation may succeed if attempted again.
+	Retryable bool
+	// Delay is the minimum amount of time to wait before retrying, as advised
+	// by the fault. A zero value means no delay was advised, in which case the
+	// caller should rely on its own backoff strategy.
+	Delay time.Duration
+	// Restart reports whether the caller should retry at a higher level, by
+	// restarting the whole sequence of operations (e.g. a read-modify-write),
+	// rather than just the failing call.
+	Restart bool
+}
+
+// RetryAdvice returns how the operation that failed with `err` can be
+// retried. It encodes the litmus test described in the package docs:
+//
+//   - Faults marked with Permanent are not retryable, whatever their category.
+//   - Unavailable is retryable, after the advised RetryInfo delay if any.
+//   - Aborted is retryable at a higher level (see Advice.Restart).
+//   - ResourceExhausted is only retryable when the fault advises a delay,
+//     since an exhausted quota is not expected to be replenished otherwise.
+//   - Every other fault, and errors that are not faults, are not retryable.
+//
+// The category of `err` is resolved with CodeOf.
+func RetryAdvice(err error) Advice {
+	if IsPermanent(err) {
+		return Advice{}
+	}
+	switch CodeOf(err) {
+	case CodeUnavailable:
+		advice := Advice{Retryable: true}
+		if e, ok := AsUnavailable(err); ok {
+			advice.Delay = e.RetryInfo.RetryDelay
+		}
+		return advice
+	case CodeAborted:
+		return Advice{Retryable: true, Restart: true}
+	case CodeResourceExhausted:
+		if e, ok := AsResourceExhausted(err); ok && e.RetryInfo.RetryDelay > 0 {
+			return Advice{Retryable: true, Delay: e.RetryInfo.RetryDelay}
+		}
+	}
+	return Advice{}
+}
+
+// IsRetryable reports whether the operation that failed with `err` may
+// succeed if attempted again. See RetryAdvice for the rules applied.
+func IsRetryable(err error) bool {
+	return RetryAdvice(err).Retryable
+}
@@ -0,0 +1,62 @@
+package faults_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+)
+
+// TestRetryAdvice ensures the litmus test is applied consistently.
+func TestRetryAdvice(t *testing.T) {
+	table := []struct {
+		Error  error
+		Advice faults.Advice
+	}{
+		{Error: nil, Advice: faults.Advice{}},
+		{Error: errors.New("boom"), Advice: faults.Advice{}},
+		{Error: faults.NotFound, Advice: faults.Advice{}},
+		{Error: faults.Bad(), Advice: faults.Advice{}},
+		{Error: faults.FailedPrecondition(), Advice: faults.Advice{}},
+		{
+			Error:  faults.Unavailable(0),
+			Advice: faults.Advice{Retryable: true},
+		},
+		{
+			Error:  fmt.Errorf("call: %w", faults.Unavailable(time.Second)),
+			Advice: faults.Advice{Retryable: true, Delay: time.Second},
+		},
+		{
+			Error:  faults.Aborted(),
+			Advice: faults.Advice{Retryable: true, Restart: true},
+		},
+		{
+			Error:  faults.ResourceExhausted(),
+			Advice: faults.Advice{},
+		},
+		{
+			Error:  &faults.QuotaFailure{RetryInfo: faults.RetryInfo{RetryDelay: time.Minute}},
+			Advice: faults.Advice{Retryable: true, Delay: time.Minute},
+		},
+		{
+			Error:  faults.Permanent(faults.Unavailable(time.Second)),
+			Advice: faults.Advice{},
+		},
+		{
+			Error:  fmt.Errorf("call: %w", faults.Permanent(faults.Aborted())),
+			Advice: faults.Advice{},
+		},
+	}
+
+	for i, test := range table {
+		advice := faults.RetryAdvice(test.Error)
+		if advice != test.Advice {
+			t.Errorf("%d - expect advice %+v, but got %+v", i, test.Advice, advice)
+		}
+		if retryable := faults.IsRetryable(test.Error); retryable != test.Advice.Retryable {
+			t.Errorf("%d - expect retryable %t, but got %t", i, test.Advice.Retryable, retryable)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package faults
+
+import "errors"
+
+// sanitized is the outbound copy of an error produced by Sanitize. It only
+// unwraps to the copy, and keeps the original error aside for InternalCause.
+type sanitized struct {
+	error
+	raw error
+}
+
+func (e *sanitized) Unwrap() error {
+	return e.error
+}
+
+// Sanitize returns a copy of `err` that is safe to hand over to external
+// renderers, e.g. before it crosses a trust boundary.
+//
+// Client faults are kept as they are, since they are meant to be read by the
+// caller, except for their DebugInfo (see SetDebug), if any. Server faults are replaced with a fault of the same category that
+// doesn't wrap anything, so neither their message nor their chain can leak
+// implementation details. Their reason, their retry delay and their permanent
+// marker are kept, and they are described by their public message, if any
+// (see WithPublicMessage). Errors that are not faults are replaced with a
+// generic error.
+//
+// The original error can still be retrieved by trusted in-process callers
+// with InternalCause.
+func Sanitize(err error) error {
+	if err == nil {
+		return nil
+	}
+	if !IsServerFault(err) {
+		if outbound, ok := undebugged(err); ok {
+			return &sanitized{error: outbound, raw: err}
+		}
+		return err
+	}
+
+	code := CodeOf(err)
+	var outbound error
+	switch {
+	case code == CodeUnavailable:
+		outbound = WithUnavailable(nil, RetryAdvice(err).Delay)
+	case code == CodeUnknown:
+		outbound = errors.New("unknown error")
+	default:
+		if d, ok := Describe(code); ok && d.New != nil {
+			outbound = d.New(nil)
+		} else {
+			outbound = errors.New(code.String())
+		}
+	}
+	if e, ok := AsErrorInfo(err); ok {
+		outbound = WithScopedErrorInfo(outbound, e.Domain, e.Reason, e.Metadata)
+	}
+	if IsPermanent(err) {
+		outbound = Permanent(outbound)
+	}
+	if message, ok := publicMessage(err); ok {
+		outbound = &described{error: outbound, message: message}
+	}
+	return &sanitized{error: outbound, raw: err}
+}
+
+// SetTrustInternalCause sets whether InternalCause returns the original
+// errors of sanitized copies. It is disabled by default, and is meant to be
+// enabled once during initialisation by processes whose audit or logging
+// paths need the complete information:
+//
+//	func main() {
+//		faults.SetTrustInternalCause(true)
+//		// ...
+//	}
+func SetTrustInternalCause(trust bool) {
+	configure(func(c *Config) { c.TrustInternalCause = trust })
+}
+
+// InternalCause returns the full, unsanitized error behind the copy produced
+// by Sanitize, e.g. for an audit log. It returns `err` as is when it was not
+// sanitized, or when InternalCause is not trusted (see SetTrustInternalCause),
+// so it never exposes more than the process allows.
+func InternalCause(err error) error {
+	if !settings().TrustInternalCause {
+		return err
+	}
+	if e, ok := as[*sanitized](err); ok {
+		return e.raw
+	}
+	return err
+}
@@ -0,0 +1,78 @@
+package faults_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+)
+
+// TestSanitize ensures sanitized copies keep the category of faults, but
+// don't leak the chain of server faults.
+func TestSanitize(t *testing.T) {
+	secret := errors.New("pq: relation \"users\" does not exist")
+
+	table := []struct {
+		Error error
+		Code  faults.Code
+		Delay time.Duration
+		Leaks bool
+	}{
+		{Error: faults.WithInternal(secret), Code: faults.CodeInternal},
+		{Error: fmt.Errorf("get user: %w", faults.WithUnavailable(secret, time.Second)), Code: faults.CodeUnavailable, Delay: time.Second},
+		{Error: faults.WithReason(faults.WithDeadlineExceeded(secret), "SLOW_QUERY"), Code: faults.CodeDeadlineExceeded},
+		{Error: secret, Code: faults.CodeUnknown},
+		{Error: faults.WithNotFound(secret), Code: faults.CodeNotFound, Leaks: true},
+	}
+
+	for i, test := range table {
+		err := faults.Sanitize(test.Error)
+		if code := faults.CodeOf(err); code != test.Code {
+			t.Errorf("%d - expect code %s, but got %s", i, test.Code, code)
+		}
+		if delay := faults.RetryAdvice(err).Delay; delay != test.Delay {
+			t.Errorf("%d - expect retry delay %s, but got %s", i, test.Delay, delay)
+		}
+		if reason := faults.ReasonOf(err); reason != faults.ReasonOf(test.Error) {
+			t.Errorf("%d - expect reason %q, but got %q", i, faults.ReasonOf(test.Error), reason)
+		}
+		leaks := errors.Is(err, secret) || strings.Contains(err.Error(), secret.Error())
+		if leaks != test.Leaks {
+			t.Errorf("%d - expect leak %t, but got %t (%q)", i, test.Leaks, leaks, err)
+		}
+	}
+
+	if err := faults.Sanitize(nil); err != nil {
+		t.Errorf("expect nil, but got %s", err)
+	}
+	if !faults.IsPermanent(faults.Sanitize(faults.Permanent(faults.WithUnavailable(secret, 0)))) {
+		t.Errorf("expect permanent marker to be kept")
+	}
+}
+
+// TestInternalCause ensures the original error is only exposed to trusted
+// processes.
+func TestInternalCause(t *testing.T) {
+	secret := errors.New("pq: relation \"users\" does not exist")
+	raw := faults.WithInternal(secret)
+	err := fmt.Errorf("handler: %w", faults.Sanitize(raw))
+
+	if cause := faults.InternalCause(err); cause != err {
+		t.Errorf("expect untrusted cause to be the sanitized error, but got %v", cause)
+	}
+
+	faults.SetTrustInternalCause(true)
+	defer faults.SetTrustInternalCause(false)
+	if cause := faults.InternalCause(err); cause != raw {
+		t.Errorf("expect trusted cause to be the original error, but got %v", cause)
+	}
+	if !errors.Is(faults.InternalCause(err), secret) {
+		t.Errorf("expect trusted cause to keep the whole chain")
+	}
+	if cause := faults.InternalCause(secret); cause != secret {
+		t.Errorf("expect error that was not sanitized to be returned as is, but got %v", cause)
+	}
+}
@@ -0,0 +1,158 @@
+package faults
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sprint returns the tree of `err` as indented text, one line per error of
+// the tree, with the category of faults and their details, and the branches
+// of `errors.Join` indented below the error joining them. It is meant for
+// logs, test failures and debug endpoints, where the message of an error
+// alone doesn't tell how it was built:
+//
+//	*fmt.wrapError "order 42: is required: email is required"
+//	  reason INVALID_FORM
+//	    BAD_REQUEST "is required: email is required"
+//	      - field email: is required (REQUIRED)
+//	      *errors.errorString "email is required"
+//
+// Messages are only written by the errors that change the message of the
+// error they wrap. The hops, stacks and sources of violations are written
+// when they were recorded (see SetRecordHops and AttachStack).
+//
+// It returns "<nil>" when `err` is nil.
+func Sprint(err error) string {
+	if err == nil {
+		return "<nil>"
+	}
+	var b strings.Builder
+	sprint(&b, err, 0)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// sprint writes the tree of `err` to `b`, indented by `depth`.
+func sprint(b *strings.Builder, err error, depth int) {
+	for ; err != nil; depth++ {
+		indent := strings.Repeat("  ", depth)
+		b.WriteString(indent + label(err))
+
+		var next error
+		switch x := err.(type) {
+		case interface{ Unwrap() []error }:
+			b.WriteString("\n")
+			writeDetails(b, err, indent+"  ")
+			for _, err := range x.Unwrap() {
+				sprint(b, err, depth+1)
+			}
+			return
+		case interface{ Unwrap() error }:
+			next = x.Unwrap()
+		}
+		if next == nil || next.Error() != err.Error() {
+			b.WriteString(" " + strconv.Quote(err.Error()))
+		}
+		b.WriteString("\n")
+		writeDetails(b, err, indent+"  ")
+		err = next
+	}
+}
+
+// label returns what `err` is, e.g. the category of a fault or the reason
+// of an ErrorInfo.
+func label(err error) string {
+	switch e := err.(type) {
+	case Fault:
+		return e.Code().String()
+	case *ErrorInfo:
+		return "reason " + e.QualifiedReason()
+	case *Help:
+		return "help"
+	case *DebugInfo:
+		if e.Detail != "" {
+			return "debug info " + strconv.Quote(e.Detail)
+		}
+		return "debug info"
+	case *Attachment:
+		return "attachment " + e.Type
+	case *hop:
+		return "at " + e.frame.String()
+	case *permanent:
+		return "permanent"
+	case *truncated:
+		return "truncated"
+	case *annotated:
+		return "context"
+	case *described:
+		return "message"
+	case *public:
+		return "public message " + strconv.Quote(e.message)
+	case *sanitized:
+		return "sanitized"
+	}
+	return fmt.Sprintf("%T", err)
+}
+
+// writeDetails writes the details of `err`, e.g. its violations, one per
+// line prefixed by `indent`.
+func writeDetails(b *strings.Builder, err error, indent string) {
+	line := func(format string, args ...any) {
+		b.WriteString(indent + "- " + fmt.Sprintf(format, args...) + "\n")
+	}
+	at := func(source *Frame) string {
+		if source == nil {
+			return ""
+		}
+		return " at " + source.String()
+	}
+
+	switch e := err.(type) {
+	case *BadRequest:
+		for _, v := range e.Violations {
+			if v.Code != "" {
+				line("field %s: %s (%s)%s", v.Field, v.Description, v.Code, at(v.Source))
+			} else {
+				line("field %s: %s%s", v.Field, v.Description, at(v.Source))
+			}
+		}
+	case *PreconditionFailure:
+		for _, v := range e.Violations {
+			line("precondition %s %s: %s%s", v.Type, v.Subject, v.Description, at(v.Source))
+		}
+	case *ConflictFailure:
+		for _, v := range e.Violations {
+			line("conflict %s: %s", v.Resource, v.Description)
+		}
+	case *QuotaFailure:
+		for _, v := range e.Violations {
+			line("quota %s: %s", v.Subject, v.Description)
+		}
+		if e.RetryInfo.RetryDelay > 0 {
+			line("retry in %s", e.RetryInfo.RetryDelay.Round(time.Millisecond))
+		}
+	case *AvailabilityFailure:
+		if e.RetryInfo.RetryDelay > 0 {
+			line("retry in %s", e.RetryInfo.RetryDelay.Round(time.Millisecond))
+		}
+	case *AuthenticationFailure:
+		for _, c := range e.Challenges {
+			line("challenge %s", c)
+		}
+	case *ErrorInfo:
+		for _, k := range sortedKeys(e.Metadata) {
+			line("%s=%s", k, strconv.Quote(e.Metadata[k]))
+		}
+	case *Help:
+		for _, l := range e.Links {
+			line("%s <%s>", l.Description, l.URL)
+		}
+	case *DebugInfo:
+		for _, f := range e.Stack {
+			line("%s", f)
+		}
+	case *Attachment:
+		line("%+v", e.Value)
+	}
+}
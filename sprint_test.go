@@ -0,0 +1,85 @@
+package faults_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+)
+
+// TestSprint ensures error trees are rendered with one line per error, and
+// the details of faults.
+func TestSprint(t *testing.T) {
+	table := []struct {
+		Error  error
+		Expect string
+	}{
+		{Error: nil, Expect: "<nil>"},
+		{Error: faults.NotFound, Expect: `NOT_FOUND "resource not found"`},
+		{
+			Error: fmt.Errorf("order 42: %w", faults.WithReason(faults.WithBad(
+				errors.New("email is required"),
+				faults.Field("email", "is required", faults.ViolationRequired),
+			), "INVALID_FORM")),
+			Expect: `*fmt.wrapError "order 42: is required: email is required"
+  reason INVALID_FORM
+    BAD_REQUEST "is required: email is required"
+      - field email: is required (REQUIRED)
+      *errors.errorString "email is required"`,
+		},
+		{
+			Error: faults.WithErrorInfo(errors.Join(
+				faults.Permanent(faults.NotFound),
+				faults.Unavailable(1500*time.Millisecond),
+			), "payments.example.com/DEGRADED", map[string]string{"region": "eu-west"}),
+			Expect: `reason payments.example.com/DEGRADED
+  - region="eu-west"
+  *errors.joinError
+    permanent
+      NOT_FOUND "resource not found"
+    UNAVAILABLE "service temporarily unavailable, retry in 1.5s"
+      - retry in 1.5s`,
+		},
+		{
+			Error: faults.AttachHelp(faults.FailedPrecondition(
+				faults.Precondition("TOS", "user:42", "Terms of service not accepted"),
+			), faults.Link{Description: "Terms", URL: "https://example.com/tos"}),
+			Expect: `help
+  - Terms <https://example.com/tos>
+  FAILED_PRECONDITION "Terms of service not accepted"
+    - precondition TOS user:42: Terms of service not accepted`,
+		},
+		{
+			Error: faults.Messagef(faults.Attach(faults.Aborted(faults.Conflict("order:42", "Version mismatch")), "order", 7), "saving order"),
+			Expect: `context "saving order: Version mismatch"
+  attachment order
+    - 7
+    ABORTED "Version mismatch"
+      - conflict order:42: Version mismatch`,
+		},
+	}
+
+	for i, test := range table {
+		if s := faults.Sprint(test.Error); s != test.Expect {
+			t.Errorf("%d - expect\n%s\nbut got\n%s", i, test.Expect, s)
+		}
+	}
+}
+
+// TestSprintRecorded ensures the hops and the sources of violations are
+// rendered when they are recorded.
+func TestSprintRecorded(t *testing.T) {
+	faults.SetRecordHops(true)
+	defer faults.SetRecordHops(false)
+
+	s := faults.Sprint(faults.WithBad(errors.New("invalid form"), faults.Field("email", "is required")))
+	lines := strings.Split(s, "\n")
+	if len(lines) != 4 ||
+		!strings.HasPrefix(lines[1], "  - field email: is required at github.com/deixis/faults_test.TestSprintRecorded ") ||
+		!strings.HasPrefix(lines[2], "  at github.com/deixis/faults_test.TestSprintRecorded ") {
+		t.Errorf("expect hop and source, but got\n%s", s)
+	}
+}
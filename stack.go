@@ -0,0 +1,142 @@
+package faults
+
+import (
+	"runtime"
+	"strings"
+)
+
+// StackOptions configures the stacks captured with faults (see AttachStack
+// and CallStack), so they are short and point at the code of the
+// application rather than at the internals of its middlewares.
+type StackOptions struct {
+	// Depth is the maximum number of frames of a stack. It defaults to 32.
+	Depth int
+	// Skip is the number of frames skipped above the caller of AttachStack,
+	// e.g. 1 for services that attach stacks in helpers of their own.
+	Skip int
+	// SkipFunctions lists prefixes of the functions whose frames are dropped,
+	// e.g. "net/http." or "github.com/go-chi/chi/v5.", so stacks don't
+	// list the middlewares a request went through. The frames of the runtime
+	// are always dropped.
+	SkipFunctions []string
+	// TrimPrefixes lists prefixes trimmed from the paths of files, e.g. the
+	// root of the module, so "/src/orders/service.go" becomes
+	// "service.go" for the prefix "/src/orders/". The first matching prefix
+	// is trimmed.
+	TrimPrefixes []string
+	// TrimDependencies trims the paths of the files of dependencies, either
+	// in the module cache or vendored, and of the standard library, up to
+	// their import path, e.g. "google.golang.org/grpc@v1.64.0/server.go"
+	// or "net/http/server.go".
+	TrimDependencies bool
+}
+
+// depth returns the maximum number of frames of a stack.
+func (o *StackOptions) depth() int {
+	if o.Depth > 0 {
+		return o.Depth
+	}
+	return 32
+}
+
+// skipped reports whether the frame of the function `fn` is dropped.
+func (o *StackOptions) skipped(fn string) bool {
+	if strings.HasPrefix(fn, "runtime.") {
+		return true
+	}
+	for _, prefix := range o.SkipFunctions {
+		if strings.HasPrefix(fn, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// trim returns the path of the file of the function `fn`, trimmed.
+func (o *StackOptions) trim(fn, file string) string {
+	for _, prefix := range o.TrimPrefixes {
+		if strings.HasPrefix(file, prefix) {
+			return file[len(prefix):]
+		}
+	}
+	if !o.TrimDependencies {
+		return file
+	}
+	for _, dir := range []string{"/pkg/mod/", "/vendor/"} {
+		if i := strings.LastIndex(file, dir); i >= 0 {
+			return file[i+len(dir):]
+		}
+	}
+	// The packages of the standard library have no dot in the first element
+	// of their path
+	pkg := packageOf(fn)
+	if first, _, _ := strings.Cut(pkg, "/"); pkg != "" && !strings.Contains(first, ".") {
+		if i := strings.LastIndex(file, "/src/"+pkg+"/"); i >= 0 {
+			return file[i+len("/src/"):]
+		}
+	}
+	return file
+}
+
+// packageOf returns the import path of the package of the function `fn`,
+// e.g. "net/http" for "net/http.(*conn).serve".
+func packageOf(fn string) string {
+	slash := strings.LastIndexByte(fn, '/')
+	if dot := strings.IndexByte(fn[slash+1:], '.'); dot >= 0 {
+		return fn[:slash+1+dot]
+	}
+	return ""
+}
+
+// SetStackOptions sets the options of the stacks captured in the process. It
+// is meant to be called once during initialisation:
+//
+//	func main() {
+//		faults.SetStackOptions(faults.StackOptions{
+//			SkipFunctions:    []string{"net/http.", "github.com/go-chi/chi/v5."},
+//			TrimPrefixes:     []string{"/src/orders/"},
+//			TrimDependencies: true,
+//		})
+//		// ...
+//	}
+//
+// The frames of hops are trimmed with the same options (see SetRecordHops).
+func SetStackOptions(opts StackOptions) {
+	configure(func(c *Config) { c.Stack = opts })
+}
+
+// stackOptions returns the options of the stacks of the process.
+func stackOptions() *StackOptions {
+	return &settings().Stack
+}
+
+// CallStack returns the stack of the calling goroutine, from the caller of
+// CallStack, or `skip` frames above it, filtered and trimmed according to the
+// options set with SetStackOptions, except for their Skip. It is meant for
+// integrations that capture stacks of their own, e.g. when recovering from
+// panics.
+func CallStack(skip int) []Frame {
+	return callStack(skip + 1)
+}
+
+// callStack returns the stack of the calling goroutine, from the caller
+// `skip` frames above the caller of callStack.
+func callStack(skip int) []Frame {
+	opts := stackOptions()
+	// Capture more frames than needed, since some of them are dropped
+	pcs := make([]uintptr, 2*opts.depth()+16)
+	pcs = pcs[:runtime.Callers(skip+2, pcs)]
+
+	var stack []Frame
+	frames := runtime.CallersFrames(pcs)
+	for len(stack) < opts.depth() {
+		f, more := frames.Next()
+		if f.PC != 0 && !opts.skipped(f.Function) {
+			stack = append(stack, Frame{Function: f.Function, File: opts.trim(f.Function, f.File), Line: f.Line})
+		}
+		if !more {
+			break
+		}
+	}
+	return stack
+}
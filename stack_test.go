@@ -0,0 +1,73 @@
+package faults_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/deixis/faults"
+)
+
+// TestCallStack ensures stacks are filtered and trimmed according to the
+// stack options.
+func TestCallStack(t *testing.T) {
+	defer faults.SetStackOptions(faults.StackOptions{})
+	wd, _ := os.Getwd()
+
+	// Expected files are suffixes of the paths of the frames, and the paths
+	// starting with a slash are absolute
+	table := []struct {
+		Options faults.StackOptions
+		Expect  []string
+	}{
+		{
+			Options: faults.StackOptions{},
+			Expect:  []string{filepath.Join(wd, "stack_test.go"), "/sync/once.go", "/sync/once.go", filepath.Join(wd, "stack_test.go")},
+		},
+		{
+			Options: faults.StackOptions{TrimPrefixes: []string{"/nowhere/", wd + "/"}},
+			Expect:  []string{"stack_test.go", "/sync/once.go", "/sync/once.go", "stack_test.go"},
+		},
+		{
+			Options: faults.StackOptions{TrimDependencies: true},
+			Expect:  []string{"/stack_test.go", "sync/once.go", "sync/once.go", "/stack_test.go"},
+		},
+		{
+			Options: faults.StackOptions{SkipFunctions: []string{"sync."}, TrimPrefixes: []string{wd + "/"}},
+			Expect:  []string{"stack_test.go", "stack_test.go"},
+		},
+		{
+			Options: faults.StackOptions{Depth: 1, TrimPrefixes: []string{wd + "/"}},
+			Expect:  []string{"stack_test.go"},
+		},
+	}
+
+	for i, test := range table {
+		faults.SetStackOptions(test.Options)
+		var stack []faults.Frame
+		var once sync.Once
+		once.Do(func() {
+			stack = faults.CallStack(0)
+		})
+
+		if len(stack) < len(test.Expect) {
+			t.Errorf("%d - expect at least %d frames, but got %v", i, len(test.Expect), stack)
+			continue
+		}
+		for j, file := range test.Expect {
+			if !strings.HasSuffix(stack[j].File, file) || (strings.HasPrefix(file, "/") != strings.HasPrefix(stack[j].File, "/")) {
+				t.Errorf("%d - expect frame %d in %s, but got %s", i, j, file, stack[j])
+			}
+		}
+		if test.Options.Depth > 0 && len(stack) != test.Options.Depth {
+			t.Errorf("%d - expect %d frames, but got %v", i, test.Options.Depth, stack)
+		}
+		for _, f := range stack {
+			if strings.HasPrefix(f.Function, "runtime.") {
+				t.Errorf("%d - expect runtime frames to be dropped, but got %s", i, f)
+			}
+		}
+	}
+}
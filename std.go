@@ -0,0 +1,92 @@
+//go:build !faults_minimal
+
+package faults
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"os"
+)
+
+// StdClassifier classifies common errors of the standard library:
+//
+//   - `os.ErrNotExist` and `sql.ErrNoRows` are classified as NotFound
+//   - `os.ErrPermission` is classified as PermissionDenied
+//   - `os.ErrExist` is classified as Aborted
+//   - `context.DeadlineExceeded` and `os.ErrDeadlineExceeded` are classified
+//     as DeadlineExceeded
+//   - `net.Error` timeouts, `sql.ErrConnDone` and `driver.ErrBadConn` are
+//     classified as Unavailable
+//   - `errors.ErrUnsupported` is classified as Unimplemented
+//
+// It is not part of the default chain. It can be registered with
+// RegisterClassifier, or applied explicitly at boundaries with FromStd.
+var StdClassifier Classifier = ClassifierFunc(classifyStd)
+
+func classifyStd(err error) (Code, bool) {
+	switch {
+	case errors.Is(err, os.ErrNotExist), errors.Is(err, sql.ErrNoRows):
+		return CodeNotFound, true
+	case errors.Is(err, os.ErrPermission):
+		return CodePermissionDenied, true
+	case errors.Is(err, os.ErrExist):
+		return CodeAborted, true
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, os.ErrDeadlineExceeded):
+		return CodeDeadlineExceeded, true
+	case errors.Is(err, sql.ErrConnDone), errors.Is(err, driver.ErrBadConn):
+		return CodeUnavailable, true
+	case errors.Is(err, errors.ErrUnsupported):
+		return CodeUnimplemented, true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return CodeUnavailable, true
+	}
+	return CodeUnknown, false
+}
+
+// FromStd wraps `err` with the fault matching the standard library error it
+// carries (see StdClassifier), so boundary code doesn't need its own
+// translation table.
+//
+// Example:
+//
+//	row := db.QueryRowContext(ctx, "SELECT name FROM users WHERE id = $1", id)
+//	if err := row.Scan(&name); err != nil {
+//		return faults.FromStd(err) // NotFound when there is no such user
+//	}
+//
+// `err` is returned as is when it already carries a fault, or when it is not
+// recognised.
+func FromStd(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := FaultClassifier.Classify(err); ok {
+		return err
+	}
+
+	code, ok := classifyStd(err)
+	if !ok {
+		return err
+	}
+	switch code {
+	case CodeNotFound:
+		return WithNotFound(err)
+	case CodePermissionDenied:
+		return WithPermissionDenied(err)
+	case CodeAborted:
+		return WithAborted(err)
+	case CodeDeadlineExceeded:
+		return WithDeadlineExceeded(err)
+	case CodeUnavailable:
+		return WithUnavailable(err, 0)
+	case CodeUnimplemented:
+		return WithUnimplemented(err)
+	}
+	return err
+}
@@ -0,0 +1,70 @@
+//go:build !faults_minimal
+
+package faults_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"testing"
+
+	"github.com/deixis/faults"
+)
+
+// TestFromStd ensures common standard library errors are wrapped with the
+// matching fault, while preserving the original error.
+func TestFromStd(t *testing.T) {
+	_, errNotExist := os.Stat("/does/not/exist")
+
+	table := []struct {
+		Error error
+		Code  faults.Code
+	}{
+		{Error: errNotExist, Code: faults.CodeNotFound},
+		{Error: fs.ErrNotExist, Code: faults.CodeNotFound},
+		{Error: fmt.Errorf("load user: %w", sql.ErrNoRows), Code: faults.CodeNotFound},
+		{Error: os.ErrPermission, Code: faults.CodePermissionDenied},
+		{Error: os.ErrExist, Code: faults.CodeAborted},
+		{Error: context.DeadlineExceeded, Code: faults.CodeDeadlineExceeded},
+		{Error: os.ErrDeadlineExceeded, Code: faults.CodeDeadlineExceeded},
+		{Error: sql.ErrConnDone, Code: faults.CodeUnavailable},
+		{Error: &net.DNSError{Err: "timeout", IsTimeout: true}, Code: faults.CodeUnavailable},
+		{Error: errors.ErrUnsupported, Code: faults.CodeUnimplemented},
+		{Error: &net.DNSError{Err: "no such host", IsNotFound: true}, Code: faults.CodeUnknown},
+		{Error: context.Canceled, Code: faults.CodeUnknown},
+		{Error: faults.WithInternal(sql.ErrNoRows), Code: faults.CodeInternal},
+	}
+
+	for i, test := range table {
+		err := faults.FromStd(test.Error)
+		if code := faults.CodeOf(err); code != test.Code {
+			t.Errorf("%d - expect code %s, but got %s", i, test.Code, code)
+		}
+		if !errors.Is(err, test.Error) {
+			t.Errorf("%d - expect original error to be preserved", i)
+		}
+	}
+
+	if err := faults.FromStd(nil); err != nil {
+		t.Errorf("expect nil error, but got %s", err)
+	}
+}
+
+// TestStdClassifier ensures the standard library classifier can be plugged
+// into CodeOf.
+func TestStdClassifier(t *testing.T) {
+	defer faults.SetClassifiers()
+
+	if code := faults.CodeOf(sql.ErrNoRows); code != faults.CodeUnknown {
+		t.Errorf("expect code %s, but got %s", faults.CodeUnknown, code)
+	}
+
+	faults.RegisterClassifier(faults.StdClassifier)
+	if code := faults.CodeOf(sql.ErrNoRows); code != faults.CodeNotFound {
+		t.Errorf("expect code %s, but got %s", faults.CodeNotFound, code)
+	}
+}
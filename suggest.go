@@ -0,0 +1,122 @@
+package faults
+
+import (
+	"math"
+	"time"
+)
+
+// A Signal describes the current load of a service. Each signal is turned
+// into a utilisation ratio, where 0 is idle and 1 is saturated, and the
+// highest ratio is the load of the service (see Signal.Load). Signals that
+// are not measured are left to zero.
+type Signal struct {
+	// QueueDepth is the number of requests waiting to be processed, out of
+	// QueueCapacity.
+	QueueDepth    int
+	QueueCapacity int
+	// CPU is the CPU utilisation, from 0 to 1.
+	CPU float64
+	// Concurrency is the number of requests being processed, out of
+	// MaxConcurrency.
+	Concurrency    int
+	MaxConcurrency int
+}
+
+// Load returns the highest utilisation ratio of `s`, capped to 1.
+func (s Signal) Load() float64 {
+	load := s.CPU
+	if s.QueueCapacity > 0 {
+		load = math.Max(load, float64(s.QueueDepth)/float64(s.QueueCapacity))
+	}
+	if s.MaxConcurrency > 0 {
+		load = math.Max(load, float64(s.Concurrency)/float64(s.MaxConcurrency))
+	}
+	if math.IsNaN(load) || load < 0 {
+		return 0
+	}
+	return math.Min(load, 1)
+}
+
+// A Curve maps a load, from 0 to 1, to a factor from 0 to 1 that scales a
+// retry delay between its minimum and its maximum.
+type Curve func(load float64) float64
+
+var (
+	// Linear grows delays proportionally to the load.
+	Linear Curve = func(load float64) float64 { return load }
+	// Quadratic keeps delays short under moderate load, and grows them
+	// quickly as the service saturates.
+	Quadratic Curve = func(load float64) float64 { return load * load }
+)
+
+// Exponential returns a curve that grows delays exponentially with the load.
+// The steepness `k` must be positive; the higher it is, the longer delays
+// stay short.
+func Exponential(k float64) Curve {
+	return func(load float64) float64 {
+		return math.Expm1(k*load) / math.Expm1(k)
+	}
+}
+
+// DefaultRetryDelayPolicy is the policy used by SuggestRetryDelay.
+var DefaultRetryDelayPolicy = RetryDelayPolicy{
+	Min:    100 * time.Millisecond,
+	Max:    30 * time.Second,
+	Curve:  Quadratic,
+	Jitter: 0.1,
+}
+
+// RetryDelayPolicy computes the retry delay a service advises to its callers
+// from its current load, so that callers back off more as the service gets
+// closer to saturation.
+type RetryDelayPolicy struct {
+	// Min is the delay advised when the service is idle.
+	Min time.Duration
+	// Max is the delay advised when the service is saturated.
+	Max time.Duration
+	// Curve shapes how the delay grows with the load. Linear is used when it
+	// is nil.
+	Curve Curve
+	// Jitter randomises each delay by a factor in [1-Jitter, 1+Jitter], so
+	// the callers rejected at the same time don't retry in lockstep. There is
+	// no jitter when it is zero.
+	Jitter float64
+	// Source provides the randomness used for jitter (see Backoff.Source).
+	Source JitterSource
+}
+
+// Suggest returns the retry delay matching the load `load`.
+func (p RetryDelayPolicy) Suggest(load Signal) time.Duration {
+	curve := p.Curve
+	if curve == nil {
+		curve = Linear
+	}
+	factor := math.Min(math.Max(curve(load.Load()), 0), 1)
+	if math.IsNaN(factor) {
+		factor = 1
+	}
+
+	delay := float64(p.Min) + float64(p.Max-p.Min)*factor
+	if p.Jitter > 0 {
+		b := Backoff{Source: p.Source}
+		delay *= 1 + p.Jitter*(2*b.source().Float64()-1)
+	}
+	if delay < 0 {
+		return 0
+	}
+	return time.Duration(delay)
+}
+
+// SuggestRetryDelay returns the retry delay matching the load `load`, as
+// computed by DefaultRetryDelayPolicy. It allows services to advise adaptive
+// rather than hard-coded delays:
+//
+//	if queue.Len() >= queue.Cap() {
+//		return faults.Unavailable(faults.SuggestRetryDelay(faults.Signal{
+//			QueueDepth:    queue.Len(),
+//			QueueCapacity: queue.Cap(),
+//		}))
+//	}
+func SuggestRetryDelay(load Signal) time.Duration {
+	return DefaultRetryDelayPolicy.Suggest(load)
+}
@@ -0,0 +1,85 @@
+package faults_test
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+)
+
+// TestSignalLoad ensures the load is the highest utilisation ratio.
+func TestSignalLoad(t *testing.T) {
+	table := []struct {
+		Signal faults.Signal
+		Load   float64
+	}{
+		{Signal: faults.Signal{}, Load: 0},
+		{Signal: faults.Signal{CPU: 0.3}, Load: 0.3},
+		{Signal: faults.Signal{CPU: 0.3, QueueDepth: 50, QueueCapacity: 100}, Load: 0.5},
+		{Signal: faults.Signal{CPU: 0.3, Concurrency: 90, MaxConcurrency: 100}, Load: 0.9},
+		{Signal: faults.Signal{QueueDepth: 300, QueueCapacity: 100}, Load: 1},
+		{Signal: faults.Signal{QueueDepth: 10}, Load: 0},
+		{Signal: faults.Signal{CPU: -1}, Load: 0},
+		{Signal: faults.Signal{CPU: math.NaN()}, Load: 0},
+	}
+
+	for i, test := range table {
+		if load := test.Signal.Load(); load != test.Load {
+			t.Errorf("%d - expect load %v, but got %v", i, test.Load, load)
+		}
+	}
+}
+
+// TestSuggest ensures delays follow the curve between their bounds.
+func TestSuggest(t *testing.T) {
+	table := []struct {
+		Curve faults.Curve
+		CPU   float64
+		Delay time.Duration
+	}{
+		{Curve: nil, CPU: 0, Delay: time.Second},
+		{Curve: nil, CPU: 0.5, Delay: 6 * time.Second},
+		{Curve: faults.Linear, CPU: 1, Delay: 11 * time.Second},
+		{Curve: faults.Quadratic, CPU: 0.5, Delay: 3500 * time.Millisecond},
+		{Curve: faults.Exponential(4), CPU: 0, Delay: time.Second},
+		{Curve: faults.Exponential(4), CPU: 1, Delay: 11 * time.Second},
+		{Curve: func(float64) float64 { return 3 }, CPU: 0.5, Delay: 11 * time.Second},
+	}
+
+	for i, test := range table {
+		p := faults.RetryDelayPolicy{Min: time.Second, Max: 11 * time.Second, Curve: test.Curve}
+		if delay := p.Suggest(faults.Signal{CPU: test.CPU}); delay != test.Delay {
+			t.Errorf("%d - expect delay %s, but got %s", i, test.Delay, delay)
+		}
+	}
+
+	exp := faults.RetryDelayPolicy{Min: 0, Max: time.Second, Curve: faults.Exponential(4)}
+	if low, high := exp.Suggest(faults.Signal{CPU: 0.5}), exp.Suggest(faults.Signal{CPU: 0.9}); low >= 200*time.Millisecond || high <= low {
+		t.Errorf("expect exponential delays to stay short under moderate load, but got %s and %s", low, high)
+	}
+}
+
+// TestSuggestJitter ensures jitter stays within its bounds, and is
+// reproducible with an injected source.
+func TestSuggestJitter(t *testing.T) {
+	p := faults.RetryDelayPolicy{Min: time.Second, Max: time.Second, Jitter: 0.5}
+	p.Source = rand.New(rand.NewPCG(1, 2))
+	q := p
+	q.Source = rand.New(rand.NewPCG(1, 2))
+
+	for i := 0; i < 10; i++ {
+		a, b := p.Suggest(faults.Signal{}), q.Suggest(faults.Signal{})
+		if a != b {
+			t.Errorf("%d - expect reproducible delays, but got %s and %s", i, a, b)
+		}
+		if a < 500*time.Millisecond || a > 1500*time.Millisecond {
+			t.Errorf("%d - expect delay within jitter bounds, but got %s", i, a)
+		}
+	}
+
+	if d := faults.SuggestRetryDelay(faults.Signal{CPU: 1}); d < 27*time.Second || d > 33*time.Second {
+		t.Errorf("expect default maximum delay, but got %s", d)
+	}
+}
@@ -0,0 +1,44 @@
+package faults
+
+import "strings"
+
+// ExpandTemplate returns `template` with its placeholders, e.g. "{max}",
+// replaced by the values of `params` of the same name, like the description
+// of a violation is rendered from its template (see FieldTemplate).
+// Translation layers render translated templates with the parameters of the
+// violation:
+//
+//	msg := faults.ExpandTemplate(catalog.Translate(lang, v.Template), v.Params)
+//
+// Placeholders without a parameter are left as is, and "{{" and "}}" are
+// written as "{" and "}".
+func ExpandTemplate(template string, params map[string]string) string {
+	if !strings.ContainsAny(template, "{}") {
+		return template
+	}
+	var b strings.Builder
+	for i := 0; i < len(template); i++ {
+		c := template[i]
+		switch {
+		case (c == '{' || c == '}') && i+1 < len(template) && template[i+1] == c:
+			b.WriteByte(c)
+			i++
+		case c == '{':
+			end := strings.IndexByte(template[i:], '}')
+			if end < 0 {
+				b.WriteString(template[i:])
+				return b.String()
+			}
+			name := template[i+1 : i+end]
+			if v, ok := params[name]; ok {
+				b.WriteString(v)
+			} else {
+				b.WriteString(template[i : i+end+1])
+			}
+			i += end
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,91 @@
+package faults_test
+
+import (
+	"testing"
+
+	"github.com/deixis/faults"
+)
+
+// TestExpandTemplate ensures placeholders are replaced by their parameters.
+func TestExpandTemplate(t *testing.T) {
+	table := []struct {
+		Template string
+		Params   map[string]string
+		Expect   string
+	}{
+		{Template: "", Expect: ""},
+		{Template: "Field required", Expect: "Field required"},
+		{
+			Template: "must be at most {max} characters",
+			Params:   map[string]string{"max": "50"},
+			Expect:   "must be at most 50 characters",
+		},
+		{
+			Template: "must be between {min} and {max}",
+			Params:   map[string]string{"min": "1", "max": "10"},
+			Expect:   "must be between 1 and 10",
+		},
+		{
+			Template: "must match {pattern}",
+			Expect:   "must match {pattern}",
+		},
+		{
+			Template: "must be a {{name}} of {count}",
+			Params:   map[string]string{"name": "x", "count": "2"},
+			Expect:   "must be a {name} of 2",
+		},
+		{
+			Template: "unbalanced {max",
+			Params:   map[string]string{"max": "50"},
+			Expect:   "unbalanced {max",
+		},
+	}
+
+	for i, test := range table {
+		if got := faults.ExpandTemplate(test.Template, test.Params); got != test.Expect {
+			t.Errorf("%d - expect %q, but got %q", i, test.Expect, got)
+		}
+	}
+}
+
+// TestTemplateEncodings ensures the templates of violations and their
+// parameters survive the encodings of faults.
+func TestTemplateEncodings(t *testing.T) {
+	fault := faults.Bad(
+		faults.FieldTemplate("name", "must be at most {max} characters", map[string]string{"max": "50"}, faults.ViolationTooLong),
+		faults.FieldTemplate("tags", "must have {min} to {max} {item}", map[string]string{"min": "1", "max": "5", "item": "tag names"}),
+		faults.Field("email", "Field required"),
+	)
+	expect, _ := faults.AsBad(fault)
+
+	table := []struct {
+		Name   string
+		Encode func(error) ([]byte, error)
+		Decode func([]byte) error
+	}{
+		{Name: "binary", Encode: faults.Encode, Decode: faults.Decode},
+		{Name: "CBOR", Encode: faults.EncodeCBOR, Decode: faults.DecodeCBOR},
+		{Name: "text", Encode: faults.EncodeText, Decode: faults.DecodeText},
+	}
+
+	for _, test := range table {
+		data, _ := test.Encode(fault)
+		e, ok := faults.AsBad(test.Decode(data))
+		if !ok {
+			t.Fatalf("%s - expect bad request", test.Name)
+		}
+		if len(e.Violations) != len(expect.Violations) {
+			t.Fatalf("%s - expect %d violations, but got %d", test.Name, len(expect.Violations), len(e.Violations))
+		}
+		for i, v := range e.Violations {
+			if w := expect.Violations[i]; v.Template != w.Template || len(v.Params) != len(w.Params) {
+				t.Errorf("%s - expect violation %d to be %#v, but got %#v", test.Name, i, w, v)
+			}
+			for k, p := range expect.Violations[i].Params {
+				if v.Params[k] != p {
+					t.Errorf("%s - expect parameter %s of violation %d to be %q, but got %q", test.Name, k, i, p, v.Params[k])
+				}
+			}
+		}
+	}
+}
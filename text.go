@@ -0,0 +1,497 @@
+package faults
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EncodeText returns the canonical text representation of `err`, a single
+// line made of the name of its category, followed by the details of the
+// fault as key-value pairs:
+//
+//	NOT_FOUND
+//	UNAVAILABLE: retry=2s
+//	BAD_REQUEST: violations[0].field=email violations[0].code=REQUIRED violations[0].description="is required"
+//	NOT_FOUND: cause="no such user" reason=USER_NOT_FOUND metadata.id=user:123
+//
+// It is meant for structured log fields, and for fixtures written by hand,
+// e.g. in environment variables. DecodeText converts it back to a fault.
+//
+// The keys are, in order: message, cause, violations[i].field,
+// violations[i].code, violations[i].type, violations[i].subject,
+// violations[i].resource, violations[i].description, violations[i].template,
+// violations[i].params.<key>, retry, reason, domain, metadata.<key>, permanent,
+// challenges[i] and truncated. Their meaning is described by EncodeJSON.
+// Values, and keys of maps, are quoted like Go strings when they contain
+// spaces, quotes or equal signs. The message is only written when it differs
+// from the message of the fault rebuilt from its details, which keeps the
+// representation of most faults short.
+//
+// It returns nil when `err` is nil.
+func EncodeText(err error) ([]byte, error) {
+	if err == nil {
+		return nil, nil
+	}
+	return marshalText(err), nil
+}
+
+// DecodeText converts the text representation produced by EncodeText back to
+// a fault. Categories that are not registered in this process are decoded as
+// plain errors.
+//
+// It returns nil when `text` is empty, and an Unknown error when `text` is
+// malformed.
+func DecodeText(text []byte) error {
+	if len(strings.TrimSpace(string(text))) == 0 {
+		return nil
+	}
+	e, err := parseText(string(text))
+	if err != nil {
+		return err
+	}
+	return e.fault()
+}
+
+func marshalText(err error) []byte {
+	return limited(err, textEnvelope)
+}
+
+// textEnvelope returns the text representation of `e`.
+func textEnvelope(e *envelope) []byte {
+	var pairs [][2]string
+	rebuilt := *e
+	rebuilt.Message = ""
+	if rebuilt.fault().Error() != e.Message {
+		pairs = append(pairs, [2]string{"message", e.Message})
+	}
+	if e.Cause != "" {
+		pairs = append(pairs, [2]string{"cause", e.Cause})
+	}
+	for i, v := range e.Violations {
+		prefix := "violations[" + strconv.Itoa(i) + "]."
+		n := len(pairs)
+		for _, f := range [...][2]string{
+			{"field", v.Field},
+			{"code", v.Code},
+			{"type", v.Type},
+			{"subject", v.Subject},
+			{"resource", v.Resource},
+			{"description", v.Description},
+		} {
+			if f[1] != "" {
+				pairs = append(pairs, [2]string{prefix + f[0], f[1]})
+			}
+		}
+		if v.Template != "" {
+			pairs = append(pairs, [2]string{prefix + "template", v.Template})
+		}
+		for _, k := range v.paramKeys() {
+			key := k
+			if needsQuote(k) {
+				key = strconv.Quote(k)
+			}
+			pairs = append(pairs, [2]string{prefix + "params." + key, v.Params[k]})
+		}
+		if len(pairs) == n {
+			// Keep empty violations, so the following ones keep their index
+			pairs = append(pairs, [2]string{prefix + "description", ""})
+		}
+	}
+	if e.RetryDelay > 0 {
+		pairs = append(pairs, [2]string{"retry", (time.Duration(e.RetryDelay) * time.Millisecond).String()})
+	}
+	if e.Reason != "" {
+		pairs = append(pairs, [2]string{"reason", e.Reason})
+	}
+	if e.Domain != "" {
+		pairs = append(pairs, [2]string{"domain", e.Domain})
+	}
+	for _, k := range e.metadataKeys() {
+		key := k
+		if needsQuote(k) {
+			key = strconv.Quote(k)
+		}
+		pairs = append(pairs, [2]string{"metadata." + key, e.Metadata[k]})
+	}
+	if e.Permanent {
+		pairs = append(pairs, [2]string{"permanent", "true"})
+	}
+	for i, c := range e.Challenges {
+		pairs = append(pairs, [2]string{"challenges[" + strconv.Itoa(i) + "]", c})
+	}
+	if e.Truncated {
+		pairs = append(pairs, [2]string{"truncated", "true"})
+	}
+
+	b := []byte(e.Code)
+	for i, p := range pairs {
+		if i == 0 {
+			b = append(b, ':')
+		}
+		b = append(b, ' ')
+		b = append(b, p[0]...)
+		b = append(b, '=')
+		if needsQuote(p[1]) {
+			b = strconv.AppendQuote(b, p[1])
+		} else {
+			b = append(b, p[1]...)
+		}
+	}
+	return b
+}
+
+// needsQuote reports whether `s` must be quoted to be read back as a single
+// value.
+func needsQuote(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		if r <= ' ' || r == '"' || r == '=' || r == '\\' || r >= 0x7f {
+			return true
+		}
+	}
+	return false
+}
+
+// parseText parses the text representation of a fault.
+func parseText(text string) (*envelope, error) {
+	code, rest, _ := strings.Cut(strings.TrimSpace(text), ":")
+	if code == "" || strings.ContainsAny(code, " \t") {
+		return nil, malformedText("missing code")
+	}
+	e := &envelope{Code: code}
+	for {
+		rest = strings.TrimLeft(rest, " \t")
+		if rest == "" {
+			return e, nil
+		}
+
+		var key string
+		if i := strings.IndexAny(rest, "\"= \t"); i > 0 && rest[i] == '"' && rest[i-1] == '.' {
+			// Quoted keys of maps, e.g. metadata."user id"
+			quoted, err := strconv.QuotedPrefix(rest[i:])
+			if err != nil {
+				return nil, malformedText("invalid key of " + rest[:i-1])
+			}
+			unquoted, _ := strconv.Unquote(quoted)
+			key = rest[:i] + unquoted
+			rest = rest[i+len(quoted):]
+			if !strings.HasPrefix(rest, "=") {
+				return nil, malformedText("missing value of " + key)
+			}
+		} else {
+			i := strings.IndexAny(rest, "= \t")
+			if i < 0 || rest[i] != '=' {
+				return nil, malformedText("missing value of " + strings.Fields(rest)[0])
+			}
+			key, rest = rest[:i], rest[i:]
+		}
+		rest = rest[1:]
+
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			quoted, err := strconv.QuotedPrefix(rest)
+			if err != nil {
+				return nil, malformedText("invalid value of " + key)
+			}
+			value, _ = strconv.Unquote(quoted)
+			rest = rest[len(quoted):]
+		} else {
+			i := strings.IndexAny(rest, " \t")
+			if i < 0 {
+				i = len(rest)
+			}
+			value, rest = rest[:i], rest[i:]
+		}
+		if err := e.setText(key, value); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// setText sets the detail `key` of `e` to `value`.
+func (e *envelope) setText(key, value string) error {
+	switch key {
+	case "message":
+		e.Message = value
+	case "cause":
+		e.Cause = value
+	case "retry":
+		d, err := time.ParseDuration(value)
+		if err != nil || d < 0 {
+			return malformedText("invalid retry delay " + strconv.Quote(value))
+		}
+		e.RetryDelay = d.Milliseconds()
+	case "reason":
+		e.Reason = value
+	case "domain":
+		e.Domain = value
+	case "permanent":
+		permanent, err := strconv.ParseBool(value)
+		if err != nil {
+			return malformedText("invalid permanent marker " + strconv.Quote(value))
+		}
+		e.Permanent = permanent
+	case "truncated":
+		truncated, err := strconv.ParseBool(value)
+		if err != nil {
+			return malformedText("invalid truncation marker " + strconv.Quote(value))
+		}
+		e.Truncated = truncated
+	default:
+		switch {
+		case strings.HasPrefix(key, "metadata."):
+			if e.Metadata == nil {
+				e.Metadata = map[string]string{}
+			}
+			e.Metadata[key[len("metadata."):]] = value
+		case strings.HasPrefix(key, "violations["):
+			i, field, ok := textIndex(key[len("violations["):], len(e.Violations))
+			if !ok || !strings.HasPrefix(field, ".") {
+				return malformedText("invalid key " + key)
+			}
+			if i == len(e.Violations) {
+				e.Violations = append(e.Violations, violation{})
+			}
+			v := &e.Violations[i]
+			switch field[1:] {
+			case "field":
+				v.Field = value
+			case "code":
+				v.Code = value
+			case "type":
+				v.Type = value
+			case "subject":
+				v.Subject = value
+			case "resource":
+				v.Resource = value
+			case "description":
+				v.Description = value
+			case "template":
+				v.Template = value
+			default:
+				name, ok := strings.CutPrefix(field[1:], "params.")
+				if !ok {
+					return malformedText("unknown key " + key)
+				}
+				if v.Params == nil {
+					v.Params = map[string]string{}
+				}
+				v.Params[name] = value
+			}
+		case strings.HasPrefix(key, "challenges["):
+			i, rest, ok := textIndex(key[len("challenges["):], len(e.Challenges))
+			if !ok || rest != "" {
+				return malformedText("invalid key " + key)
+			}
+			if i == len(e.Challenges) {
+				e.Challenges = append(e.Challenges, value)
+			} else {
+				e.Challenges[i] = value
+			}
+		default:
+			return malformedText("unknown key " + key)
+		}
+	}
+	return nil
+}
+
+// textIndex parses the index of a key, e.g. "0]" in "violations[0]", which
+// refers to an existing element, or to the next one. It returns the remainder
+// of the key after the closing bracket.
+func textIndex(s string, n int) (i int, rest string, ok bool) {
+	end := strings.IndexByte(s, ']')
+	if end < 0 {
+		return 0, "", false
+	}
+	i, err := strconv.Atoi(s[:end])
+	if err != nil || i < 0 || i > n {
+		return 0, "", false
+	}
+	return i, s[end+1:], true
+}
+
+func malformedText(reason string) error {
+	return fmt.Errorf("faults: malformed text encoding: %s", reason)
+}
+
+// MarshalText implements encoding.TextMarshaler (see EncodeText).
+func (e *AvailabilityFailure) MarshalText() ([]byte, error) {
+	return marshalText(e), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler (see EncodeText).
+func (e *AvailabilityFailure) UnmarshalText(text []byte) error {
+	env, err := unmarshalText(text, CodeUnavailable, e)
+	if err != nil {
+		return err
+	}
+	*e = AvailabilityFailure{error: env.parent(), RetryInfo: env.retryInfo()}
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler (see EncodeText).
+func (e *QuotaFailure) MarshalText() ([]byte, error) {
+	return marshalText(e), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler (see EncodeText).
+func (e *QuotaFailure) UnmarshalText(text []byte) error {
+	env, err := unmarshalText(text, CodeResourceExhausted, e)
+	if err != nil {
+		return err
+	}
+	*e = QuotaFailure{error: env.parent(), Violations: env.quotaViolations(), RetryInfo: env.retryInfo()}
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler (see EncodeText).
+func (e *PreconditionFailure) MarshalText() ([]byte, error) {
+	return marshalText(e), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler (see EncodeText).
+func (e *PreconditionFailure) UnmarshalText(text []byte) error {
+	env, err := unmarshalText(text, CodeFailedPrecondition, e)
+	if err != nil {
+		return err
+	}
+	*e = PreconditionFailure{error: env.parent(), Violations: env.preconditionViolations()}
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler (see EncodeText).
+func (e *BadRequest) MarshalText() ([]byte, error) {
+	return marshalText(e), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler (see EncodeText).
+func (e *BadRequest) UnmarshalText(text []byte) error {
+	env, err := unmarshalText(text, CodeBad, e)
+	if err != nil {
+		return err
+	}
+	*e = BadRequest{error: env.parent(), Violations: env.fieldViolations()}
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler (see EncodeText).
+func (e *ConflictFailure) MarshalText() ([]byte, error) {
+	return marshalText(e), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler (see EncodeText).
+func (e *ConflictFailure) UnmarshalText(text []byte) error {
+	env, err := unmarshalText(text, CodeAborted, e)
+	if err != nil {
+		return err
+	}
+	*e = ConflictFailure{error: env.parent(), Violations: env.conflictViolations()}
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler (see EncodeText).
+func (e *MissingFailure) MarshalText() ([]byte, error) {
+	return marshalText(e), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler (see EncodeText).
+func (e *MissingFailure) UnmarshalText(text []byte) error {
+	env, err := unmarshalText(text, CodeNotFound, e)
+	if err != nil {
+		return err
+	}
+	*e = MissingFailure{error: env.parent()}
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler (see EncodeText).
+func (e *PermissionFailure) MarshalText() ([]byte, error) {
+	return marshalText(e), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler (see EncodeText).
+func (e *PermissionFailure) UnmarshalText(text []byte) error {
+	env, err := unmarshalText(text, CodePermissionDenied, e)
+	if err != nil {
+		return err
+	}
+	*e = PermissionFailure{error: env.parent()}
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler (see EncodeText).
+func (e *AuthenticationFailure) MarshalText() ([]byte, error) {
+	return marshalText(e), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler (see EncodeText).
+func (e *AuthenticationFailure) UnmarshalText(text []byte) error {
+	env, err := unmarshalText(text, CodeUnauthenticated, e)
+	if err != nil {
+		return err
+	}
+	*e = AuthenticationFailure{error: env.parent(), Challenges: env.challenges()}
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler (see EncodeText).
+func (e *UnimplementedFailure) MarshalText() ([]byte, error) {
+	return marshalText(e), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler (see EncodeText).
+func (e *UnimplementedFailure) UnmarshalText(text []byte) error {
+	env, err := unmarshalText(text, CodeUnimplemented, e)
+	if err != nil {
+		return err
+	}
+	*e = UnimplementedFailure{error: env.parent()}
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler (see EncodeText).
+func (e *InternalFailure) MarshalText() ([]byte, error) {
+	return marshalText(e), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler (see EncodeText).
+func (e *InternalFailure) UnmarshalText(text []byte) error {
+	env, err := unmarshalText(text, CodeInternal, e)
+	if err != nil {
+		return err
+	}
+	*e = InternalFailure{error: env.parent()}
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler (see EncodeText).
+func (e *DeadlineFailure) MarshalText() ([]byte, error) {
+	return marshalText(e), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler (see EncodeText).
+func (e *DeadlineFailure) UnmarshalText(text []byte) error {
+	env, err := unmarshalText(text, CodeDeadlineExceeded, e)
+	if err != nil {
+		return err
+	}
+	*e = DeadlineFailure{error: env.parent()}
+	return nil
+}
+
+// unmarshalText parses the text representation of a fault of the category
+// `code`, which is about to be decoded into `target`.
+func unmarshalText(text []byte, code Code, target error) (*envelope, error) {
+	e, err := parseText(string(text))
+	if err != nil {
+		return nil, err
+	}
+	if err := e.check(code, target); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
@@ -0,0 +1,147 @@
+package faults_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+)
+
+// TestEncodeText ensures faults are written in their canonical text form.
+func TestEncodeText(t *testing.T) {
+	table := []struct {
+		Error error
+		Text  string
+	}{
+		{Error: faults.NotFound, Text: "NOT_FOUND"},
+		{Error: faults.Unavailable(2 * time.Second), Text: "UNAVAILABLE: retry=2s"},
+		{
+			Error: faults.Bad(&faults.FieldViolation{Field: "email", Code: faults.ViolationRequired, Description: "is required"}),
+			Text:  `BAD_REQUEST: violations[0].field=email violations[0].code=REQUIRED violations[0].description="is required"`,
+		},
+		{
+			Error: faults.Bad(faults.FieldTemplate("name", "at most {max}", map[string]string{"max": "50", "a b": "c"})),
+			Text: `BAD_REQUEST: violations[0].field=name violations[0].description="at most 50" ` +
+				`violations[0].template="at most {max}" violations[0].params."a b"=c violations[0].params.max=50`,
+		},
+		{
+			Error: faults.WithErrorInfo(faults.WithNotFound(errors.New("no such user")), "USER_NOT_FOUND", map[string]string{"id": "user:123"}),
+			Text:  `NOT_FOUND: cause="no such user" reason=USER_NOT_FOUND metadata.id=user:123`,
+		},
+		{
+			Error: fmt.Errorf("get user: %w", faults.NotFound),
+			Text:  `NOT_FOUND: message="get user: resource not found"`,
+		},
+		{
+			Error: faults.Permanent(faults.WithErrorInfo(faults.Unavailable(0), "DECOMMISSIONED", map[string]string{"a b": "c=d"})),
+			Text:  `UNAVAILABLE: reason=DECOMMISSIONED metadata."a b"="c=d" permanent=true`,
+		},
+		{
+			Error: faults.WithUnauthenticated(nil, faults.Challenge{Scheme: "Bearer", Realm: "api"}),
+			Text:  `UNAUTHENTICATED: challenges[0]="Bearer realm=\"api\""`,
+		},
+		{Error: errors.New("boom"), Text: "UNKNOWN: message=boom"},
+	}
+
+	for i, test := range table {
+		text, err := faults.EncodeText(test.Error)
+		if err != nil {
+			t.Fatalf("%d - expect fault to be encoded, but got %s", i, err)
+		}
+		if string(text) != test.Text {
+			t.Errorf("%d - expect %s, but got %s", i, test.Text, text)
+		}
+
+		err = faults.DecodeText(text)
+		if err.Error() != test.Error.Error() {
+			t.Errorf("%d - expect message %q, but got %q", i, test.Error, err)
+		}
+		if code := faults.CodeOf(err); code != faults.CodeOf(test.Error) {
+			t.Errorf("%d - expect code %s, but got %s", i, faults.CodeOf(test.Error), code)
+		}
+		if expect, delay := faults.RetryAdvice(test.Error).Delay, faults.RetryAdvice(err).Delay; delay != expect {
+			t.Errorf("%d - expect retry delay %s, but got %s", i, expect, delay)
+		}
+		if expect, permanent := faults.IsPermanent(test.Error), faults.IsPermanent(err); permanent != expect {
+			t.Errorf("%d - expect permanent %t, but got %t", i, expect, permanent)
+		}
+		if expect, challenges := faults.ChallengesOf(test.Error), faults.ChallengesOf(err); !reflect.DeepEqual(expect, challenges) {
+			t.Errorf("%d - expect challenges %v, but got %v", i, expect, challenges)
+		}
+	}
+
+	if text, _ := faults.EncodeText(nil); text != nil {
+		t.Errorf("expect no text, but got %s", text)
+	}
+}
+
+// TestDecodeText ensures fixtures written by hand are parsed.
+func TestDecodeText(t *testing.T) {
+	err := faults.DecodeText([]byte(`  BAD_REQUEST:  violations[0].field=email   violations[1].field=name violations[1].description="is too long" `))
+	bad, ok := faults.AsBad(err)
+	if !ok {
+		t.Fatalf("expect bad request, but got %v", err)
+	}
+	expect := []*faults.FieldViolation{{Field: "email"}, {Field: "name", Description: "is too long"}}
+	if !reflect.DeepEqual(bad.Violations, expect) {
+		t.Errorf("expect violations %v, but got %v", expect, bad.Violations)
+	}
+
+	err = faults.DecodeText([]byte("resource_exhausted: retry=1m violations[0].subject=project:42"))
+	if !faults.IsResourceExhausted(err) || faults.RetryAdvice(err).Delay != time.Minute {
+		t.Errorf("expect resource exhausted with a retry delay, but got %v", err)
+	}
+
+	if err := faults.DecodeText([]byte(" ")); err != nil {
+		t.Errorf("expect nil, but got %v", err)
+	}
+}
+
+// TestDecodeTextMalformed ensures malformed texts are rejected.
+func TestDecodeTextMalformed(t *testing.T) {
+	table := []string{
+		": message=hi",
+		"NOT FOUND",
+		"NOT_FOUND: message",
+		"NOT_FOUND: message=hi oops",
+		`NOT_FOUND: message="hi`,
+		"NOT_FOUND: colour=blue",
+		"NOT_FOUND: retry=soon",
+		"NOT_FOUND: permanent=maybe",
+		"BAD_REQUEST: violations[1].field=email",
+		"BAD_REQUEST: violations[0].colour=blue",
+		"BAD_REQUEST: violations[x].field=email",
+		`NOT_FOUND: metadata."a=b`,
+		"UNAUTHENTICATED: challenges[0].scheme=Bearer",
+	}
+
+	for i, text := range table {
+		err := faults.DecodeText([]byte(text))
+		if err == nil || faults.CodeOf(err) != faults.CodeUnknown {
+			t.Errorf("%d - expect %q to be rejected, but got %v", i, text, err)
+		}
+	}
+}
+
+// TestMarshalText ensures fault types can be marshalled and unmarshalled on
+// their own, e.g. by configuration loaders.
+func TestMarshalText(t *testing.T) {
+	var e faults.AvailabilityFailure
+	if err := e.UnmarshalText([]byte("UNAVAILABLE: retry=3s")); err != nil {
+		t.Fatal(err)
+	}
+	if e.RetryInfo.RetryDelay != 3*time.Second {
+		t.Errorf("expect retry delay %s, but got %s", 3*time.Second, e.RetryInfo.RetryDelay)
+	}
+	if text, _ := e.MarshalText(); string(text) != "UNAVAILABLE: retry=3s" {
+		t.Errorf("expect text to round trip, but got %s", text)
+	}
+
+	var missing faults.MissingFailure
+	if err := missing.UnmarshalText([]byte("UNAVAILABLE")); err == nil {
+		t.Error("expect text of another category to be rejected")
+	}
+}
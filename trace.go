@@ -0,0 +1,253 @@
+package faults
+
+import (
+	"context"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// An EventKind identifies a step of the lifecycle of a fault.
+type EventKind int
+
+const (
+	// EventCreate is emitted when a fault is created, e.g. with Bad.
+	EventCreate EventKind = iota + 1
+	// EventWrap is emitted when an error is wrapped with a fault, e.g. with
+	// WithNotFound.
+	EventWrap
+	// EventClassify is emitted when the code of an error is resolved by
+	// CodeOf.
+	EventClassify
+	// EventRender is emitted when a fault is rendered by a transport.
+	EventRender
+	// EventRetry is emitted when a retry decision is taken for a fault.
+	EventRetry
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventCreate:
+		return "create"
+	case EventWrap:
+		return "wrap"
+	case EventClassify:
+		return "classify"
+	case EventRender:
+		return "render"
+	case EventRetry:
+		return "retry"
+	}
+	return "unknown"
+}
+
+// An Event describes a step of the lifecycle of a fault.
+type Event struct {
+	// Kind is the lifecycle step.
+	Kind EventKind
+	// Time is when the event occurred.
+	Time time.Time
+	// Err is the error at this step.
+	Err error
+	// Code is the code of Err at this step.
+	Code Code
+	// Caller is the location ("file:line") of the code that triggered the
+	// event, when known.
+	Caller string
+	// Detail describes the step, e.g. the transport that rendered the fault or
+	// the retry decision that was taken.
+	Detail string
+}
+
+// A TraceSink receives the lifecycle events of faults.
+//
+// Sinks are called synchronously, from the goroutine that triggered the
+// event, so they must be safe for concurrent use and return quickly.
+type TraceSink interface {
+	Trace(ev Event)
+}
+
+// The TraceFunc type is an adapter to allow the use of ordinary functions as
+// trace sinks.
+type TraceFunc func(ev Event)
+
+// Trace calls f(ev).
+func (f TraceFunc) Trace(ev Event) {
+	f(ev)
+}
+
+type sinkHolder struct {
+	sink TraceSink
+}
+
+var globalSink atomic.Pointer[sinkHolder]
+
+// SetTraceSink enables tracing process-wide. Every fault created or wrapped,
+// classified, rendered or retried is reported to `sink`, which produces a
+// timeline of how errors are transformed as they bubble up.
+//
+// Tracing is meant for debugging sessions, e.g. when a mapping misbehaves. It
+// is disabled by default, and can be disabled again with a nil sink. See
+// ContextWithTrace to trace a single request instead.
+func SetTraceSink(sink TraceSink) {
+	if sink == nil {
+		globalSink.Store(nil)
+		return
+	}
+	globalSink.Store(&sinkHolder{sink: sink})
+}
+
+// MultiSink returns a sink that reports events to each of `sinks`, in order,
+// e.g. to set more than one process-wide sink with SetTraceSink. Nil sinks
+// are ignored.
+func MultiSink(sinks ...TraceSink) TraceSink {
+	var m multiSink
+	for _, sink := range sinks {
+		m = m.add(sink)
+	}
+	if len(m) == 0 {
+		return nil
+	}
+	return m
+}
+
+// multiSink is a chain of trace sinks.
+type multiSink []TraceSink
+
+func (m multiSink) Trace(ev Event) {
+	for _, sink := range m {
+		sink.Trace(ev)
+	}
+}
+
+// add returns a copy of `m` that ends with `sink`, or with the sinks of
+// `sink` when it is a chain.
+func (m multiSink) add(sink TraceSink) multiSink {
+	switch sink := sink.(type) {
+	case nil:
+		return m
+	case multiSink:
+		for _, s := range sink {
+			m = m.add(s)
+		}
+		return m
+	}
+	if m.has(sink) {
+		return m
+	}
+	return append(m[:len(m):len(m)], sink)
+}
+
+// has reports whether `sink` is part of `m`.
+func (m multiSink) has(sink TraceSink) bool {
+	for _, s := range m {
+		if sameSink(s, sink) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameSink reports whether `a` and `b` are the same sink. Sinks that can't be
+// compared, such as TraceFunc, are never the same.
+func sameSink(a, b TraceSink) (same bool) {
+	defer func() {
+		if recover() != nil {
+			same = false
+		}
+	}()
+	return a == b
+}
+
+type traceKey struct{}
+
+// ContextWithTrace returns a copy of `ctx` that reports the lifecycle events
+// of faults handled on behalf of a single request to `sink`, in addition to
+// the sinks `ctx` already reports to, so middlewares attaching sinks of their
+// own can be stacked.
+//
+// Only components that have access to the context of the request, such as
+// transport middlewares and retry loops, report events to it (see Trace).
+func ContextWithTrace(ctx context.Context, sink TraceSink) context.Context {
+	chain, _ := ctx.Value(traceKey{}).(multiSink)
+	return context.WithValue(ctx, traceKey{}, chain.add(sink))
+}
+
+// Trace reports an event to the sinks attached to `ctx` with
+// ContextWithTrace, and to the process-wide sink set with SetTraceSink. A sink
+// that is both receives the event once.
+//
+// It is meant to be called by components that transform faults, such as
+// transport adapters reporting EventRender.
+func Trace(ctx context.Context, kind EventKind, err error, detail string) {
+	chain, _ := ctx.Value(traceKey{}).(multiSink)
+	global := globalSink.Load()
+	if chain == nil && global == nil {
+		return
+	}
+
+	var code Code
+	if err != nil {
+		code, _ = DefaultClassifier().Classify(err)
+	}
+	ev := newEvent(kind, err, code, detail, 2)
+	chain.Trace(ev)
+	if global == nil {
+		return
+	}
+	// The process-wide sinks attached to the context too are only called once
+	for _, sink := range multiSink(nil).add(global.sink) {
+		if !chain.has(sink) {
+			sink.Trace(ev)
+		}
+	}
+}
+
+// traced reports an event that occurred in the caller of the caller of
+// traced to the process-wide sink, if any, and returns `err`, with its
+// DebugInfo in debug mode (see SetDebug).
+func traced(kind EventKind, err error) error {
+	return tracedAt(kind, err, 1)
+}
+
+// tracedAt is like traced, for an event that occurred `skip` frames above
+// the caller of the caller of tracedAt.
+func tracedAt(kind EventKind, err error, skip int) error {
+	if kind == EventCreate || kind == EventWrap {
+		err = debugged(err, skip+2)
+	}
+	if global := globalSink.Load(); global != nil {
+		code, _ := classifyFaults(err)
+		global.sink.Trace(newEvent(kind, err, code, "", skip+3))
+	}
+	return err
+}
+
+// tracedf is like traced, but with an explicit code and detail.
+func tracedf(kind EventKind, err error, code Code, detail string) {
+	if global := globalSink.Load(); global != nil {
+		global.sink.Trace(newEvent(kind, err, code, detail, 3))
+	}
+}
+
+// tracing reports whether tracing is enabled process-wide.
+func tracing() bool {
+	return globalSink.Load() != nil
+}
+
+// newEvent creates an event that occurred at the location of the caller
+// `skip` frames above newEvent.
+func newEvent(kind EventKind, err error, code Code, detail string, skip int) Event {
+	ev := Event{
+		Kind:   kind,
+		Time:   time.Now(),
+		Err:    err,
+		Code:   code,
+		Detail: detail,
+	}
+	if _, file, line, ok := runtime.Caller(skip); ok {
+		ev.Caller = file + ":" + strconv.Itoa(line)
+	}
+	return ev
+}
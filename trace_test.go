@@ -0,0 +1,147 @@
+package faults_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/deixis/faults"
+)
+
+// recorder is a trace sink that records events.
+type recorder struct {
+	mu     sync.Mutex
+	events []faults.Event
+}
+
+func (r *recorder) Trace(ev faults.Event) {
+	r.mu.Lock()
+	r.events = append(r.events, ev)
+	r.mu.Unlock()
+}
+
+// TestTraceSink ensures the lifecycle of faults is reported to the
+// process-wide sink.
+func TestTraceSink(t *testing.T) {
+	rec := &recorder{}
+	faults.SetTraceSink(rec)
+	defer faults.SetTraceSink(nil)
+
+	err := faults.WithUnavailable(faults.Bad(), 0)
+	faults.CodeOf(err)
+	faults.Backoff{Base: time.Second}.Next(err, 0)
+	faults.SetTraceSink(nil)
+	faults.Bad() // Not traced
+
+	expect := []struct {
+		Kind   faults.EventKind
+		Code   faults.Code
+		Detail string
+	}{
+		{Kind: faults.EventCreate, Code: faults.CodeBad},
+		{Kind: faults.EventWrap, Code: faults.CodeUnavailable},
+		{Kind: faults.EventClassify, Code: faults.CodeUnavailable},
+		{Kind: faults.EventRetry, Code: faults.CodeUnavailable, Detail: "retry in 1s"},
+	}
+
+	var events []faults.Event
+	for _, ev := range rec.events {
+		// Classification events emitted while retrying are not relevant here
+		if ev.Kind != faults.EventClassify || len(events) == 2 {
+			events = append(events, ev)
+		}
+	}
+	if len(events) != len(expect) {
+		t.Fatalf("expect %d events, but got %d: %v", len(expect), len(events), events)
+	}
+	for i, ev := range events {
+		if ev.Kind != expect[i].Kind || ev.Code != expect[i].Code || ev.Detail != expect[i].Detail {
+			t.Errorf("%d - expect event %v, but got %s %s %q", i, expect[i], ev.Kind, ev.Code, ev.Detail)
+		}
+		if ev.Time.IsZero() {
+			t.Errorf("%d - expect event time to be set", i)
+		}
+	}
+	for i, ev := range events[:3] {
+		if !strings.Contains(ev.Caller, "trace_test.go") {
+			t.Errorf("%d - expect caller to be in trace_test.go, but got %s", i, ev.Caller)
+		}
+	}
+}
+
+// TestContextWithTrace ensures events can be traced for a single request.
+func TestContextWithTrace(t *testing.T) {
+	rec := &recorder{}
+	ctx := faults.ContextWithTrace(context.Background(), rec)
+
+	faults.Trace(ctx, faults.EventRender, faults.NotFound, "http")
+	faults.Trace(context.Background(), faults.EventRender, errors.New("boom"), "http")
+
+	if len(rec.events) != 1 {
+		t.Fatalf("expect 1 event, but got %d", len(rec.events))
+	}
+	ev := rec.events[0]
+	if ev.Kind != faults.EventRender || ev.Code != faults.CodeNotFound || ev.Detail != "http" {
+		t.Errorf("expect render event, but got %s %s %q", ev.Kind, ev.Code, ev.Detail)
+	}
+	if !strings.Contains(ev.Caller, "trace_test.go") {
+		t.Errorf("expect caller to be in trace_test.go, but got %s", ev.Caller)
+	}
+}
+
+// TestContextWithTraceStacked ensures the sinks attached to a context by
+// stacked middlewares all receive its events.
+func TestContextWithTraceStacked(t *testing.T) {
+	outer, inner := &recorder{}, &recorder{}
+	ctx := faults.ContextWithTrace(context.Background(), outer)
+	ctx = faults.ContextWithTrace(ctx, inner)
+	faults.ContextWithTrace(ctx, &recorder{}) // doesn't leak into ctx
+
+	faults.Trace(ctx, faults.EventRender, faults.NotFound, "http")
+
+	for i, rec := range []*recorder{outer, inner} {
+		if len(rec.events) != 1 {
+			t.Errorf("%d - expect 1 event, but got %d", i, len(rec.events))
+		}
+	}
+}
+
+// TestMultiSink ensures events are reported to every sink of a chain.
+func TestMultiSink(t *testing.T) {
+	a, b := &recorder{}, &recorder{}
+	faults.SetTraceSink(faults.MultiSink(a, nil, faults.MultiSink(b)))
+	defer faults.SetTraceSink(nil)
+
+	faults.Bad()
+
+	for i, rec := range []*recorder{a, b} {
+		if len(rec.events) != 1 {
+			t.Errorf("%d - expect 1 event, but got %d", i, len(rec.events))
+		}
+	}
+	if sink := faults.MultiSink(nil); sink != nil {
+		t.Errorf("expect no sink, but got %v", sink)
+	}
+}
+
+// TestTraceOnce ensures a sink attached to a context that is also the
+// process-wide sink receives each event once.
+func TestTraceOnce(t *testing.T) {
+	rec, other := &recorder{}, &recorder{}
+	faults.SetTraceSink(faults.MultiSink(rec, other))
+	defer faults.SetTraceSink(nil)
+
+	ctx := faults.ContextWithTrace(context.Background(), rec)
+	ctx = faults.ContextWithTrace(ctx, rec)
+	ctx = faults.ContextWithTrace(ctx, faults.TraceFunc(func(faults.Event) {}))
+	faults.Trace(ctx, faults.EventRender, faults.NotFound, "http")
+
+	for i, rec := range []*recorder{rec, other} {
+		if len(rec.events) != 1 {
+			t.Errorf("%d - expect 1 event, but got %d", i, len(rec.events))
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package faults
+
+// Field returns the violation of the field `field` of a request, with an
+// optional code telling why the field is bad (see FieldViolation.Code).
+//
+// Example:
+//
+//	return faults.Bad(
+//		faults.Field("email", "Must be a valid address", faults.ViolationFormat),
+//		faults.Field("name", "Field required", faults.ViolationRequired),
+//	)
+func Field(field, description string, code ...string) *FieldViolation {
+	return newField(field, description, code, 1)
+}
+
+// newField returns the violation of the field `field`, produced by the caller
+// `skip` frames above the caller of newField.
+func newField(field, description string, code []string, skip int) *FieldViolation {
+	v := &FieldViolation{Field: field, Description: description, Source: sourceOf(skip + 1)}
+	if len(code) > 0 {
+		v.Code = code[0]
+	}
+	return v
+}
+
+// FieldTemplate returns the violation of the field `field` of a request,
+// whose description is rendered from `template` and `params` (see
+// ExpandTemplate). The violation carries the template and its parameters
+// too, so clients can render the description again, e.g. in their language.
+//
+// Example:
+//
+//	return faults.Bad(faults.FieldTemplate("name", "must be at most {max} characters",
+//		map[string]string{"max": "50"}, faults.ViolationTooLong))
+func FieldTemplate(field, template string, params map[string]string, code ...string) *FieldViolation {
+	v := newField(field, ExpandTemplate(template, params), code, 1)
+	v.Template = template
+	v.Params = params
+	return v
+}
+
+// Precondition returns the violation of the precondition of type `typ`, e.g.
+// "TOS", for the subject `subject`.
+//
+// Example:
+//
+//	return faults.FailedPrecondition(faults.Precondition("TOS", "example.com", "Terms of service not accepted"))
+func Precondition(typ, subject, description string) *PreconditionViolation {
+	return newPrecondition(typ, subject, description, 1)
+}
+
+// newPrecondition returns the violation of the precondition of type `typ`,
+// produced by the caller `skip` frames above the caller of newPrecondition.
+func newPrecondition(typ, subject, description string, skip int) *PreconditionViolation {
+	return &PreconditionViolation{Type: typ, Subject: subject, Description: description, Source: sourceOf(skip + 1)}
+}
+
+// Conflict returns the violation describing a conflict on the resource
+// `resource`.
+//
+// Example:
+//
+//	return faults.Aborted(faults.Conflict("invoice:123", "Version mismatch"))
+func Conflict(resource, description string) *ConflictViolation {
+	return &ConflictViolation{Resource: resource, Description: description}
+}
+
+// Quota returns the violation of a quota of the subject `subject`.
+//
+// Example:
+//
+//	return faults.ResourceExhausted(faults.Quota("project:42", "Daily Limit for read operations exceeded"))
+func Quota(subject, description string) *QuotaViolation {
+	return &QuotaViolation{Subject: subject, Description: description}
+}
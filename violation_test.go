@@ -0,0 +1,94 @@
+package faults_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/deixis/faults"
+)
+
+// TestViolations ensures violation constructors set the fields of their
+// violation.
+func TestViolations(t *testing.T) {
+	table := []struct {
+		Violation faults.Violation
+		Expect    faults.Violation
+	}{
+		{
+			Violation: faults.Field("email", "Must be a valid address"),
+			Expect:    &faults.FieldViolation{Field: "email", Description: "Must be a valid address"},
+		},
+		{
+			Violation: faults.Field("email", "Must be a valid address", faults.ViolationFormat),
+			Expect:    &faults.FieldViolation{Field: "email", Code: faults.ViolationFormat, Description: "Must be a valid address"},
+		},
+		{
+			Violation: faults.FieldTemplate("name", "must be at most {max} characters", map[string]string{"max": "50"}, faults.ViolationTooLong),
+			Expect: &faults.FieldViolation{
+				Field:       "name",
+				Code:        faults.ViolationTooLong,
+				Description: "must be at most 50 characters",
+				Template:    "must be at most {max} characters",
+				Params:      map[string]string{"max": "50"},
+			},
+		},
+		{
+			Violation: faults.Precondition("TOS", "example.com", "not accepted"),
+			Expect:    &faults.PreconditionViolation{Type: "TOS", Subject: "example.com", Description: "not accepted"},
+		},
+		{
+			Violation: faults.Conflict("invoice:123", "version mismatch"),
+			Expect:    &faults.ConflictViolation{Resource: "invoice:123", Description: "version mismatch"},
+		},
+		{
+			Violation: faults.Quota("project:x", "daily reads exceeded"),
+			Expect:    &faults.QuotaViolation{Subject: "project:x", Description: "daily reads exceeded"},
+		},
+	}
+
+	for i, test := range table {
+		if !reflect.DeepEqual(test.Violation, test.Expect) {
+			t.Errorf("%d - expect %#v, but got %#v", i, test.Expect, test.Violation)
+		}
+	}
+}
+
+// TestViolationSource ensures constructors record where violations were
+// produced when hops are recorded, and only then.
+func TestViolationSource(t *testing.T) {
+	defer faults.SetRecordHops(false)
+	faults.SetRecordHops(true)
+	var c faults.Collector
+	c.Check(false, "email", "Field required", faults.ViolationRequired)
+	collected, _ := faults.AsBad(c.Err())
+	built, _ := faults.AsFailedPrecondition(faults.Build(faults.CodeFailedPrecondition).
+		Precondition("TOS", "user:42", "Terms of service not accepted").
+		Err())
+	fields, _ := faults.AsBad(faults.Build(faults.CodeBad).Field("name", "Too long").Err())
+	table := []*faults.Frame{
+		faults.Field("email", "Field required").Source,
+		faults.FieldTemplate("name", "must be at most {max} characters", map[string]string{"max": "50"}).Source,
+		faults.Precondition("TOS", "user:42", "Terms of service not accepted").Source,
+		collected.Violations[0].Source,
+		built.Violations[0].Source,
+		fields.Violations[0].Source,
+	}
+	faults.SetRecordHops(false)
+
+	for i, source := range table {
+		if source == nil || !strings.HasSuffix(source.Function, "faults_test.TestViolationSource") || !strings.HasSuffix(source.File, "violation_test.go") {
+			t.Errorf("%d - expect source in TestViolationSource, but got %v", i, source)
+		}
+	}
+
+	if source := faults.Field("email", "Field required").Source; source != nil {
+		t.Errorf("expect no source, but got %s", source)
+	}
+	faults.SetRecordHops(true)
+	data, _ := faults.Encode(faults.Bad(faults.Field("email", "Field required")))
+	faults.SetRecordHops(false)
+	if e, ok := faults.AsBad(faults.Decode(data)); !ok || e.Violations[0].Source != nil {
+		t.Errorf("expect source not to be encoded, but got %v", e)
+	}
+}
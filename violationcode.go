@@ -0,0 +1,100 @@
+package faults
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// Well-known codes of field violations (see FieldViolation.Code).
+const (
+	// ViolationRequired indicates that a required field is missing or empty.
+	ViolationRequired = "REQUIRED"
+	// ViolationTooShort indicates that a value is shorter than allowed.
+	ViolationTooShort = "TOO_SHORT"
+	// ViolationTooLong indicates that a value is longer than allowed.
+	ViolationTooLong = "TOO_LONG"
+	// ViolationFormat indicates that a value doesn't have the expected
+	// format, e.g. an email address without "@".
+	ViolationFormat = "FORMAT"
+	// ViolationOutOfRange indicates that a value is outside of the allowed
+	// range.
+	ViolationOutOfRange = "OUT_OF_RANGE"
+	// ViolationNotAllowed indicates that a value is not one of the allowed
+	// values.
+	ViolationNotAllowed = "NOT_ALLOWED"
+	// ViolationDuplicate indicates that a value must be unique, but is
+	// already in use.
+	ViolationDuplicate = "DUPLICATE"
+	// ViolationUnknown indicates that a field is not expected.
+	ViolationUnknown = "UNKNOWN"
+)
+
+var (
+	// violationCodesMu serialises registrations, while lookups read the
+	// current snapshot without locking
+	violationCodesMu sync.Mutex
+	violationCodes   atomic.Pointer[map[string]string]
+)
+
+func init() {
+	violationCodes.Store(&map[string]string{
+		ViolationRequired:   "The field is required",
+		ViolationTooShort:   "The value is too short",
+		ViolationTooLong:    "The value is too long",
+		ViolationFormat:     "The value has an invalid format",
+		ViolationOutOfRange: "The value is out of range",
+		ViolationNotAllowed: "The value is not allowed",
+		ViolationDuplicate:  "The value is already in use",
+		ViolationUnknown:    "The field is unknown",
+	})
+}
+
+// RegisterViolationCode registers the well-known violation code `code`,
+// with a default description, e.g. for clients that don't have a localised
+// message for it. Codes are written in upper snake case, like category names.
+//
+// It panics when the code is invalid or already registered, and is meant to
+// be called during initialisation:
+//
+//	func init() {
+//		faults.RegisterViolationCode("WEAK_PASSWORD", "The password is too weak")
+//	}
+func RegisterViolationCode(code, description string) {
+	if !validName(code) {
+		panic(fmt.Sprintf("faults: invalid violation code %q", code))
+	}
+
+	violationCodesMu.Lock()
+	defer violationCodesMu.Unlock()
+
+	cur := *violationCodes.Load()
+	if _, ok := cur[code]; ok {
+		panic(fmt.Sprintf("faults: violation code %s already registered", code))
+	}
+	next := make(map[string]string, len(cur)+1)
+	for k, v := range cur {
+		next[k] = v
+	}
+	next[code] = description
+	violationCodes.Store(&next)
+}
+
+// DescribeViolationCode returns the default description of the well-known
+// violation code `code`, or false when it is not registered.
+func DescribeViolationCode(code string) (string, bool) {
+	description, ok := (*violationCodes.Load())[code]
+	return description, ok
+}
+
+// ViolationCodes returns the well-known violation codes, sorted.
+func ViolationCodes() []string {
+	cur := *violationCodes.Load()
+	codes := make([]string, 0, len(cur))
+	for code := range cur {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
@@ -0,0 +1,42 @@
+package faults_test
+
+import (
+	"testing"
+
+	"github.com/deixis/faults"
+)
+
+// TestViolationCodes ensures violation codes can be registered and described.
+func TestViolationCodes(t *testing.T) {
+	if _, ok := faults.DescribeViolationCode(faults.ViolationRequired); !ok {
+		t.Errorf("expect %s to be well-known", faults.ViolationRequired)
+	}
+	if _, ok := faults.DescribeViolationCode("WEAK_PASSWORD"); ok {
+		t.Errorf("expect WEAK_PASSWORD not to be registered yet")
+	}
+
+	faults.RegisterViolationCode("WEAK_PASSWORD", "The password is too weak")
+	if description, ok := faults.DescribeViolationCode("WEAK_PASSWORD"); !ok || description != "The password is too weak" {
+		t.Errorf("expect WEAK_PASSWORD description, but got %q", description)
+	}
+
+	codes := faults.ViolationCodes()
+	for i := 1; i < len(codes); i++ {
+		if codes[i-1] >= codes[i] {
+			t.Errorf("expect codes to be sorted, but got %v", codes)
+			break
+		}
+	}
+
+	table := []string{"", "weak", "WEAK_", faults.ViolationRequired, "WEAK_PASSWORD"}
+	for i, code := range table {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%d - expect registration of %q to panic", i, code)
+				}
+			}()
+			faults.RegisterViolationCode(code, "")
+		}()
+	}
+}
@@ -0,0 +1,58 @@
+package faults
+
+// Walk calls `fn` for every error found in the tree of `err`, starting with
+// `err` itself, until `fn` returns false.
+//
+// The tree is traversed depth-first, following both `Unwrap() error` and
+// `Unwrap() []error`, so errors are visited from the outermost to the
+// innermost one, and the branches of `errors.Join` in the order they were
+// joined. Nil errors are not visited.
+//
+// Example:
+//
+//	// Collect the field violations found anywhere in the tree
+//	var violations []*faults.FieldViolation
+//	faults.Walk(err, func(err error) bool {
+//		if e, ok := err.(*faults.BadRequest); ok {
+//			violations = append(violations, e.Violations...)
+//		}
+//		return true
+//	})
+func Walk(err error, fn func(err error) bool) {
+	walk(err, fn)
+}
+
+// walk is like Walk, but reports whether the traversal ran to completion.
+func walk(err error, fn func(err error) bool) bool {
+	for err != nil {
+		if !fn(err) {
+			return false
+		}
+
+		switch x := err.(type) {
+		case interface{ Unwrap() []error }:
+			for _, err := range x.Unwrap() {
+				if !walk(err, fn) {
+					return false
+				}
+			}
+			return true
+		case interface{ Unwrap() error }:
+			err = x.Unwrap()
+		default:
+			return true
+		}
+	}
+	return true
+}
+
+// Chain returns every error found in the tree of `err`, in the order they are
+// visited by Walk. It returns nil when `err` is nil.
+func Chain(err error) []error {
+	var chain []error
+	walk(err, func(err error) bool {
+		chain = append(chain, err)
+		return true
+	})
+	return chain
+}
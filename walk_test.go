@@ -0,0 +1,59 @@
+package faults_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/deixis/faults"
+)
+
+// TestChain ensures every error of a tree is visited, including the branches
+// of joined errors.
+func TestChain(t *testing.T) {
+	root := errors.New("root")
+	wrapped := fmt.Errorf("wrapped: %w", root)
+	notFound := faults.WithNotFound(wrapped)
+	bad := faults.Bad()
+	joined := errors.Join(notFound, nil, bad)
+	outer := faults.WithUnavailable(joined, 0)
+
+	table := []struct {
+		Error error
+		Chain []error
+	}{
+		{Error: nil, Chain: nil},
+		{Error: root, Chain: []error{root}},
+		{Error: notFound, Chain: []error{notFound, wrapped, root}},
+		{Error: outer, Chain: []error{outer, joined, notFound, wrapped, root, bad}},
+	}
+
+	for i, test := range table {
+		if chain := faults.Chain(test.Error); !reflect.DeepEqual(test.Chain, chain) {
+			t.Errorf("%d - expect chain %v, but got %v", i, test.Chain, chain)
+		}
+	}
+}
+
+// TestWalk ensures the traversal stops as soon as the callback returns false.
+func TestWalk(t *testing.T) {
+	err := errors.Join(
+		faults.WithAborted(faults.NotFound),
+		faults.Bad(),
+	)
+
+	var codes []faults.Code
+	faults.Walk(err, func(err error) bool {
+		if f, ok := err.(faults.Fault); ok {
+			codes = append(codes, f.Code())
+			return f.Code() != faults.CodeNotFound
+		}
+		return true
+	})
+
+	expect := []faults.Code{faults.CodeAborted, faults.CodeNotFound}
+	if !reflect.DeepEqual(expect, codes) {
+		t.Errorf("expect codes %v, but got %v", expect, codes)
+	}
+}